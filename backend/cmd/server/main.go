@@ -12,6 +12,7 @@ import (
 	"claraverse/internal/middleware"
 	"claraverse/internal/models"
 	"claraverse/internal/preflight"
+	"claraverse/internal/security"
 	"claraverse/internal/services"
 	"claraverse/internal/tools"
 	"claraverse/pkg/auth"
@@ -36,6 +37,10 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// version is reported by GET /capabilities; release builds set it with
+// -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Println("🚀 Starting ClaraVerse Server...")
@@ -157,6 +162,10 @@ func main() {
 
 	// Initialize services
 	providerService := services.NewProviderService(db)
+	modelCatalog := services.NewModelCatalog(db, cfg.ModelCatalogTTL)
+	services.SetModelCatalog(modelCatalog)
+	providerService.OnProvidersChanged(func([]models.Provider) { modelCatalog.Invalidate(0) })
+	providerService.OnProvidersChanged(loadImageProviders)
 	modelService := services.NewModelService(db)
 	connManager := services.NewConnectionManager()
 
@@ -166,6 +175,18 @@ func main() {
 
 	// Initialize MCP bridge service
 	mcpBridge := services.NewMCPBridgeService(db, tools.GetRegistry())
+	mcpBridge.SetMaxResultBytes(cfg.MCPMaxToolResultBytes)
+	mcpBridge.SetMaxArgBytes(cfg.MCPMaxToolArgBytes)
+	mcpBridge.SetDefaultToolTimeout(cfg.MCPToolTimeout)
+	mcpBridge.SetMaxToolTimeout(cfg.MCPMaxToolTimeout)
+	mcpBridge.SetSendTimeout(cfg.MCPToolSendTimeout)
+	mcpBridge.SetShowToolExamples(cfg.MCPShowToolExamples)
+	if err := mcpBridge.SetOutputValidation(cfg.MCPToolOutputValidation); err != nil {
+		log.Printf("⚠️  Invalid MCP_TOOL_OUTPUT_VALIDATION, using warn: %v", err)
+	}
+	mcpBridge.SetMaxToolsPerClient(cfg.MCPMaxToolsPerClient)
+	mcpBridge.SetReconnectGrace(cfg.MCPReconnectGrace)
+	mcpBridge.StartHeartbeatWatchdog(context.Background(), 30*time.Second, services.MCPHeartbeatTimeout)
 	log.Println("✅ MCP bridge service initialized")
 
 	chatService := services.NewChatService(db, providerService, mcpBridge, nil) // toolService set later after credential service init
@@ -193,12 +214,14 @@ func main() {
 	var tierService *services.TierService
 	if mongoDB != nil {
 		tierService = services.NewTierService(mongoDB)
+		mcpBridge.SetTierService(tierService)
 		log.Println("✅ Tier service initialized")
 	}
 
 	// Initialize execution limiter (requires TierService + Redis)
 	if tierService != nil && redisService != nil {
 		executionLimiter = middleware.NewExecutionLimiter(tierService, redisService.Client())
+		executionLimiter.SetFailClosed(cfg.ExecutionLimitFailClosed)
 		log.Println("✅ Execution limiter initialized")
 	} else {
 		log.Println("⚠️ Execution limiter disabled (requires TierService and Redis)")
@@ -269,6 +292,7 @@ func main() {
 
 	// Set tool service on chat service (was initialized with nil earlier)
 	chatService.SetToolService(toolService)
+	toolService.SetMCPBridgeService(mcpBridge)
 
 	// Initialize and set tool predictor service for dynamic tool selection
 	toolPredictorService := services.NewToolPredictorService(db, providerService, chatService)
@@ -287,12 +311,17 @@ func main() {
 
 		// Initialize model pool for dynamic memory model selection
 		var err error
-		memoryModelPool, err = services.NewMemoryModelPool(chatService, db.DB)
+		memoryModelPool, err = services.NewMemoryModelPool(chatService)
 		if err != nil {
 			log.Printf("⚠️ Failed to initialize memory model pool: %v", err)
 			log.Println("⚠️ Memory extraction/selection services disabled (requires valid memory models)")
 		} else {
 			log.Println("✅ Memory model pool initialized")
+			providerService.OnProvidersChanged(func([]models.Provider) {
+				if err := memoryModelPool.Refresh(); err != nil {
+					log.Printf("⚠️ Failed to refresh memory model pool: %v", err)
+				}
+			})
 
 			memoryExtractionService = services.NewMemoryExtractionService(
 				mongoDB,
@@ -352,12 +381,37 @@ func main() {
 	// Initialize workflow execution engine with block checker support
 	executorRegistry := execution.NewExecutorRegistry(chatService, providerService, tools.GetRegistry(), credentialService)
 	workflowEngine := execution.NewWorkflowEngineWithChecker(executorRegistry, providerService)
+	workflowEngine.SetCheckerModelPool(execution.NewCheckerModelPool(cfg.BlockCheckerModels))
+	if executionService != nil {
+		workflowEngine.SetExecutionService(executionService)
+	}
+	if credentialService != nil {
+		workflowEngine.SetCredentialService(credentialService)
+	}
+	// Blocks marked cacheable reuse outputs for identical inputs (requires Redis)
+	if redisService != nil {
+		workflowEngine.SetBlockCache(execution.NewRedisBlockCache(redisService))
+	}
 	log.Println("✅ Workflow execution engine initialized (with block checker)")
 
+	// Registry of running executions, shared by every entry point so they can be cancelled by ID
+	activeExecutions := services.NewActiveExecutionRegistry()
+	activeExecutions.SetLimits(services.ExecutionLimits{
+		MaxConcurrent:        cfg.MaxConcurrentExecutions,
+		MaxConcurrentPerUser: cfg.MaxConcurrentExecutionsPerUser,
+		QueueTimeout:         cfg.ExecutionQueueTimeout,
+	})
+	if executionService != nil {
+		executionService.SetActiveExecutions(activeExecutions)
+	}
+
+	// Completion webhooks are shared by the WebSocket, trigger and scheduler execution paths
+	completionWebhooks := services.NewCompletionWebhookService()
+
 	// Set workflow executor on scheduler and start it
 	if schedulerService != nil {
 		// Create a workflow executor callback that wraps the workflow engine
-		workflowExecutor := func(workflow *models.Workflow, inputs map[string]interface{}) (*models.WorkflowExecuteResult, error) {
+		workflowExecutor := func(ctx context.Context, workflow *models.Workflow, inputs map[string]interface{}) (*models.WorkflowExecuteResult, error) {
 			// Create a dummy status channel (scheduled jobs don't need real-time updates)
 			statusChan := make(chan models.ExecutionUpdate, 100)
 			go func() {
@@ -366,7 +420,7 @@ func main() {
 				}
 			}()
 
-			result, err := workflowEngine.Execute(context.Background(), workflow, inputs, statusChan)
+			result, err := workflowEngine.Execute(ctx, workflow, inputs, statusChan)
 			close(statusChan)
 
 			if err != nil {
@@ -380,10 +434,13 @@ func main() {
 				Output:      result.Output,
 				BlockStates: result.BlockStates,
 				Error:       result.Error,
+				APIResponse: workflowEngine.BuildAPIResponse(result, workflow, "", 0),
 			}, nil
 		}
 
 		schedulerService.SetWorkflowExecutor(workflowExecutor)
+		schedulerService.SetActiveExecutions(activeExecutions)
+		schedulerService.SetCompletionWebhookService(completionWebhooks)
 		if err := schedulerService.Start(context.Background()); err != nil {
 			log.Printf("⚠️ Failed to start scheduler: %v", err)
 		} else {
@@ -429,6 +486,29 @@ func main() {
 		log.Printf("✅ Local JWT authentication initialized (access: %v, refresh: %v)", accessTokenExpiry, refreshTokenExpiry)
 	}
 
+	// Opt-in: Supabase access tokens of users linked to a Supabase account are also
+	// accepted on WebSocket upgrades (verified locally)
+	var supabaseWSAuth *middleware.SupabaseWebSocketAuth
+	if cfg.SupabaseWebSocketAuth {
+		if cfg.SupabaseURL == "" || cfg.SupabaseKey == "" || userService == nil {
+			log.Println("⚠️  SUPABASE_WEBSOCKET_AUTH needs SUPABASE_URL, SUPABASE_KEY and MongoDB, ignoring it")
+		} else {
+			supabaseAuth := auth.NewSupabaseAuth(cfg.SupabaseURL, cfg.SupabaseKey, 0, 0)
+			supabaseAuth.JWTSecret = cfg.SupabaseJWTSecret
+			supabaseWSAuth = &middleware.SupabaseWebSocketAuth{
+				Auth: supabaseAuth,
+				LookupUser: func(ctx context.Context, supabaseUserID string) (*auth.User, error) {
+					user, err := userService.GetUserBySupabaseID(ctx, supabaseUserID)
+					if err != nil {
+						return nil, err
+					}
+					return &auth.User{ID: user.ID.Hex(), Email: user.Email, Role: user.Role}, nil
+				},
+			}
+			log.Println("✅ Supabase token verification enabled for WebSocket connections")
+		}
+	}
+
 	// Try loading configuration from database first
 	_, err = loadConfigFromDatabase(modelService, chatService, providerService)
 	if err != nil {
@@ -439,7 +519,8 @@ func main() {
 
 	// Initialize vision service (for describe_image tool)
 	// Must be after provider sync so model aliases are available
-	services.SetVisionDependencies(providerService, db)
+	services.SetVisionDependencies(providerService)
+	services.SetVisionPromptTemplates(cfg.VisionPromptTemplates)
 	services.InitVisionService()
 
 	// Initialize audio service (for transcribe_audio tool)
@@ -558,6 +639,10 @@ func main() {
 		chatService.SetUsageLimiter(usageLimiter)
 	}
 	mcpWSHandler := handlers.NewMCPWebSocketHandler(mcpBridge)
+	mcpWSHandler.SetWriteTimeout(cfg.MCPWriteTimeout)
+	mcpWSHandler.SetMaxConnections(cfg.MCPMaxConnections)
+	mcpWSHandler.SetMaxMessageBytes(cfg.MCPMaxMessageBytes)
+	mcpWSHandler.SetConnectTokens(security.NewConnectTokenStore(cfg.MCPConnectTokenTTL))
 	configHandler := handlers.NewConfigHandler()
 	// Initialize agent handler (requires agentService)
 	var agentHandler *handlers.AgentHandler
@@ -572,17 +657,22 @@ func main() {
 		agentHandler.SetWorkflowGeneratorV2Service(workflowGeneratorV2Service)
 		// Wire up provider service for Ask mode
 		agentHandler.SetProviderService(providerService)
+		// Wire up tool service for the tool preview endpoint
+		agentHandler.SetToolService(toolService)
 		workflowWSHandler = handlers.NewWorkflowWebSocketHandler(agentService, workflowEngine, executionLimiter)
 		// Wire up execution service for workflow execution tracking
 		if executionService != nil {
 			workflowWSHandler.SetExecutionService(executionService)
 		}
+		workflowWSHandler.SetActiveExecutions(activeExecutions)
+		workflowWSHandler.SetCompletionWebhookService(completionWebhooks)
 		log.Println("✅ Agent handler initialized")
 	}
 	toolsHandler := handlers.NewToolsHandler(tools.GetRegistry(), toolService)
 	imageProxyHandler := handlers.NewImageProxyHandler()
 	audioHandler := handlers.NewAudioHandler()
 	log.Println("✅ Audio handler initialized")
+	visionHandler := handlers.NewVisionHandler()
 
 	// Initialize schedule handler (requires scheduler service)
 	var scheduleHandler *handlers.ScheduleHandler
@@ -595,6 +685,7 @@ func main() {
 	var executionHandler *handlers.ExecutionHandler
 	if executionService != nil {
 		executionHandler = handlers.NewExecutionHandler(executionService)
+		executionHandler.SetActiveExecutions(activeExecutions)
 		log.Println("✅ Execution handler initialized")
 	}
 
@@ -609,6 +700,9 @@ func main() {
 	var triggerHandler *handlers.TriggerHandler
 	if executionService != nil {
 		triggerHandler = handlers.NewTriggerHandler(agentService, executionService, workflowEngine)
+		triggerHandler.SetActiveExecutions(activeExecutions)
+		triggerHandler.SetCompletionWebhookService(completionWebhooks)
+		triggerHandler.SetExecutionLimiter(executionLimiter)
 		log.Println("✅ Trigger handler initialized")
 	}
 
@@ -657,6 +751,27 @@ func main() {
 	// Health check (public)
 	app.Get("/health", healthHandler.Handle)
 
+	// Capabilities (public) - lets clients enable only the features this deployment supports
+	capabilitiesHandler := handlers.NewCapabilitiesHandler(version, map[string]bool{
+		"local_auth":          localAuthHandler != nil,
+		"agents":              agentHandler != nil,
+		"workflow_execution":  workflowWSHandler != nil,
+		"execution_history":   executionHandler != nil,
+		"scheduling":          scheduleHandler != nil,
+		"api_triggers":        triggerHandler != nil && apiKeyService != nil,
+		"api_keys":            apiKeyHandler != nil,
+		"external_upload":     apiKeyService != nil,
+		"completion_webhooks": workflowWSHandler != nil || triggerHandler != nil,
+		"memory":              memoryHandler != nil,
+		"chat_sync":           chatSyncHandler != nil,
+		"credentials":         credentialHandler != nil,
+		"composio":            composioAuthHandler != nil,
+		"user_preferences":    userPreferencesHandler != nil,
+		"mcp":                 true,
+		"mcp_connect_token":   true,
+	})
+	app.Get("/capabilities", capabilitiesHandler.Handle)
+
 	// Rate limiter for upload endpoint (10 uploads per minute per user)
 	uploadLimiter := limiter.New(limiter.Config{
 		Max:        10,
@@ -737,6 +852,9 @@ func main() {
 		// Audio transcription endpoint (requires authentication + rate limiting for expensive GPU operation)
 		api.Post("/audio/transcribe", middleware.OptionalLocalAuthMiddleware(jwtAuth), transcribeLimiter, audioHandler.Transcribe)
 
+		// Image analysis of uploaded images (requires auth)
+		api.Post("/vision/describe-batch", middleware.LocalAuthMiddleware(jwtAuth), visionHandler.DescribeBatch)
+
 		// Document download (requires authentication for access control)
 		api.Get("/download/:id", middleware.OptionalLocalAuthMiddleware(jwtAuth), downloadHandler.Download)
 
@@ -763,9 +881,11 @@ func main() {
 			memories := api.Group("/memories", middleware.LocalAuthMiddleware(jwtAuth))
 			memories.Get("/", memoryHandler.ListMemories)
 			memories.Get("/stats", memoryHandler.GetMemoryStats) // Must be before /:id to avoid route conflict
+			memories.Get("/search", memoryHandler.SearchMemories)
 			memories.Get("/:id", memoryHandler.GetMemory)
 			memories.Post("/", memoryHandler.CreateMemory)
 			memories.Put("/:id", memoryHandler.UpdateMemory)
+			memories.Delete("/", memoryHandler.DeleteAllMemories)
 			memories.Delete("/:id", memoryHandler.DeleteMemory)
 			memories.Post("/:id/archive", memoryHandler.ArchiveMemory)
 			memories.Post("/:id/unarchive", memoryHandler.UnarchiveMemory)
@@ -781,10 +901,15 @@ func main() {
 			agents.Get("/", agentHandler.List)
 			agents.Get("/recent", agentHandler.ListRecent) // Must be before /:id to avoid route conflict
 			agents.Post("/ask", agentHandler.Ask)          // Ask mode - must be before /:id to avoid route conflict
+			agents.Post("/import", agentHandler.Import)    // Import an exported agent definition
 			agents.Get("/:id", agentHandler.Get)
 			agents.Put("/:id", agentHandler.Update)
 			agents.Delete("/:id", agentHandler.Delete)
+			agents.Post("/:id/disable", agentHandler.Disable) // Kill switch: refuse all executions
+			agents.Post("/:id/enable", agentHandler.Enable)
+			agents.Post("/:id/completion-webhook/rotate-secret", agentHandler.RotateCompletionWebhookSecret)
 			agents.Post("/:id/sync", agentHandler.SyncAgent) // Sync local agent to backend
+			agents.Get("/:id/export", agentHandler.Export)   // Portable definition without secrets
 
 			// Workflow version routes - MUST be before /:id/workflow to avoid route conflict
 			agents.Get("/:id/workflow/versions", agentHandler.ListWorkflowVersions)
@@ -794,6 +919,7 @@ func main() {
 			// Workflow routes (less specific, must come after /versions routes)
 			agents.Put("/:id/workflow", agentHandler.SaveWorkflow)
 			agents.Get("/:id/workflow", agentHandler.GetWorkflow)
+			agents.Get("/:id/tools", agentHandler.PreviewTools) // Resolved tools and whether each is usable
 			agents.Post("/:id/generate-workflow", agentHandler.GenerateWorkflow)
 			agents.Post("/:id/generate-workflow-v2", agentHandler.GenerateWorkflowV2) // Multi-step with tool selection
 			agents.Post("/:id/select-tools", agentHandler.SelectTools)               // Tool selection only (step 1)
@@ -829,6 +955,13 @@ func main() {
 			executions := api.Group("/executions", middleware.LocalAuthMiddleware(jwtAuth))
 			executions.Get("/", executionHandler.ListAll)
 			executions.Get("/:id", executionHandler.GetByID)
+			executions.Get("/:id/blocks/:blockId", executionHandler.GetBlock)
+			executions.Get("/:id/files/:fileId", executionHandler.DownloadFile) // Stream a generated file (supports Range)
+			executions.Post("/cancel-all", executionHandler.CancelAll)
+			executions.Post("/:id/cancel", executionHandler.Cancel)
+			if triggerHandler != nil {
+				executions.Post("/:id/replay", triggerHandler.ReplayExecution)
+			}
 		}
 
 		// Schedule routes (top-level, authenticated) - for usage stats
@@ -846,6 +979,14 @@ func main() {
 			tools.Get("/registry", agentHandler.GetToolRegistry) // Tool registry for workflow builder
 		}
 
+		// MCP connection management routes (requires authentication)
+		// MCP clients call these with the token they connect with, so accept what /mcp/connect does
+		mcpRoutes := api.Group("/mcp", middleware.WebSocketClientAuthMiddleware(jwtAuth, supabaseWSAuth))
+		mcpRoutes.Get("/connections", mcpWSHandler.ListConnections)
+		mcpRoutes.Delete("/connections/:clientID", mcpWSHandler.RevokeConnection)
+		mcpRoutes.Get("/tools/latency", mcpWSHandler.GetToolLatencyStats)
+		mcpRoutes.Post("/connect-token", mcpWSHandler.IssueConnectToken) // Short-lived token for /mcp/connect
+
 		// API Key management routes (requires authentication)
 		if apiKeyHandler != nil {
 			keys := api.Group("/keys", middleware.LocalAuthMiddleware(jwtAuth))
@@ -914,6 +1055,10 @@ func main() {
 		// Admin routes (protected by admin middleware - superadmin only)
 		if userService != nil && tierService != nil {
 			adminHandler := handlers.NewAdminHandler(userService, tierService, analyticsService, providerService, modelService)
+			adminHandler.SetActiveExecutions(activeExecutions)
+			adminHandler.SetExecutionService(executionService)
+			adminHandler.SetAgentService(agentService)
+			adminHandler.SetMemoryModelPool(memoryModelPool)
 			adminRoutes := api.Group("/admin", middleware.LocalAuthMiddleware(jwtAuth), middleware.AdminMiddleware(cfg))
 
 			// Admin status
@@ -923,8 +1068,13 @@ func main() {
 			adminRoutes.Get("/users/:userID", adminHandler.GetUserDetails)
 			adminRoutes.Post("/users/:userID/overrides", adminHandler.SetLimitOverrides)
 			adminRoutes.Delete("/users/:userID/overrides", adminHandler.RemoveAllOverrides)
+			adminRoutes.Post("/users/:userID/executions/cancel", adminHandler.CancelUserExecutions)
 			adminRoutes.Get("/users", adminHandler.ListUsers)
 
+			// Agent kill switch (any user's agent)
+			adminRoutes.Post("/agents/:agentID/disable", adminHandler.DisableAgent)
+			adminRoutes.Post("/agents/:agentID/enable", adminHandler.EnableAgent)
+
 			// Analytics
 			adminRoutes.Get("/analytics/overview", adminHandler.GetOverviewAnalytics)
 			adminRoutes.Get("/analytics/providers", adminHandler.GetProviderAnalytics)
@@ -936,10 +1086,16 @@ func main() {
 			// Provider management (CRUD)
 			adminRoutes.Get("/providers", adminHandler.GetProviders)
 			adminRoutes.Post("/providers", adminHandler.CreateProvider)
+			adminRoutes.Post("/providers/reload", adminHandler.ReloadProviders) // Refresh cached provider config (e.g. rotated keys)
 			adminRoutes.Put("/providers/:id", adminHandler.UpdateProvider)
 			adminRoutes.Delete("/providers/:id", adminHandler.DeleteProvider)
 			adminRoutes.Put("/providers/:id/toggle", adminHandler.ToggleProvider)
 
+			// Memory model health
+			adminRoutes.Post("/memory/models/reset-health", adminHandler.ResetMemoryModelHealth) // Retry models tripped by failures
+			adminRoutes.Post("/memory/models/:modelId/disable", adminHandler.DisableMemoryModel)
+			adminRoutes.Post("/memory/models/:modelId/enable", adminHandler.EnableMemoryModel)
+
 			// Model management (CRUD, testing, benchmarking, aliases)
 			if modelService != nil && providerService != nil {
 				modelMgmtService := services.NewModelManagementService(db)
@@ -949,6 +1105,8 @@ func main() {
 				adminRoutes.Post("/models/import-aliases", modelMgmtHandler.ImportAliasesFromJSON)
 				adminRoutes.Put("/models/bulk/agents-enabled", modelMgmtHandler.BulkUpdateAgentsEnabled)
 				adminRoutes.Put("/models/bulk/visibility", modelMgmtHandler.BulkUpdateVisibility)
+				adminRoutes.Get("/models/catalog", modelMgmtHandler.GetModelCatalog)
+				adminRoutes.Post("/models/catalog/refresh", modelMgmtHandler.RefreshModelCatalog)
 
 				// Model CRUD (list and create don't conflict with specific paths)
 				adminRoutes.Get("/models", modelMgmtHandler.GetAllModels)
@@ -982,6 +1140,13 @@ func main() {
 				log.Println("✅ Model management routes registered (CRUD, testing, tiers, aliases)")
 			}
 
+			// MCP connections (list and revoke any user's clients)
+			adminRoutes.Get("/mcp/connections", mcpWSHandler.AdminListConnections)
+			adminRoutes.Delete("/mcp/connections/:clientID", mcpWSHandler.AdminRevokeConnection)
+
+			// Execution load (running and queued workflow executions)
+			adminRoutes.Get("/executions/active", adminHandler.GetActiveExecutions)
+
 			// Legacy stats endpoint
 			adminRoutes.Get("/stats", adminHandler.GetSystemStats)
 
@@ -1096,7 +1261,7 @@ func main() {
 	wsConnectionLimiter := middleware.WebSocketRateLimiter(rateLimitConfig)
 
 	app.Use("/ws/chat", wsConnectionLimiter)
-	app.Use("/ws/chat", middleware.OptionalLocalAuthMiddleware(jwtAuth))
+	app.Use("/ws/chat", middleware.OptionalWebSocketAuthMiddleware(jwtAuth, supabaseWSAuth))
 	app.Get("/ws/chat", websocket.New(wsHandler.Handle))
 
 	// MCP WebSocket endpoint (requires authentication)
@@ -1112,7 +1277,8 @@ func main() {
 	mcpConnectionLimiter := middleware.WebSocketRateLimiter(rateLimitConfig)
 
 	app.Use("/mcp/connect", mcpConnectionLimiter)
-	app.Use("/mcp/connect", middleware.OptionalLocalAuthMiddleware(jwtAuth))
+	app.Use("/mcp/connect", mcpWSHandler.LimitConnections)
+	app.Use("/mcp/connect", mcpWSHandler.ConnectTokenAuth(middleware.OptionalWebSocketAuthMiddleware(jwtAuth, supabaseWSAuth)))
 	app.Get("/mcp/connect", websocket.New(mcpWSHandler.HandleConnection))
 
 	// Workflow execution WebSocket endpoint (requires authentication + MongoDB)
@@ -1130,11 +1296,36 @@ func main() {
 		app.Get("/ws/workflow", websocket.New(workflowWSHandler.Handle))
 	}
 
+	// Reconcile executions orphaned by a previous crash (once at boot, optionally periodically)
+	var orphanReconciler *jobs.OrphanedExecutionReconciler
+	if executionService != nil {
+		orphanReconciler = jobs.NewOrphanedExecutionReconciler(
+			executionService,
+			cfg.OrphanedExecutionThreshold,
+			cfg.OrphanedExecutionInterval,
+			activeExecutions.ActiveIDs,
+		)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := orphanReconciler.Run(ctx); err != nil {
+				log.Printf("⚠️ Failed to reconcile orphaned executions: %v", err)
+			}
+		}()
+		// Keep this server's running executions from being reconciled by other replicas
+		go orphanReconciler.RunHeartbeat(context.Background(), cfg.OrphanedExecutionThreshold/3)
+	}
+
 	// Initialize background jobs
 	var jobScheduler *jobs.JobScheduler
 	if mongoDB != nil && tierService != nil && userService != nil {
 		jobScheduler = jobs.NewJobScheduler()
 
+		// Register orphaned execution reconciler (only if a periodic interval is configured)
+		if orphanReconciler != nil && cfg.OrphanedExecutionInterval > 0 {
+			jobScheduler.Register("orphaned_execution_reconcile", orphanReconciler)
+		}
+
 		// Register retention cleanup job (runs daily at 2 AM UTC)
 		retentionJob := jobs.NewRetentionCleanupJob(mongoDB, tierService)
 		jobScheduler.Register("retention_cleanup", retentionJob)
@@ -1178,6 +1369,11 @@ func main() {
 
 		log.Println("\n🛑 Shutting down server...")
 
+		// Drain in-flight workflow executions before tearing anything down
+		if workflowWSHandler != nil {
+			workflowWSHandler.Shutdown(cfg.ShutdownDrainTimeout)
+		}
+
 		// Stop background jobs
 		if jobScheduler != nil {
 			jobScheduler.Stop()
@@ -1322,43 +1518,40 @@ func syncProviders(filePath string, providerService *services.ProviderService, m
 		}
 	}
 
-	// After syncing providers to database, load image providers from database
-	log.Println("🎨 Loading image providers from database...")
-	allProviders, err := providerService.GetAll()
-	if err != nil {
+	// After syncing providers to database, refresh the services caching provider config
+	if _, err := providerService.Reload(); err != nil {
 		log.Printf("⚠️  Failed to load providers from database: %v", err)
-	} else {
-		// Convert database Provider models to ProviderConfig
-		var providerConfigs []models.ProviderConfig
-		for _, p := range allProviders {
-			providerConfigs = append(providerConfigs, models.ProviderConfig{
-				Name:          p.Name,
-				BaseURL:       p.BaseURL,
-				APIKey:        p.APIKey,
-				Enabled:       p.Enabled,
-				Secure:        p.Secure,
-				AudioOnly:     p.AudioOnly,
-				ImageOnly:     p.ImageOnly,
-				ImageEditOnly: p.ImageEditOnly,
-				DefaultModel:  p.DefaultModel,
-				SystemPrompt:  p.SystemPrompt,
-				Favicon:       p.Favicon,
-			})
-		}
-
-		// Load image providers into the image provider service
-		imageProviderService := services.GetImageProviderService()
-		imageProviderService.LoadFromProviders(providerConfigs)
-
-		// Load image edit providers into the image edit provider service
-		imageEditProviderService := services.GetImageEditProviderService()
-		imageEditProviderService.LoadFromProviders(providerConfigs)
 	}
 
 	log.Println("✅ Provider sync completed")
 	return nil
 }
 
+// loadImageProviders loads the image and image edit providers from all providers.
+// It is subscribed to provider reloads so rotated keys take effect immediately.
+func loadImageProviders(providers []models.Provider) {
+	var providerConfigs []models.ProviderConfig
+	for _, p := range providers {
+		providerConfigs = append(providerConfigs, models.ProviderConfig{
+			Name:          p.Name,
+			BaseURL:       p.BaseURL,
+			APIKey:        p.APIKey,
+			Enabled:       p.Enabled,
+			Secure:        p.Secure,
+			AudioOnly:     p.AudioOnly,
+			ImageOnly:     p.ImageOnly,
+			ImageEditOnly: p.ImageEditOnly,
+			DefaultModel:  p.DefaultModel,
+			SystemPrompt:  p.SystemPrompt,
+			Favicon:       p.Favicon,
+			Flavor:        p.Flavor,
+		})
+	}
+
+	services.GetImageProviderService().LoadFromProviders(providerConfigs)
+	services.GetImageEditProviderService().LoadFromProviders(providerConfigs)
+}
+
 // loadConfigFromDatabase loads model aliases and recommended models from database
 // Returns true if data was successfully loaded, false if database is empty (first run)
 func loadConfigFromDatabase(modelService *services.ModelService, chatService *services.ChatService, providerService *services.ProviderService) (bool, error) {
@@ -1406,37 +1599,9 @@ func loadConfigFromDatabase(modelService *services.ModelService, chatService *se
 		log.Printf("   ✅ Loaded recommended models for provider %d", providerID)
 	}
 
-	// Load image providers from database
-	log.Println("🎨 Loading image providers from database...")
-	allProviders, err := providerService.GetAll()
-	if err != nil {
+	// Load image providers (and other services caching provider config) from database
+	if _, err := providerService.Reload(); err != nil {
 		log.Printf("⚠️  Failed to load providers from database: %v", err)
-	} else {
-		// Convert database Provider models to ProviderConfig
-		var providerConfigs []models.ProviderConfig
-		for _, p := range allProviders {
-			providerConfigs = append(providerConfigs, models.ProviderConfig{
-				Name:          p.Name,
-				BaseURL:       p.BaseURL,
-				APIKey:        p.APIKey,
-				Enabled:       p.Enabled,
-				Secure:        p.Secure,
-				AudioOnly:     p.AudioOnly,
-				ImageOnly:     p.ImageOnly,
-				ImageEditOnly: p.ImageEditOnly,
-				DefaultModel:  p.DefaultModel,
-				SystemPrompt:  p.SystemPrompt,
-				Favicon:       p.Favicon,
-			})
-		}
-
-		// Load image providers into the image provider service
-		imageProviderService := services.GetImageProviderService()
-		imageProviderService.LoadFromProviders(providerConfigs)
-
-		// Load image edit providers into the image edit provider service
-		imageEditProviderService := services.GetImageEditProviderService()
-		imageEditProviderService.LoadFromProviders(providerConfigs)
 	}
 
 	log.Printf("✅ Loaded configuration from database: %d provider aliases, %d recommended model sets",
@@ -2,6 +2,7 @@ package audio
 
 import (
 	"bytes"
+	"claraverse/internal/providerhttp"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -49,9 +50,7 @@ func GetService() *Service {
 func InitService(groqProviderGetter, openaiProviderGetter ProviderGetter) *Service {
 	once.Do(func() {
 		instance = &Service{
-			httpClient: &http.Client{
-				Timeout: 120 * time.Second, // Whisper can take a while for long audio
-			},
+			httpClient:           providerhttp.NewClient(120 * time.Second), // Whisper can take a while for long audio
 			groqProviderGetter:   groqProviderGetter,
 			openaiProviderGetter: openaiProviderGetter,
 		}
@@ -20,6 +20,13 @@ type Config struct {
 	SearXNGURL   string
 	RedisURL     string
 
+	// SupabaseWebSocketAuth opts in to accepting Supabase access tokens on WebSocket
+	// upgrades, for users whose account is linked to their Supabase user ID
+	SupabaseWebSocketAuth bool
+	// SupabaseJWTSecret is the project's legacy HS256 JWT secret (optional), letting
+	// WebSocket upgrades verify HS256 Supabase tokens without calling Supabase
+	SupabaseJWTSecret string
+
 	// DodoPayments configuration
 	DodoAPIKey        string
 	DodoWebhookSecret string
@@ -34,6 +41,69 @@ type Config struct {
 
 	// Superadmin configuration
 	SuperadminUserIDs []string // List of Supabase user IDs with superadmin access
+
+	// ShutdownDrainTimeout is how long running workflow executions may keep
+	// running after SIGTERM before they are marked interrupted
+	ShutdownDrainTimeout time.Duration
+
+	// Orphaned execution reconciliation: pending/running executions whose server sent
+	// no heartbeat within the threshold are marked interrupted at boot, and every
+	// interval if it is > 0
+	OrphanedExecutionThreshold time.Duration
+	OrphanedExecutionInterval  time.Duration
+
+	// Vision prompt templates keyed by detail level ("detailed", "brief")
+	// JSON object, may contain a {question} placeholder
+	VisionPromptTemplates map[string]string
+
+	// Block checker models (comma-separated), used as a failover pool
+	BlockCheckerModels []string
+
+	// Concurrent workflow execution caps (0 = unlimited). Requests over the cap
+	// wait up to ExecutionQueueTimeout for a slot before being rejected as busy.
+	MaxConcurrentExecutions        int
+	MaxConcurrentExecutionsPerUser int
+	ExecutionQueueTimeout          time.Duration
+
+	// MCPWriteTimeout bounds each WebSocket write to an MCP client; a stalled
+	// client fails the write and is disconnected
+	MCPWriteTimeout time.Duration
+	// MCPMaxToolResultBytes is the ceiling on MCP tool result size and
+	// MCPMaxToolArgBytes on tool call argument size (0 = unlimited)
+	MCPMaxToolResultBytes int
+	MCPMaxToolArgBytes    int
+	// MCPToolTimeout is the timeout for MCP tool calls that declare none, and
+	// MCPMaxToolTimeout the ceiling every call's timeout is clamped to
+	MCPToolTimeout    time.Duration
+	MCPMaxToolTimeout time.Duration
+	// MCPToolSendTimeout bounds queueing a tool call for a busy MCP client
+	MCPToolSendTimeout time.Duration
+	// MCPShowToolExamples appends the usage examples clients register to tool descriptions
+	MCPShowToolExamples bool
+	// MCPToolOutputValidation checks results against the output schema tools declare:
+	// "off", "warn" (annotate mismatches) or "retry" (re-run read-only tools first)
+	MCPToolOutputValidation string
+	// MCPMaxConnections caps concurrent MCP WebSocket connections (0 = unlimited);
+	// upgrades over the cap are rejected with 503
+	MCPMaxConnections int
+	// MCPMaxMessageBytes caps a single message from an MCP client (0 = unlimited), and
+	// MCPMaxToolsPerClient the tools one client may register regardless of tier
+	MCPMaxMessageBytes   int
+	MCPMaxToolsPerClient int
+	// MCPReconnectGrace is how long a client reaped for missed heartbeats keeps its
+	// tools and pending calls while it reconnects (0 = unregister immediately)
+	MCPReconnectGrace time.Duration
+	// MCPConnectTokenTTL is how long a connection token from POST /api/mcp/connect-token
+	// can be exchanged for an MCP WebSocket connection
+	MCPConnectTokenTTL time.Duration
+
+	// ModelCatalogTTL is how long the shared model catalog serves a provider's
+	// models before reading them again
+	ModelCatalogTTL time.Duration
+
+	// ExecutionLimitFailClosed rejects executions while Redis is unavailable instead
+	// of enforcing daily execution limits per instance in memory
+	ExecutionLimitFailClosed bool
 }
 
 // Load loads configuration from environment variables with defaults
@@ -57,6 +127,9 @@ func Load() *Config {
 		SearXNGURL:  getEnv("SEARXNG_URL", "http://localhost:8080"),
 		RedisURL:    getEnv("REDIS_URL", "redis://localhost:6379"),
 
+		SupabaseWebSocketAuth: getBoolEnv("SUPABASE_WEBSOCKET_AUTH", false),
+		SupabaseJWTSecret:     getEnv("SUPABASE_JWT_SECRET", ""),
+
 		// DodoPayments configuration
 		DodoAPIKey:        getEnv("DODO_API_KEY", ""),
 		DodoWebhookSecret: getEnv("DODO_WEBHOOK_SECRET", ""),
@@ -71,6 +144,42 @@ func Load() *Config {
 
 		// Superadmin configuration
 		SuperadminUserIDs: superadminUserIDs,
+
+		// Graceful shutdown
+		ShutdownDrainTimeout: time.Duration(getIntEnv("SHUTDOWN_DRAIN_TIMEOUT_SECONDS", 25)) * time.Second,
+
+		// Orphaned execution reconciliation
+		OrphanedExecutionThreshold: time.Duration(getIntEnv("ORPHANED_EXECUTION_THRESHOLD_MINUTES", 30)) * time.Minute,
+		OrphanedExecutionInterval:  time.Duration(getIntEnv("ORPHANED_EXECUTION_RECONCILE_INTERVAL_MINUTES", 0)) * time.Minute,
+
+		// Vision prompt templates
+		VisionPromptTemplates: getJSONMapEnv("VISION_PROMPT_TEMPLATES"),
+
+		// Block checker model pool
+		BlockCheckerModels: getListEnv("BLOCK_CHECKER_MODELS", "gpt-4.1"),
+
+		// Concurrent execution caps
+		MaxConcurrentExecutions:        getIntEnv("MAX_CONCURRENT_EXECUTIONS", 0),
+		MaxConcurrentExecutionsPerUser: getIntEnv("MAX_CONCURRENT_EXECUTIONS_PER_USER", 0),
+		ExecutionQueueTimeout:          time.Duration(getIntEnv("EXECUTION_QUEUE_TIMEOUT_SECONDS", 30)) * time.Second,
+
+		MCPWriteTimeout:         time.Duration(getIntEnv("MCP_WRITE_TIMEOUT_SECONDS", 10)) * time.Second,
+		MCPMaxToolResultBytes:   getIntEnv("MCP_MAX_TOOL_RESULT_BYTES", 8<<20),
+		MCPMaxToolArgBytes:      getIntEnv("MCP_MAX_TOOL_ARG_BYTES", 4<<20),
+		MCPMaxConnections:       getIntEnv("MCP_MAX_CONNECTIONS", 1000),
+		MCPMaxMessageBytes:      getIntEnv("MCP_MAX_MESSAGE_BYTES", 16<<20),
+		MCPMaxToolsPerClient:    getIntEnv("MCP_MAX_TOOLS_PER_CLIENT", 1000),
+		MCPToolTimeout:          time.Duration(getIntEnv("MCP_TOOL_TIMEOUT_SECONDS", 30)) * time.Second,
+		MCPMaxToolTimeout:       time.Duration(getIntEnv("MCP_MAX_TOOL_TIMEOUT_SECONDS", 300)) * time.Second,
+		MCPToolSendTimeout:      time.Duration(getIntEnv("MCP_TOOL_SEND_TIMEOUT_MS", 5000)) * time.Millisecond,
+		MCPShowToolExamples:     getBoolEnv("MCP_SHOW_TOOL_EXAMPLES", true),
+		MCPToolOutputValidation: getEnv("MCP_TOOL_OUTPUT_VALIDATION", "warn"),
+		MCPReconnectGrace:       time.Duration(getIntEnv("MCP_RECONNECT_GRACE_SECONDS", 60)) * time.Second,
+		MCPConnectTokenTTL:      time.Duration(getIntEnv("MCP_CONNECT_TOKEN_TTL_SECONDS", 60)) * time.Second,
+
+		ModelCatalogTTL: time.Duration(getIntEnv("MODEL_CATALOG_TTL_SECONDS", 300)) * time.Second,
+
+		ExecutionLimitFailClosed: getBoolEnv("EXECUTION_LIMIT_FAIL_CLOSED", false),
 	}
 }
 
@@ -116,6 +225,29 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getListEnv(key, defaultValue string) []string {
+	var values []string
+	for _, v := range strings.Split(getEnv(key, defaultValue), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func getJSONMapEnv(key string) map[string]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	var parsed map[string]string
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		// If parsing fails, fall back to defaults
+		return nil
+	}
+	return parsed
+}
+
 func getTimeEnv(key string, defaultValue string) time.Time {
 	value := getEnv(key, defaultValue)
 	parsed, err := time.Parse(time.RFC3339, value)
@@ -172,6 +172,67 @@ func (db *DB) runMigrations() error {
 		}
 	}
 
+	// Migration: Add auth_style and custom_headers columns to providers table (if missing)
+	if exists, _ := tableExists("providers"); exists {
+		if colExists, _ := columnExists("providers", "auth_style"); !colExists {
+			log.Println("📦 Running migration: Adding auth_style to providers table")
+			if _, err := db.Exec("ALTER TABLE providers ADD COLUMN auth_style VARCHAR(32) COMMENT 'How the API key is sent: bearer or api-key'"); err != nil {
+				return fmt.Errorf("failed to add auth_style to providers: %w", err)
+			}
+			log.Println("✅ Migration completed: providers.auth_style added")
+		}
+		if colExists, _ := columnExists("providers", "custom_headers"); !colExists {
+			log.Println("📦 Running migration: Adding custom_headers to providers table")
+			if _, err := db.Exec("ALTER TABLE providers ADD COLUMN custom_headers TEXT COMMENT 'Extra request headers (JSON object)'"); err != nil {
+				return fmt.Errorf("failed to add custom_headers to providers: %w", err)
+			}
+			log.Println("✅ Migration completed: providers.custom_headers added")
+		}
+		if colExists, _ := columnExists("providers", "uses_max_completion_tokens"); !colExists {
+			log.Println("📦 Running migration: Adding uses_max_completion_tokens to providers table")
+			if _, err := db.Exec("ALTER TABLE providers ADD COLUMN uses_max_completion_tokens BOOLEAN NULL COMMENT 'Send max_completion_tokens instead of max_tokens (NULL = detect from base URL)'"); err != nil {
+				return fmt.Errorf("failed to add uses_max_completion_tokens to providers: %w", err)
+			}
+			log.Println("✅ Migration completed: providers.uses_max_completion_tokens added")
+		}
+		if colExists, _ := columnExists("providers", "flavor"); !colExists {
+			log.Println("📦 Running migration: Adding flavor to providers table")
+			if _, err := db.Exec("ALTER TABLE providers ADD COLUMN flavor VARCHAR(32) COMMENT 'API dialect: openai, azure-openai, anthropic, ollama, chutes, openai-compatible (NULL = detect from base URL)'"); err != nil {
+				return fmt.Errorf("failed to add flavor to providers: %w", err)
+			}
+			log.Println("✅ Migration completed: providers.flavor added")
+		}
+	}
+
+	// Migration: Add timing columns to mcp_audit_log table (if missing)
+	if exists, _ := tableExists("mcp_audit_log"); exists {
+		if colExists, _ := columnExists("mcp_audit_log", "execution_time_ms"); !colExists {
+			log.Println("📦 Running migration: Adding execution_time_ms to mcp_audit_log table")
+			if _, err := db.Exec("ALTER TABLE mcp_audit_log ADD COLUMN execution_time_ms INT COMMENT 'Round trip measured by the server'"); err != nil {
+				return fmt.Errorf("failed to add execution_time_ms to mcp_audit_log: %w", err)
+			}
+			log.Println("✅ Migration completed: mcp_audit_log.execution_time_ms added")
+		}
+		if colExists, _ := columnExists("mcp_audit_log", "tool_time_ms"); !colExists {
+			log.Println("📦 Running migration: Adding tool_time_ms to mcp_audit_log table")
+			if _, err := db.Exec("ALTER TABLE mcp_audit_log ADD COLUMN tool_time_ms INT COMMENT 'Tool run time reported by the client'"); err != nil {
+				return fmt.Errorf("failed to add tool_time_ms to mcp_audit_log: %w", err)
+			}
+			log.Println("✅ Migration completed: mcp_audit_log.tool_time_ms added")
+		}
+	}
+
+	// Migration: Add revoked_at column to mcp_connections table (if missing)
+	if exists, _ := tableExists("mcp_connections"); exists {
+		if colExists, _ := columnExists("mcp_connections", "revoked_at"); !colExists {
+			log.Println("📦 Running migration: Adding revoked_at to mcp_connections table")
+			if _, err := db.Exec("ALTER TABLE mcp_connections ADD COLUMN revoked_at TIMESTAMP NULL COMMENT 'When the client was revoked; it may not register again'"); err != nil {
+				return fmt.Errorf("failed to add revoked_at to mcp_connections: %w", err)
+			}
+			log.Println("✅ Migration completed: mcp_connections.revoked_at added")
+		}
+	}
+
 	// Migration: Add smart_tool_router column to models table (if missing)
 	if exists, _ := tableExists("models"); exists {
 		if colExists, _ := columnExists("models", "smart_tool_router"); !colExists {
@@ -222,6 +283,13 @@ func (db *DB) runMigrations() error {
 			}
 			log.Println("✅ Migration completed: model_aliases.smart_tool_router added")
 		}
+		if colExists, _ := columnExists("model_aliases", "memory_tags"); !colExists {
+			log.Println("📦 Running migration: Adding memory_tags to model_aliases table")
+			if _, err := db.Exec("ALTER TABLE model_aliases ADD COLUMN memory_tags VARCHAR(255) COMMENT 'Comma-separated content tags preferred for memory operations (e.g. code,prose)'"); err != nil {
+				return fmt.Errorf("failed to add memory_tags to model_aliases: %w", err)
+			}
+			log.Println("✅ Migration completed: model_aliases.memory_tags added")
+		}
 	}
 
 	log.Println("✅ All migrations completed")
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"claraverse/internal/filecache"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"claraverse/internal/services"
 	"claraverse/internal/tools"
 	"context"
@@ -190,6 +191,22 @@ func (e *AgentBlockExecutor) Execute(ctx context.Context, block models.Block, in
 	// Parse config with defaults
 	config := e.parseConfig(block.Config)
 
+	// Check the tools before calling the model: without them it tends to make up tool
+	// results, so fail the block or (if the agent allows) tell the model what is missing
+	userID, _ := inputs["__user_id__"].(string)
+	if unavailable := e.unavailableTools(ctx, userID, config.EnabledTools); len(unavailable) > 0 {
+		if mode, _ := inputs["_unavailableTools"].(string); mode != models.UnavailableToolsMark {
+			return nil, fmt.Errorf("block '%s' cannot run because tools are unavailable: %s",
+				block.Name, describeUnavailableTools(unavailable))
+		}
+		log.Printf("⚠️ [AGENT-BLOCK] Block '%s' running without unavailable tools: %s",
+			block.Name, describeUnavailableTools(unavailable))
+		config = withoutUnavailableTools(config, unavailable)
+	}
+
+	// Offer only the most relevant tools when the block caps them
+	config = e.limitTools(block, config, inputs)
+
 	// Create tool usage validator
 	validator := NewToolUsageValidator(config)
 
@@ -259,21 +276,36 @@ func (e *AgentBlockExecutor) Execute(ctx context.Context, block models.Block, in
 // executeOnce performs a single execution attempt of the LLM block
 func (e *AgentBlockExecutor) executeOnce(ctx context.Context, block models.Block, inputs map[string]any, config models.AgentBlockConfig) (map[string]any, error) {
 
-	// Check for workflow-level model override (set in Start block)
-	if workflowModelID, ok := inputs["_workflowModelId"].(string); ok && workflowModelID != "" {
-		log.Printf("🎯 [AGENT-BLOCK] Block '%s': Using workflow model override: %s", block.Name, workflowModelID)
-		config.Model = workflowModelID
+	var provider *models.Provider
+	var modelID string
+	var err error
+
+	if config.ModelOverride != "" {
+		// Per-block model pinned with model_id - beats the workflow model and never falls back
+		log.Printf("🎯 [AGENT-BLOCK] Block '%s': Using block model override: %s", block.Name, config.ModelOverride)
+		config.Model = config.ModelOverride
+		provider, modelID, err = e.resolveModelStrict(config.Model)
+		if err != nil {
+			return nil, fmt.Errorf("block '%s' is configured with model_id '%s', but that model is not available: %w",
+				block.Name, config.ModelOverride, err)
+		}
+	} else {
+		// Check for workflow-level model override (set in Start block)
+		if workflowModelID, ok := inputs["_workflowModelId"].(string); ok && workflowModelID != "" {
+			log.Printf("🎯 [AGENT-BLOCK] Block '%s': Using workflow model override: %s", block.Name, workflowModelID)
+			config.Model = workflowModelID
+		}
+
+		// Resolve model (alias -> direct -> fallback)
+		provider, modelID, err = e.resolveModel(config.Model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve model: %w", err)
+		}
 	}
 
 	log.Printf("🤖 [AGENT-BLOCK] Block '%s': model=%s, enabledTools=%v, maxToolCalls=%d",
 		block.Name, config.Model, config.EnabledTools, config.MaxToolCalls)
 
-	// Resolve model (alias -> direct -> fallback)
-	provider, modelID, err := e.resolveModel(config.Model)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve model: %w", err)
-	}
-
 	log.Printf("✅ [AGENT-BLOCK] Resolved model '%s' -> '%s' (provider: %s)",
 		config.Model, modelID, provider.Name)
 
@@ -340,7 +372,7 @@ func (e *AgentBlockExecutor) executeOnce(ctx context.Context, block models.Block
 		}
 
 		// Call LLM with retry for transient errors (timeout, rate limit, server errors)
-		response, retryAttempts, err := e.callLLMWithRetry(ctx, provider, modelID, messages, enabledTools, config.Temperature, config.RetryPolicy)
+		response, retryAttempts, err := e.callLLMWithRetry(ctx, provider, modelID, messages, enabledTools, config.Temperature, config.MaxTokens, config.RetryPolicy)
 		if err != nil {
 			// Include retry info in error for debugging
 			if len(retryAttempts) > 0 {
@@ -425,6 +457,9 @@ func (e *AgentBlockExecutor) executeOnce(ctx context.Context, block models.Block
 				formatOutput, err := e.FormatToSchema(ctx, formatInput, config.OutputSchema, modelID)
 				if err != nil {
 					log.Printf("⚠️ [AGENT-BLOCK] Phase 2 schema formatting error: %v", err)
+					if config.StrictOutput {
+						return nil, fmt.Errorf("block '%s' output does not match its schema: %w", block.Name, err)
+					}
 					// Continue with raw output if formatting fails
 					result["_formatError"] = err.Error()
 				} else if formatOutput != nil {
@@ -443,6 +478,9 @@ func (e *AgentBlockExecutor) executeOnce(ctx context.Context, block models.Block
 						totalTokens.Output += formatOutput.Tokens.Output
 					} else {
 						log.Printf("⚠️ [AGENT-BLOCK] Phase 2 schema formatting failed: %s", formatOutput.Error)
+						if config.StrictOutput {
+							return nil, fmt.Errorf("block '%s' output does not match its schema: %s", block.Name, formatOutput.Error)
+						}
 						result["_formatError"] = formatOutput.Error
 						// Fall back to basic parsing without validation
 						if parsedOutput, err := e.parseAndValidateOutput(response.Content, nil, false); err == nil {
@@ -532,6 +570,7 @@ func (e *AgentBlockExecutor) executeOnce(ctx context.Context, block models.Block
 
 		// Execute tools and add results to messages
 		log.Printf("🔧 [AGENT-BLOCK] Executing %d tool call(s) in iteration %d", len(response.ToolCalls), iterations)
+		ReportProgress(ctx, fmt.Sprintf("Step %d/%d: running %d tool call(s)", iterations, maxIterations, len(response.ToolCalls)), -1)
 
 		// Add assistant message with tool calls
 		assistantMsg := map[string]any{
@@ -558,7 +597,7 @@ func (e *AgentBlockExecutor) executeOnce(ctx context.Context, block models.Block
 
 			// Extract userID from inputs for credential resolution (uses __user_id__ convention)
 			userID, _ := inputs["__user_id__"].(string)
-			toolRecord := e.executeToolCall(toolCall, inputs, dataFiles, generatedCharts, userID, config.Credentials)
+			toolRecord := e.executeToolCall(ctx, toolCall, inputs, dataFiles, generatedCharts, userID, config.Credentials)
 			allToolCalls = append(allToolCalls, toolRecord)
 
 			// Extract any chart images from successful tool results for later injection
@@ -764,11 +803,24 @@ func (e *AgentBlockExecutor) parseConfig(config map[string]any) models.AgentBloc
 		result.Model = v
 	}
 
+	// Per-block model override (takes precedence over the workflow model)
+	if v, ok := config["model_id"].(string); ok && v != "" {
+		result.ModelOverride = v
+	}
+
 	// Temperature
 	if v, ok := config["temperature"].(float64); ok {
 		result.Temperature = v
 	}
 
+	// Max output tokens (0 = provider default limit)
+	if v, ok := config["max_tokens"].(float64); ok && v > 0 {
+		result.MaxTokens = int(v)
+	}
+	if v, ok := config["maxTokens"].(float64); ok && v > 0 {
+		result.MaxTokens = int(v)
+	}
+
 	// System prompt
 	if v, ok := config["systemPrompt"].(string); ok {
 		result.SystemPrompt = v
@@ -808,17 +860,35 @@ func (e *AgentBlockExecutor) parseConfig(config map[string]any) models.AgentBloc
 		result.MaxToolCalls = int(v)
 	}
 
+	// Tool cap and the tags that mark the tools to prefer when it applies
+	if v, ok := config["maxTools"].(float64); ok {
+		result.MaxTools = int(v)
+	} else if v, ok := config["max_tools"].(float64); ok {
+		result.MaxTools = int(v)
+	}
+	if toolTagsRaw, exists := config["toolTags"]; exists && toolTagsRaw != nil {
+		result.ToolTags = parseToolsList(toolTagsRaw)
+	} else if toolTagsRaw, exists := config["tool_tags"]; exists && toolTagsRaw != nil {
+		result.ToolTags = parseToolsList(toolTagsRaw)
+	}
+
 	// Credentials - array of credential IDs configured by user for tool authentication
 	if credentialsRaw, exists := config["credentials"]; exists && credentialsRaw != nil {
 		result.Credentials = parseToolsList(credentialsRaw) // Reuse the same parser ([]string)
 		log.Printf("🔐 [CONFIG] Parsed credentials from config: %v", result.Credentials)
 	}
 
-	// Output schema
+	// Output schema, given directly or as an OpenAI-style responseFormat
 	if v, ok := config["outputSchema"].(map[string]any); ok {
 		result.OutputSchema = e.parseJSONSchema(v)
 		log.Printf("📋 [CONFIG] Parsed outputSchema with %d required fields: %v", len(result.OutputSchema.Required), result.OutputSchema.Required)
-	} else {
+	} else if format, ok := config["responseFormat"].(map[string]any); ok {
+		if v, ok := responseFormatSchema(format); ok {
+			result.OutputSchema = e.parseJSONSchema(v)
+			log.Printf("📋 [CONFIG] Parsed responseFormat schema with %d required fields: %v", len(result.OutputSchema.Required), result.OutputSchema.Required)
+		}
+	}
+	if result.OutputSchema == nil {
 		log.Printf("📋 [CONFIG] No outputSchema found in config (outputSchema key: %v)", config["outputSchema"] != nil)
 	}
 
@@ -959,9 +1029,42 @@ func (e *AgentBlockExecutor) parseJSONSchema(schema map[string]any) *models.JSON
 		result.Description = v
 	}
 
+	switch v := schema["enum"].(type) {
+	case []interface{}:
+		for _, value := range v {
+			if str, ok := value.(string); ok {
+				result.Enum = append(result.Enum, str)
+			}
+		}
+	case []string:
+		result.Enum = v
+	case primitive.A:
+		for _, value := range v {
+			if str, ok := value.(string); ok {
+				result.Enum = append(result.Enum, str)
+			}
+		}
+	}
+
 	return result
 }
 
+// responseFormatSchema returns the JSON schema of an OpenAI-style response format,
+// {"type": "json_schema", "json_schema": {"schema": {...}}}, also accepting the
+// schema directly under "schema"
+func responseFormatSchema(format map[string]any) (map[string]any, bool) {
+	if formatType, _ := format["type"].(string); formatType != "json_schema" {
+		return nil, false
+	}
+	if jsonSchema, ok := format["json_schema"].(map[string]any); ok {
+		if schema, ok := jsonSchema["schema"].(map[string]any); ok {
+			return schema, true
+		}
+	}
+	schema, ok := format["schema"].(map[string]any)
+	return schema, ok
+}
+
 // jsonSchemaToMap converts a JSONSchema struct to a map for API requests
 // This is used for native structured output (response_format with json_schema)
 func (e *AgentBlockExecutor) jsonSchemaToMap(schema *models.JSONSchema) map[string]interface{} {
@@ -1034,6 +1137,32 @@ func (e *AgentBlockExecutor) resolveModel(modelID string) (*models.Provider, str
 	return defaultProvider, defaultModel, nil
 }
 
+// ValidateBlock checks that a model pinned with model_id is available, so a workflow
+// pinning an unavailable model fails before any of its blocks run
+func (e *AgentBlockExecutor) ValidateBlock(block models.Block) error {
+	modelID, _ := block.Config["model_id"].(string)
+	if modelID == "" {
+		return nil
+	}
+	if _, _, err := e.resolveModelStrict(modelID); err != nil {
+		return fmt.Errorf("block '%s' is configured with model_id '%s', but that model is not available: %w",
+			block.Name, modelID, err)
+	}
+	return nil
+}
+
+// resolveModelStrict resolves a model by direct lookup or alias, without falling back
+// to the default provider. Used for models pinned on a block.
+func (e *AgentBlockExecutor) resolveModelStrict(modelID string) (*models.Provider, string, error) {
+	if provider, err := e.providerService.GetByModelID(modelID); err == nil {
+		return provider, modelID, nil
+	}
+	if aliasProvider, aliasModel, found := e.chatService.ResolveModelAlias(modelID); found {
+		return aliasProvider, aliasModel, nil
+	}
+	return nil, "", fmt.Errorf("model %s not found", modelID)
+}
+
 // buildMessages creates the initial messages with interpolated prompts
 func (e *AgentBlockExecutor) buildMessages(config models.AgentBlockConfig, inputs map[string]any) []map[string]any {
 	messages := []map[string]any{}
@@ -1064,12 +1193,29 @@ func (e *AgentBlockExecutor) buildMessages(config models.AgentBlockConfig, input
 		systemPromptBuilder.WriteString("\nIMPORTANT: DO NOT respond with text only. You MUST call at least one of the above tools.\n\n")
 	}
 
+	// Tools this block was configured with but cannot use in this run
+	if len(config.UnavailableTools) > 0 {
+		systemPromptBuilder.WriteString("## UNAVAILABLE TOOLS\n")
+		systemPromptBuilder.WriteString("These tools are configured for this task but are NOT available in this run:\n")
+		systemPromptBuilder.WriteString(describeUnavailableTools(config.UnavailableTools))
+		systemPromptBuilder.WriteString("\n\nDo NOT pretend to call them or invent their results. If the task needs them, say clearly which part could not be done and why.\n\n")
+	}
+
 	// Check for retry context and add stronger instructions
 	if retryAttempt, ok := inputs["_retryAttempt"].(int); ok && retryAttempt > 0 {
 		retryReason, _ := inputs["_retryReason"].(string)
 
-		// Determine if this is a schema error or tool error
-		if strings.Contains(retryReason, "Schema validation failed") || strings.Contains(retryReason, "schema") {
+		// Determine if this is a completion check, schema or tool error
+		if strings.HasPrefix(retryReason, BlockCheckRetryPrefix) {
+			// Completion check retry - the previous run did not accomplish the task
+			systemPromptBuilder.WriteString(fmt.Sprintf(`## ⚠️ COMPLETION CHECK RETRY (Attempt %d)
+Your previous run did NOT accomplish this block's task.
+Reviewer feedback: %s
+
+Address the feedback above and complete the task fully this time.
+
+`, retryAttempt+1, strings.TrimPrefix(retryReason, BlockCheckRetryPrefix)))
+		} else if strings.Contains(retryReason, "Schema validation failed") || strings.Contains(retryReason, "schema") {
 			// Schema validation retry - guide LLM to fix JSON output format
 			systemPromptBuilder.WriteString(fmt.Sprintf(`## ⚠️ SCHEMA VALIDATION RETRY (Attempt %d)
 Your previous response did NOT match the required JSON schema.
@@ -1106,6 +1252,14 @@ This is your last chance - call the tool immediately.
 		systemPromptBuilder.WriteString("\n\n")
 	}
 
+	// Add prior runs of this agent for continuity (set when EnableHistory is on)
+	if history, ok := inputs["_executionHistory"].(string); ok && history != "" {
+		systemPromptBuilder.WriteString("## PREVIOUS RUNS (oldest first)\n")
+		systemPromptBuilder.WriteString("Earlier executions of this agent for the same user. Use them for context only; the current input takes precedence.\n\n")
+		systemPromptBuilder.WriteString(history)
+		log.Printf("📜 [AGENT-BLOCK] Added execution history to system prompt (%d chars)", len(history))
+	}
+
 	// If data files present, add analysis guidelines to system prompt
 	if len(dataAttachments) > 0 {
 		systemPromptBuilder.WriteString(`
@@ -1649,36 +1803,8 @@ func (e *AgentBlockExecutor) extractGeneratedFilesFromToolCalls(toolCalls []mode
 			continue
 		}
 
-		// Parse tool result as JSON
-		var resultData map[string]any
-		if err := json.Unmarshal([]byte(tc.Result), &resultData); err != nil {
-			continue
-		}
-
-		// Look for file reference fields
-		fileRef := GeneratedFile{}
-
-		if v, ok := resultData["file_id"].(string); ok && v != "" {
-			fileRef.FileID = v
-		}
-		if v, ok := resultData["filename"].(string); ok && v != "" {
-			fileRef.Filename = v
-		}
-		if v, ok := resultData["download_url"].(string); ok && v != "" {
-			fileRef.DownloadURL = v
-		}
-		if v, ok := resultData["access_code"].(string); ok && v != "" {
-			fileRef.AccessCode = v
-		}
-		if v, ok := resultData["size"].(float64); ok {
-			fileRef.Size = int64(v)
-		}
-		if v, ok := resultData["mime_type"].(string); ok && v != "" {
-			fileRef.MimeType = v
-		}
-
-		// Only add if we have meaningful file reference data
-		if fileRef.FileID != "" || fileRef.DownloadURL != "" {
+		// See ToolResultFilesKey for the tool result file contract
+		for _, fileRef := range parseToolResultFiles(tc.Result) {
 			files = append(files, fileRef)
 			log.Printf("📄 [AGENT-BLOCK] Extracted file reference: %s (url: %s)", fileRef.Filename, fileRef.DownloadURL)
 		}
@@ -2093,7 +2219,7 @@ func (e *AgentBlockExecutor) callLLM(
 	tools []map[string]interface{},
 	temperature float64,
 ) (*LLMResponse, error) {
-	return e.callLLMWithSchema(ctx, provider, modelID, messages, tools, temperature, nil)
+	return e.callLLMWithSchema(ctx, provider, modelID, messages, tools, temperature, 0, nil)
 }
 
 // callLLMWithSchema calls the LLM with optional native structured output support
@@ -2104,12 +2230,13 @@ func (e *AgentBlockExecutor) callLLMWithSchema(
 	messages []map[string]any,
 	tools []map[string]interface{},
 	temperature float64,
+	maxTokens int,
 	outputSchema *models.JSONSchema,
 ) (*LLMResponse, error) {
 
-	// Detect provider type by base URL to avoid sending incompatible parameters
+	// Detect provider type to avoid sending incompatible parameters
 	// OpenAI's API is strict and rejects unknown parameters with 400 errors
-	isOpenAI := strings.Contains(strings.ToLower(provider.BaseURL), "openai.com")
+	isOpenAI := providerhttp.ResolveFlavor(provider.Flavor, provider.BaseURL) == providerhttp.FlavorOpenAI
 	isOpenRouter := strings.Contains(strings.ToLower(provider.BaseURL), "openrouter.ai")
 	isGLM := strings.Contains(strings.ToLower(provider.BaseURL), "bigmodel.cn") ||
 		strings.Contains(strings.ToLower(provider.Name), "glm") ||
@@ -2130,11 +2257,11 @@ func (e *AgentBlockExecutor) callLLMWithSchema(
 	// Use correct token limit parameter based on provider
 	// OpenAI newer models (GPT-4o, o1, etc.) require max_completion_tokens instead of max_tokens
 	// Most models support 65K+ output tokens, so we use 32768 as a safe high limit
-	if isOpenAI {
-		requestBody["max_completion_tokens"] = 32768
-	} else {
-		requestBody["max_tokens"] = 32768
+	// unless the block sets its own
+	if maxTokens <= 0 {
+		maxTokens = 32768
 	}
+	requestBody[providerhttp.MaxTokensParam(provider.Flavor, provider.BaseURL, provider.UsesMaxCompletionTokens)] = maxTokens
 
 	// Add native structured output if supported and no tools are being used
 	// Note: Can't use response_format with tools - they're mutually exclusive
@@ -2214,9 +2341,10 @@ func (e *AgentBlockExecutor) callLLMWithRetry(
 	messages []map[string]any,
 	tools []map[string]interface{},
 	temperature float64,
+	maxTokens int,
 	retryPolicy *models.RetryPolicy,
 ) (*LLMResponse, []models.RetryAttempt, error) {
-	return e.callLLMWithRetryAndSchema(ctx, provider, modelID, messages, tools, temperature, retryPolicy, nil)
+	return e.callLLMWithRetryAndSchema(ctx, provider, modelID, messages, tools, temperature, maxTokens, retryPolicy, nil)
 }
 
 // callLLMWithRetryAndSchema wraps callLLMWithSchema with retry logic for transient errors
@@ -2228,6 +2356,7 @@ func (e *AgentBlockExecutor) callLLMWithRetryAndSchema(
 	messages []map[string]any,
 	tools []map[string]interface{},
 	temperature float64,
+	maxTokens int,
 	retryPolicy *models.RetryPolicy,
 	outputSchema *models.JSONSchema,
 ) (*LLMResponse, []models.RetryAttempt, error) {
@@ -2251,7 +2380,7 @@ func (e *AgentBlockExecutor) callLLMWithRetryAndSchema(
 		attemptStart := time.Now()
 
 		// Make the LLM call with optional schema
-		response, err := e.callLLMWithSchema(ctx, provider, modelID, messages, tools, temperature, outputSchema)
+		response, err := e.callLLMWithSchema(ctx, provider, modelID, messages, tools, temperature, maxTokens, outputSchema)
 		attemptDuration := time.Since(attemptStart).Milliseconds()
 
 		if err == nil {
@@ -2472,7 +2601,7 @@ type toolCallAccumulator struct {
 }
 
 // executeToolCall executes a single tool call and returns the record
-func (e *AgentBlockExecutor) executeToolCall(toolCall map[string]any, blockInputs map[string]any, dataFiles []DataFileAttachment, generatedCharts []string, userID string, credentials []string) models.ToolCallRecord {
+func (e *AgentBlockExecutor) executeToolCall(ctx context.Context, toolCall map[string]any, blockInputs map[string]any, dataFiles []DataFileAttachment, generatedCharts []string, userID string, credentials []string) models.ToolCallRecord {
 	startTime := time.Now()
 
 	record := models.ToolCallRecord{
@@ -2499,6 +2628,14 @@ func (e *AgentBlockExecutor) executeToolCall(toolCall map[string]any, blockInput
 		return record
 	}
 
+	// Enforce the execution-wide tool call budget (guards against runaway recursion)
+	if err := consumeToolCall(ctx); err != nil {
+		record.Error = err.Error()
+		record.Duration = time.Since(startTime).Milliseconds()
+		log.Printf("🛑 [AGENT-BLOCK] Tool %s rejected: %v", record.Name, err)
+		return record
+	}
+
 	// Interpolate template variables in tool arguments
 	// This allows tool calls to use {{input}} or other block outputs
 	record.Arguments = interpolateMapValues(record.Arguments, blockInputs)
@@ -2560,7 +2697,7 @@ func (e *AgentBlockExecutor) executeToolCall(toolCall map[string]any, blockInput
 	// The resolver is user-scoped for security - only credentials owned by userID can be accessed
 	var resolver tools.CredentialResolver
 	if e.credentialService != nil && userID != "" {
-		resolver = e.credentialService.CreateCredentialResolver(userID)
+		resolver = secretRecordingResolver(ctx, e.credentialService.CreateCredentialResolver(userID))
 		record.Arguments[tools.CredentialResolverKey] = resolver
 		record.Arguments[tools.UserIDKey] = userID
 	}
@@ -2583,8 +2720,7 @@ func (e *AgentBlockExecutor) executeToolCall(toolCall map[string]any, blockInput
 		// If no credential found in block config, try runtime auto-discovery from user's credentials
 		if credentialID == "" {
 			log.Printf("🔍 [AGENT-BLOCK] No credentials in block config for tool=%s, trying runtime auto-discovery...", record.Name)
-			ctx := context.Background()
-			userCreds, err := e.credentialService.ListByUserAndType(ctx, userID, toolIntegrationType)
+			userCreds, err := e.credentialService.ListByUserAndType(context.Background(), userID, toolIntegrationType)
 			if err != nil {
 				log.Printf("⚠️ [AGENT-BLOCK] Failed to fetch user credentials: %v", err)
 			} else if len(userCreds) == 1 {
@@ -2612,13 +2748,13 @@ func (e *AgentBlockExecutor) executeToolCall(toolCall map[string]any, blockInput
 	// Inject image provider config for generate_image tool
 	if record.Name == "generate_image" {
 		imageProviderService := services.GetImageProviderService()
-		provider := imageProviderService.GetProvider()
-		if provider != nil {
+		if provider, ok := imageProviderService.GetProvider(); ok {
 			record.Arguments[tools.ImageProviderConfigKey] = &tools.ImageProviderConfig{
 				Name:         provider.Name,
 				BaseURL:      provider.BaseURL,
 				APIKey:       provider.APIKey,
 				DefaultModel: provider.DefaultModel,
+				Flavor:       provider.Flavor,
 			}
 			log.Printf("🎨 [AGENT-BLOCK] Injected image provider: %s (model: %s)", provider.Name, provider.DefaultModel)
 		} else {
@@ -2628,13 +2764,17 @@ func (e *AgentBlockExecutor) executeToolCall(toolCall map[string]any, blockInput
 
 	log.Printf("🔧 [AGENT-BLOCK] Executing tool: %s with args: %+v", record.Name, record.Arguments)
 
+	// Pass the execution context so tools that trigger workflows can nest under this execution
+	record.Arguments[tools.ExecutionContextKey] = ctx
+
 	// Execute the tool
 	result, err := e.toolRegistry.Execute(record.Name, record.Arguments)
 	if err != nil {
 		record.Error = err.Error()
 		log.Printf("❌ [AGENT-BLOCK] Tool %s failed: %v", record.Name, err)
 	} else {
-		record.Result = result
+		// Store inline file blobs so the LLM sees download links instead of base64
+		record.Result = storeToolResultFiles(userID, record.Name, result)
 		log.Printf("✅ [AGENT-BLOCK] Tool %s succeeded (result length: %d)", record.Name, len(result))
 	}
 
@@ -2645,6 +2785,7 @@ func (e *AgentBlockExecutor) executeToolCall(toolCall map[string]any, blockInput
 	delete(record.Arguments, tools.CredentialResolverKey)
 	delete(record.Arguments, tools.UserIDKey)
 	delete(record.Arguments, tools.ImageProviderConfigKey)
+	delete(record.Arguments, tools.ExecutionContextKey)
 
 	return record
 }
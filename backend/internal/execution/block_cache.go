@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"bytes"
+	"claraverse/internal/models"
+	"claraverse/internal/services"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Blocks opt in to output caching with "cacheable": true in their config. Only
+// side-effect-free blocks (pure transforms, read-only API calls) should: a cache hit
+// skips the block entirely. "cacheTtlSeconds" overrides DefaultBlockCacheTTL.
+const (
+	DefaultBlockCacheTTL = time.Hour
+	MaxBlockCacheTTL     = 7 * 24 * time.Hour
+)
+
+// BlockCache stores block outputs by cache key
+type BlockCache interface {
+	Get(ctx context.Context, key string) (map[string]any, bool)
+	Set(ctx context.Context, key string, output map[string]any, ttl time.Duration) error
+}
+
+// SetBlockCache enables output caching for cacheable blocks
+func (e *WorkflowEngine) SetBlockCache(cache BlockCache) {
+	e.blockCache = cache
+}
+
+// blockCacheTTL reports whether a block opted in to caching and for how long
+func blockCacheTTL(block models.Block) (time.Duration, bool) {
+	if cacheable, _ := block.Config["cacheable"].(bool); !cacheable {
+		return 0, false
+	}
+	ttl := DefaultBlockCacheTTL
+	if seconds, ok := block.Config["cacheTtlSeconds"].(float64); ok && seconds > 0 {
+		ttl = min(time.Duration(seconds)*time.Second, MaxBlockCacheTTL)
+	}
+	return ttl, true
+}
+
+// blockCacheKey identifies a block's output by its workflow, definition and resolved
+// inputs, so editing the block or changing any input misses the cache. ok is false
+// when the inputs cannot be serialized.
+func blockCacheKey(workflow *models.Workflow, block models.Block, inputs map[string]any) (key string, ok bool) {
+	// encoding/json sorts map keys, so equal inputs always hash the same
+	data, err := json.Marshal(struct {
+		Type   string         `json:"type"`
+		Config map[string]any `json:"config"`
+		Inputs map[string]any `json:"inputs"`
+	}{block.Type, block.Config, inputs})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return "block_cache:" + workflow.ID + ":" + block.ID + ":" + hex.EncodeToString(sum[:]), true
+}
+
+// containsSecrets reports whether redacting output would change it. Such outputs are
+// not cached, since a cache hit would not register the secrets for redaction.
+func containsSecrets(secrets *secretSet, output map[string]any) bool {
+	if secrets.empty() {
+		return false
+	}
+	raw, err := json.Marshal(output)
+	if err != nil {
+		return true
+	}
+	redacted, err := json.Marshal(secrets.redactMap(output))
+	return err != nil || !bytes.Equal(raw, redacted)
+}
+
+// RedisBlockCache stores block outputs in Redis as JSON
+type RedisBlockCache struct {
+	redis *services.RedisService
+}
+
+// NewRedisBlockCache creates a block cache backed by Redis
+func NewRedisBlockCache(redis *services.RedisService) *RedisBlockCache {
+	return &RedisBlockCache{redis: redis}
+}
+
+// Get returns the cached output for key. Redis errors are treated as misses.
+func (c *RedisBlockCache) Get(ctx context.Context, key string) (map[string]any, bool) {
+	data, err := c.redis.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	var output map[string]any
+	if err := json.Unmarshal([]byte(data), &output); err != nil {
+		log.Printf("⚠️ [CACHE] Discarding unreadable cached output %s: %v", key, err)
+		return nil, false
+	}
+	return output, true
+}
+
+// Set caches output under key for ttl
+func (c *RedisBlockCache) Set(ctx context.Context, key string, output map[string]any, ttl time.Duration) error {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	return c.redis.Set(ctx, key, data, ttl)
+}
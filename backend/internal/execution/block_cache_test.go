@@ -0,0 +1,101 @@
+package execution
+
+import (
+	"claraverse/internal/models"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBlockCache is an in-memory BlockCache for tests
+type memoryBlockCache struct {
+	mu      sync.Mutex
+	outputs map[string]map[string]any
+	ttls    map[string]time.Duration
+}
+
+func newMemoryBlockCache() *memoryBlockCache {
+	return &memoryBlockCache{outputs: map[string]map[string]any{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *memoryBlockCache) Get(ctx context.Context, key string) (map[string]any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output, ok := c.outputs[key]
+	return output, ok
+}
+
+func (c *memoryBlockCache) Set(ctx context.Context, key string, output map[string]any, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.outputs[key] = output
+	c.ttls[key] = ttl
+	return nil
+}
+
+// countingExecutor echoes its "query" input and counts its runs
+type countingExecutor struct {
+	mu   sync.Mutex
+	runs int
+}
+
+func (e *countingExecutor) Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error) {
+	e.mu.Lock()
+	e.runs++
+	e.mu.Unlock()
+	return map[string]any{"response": inputs["query"]}, nil
+}
+
+func TestCacheableBlockReusesOutputForSameInputs(t *testing.T) {
+	executor := &countingExecutor{}
+	engine := NewWorkflowEngine(&ExecutorRegistry{executors: map[string]BlockExecutor{"lookup": executor}})
+	cache := newMemoryBlockCache()
+	engine.SetBlockCache(cache)
+
+	workflow := &models.Workflow{ID: "wf-1", Blocks: []models.Block{
+		{ID: "lookup", Name: "Lookup", Type: "lookup", Config: map[string]any{"cacheable": true, "cacheTtlSeconds": float64(60)}},
+	}}
+	run := func(query string) *ExecutionResult {
+		t.Helper()
+		result, err := engine.Execute(context.Background(), workflow, map[string]any{"query": query}, make(chan models.ExecutionUpdate, 32))
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result
+	}
+
+	if first := run("weather"); first.BlockStates["lookup"].CacheHit {
+		t.Error("Expected the first run to miss the cache")
+	}
+	second := run("weather")
+	if !second.BlockStates["lookup"].CacheHit || second.BlockStates["lookup"].Outputs["response"] != "weather" {
+		t.Errorf("Expected the second run to be served from cache, got %+v", second.BlockStates["lookup"])
+	}
+	run("news")
+
+	if executor.runs != 2 {
+		t.Errorf("Expected 2 executions (one per distinct input), got %d", executor.runs)
+	}
+	for _, ttl := range cache.ttls {
+		if ttl != time.Minute {
+			t.Errorf("Expected the configured TTL, got %v", ttl)
+		}
+	}
+}
+
+func TestBlockWithoutCacheableFlagIsNotCached(t *testing.T) {
+	executor := &countingExecutor{}
+	engine := NewWorkflowEngine(&ExecutorRegistry{executors: map[string]BlockExecutor{"lookup": executor}})
+	engine.SetBlockCache(newMemoryBlockCache())
+
+	workflow := &models.Workflow{ID: "wf-1", Blocks: []models.Block{{ID: "lookup", Name: "Lookup", Type: "lookup"}}}
+	for i := 0; i < 2; i++ {
+		if _, err := engine.Execute(context.Background(), workflow, map[string]any{"query": "weather"}, make(chan models.ExecutionUpdate, 32)); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+	}
+	if executor.runs != 2 {
+		t.Errorf("Expected the block to run every time, got %d runs", executor.runs)
+	}
+}
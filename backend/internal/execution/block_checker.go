@@ -3,6 +3,7 @@ package execution
 import (
 	"bytes"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"claraverse/internal/services"
 	"context"
 	"encoding/json"
@@ -10,10 +11,17 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 )
 
+// BlockCheckRetryPrefix starts the _retryReason of blocks re-run after failing the completion check
+const BlockCheckRetryPrefix = "Block check failed: "
+
+// MaxCheckerRetries caps ExecutionOptions.CheckerMaxRetries since every retry re-runs the block
+const MaxCheckerRetries = 3
+
 // BlockCheckResult represents the structured output from the block completion checker
 type BlockCheckResult struct {
 	Passed       bool   `json:"passed"`        // true if block accomplished its job
@@ -33,9 +41,7 @@ type BlockChecker struct {
 func NewBlockChecker(providerService *services.ProviderService) *BlockChecker {
 	return &BlockChecker{
 		providerService: providerService,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient:      providerhttp.NewClient(30 * time.Second),
 	}
 }
 
@@ -58,6 +64,21 @@ func (c *BlockChecker) CheckBlockCompletion(
 	blockInput map[string]any,
 	blockOutput map[string]any,
 	modelID string,
+) (*BlockCheckResult, error) {
+	return c.CheckBlockCompletionWithPool(ctx, workflowGoal, block, blockInput, blockOutput, modelID, nil)
+}
+
+// CheckBlockCompletionWithPool validates a block, failing over between checker models.
+// preferredModelID (if set) is tried first, then models drawn from pool. If every
+// checker model is unavailable the block defaults to passed, as with a single model.
+func (c *BlockChecker) CheckBlockCompletionWithPool(
+	ctx context.Context,
+	workflowGoal string,
+	block models.Block,
+	blockInput map[string]any,
+	blockOutput map[string]any,
+	preferredModelID string,
+	pool *CheckerModelPool,
 ) (*BlockCheckResult, error) {
 	log.Printf("🔍 [BLOCK-CHECKER] Checking completion for block '%s' (type: %s)", block.Name, block.Type)
 
@@ -70,11 +91,62 @@ func (c *BlockChecker) CheckBlockCompletion(
 	// Build the validation prompt
 	prompt := c.buildValidationPrompt(workflowGoal, block, blockInput, blockOutput)
 
+	// Candidate models: explicit choice first, then the pool
+	var candidates []string
+	if preferredModelID != "" {
+		candidates = append(candidates, preferredModelID)
+	}
+	if pool != nil {
+		for i := 0; i < pool.Size(); i++ {
+			modelID, err := pool.GetNext()
+			if err != nil {
+				break
+			}
+			if !slices.Contains(candidates, modelID) {
+				candidates = append(candidates, modelID)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = []string{DefaultCheckerModelID}
+	}
+
+	var lastErr error
+	for _, modelID := range candidates {
+		result, err := c.checkWithModel(ctx, prompt, block, blockOutput, modelID)
+		if err == nil {
+			if pool != nil {
+				pool.MarkSuccess(modelID)
+			}
+			return result, nil
+		}
+		lastErr = err
+		if pool != nil {
+			pool.MarkFailure(modelID)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		log.Printf("⚠️ [BLOCK-CHECKER] Checker model %s unavailable: %v", modelID, err)
+	}
+
+	log.Printf("⚠️ [BLOCK-CHECKER] No checker model available, defaulting to passed: %v", lastErr)
+	return &BlockCheckResult{Passed: true, Reason: fmt.Sprintf("Checker unavailable - defaulting to passed (%v)", lastErr)}, nil
+}
+
+// checkWithModel runs the validation prompt against one model.
+// Errors mean the checker itself failed (provider, HTTP, or response problems).
+func (c *BlockChecker) checkWithModel(
+	ctx context.Context,
+	prompt string,
+	block models.Block,
+	blockOutput map[string]any,
+	modelID string,
+) (*BlockCheckResult, error) {
 	// Get provider for the model
 	provider, err := c.providerService.GetByModelID(modelID)
 	if err != nil {
-		log.Printf("⚠️ [BLOCK-CHECKER] Provider error, defaulting to passed: %v", err)
-		return &BlockCheckResult{Passed: true, Reason: "Provider error - defaulting to passed"}, nil
+		return nil, fmt.Errorf("provider error: %w", err)
 	}
 
 	// Build the request with structured output
@@ -133,15 +205,13 @@ func (c *BlockChecker) CheckBlockCompletion(
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		log.Printf("⚠️ [BLOCK-CHECKER] HTTP error, defaulting to passed: %v", err)
-		return &BlockCheckResult{Passed: true, Reason: "HTTP error during check - defaulting to passed"}, nil
+		return nil, fmt.Errorf("HTTP error: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("⚠️ [BLOCK-CHECKER] API error (status %d): %s, defaulting to passed", resp.StatusCode, string(body))
-		return &BlockCheckResult{Passed: true, Reason: "API error during check - defaulting to passed"}, nil
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, truncateString(string(body), 200))
 	}
 
 	// Parse response
@@ -154,20 +224,17 @@ func (c *BlockChecker) CheckBlockCompletion(
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		log.Printf("⚠️ [BLOCK-CHECKER] Decode error, defaulting to passed: %v", err)
-		return &BlockCheckResult{Passed: true, Reason: "Response decode error - defaulting to passed"}, nil
+		return nil, fmt.Errorf("response decode error: %w", err)
 	}
 
 	if len(apiResp.Choices) == 0 || apiResp.Choices[0].Message.Content == "" {
-		log.Printf("⚠️ [BLOCK-CHECKER] Empty response, defaulting to passed")
-		return &BlockCheckResult{Passed: true, Reason: "Empty response from checker - defaulting to passed"}, nil
+		return nil, fmt.Errorf("empty response from checker")
 	}
 
 	// Parse the structured output
 	var result BlockCheckResult
 	if err := json.Unmarshal([]byte(apiResp.Choices[0].Message.Content), &result); err != nil {
-		log.Printf("⚠️ [BLOCK-CHECKER] JSON parse error, defaulting to passed: %v", err)
-		return &BlockCheckResult{Passed: true, Reason: "JSON parse error - defaulting to passed"}, nil
+		return nil, fmt.Errorf("JSON parse error: %w", err)
 	}
 
 	// Always populate ActualOutput with a summary of what the block produced
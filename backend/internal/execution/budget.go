@@ -0,0 +1,146 @@
+package execution
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"claraverse/internal/tools"
+)
+
+const (
+	// DefaultMaxExecutionDepth is how many nested workflow executions may be stacked
+	// on top of a top-level execution before it is rejected
+	DefaultMaxExecutionDepth = 5
+	// DefaultToolCallBudget is the total number of tool calls allowed across a
+	// top-level execution and all executions it triggers
+	DefaultToolCallBudget = 200
+)
+
+var (
+	// ErrMaxDepthExceeded is returned when nested executions exceed the configured max depth
+	ErrMaxDepthExceeded = errors.New("maximum execution depth exceeded")
+	// ErrToolCallBudgetExceeded is returned when an execution tree runs out of tool calls
+	ErrToolCallBudgetExceeded = errors.New("tool call budget exceeded")
+)
+
+// executionBudgetKey is the context key for the per-execution budget
+type executionBudgetKey struct{}
+
+// executionBudget tracks recursion depth and tool usage for one top-level execution.
+// Nested executions get a copy with depth+1 that shares the same tool call counter.
+type executionBudget struct {
+	depth          int
+	maxDepth       int
+	toolCallBudget int64
+	toolCalls      *atomic.Int64
+}
+
+// withExecutionBudget returns a context carrying the budget for a new execution.
+// A top-level execution starts at depth 0 with limits taken from options; a nested
+// execution inherits its parent's limits and counter and increments the depth.
+func withExecutionBudget(ctx context.Context, options *ExecutionOptions) (context.Context, error) {
+	if parent, ok := ctx.Value(executionBudgetKey{}).(*executionBudget); ok && parent != nil {
+		nested := &executionBudget{
+			depth:          parent.depth + 1,
+			maxDepth:       parent.maxDepth,
+			toolCallBudget: parent.toolCallBudget,
+			toolCalls:      parent.toolCalls,
+		}
+		if nested.depth > nested.maxDepth {
+			return ctx, fmt.Errorf("%w: depth %d exceeds limit of %d", ErrMaxDepthExceeded, nested.depth, nested.maxDepth)
+		}
+		return context.WithValue(ctx, executionBudgetKey{}, nested), nil
+	}
+
+	budget := &executionBudget{
+		maxDepth:       DefaultMaxExecutionDepth,
+		toolCallBudget: DefaultToolCallBudget,
+		toolCalls:      &atomic.Int64{},
+	}
+	if options != nil {
+		if options.MaxDepth > 0 {
+			budget.maxDepth = options.MaxDepth
+		}
+		if options.ToolCallBudget > 0 {
+			budget.toolCallBudget = int64(options.ToolCallBudget)
+		}
+	}
+	return context.WithValue(ctx, executionBudgetKey{}, budget), nil
+}
+
+// linkedExecution is a running execution that workflows it triggers may join
+type linkedExecution struct {
+	budget *executionBudget
+	userID string
+}
+
+// linkedExecutions holds running executions by link token
+var linkedExecutions sync.Map
+
+// linkExecution gives the execution running in ctx a link token that its tools send
+// on outgoing requests (tools.ExecutionLinkHeader), so a workflow it triggers through
+// the API can join its budget with JoinExecution. Call unlink when it finishes.
+func linkExecution(ctx context.Context, userID string) (linked context.Context, unlink func()) {
+	budget, ok := ctx.Value(executionBudgetKey{}).(*executionBudget)
+	if !ok || budget == nil || userID == "" {
+		return ctx, func() {}
+	}
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return ctx, func() {}
+	}
+	link := hex.EncodeToString(randomBytes)
+
+	linkedExecutions.Store(link, &linkedExecution{budget: budget, userID: userID})
+	return tools.WithExecutionLink(ctx, link), func() { linkedExecutions.Delete(link) }
+}
+
+// JoinExecution returns ctx carrying the budget of the running execution that link
+// identifies, so a workflow it triggered through the API runs nested under it, one
+// level deeper and sharing its tool call budget. Empty, unknown or finished links
+// and links of another user's execution are ignored. It fails with
+// ErrMaxDepthExceeded when the nested execution would be too deep.
+func JoinExecution(ctx context.Context, link, userID string) (context.Context, error) {
+	if link == "" {
+		return ctx, nil
+	}
+	value, ok := linkedExecutions.Load(link)
+	if !ok {
+		return ctx, nil
+	}
+	parent := value.(*linkedExecution)
+	if parent.userID != userID {
+		return ctx, nil
+	}
+	if depth := parent.budget.depth + 1; depth > parent.budget.maxDepth {
+		return ctx, fmt.Errorf("%w: depth %d exceeds limit of %d", ErrMaxDepthExceeded, depth, parent.budget.maxDepth)
+	}
+	return context.WithValue(ctx, executionBudgetKey{}, parent.budget), nil
+}
+
+// ExecutionDepth returns the nesting depth of the execution running in ctx (0 = top-level)
+func ExecutionDepth(ctx context.Context) int {
+	if budget, ok := ctx.Value(executionBudgetKey{}).(*executionBudget); ok && budget != nil {
+		return budget.depth
+	}
+	return 0
+}
+
+// consumeToolCall reserves one tool call from the execution budget.
+// Contexts without a budget (e.g. direct executor use in tests) are unlimited.
+func consumeToolCall(ctx context.Context) error {
+	budget, ok := ctx.Value(executionBudgetKey{}).(*executionBudget)
+	if !ok || budget == nil {
+		return nil
+	}
+	used := budget.toolCalls.Add(1)
+	if used > budget.toolCallBudget {
+		return fmt.Errorf("%w: %d tool calls allowed per execution", ErrToolCallBudgetExceeded, budget.toolCallBudget)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package execution
+
+import (
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestExecutionBudgetDepthAndToolCalls tests nested depth tracking and the shared tool call budget
+func TestExecutionBudgetDepthAndToolCalls(t *testing.T) {
+	ctx, err := withExecutionBudget(context.Background(), &ExecutionOptions{MaxDepth: 2, ToolCallBudget: 3})
+	if err != nil {
+		t.Fatalf("top-level execution rejected: %v", err)
+	}
+	if depth := ExecutionDepth(ctx); depth != 0 {
+		t.Errorf("Expected top-level depth 0, got %d", depth)
+	}
+
+	// Nested options must not loosen the parent's limits
+	nested, err := withExecutionBudget(ctx, &ExecutionOptions{MaxDepth: 100, ToolCallBudget: 100})
+	if err != nil {
+		t.Fatalf("depth 1 rejected: %v", err)
+	}
+	nested, err = withExecutionBudget(nested, nil)
+	if err != nil {
+		t.Fatalf("depth 2 rejected: %v", err)
+	}
+	if depth := ExecutionDepth(nested); depth != 2 {
+		t.Errorf("Expected depth 2, got %d", depth)
+	}
+	if _, err := withExecutionBudget(nested, nil); !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("Expected ErrMaxDepthExceeded at depth 3, got %v", err)
+	}
+
+	// Tool calls are counted across the whole execution tree
+	for i := 0; i < 2; i++ {
+		if err := consumeToolCall(ctx); err != nil {
+			t.Fatalf("tool call %d rejected: %v", i+1, err)
+		}
+	}
+	if err := consumeToolCall(nested); err != nil {
+		t.Fatalf("nested tool call rejected: %v", err)
+	}
+	if err := consumeToolCall(ctx); !errors.Is(err, ErrToolCallBudgetExceeded) {
+		t.Errorf("Expected ErrToolCallBudgetExceeded, got %v", err)
+	}
+
+	// Contexts without a budget are unlimited
+	if err := consumeToolCall(context.Background()); err != nil {
+		t.Errorf("Expected no budget enforcement without execution context, got %v", err)
+	}
+}
+
+// triggeringExecutor calls a trigger endpoint the way the webhook tools do and records
+// the depth it ran at and the status the endpoint answered
+type triggeringExecutor struct {
+	url      string
+	mu       sync.Mutex
+	depths   []int
+	statuses []int
+}
+
+func (e *triggeringExecutor) Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error) {
+	e.mu.Lock()
+	e.depths = append(e.depths, ExecutionDepth(ctx))
+	e.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodPost, e.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	tools.SetExecutionLinkHeader(req, map[string]interface{}{tools.ExecutionContextKey: ctx})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	e.mu.Lock()
+	e.statuses = append(e.statuses, resp.StatusCode)
+	e.mu.Unlock()
+	return map[string]any{"status": resp.StatusCode}, nil
+}
+
+// TestTriggeredWorkflowNestsUnderCallingExecution tests that a workflow triggered
+// through the API by another execution's tool call joins that execution's budget
+func TestTriggeredWorkflowNestsUnderCallingExecution(t *testing.T) {
+	executor := &triggeringExecutor{}
+	engine := NewWorkflowEngine(&ExecutorRegistry{executors: map[string]BlockExecutor{"trigger": executor}})
+	workflow := &models.Workflow{ID: "wf-loop", Blocks: []models.Block{
+		{ID: "trigger", Name: "Trigger", Type: "trigger"},
+	}}
+	options := &ExecutionOptions{MaxDepth: 2}
+
+	// Stands in for the trigger endpoint: the workflow triggers itself
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := JoinExecution(context.Background(), r.Header.Get(tools.ExecutionLinkHeader), "user-1")
+		if err != nil {
+			w.WriteHeader(http.StatusLoopDetected)
+			return
+		}
+		if _, err := engine.ExecuteWithOptions(ctx, workflow, map[string]any{"__user_id__": "user-1"}, make(chan models.ExecutionUpdate, 32), options); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	executor.url = server.URL
+	// The link is only sent to this backend
+	t.Setenv("BACKEND_URL", server.URL)
+
+	if _, err := engine.ExecuteWithOptions(context.Background(), workflow, map[string]any{"__user_id__": "user-1"}, make(chan models.ExecutionUpdate, 32), options); err != nil {
+		t.Fatalf("ExecuteWithOptions failed: %v", err)
+	}
+
+	if len(executor.depths) != 3 || executor.depths[0] != 0 || executor.depths[1] != 1 || executor.depths[2] != 2 {
+		t.Errorf("Expected the triggered workflows to run at depths [0 1 2], got %v", executor.depths)
+	}
+	// The deepest execution's trigger is refused; the others were accepted
+	if len(executor.statuses) != 3 || executor.statuses[0] != http.StatusLoopDetected ||
+		executor.statuses[1] != http.StatusAccepted || executor.statuses[2] != http.StatusAccepted {
+		t.Errorf("Expected statuses [508 202 202] (innermost first), got %v", executor.statuses)
+	}
+
+	linkedExecutions.Range(func(link, _ any) bool {
+		t.Errorf("Expected finished executions to be unlinked, found %v", link)
+		return true
+	})
+}
+
+// TestJoinExecutionIgnoresForeignLinks tests that only a running execution of the
+// same user can be joined
+func TestJoinExecutionIgnoresForeignLinks(t *testing.T) {
+	ctx, err := withExecutionBudget(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("top-level execution rejected: %v", err)
+	}
+	ctx, unlink := linkExecution(ctx, "user-1")
+	link := tools.ExecutionLink(ctx)
+	if link == "" {
+		t.Fatal("Expected the execution to get a link")
+	}
+
+	joined, err := JoinExecution(context.Background(), link, "user-1")
+	if err != nil || joined.Value(executionBudgetKey{}) == nil {
+		t.Errorf("Expected the owner to join the execution, got err %v", err)
+	}
+	for name, check := range map[string]struct{ link, userID string }{
+		"other user": {link, "user-2"},
+		"unknown":    {"not-a-link", "user-1"},
+		"empty":      {"", "user-1"},
+	} {
+		joined, err := JoinExecution(context.Background(), check.link, check.userID)
+		if err != nil || joined.Value(executionBudgetKey{}) != nil {
+			t.Errorf("%s: expected the link to be ignored, got err %v", name, err)
+		}
+	}
+
+	unlink()
+	if joined, _ := JoinExecution(context.Background(), link, "user-1"); joined.Value(executionBudgetKey{}) != nil {
+		t.Error("Expected a finished execution's link to be ignored")
+	}
+}
@@ -0,0 +1,139 @@
+package execution
+
+import (
+	"claraverse/internal/services"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultCheckerModelID is used when no checker model or pool is configured
+const DefaultCheckerModelID = "gpt-4.1"
+
+// CheckerModelPool rotates block checker calls across several models with the same
+// health tracking and failover rules as services.MemoryModelPool, so one model being
+// down doesn't break block validation
+type CheckerModelPool struct {
+	models        []string
+	index         int
+	healthTracker map[string]*services.ModelHealth
+	mu            sync.Mutex
+}
+
+// NewCheckerModelPool creates a pool from model IDs (in preference order)
+func NewCheckerModelPool(modelIDs []string) *CheckerModelPool {
+	pool := &CheckerModelPool{
+		healthTracker: make(map[string]*services.ModelHealth),
+	}
+	for _, id := range modelIDs {
+		if id == "" {
+			continue
+		}
+		if _, exists := pool.healthTracker[id]; exists {
+			continue
+		}
+		pool.models = append(pool.models, id)
+		pool.healthTracker[id] = &services.ModelHealth{IsHealthy: true}
+	}
+	log.Printf("🎯 [CHECKER-POOL] Initialized with %d checker model(s): %v", len(pool.models), pool.models)
+	return pool
+}
+
+// Size returns the number of models in the pool
+func (p *CheckerModelPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.models)
+}
+
+// GetNext returns the next healthy checker model using round-robin
+func (p *CheckerModelPool) GetNext() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.models) == 0 {
+		return "", fmt.Errorf("no checker models available")
+	}
+
+	for attempts := 0; attempts < len(p.models); attempts++ {
+		candidate := p.models[p.index]
+		p.index = (p.index + 1) % len(p.models)
+
+		health := p.healthTracker[candidate]
+		if health.IsHealthy {
+			return candidate, nil
+		}
+
+		// Check if enough time has passed since last failure (cooldown)
+		if time.Since(health.LastFailure) > services.HealthCheckCooldown {
+			log.Printf("⚡ [CHECKER-POOL] Retrying checker after cooldown: %s", candidate)
+			health.IsHealthy = true
+			health.ConsecutiveFails = 0
+			return candidate, nil
+		}
+	}
+
+	// All models unhealthy - return first anyway as last resort
+	log.Printf("⚠️ [CHECKER-POOL] All checker models unhealthy, using: %s", p.models[0])
+	return p.models[0], nil
+}
+
+// MarkSuccess records a successful checker call
+func (p *CheckerModelPool) MarkSuccess(modelID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health, exists := p.healthTracker[modelID]
+	if !exists {
+		return
+	}
+
+	health.SuccessCount++
+	health.LastSuccess = time.Now()
+	health.ConsecutiveFails = 0
+
+	if !health.IsHealthy && health.SuccessCount >= services.MinSuccessesToRecover {
+		health.IsHealthy = true
+		log.Printf("💚 [CHECKER-POOL] Checker model recovered: %s", modelID)
+	}
+}
+
+// MarkFailure records a failed checker call
+func (p *CheckerModelPool) MarkFailure(modelID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health, exists := p.healthTracker[modelID]
+	if !exists {
+		return
+	}
+
+	health.FailureCount++
+	health.ConsecutiveFails++
+	health.LastFailure = time.Now()
+
+	if health.ConsecutiveFails >= services.MaxConsecutiveFailures {
+		health.IsHealthy = false
+		log.Printf("💔 [CHECKER-POOL] Checker model marked unhealthy: %s (consecutive fails: %d)",
+			modelID, health.ConsecutiveFails)
+	}
+}
+
+// GetStats returns current pool statistics
+func (p *CheckerModelPool) GetStats() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	healthy := 0
+	for _, id := range p.models {
+		if p.healthTracker[id].IsHealthy {
+			healthy++
+		}
+	}
+
+	return map[string]interface{}{
+		"total_checkers":   len(p.models),
+		"healthy_checkers": healthy,
+	}
+}
@@ -6,14 +6,19 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
 // WorkflowEngine executes workflows as DAGs with parallel execution
 type WorkflowEngine struct {
-	registry     *ExecutorRegistry
-	blockChecker *BlockChecker
+	registry          *ExecutorRegistry
+	blockChecker      *BlockChecker
+	checkerModelPool  *CheckerModelPool
+	executionService  *services.ExecutionService
+	credentialService *services.CredentialService
+	blockCache        BlockCache
 }
 
 // NewWorkflowEngine creates a new workflow engine
@@ -34,6 +39,23 @@ func (e *WorkflowEngine) SetBlockChecker(checker *BlockChecker) {
 	e.blockChecker = checker
 }
 
+// SetCheckerModelPool sets the pool of models used for block completion checking.
+// Checks fail over across the pool when a model is unavailable.
+func (e *WorkflowEngine) SetCheckerModelPool(pool *CheckerModelPool) {
+	e.checkerModelPool = pool
+}
+
+// SetExecutionService sets the service used to load prior runs when EnableHistory is set
+func (e *WorkflowEngine) SetExecutionService(svc *services.ExecutionService) {
+	e.executionService = svc
+}
+
+// SetCredentialService sets the service used to resolve the workflow's credentials,
+// so their values are redacted from execution history
+func (e *WorkflowEngine) SetCredentialService(svc *services.CredentialService) {
+	e.credentialService = svc
+}
+
 // ExecutionResult contains the final result of a workflow execution
 type ExecutionResult struct {
 	Status      string                        `json:"status"` // completed, failed, partial
@@ -46,11 +68,31 @@ type ExecutionResult struct {
 type ExecutionOptions struct {
 	// WorkflowGoal is the high-level objective of the workflow (used for block checking)
 	WorkflowGoal string
-	// CheckerModelID is the model to use for block completion checking
-	// If empty, block checking is disabled
+	// CheckerModelID is the preferred model for block completion checking
+	// If empty, models are drawn from the engine's checker model pool
 	CheckerModelID string
 	// EnableBlockChecker enables/disables block completion validation
 	EnableBlockChecker bool
+	// MaxDepth limits how deeply executions may trigger nested executions
+	// Only honored on top-level executions; 0 uses DefaultMaxExecutionDepth
+	MaxDepth int
+	// ToolCallBudget limits total tool calls across the whole execution tree
+	// Only honored on top-level executions; 0 uses DefaultToolCallBudget
+	ToolCallBudget int
+	// AgentID identifies the agent whose prior runs are loaded when EnableHistory is set
+	AgentID string
+	// EnableHistory injects a summary of the agent's recent runs for this user into
+	// llm_inference blocks, giving conversational agents continuity between executions
+	EnableHistory bool
+	// HistoryWindow is how many prior runs to summarize (0 uses DefaultHistoryWindow)
+	HistoryWindow int
+	// CheckerMaxRetries re-runs a block that fails the completion check up to this many
+	// times (at most MaxCheckerRetries), passing the checker's reason to the block.
+	// 0 fails the block immediately.
+	CheckerMaxRetries int
+	// UnavailableTools is how llm_inference blocks handle configured tools that cannot
+	// run: models.UnavailableToolsFail (default) or models.UnavailableToolsMark
+	UnavailableTools string
 }
 
 // Execute runs a workflow and streams updates via the statusChan
@@ -64,6 +106,22 @@ func (e *WorkflowEngine) Execute(
 	return e.ExecuteWithOptions(ctx, workflow, input, statusChan, nil)
 }
 
+// validateBlocks runs the BlockValidator of every block's executor
+func (e *WorkflowEngine) validateBlocks(workflow *models.Workflow) error {
+	for _, block := range workflow.Blocks {
+		executor, err := e.registry.Get(block.Type)
+		if err != nil {
+			continue // Reported when the block runs
+		}
+		if validator, ok := executor.(BlockValidator); ok {
+			if err := validator.ValidateBlock(block); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // ExecuteWithOptions runs a workflow with optional block completion checking
 func (e *WorkflowEngine) ExecuteWithOptions(
 	ctx context.Context,
@@ -74,6 +132,23 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 ) (*ExecutionResult, error) {
 	log.Printf("🚀 [ENGINE] Starting workflow execution with %d blocks", len(workflow.Blocks))
 
+	// Track recursion depth and tool call budget across nested executions
+	ctx, budgetErr := withExecutionBudget(ctx, options)
+	if budgetErr != nil {
+		log.Printf("🛑 [ENGINE] Refusing nested execution: %v", budgetErr)
+		return nil, budgetErr
+	}
+	if depth := ExecutionDepth(ctx); depth > 0 {
+		log.Printf("🔁 [ENGINE] Nested execution at depth %d", depth)
+	}
+	// Workflows this one triggers through the API nest under it
+	userID, _ := input["__user_id__"].(string)
+	ctx, unlink := linkExecution(ctx, userID)
+	defer unlink()
+
+	// Credential values resolved by tools are redacted from stored and streamed data
+	ctx, secrets := withSecretSet(ctx)
+
 	// Build block index
 	blockIndex := make(map[string]models.Block)
 	for _, block := range workflow.Blocks {
@@ -109,6 +184,12 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 		return nil, fmt.Errorf("workflow has no start blocks (circular dependency?)")
 	}
 
+	// Reject invalid block configurations (e.g. unavailable pinned models) before any block runs
+	if err := e.validateBlocks(workflow); err != nil {
+		log.Printf("❌ [ENGINE] Workflow rejected: %v", err)
+		return nil, err
+	}
+
 	log.Printf("📊 [ENGINE] Found %d start blocks: %v", len(startBlocks), startBlocks)
 
 	// Initialize block states and outputs
@@ -155,6 +236,19 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 		}
 	}
 
+	if options != nil && options.UnavailableTools != "" {
+		globalInputs["_unavailableTools"] = options.UnavailableTools
+	}
+
+	// Summarize prior runs for conversational agents (top-level executions only)
+	if ExecutionDepth(ctx) == 0 {
+		userID, _ := globalInputs["__user_id__"].(string)
+		if history := e.loadExecutionHistory(ctx, options, userID); history != "" {
+			e.registerWorkflowCredentials(ctx, workflow, userID)
+			globalInputs["_executionHistory"] = secrets.redactString(history)
+		}
+	}
+
 	// Track completed blocks for dependency resolution
 	completedBlocks := make(map[string]bool)
 	failedBlocks := make(map[string]bool)
@@ -168,7 +262,28 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 	var wg sync.WaitGroup
 
 	// Recursive function to execute a block and schedule dependents
-	var executeBlock func(blockID string)
+	var executeBlock, scheduleDependents func(blockID string)
+
+	// failBlock records a block failure. A block with continue_on_error set gets an
+	// empty output and its dependents still run; any other failure stops its branch.
+	failBlock := func(blockID string, block models.Block, err error) {
+		handleBlockError(blockID, block.Name, err, blockStates, &statesMu, statusChan, &executionErrors, &errorsMu, secrets)
+		if !blockContinuesOnError(block) {
+			completedMu.Lock()
+			failedBlocks[blockID] = true
+			completedMu.Unlock()
+			return
+		}
+
+		log.Printf("⏭️ [ENGINE] Block '%s' is continue_on_error, continuing without its output", block.Name)
+		statesMu.Lock()
+		// An empty response renders {{block.response}} as "" in dependents
+		blockOutputs[blockID] = map[string]any{"response": ""}
+		blockStates[blockID].ContinuedOnError = true
+		statesMu.Unlock()
+		scheduleDependents(blockID)
+	}
+
 	executeBlock = func(blockID string) {
 		block := blockIndex[blockID]
 
@@ -179,11 +294,11 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 		statesMu.Unlock()
 
 		// Send status update (without inputs yet - will send after building them)
-		statusChan <- models.ExecutionUpdate{
+		statusChan <- secrets.redactUpdate(models.ExecutionUpdate{
 			Type:    "execution_update",
 			BlockID: blockID,
 			Status:  "running",
-		}
+		})
 
 		log.Printf("▶️ [ENGINE] Executing block '%s' (type: %s)", block.Name, block.Type)
 
@@ -263,26 +378,23 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 
 		// Store the available inputs in BlockState for debugging
 		statesMu.Lock()
-		blockStates[blockID].Inputs = blockInputs
+		blockStates[blockID].Inputs = secrets.redactMap(blockInputs)
 		statesMu.Unlock()
 
 		log.Printf("🔍 [ENGINE] Block '%s': stored %d input keys for debugging: %v", block.Name, len(blockInputs), getMapKeys(blockInputs))
 
 		// Send updated status with inputs for debugging
-		statusChan <- models.ExecutionUpdate{
+		statusChan <- secrets.redactUpdate(models.ExecutionUpdate{
 			Type:    "execution_update",
 			BlockID: blockID,
 			Status:  "running",
 			Inputs:  blockInputs,
-		}
+		})
 
 		// Get executor for this block type
 		executor, execErr := e.registry.Get(block.Type)
 		if execErr != nil {
-			handleBlockError(blockID, block.Name, execErr, blockStates, &statesMu, statusChan, &executionErrors, &errorsMu)
-			completedMu.Lock()
-			failedBlocks[blockID] = true
-			completedMu.Unlock()
+			failBlock(blockID, block, execErr)
 			return
 		}
 
@@ -302,59 +414,121 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 				timeout = userTimeout
 			}
 		}
-		blockCtx, cancel := context.WithTimeout(ctx, timeout)
-		defer cancel()
+		checkEnabled := options != nil && options.EnableBlockChecker && e.blockChecker != nil && ShouldCheckBlock(block)
+		maxCheckRetries := 0
+		if checkEnabled && options.CheckerMaxRetries > 0 {
+			maxCheckRetries = min(options.CheckerMaxRetries, MaxCheckerRetries)
+		}
 
-		// Execute the block
-		output, execErr := executor.Execute(blockCtx, block, blockInputs)
-		if execErr != nil {
-			handleBlockError(blockID, block.Name, execErr, blockStates, &statesMu, statusChan, &executionErrors, &errorsMu)
-			completedMu.Lock()
-			failedBlocks[blockID] = true
-			completedMu.Unlock()
-			return
+		// Checker outcome for this block, persisted with the block state
+		var checkOutcome *models.BlockCheckerOutcome
+		recordCheckOutcome := func() {
+			if checkOutcome != nil {
+				statesMu.Lock()
+				blockStates[blockID].Checker = checkOutcome
+				statesMu.Unlock()
+			}
 		}
 
-		// Block Completion Check: Validate if block actually accomplished its job
-		// This catches cases where a block "completed" but didn't actually succeed
-		// (e.g., repeated tool errors, timeouts, empty responses)
-		if options != nil && options.EnableBlockChecker && e.blockChecker != nil && ShouldCheckBlock(block) {
-			log.Printf("🔍 [ENGINE] Running block completion check for '%s'", block.Name)
+		// Cacheable blocks reuse the output of an earlier run with the same inputs
+		var output map[string]any
+		cacheTTL, cacheable := blockCacheTTL(block)
+		cacheable = cacheable && e.blockCache != nil
+		var cacheKey string
+		var cacheHit bool
+		if cacheable {
+			cacheKey, cacheable = blockCacheKey(workflow, block, blockInputs)
+		}
+		if cacheable {
+			if output, cacheHit = e.blockCache.Get(ctx, cacheKey); cacheHit {
+				log.Printf("💾 [ENGINE] Block '%s' served from cache", block.Name)
+			}
+		}
 
-			checkerModelID := options.CheckerModelID
-			if checkerModelID == "" {
-				// Default to a fast model for checking
-				checkerModelID = "gpt-4.1"
+		// A cache hit skips execution and the completion check
+		for attempt := 0; !cacheHit; attempt++ {
+			// Each attempt gets the full block timeout
+			blockCtx, cancel := context.WithTimeout(ctx, timeout)
+			blockCtx, progress := withProgressReporter(blockCtx, blockID, statusChan)
+
+			// Execute the block
+			output, execErr = executor.Execute(blockCtx, block, blockInputs)
+			progress.stop()
+			cancel()
+			if execErr != nil {
+				recordCheckOutcome()
+				failBlock(blockID, block, execErr)
+				return
 			}
 
-			checkResult, checkErr := e.blockChecker.CheckBlockCompletion(
+			// Block Completion Check: Validate if block actually accomplished its job
+			// This catches cases where a block "completed" but didn't actually succeed
+			// (e.g., repeated tool errors, timeouts, empty responses)
+			if !checkEnabled {
+				break
+			}
+			log.Printf("🔍 [ENGINE] Running block completion check for '%s'", block.Name)
+
+			checkResult, checkErr := e.blockChecker.CheckBlockCompletionWithPool(
 				ctx,
 				options.WorkflowGoal,
 				block,
-				blockInputs,
-				output,
-				checkerModelID,
+				secrets.redactMap(blockInputs),
+				secrets.redactMap(output),
+				options.CheckerModelID,
+				e.checkerModelPool,
 			)
 
+			if checkOutcome == nil {
+				checkOutcome = &models.BlockCheckerOutcome{}
+			}
+			checkOutcome.Checks++
+
 			if checkErr != nil {
 				log.Printf("⚠️ [ENGINE] Block checker error (continuing): %v", checkErr)
-			} else if !checkResult.Passed {
-				// Block failed the completion check - treat as failure
-				log.Printf("❌ [ENGINE] Block '%s' failed completion check: %s\n   Actual Output: %s", block.Name, checkResult.Reason, checkResult.ActualOutput)
-
-				// Add check failure info to output for visibility
-				output["_blockCheckFailed"] = true
-				output["_blockCheckReason"] = checkResult.Reason
-				output["_blockActualOutput"] = checkResult.ActualOutput
-
-				checkError := fmt.Errorf("block did not accomplish its job: %s\n\nActual Output: %s", checkResult.Reason, checkResult.ActualOutput)
-				handleBlockError(blockID, block.Name, checkError, blockStates, &statesMu, statusChan, &executionErrors, &errorsMu)
-				completedMu.Lock()
-				failedBlocks[blockID] = true
-				completedMu.Unlock()
-				return
-			} else {
+				checkOutcome.Status = models.CheckerStatusError
+				checkOutcome.Reason = secrets.redactString(checkErr.Error())
+				break
+			}
+
+			checkOutcome.Reason = secrets.redactString(checkResult.Reason)
+			if checkResult.Passed {
 				log.Printf("✓ [ENGINE] Block '%s' passed completion check: %s", block.Name, checkResult.Reason)
+				checkOutcome.Status = models.CheckerStatusPassed
+				break
+			}
+			checkOutcome.Status = models.CheckerStatusFailed
+
+			// Re-run the block with the checker's reason as feedback
+			if attempt < maxCheckRetries {
+				checkOutcome.Retries++
+				log.Printf("🔄 [ENGINE] Block '%s' failed completion check, retrying (%d/%d): %s",
+					block.Name, attempt+1, maxCheckRetries, checkResult.Reason)
+				blockInputs["_retryAttempt"] = attempt + 1
+				blockInputs["_retryReason"] = BlockCheckRetryPrefix + checkResult.Reason
+				continue
+			}
+
+			// Block failed the completion check - treat as failure
+			log.Printf("❌ [ENGINE] Block '%s' failed completion check: %s\n   Actual Output: %s", block.Name, checkResult.Reason, checkResult.ActualOutput)
+
+			// Add check failure info to output for visibility
+			output["_blockCheckFailed"] = true
+			output["_blockCheckReason"] = checkResult.Reason
+			output["_blockActualOutput"] = checkResult.ActualOutput
+
+			recordCheckOutcome()
+			checkError := fmt.Errorf("block did not accomplish its job: %s\n\nActual Output: %s", checkResult.Reason, checkResult.ActualOutput)
+			failBlock(blockID, block, checkError)
+			return
+		}
+		delete(blockInputs, "_retryAttempt")
+		delete(blockInputs, "_retryReason")
+		recordCheckOutcome()
+
+		if cacheable && !cacheHit && !containsSecrets(secrets, output) {
+			if err := e.blockCache.Set(ctx, cacheKey, output, cacheTTL); err != nil {
+				log.Printf("⚠️ [ENGINE] Failed to cache output of block '%s': %v", block.Name, err)
 			}
 		}
 
@@ -363,21 +537,27 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 		blockOutputs[blockID] = output
 		blockStates[blockID].Status = "completed"
 		blockStates[blockID].CompletedAt = timePtr(time.Now())
-		blockStates[blockID].Outputs = output
+		blockStates[blockID].Outputs = secrets.redactMap(output)
+		blockStates[blockID].CacheHit = cacheHit
 		statesMu.Unlock()
 
 		// Send completion update with inputs for debugging
-		statusChan <- models.ExecutionUpdate{
+		statusChan <- secrets.redactUpdate(models.ExecutionUpdate{
 			Type:    "execution_update",
 			BlockID: blockID,
 			Status:  "completed",
 			Inputs:  blockInputs,
 			Output:  output,
-		}
+		})
 
 		log.Printf("✅ [ENGINE] Block '%s' completed", block.Name)
 
-		// Mark as completed and check dependents
+		scheduleDependents(blockID)
+	}
+
+	// scheduleDependents marks a block completed and starts the dependents whose
+	// dependencies have now all completed
+	scheduleDependents = func(blockID string) {
 		completedMu.Lock()
 		completedBlocks[blockID] = true
 
@@ -423,18 +603,22 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 	// Determine final status
 	finalStatus := "completed"
 	var failedBlockIDs []string
-	var completedCount, failedCount int
+	var completedCount, failedCount, continuedCount int
 
-	statesMu.RLock()
+	statesMu.Lock()
 	for blockID, state := range blockStates {
+		// Secrets resolved by later blocks may already be in earlier blocks' state
+		secrets.redactBlockState(state)
 		if state.Status == "completed" {
 			completedCount++
+		} else if state.Status == "failed" && state.ContinuedOnError {
+			continuedCount++
 		} else if state.Status == "failed" {
 			failedCount++
 			failedBlockIDs = append(failedBlockIDs, blockID)
 		}
 	}
-	statesMu.RUnlock()
+	statesMu.Unlock()
 
 	if failedCount > 0 {
 		if completedCount > 0 {
@@ -442,6 +626,8 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 		} else {
 			finalStatus = "failed"
 		}
+	} else if continuedCount > 0 {
+		finalStatus = "completed_with_errors"
 	}
 
 	// Collect final output from terminal blocks (blocks with no dependents)
@@ -451,7 +637,7 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 		if len(deps) == 0 {
 			if output, ok := blockOutputs[blockID]; ok {
 				block := blockIndex[blockID]
-				finalOutput[block.Name] = output
+				finalOutput[block.Name] = secrets.redactMap(output)
 			}
 		}
 	}
@@ -465,8 +651,8 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 	}
 	errorsMu.Unlock()
 
-	log.Printf("🏁 [ENGINE] Workflow execution %s: %d completed, %d failed",
-		finalStatus, completedCount, failedCount)
+	log.Printf("🏁 [ENGINE] Workflow execution %s: %d completed, %d failed, %d continued on error",
+		finalStatus, completedCount, failedCount, continuedCount)
 
 	return &ExecutionResult{
 		Status:      finalStatus,
@@ -476,6 +662,16 @@ func (e *WorkflowEngine) ExecuteWithOptions(
 	}, nil
 }
 
+// blockContinuesOnError reports whether a block is best-effort: its failure is
+// recorded but does not stop its dependents
+func blockContinuesOnError(block models.Block) bool {
+	if v, ok := block.Config["continue_on_error"].(bool); ok {
+		return v
+	}
+	v, _ := block.Config["continueOnError"].(bool)
+	return v
+}
+
 // handleBlockError handles block execution errors with classification for debugging
 func handleBlockError(
 	blockID, blockName string,
@@ -485,6 +681,7 @@ func handleBlockError(
 	statusChan chan<- models.ExecutionUpdate,
 	executionErrors *[]string,
 	errorsMu *sync.Mutex,
+	secrets *secretSet,
 ) {
 	// Try to extract error classification for better debugging
 	var errorType string
@@ -500,26 +697,28 @@ func handleBlockError(
 		log.Printf("❌ [ENGINE] Block '%s' failed: %v", blockName, err)
 	}
 
+	errMsg := secrets.redactString(err.Error())
+
 	statesMu.Lock()
 	blockStates[blockID].Status = "failed"
 	blockStates[blockID].CompletedAt = timePtr(time.Now())
-	blockStates[blockID].Error = err.Error()
+	blockStates[blockID].Error = errMsg
 	statesMu.Unlock()
 
 	// Include error classification in status update for frontend visibility
-	statusChan <- models.ExecutionUpdate{
+	statusChan <- secrets.redactUpdate(models.ExecutionUpdate{
 		Type:    "execution_update",
 		BlockID: blockID,
 		Status:  "failed",
-		Error:   err.Error(),
+		Error:   errMsg,
 		Output: map[string]any{
 			"errorType": errorType,
 			"retryable": retryable,
 		},
-	}
+	})
 
 	errorsMu.Lock()
-	*executionErrors = append(*executionErrors, fmt.Sprintf("%s: %s", blockName, err.Error()))
+	*executionErrors = append(*executionErrors, fmt.Sprintf("%s: %s", blockName, errMsg))
 	errorsMu.Unlock()
 }
 
@@ -645,6 +844,7 @@ func (e *WorkflowEngine) BuildAPIResponse(
 	}
 
 	// Set metadata
+	response.Metadata.CheckerSummary = buildCheckerSummary(result.BlockStates, blockIndex)
 	response.Metadata.TotalTokens = totalTokens
 	response.Metadata.BlocksExecuted = blocksExecuted
 	response.Metadata.BlocksFailed = blocksFailed
@@ -661,6 +861,38 @@ func (e *WorkflowEngine) BuildAPIResponse(
 	return response
 }
 
+// buildCheckerSummary aggregates block checker outcomes, or returns nil if the checker never ran
+func buildCheckerSummary(states map[string]*models.BlockState, blockIndex map[string]models.Block) *models.CheckerSummary {
+	summary := &models.CheckerSummary{Blocks: []models.CheckerBlockSummary{}}
+	for blockID, state := range states {
+		if state == nil || state.Checker == nil {
+			continue
+		}
+
+		summary.BlocksChecked++
+		summary.TotalRetries += state.Checker.Retries
+		switch state.Checker.Status {
+		case models.CheckerStatusPassed:
+			summary.BlocksPassed++
+		case models.CheckerStatusFailed:
+			summary.BlocksFailed++
+		}
+		summary.Blocks = append(summary.Blocks, models.CheckerBlockSummary{
+			BlockID:             blockID,
+			Name:                blockIndex[blockID].Name,
+			BlockCheckerOutcome: *state.Checker,
+		})
+	}
+	if summary.BlocksChecked == 0 {
+		return nil
+	}
+
+	sort.Slice(summary.Blocks, func(i, j int) bool {
+		return summary.Blocks[i].Name < summary.Blocks[j].Name
+	})
+	return summary
+}
+
 // extractPrimaryResultAndData gets the main text result AND structured data from the workflow output
 // For structured output blocks, the "data" field contains the parsed JSON which we return separately
 func extractPrimaryResultAndData(output map[string]any, blockStates map[string]*models.BlockState) (string, any) {
@@ -745,6 +977,16 @@ func extractArtifactsFromBlockOutput(outputs map[string]any, blockName string) [
 	return artifacts
 }
 
+// hasFileURL reports whether files already contains a download URL
+func hasFileURL(files []models.APIFile, url string) bool {
+	for _, f := range files {
+		if f.DownloadURL == url {
+			return true
+		}
+	}
+	return false
+}
+
 // extractFilesFromBlockOutput extracts generated files from a block's output
 func extractFilesFromBlockOutput(outputs map[string]any, blockName string) []models.APIFile {
 	var files []models.APIFile
@@ -752,6 +994,20 @@ func extractFilesFromBlockOutput(outputs map[string]any, blockName string) []mod
 	// Check for generatedFiles array
 	if rawFiles, ok := outputs["generatedFiles"]; ok {
 		switch fs := rawFiles.(type) {
+		case []GeneratedFile:
+			// In-process outputs (not round-tripped through JSON)
+			for _, f := range fs {
+				if f.FileID != "" || f.DownloadURL != "" {
+					files = append(files, models.APIFile{
+						FileID:      f.FileID,
+						Filename:    f.Filename,
+						DownloadURL: f.DownloadURL,
+						MimeType:    f.MimeType,
+						Size:        f.Size,
+						SourceBlock: blockName,
+					})
+				}
+			}
 		case []any:
 			for _, f := range fs {
 				if fileMap, ok := f.(map[string]any); ok {
@@ -781,8 +1037,8 @@ func extractFilesFromBlockOutput(outputs map[string]any, blockName string) []mod
 		}
 	}
 
-	// Also check for single file reference
-	if fileURL, ok := outputs["file_url"].(string); ok && fileURL != "" {
+	// Also check for single file reference (usually a duplicate of the first generated file)
+	if fileURL, ok := outputs["file_url"].(string); ok && fileURL != "" && !hasFileURL(files, fileURL) {
 		file := models.APIFile{
 			DownloadURL: fileURL,
 			SourceBlock: blockName,
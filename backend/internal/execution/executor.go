@@ -13,23 +13,30 @@ type BlockExecutor interface {
 	Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error)
 }
 
+// BlockValidator is implemented by executors that can reject a block's configuration
+// before the workflow runs (e.g. a pinned model that is not available)
+type BlockValidator interface {
+	ValidateBlock(block models.Block) error
+}
+
 // ExecutorRegistry maps block types to executors
 type ExecutorRegistry struct {
 	executors map[string]BlockExecutor
 }
 
 // NewExecutorRegistry creates a new executor registry with all block type executors
-// Hybrid Architecture: Supports variable, llm_inference, and code_block types.
+// Hybrid Architecture: Supports variable, llm_inference, code_block, and loop types.
 // - variable: Input/output data handling
 // - llm_inference: AI reasoning with tool access
 // - code_block: Direct tool execution (no LLM, faster & deterministic)
+// - loop: Runs one of the other types once per element of an array
 func NewExecutorRegistry(
 	chatService *services.ChatService,
 	providerService *services.ProviderService,
 	toolRegistry *tools.Registry,
 	credentialService *services.CredentialService,
 ) *ExecutorRegistry {
-	return &ExecutorRegistry{
+	registry := &ExecutorRegistry{
 		executors: map[string]BlockExecutor{
 			// Variable blocks handle input/output data
 			"variable": NewVariableExecutor(),
@@ -41,6 +48,9 @@ func NewExecutorRegistry(
 			"code_block": NewToolExecutor(toolRegistry, credentialService),
 		},
 	}
+	// Loop blocks run another block type once per array element
+	registry.Register("loop", NewLoopExecutor(registry))
+	return registry
 }
 
 // Get retrieves an executor for a block type
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"regexp"
 	"strings"
 )
@@ -46,6 +47,9 @@ type FormatOutput struct {
 	Error string
 }
 
+// formatMaxAttempts is how many times FormatToSchema asks for output that validates
+const formatMaxAttempts = 3
+
 // FormatToSchema formats the given input data into the specified JSON schema
 // This is a method on AgentBlockExecutor so it can reuse the existing LLM call infrastructure
 func (e *AgentBlockExecutor) FormatToSchema(
@@ -78,46 +82,57 @@ func (e *AgentBlockExecutor) FormatToSchema(
 		{"role": "user", "content": userPrompt},
 	}
 
-	// Make the LLM call with native structured output if supported
-	// Use existing callLLMWithRetryAndSchema for consistency
-	response, _, err := e.callLLMWithRetryAndSchema(ctx, provider, resolvedModelID, messages, nil, 0.1, nil, schema)
-	if err != nil {
-		return &FormatOutput{
-			Success: false,
-			Error:   fmt.Sprintf("LLM call failed: %v", err),
-			Model:   resolvedModelID,
-		}, nil
-	}
-
-	log.Printf("📐 [FORMAT-SCHEMA] LLM response received, length=%d chars", len(response.Content))
+	// Providers with native structured output get the schema as response_format;
+	// for the rest the prompt asks for the JSON shape, and output that fails
+	// validation is sent back with the error for another attempt
+	var tokens models.TokenUsage
+	var lastErr error
+	var lastContent string
+	for attempt := 1; attempt <= formatMaxAttempts; attempt++ {
+		response, _, err := e.callLLMWithRetryAndSchema(ctx, provider, resolvedModelID, messages, nil, 0.1, 0, nil, schema)
+		if err != nil {
+			return &FormatOutput{
+				Success: false,
+				Error:   fmt.Sprintf("LLM call failed: %v", err),
+				Model:   resolvedModelID,
+				Tokens:  tokens,
+			}, nil
+		}
+		tokens.Input += response.InputTokens
+		tokens.Output += response.OutputTokens
+		lastContent = response.Content
+
+		log.Printf("📐 [FORMAT-SCHEMA] LLM response received (attempt %d/%d), length=%d chars",
+			attempt, formatMaxAttempts, len(response.Content))
+
+		// Parse and validate the output
+		output, err := parseAndValidateSchema(response.Content, schema)
+		if err == nil {
+			log.Printf("✅ [FORMAT-SCHEMA] Successfully formatted data to schema")
+			return &FormatOutput{
+				Data:    output,
+				RawJSON: response.Content,
+				Model:   resolvedModelID,
+				Tokens:  tokens,
+				Success: true,
+			}, nil
+		}
 
-	// Parse and validate the output
-	output, err := parseAndValidateSchema(response.Content, schema)
-	if err != nil {
-		log.Printf("⚠️ [FORMAT-SCHEMA] Validation failed: %v", err)
-		return &FormatOutput{
-			Success: false,
-			Error:   fmt.Sprintf("validation failed: %v", err),
-			RawJSON: response.Content,
-			Model:   resolvedModelID,
-			Tokens: models.TokenUsage{
-				Input:  response.InputTokens,
-				Output: response.OutputTokens,
-			},
-		}, nil
+		log.Printf("⚠️ [FORMAT-SCHEMA] Validation failed (attempt %d/%d): %v", attempt, formatMaxAttempts, err)
+		lastErr = err
+		messages = append(messages,
+			map[string]any{"role": "assistant", "content": response.Content},
+			map[string]any{"role": "user", "content": fmt.Sprintf(
+				"That response does not match the required schema: %v\n\nRespond again with ONLY the corrected JSON object.", err)},
+		)
 	}
 
-	log.Printf("✅ [FORMAT-SCHEMA] Successfully formatted data to schema")
-
 	return &FormatOutput{
-		Data:    output,
-		RawJSON: response.Content,
+		Success: false,
+		Error:   fmt.Sprintf("validation failed after %d attempts: %v", formatMaxAttempts, lastErr),
+		RawJSON: lastContent,
 		Model:   resolvedModelID,
-		Tokens: models.TokenUsage{
-			Input:  response.InputTokens,
-			Output: response.OutputTokens,
-		},
-		Success: true,
+		Tokens:  tokens,
 	}, nil
 }
 
@@ -240,6 +255,9 @@ func parseAndValidateSchema(content string, schema *models.JSONSchema) (map[stri
 		if err := validateFieldType(val, propSchema.Type); err != nil {
 			return nil, fmt.Errorf("field %s: %w", propName, err)
 		}
+		if err := validateFieldEnum(val, propSchema.Enum); err != nil {
+			return nil, fmt.Errorf("field %s: %w", propName, err)
+		}
 	}
 
 	return output, nil
@@ -288,13 +306,24 @@ func validateFieldType(val any, expectedType string) error {
 		if _, ok := val.(string); !ok {
 			return fmt.Errorf("expected string, got %T", val)
 		}
-	case "number", "integer":
+	case "number":
 		switch val.(type) {
 		case float64, float32, int, int32, int64:
 			// OK
 		default:
 			return fmt.Errorf("expected number, got %T", val)
 		}
+	case "integer":
+		switch v := val.(type) {
+		case int, int32, int64:
+			// OK
+		case float64:
+			if v != math.Trunc(v) {
+				return fmt.Errorf("expected integer, got %v", v)
+			}
+		default:
+			return fmt.Errorf("expected integer, got %T", val)
+		}
 	case "boolean":
 		if _, ok := val.(bool); !ok {
 			return fmt.Errorf("expected boolean, got %T", val)
@@ -311,3 +340,17 @@ func validateFieldType(val any, expectedType string) error {
 
 	return nil
 }
+
+// validateFieldEnum checks that a string value is one of the schema's allowed values
+func validateFieldEnum(val any, enum []string) error {
+	str, ok := val.(string)
+	if !ok || len(enum) == 0 {
+		return nil
+	}
+	for _, allowed := range enum {
+		if str == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %s", str, strings.Join(enum, ", "))
+}
@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"strings"
+	"testing"
+
+	"claraverse/internal/models"
+)
+
+func sentimentSchema() *models.JSONSchema {
+	return &models.JSONSchema{
+		Type: "object",
+		Properties: map[string]*models.JSONSchema{
+			"sentiment": {Type: "string", Enum: []string{"positive", "negative", "neutral"}},
+			"score":     {Type: "number"},
+			"votes":     {Type: "integer"},
+		},
+		Required: []string{"sentiment", "score"},
+	}
+}
+
+func TestParseAndValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", `{"sentiment": "positive", "score": 0.9, "votes": 3}`, ""},
+		{"code block", "```json\n{\"sentiment\": \"neutral\", \"score\": 0}\n```", ""},
+		{"missing required", `{"sentiment": "positive"}`, "missing required field: score"},
+		{"wrong type", `{"sentiment": "positive", "score": "high"}`, "expected number"},
+		{"not in enum", `{"sentiment": "happy", "score": 1}`, `"happy" is not one of`},
+		{"fractional integer", `{"sentiment": "positive", "score": 1, "votes": 2.5}`, "expected integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := parseAndValidateSchema(tt.content, sentimentSchema())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if _, ok := output["sentiment"].(string); !ok {
+					t.Errorf("Expected a parsed sentiment, got %v", output)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResponseFormatSchema(t *testing.T) {
+	schema, ok := responseFormatSchema(map[string]any{
+		"type": "json_schema",
+		"json_schema": map[string]any{
+			"name": "sentiment",
+			"schema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"sentiment": map[string]any{"type": "string", "enum": []any{"positive", "negative"}},
+				},
+				"required": []any{"sentiment"},
+			},
+		},
+	})
+	if !ok {
+		t.Fatal("Expected a json_schema response format to have a schema")
+	}
+
+	parsed := (&AgentBlockExecutor{}).parseJSONSchema(schema)
+	if len(parsed.Required) != 1 || parsed.Required[0] != "sentiment" {
+		t.Errorf("Expected required [sentiment], got %v", parsed.Required)
+	}
+	if enum := parsed.Properties["sentiment"].Enum; len(enum) != 2 {
+		t.Errorf("Expected the enum to be parsed, got %v", enum)
+	}
+
+	if _, ok := responseFormatSchema(map[string]any{"type": "json_schema", "schema": map[string]any{"type": "object"}}); !ok {
+		t.Error("Expected a schema directly under the response format to be accepted")
+	}
+	if _, ok := responseFormatSchema(map[string]any{"type": "text"}); ok {
+		t.Error("Expected a text response format to have no schema")
+	}
+}
@@ -0,0 +1,219 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"claraverse/internal/models"
+	"claraverse/internal/services"
+	"claraverse/internal/tools"
+)
+
+const (
+	// DefaultHistoryWindow is how many prior runs are summarized when EnableHistory is set
+	DefaultHistoryWindow = 5
+	// MaxHistoryWindow caps the history window to keep prompts small
+	MaxHistoryWindow = 20
+
+	// historyFieldLimit truncates each input/output in the summary
+	historyFieldLimit = 500
+	// historyLoadTimeout bounds the history lookup so it never delays execution noticeably
+	historyLoadTimeout = 5 * time.Second
+)
+
+// historyWindow returns the number of prior runs to load for options
+func historyWindow(options *ExecutionOptions) int {
+	switch {
+	case options.HistoryWindow <= 0:
+		return DefaultHistoryWindow
+	case options.HistoryWindow > MaxHistoryWindow:
+		return MaxHistoryWindow
+	default:
+		return options.HistoryWindow
+	}
+}
+
+// loadExecutionHistory summarizes the agent's recent completed runs for userID.
+// Returns "" when history is disabled, unavailable or empty.
+func (e *WorkflowEngine) loadExecutionHistory(ctx context.Context, options *ExecutionOptions, userID string) string {
+	if options == nil || !options.EnableHistory || e.executionService == nil {
+		return ""
+	}
+	if options.AgentID == "" || userID == "" {
+		log.Printf("⚠️ [ENGINE] Execution history requested without agent/user, skipping")
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, historyLoadTimeout)
+	defer cancel()
+
+	result, err := e.executionService.ListByAgent(ctx, options.AgentID, userID, &services.ListExecutionsOptions{
+		Limit:  historyWindow(options),
+		Status: "completed",
+	})
+	if err != nil {
+		log.Printf("⚠️ [ENGINE] Failed to load execution history for agent %s: %v", options.AgentID, err)
+		return ""
+	}
+
+	log.Printf("📜 [ENGINE] Loaded %d prior executions for agent %s", len(result.Executions), options.AgentID)
+	return formatExecutionHistory(result.Executions)
+}
+
+// formatExecutionHistory renders prior runs oldest first as a short prompt section
+func formatExecutionHistory(records []services.ExecutionRecord) string {
+	if len(records) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		output := record.Result
+		if output == "" {
+			output = historyValue(record.Output)
+		}
+		builder.WriteString(fmt.Sprintf("### Run from %s\n", record.StartedAt.UTC().Format(time.RFC3339)))
+		builder.WriteString(fmt.Sprintf("- **Input:** %s\n", historyValue(record.Input)))
+		builder.WriteString(fmt.Sprintf("- **Output:** %s\n\n", truncateHistoryField(output)))
+	}
+	return builder.String()
+}
+
+// historyValue renders a stored input/output map without internal keys
+func historyValue(m map[string]any) string {
+	visible := make(map[string]any, len(m))
+	for k, v := range m {
+		if !strings.HasPrefix(k, "_") {
+			visible[k] = v
+		}
+	}
+	if len(visible) == 0 {
+		return "(none)"
+	}
+
+	// A single text value reads better unwrapped
+	if len(visible) == 1 {
+		for _, v := range visible {
+			if s, ok := v.(string); ok {
+				return truncateHistoryField(s)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(visible))
+	for k := range visible {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		encoded, err := json.Marshal(visible[k])
+		if err != nil {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", k, encoded))
+	}
+	return truncateHistoryField(strings.Join(parts, ", "))
+}
+
+func truncateHistoryField(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "(none)"
+	}
+	if len(s) <= historyFieldLimit {
+		return s
+	}
+	cut := historyFieldLimit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "... [truncated]"
+}
+
+// registerWorkflowCredentials resolves the credentials the workflow's blocks can use
+// and registers them as secret for the execution in ctx. Blocks resolve credentials
+// only as their tools run, so without this the set is still empty when history is
+// redacted.
+func (e *WorkflowEngine) registerWorkflowCredentials(ctx context.Context, workflow *models.Workflow, userID string) {
+	if e.credentialService == nil || userID == "" {
+		return
+	}
+	resolver := secretRecordingResolver(ctx, e.credentialService.CreateCredentialResolver(userID))
+
+	credentialIDs, integrationTypes := workflowCredentialRefs(workflow)
+	// Tools without a configured credential auto-discover one of their integration type
+	for _, integrationType := range integrationTypes {
+		credentials, err := e.credentialService.ListByUserAndType(ctx, userID, integrationType)
+		if err != nil {
+			log.Printf("⚠️ [ENGINE] Failed to list %s credentials for history redaction: %v", integrationType, err)
+			continue
+		}
+		for _, cred := range credentials {
+			credentialIDs = append(credentialIDs, cred.ID)
+		}
+	}
+
+	for _, credentialID := range credentialIDs {
+		if _, err := resolver(credentialID); err != nil {
+			log.Printf("⚠️ [ENGINE] Failed to resolve credential %s for history redaction: %v", credentialID, err)
+		}
+	}
+}
+
+// workflowCredentialRefs returns the credential IDs configured on the workflow's blocks
+// (including loop inner blocks) and the integration types of the tools they use
+func workflowCredentialRefs(workflow *models.Workflow) (credentialIDs []string, integrationTypes []string) {
+	seenIDs := make(map[string]bool)
+	seenTypes := make(map[string]bool)
+	addTool := func(name string) {
+		if integrationType := tools.GetIntegrationTypeForTool(name); integrationType != "" && !seenTypes[integrationType] {
+			seenTypes[integrationType] = true
+			integrationTypes = append(integrationTypes, integrationType)
+		}
+	}
+
+	list := func(config map[string]any, key string) []string {
+		if raw, exists := config[key]; exists && raw != nil {
+			return parseToolsList(raw)
+		}
+		return nil
+	}
+
+	var visit func(config map[string]any)
+	visit = func(config map[string]any) {
+		if config == nil {
+			return
+		}
+		for _, id := range list(config, "credentials") {
+			if !seenIDs[id] {
+				seenIDs[id] = true
+				credentialIDs = append(credentialIDs, id)
+			}
+		}
+		for _, key := range []string{"enabledTools", "enabled_tools"} {
+			for _, name := range list(config, key) {
+				addTool(name)
+			}
+		}
+		if name := getString(config, "toolName", ""); name != "" {
+			addTool(name)
+		}
+		visit(getMap(getMap(config, "block"), "config"))
+	}
+
+	if workflow == nil {
+		return nil, nil
+	}
+	for _, block := range workflow.Blocks {
+		visit(block.Config)
+	}
+	return credentialIDs, integrationTypes
+}
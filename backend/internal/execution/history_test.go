@@ -0,0 +1,82 @@
+package execution
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+	"claraverse/internal/services"
+)
+
+func TestFormatExecutionHistory(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	records := []services.ExecutionRecord{
+		{
+			StartedAt: now,
+			Input:     map[string]any{"input": "second question", "__user_id__": "user-1"},
+			Result:    "second answer",
+		},
+		{
+			StartedAt: now.Add(-time.Hour),
+			Input:     map[string]any{"city": "Paris", "days": 3},
+			Output:    map[string]any{"response": strings.Repeat("é", 400)},
+		},
+	}
+
+	summary := formatExecutionHistory(records)
+
+	first := strings.Index(summary, "2026-01-02T14:04:05Z")
+	second := strings.Index(summary, "2026-01-02T15:04:05Z")
+	if first < 0 || second < 0 || first > second {
+		t.Fatalf("expected runs oldest first, got:\n%s", summary)
+	}
+	if strings.Contains(summary, "user-1") {
+		t.Error("expected internal input keys to be omitted")
+	}
+	if !strings.Contains(summary, "**Input:** second question") || !strings.Contains(summary, "**Output:** second answer") {
+		t.Errorf("expected single text values unwrapped, got:\n%s", summary)
+	}
+	if !strings.Contains(summary, `city="Paris", days=3`) {
+		t.Errorf("expected structured input as sorted key=value pairs, got:\n%s", summary)
+	}
+	if !strings.Contains(summary, "é... [truncated]") {
+		t.Errorf("expected long output truncated on a rune boundary, got:\n%s", summary)
+	}
+	if formatExecutionHistory(nil) != "" {
+		t.Error("expected no summary without prior runs")
+	}
+}
+
+func TestHistoryWindow(t *testing.T) {
+	cases := map[int]int{0: DefaultHistoryWindow, -1: DefaultHistoryWindow, 3: 3, 100: MaxHistoryWindow}
+	for window, want := range cases {
+		if got := historyWindow(&ExecutionOptions{HistoryWindow: window}); got != want {
+			t.Errorf("historyWindow(%d) = %d, want %d", window, got, want)
+		}
+	}
+}
+
+func TestWorkflowCredentialRefs(t *testing.T) {
+	workflow := &models.Workflow{
+		Blocks: []models.Block{
+			{Type: "llm_inference", Config: map[string]any{
+				"enabledTools": []any{"send_slack_message", "search_web"},
+				"credentials":  []any{"cred-1"},
+			}},
+			{Type: "code_block", Config: map[string]any{"toolName": "send_email", "credentials": []any{"cred-1"}}},
+			{Type: "loop", Config: map[string]any{"block": map[string]any{
+				"type":   "llm_inference",
+				"config": map[string]any{"enabledTools": []any{"send_slack_message"}, "credentials": []any{"cred-2"}},
+			}}},
+		},
+	}
+
+	ids, integrationTypes := workflowCredentialRefs(workflow)
+	if strings.Join(ids, ",") != "cred-1,cred-2" {
+		t.Errorf("expected configured credentials cred-1,cred-2, got %v", ids)
+	}
+	if strings.Join(integrationTypes, ",") != "slack,sendgrid" {
+		t.Errorf("expected integration types slack,sendgrid, got %v", integrationTypes)
+	}
+}
@@ -3,6 +3,7 @@ package execution
 import (
 	"bytes"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"claraverse/internal/services"
 	"context"
 	"encoding/json"
@@ -27,9 +28,7 @@ func NewLLMExecutor(chatService *services.ChatService, providerService *services
 	return &LLMExecutor{
 		chatService:     chatService,
 		providerService: providerService,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
+		httpClient:      providerhttp.NewClient(120 * time.Second),
 	}
 }
 
@@ -0,0 +1,256 @@
+package execution
+
+import (
+	"claraverse/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLoopConcurrency keeps downstream APIs safe when a loop sets no concurrency
+	defaultLoopConcurrency = 3
+	// maxLoopConcurrency caps the worker pool regardless of config
+	maxLoopConcurrency = 20
+	// defaultLoopItemTimeout bounds each element's run when itemTimeout is not set
+	defaultLoopItemTimeout = 60 * time.Second
+)
+
+// LoopExecutor executes loop blocks: it runs an inner block once per element of an
+// array, on a bounded worker pool.
+//
+// Config:
+//   - items: the array, as a path or {{template}} into the inputs, or a literal array
+//   - block: the inner block, {"type": ..., "config": ...}; it sees the loop's inputs
+//     plus {{item}} and {{index}}
+//   - concurrency: how many elements run at once (default 3, max 20)
+//   - itemTimeout: seconds each element may run (default 60)
+//   - failFast: stop at the first failed element instead of collecting errors
+//
+// Results keep the input order whatever order elements finish in. The whole loop is
+// still bounded by the block's own timeout.
+type LoopExecutor struct {
+	registry *ExecutorRegistry
+}
+
+// NewLoopExecutor creates a loop executor that runs inner blocks from registry
+func NewLoopExecutor(registry *ExecutorRegistry) *LoopExecutor {
+	return &LoopExecutor{registry: registry}
+}
+
+// ValidateBlock validates the inner block with its executor
+func (e *LoopExecutor) ValidateBlock(block models.Block) error {
+	inner := getMap(block.Config, "block")
+	innerType := getString(inner, "type", "")
+	executor, err := e.registry.Get(innerType)
+	if err != nil {
+		return nil // Reported when the loop runs
+	}
+	validator, ok := executor.(BlockValidator)
+	if !ok {
+		return nil
+	}
+	return validator.ValidateBlock(models.Block{ID: block.ID, Name: block.Name, Type: innerType, Config: getMap(inner, "config")})
+}
+
+// loopItemError records why one element failed
+type loopItemError struct {
+	Index int
+	Error string
+}
+
+// Execute runs a loop block
+func (e *LoopExecutor) Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error) {
+	config := block.Config
+
+	items, err := loopItems(config["items"], inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	inner := getMap(config, "block")
+	innerType := getString(inner, "type", "")
+	if innerType == "" {
+		return nil, fmt.Errorf("block.type is required for loop block")
+	}
+	if innerType == "loop" {
+		return nil, fmt.Errorf("loop blocks cannot be nested")
+	}
+	executor, err := e.registry.Get(innerType)
+	if err != nil {
+		return nil, err
+	}
+	innerConfig := getMap(inner, "config")
+	if innerConfig == nil {
+		innerConfig = map[string]any{}
+	}
+
+	concurrency := int(getFloat(config, "concurrency", defaultLoopConcurrency))
+	if concurrency < 1 {
+		concurrency = defaultLoopConcurrency
+	}
+	concurrency = min(concurrency, maxLoopConcurrency, max(len(items), 1))
+
+	itemTimeout := defaultLoopItemTimeout
+	if seconds := getFloat(config, "itemTimeout", 0); seconds > 0 {
+		itemTimeout = time.Duration(seconds * float64(time.Second))
+	}
+	failFast, _ := config["failFast"].(bool)
+
+	log.Printf("🔁 [LOOP-EXEC] Block '%s': running %s over %d items (concurrency %d)",
+		block.Name, innerType, len(items), concurrency)
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]any, len(items))
+	var (
+		mu        sync.Mutex
+		errs      []loopItemError
+		firstErr  *loopItemError // the failure that stopped a failFast loop
+		completed int
+	)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				output, err := e.runItem(loopCtx, executor, block, innerType, innerConfig, inputs, items[i], i, itemTimeout)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, loopItemError{Index: i, Error: err.Error()})
+					if failFast && firstErr == nil {
+						itemErr := errs[len(errs)-1]
+						firstErr = &itemErr
+						cancel()
+					}
+				} else {
+					results[i] = output
+				}
+				completed++
+				done := completed
+				mu.Unlock()
+
+				ReportProgress(ctx, fmt.Sprintf("Processed %d/%d items", done, len(items)),
+					float64(done)*100/float64(len(items)))
+			}
+		}()
+	}
+
+feed:
+	for i := range items {
+		select {
+		case indexes <- i:
+		case <-loopCtx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("loop block interrupted after %d/%d items: %w", completed, len(items), err)
+	}
+
+	if failFast && firstErr != nil {
+		return nil, fmt.Errorf("item %d failed: %s", firstErr.Index, firstErr.Error)
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Index < errs[j].Index })
+	if len(items) > 0 && len(errs) == len(items) {
+		return nil, fmt.Errorf("all %d items failed; first error (item %d): %s", len(items), errs[0].Index, errs[0].Error)
+	}
+
+	log.Printf("✅ [LOOP-EXEC] Block '%s': %d succeeded, %d failed", block.Name, len(items)-len(errs), len(errs))
+
+	errorList := make([]any, len(errs))
+	for i, itemErr := range errs {
+		errorList[i] = map[string]any{"index": itemErr.Index, "error": itemErr.Error}
+	}
+
+	return map[string]any{
+		"output": results,
+		"data": map[string]any{
+			"results":   results,
+			"errors":    errorList,
+			"count":     len(items),
+			"succeeded": len(items) - len(errs),
+			"failed":    len(errs),
+		},
+	}, nil
+}
+
+// runItem runs the inner block for one element under its own timeout
+func (e *LoopExecutor) runItem(
+	ctx context.Context,
+	executor BlockExecutor,
+	block models.Block,
+	innerType string,
+	innerConfig map[string]any,
+	inputs map[string]any,
+	item any,
+	index int,
+	timeout time.Duration,
+) (output map[string]any, err error) {
+	// A panicking element must not take down the other workers
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	itemInputs := make(map[string]any, len(inputs)+2)
+	for k, v := range inputs {
+		itemInputs[k] = v
+	}
+	itemInputs["item"] = item
+	itemInputs["index"] = index
+
+	innerBlock := models.Block{
+		ID:           fmt.Sprintf("%s[%d]", block.ID, index),
+		NormalizedID: block.NormalizedID,
+		Type:         innerType,
+		Name:         fmt.Sprintf("%s [%d]", block.Name, index),
+		Config:       innerConfig,
+	}
+
+	itemCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err = executor.Execute(itemCtx, innerBlock, itemInputs)
+	if err != nil && itemCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	return output, err
+}
+
+// loopItems resolves the loop's items config to an array
+func loopItems(raw any, inputs map[string]any) ([]any, error) {
+	switch v := raw.(type) {
+	case []any:
+		return v, nil
+	case string:
+		path := strings.TrimSpace(v)
+		path = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(path, "{{"), "}}"))
+		if path == "" {
+			break
+		}
+		resolved := resolvePath(inputs, path)
+		if resolved == nil {
+			return nil, fmt.Errorf("loop items %q not found in inputs", path)
+		}
+		items, ok := resolved.([]any)
+		if !ok {
+			return nil, fmt.Errorf("loop items %q is %T, not an array", path, resolved)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("items is required for loop block")
+}
@@ -0,0 +1,143 @@
+package execution
+
+import (
+	"claraverse/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// itemExecutor doubles its {{item}} after a delay that shrinks with the index, so
+// later items finish first. It fails on negative items, stalls on zero, and records
+// how many items ran at once.
+type itemExecutor struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (e *itemExecutor) Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error) {
+	e.mu.Lock()
+	e.running++
+	e.peak = max(e.peak, e.running)
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.running--
+		e.mu.Unlock()
+	}()
+
+	item := inputs["item"].(float64)
+	if item == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if item < 0 {
+		return nil, fmt.Errorf("negative item %v", item)
+	}
+	time.Sleep(time.Duration(20-inputs["index"].(int)) * time.Millisecond)
+	return map[string]any{"response": item * 2}, nil
+}
+
+func newLoopTestExecutor(inner BlockExecutor) *LoopExecutor {
+	registry := &ExecutorRegistry{executors: map[string]BlockExecutor{"double": inner}}
+	return NewLoopExecutor(registry)
+}
+
+func loopBlock(config map[string]any) models.Block {
+	config["block"] = map[string]any{"type": "double"}
+	return models.Block{ID: "loop", Name: "Loop", Type: "loop", Config: config}
+}
+
+func TestLoopPreservesOrderAndLimitsConcurrency(t *testing.T) {
+	inner := &itemExecutor{}
+	loop := newLoopTestExecutor(inner)
+
+	items := make([]any, 10)
+	for i := range items {
+		items[i] = float64(i + 1)
+	}
+	output, err := loop.Execute(context.Background(),
+		loopBlock(map[string]any{"items": "{{search.data}}", "concurrency": float64(3)}),
+		map[string]any{"search": map[string]any{"data": items}})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	results := output["output"].([]any)
+	for i, result := range results {
+		if got := result.(map[string]any)["response"]; got != float64(2*(i+1)) {
+			t.Errorf("Result %d = %v, want %v", i, got, 2*(i+1))
+		}
+	}
+	if inner.peak > 3 {
+		t.Errorf("Expected at most 3 items at once, got %d", inner.peak)
+	}
+}
+
+func TestLoopCollectsItemErrors(t *testing.T) {
+	loop := newLoopTestExecutor(&itemExecutor{})
+
+	output, err := loop.Execute(context.Background(), loopBlock(map[string]any{
+		"items":       []any{float64(1), float64(-1), float64(0), float64(4)},
+		"itemTimeout": 0.05,
+	}), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	data := output["data"].(map[string]any)
+	if data["succeeded"] != 2 || data["failed"] != 2 {
+		t.Fatalf("Expected 2 succeeded and 2 failed, got %v", data)
+	}
+	errs := data["errors"].([]any)
+	first, second := errs[0].(map[string]any), errs[1].(map[string]any)
+	if first["index"] != 1 || !strings.Contains(first["error"].(string), "negative item") {
+		t.Errorf("Expected item 1's error first, got %v", first)
+	}
+	if second["index"] != 2 || !strings.Contains(second["error"].(string), "timed out") {
+		t.Errorf("Expected item 2 to time out, got %v", second)
+	}
+	if results := output["output"].([]any); results[1] != nil || results[3] == nil {
+		t.Errorf("Expected failed items to have nil results, got %v", results)
+	}
+}
+
+func TestLoopFailFast(t *testing.T) {
+	loop := newLoopTestExecutor(&itemExecutor{})
+
+	_, err := loop.Execute(context.Background(), loopBlock(map[string]any{
+		"items":    []any{float64(1), float64(-3), float64(2)},
+		"failFast": true,
+	}), map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "item 1 failed") {
+		t.Errorf("Expected item 1's failure to fail the loop, got %v", err)
+	}
+}
+
+func TestLoopConfigErrors(t *testing.T) {
+	loop := newLoopTestExecutor(&itemExecutor{})
+
+	tests := []struct {
+		name    string
+		block   models.Block
+		wantErr string
+	}{
+		{"missing items", loopBlock(map[string]any{}), "items is required"},
+		{"items not an array", loopBlock(map[string]any{"items": "query"}), "not an array"},
+		{"nested loop", models.Block{Config: map[string]any{"items": []any{}, "block": map[string]any{"type": "loop"}}}, "cannot be nested"},
+		{"unknown type", models.Block{Config: map[string]any{"items": []any{}, "block": map[string]any{"type": "nope"}}}, "no executor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loop.Execute(context.Background(), tt.block, map[string]any{"query": "text"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
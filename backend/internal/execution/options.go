@@ -0,0 +1,34 @@
+package execution
+
+import "claraverse/internal/models"
+
+// ApplyDefaults sets the options' per-run settings from layers of defaults, most
+// specific first (the request, then the agent). Each setting comes from the first
+// layer that sets it; settings no layer sets keep the system default. Nil layers
+// are skipped.
+func (o *ExecutionOptions) ApplyDefaults(layers ...*models.ExecutionDefaults) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		if layer == nil {
+			continue
+		}
+		if layer.EnableBlockChecker != nil {
+			o.EnableBlockChecker = *layer.EnableBlockChecker
+		}
+		if layer.CheckerModelID != "" {
+			o.CheckerModelID = layer.CheckerModelID
+		}
+		if layer.CheckerMaxRetries != nil {
+			o.CheckerMaxRetries = *layer.CheckerMaxRetries
+		}
+		if layer.EnableHistory != nil {
+			o.EnableHistory = *layer.EnableHistory
+		}
+		if layer.HistoryWindow != nil {
+			o.HistoryWindow = *layer.HistoryWindow
+		}
+		if layer.UnavailableTools != "" {
+			o.UnavailableTools = layer.UnavailableTools
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package execution
+
+import (
+	"testing"
+
+	"claraverse/internal/models"
+)
+
+func TestApplyDefaultsPrecedence(t *testing.T) {
+	enabled, disabled := true, false
+	agentRetries, agentWindow := 2, 10
+
+	agent := &models.ExecutionDefaults{
+		EnableBlockChecker: &enabled,
+		CheckerModelID:     "agent-model",
+		CheckerMaxRetries:  &agentRetries,
+		HistoryWindow:      &agentWindow,
+	}
+	request := &models.ExecutionDefaults{
+		EnableBlockChecker: &disabled,
+		CheckerModelID:     "request-model",
+	}
+
+	options := &ExecutionOptions{}
+	options.ApplyDefaults(request, agent)
+
+	if options.EnableBlockChecker {
+		t.Error("Expected the request to turn the checker off over the agent default")
+	}
+	if options.CheckerModelID != "request-model" {
+		t.Errorf("Expected the request's checker model, got %q", options.CheckerModelID)
+	}
+	if options.CheckerMaxRetries != 2 || options.HistoryWindow != 10 {
+		t.Errorf("Expected the agent's retries and window, got %d and %d", options.CheckerMaxRetries, options.HistoryWindow)
+	}
+	if options.EnableHistory {
+		t.Error("Expected history to keep the system default")
+	}
+}
+
+func TestApplyDefaultsSkipsNilLayers(t *testing.T) {
+	enabled := true
+	options := &ExecutionOptions{}
+	options.ApplyDefaults(nil, &models.ExecutionDefaults{EnableBlockChecker: &enabled})
+
+	if !options.EnableBlockChecker {
+		t.Error("Expected the agent default to apply when the request sets nothing")
+	}
+}
+
+func TestApplyDefaultsUnavailableTools(t *testing.T) {
+	options := &ExecutionOptions{}
+	options.ApplyDefaults(&models.ExecutionDefaults{}, &models.ExecutionDefaults{UnavailableTools: models.UnavailableToolsMark})
+
+	if options.UnavailableTools != models.UnavailableToolsMark {
+		t.Errorf("Expected the agent's unavailable tools handling, got %q", options.UnavailableTools)
+	}
+}
@@ -0,0 +1,82 @@
+package execution
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+// progressMinInterval throttles progress updates per block so a tight loop
+// reporting every item does not flood the client. Completion (100%) is always sent.
+const progressMinInterval = 250 * time.Millisecond
+
+// progressReporterKey is the context key for the running block's progress reporter
+type progressReporterKey struct{}
+
+// blockProgressReporter forwards a block's progress to the execution's status channel
+// while the block runs. Updates are dropped rather than blocking the block.
+type blockProgressReporter struct {
+	mu         sync.Mutex
+	blockID    string
+	statusChan chan<- models.ExecutionUpdate
+	lastSent   time.Time
+	done       bool
+}
+
+// withProgressReporter returns a context whose blocks report progress for blockID
+func withProgressReporter(ctx context.Context, blockID string, statusChan chan<- models.ExecutionUpdate) (context.Context, *blockProgressReporter) {
+	reporter := &blockProgressReporter{blockID: blockID, statusChan: statusChan}
+	return context.WithValue(ctx, progressReporterKey{}, reporter), reporter
+}
+
+// ReportProgress sends a block_progress update for the block running in ctx.
+// percent is 0-100, or negative when the total amount of work is unknown.
+// It is a no-op outside a workflow execution, so executors may call it freely.
+func ReportProgress(ctx context.Context, message string, percent float64) {
+	reporter, ok := ctx.Value(progressReporterKey{}).(*blockProgressReporter)
+	if !ok || reporter == nil {
+		return
+	}
+	reporter.report(secretsFromContext(ctx), message, percent)
+}
+
+func (r *blockProgressReporter) report(secrets *secretSet, message string, percent float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The status channel is closed once the workflow finishes; stop before that
+	if r.done {
+		return
+	}
+	now := time.Now()
+	if percent < 100 && now.Sub(r.lastSent) < progressMinInterval {
+		return
+	}
+
+	update := models.ExecutionUpdate{
+		Type:    "block_progress",
+		BlockID: r.blockID,
+		Status:  "running",
+		Message: message,
+	}
+	if percent >= 0 {
+		p := math.Min(percent, 100)
+		update.Progress = &p
+	}
+
+	select {
+	case r.statusChan <- secrets.redactUpdate(update):
+		r.lastSent = now
+	default:
+	}
+}
+
+// stop ends forwarding; called when the block's executor returns
+func (r *blockProgressReporter) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = true
+}
@@ -0,0 +1,180 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+)
+
+// RedactedValue replaces credential values in stored and streamed execution data
+const RedactedValue = "***"
+
+// secretSetKey is the context key for the per-execution secret set
+type secretSetKey struct{}
+
+// secretSet collects credential values resolved during one top-level execution.
+// Nested executions share their parent's set, like the execution budget.
+type secretSet struct {
+	mu     sync.RWMutex
+	values map[string]struct{}
+	sorted []string // longest first, so overlapping secrets are fully replaced
+}
+
+// withSecretSet returns a context carrying a secret set, reusing the parent's if present
+func withSecretSet(ctx context.Context) (context.Context, *secretSet) {
+	if parent := secretsFromContext(ctx); parent != nil {
+		return ctx, parent
+	}
+	secrets := &secretSet{values: make(map[string]struct{})}
+	return context.WithValue(ctx, secretSetKey{}, secrets), secrets
+}
+
+func secretsFromContext(ctx context.Context) *secretSet {
+	if ctx == nil {
+		return nil
+	}
+	secrets, _ := ctx.Value(secretSetKey{}).(*secretSet)
+	return secrets
+}
+
+// add registers values as secret, along with their JSON-escaped form so they are
+// also caught inside raw JSON strings
+func (s *secretSet) add(values []string) {
+	if s == nil || len(values) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range values {
+		variants := []string{v}
+		if encoded, err := json.Marshal(v); err == nil {
+			if escaped := string(encoded[1 : len(encoded)-1]); escaped != v {
+				variants = append(variants, escaped)
+			}
+		}
+		for _, variant := range variants {
+			if _, exists := s.values[variant]; !exists {
+				s.values[variant] = struct{}{}
+				s.sorted = append(s.sorted, variant)
+			}
+		}
+	}
+	sort.Slice(s.sorted, func(i, j int) bool {
+		return len(s.sorted[i]) > len(s.sorted[j])
+	})
+}
+
+func (s *secretSet) empty() bool {
+	if s == nil {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.sorted) == 0
+}
+
+// redactString replaces every known secret in str with RedactedValue
+func (s *secretSet) redactString(str string) string {
+	if s == nil {
+		return str
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, secret := range s.sorted {
+		if strings.Contains(str, secret) {
+			str = strings.ReplaceAll(str, secret, RedactedValue)
+		}
+	}
+	return str
+}
+
+// redactMap returns a copy of m with secrets redacted. The original map is left
+// untouched because downstream blocks still need the real values.
+func (s *secretSet) redactMap(m map[string]any) map[string]any {
+	if m == nil || s.empty() {
+		return m
+	}
+	redacted, _ := s.redactValue(m).(map[string]any)
+	return redacted
+}
+
+func (s *secretSet) redactValue(v any) any {
+	switch val := v.(type) {
+	case nil, bool, int, int64, float64:
+		return val
+	case string:
+		return s.redactString(val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, nested := range val {
+			out[k] = s.redactValue(nested)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, nested := range val {
+			out[i] = s.redactValue(nested)
+		}
+		return out
+	default:
+		// Structs and typed slices (tool call records, files): only rewrite them
+		// through JSON when they actually contain a secret
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return val
+		}
+		text := string(encoded)
+		if redactedText := s.redactString(text); redactedText != text {
+			var decoded any
+			if err := json.Unmarshal([]byte(redactedText), &decoded); err == nil {
+				return decoded
+			}
+			return RedactedValue
+		}
+		return val
+	}
+}
+
+// redactUpdate redacts the inputs, output, error and message of a streamed update.
+// Every update sent on an execution's status channel goes through it.
+func (s *secretSet) redactUpdate(update models.ExecutionUpdate) models.ExecutionUpdate {
+	if s.empty() {
+		return update
+	}
+	update.Inputs = s.redactMap(update.Inputs)
+	update.Output = s.redactMap(update.Output)
+	update.Error = s.redactString(update.Error)
+	update.Message = s.redactString(update.Message)
+	return update
+}
+
+// redactBlockState redacts everything persisted for a block
+func (s *secretSet) redactBlockState(state *models.BlockState) {
+	if state == nil || s.empty() {
+		return
+	}
+	state.Inputs = s.redactMap(state.Inputs)
+	state.Outputs = s.redactMap(state.Outputs)
+	state.Error = s.redactString(state.Error)
+}
+
+// secretRecordingResolver wraps a credential resolver so that every credential it
+// resolves is registered as secret for the execution running in ctx
+func secretRecordingResolver(ctx context.Context, resolver tools.CredentialResolver) tools.CredentialResolver {
+	secrets := secretsFromContext(ctx)
+	if resolver == nil || secrets == nil {
+		return resolver
+	}
+	return func(credentialID string) (*models.DecryptedCredential, error) {
+		cred, err := resolver(credentialID)
+		if err == nil {
+			secrets.add(cred.SecretValues())
+		}
+		return cred, err
+	}
+}
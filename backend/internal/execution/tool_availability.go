@@ -0,0 +1,88 @@
+package execution
+
+import (
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// unavailableTools returns the enabled tools of a block that cannot run for the user,
+// each with the reason. Only built-in tools run in workflow blocks: MCP tools run on
+// the user's MCP client, which blocks cannot call, and integration tools also need a
+// credential of their integration type.
+func (e *AgentBlockExecutor) unavailableTools(ctx context.Context, userID string, names []string) map[string]string {
+	unavailable := make(map[string]string)
+	var integrations map[string]bool
+
+	for _, name := range names {
+		if _, builtin := e.toolRegistry.Get(name); !builtin {
+			if tool, exists := e.toolRegistry.GetUserTool(userID, name); exists && tool.Source == tools.ToolSourceMCPLocal {
+				unavailable[name] = "MCP tools run on the MCP client and cannot be called from workflow blocks"
+			} else {
+				unavailable[name] = "tool is not available (if it is an MCP tool, no MCP client is connected)"
+			}
+			continue
+		}
+
+		integrationType := tools.GetIntegrationTypeForTool(name)
+		if integrationType == "" || e.credentialService == nil || userID == "" {
+			continue
+		}
+		if integrations == nil {
+			integrations = make(map[string]bool)
+			credentials, err := e.credentialService.ListByUser(ctx, userID)
+			if err != nil {
+				// Don't block the run on a lookup failure; the tool call reports it
+				log.Printf("⚠️ [AGENT-BLOCK] Failed to load credentials to check tools: %v", err)
+				return unavailable
+			}
+			for _, cred := range credentials {
+				integrations[cred.IntegrationType] = true
+			}
+		}
+		if !integrations[integrationType] {
+			unavailable[name] = fmt.Sprintf("no %s credential is configured", integrationType)
+		}
+	}
+	return unavailable
+}
+
+// describeUnavailableTools lists unavailable tools with their reasons, sorted by name
+func describeUnavailableTools(unavailable map[string]string) string {
+	names := make([]string, 0, len(unavailable))
+	for name := range unavailable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%s)", name, unavailable[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// withoutUnavailableTools drops unavailable tools from a block's enabled and required
+// tools, so the model is neither offered them nor retried for not calling them
+func withoutUnavailableTools(config models.AgentBlockConfig, unavailable map[string]string) models.AgentBlockConfig {
+	keep := func(names []string) []string {
+		var kept []string
+		for _, name := range names {
+			if _, missing := unavailable[name]; !missing {
+				kept = append(kept, name)
+			}
+		}
+		return kept
+	}
+	config.EnabledTools = keep(config.EnabledTools)
+	config.RequiredTools = keep(config.RequiredTools)
+	if len(config.EnabledTools) == 0 {
+		config.RequireToolUsage = false
+	}
+	config.UnavailableTools = unavailable
+	return config
+}
@@ -0,0 +1,68 @@
+package execution
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+)
+
+func TestUnavailableTools(t *testing.T) {
+	registry := tools.GetRegistry()
+	if err := registry.RegisterUserTool("availability-user", &tools.Tool{
+		Name:   "availability_mcp_tool",
+		Source: tools.ToolSourceMCPLocal,
+	}); err != nil {
+		t.Fatalf("RegisterUserTool failed: %v", err)
+	}
+	defer registry.UnregisterAllUserTools("availability-user")
+
+	executor := &AgentBlockExecutor{toolRegistry: registry}
+	unavailable := executor.unavailableTools(context.Background(), "availability-user",
+		[]string{"calculate_math", "availability_mcp_tool", "availability_missing_tool"})
+
+	if _, ok := unavailable["calculate_math"]; ok {
+		t.Error("Expected a built-in tool to be available")
+	}
+	if !strings.Contains(unavailable["availability_mcp_tool"], "MCP client") {
+		t.Errorf("Expected the MCP tool to be unavailable, got %q", unavailable["availability_mcp_tool"])
+	}
+	if !strings.Contains(unavailable["availability_missing_tool"], "no MCP client is connected") {
+		t.Errorf("Expected the unknown tool to be unavailable, got %q", unavailable["availability_missing_tool"])
+	}
+}
+
+func TestWithoutUnavailableTools(t *testing.T) {
+	config := models.AgentBlockConfig{
+		EnabledTools:     []string{"calculate_math", "mcp_tool"},
+		RequiredTools:    []string{"mcp_tool"},
+		RequireToolUsage: true,
+	}
+	unavailable := map[string]string{"mcp_tool": "not connected"}
+
+	config = withoutUnavailableTools(config, unavailable)
+
+	if len(config.EnabledTools) != 1 || config.EnabledTools[0] != "calculate_math" {
+		t.Errorf("Expected only the available tool to stay enabled, got %v", config.EnabledTools)
+	}
+	if len(config.RequiredTools) != 0 {
+		t.Errorf("Expected the unavailable tool to no longer be required, got %v", config.RequiredTools)
+	}
+	if !config.RequireToolUsage {
+		t.Error("Expected tool usage to stay required while a tool is available")
+	}
+
+	config = withoutUnavailableTools(config, map[string]string{"calculate_math": "no credential"})
+	if config.RequireToolUsage {
+		t.Error("Expected tool usage not to be required once no tool is available")
+	}
+}
+
+func TestDescribeUnavailableTools(t *testing.T) {
+	got := describeUnavailableTools(map[string]string{"b_tool": "reason b", "a_tool": "reason a"})
+	if got != "a_tool (reason a), b_tool (reason b)" {
+		t.Errorf("Unexpected description: %q", got)
+	}
+}
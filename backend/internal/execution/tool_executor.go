@@ -123,12 +123,20 @@ func (e *ToolExecutor) Execute(ctx context.Context, block models.Block, inputs m
 
 	log.Printf("🔧 [TOOL-EXEC] Tool '%s' args: %v", toolName, args)
 
+	// Enforce the execution-wide tool call budget
+	if err := consumeToolCall(ctx); err != nil {
+		log.Printf("🛑 [TOOL-EXEC] Tool '%s' rejected: %v", toolName, err)
+		return nil, err
+	}
+	args[tools.ExecutionContextKey] = ctx
+
 	// Execute tool
 	result, err := tool.Execute(args)
 
 	// Clean up internal keys from args (don't log them)
 	delete(args, tools.CredentialResolverKey)
 	delete(args, tools.UserIDKey)
+	delete(args, tools.ExecutionContextKey)
 
 	if err != nil {
 		log.Printf("❌ [TOOL-EXEC] Tool '%s' failed: %v", toolName, err)
@@ -137,6 +145,10 @@ func (e *ToolExecutor) Execute(ctx context.Context, block models.Block, inputs m
 
 	log.Printf("✅ [TOOL-EXEC] Tool '%s' completed, result_len=%d", toolName, len(result))
 
+	// Store inline file blobs and collect file references (see ToolResultFilesKey)
+	result = storeToolResultFiles(userID, toolName, result)
+	generatedFiles := parseToolResultFiles(result)
+
 	// Try to parse result as JSON for structured output
 	var parsedResult any
 	if err := json.Unmarshal([]byte(result), &parsedResult); err != nil {
@@ -145,11 +157,12 @@ func (e *ToolExecutor) Execute(ctx context.Context, block models.Block, inputs m
 	}
 
 	return map[string]any{
-		"response": parsedResult, // Primary output key for consistency with other blocks
-		"result":   parsedResult, // Kept for backwards compatibility
-		"data":     parsedResult, // For structured data access
-		"toolName": toolName,
-		"raw":      result,
+		"response":       parsedResult, // Primary output key for consistency with other blocks
+		"result":         parsedResult, // Kept for backwards compatibility
+		"data":           parsedResult, // For structured data access
+		"toolName":       toolName,
+		"raw":            result,
+		"generatedFiles": generatedFiles,
 	}, nil
 }
 
@@ -161,7 +174,8 @@ func (e *ToolExecutor) injectCredentials(ctx context.Context, toolName string, a
 
 	// Inject credential resolver for tools that need authentication
 	// Cast to tools.CredentialResolver type for proper type assertion in credential_helper.go
-	resolver := tools.CredentialResolver(e.credentialService.CreateCredentialResolver(userID))
+	// Resolved credential values are recorded so the engine can redact them
+	resolver := secretRecordingResolver(ctx, e.credentialService.CreateCredentialResolver(userID))
 	args[tools.CredentialResolverKey] = resolver
 	args[tools.UserIDKey] = userID
 
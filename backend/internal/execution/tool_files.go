@@ -0,0 +1,189 @@
+package execution
+
+import (
+	"claraverse/internal/securefile"
+	"claraverse/internal/services"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Tool result file contract
+//
+// A tool (built-in or MCP) attaches files by returning a JSON object result:
+//
+//   - Reference: a file already in the secure file service, described by top-level
+//     "file_id" / "download_url" fields (plus optional "filename", "mime_type",
+//     "size", "access_code"), or by objects with the same fields in a "files" array.
+//   - Inline blob: an object in the "files" array carrying base64 content under the
+//     MCP binary key, e.g. {"filename": "report.pdf", "mime_type": "application/pdf",
+//     "__b64__": "..."}. A result that is itself one wrapped binary value (as MCP
+//     image/audio results are) is treated as a single blob.
+//
+// Blobs are stored in the secure file service for the executing user (subject to
+// services.MCPMaxBinarySize) and replaced by references before the LLM sees the result,
+// so downstream blocks get a download URL instead of raw base64. Every referenced file
+// ends up in the block's generatedFiles and in ExecutionAPIResponse.Files.
+const ToolResultFilesKey = "files"
+
+// storeToolResultFiles persists inline blobs in a tool result and returns the result
+// rewritten to reference the stored files. Results without blobs are returned unchanged.
+func storeToolResultFiles(userID, toolName, result string) string {
+	trimmed := strings.TrimSpace(result)
+	if !strings.HasPrefix(trimmed, "{") || !strings.Contains(trimmed, services.MCPBinaryKey) {
+		return result
+	}
+
+	var parsed map[string]any
+	if err := json.Unmarshal([]byte(trimmed), &parsed); err != nil {
+		return result
+	}
+
+	// Whole result is a single wrapped binary value
+	if _, isBlob := parsed[services.MCPBinaryKey]; isBlob {
+		ref, err := storeToolFileBlob(userID, toolName, parsed, 0)
+		if err != nil {
+			log.Printf("⚠️ [TOOL-FILES] Could not store file from %s: %v", toolName, err)
+			return result
+		}
+		return marshalToolResult(map[string]any{
+			"success":          true,
+			ToolResultFilesKey: []any{ref},
+			"file_id":          ref["file_id"],
+			"filename":         ref["filename"],
+			"download_url":     ref["download_url"],
+			"mime_type":        ref["mime_type"],
+			"size":             ref["size"],
+			"message":          fmt.Sprintf("File '%s' saved. Download link: %s", ref["filename"], ref["download_url"]),
+		}, result)
+	}
+
+	files, ok := parsed[ToolResultFilesKey].([]any)
+	if !ok {
+		return result
+	}
+
+	changed := false
+	for i, f := range files {
+		fileMap, ok := f.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, isBlob := fileMap[services.MCPBinaryKey]; !isBlob {
+			continue
+		}
+		ref, err := storeToolFileBlob(userID, toolName, fileMap, i)
+		if err != nil {
+			log.Printf("⚠️ [TOOL-FILES] Could not store file %d from %s: %v", i, toolName, err)
+			files[i] = map[string]any{"filename": fileMap["filename"], "error": err.Error()}
+		} else {
+			files[i] = ref
+		}
+		changed = true
+	}
+	if !changed {
+		return result
+	}
+	parsed[ToolResultFilesKey] = files
+	return marshalToolResult(parsed, result)
+}
+
+// storeToolFileBlob decodes one inline blob and stores it, returning its reference
+func storeToolFileBlob(userID, toolName string, blob map[string]any, index int) (map[string]any, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("no user context to store the file for")
+	}
+
+	data, mimeType, _, err := services.DecodeMCPBinary(blob)
+	if err != nil {
+		return nil, err
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	filename, _ := blob["filename"].(string)
+	if filename == "" {
+		filename = fmt.Sprintf("%s_output_%d", toolName, index+1)
+	}
+
+	stored, err := securefile.GetService().CreateFile(userID, data, filename, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📎 [TOOL-FILES] Stored %s from tool %s (%d bytes)", stored.Filename, toolName, stored.Size)
+	return map[string]any{
+		"file_id":      stored.ID,
+		"filename":     stored.Filename,
+		"download_url": stored.DownloadURL,
+		"access_code":  stored.AccessCode,
+		"mime_type":    stored.MimeType,
+		"size":         stored.Size,
+	}, nil
+}
+
+func marshalToolResult(v map[string]any, fallback string) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fallback
+	}
+	return string(encoded)
+}
+
+// parseToolResultFiles returns the file references in a tool result
+func parseToolResultFiles(result string) []GeneratedFile {
+	var resultData map[string]any
+	if err := json.Unmarshal([]byte(result), &resultData); err != nil {
+		return nil
+	}
+
+	var files []GeneratedFile
+	seen := make(map[string]bool)
+	add := func(fileMap map[string]any) {
+		file := generatedFileFromMap(fileMap)
+		if file.FileID == "" && file.DownloadURL == "" {
+			return
+		}
+		key := file.FileID + "|" + file.DownloadURL
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		files = append(files, file)
+	}
+
+	add(resultData)
+	if list, ok := resultData[ToolResultFilesKey].([]any); ok {
+		for _, f := range list {
+			if fileMap, ok := f.(map[string]any); ok {
+				add(fileMap)
+			}
+		}
+	}
+	return files
+}
+
+func generatedFileFromMap(fileMap map[string]any) GeneratedFile {
+	file := GeneratedFile{}
+	if v, ok := fileMap["file_id"].(string); ok && v != "" {
+		file.FileID = v
+	}
+	if v, ok := fileMap["filename"].(string); ok && v != "" {
+		file.Filename = v
+	}
+	if v, ok := fileMap["download_url"].(string); ok && v != "" {
+		file.DownloadURL = v
+	}
+	if v, ok := fileMap["access_code"].(string); ok && v != "" {
+		file.AccessCode = v
+	}
+	if v, ok := fileMap["size"].(float64); ok {
+		file.Size = int64(v)
+	}
+	if v, ok := fileMap["mime_type"].(string); ok && v != "" {
+		file.MimeType = v
+	}
+	return file
+}
@@ -0,0 +1,48 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"claraverse/internal/mcptest"
+	"claraverse/internal/models"
+	"claraverse/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// TestMCPImageResultBecomesExecutionFile tests that an image returned by an MCP client
+// reaches the execution as a stored file rather than a placeholder or raw base64
+func TestMCPImageResultBecomesExecutionFile(t *testing.T) {
+	service := mcptest.NewService(t)
+	service.SetMaxResultBytes(256)
+	userID := "tool-files-user-" + uuid.New().String()
+	client := mcptest.Connect(t, service, userID, models.MCPTool{Name: "screenshot"})
+
+	image := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 1024)
+	client.Handle(func(call mcptest.ToolCall) (string, error) {
+		wrapped, err := services.EncodeMCPBinary(image, "image/png")
+		if err != nil {
+			return "", err
+		}
+		encoded, err := json.Marshal(wrapped)
+		return string(encoded), err
+	})
+
+	result, err := service.ExecuteToolOnClient(context.Background(), userID, "screenshot", map[string]interface{}{}, time.Second)
+	if err != nil {
+		t.Fatalf("ExecuteToolOnClient failed: %v", err)
+	}
+
+	result = storeToolResultFiles(userID, "screenshot", result)
+	files := parseToolResultFiles(result)
+	if len(files) != 1 {
+		t.Fatalf("Expected one generated file, got %v from %q", files, result)
+	}
+	if files[0].FileID == "" || files[0].DownloadURL == "" || files[0].MimeType != "image/png" || files[0].Size != int64(len(image)) {
+		t.Errorf("Unexpected generated file: %+v", files[0])
+	}
+}
@@ -0,0 +1,158 @@
+package execution
+
+import (
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+	"log"
+	"sort"
+	"unicode"
+)
+
+// toolRelevanceStopwords are skipped when matching a block's goal against tools
+var toolRelevanceStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"from": true, "into": true, "your": true, "you": true, "are": true, "was": true,
+	"will": true, "use": true, "using": true, "then": true, "them": true, "all": true,
+	"any": true, "each": true, "its": true, "not": true, "but": true, "about": true,
+}
+
+// relevanceWords splits text into lowercase words of three or more letters or
+// digits, without stopwords. Snake and camel case names split into their parts.
+func relevanceWords(text string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) >= 3 {
+			if word := string(current); !toolRelevanceStopwords[word] {
+				words = append(words, word)
+			}
+		}
+		current = current[:0]
+	}
+
+	var prev rune
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			flush()
+			current = append(current, unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			current = append(current, unicode.ToLower(r))
+		default:
+			flush()
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
+
+// toolRelevanceScore rates how likely a tool is to be needed for a goal: a preferred
+// tag counts most, then goal words in the tool's name, keywords and tags, then goal
+// words in its description
+func toolRelevanceScore(tool *tools.Tool, goalWords map[string]bool, preferTags []string) int {
+	score := 0
+	if len(preferTags) > 0 && tool.HasTag(preferTags...) {
+		score += 10
+	}
+
+	strong := make(map[string]bool)
+	texts := []string{tool.Name, tool.DisplayName, tool.Category}
+	texts = append(texts, tool.Keywords...)
+	texts = append(texts, tool.Tags...)
+	for _, text := range texts {
+		for _, word := range relevanceWords(text) {
+			strong[word] = true
+		}
+	}
+	weak := make(map[string]bool)
+	for _, word := range relevanceWords(tool.Description) {
+		weak[word] = true
+	}
+
+	for word := range goalWords {
+		switch {
+		case strong[word]:
+			score += 3
+		case weak[word]:
+			score++
+		}
+	}
+	return score
+}
+
+// selectRelevantTools caps a block's tools at maxTools, keeping the ones most
+// relevant to goal. Required tools are always kept (even past the cap), ties keep
+// the configured order, and tools lookup does not know rank last.
+func selectRelevantTools(lookup func(name string) (*tools.Tool, bool), enabled []string, goal string, maxTools int, preferTags, required []string) (kept, dropped []string) {
+	if maxTools <= 0 || len(enabled) <= maxTools {
+		return enabled, nil
+	}
+
+	goalWords := make(map[string]bool)
+	for _, word := range relevanceWords(goal) {
+		goalWords[word] = true
+	}
+	requiredSet := make(map[string]bool, len(required))
+	for _, name := range required {
+		requiredSet[name] = true
+	}
+
+	type candidate struct {
+		name  string
+		score int
+	}
+	candidates := make([]candidate, 0, len(enabled))
+	for _, name := range enabled {
+		score := -1
+		if tool, ok := lookup(name); ok {
+			score = toolRelevanceScore(tool, goalWords, preferTags)
+		}
+		candidates = append(candidates, candidate{name: name, score: score})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, rj := requiredSet[candidates[i].name], requiredSet[candidates[j].name]
+		if ri != rj {
+			return ri
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	keep := make(map[string]bool, maxTools)
+	for i, c := range candidates {
+		if i < maxTools || requiredSet[c.name] {
+			keep[c.name] = true
+		}
+	}
+	// Report in the configured order
+	for _, name := range enabled {
+		if keep[name] {
+			kept = append(kept, name)
+		} else {
+			dropped = append(dropped, name)
+		}
+	}
+	return kept, dropped
+}
+
+// limitTools applies the block's maxTools cap, narrowing EnabledTools to the tools
+// most relevant to its prompts. The dropped tools are not offered to the model in
+// this run, and are left out of the prompt's tool list too.
+func (e *AgentBlockExecutor) limitTools(block models.Block, config models.AgentBlockConfig, inputs map[string]any) models.AgentBlockConfig {
+	if config.MaxTools <= 0 || len(config.EnabledTools) <= config.MaxTools {
+		return config
+	}
+
+	userID, _ := inputs["__user_id__"].(string)
+	lookup := func(name string) (*tools.Tool, bool) { return e.toolRegistry.GetUserTool(userID, name) }
+	goal := interpolateTemplate(config.SystemPrompt, inputs) + "\n" + interpolateTemplate(config.UserPrompt, inputs)
+	kept, dropped := selectRelevantTools(lookup, config.EnabledTools, goal, config.MaxTools, config.ToolTags, config.RequiredTools)
+	if len(dropped) == 0 {
+		return config
+	}
+
+	log.Printf("🔧 [AGENT-BLOCK] Block '%s': offering %d of %d tools (maxTools=%d), not offered: %v",
+		block.Name, len(kept), len(config.EnabledTools), config.MaxTools, dropped)
+	config.EnabledTools = kept
+	return config
+}
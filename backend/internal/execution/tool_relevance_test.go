@@ -0,0 +1,62 @@
+package execution
+
+import (
+	"reflect"
+	"testing"
+
+	"claraverse/internal/tools"
+)
+
+func TestRelevanceWords(t *testing.T) {
+	got := relevanceWords("Send the sendSlackMessage to #general_channel, OK?")
+	want := []string{"send", "send", "slack", "message", "general", "channel"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relevanceWords = %v, want %v", got, want)
+	}
+}
+
+func TestSelectRelevantTools(t *testing.T) {
+	known := map[string]*tools.Tool{
+		"search_web":         {Name: "search_web", Description: "Search the internet for current information"},
+		"send_slack_message": {Name: "send_slack_message", Description: "Post a message to a channel"},
+		"calculate_math":     {Name: "calculate_math", Description: "Evaluate arithmetic expressions"},
+		"github_issue":       {Name: "github_issue", Description: "Create an issue", Tags: []string{"dev"}},
+	}
+	lookup := func(name string) (*tools.Tool, bool) {
+		tool, ok := known[name]
+		return tool, ok
+	}
+	enabled := []string{"unknown_tool", "calculate_math", "search_web", "send_slack_message", "github_issue"}
+
+	tests := []struct {
+		name        string
+		goal        string
+		maxTools    int
+		tags        []string
+		required    []string
+		wantKept    []string
+		wantDropped []string
+	}{
+		{"no cap", "anything", 0, nil, nil, enabled, nil},
+		{"under cap", "anything", 10, nil, nil, enabled, nil},
+		{"keyword match", "Search for today's news and post it to Slack", 2, nil, nil,
+			[]string{"search_web", "send_slack_message"}, []string{"unknown_tool", "calculate_math", "github_issue"}},
+		{"preferred tag", "Search the news", 2, []string{"dev"}, nil,
+			[]string{"search_web", "github_issue"}, []string{"unknown_tool", "calculate_math", "send_slack_message"}},
+		{"required first", "Search the news", 2, nil, []string{"calculate_math"},
+			[]string{"calculate_math", "search_web"}, []string{"unknown_tool", "send_slack_message", "github_issue"}},
+		{"required kept past cap", "Search the news", 1, nil, []string{"calculate_math", "github_issue"},
+			[]string{"calculate_math", "github_issue"}, []string{"unknown_tool", "search_web", "send_slack_message"}},
+		{"ties keep configured order, unknown last", "nothing relevant", 2, nil, nil,
+			[]string{"calculate_math", "search_web"}, []string{"unknown_tool", "send_slack_message", "github_issue"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := selectRelevantTools(lookup, enabled, tt.goal, tt.maxTools, tt.tags, tt.required)
+			if !reflect.DeepEqual(kept, tt.wantKept) || !reflect.DeepEqual(dropped, tt.wantDropped) {
+				t.Errorf("Got kept=%v dropped=%v, want kept=%v dropped=%v", kept, dropped, tt.wantKept, tt.wantDropped)
+			}
+		})
+	}
+}
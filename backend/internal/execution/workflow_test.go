@@ -2,7 +2,12 @@ package execution
 
 import (
 	"claraverse/internal/models"
+	"claraverse/internal/services"
+	"claraverse/internal/tools"
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -346,6 +351,173 @@ func TestWorkflowEngineBlockInputConstruction(t *testing.T) {
 	}
 }
 
+// TestCheckerModelPoolFailover tests that unhealthy checker models are skipped
+func TestCheckerModelPoolFailover(t *testing.T) {
+	pool := NewCheckerModelPool([]string{"model-a", "model-b", "model-a", ""})
+	if pool.Size() != 2 {
+		t.Fatalf("Expected 2 unique models, got %d", pool.Size())
+	}
+
+	for i := 0; i < services.MaxConsecutiveFailures; i++ {
+		pool.MarkFailure("model-a")
+	}
+
+	for i := 0; i < 4; i++ {
+		modelID, err := pool.GetNext()
+		if err != nil {
+			t.Fatalf("GetNext failed: %v", err)
+		}
+		if modelID != "model-b" {
+			t.Errorf("Expected healthy model-b, got %s", modelID)
+		}
+	}
+
+	pool.MarkFailure("model-b")
+	pool.MarkFailure("model-b")
+	pool.MarkFailure("model-b")
+	if modelID, _ := pool.GetNext(); modelID != "model-a" {
+		t.Errorf("Expected first model as last resort, got %s", modelID)
+	}
+
+	stats := pool.GetStats()
+	if stats["healthy_checkers"] != 0 {
+		t.Errorf("Expected 0 healthy checkers, got %v", stats["healthy_checkers"])
+	}
+}
+
+// TestBuildAPIResponseCheckerSummary tests that block checker outcomes are aggregated into metadata
+func TestBuildAPIResponseCheckerSummary(t *testing.T) {
+	workflow := &models.Workflow{Blocks: []models.Block{
+		{ID: "b1", Name: "Research", Type: "llm_inference"},
+		{ID: "b2", Name: "Write", Type: "llm_inference"},
+		{ID: "b3", Name: "Start", Type: "variable"},
+	}}
+	engine := NewWorkflowEngine(nil)
+
+	result := &ExecutionResult{Status: "failed", BlockStates: map[string]*models.BlockState{
+		"b1": {Status: "completed", Checker: &models.BlockCheckerOutcome{Status: models.CheckerStatusPassed, Checks: 2, Retries: 1}},
+		"b2": {Status: "failed", Checker: &models.BlockCheckerOutcome{Status: models.CheckerStatusFailed, Checks: 3, Retries: 2}},
+		"b3": {Status: "completed"},
+	}}
+	summary := engine.BuildAPIResponse(result, workflow, "exec-1", 10).Metadata.CheckerSummary
+	if summary == nil {
+		t.Fatal("Expected a checker summary")
+	}
+	if summary.BlocksChecked != 2 || summary.BlocksPassed != 1 || summary.BlocksFailed != 1 || summary.TotalRetries != 3 {
+		t.Errorf("Unexpected summary totals: %+v", summary)
+	}
+	if len(summary.Blocks) != 2 || summary.Blocks[0].Name != "Research" || summary.Blocks[1].Retries != 2 {
+		t.Errorf("Unexpected per-block summary: %+v", summary.Blocks)
+	}
+
+	unchecked := &ExecutionResult{Status: "completed", BlockStates: map[string]*models.BlockState{"b3": {Status: "completed"}}}
+	if engine.BuildAPIResponse(unchecked, workflow, "exec-2", 10).Metadata.CheckerSummary != nil {
+		t.Error("Expected no checker summary when the checker did not run")
+	}
+}
+
+// TestToolResultFiles tests the tool result file contract and its collection into API files
+func TestToolResultFiles(t *testing.T) {
+	result := `{"success": true, "file_id": "f1", "filename": "a.txt", "download_url": "http://x/api/files/f1?code=1",
+		"files": [{"file_id": "f1", "download_url": "http://x/api/files/f1?code=1"},
+			{"file_id": "f2", "filename": "b.png", "download_url": "http://x/api/files/f2?code=2", "mime_type": "image/png", "size": 12}]}`
+
+	// Results without inline blobs pass through untouched
+	if got := storeToolResultFiles("user-1", "tool", result); got != result {
+		t.Errorf("Expected result without blobs to be unchanged")
+	}
+
+	files := parseToolResultFiles(result)
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 unique files, got %d: %+v", len(files), files)
+	}
+	if files[1].FileID != "f2" || files[1].MimeType != "image/png" || files[1].Size != 12 {
+		t.Errorf("Unexpected second file: %+v", files[1])
+	}
+
+	apiFiles := extractFilesFromBlockOutput(map[string]any{
+		"generatedFiles": files,
+		"file_url":       files[0].DownloadURL,
+	}, "Block")
+	if len(apiFiles) != 2 {
+		t.Fatalf("Expected 2 API files (file_url deduplicated), got %d", len(apiFiles))
+	}
+	if apiFiles[0].SourceBlock != "Block" {
+		t.Errorf("Expected source block to be set, got %q", apiFiles[0].SourceBlock)
+	}
+}
+
+// secretEchoExecutor resolves a credential through the recording resolver and echoes it
+type secretEchoExecutor struct {
+	resolver tools.CredentialResolver
+	received any
+}
+
+func (e *secretEchoExecutor) Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error) {
+	if block.ID == "echo" {
+		e.received = inputs["response"]
+		return map[string]any{"response": inputs["response"]}, nil
+	}
+	cred, err := secretRecordingResolver(ctx, e.resolver)("cred-1")
+	if err != nil {
+		return nil, err
+	}
+	token := cred.Data["api_key"].(string)
+	ReportProgress(ctx, "calling API with key "+token, 100)
+	return map[string]any{
+		"response": "called API with key " + token,
+		"raw":      `{"headers":{"Authorization":"Bearer ` + token + `"}}`,
+	}, nil
+}
+
+// TestCredentialValuesRedactedFromExecutionRecord tests that resolved credentials never
+// reach block states, final output or streamed updates
+func TestCredentialValuesRedactedFromExecutionRecord(t *testing.T) {
+	const secret = "sk-live-0123456789abcdef"
+	executor := &secretEchoExecutor{resolver: func(credentialID string) (*models.DecryptedCredential, error) {
+		return &models.DecryptedCredential{ID: credentialID, Data: map[string]interface{}{"api_key": secret}}, nil
+	}}
+	engine := NewWorkflowEngine(&ExecutorRegistry{executors: map[string]BlockExecutor{"fake_tool": executor}})
+
+	workflow := &models.Workflow{
+		Blocks: []models.Block{
+			{ID: "call", Name: "Call", Type: "fake_tool"},
+			{ID: "echo", Name: "Echo", Type: "fake_tool"},
+		},
+		Connections: []models.Connection{{ID: "c1", SourceBlockID: "call", TargetBlockID: "echo"}},
+	}
+
+	statusChan := make(chan models.ExecutionUpdate, 32)
+	result, err := engine.Execute(context.Background(), workflow, map[string]any{}, statusChan)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	close(statusChan)
+
+	persisted, _ := json.Marshal(result)
+	if strings.Contains(string(persisted), secret) {
+		t.Errorf("Secret leaked into execution record: %s", persisted)
+	}
+	if !strings.Contains(string(persisted), "called API with key "+RedactedValue) {
+		t.Errorf("Expected redacted response in execution record: %s", persisted)
+	}
+	// Downstream blocks still receive the real value
+	if executor.received != "called API with key "+secret {
+		t.Errorf("Expected downstream block to receive the unredacted value, got %v", executor.received)
+	}
+	progressSeen := false
+	for update := range statusChan {
+		streamed, _ := json.Marshal(update)
+		if strings.Contains(string(streamed), secret) {
+			t.Errorf("Secret leaked into execution update: %s", streamed)
+		}
+		progressSeen = progressSeen || update.Type == "block_progress"
+	}
+	if !progressSeen {
+		t.Error("Expected the block's progress update to be streamed")
+	}
+}
+
 // Helper function for deep map comparison
 func mapsEqual(a, b map[string]any) bool {
 	if len(a) != len(b) {
@@ -388,3 +560,150 @@ func slicesEqual(a, b []any) bool {
 	}
 	return true
 }
+
+// progressExecutor reports progress for each item it processes
+type progressExecutor struct{ items int }
+
+func (e *progressExecutor) Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error) {
+	for i := 1; i <= e.items; i++ {
+		ReportProgress(ctx, fmt.Sprintf("Processed %d/%d", i, e.items), float64(i)*100/float64(e.items))
+	}
+	return map[string]any{"response": "done"}, nil
+}
+
+// TestBlockProgressUpdates tests that progress reported by a block is streamed as
+// throttled block_progress updates, always including completion
+func TestBlockProgressUpdates(t *testing.T) {
+	engine := NewWorkflowEngine(&ExecutorRegistry{executors: map[string]BlockExecutor{"batch": &progressExecutor{items: 50}}})
+	workflow := &models.Workflow{Blocks: []models.Block{{ID: "batch", Name: "Batch", Type: "batch"}}}
+
+	statusChan := make(chan models.ExecutionUpdate, 32)
+	if _, err := engine.Execute(context.Background(), workflow, map[string]any{}, statusChan); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	close(statusChan)
+
+	var progress []models.ExecutionUpdate
+	for update := range statusChan {
+		if update.Type == "block_progress" {
+			progress = append(progress, update)
+		}
+	}
+	// The first report is sent, the rest are throttled except the final 100%
+	if len(progress) != 2 {
+		t.Fatalf("Expected 2 progress updates, got %d: %+v", len(progress), progress)
+	}
+	last := progress[len(progress)-1]
+	if last.BlockID != "batch" || last.Message != "Processed 50/50" || last.Progress == nil || *last.Progress != 100 {
+		t.Errorf("Unexpected final progress update: %+v", last)
+	}
+
+	// Outside an execution reporting is a no-op
+	ReportProgress(context.Background(), "ignored", 50)
+}
+
+// flakyExecutor fails the "notify" block and echoes its {{response}} input otherwise
+type flakyExecutor struct{ received any }
+
+func (e *flakyExecutor) Execute(ctx context.Context, block models.Block, inputs map[string]any) (map[string]any, error) {
+	if block.ID == "notify" {
+		return nil, fmt.Errorf("notification service unavailable")
+	}
+	if block.ID == "summary" {
+		e.received = inputs["response"]
+	}
+	return map[string]any{"response": "done"}, nil
+}
+
+// TestContinueOnErrorBlock tests that a failed best-effort block lets its dependents
+// run and marks the execution completed_with_errors
+func TestContinueOnErrorBlock(t *testing.T) {
+	run := func(continueOnError bool) (*ExecutionResult, *flakyExecutor) {
+		t.Helper()
+		executor := &flakyExecutor{}
+		engine := NewWorkflowEngine(&ExecutorRegistry{executors: map[string]BlockExecutor{"step": executor}})
+		workflow := &models.Workflow{
+			Blocks: []models.Block{
+				{ID: "notify", Name: "Notify", Type: "step", Config: map[string]any{"continue_on_error": continueOnError}},
+				{ID: "summary", Name: "Summary", Type: "step"},
+			},
+			Connections: []models.Connection{{ID: "c1", SourceBlockID: "notify", TargetBlockID: "summary"}},
+		}
+		statusChan := make(chan models.ExecutionUpdate, 32)
+		result, err := engine.Execute(context.Background(), workflow, map[string]any{}, statusChan)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result, executor
+	}
+
+	result, executor := run(true)
+	if result.Status != "completed_with_errors" {
+		t.Errorf("Expected status completed_with_errors, got %s", result.Status)
+	}
+	if state := result.BlockStates["notify"]; state.Status != "failed" || !state.ContinuedOnError {
+		t.Errorf("Expected notify to be failed and continued on error, got %+v", state)
+	}
+	if result.BlockStates["summary"].Status != "completed" {
+		t.Errorf("Expected summary to run, got %s", result.BlockStates["summary"].Status)
+	}
+	if executor.received != "" {
+		t.Errorf("Expected an empty response from the failed block, got %v", executor.received)
+	}
+	if !strings.Contains(result.Error, "notification service unavailable") {
+		t.Errorf("Expected the failure in the execution error, got %q", result.Error)
+	}
+
+	result, _ = run(false)
+	if result.Status != "failed" || result.BlockStates["summary"].Status == "completed" {
+		t.Errorf("Expected the failure to stop the workflow without continue_on_error, got %s", result.Status)
+	}
+}
+
+// pinnedModelExecutor rejects blocks pinning a model other than "available"
+type pinnedModelExecutor struct {
+	countingExecutor
+}
+
+func (e *pinnedModelExecutor) ValidateBlock(block models.Block) error {
+	if modelID, _ := block.Config["model_id"].(string); modelID != "" && modelID != "available" {
+		return fmt.Errorf("block '%s' is configured with model_id '%s', but that model is not available", block.Name, modelID)
+	}
+	return nil
+}
+
+func TestUnavailablePinnedModelFailsBeforeAnyBlockRuns(t *testing.T) {
+	executor := &pinnedModelExecutor{}
+	registry := &ExecutorRegistry{executors: map[string]BlockExecutor{"llm": executor}}
+	registry.Register("loop", NewLoopExecutor(registry))
+	engine := NewWorkflowEngine(registry)
+
+	run := func(blocks ...models.Block) error {
+		t.Helper()
+		workflow := &models.Workflow{ID: "wf-1", Blocks: blocks}
+		for i := 1; i < len(blocks); i++ {
+			workflow.Connections = append(workflow.Connections, models.Connection{
+				ID: fmt.Sprintf("c%d", i), SourceBlockID: blocks[i-1].ID, TargetBlockID: blocks[i].ID,
+			})
+		}
+		_, err := engine.Execute(context.Background(), workflow, nil, make(chan models.ExecutionUpdate, 32))
+		return err
+	}
+
+	first := models.Block{ID: "first", Name: "First", Type: "llm", Config: map[string]any{"model_id": "available"}}
+	pinned := models.Block{ID: "pinned", Name: "Pinned", Type: "llm", Config: map[string]any{"model_id": "gone"}}
+	if err := run(first, pinned); err == nil || !strings.Contains(err.Error(), "model_id 'gone'") {
+		t.Fatalf("Expected the pinned model to be rejected, got %v", err)
+	}
+
+	loop := models.Block{ID: "loop", Name: "Loop", Type: "loop", Config: map[string]any{
+		"items": []any{1.0},
+		"block": map[string]any{"type": "llm", "config": map[string]any{"model_id": "gone"}},
+	}}
+	if err := run(first, loop); err == nil {
+		t.Fatal("Expected a model pinned inside a loop to be rejected")
+	}
+	if executor.runs != 0 {
+		t.Errorf("Expected no block to run, got %d runs", executor.runs)
+	}
+}
@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"claraverse/internal/services"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,6 +19,10 @@ type AdminHandler struct {
 	analyticsService *services.AnalyticsService
 	providerService  *services.ProviderService
 	modelService     *services.ModelService
+	activeExecutions *services.ActiveExecutionRegistry
+	executionService *services.ExecutionService
+	agentService     *services.AgentService
+	memoryModelPool  *services.MemoryModelPool
 }
 
 // NewAdminHandler creates a new admin handler
@@ -29,6 +36,26 @@ func NewAdminHandler(userService *services.UserService, tierService *services.Ti
 	}
 }
 
+// SetActiveExecutions sets the registry of running executions (for execution load stats)
+func (h *AdminHandler) SetActiveExecutions(registry *services.ActiveExecutionRegistry) {
+	h.activeExecutions = registry
+}
+
+// SetExecutionService sets the execution service (required for cancelling a user's executions)
+func (h *AdminHandler) SetExecutionService(executionService *services.ExecutionService) {
+	h.executionService = executionService
+}
+
+// SetAgentService sets the agent service (required for disabling agents)
+func (h *AdminHandler) SetAgentService(agentService *services.AgentService) {
+	h.agentService = agentService
+}
+
+// SetMemoryModelPool sets the memory model pool (required for resetting model health)
+func (h *AdminHandler) SetMemoryModelPool(pool *services.MemoryModelPool) {
+	h.memoryModelPool = pool
+}
+
 // GetUserDetails returns detailed user information (admin only)
 // GET /api/admin/users/:userID
 func (h *AdminHandler) GetUserDetails(c *fiber.Ctx) error {
@@ -231,6 +258,95 @@ func (h *AdminHandler) GetSystemStats(c *fiber.Ctx) error {
 	})
 }
 
+// GetActiveExecutions returns how many workflow executions are running and queued on this server
+// GET /api/admin/executions/active
+func (h *AdminHandler) GetActiveExecutions(c *fiber.Ctx) error {
+	if h.activeExecutions == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Execution tracking not available",
+		})
+	}
+
+	return c.JSON(h.activeExecutions.Load())
+}
+
+// CancelUserExecutions cancels every execution a user has running (admin only)
+// POST /api/admin/users/:userID/executions/cancel
+func (h *AdminHandler) CancelUserExecutions(c *fiber.Ctx) error {
+	targetUserID := c.Params("userID")
+	if targetUserID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "User ID is required",
+		})
+	}
+	if h.executionService == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Execution tracking not available",
+		})
+	}
+
+	cancelled, err := h.executionService.CancelUserExecutions(c.Context(), targetUserID)
+	if err != nil {
+		log.Printf("⚠️ [ADMIN] Failed to record some cancellations for user %s: %v", targetUserID, err)
+	}
+
+	adminID, _ := c.Locals("user_id").(string)
+	log.Printf("🛑 [ADMIN] %s cancelled %d execution(s) of user %s", adminID, len(cancelled), targetUserID)
+
+	return c.JSON(fiber.Map{
+		"user_id":         targetUserID,
+		"cancelled":       cancelled,
+		"cancelled_count": len(cancelled),
+	})
+}
+
+// DisableAgent turns on the kill switch of any user's agent (admin only)
+// POST /api/admin/agents/:agentID/disable
+func (h *AdminHandler) DisableAgent(c *fiber.Ctx) error {
+	return h.setAgentDisabled(c, true)
+}
+
+// EnableAgent turns off the kill switch of any user's agent (admin only)
+// POST /api/admin/agents/:agentID/enable
+func (h *AdminHandler) EnableAgent(c *fiber.Ctx) error {
+	return h.setAgentDisabled(c, false)
+}
+
+func (h *AdminHandler) setAgentDisabled(c *fiber.Ctx, disabled bool) error {
+	if h.agentService == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Agent service not available",
+		})
+	}
+
+	agentID := c.Params("agentID")
+	var req models.DisableAgentRequest
+	if disabled && len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	adminID := c.Locals("user_id").(string)
+	agent, err := h.agentService.SetAgentDisabled(agentID, disabled, req.Reason, adminID)
+	if err != nil {
+		if err.Error() == "agent not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Agent not found",
+			})
+		}
+		log.Printf("❌ [ADMIN] Failed to update kill switch of agent %s: %v", agentID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update agent",
+		})
+	}
+
+	log.Printf("🛑 [ADMIN] %s set disabled=%v on agent %s (owner %s)", adminID, disabled, agentID, agent.UserID)
+	return c.JSON(agent)
+}
+
 // GetAdminStatus returns admin status for the authenticated user
 // GET /api/admin/me
 func (h *AdminHandler) GetAdminStatus(c *fiber.Ctx) error {
@@ -422,17 +538,23 @@ func (h *AdminHandler) GetProviders(c *fiber.Ctx) error {
 // POST /api/admin/providers
 func (h *AdminHandler) CreateProvider(c *fiber.Ctx) error {
 	var req struct {
-		Name          string `json:"name"`
-		BaseURL       string `json:"base_url"`
-		APIKey        string `json:"api_key"`
-		Enabled       *bool  `json:"enabled"`
-		AudioOnly     *bool  `json:"audio_only"`
-		ImageOnly     *bool  `json:"image_only"`
-		ImageEditOnly *bool  `json:"image_edit_only"`
-		Secure        *bool  `json:"secure"`
-		DefaultModel  string `json:"default_model"`
-		SystemPrompt  string `json:"system_prompt"`
-		Favicon       string `json:"favicon"`
+		Name          string            `json:"name"`
+		BaseURL       string            `json:"base_url"`
+		APIKey        string            `json:"api_key"`
+		Enabled       *bool             `json:"enabled"`
+		AudioOnly     *bool             `json:"audio_only"`
+		ImageOnly     *bool             `json:"image_only"`
+		ImageEditOnly *bool             `json:"image_edit_only"`
+		Secure        *bool             `json:"secure"`
+		DefaultModel  string            `json:"default_model"`
+		SystemPrompt  string            `json:"system_prompt"`
+		Favicon       string            `json:"favicon"`
+		AuthStyle     string            `json:"auth_style"`
+		Headers       map[string]string `json:"headers"`
+		// UsesMaxCompletionTokens overrides the token limit parameter; omit to detect it from base_url
+		UsesMaxCompletionTokens *bool `json:"uses_max_completion_tokens"`
+		// Flavor is the provider's API dialect; omit to detect it from base_url
+		Flavor string `json:"flavor"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -447,20 +569,34 @@ func (h *AdminHandler) CreateProvider(c *fiber.Ctx) error {
 			"error": "Name, base_url, and api_key are required",
 		})
 	}
+	if !validAuthStyle(req.AuthStyle) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "auth_style must be \"bearer\" or \"api-key\"",
+		})
+	}
+	if !providerhttp.IsValidFlavor(req.Flavor) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": invalidFlavorMessage(),
+		})
+	}
 
 	// Build provider config
 	config := models.ProviderConfig{
-		Name:          req.Name,
-		BaseURL:       req.BaseURL,
-		APIKey:        req.APIKey,
-		Enabled:       req.Enabled != nil && *req.Enabled,
-		AudioOnly:     req.AudioOnly != nil && *req.AudioOnly,
-		ImageOnly:     req.ImageOnly != nil && *req.ImageOnly,
-		ImageEditOnly: req.ImageEditOnly != nil && *req.ImageEditOnly,
-		Secure:        req.Secure != nil && *req.Secure,
-		DefaultModel:  req.DefaultModel,
-		SystemPrompt:  req.SystemPrompt,
-		Favicon:       req.Favicon,
+		Name:                    req.Name,
+		BaseURL:                 req.BaseURL,
+		APIKey:                  req.APIKey,
+		Enabled:                 req.Enabled != nil && *req.Enabled,
+		AudioOnly:               req.AudioOnly != nil && *req.AudioOnly,
+		ImageOnly:               req.ImageOnly != nil && *req.ImageOnly,
+		ImageEditOnly:           req.ImageEditOnly != nil && *req.ImageEditOnly,
+		Secure:                  req.Secure != nil && *req.Secure,
+		DefaultModel:            req.DefaultModel,
+		SystemPrompt:            req.SystemPrompt,
+		Favicon:                 req.Favicon,
+		AuthStyle:               req.AuthStyle,
+		Headers:                 req.Headers,
+		UsesMaxCompletionTokens: req.UsesMaxCompletionTokens,
+		Flavor:                  req.Flavor,
 	}
 
 	provider, err := h.providerService.Create(config)
@@ -472,6 +608,7 @@ func (h *AdminHandler) CreateProvider(c *fiber.Ctx) error {
 	}
 
 	log.Printf("✅ [ADMIN] Created provider: %s (ID %d)", provider.Name, provider.ID)
+	h.reloadProviders()
 	return c.Status(fiber.StatusCreated).JSON(provider)
 }
 
@@ -486,17 +623,23 @@ func (h *AdminHandler) UpdateProvider(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Name          *string `json:"name"`
-		BaseURL       *string `json:"base_url"`
-		APIKey        *string `json:"api_key"`
-		Enabled       *bool   `json:"enabled"`
-		AudioOnly     *bool   `json:"audio_only"`
-		ImageOnly     *bool   `json:"image_only"`
-		ImageEditOnly *bool   `json:"image_edit_only"`
-		Secure        *bool   `json:"secure"`
-		DefaultModel  *string `json:"default_model"`
-		SystemPrompt  *string `json:"system_prompt"`
-		Favicon       *string `json:"favicon"`
+		Name          *string           `json:"name"`
+		BaseURL       *string           `json:"base_url"`
+		APIKey        *string           `json:"api_key"`
+		Enabled       *bool             `json:"enabled"`
+		AudioOnly     *bool             `json:"audio_only"`
+		ImageOnly     *bool             `json:"image_only"`
+		ImageEditOnly *bool             `json:"image_edit_only"`
+		Secure        *bool             `json:"secure"`
+		DefaultModel  *string           `json:"default_model"`
+		SystemPrompt  *string           `json:"system_prompt"`
+		Favicon       *string           `json:"favicon"`
+		AuthStyle     *string           `json:"auth_style"`
+		Headers       map[string]string `json:"headers"` // Replaces all headers when present; {} clears them
+		// UsesMaxCompletionTokens sets the token limit parameter override; null restores detection from base_url
+		UsesMaxCompletionTokens json.RawMessage `json:"uses_max_completion_tokens"`
+		// Flavor sets the API dialect; "" restores detection from base_url
+		Flavor *string `json:"flavor"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -515,13 +658,17 @@ func (h *AdminHandler) UpdateProvider(c *fiber.Ctx) error {
 
 	// Build update config with existing values as defaults
 	config := models.ProviderConfig{
-		Name:          existing.Name,
-		BaseURL:       existing.BaseURL,
-		APIKey:        existing.APIKey,
-		Enabled:       existing.Enabled,
-		AudioOnly:     existing.AudioOnly,
-		SystemPrompt:  existing.SystemPrompt,
-		Favicon:       existing.Favicon,
+		Name:                    existing.Name,
+		BaseURL:                 existing.BaseURL,
+		APIKey:                  existing.APIKey,
+		Enabled:                 existing.Enabled,
+		AudioOnly:               existing.AudioOnly,
+		SystemPrompt:            existing.SystemPrompt,
+		Favicon:                 existing.Favicon,
+		AuthStyle:               existing.AuthStyle,
+		Headers:                 existing.Headers,
+		UsesMaxCompletionTokens: existing.UsesMaxCompletionTokens,
+		Flavor:                  existing.Flavor,
 	}
 
 	// Apply updates
@@ -558,6 +705,34 @@ func (h *AdminHandler) UpdateProvider(c *fiber.Ctx) error {
 	if req.Favicon != nil {
 		config.Favicon = *req.Favicon
 	}
+	if req.AuthStyle != nil {
+		if !validAuthStyle(*req.AuthStyle) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "auth_style must be \"bearer\" or \"api-key\"",
+			})
+		}
+		config.AuthStyle = *req.AuthStyle
+	}
+	if req.Headers != nil {
+		config.Headers = req.Headers
+	}
+	if len(req.UsesMaxCompletionTokens) > 0 {
+		var override *bool
+		if err := json.Unmarshal(req.UsesMaxCompletionTokens, &override); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "uses_max_completion_tokens must be true, false or null",
+			})
+		}
+		config.UsesMaxCompletionTokens = override
+	}
+	if req.Flavor != nil {
+		if !providerhttp.IsValidFlavor(*req.Flavor) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": invalidFlavorMessage(),
+			})
+		}
+		config.Flavor = *req.Flavor
+	}
 
 	if err := h.providerService.Update(providerID, config); err != nil {
 		log.Printf("❌ [ADMIN] Failed to update provider %d: %v", providerID, err)
@@ -575,9 +750,20 @@ func (h *AdminHandler) UpdateProvider(c *fiber.Ctx) error {
 	}
 
 	log.Printf("✅ [ADMIN] Updated provider: %s (ID %d)", updated.Name, updated.ID)
+	h.reloadProviders()
 	return c.JSON(updated)
 }
 
+// validAuthStyle reports whether style is a supported provider auth style (empty means bearer)
+func validAuthStyle(style string) bool {
+	return style == "" || style == models.AuthStyleBearer || style == models.AuthStyleAPIKey
+}
+
+// invalidFlavorMessage lists the accepted provider flavors
+func invalidFlavorMessage() string {
+	return fmt.Sprintf("flavor must be one of: %s", strings.Join(providerhttp.Flavors, ", "))
+}
+
 // DeleteProvider deletes a provider
 // DELETE /api/admin/providers/:id
 func (h *AdminHandler) DeleteProvider(c *fiber.Ctx) error {
@@ -604,6 +790,7 @@ func (h *AdminHandler) DeleteProvider(c *fiber.Ctx) error {
 	}
 
 	log.Printf("✅ [ADMIN] Deleted provider: %s (ID %d)", provider.Name, provider.ID)
+	h.reloadProviders()
 	return c.JSON(fiber.Map{
 		"message": "Provider deleted successfully",
 	})
@@ -664,28 +851,118 @@ func (h *AdminHandler) ToggleProvider(c *fiber.Ctx) error {
 	}
 
 	log.Printf("✅ [ADMIN] Toggled provider %s to enabled=%v", updated.Name, updated.Enabled)
+	h.reloadProviders()
 	return c.JSON(updated)
 }
 
+// ReloadProviders refreshes every service caching provider config from the database,
+// e.g. after an API key was rotated directly in the database
+// POST /api/admin/providers/reload
+func (h *AdminHandler) ReloadProviders(c *fiber.Ctx) error {
+	count, err := h.providerService.Reload()
+	if err != nil {
+		log.Printf("❌ [ADMIN] Failed to reload providers: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reload providers",
+		})
+	}
+
+	log.Printf("✅ [ADMIN] Reloaded %d providers", count)
+	return c.JSON(fiber.Map{
+		"message":   "Providers reloaded",
+		"providers": count,
+	})
+}
+
+// ResetMemoryModelHealth marks every memory model healthy again, e.g. after a provider
+// outage, instead of waiting for their health check cooldown
+// POST /api/admin/memory/models/reset-health
+func (h *AdminHandler) ResetMemoryModelHealth(c *fiber.Ctx) error {
+	if h.memoryModelPool == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Memory model pool is not available",
+		})
+	}
+
+	recovered := h.memoryModelPool.ResetHealth()
+	return c.JSON(fiber.Map{
+		"message":      "Memory model health reset",
+		"models_reset": recovered,
+		"stats":        h.memoryModelPool.GetStats(),
+	})
+}
+
+// DisableMemoryModel stops the memory model pool from selecting a model until it is
+// enabled again, without reloading providers
+// POST /api/admin/memory/models/:modelId/disable
+func (h *AdminHandler) DisableMemoryModel(c *fiber.Ctx) error {
+	return h.setMemoryModelDisabled(c, true)
+}
+
+// EnableMemoryModel lets the memory model pool select a disabled model again
+// POST /api/admin/memory/models/:modelId/enable
+func (h *AdminHandler) EnableMemoryModel(c *fiber.Ctx) error {
+	return h.setMemoryModelDisabled(c, false)
+}
+
+func (h *AdminHandler) setMemoryModelDisabled(c *fiber.Ctx, disabled bool) error {
+	if h.memoryModelPool == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Memory model pool is not available",
+		})
+	}
+
+	modelID, err := decodeModelID(c.Params("modelId"))
+	if err != nil || modelID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid model ID",
+		})
+	}
+
+	if disabled {
+		err = h.memoryModelPool.DisableModel(modelID)
+	} else {
+		err = h.memoryModelPool.EnableModel(modelID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"model_id": modelID,
+		"disabled": disabled,
+		"stats":    h.memoryModelPool.GetStats(),
+	})
+}
+
+// reloadProviders refreshes cached provider config after a provider changed
+func (h *AdminHandler) reloadProviders() {
+	if _, err := h.providerService.Reload(); err != nil {
+		log.Printf("⚠️ [ADMIN] Failed to reload providers: %v", err)
+	}
+}
+
 // Helper function to convert ModelAlias map to interface{} map for JSON
 func convertAliasesMapToInterface(aliases map[string]models.ModelAlias) map[string]interface{} {
 	result := make(map[string]interface{})
 	for key, alias := range aliases {
 		result[key] = fiber.Map{
-			"actual_model":                alias.ActualModel,
-			"display_name":                alias.DisplayName,
-			"description":                 alias.Description,
-			"supports_vision":             alias.SupportsVision,
-			"agents":                      alias.Agents,
-			"smart_tool_router":           alias.SmartToolRouter,
-			"free_tier":                   alias.FreeTier,
-			"structured_output_support":   alias.StructuredOutputSupport,
+			"actual_model":                 alias.ActualModel,
+			"display_name":                 alias.DisplayName,
+			"description":                  alias.Description,
+			"supports_vision":              alias.SupportsVision,
+			"agents":                       alias.Agents,
+			"smart_tool_router":            alias.SmartToolRouter,
+			"free_tier":                    alias.FreeTier,
+			"structured_output_support":    alias.StructuredOutputSupport,
 			"structured_output_compliance": alias.StructuredOutputCompliance,
-			"structured_output_warning":   alias.StructuredOutputWarning,
-			"structured_output_speed_ms":  alias.StructuredOutputSpeedMs,
-			"structured_output_badge":     alias.StructuredOutputBadge,
-			"memory_extractor":            alias.MemoryExtractor,
-			"memory_selector":             alias.MemorySelector,
+			"structured_output_warning":    alias.StructuredOutputWarning,
+			"structured_output_speed_ms":   alias.StructuredOutputSpeedMs,
+			"structured_output_badge":      alias.StructuredOutputBadge,
+			"memory_extractor":             alias.MemoryExtractor,
+			"memory_selector":              alias.MemorySelector,
 		}
 	}
 	return result
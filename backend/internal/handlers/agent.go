@@ -3,8 +3,11 @@ package handlers
 import (
 	"bytes"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
+	"claraverse/internal/security"
 	"claraverse/internal/services"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -48,6 +51,7 @@ type AgentHandler struct {
 	workflowGeneratorV2Service *services.WorkflowGeneratorV2Service
 	builderConvService         *services.BuilderConversationService
 	providerService            *services.ProviderService
+	toolService                *services.ToolService
 }
 
 // NewAgentHandler creates a new agent handler
@@ -73,6 +77,11 @@ func (h *AgentHandler) SetProviderService(svc *services.ProviderService) {
 	h.providerService = svc
 }
 
+// SetToolService sets the tool service (for the tool preview endpoint)
+func (h *AgentHandler) SetToolService(svc *services.ToolService) {
+	h.toolService = svc
+}
+
 // Create creates a new agent
 // POST /api/agents
 func (h *AgentHandler) Create(c *fiber.Ctx) error {
@@ -228,6 +237,27 @@ func (h *AgentHandler) Update(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.CompletionWebhookURL != nil && *req.CompletionWebhookURL != "" {
+		if err := security.ValidateURLForSSRF(*req.CompletionWebhookURL); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Invalid completion webhook URL: %v", err),
+			})
+		}
+	}
+
+	if d := req.ExecutionDefaults; d != nil {
+		if (d.CheckerMaxRetries != nil && *d.CheckerMaxRetries < 0) || (d.HistoryWindow != nil && *d.HistoryWindow < 0) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Execution defaults must not be negative",
+			})
+		}
+		if d.UnavailableTools != "" && d.UnavailableTools != models.UnavailableToolsFail && d.UnavailableTools != models.UnavailableToolsMark {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("unavailable_tools must be %q or %q", models.UnavailableToolsFail, models.UnavailableToolsMark),
+			})
+		}
+	}
+
 	log.Printf("✏️ [AGENT] Updating agent %s for user %s", agentID, userID)
 
 	// Check if we're deploying and need to auto-generate a description
@@ -266,9 +296,59 @@ func (h *AgentHandler) Update(c *fiber.Ctx) error {
 	}
 
 	log.Printf("✅ [AGENT] Updated agent %s", agentID)
+	if req.CompletionWebhookURL != nil && agent.CompletionWebhookSecret != "" {
+		return c.JSON(agentWithWebhookSecret{Agent: agent, CompletionWebhookSecret: agent.CompletionWebhookSecret})
+	}
 	return c.JSON(agent)
 }
 
+// agentWithWebhookSecret is an agent together with its completion webhook secret,
+// which is otherwise never serialized
+type agentWithWebhookSecret struct {
+	*models.Agent
+	CompletionWebhookSecret string `json:"completion_webhook_secret"`
+}
+
+// RotateCompletionWebhookSecret replaces the signing secret of the agent's completion webhook
+// POST /api/agents/:id/completion-webhook/rotate-secret
+func (h *AgentHandler) RotateCompletionWebhookSecret(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	agentID := c.Params("id")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Agent ID is required",
+		})
+	}
+
+	secret, err := h.agentService.RotateCompletionWebhookSecret(agentID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoCompletionWebhook) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Agent has no completion webhook URL",
+			})
+		}
+		if err.Error() == "agent not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Agent not found",
+			})
+		}
+		log.Printf("❌ [AGENT] Failed to rotate completion webhook secret of agent %s: %v", agentID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to rotate completion webhook secret",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"completion_webhook_secret": secret,
+	})
+}
+
 // Delete deletes an agent
 // DELETE /api/agents/:id
 func (h *AgentHandler) Delete(c *fiber.Ctx) error {
@@ -305,6 +385,63 @@ func (h *AgentHandler) Delete(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
 
+// Disable turns on the agent's kill switch: it is kept, but every execution is refused
+// POST /api/agents/:id/disable
+func (h *AgentHandler) Disable(c *fiber.Ctx) error {
+	return h.setDisabled(c, true)
+}
+
+// Enable turns off the agent's kill switch
+// POST /api/agents/:id/enable
+func (h *AgentHandler) Enable(c *fiber.Ctx) error {
+	return h.setDisabled(c, false)
+}
+
+func (h *AgentHandler) setDisabled(c *fiber.Ctx, disabled bool) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	agentID := c.Params("id")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Agent ID is required",
+		})
+	}
+
+	var req models.DisableAgentRequest
+	if disabled && len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	// Owners can only switch their own agents, and not while an admin has disabled them
+	agent, err := h.agentService.SetAgentDisabledByOwner(agentID, disabled, req.Reason, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrAgentDisabledByAdmin) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Agent was disabled by an administrator; contact support to enable it",
+			})
+		}
+		if err.Error() == "agent not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Agent not found",
+			})
+		}
+		log.Printf("❌ [AGENT] Failed to update kill switch of agent %s: %v", agentID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update agent",
+		})
+	}
+	return c.JSON(agent)
+}
+
 // SaveWorkflow saves or updates the workflow for an agent
 // PUT /api/agents/:id/workflow
 func (h *AgentHandler) SaveWorkflow(c *fiber.Ctx) error {
@@ -394,6 +531,64 @@ func (h *AgentHandler) GetWorkflow(c *fiber.Ctx) error {
 	return c.JSON(workflow)
 }
 
+// PreviewTools returns the tools the agent's workflow will use, with their source
+// (builtin, mcp, integration) and whether each is currently usable by the user
+// GET /api/agents/:id/tools
+func (h *AgentHandler) PreviewTools(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	if h.toolService == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Tool preview is not available",
+		})
+	}
+
+	agentID := c.Params("id")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Agent ID is required",
+		})
+	}
+
+	agent, err := h.agentService.GetAgent(agentID, userID)
+	if err != nil {
+		if err.Error() == "agent not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Agent not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get agent",
+		})
+	}
+
+	previews, err := h.toolService.PreviewWorkflowTools(c.Context(), userID, agent.Workflow)
+	if err != nil {
+		log.Printf("❌ [AGENT] Failed to preview tools for agent %s: %v", agentID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to resolve tools",
+		})
+	}
+
+	unusable := 0
+	for _, preview := range previews {
+		if !preview.Usable {
+			unusable++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"tools":    previews,
+		"total":    len(previews),
+		"unusable": unusable,
+	})
+}
+
 // GenerateWorkflow generates or modifies a workflow using AI
 // POST /api/agents/:id/generate-workflow
 func (h *AgentHandler) GenerateWorkflow(c *fiber.Ctx) error {
@@ -701,6 +896,98 @@ func (h *AgentHandler) SyncAgent(c *fiber.Ctx) error {
 	})
 }
 
+// Export returns a portable definition of the agent (workflow, inputs and required
+// integrations, without secrets or credential references)
+// GET /api/agents/:id/export
+func (h *AgentHandler) Export(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	agentID := c.Params("id")
+	if agentID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Agent ID is required",
+		})
+	}
+
+	export, err := h.agentService.ExportAgent(agentID, userID)
+	if err != nil {
+		if err.Error() == "agent not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Agent not found",
+			})
+		}
+		log.Printf("❌ [AGENT] Failed to export agent %s: %v", agentID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export agent",
+		})
+	}
+
+	log.Printf("📤 [AGENT] Exported agent %s (blocks: %d, removed fields: %d)", agentID, len(export.Workflow.Blocks), len(export.RemovedFields))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="agent-%s.json"`, agentID))
+	return c.JSON(export)
+}
+
+// Import creates a new agent for the caller from an exported definition, with fresh
+// IDs, and reports which credentials the importer still needs to configure
+// POST /api/agents/import
+func (h *AgentHandler) Import(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var export models.AgentExport
+	if err := c.BodyParser(&export); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	agent, workflow, err := h.agentService.ImportAgent(userID, &export)
+	if err != nil {
+		var validationErr *services.WorkflowValidationError
+		if errors.As(err, &validationErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":  "Invalid agent export",
+				"errors": validationErr.Errors,
+			})
+		}
+		log.Printf("❌ [AGENT] Failed to import agent: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import agent",
+		})
+	}
+
+	response := &models.ImportAgentResponse{
+		Agent:                agent,
+		Workflow:             workflow,
+		RequiredIntegrations: services.WorkflowRequiredIntegrations(workflow),
+		MissingCredentials:   []string{},
+	}
+	var configured map[string]bool
+	if h.toolService != nil {
+		if configured, err = h.toolService.GetUserIntegrationTypes(c.Context(), userID); err != nil {
+			log.Printf("⚠️ [AGENT] Failed to load credentials for import of agent %s: %v", agent.ID, err)
+		}
+	}
+	for i, integration := range response.RequiredIntegrations {
+		response.RequiredIntegrations[i].Configured = configured[integration.IntegrationType]
+		if !configured[integration.IntegrationType] {
+			response.MissingCredentials = append(response.MissingCredentials, integration.IntegrationType)
+		}
+	}
+
+	log.Printf("📥 [AGENT] Imported agent %s for user %s (blocks: %d, missing credentials: %v)", agent.ID, userID, len(workflow.Blocks), response.MissingCredentials)
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
 // GenerateWorkflowV2 generates a workflow using multi-step process with tool selection
 // POST /api/agents/:id/generate-workflow-v2
 func (h *AgentHandler) GenerateWorkflowV2(c *fiber.Ctx) error {
@@ -1188,7 +1475,7 @@ Be helpful, clear, and concise. If you don't know something, say so.`,
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := providerhttp.NewClient(60 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		log.Printf("❌ [ASK] HTTP request failed: %v", err)
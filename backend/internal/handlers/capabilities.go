@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"claraverse/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CapabilitiesHandler reports the backend version and which optional features this
+// deployment has enabled, so clients can adapt instead of assuming every feature exists
+type CapabilitiesHandler struct {
+	version  string
+	features map[string]bool
+}
+
+// NewCapabilitiesHandler creates a new capabilities handler. features maps feature
+// names to whether they are enabled; it is not modified after startup.
+func NewCapabilitiesHandler(version string, features map[string]bool) *CapabilitiesHandler {
+	return &CapabilitiesHandler{version: version, features: features}
+}
+
+// Handle responds with the version and feature map
+// GET /capabilities
+func (h *CapabilitiesHandler) Handle(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"version":  h.version,
+		"features": h.features,
+		"mcp": fiber.Map{
+			"protocol_version":     services.MCPProtocolVersion,
+			"min_protocol_version": services.MCPMinProtocolVersion,
+			"encodings":            []string{services.MCPEncodingJSON, services.MCPEncodingMsgpack},
+		},
+	})
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"claraverse/internal/models"
 	"claraverse/internal/services"
+	"errors"
+	"fmt"
 	"log"
 
 	"github.com/gofiber/fiber/v2"
@@ -233,12 +235,22 @@ func (h *CredentialHandler) Test(c *fiber.Ctx) error {
 	// Test the credential
 	result := h.credentialTester.Test(c.Context(), decrypted)
 
-	// Update test status
+	// Untestable integrations keep their status instead of being marked failed
+	if result.Unsupported {
+		return c.JSON(result)
+	}
+
+	// Update test status, keeping the provider's error for failed tests
 	status := "failed"
+	var testErr error
 	if result.Success {
 		status = "success"
+	} else if result.Details != "" {
+		testErr = fmt.Errorf("%s: %s", result.Message, result.Details)
+	} else {
+		testErr = errors.New(result.Message)
 	}
-	if err := h.credentialService.UpdateTestStatus(c.Context(), credID, userID, status, nil); err != nil {
+	if err := h.credentialService.UpdateTestStatus(c.Context(), credID, userID, status, testErr); err != nil {
 		log.Printf("⚠️ [CREDENTIAL] Failed to update test status: %v", err)
 	}
 
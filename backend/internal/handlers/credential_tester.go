@@ -88,10 +88,29 @@ func (t *CredentialTester) Test(ctx context.Context, cred *models.DecryptedCrede
 		return t.testMongoDB(ctx, cred.Data)
 	case "redis":
 		return t.testRedis(ctx, cred.Data)
+	case "sql_database":
+		return t.testSQLDatabase(ctx, cred.Data)
+	case "calendly":
+		return t.testCalendly(ctx, cred.Data)
+	case "clickup":
+		return t.testClickUp(ctx, cred.Data)
+	case "netlify":
+		return t.testNetlify(ctx, cred.Data)
+	case "shopify":
+		return t.testShopify(ctx, cred.Data)
+	case "twilio":
+		return t.testTwilio(ctx, cred.Data)
+	case "x_twitter":
+		return t.testXTwitter(ctx, cred.Data)
+	case "posthog":
+		return t.testPostHog(ctx, cred.Data)
+	case "leadsquared":
+		return t.testLeadSquared(ctx, cred.Data)
 	default:
 		return &models.TestCredentialResponse{
-			Success: false,
-			Message: "Testing not implemented for this integration type",
+			Success:     false,
+			Unsupported: true,
+			Message:     "Testing not implemented for this integration type",
 		}
 	}
 }
@@ -1405,6 +1424,14 @@ func (t *CredentialTester) testRedis(ctx context.Context, data map[string]interf
 	}
 }
 
+// testSQLDatabase tests a SQL datasource by connecting and reading the server version
+func (t *CredentialTester) testSQLDatabase(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	testCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return testSQLDatabaseWithDriver(testCtx, data)
+}
+
 // testReferralMonk tests ReferralMonk API credentials by calling their API
 func (t *CredentialTester) testReferralMonk(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
 	apiToken, ok := data["api_token"].(string)
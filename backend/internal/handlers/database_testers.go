@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"claraverse/internal/models"
+	"claraverse/internal/tools"
 	"context"
 	"fmt"
 	"strconv"
@@ -244,3 +245,32 @@ func trimSpace(s string) string {
 	}
 	return s[start:end]
 }
+
+// testSQLDatabaseWithDriver tests a SQL datasource using the same connection settings as the sql_query tool
+func testSQLDatabaseWithDriver(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	db, err := tools.OpenSQLDatasource(data)
+	if err != nil {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Invalid SQL database configuration",
+			Details: err.Error(),
+		}
+	}
+	defer db.Close()
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Failed to connect to SQL database",
+			Details: err.Error(),
+		}
+	}
+
+	database, _ := data["database"].(string)
+	return &models.TestCredentialResponse{
+		Success: true,
+		Message: "SQL database connection successful!",
+		Details: fmt.Sprintf("Server version: %s\nDatabase: %s", version, database),
+	}
+}
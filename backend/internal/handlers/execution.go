@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"claraverse/internal/filecache"
+	"claraverse/internal/securefile"
 	"claraverse/internal/services"
 	"log"
+	"mime"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,15 +15,25 @@ import (
 // ExecutionHandler handles execution-related HTTP requests
 type ExecutionHandler struct {
 	executionService *services.ExecutionService
+	activeExecutions *services.ActiveExecutionRegistry
+	secureFiles      *securefile.Service
+	fileCache        *filecache.Service
 }
 
 // NewExecutionHandler creates a new execution handler
 func NewExecutionHandler(executionService *services.ExecutionService) *ExecutionHandler {
 	return &ExecutionHandler{
 		executionService: executionService,
+		secureFiles:      securefile.GetService(),
+		fileCache:        filecache.GetService(),
 	}
 }
 
+// SetActiveExecutions sets the registry of running executions (required for cancellation)
+func (h *ExecutionHandler) SetActiveExecutions(registry *services.ActiveExecutionRegistry) {
+	h.activeExecutions = registry
+}
+
 // ListByAgent returns paginated executions for a specific agent
 // GET /api/agents/:id/executions
 func (h *ExecutionHandler) ListByAgent(c *fiber.Ctx) error {
@@ -87,6 +100,175 @@ func (h *ExecutionHandler) GetByID(c *fiber.Ctx) error {
 	return c.JSON(execution)
 }
 
+// GetBlock returns one block's inputs, output and outcome from an execution, for debugging
+// GET /api/executions/:id/blocks/:blockId
+func (h *ExecutionHandler) GetBlock(c *fiber.Ctx) error {
+	executionIDStr := c.Params("id")
+	blockID := c.Params("blockId")
+	userID := c.Locals("user_id").(string)
+
+	executionID, err := primitive.ObjectIDFromHex(executionIDStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid execution ID",
+		})
+	}
+
+	detail, err := h.executionService.GetBlockDetail(c.Context(), executionID, userID, blockID)
+	if err != nil {
+		switch err.Error() {
+		case "execution not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Execution not found",
+			})
+		case "block not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Block not found in this execution",
+			})
+		}
+		log.Printf("❌ [EXECUTION] Failed to get execution block: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get execution block",
+		})
+	}
+
+	return c.JSON(detail)
+}
+
+// DownloadFile streams a file generated by an execution. Only files listed in the
+// execution's results can be fetched, and Range requests are supported.
+// GET /api/executions/:id/files/:fileId
+func (h *ExecutionHandler) DownloadFile(c *fiber.Ctx) error {
+	executionIDStr := c.Params("id")
+	fileID := c.Params("fileId")
+	userID := c.Locals("user_id").(string)
+
+	executionID, err := primitive.ObjectIDFromHex(executionIDStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid execution ID",
+		})
+	}
+
+	execution, err := h.executionService.GetByIDAndUser(c.Context(), executionID, userID)
+	if err != nil {
+		if err.Error() == "execution not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Execution not found",
+			})
+		}
+		log.Printf("❌ [EXECUTION] Failed to get execution: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get execution",
+		})
+	}
+
+	listed := false
+	for _, file := range execution.Files {
+		if file.FileID == fileID {
+			listed = true
+			break
+		}
+	}
+	if !listed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "File not found in this execution",
+		})
+	}
+
+	// Generated documents live in the secure file store; generated images in the file cache
+	var path, filename, mimeType string
+	if file, err := h.secureFiles.GetFileForUser(fileID, userID); err == nil {
+		path, filename, mimeType = file.FilePath, file.Filename, file.MimeType
+	} else if cached, found := h.fileCache.Get(fileID); found && cached.UserID == userID && cached.FilePath != "" {
+		path, filename, mimeType = cached.FilePath, cached.Filename, cached.MimeType
+	} else {
+		log.Printf("⚠️  [EXECUTION] File %s of execution %s is no longer available (user: %s)", fileID, executionIDStr, userID)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "File not found or has expired",
+		})
+	}
+
+	if err := c.SendFile(path); err != nil {
+		log.Printf("❌ [EXECUTION] Failed to send file %s: %v", fileID, err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "File not found or has expired",
+		})
+	}
+
+	// SendFile guesses the type from the extension; the stored type is authoritative
+	if mimeType != "" {
+		c.Set(fiber.HeaderContentType, mimeType)
+	}
+	if filename != "" {
+		c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	return nil
+}
+
+// Cancel cancels a running execution
+// POST /api/executions/:id/cancel
+func (h *ExecutionHandler) Cancel(c *fiber.Ctx) error {
+	executionIDStr := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	executionID, err := primitive.ObjectIDFromHex(executionIDStr)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid execution ID",
+		})
+	}
+
+	// Ownership check
+	if _, err := h.executionService.GetByIDAndUser(c.Context(), executionID, userID); err != nil {
+		if err.Error() == "execution not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Execution not found",
+			})
+		}
+		log.Printf("❌ [EXECUTION] Failed to get execution: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to cancel execution",
+		})
+	}
+
+	if !h.activeExecutions.Cancel(executionIDStr, userID) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Execution is not running",
+		})
+	}
+
+	if err := h.executionService.Complete(c.Context(), executionID, &services.ExecutionCompleteRequest{
+		Status: "cancelled",
+		Error:  services.ExecutionCancelledError,
+	}); err != nil {
+		log.Printf("⚠️ [EXECUTION] Failed to mark execution %s cancelled: %v", executionIDStr, err)
+	}
+
+	log.Printf("🛑 [EXECUTION] Execution %s cancelled by user %s", executionIDStr, userID)
+
+	return c.JSON(fiber.Map{
+		"execution_id": executionIDStr,
+		"status":       "cancelled",
+	})
+}
+
+// CancelAll cancels every execution the current user has running
+// POST /api/executions/cancel-all
+func (h *ExecutionHandler) CancelAll(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	cancelled, err := h.executionService.CancelUserExecutions(c.Context(), userID)
+	if err != nil {
+		log.Printf("⚠️ [EXECUTION] Failed to record some cancellations for user %s: %v", userID, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"cancelled":       cancelled,
+		"cancelled_count": len(cancelled),
+	})
+}
+
 // GetStats returns execution statistics for an agent
 // GET /api/agents/:id/executions/stats
 func (h *ExecutionHandler) GetStats(c *fiber.Ctx) error {
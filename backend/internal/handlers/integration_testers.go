@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"claraverse/internal/models"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxProviderErrorBytes bounds how much of a provider's error response is returned
+const maxProviderErrorBytes = 512
+
+// verifyRequest sends a lightweight authenticated request and reports whether the
+// provider accepted the credential. On success, describe (if set) builds the details
+// from the decoded JSON body; on failure the provider's error body is returned.
+func (t *CredentialTester) verifyRequest(req *http.Request, provider string, describe func(map[string]interface{}) string) *models.TestCredentialResponse {
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to connect to %s", provider),
+			Details: err.Error(),
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		result := &models.TestCredentialResponse{
+			Success: true,
+			Message: fmt.Sprintf("%s credentials are valid!", provider),
+		}
+		if describe != nil {
+			var body map[string]interface{}
+			if json.NewDecoder(resp.Body).Decode(&body) == nil {
+				result.Details = describe(body)
+			}
+		}
+		return result
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxProviderErrorBytes))
+	return &models.TestCredentialResponse{
+		Success: false,
+		Message: fmt.Sprintf("%s returned status %d", provider, resp.StatusCode),
+		Details: strings.TrimSpace(string(body)),
+	}
+}
+
+// testCalendly tests a Calendly personal access token
+func (t *CredentialTester) testCalendly(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	apiKey, _ := data["api_key"].(string)
+	if apiKey == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "API key is required",
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.calendly.com/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	return t.verifyRequest(req, "Calendly", func(body map[string]interface{}) string {
+		resource, _ := body["resource"].(map[string]interface{})
+		name, _ := resource["name"].(string)
+		return fmt.Sprintf("Authenticated as: %s", name)
+	})
+}
+
+// testClickUp tests a ClickUp personal API token
+func (t *CredentialTester) testClickUp(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	apiKey, _ := data["api_key"].(string)
+	if apiKey == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "API key is required",
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.clickup.com/api/v2/user", nil)
+	req.Header.Set("Authorization", apiKey)
+
+	return t.verifyRequest(req, "ClickUp", func(body map[string]interface{}) string {
+		user, _ := body["user"].(map[string]interface{})
+		username, _ := user["username"].(string)
+		return fmt.Sprintf("Authenticated as: %s", username)
+	})
+}
+
+// testNetlify tests a Netlify personal access token
+func (t *CredentialTester) testNetlify(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	accessToken, _ := data["access_token"].(string)
+	if accessToken == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Access token is required",
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.netlify.com/api/v1/user", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	return t.verifyRequest(req, "Netlify", func(body map[string]interface{}) string {
+		email, _ := body["email"].(string)
+		return fmt.Sprintf("Authenticated as: %s", email)
+	})
+}
+
+// testShopify tests a Shopify Admin API access token against the store
+func (t *CredentialTester) testShopify(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	storeURL, _ := data["store_url"].(string)
+	accessToken, _ := data["access_token"].(string)
+	if storeURL == "" || accessToken == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Store URL and access token are required",
+		}
+	}
+
+	storeURL = strings.TrimPrefix(storeURL, "https://")
+	storeURL = strings.TrimPrefix(storeURL, "http://")
+	storeURL = strings.TrimSuffix(storeURL, "/")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/admin/api/2025-01/shop.json", storeURL), nil)
+	if err != nil {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Invalid store URL",
+			Details: err.Error(),
+		}
+	}
+	req.Header.Set("X-Shopify-Access-Token", accessToken)
+
+	return t.verifyRequest(req, "Shopify", func(body map[string]interface{}) string {
+		shop, _ := body["shop"].(map[string]interface{})
+		name, _ := shop["name"].(string)
+		return fmt.Sprintf("Store: %s", name)
+	})
+}
+
+// testTwilio tests Twilio account credentials by fetching the account
+func (t *CredentialTester) testTwilio(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	accountSID, _ := data["account_sid"].(string)
+	authToken, _ := data["auth_token"].(string)
+	if accountSID == "" || authToken == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Account SID and auth token are required",
+		}
+	}
+
+	apiURL := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s.json", url.PathEscape(accountSID))
+	req, _ := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(accountSID+":"+authToken)))
+
+	return t.verifyRequest(req, "Twilio", func(body map[string]interface{}) string {
+		name, _ := body["friendly_name"].(string)
+		status, _ := body["status"].(string)
+		return fmt.Sprintf("Account: %s (%s)", name, status)
+	})
+}
+
+// testXTwitter tests an X (Twitter) API bearer token with an app-only lookup
+func (t *CredentialTester) testXTwitter(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	bearerToken, _ := data["bearer_token"].(string)
+	if bearerToken == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Bearer token is required",
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.x.com/2/users/by/username/X", nil)
+	req.Header.Set("Authorization", "Bearer "+bearerToken)
+
+	return t.verifyRequest(req, "X", nil)
+}
+
+// testPostHog tests a PostHog personal API key. Project API keys are write-only
+// and cannot be verified, so credentials without a personal key are only format-checked.
+func (t *CredentialTester) testPostHog(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	apiKey, _ := data["api_key"].(string)
+	if apiKey == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Project API key is required",
+		}
+	}
+
+	personalAPIKey, _ := data["personal_api_key"].(string)
+	if personalAPIKey == "" {
+		if !strings.HasPrefix(apiKey, "phc_") {
+			return &models.TestCredentialResponse{
+				Success: false,
+				Message: "Invalid project API key format",
+				Details: "PostHog project API keys start with 'phc_'",
+			}
+		}
+		return &models.TestCredentialResponse{
+			Success: true,
+			Message: "Project API key format is valid",
+			Details: "Add a personal API key to verify the credential against PostHog",
+		}
+	}
+
+	host, _ := data["host"].(string)
+	if host == "" {
+		host = "https://app.posthog.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(host, "/")+"/api/users/@me/", nil)
+	if err != nil {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Invalid PostHog host",
+			Details: err.Error(),
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+personalAPIKey)
+
+	return t.verifyRequest(req, "PostHog", func(body map[string]interface{}) string {
+		email, _ := body["email"].(string)
+		return fmt.Sprintf("Authenticated as: %s", email)
+	})
+}
+
+// testLeadSquared tests LeadSquared access and secret keys
+func (t *CredentialTester) testLeadSquared(ctx context.Context, data map[string]interface{}) *models.TestCredentialResponse {
+	accessKey, _ := data["access_key"].(string)
+	secretKey, _ := data["secret_key"].(string)
+	host, _ := data["host"].(string)
+	if accessKey == "" || secretKey == "" {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Access key and secret key are required",
+		}
+	}
+	if host == "" {
+		host = "api.leadsquared.com"
+	}
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+
+	query := url.Values{"accessKey": {accessKey}, "secretKey": {secretKey}}
+	apiURL := fmt.Sprintf("https://%s/v2/Authentication.svc/UserByAccessKey.Get?%s", strings.TrimSuffix(host, "/"), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return &models.TestCredentialResponse{
+			Success: false,
+			Message: "Invalid LeadSquared host",
+			Details: err.Error(),
+		}
+	}
+
+	return t.verifyRequest(req, "LeadSquared", nil)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"claraverse/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MCPConnectTokenParam is the query parameter a connection token is passed in
+const MCPConnectTokenParam = "connect_token"
+
+// IssueConnectToken returns a short-lived, single-use token the authenticated user's
+// MCP client exchanges for a WebSocket connection, so its access token never
+// appears in the connection URL
+// POST /api/mcp/connect-token
+func (h *MCPWebSocketHandler) IssueConnectToken(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" || userID == "anonymous" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+	if h.connectTokens == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Connection tokens are not enabled",
+		})
+	}
+
+	// A revoked client's access token must not get it a new connection
+	tokenIssuedAt, _ := c.Locals("token_issued_at").(time.Time)
+	if err := h.mcpService.CheckTokenRevocation(userID, tokenIssuedAt); err != nil {
+		if errors.Is(err, services.ErrMCPTokenRevoked) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Printf("❌ Failed to check MCP token revocation: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to issue connection token",
+		})
+	}
+
+	email, _ := c.Locals("user_email").(string)
+	token, issued, err := h.connectTokens.Issue(userID, email, tokenIssuedAt)
+	if err != nil {
+		log.Printf("❌ Failed to issue MCP connection token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to issue connection token",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"token":      token,
+		"expires_at": issued.ExpiresAt,
+		"expires_in": int(time.Until(issued.ExpiresAt).Seconds()),
+	})
+}
+
+// ConnectTokenAuth authenticates upgrade requests carrying a connection token,
+// consuming the token. Requests without one are passed to jwtAuth.
+func (h *MCPWebSocketHandler) ConnectTokenAuth(jwtAuth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query(MCPConnectTokenParam)
+		if token == "" {
+			return jwtAuth(c)
+		}
+		if h.connectTokens == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Connection tokens are not enabled",
+			})
+		}
+
+		issued, err := h.connectTokens.Consume(token)
+		if err != nil {
+			log.Printf("❌ MCP connection token rejected: %v", err)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired connection token",
+			})
+		}
+
+		c.Locals("user_id", issued.UserID)
+		c.Locals("user_email", issued.Email)
+		c.Locals("token_issued_at", issued.TokenIssuedAt)
+		log.Printf("✅ MCP connection token accepted: user=%s", issued.UserID)
+		return c.Next()
+	}
+}
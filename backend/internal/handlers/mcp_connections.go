@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"claraverse/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListConnections returns the authenticated user's active MCP client connections
+// GET /api/mcp/connections
+func (h *MCPWebSocketHandler) ListConnections(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	connections := h.mcpService.ListConnections(userID)
+	return c.JSON(fiber.Map{
+		"connections": connections,
+		"total":       len(connections),
+	})
+}
+
+// RevokeConnection force-disconnects one of the authenticated user's MCP clients
+// DELETE /api/mcp/connections/:clientID
+func (h *MCPWebSocketHandler) RevokeConnection(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	return h.revoke(c, userID)
+}
+
+// AdminListConnections returns the active MCP client connections of all users (admin only)
+// GET /api/admin/mcp/connections
+func (h *MCPWebSocketHandler) AdminListConnections(c *fiber.Ctx) error {
+	connections := h.mcpService.ListConnections("")
+	return c.JSON(fiber.Map{
+		"connections": connections,
+		"total":       len(connections),
+	})
+}
+
+// AdminRevokeConnection force-disconnects any user's MCP client (admin only)
+// DELETE /api/admin/mcp/connections/:clientID
+func (h *MCPWebSocketHandler) AdminRevokeConnection(c *fiber.Ctx) error {
+	return h.revoke(c, "")
+}
+
+// revoke disconnects the client in the clientID param; a non-empty ownerID limits
+// revocation to that user's clients
+func (h *MCPWebSocketHandler) revoke(c *fiber.Ctx, ownerID string) error {
+	clientID := c.Params("clientID")
+	if clientID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Client ID is required",
+		})
+	}
+
+	if err := h.mcpService.RevokeConnection(clientID, ownerID); err != nil {
+		if errors.Is(err, services.ErrMCPConnectionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Connection not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	revokedBy, _ := c.Locals("user_id").(string)
+	log.Printf("⛔ [MCP] Connection %s revoked by user %s", clientID, revokedBy)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Connection revoked",
+	})
+}
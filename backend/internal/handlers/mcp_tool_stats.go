@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"claraverse/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetToolLatencyStats returns latency percentiles for each of the user's MCP tools
+// GET /api/mcp/tools/latency?window=24h
+func (h *MCPWebSocketHandler) GetToolLatencyStats(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User not authenticated",
+		})
+	}
+
+	window := services.DefaultToolLatencyWindow
+	if raw := c.Query("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > services.MaxToolLatencyWindow {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "window must be a duration like 24h, up to 2160h (90 days)",
+			})
+		}
+		window = parsed
+	}
+
+	stats, err := h.mcpService.GetToolLatencyStats(userID, window)
+	if err != nil {
+		log.Printf("❌ [MCP] Failed to get tool latency stats for user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get tool latency stats",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"window_seconds": int64(window.Seconds()),
+		"tools":          stats,
+	})
+}
@@ -2,30 +2,120 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"claraverse/internal/models"
+	"claraverse/internal/security"
 	"claraverse/internal/services"
+	fastws "github.com/fasthttp/websocket"
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 )
 
+// DefaultMCPWriteTimeout bounds each write to an MCP client unless overridden
+const DefaultMCPWriteTimeout = 10 * time.Second
+
+// MCPConnectionRetryAfter is the Retry-After sent when the connection cap is reached
+const MCPConnectionRetryAfter = 30 * time.Second
+
+// DefaultMCPMaxMessageBytes caps a single message read from an MCP client. It must
+// leave room for the largest tool result the backend accepts.
+const DefaultMCPMaxMessageBytes = 16 << 20
+
 // MCPWebSocketHandler handles MCP client WebSocket connections
 type MCPWebSocketHandler struct {
-	mcpService *services.MCPBridgeService
+	mcpService      *services.MCPBridgeService
+	writeTimeout    time.Duration
+	maxMessageBytes int64
+
+	// maxConnections caps concurrent sockets (0 = unlimited); activeConns counts open ones
+	maxConnections int64
+	activeConns    atomic.Int64
+
+	// connectTokens authenticates upgrades without a long-lived token in the URL (nil = disabled)
+	connectTokens *security.ConnectTokenStore
 }
 
 // NewMCPWebSocketHandler creates a new MCP WebSocket handler
 func NewMCPWebSocketHandler(mcpService *services.MCPBridgeService) *MCPWebSocketHandler {
 	return &MCPWebSocketHandler{
-		mcpService: mcpService,
+		mcpService:      mcpService,
+		writeTimeout:    DefaultMCPWriteTimeout,
+		maxMessageBytes: DefaultMCPMaxMessageBytes,
+	}
+}
+
+// SetWriteTimeout sets how long a write to a client may block before the
+// connection is treated as dead and closed
+func (h *MCPWebSocketHandler) SetWriteTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		h.writeTimeout = timeout
 	}
 }
 
+// SetMaxMessageBytes caps the size of a single message from a client (0 = unlimited).
+// Larger messages close the connection with status 1009 (message too big) before
+// they are read into memory.
+func (h *MCPWebSocketHandler) SetMaxMessageBytes(max int) {
+	h.maxMessageBytes = int64(max)
+}
+
+// SetMaxConnections caps the number of concurrent MCP sockets (0 = unlimited)
+func (h *MCPWebSocketHandler) SetMaxConnections(max int) {
+	h.maxConnections = int64(max)
+}
+
+// SetConnectTokens enables short-lived connection tokens issued by IssueConnectToken
+func (h *MCPWebSocketHandler) SetConnectTokens(store *security.ConnectTokenStore) {
+	h.connectTokens = store
+}
+
+// ActiveConnections returns the number of open MCP sockets
+func (h *MCPWebSocketHandler) ActiveConnections() int64 {
+	return h.activeConns.Load()
+}
+
+// LimitConnections rejects upgrade requests with 503 once the connection cap is reached
+func (h *MCPWebSocketHandler) LimitConnections(c *fiber.Ctx) error {
+	if h.maxConnections > 0 && h.activeConns.Load() >= h.maxConnections {
+		log.Printf("⚠️ MCP connection rejected: %d/%d connections open", h.activeConns.Load(), h.maxConnections)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(MCPConnectionRetryAfter.Seconds())))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Too many MCP connections, try again later",
+		})
+	}
+	return c.Next()
+}
+
+// acquireConnection counts a new socket, failing if concurrent upgrades raced past the cap
+func (h *MCPWebSocketHandler) acquireConnection() bool {
+	if n := h.activeConns.Add(1); h.maxConnections > 0 && n > h.maxConnections {
+		h.activeConns.Add(-1)
+		return false
+	}
+	return true
+}
+
 // HandleConnection handles incoming MCP client WebSocket connections
 func (h *MCPWebSocketHandler) HandleConnection(c *websocket.Conn) {
+	if !h.acquireConnection() {
+		c.WriteJSON(fiber.Map{
+			"type": "error",
+			"payload": map[string]interface{}{
+				"message": "Too many MCP connections, try again later",
+			},
+		})
+		c.Close()
+		return
+	}
+	// Every disconnect path (client, revoke, replacement, watchdog reap) ends the read loop
+	defer h.activeConns.Add(-1)
+
 	// Get user from fiber context (set by auth middleware)
 	userID := c.Locals("user_id").(string)
 
@@ -41,19 +131,47 @@ func (h *MCPWebSocketHandler) HandleConnection(c *websocket.Conn) {
 		return
 	}
 
+	// Tokens issued before one of the user's clients was revoked are not accepted
+	tokenIssuedAt, _ := c.Locals("token_issued_at").(time.Time)
+	if err := h.mcpService.CheckTokenRevocation(userID, tokenIssuedAt); err != nil {
+		log.Printf("❌ MCP connection rejected: user=%s: %v", userID, err)
+		c.WriteJSON(models.MCPServerMessage{
+			Type: "error",
+			Payload: map[string]interface{}{
+				"message": err.Error(),
+			},
+		})
+		if errors.Is(err, services.ErrMCPTokenRevoked) {
+			c.WriteJSON(models.MCPServerMessage{
+				Type: "disconnect",
+				Payload: map[string]interface{}{
+					"reason": services.MCPDisconnectRevoked,
+				},
+			})
+		}
+		c.Close()
+		return
+	}
+
 	log.Printf("🔌 MCP client connecting: user=%s", userID)
 
+	if h.maxMessageBytes > 0 {
+		c.SetReadLimit(h.maxMessageBytes)
+	}
+
 	var mcpConn *models.MCPConnection
 	var clientID string
 
 	// Read loop
 	for {
-		var msg models.MCPClientMessage
-		err := c.ReadJSON(&msg)
+		msg, err := readMCPMessage(c)
 		if err != nil {
+			if errors.Is(err, fastws.ErrReadLimit) {
+				log.Printf("❌ MCP message from user %s exceeded %d bytes, connection closed", userID, h.maxMessageBytes)
+			}
 			if mcpConn != nil {
 				log.Printf("MCP client disconnected: %v", err)
-				h.mcpService.DisconnectClient(clientID)
+				h.disconnectOwn(clientID, mcpConn, services.MCPDisconnectConnectionLost)
 			}
 			break
 		}
@@ -82,6 +200,50 @@ func (h *MCPWebSocketHandler) HandleConnection(c *websocket.Conn) {
 
 			// Register client
 			conn, err := h.mcpService.RegisterClient(userID, &registration)
+			if errors.Is(err, services.ErrMCPClientRevoked) {
+				// A revoked client must not keep reconnecting either
+				log.Printf("❌ MCP client rejected: user=%s, client=%s: %v", userID, registration.ClientID, err)
+				c.WriteJSON(models.MCPServerMessage{
+					Type: "error",
+					Payload: map[string]interface{}{
+						"message": err.Error(),
+					},
+				})
+				c.WriteJSON(models.MCPServerMessage{
+					Type: "disconnect",
+					Payload: map[string]interface{}{
+						"reason": services.MCPDisconnectRevoked,
+					},
+				})
+				if mcpConn != nil {
+					h.disconnectOwn(clientID, mcpConn, services.MCPDisconnectClientClosed)
+				}
+				c.Close()
+				return
+			}
+			if errors.Is(err, services.ErrMCPProtocolUnsupported) {
+				// Retrying cannot help, so tell the client to stop reconnecting
+				log.Printf("❌ MCP client rejected: user=%s, client=%s: %v", userID, registration.ClientID, err)
+				c.WriteJSON(models.MCPServerMessage{
+					Type: "error",
+					Payload: map[string]interface{}{
+						"message": err.Error(),
+					},
+				})
+				c.WriteJSON(models.MCPServerMessage{
+					Type: "disconnect",
+					Payload: map[string]interface{}{
+						"reason":                   services.MCPDisconnectUnsupportedProtocol,
+						"min_protocol_version":     services.MCPMinProtocolVersion,
+						"current_protocol_version": services.MCPProtocolVersion,
+					},
+				})
+				if mcpConn != nil {
+					h.disconnectOwn(clientID, mcpConn, services.MCPDisconnectClientClosed)
+				}
+				c.Close()
+				return
+			}
 			if err != nil {
 				log.Printf("Failed to register MCP client: %v", err)
 				c.WriteJSON(models.MCPServerMessage{
@@ -101,6 +263,18 @@ func (h *MCPWebSocketHandler) HandleConnection(c *websocket.Conn) {
 
 			log.Printf("✅ MCP client registered successfully: user=%s, client=%s", userID, clientID)
 
+		case "add_tools", "remove_tools", "update_tool":
+			// Incremental changes to an already registered tool set
+			if err := h.applyToolChanges(clientID, msg); err != nil {
+				log.Printf("Failed to apply %s: %v", msg.Type, err)
+				c.WriteJSON(models.MCPServerMessage{
+					Type: "error",
+					Payload: map[string]interface{}{
+						"message": fmt.Sprintf("%s failed: %v", msg.Type, err),
+					},
+				})
+			}
+
 		case "tool_result":
 			// Handle tool execution result
 			resultData, err := json.Marshal(msg.Payload)
@@ -116,26 +290,41 @@ func (h *MCPWebSocketHandler) HandleConnection(c *websocket.Conn) {
 				continue
 			}
 
-			// Log execution for audit
-			execTime := 0 // We don't track this yet, but could add it
-			h.mcpService.LogToolExecution(userID, "", "", execTime, result.Success, result.Error)
-
+			// Calls are audited (with timings) by the caller of ExecuteToolOnClient
 			log.Printf("Tool result received: call_id=%s, success=%v", result.CallID, result.Success)
 
 			// Forward result to pending result channel
-			if conn, exists := h.mcpService.GetConnection(clientID); exists {
-				if resultChan, pending := conn.PendingResults[result.CallID]; pending {
-					// Non-blocking send to result channel
-					select {
-					case resultChan <- result:
-						log.Printf("✅ Tool result forwarded to waiting channel: %s", result.CallID)
-					default:
-						log.Printf("⚠️  Result channel full or closed for call_id: %s", result.CallID)
-					}
-				} else {
-					log.Printf("⚠️  No pending result channel for call_id: %s", result.CallID)
-				}
+			h.mcpService.DeliverToolResult(clientID, result)
+
+		case "tool_progress":
+			// Progress of a running tool call, passed on to whoever made the call
+			progressData, err := json.Marshal(msg.Payload)
+			if err != nil {
+				log.Printf("Failed to marshal tool progress: %v", err)
+				continue
+			}
+
+			var progress models.MCPToolProgress
+			if err := json.Unmarshal(progressData, &progress); err != nil {
+				log.Printf("Failed to unmarshal tool progress: %v", err)
+				continue
+			}
+			h.mcpService.DeliverToolProgress(clientID, progress)
+
+		case "tool_result_partial":
+			// Part of a streamed tool call's output; the final part completes the call
+			partialData, err := json.Marshal(msg.Payload)
+			if err != nil {
+				log.Printf("Failed to marshal partial tool result: %v", err)
+				continue
+			}
+
+			var partial models.MCPToolResultPartial
+			if err := json.Unmarshal(partialData, &partial); err != nil {
+				log.Printf("Failed to unmarshal partial tool result: %v", err)
+				continue
 			}
+			h.mcpService.DeliverToolResultPartial(clientID, partial)
 
 		case "heartbeat":
 			// Update heartbeat
@@ -149,7 +338,7 @@ func (h *MCPWebSocketHandler) HandleConnection(c *websocket.Conn) {
 		case "disconnect":
 			// Client is gracefully disconnecting
 			if clientID != "" {
-				h.mcpService.DisconnectClient(clientID)
+				h.disconnectOwn(clientID, mcpConn, services.MCPDisconnectClientClosed)
 			}
 			c.Close()
 			return
@@ -166,36 +355,123 @@ func (h *MCPWebSocketHandler) HandleConnection(c *websocket.Conn) {
 	}
 }
 
-// writeLoop handles outgoing messages to the MCP client
+// applyToolChanges applies an add_tools, remove_tools or update_tool message to the
+// client's existing tool set without re-registering the rest
+func (h *MCPWebSocketHandler) applyToolChanges(clientID string, msg models.MCPClientMessage) error {
+	if clientID == "" {
+		return fmt.Errorf("tools must be registered with register_tools first")
+	}
+
+	changeData, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	var changes models.MCPToolChanges
+	if err := json.Unmarshal(changeData, &changes); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	switch msg.Type {
+	case "add_tools":
+		_, err = h.mcpService.AddTools(clientID, changes.Tools)
+	case "remove_tools":
+		_, err = h.mcpService.RemoveTools(clientID, changes.Names)
+	case "update_tool":
+		if changes.Tool == nil {
+			return fmt.Errorf("missing tool")
+		}
+		err = h.mcpService.UpdateTool(clientID, *changes.Tool)
+	}
+	return err
+}
+
+// disconnectOwn disconnects clientID only if it is still this socket's connection.
+// A replaced or watchdog-reaped connection must not tear down its successor.
+func (h *MCPWebSocketHandler) disconnectOwn(clientID string, mcpConn *models.MCPConnection, reason string) {
+	if current, exists := h.mcpService.GetConnection(clientID); !exists || current != mcpConn {
+		return
+	}
+	h.mcpService.DisconnectClientWithReason(clientID, reason)
+}
+
+// writeLoop handles outgoing messages to the MCP client. Every write has a deadline;
+// a failed write closes the socket so the read loop runs the disconnect path.
 func (h *MCPWebSocketHandler) writeLoop(c *websocket.Conn, conn *models.MCPConnection) {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+	// Pings are jittered so connections opened together don't stay in lockstep
+	pingTimer := time.NewTimer(services.JitterMCPInterval(services.MCPPingInterval))
+	defer pingTimer.Stop()
 
 	for {
 		select {
-		case msg, ok := <-conn.WriteChan:
-			if !ok {
-				// Channel closed
+		case msg := <-conn.WriteChan:
+			c.SetWriteDeadline(time.Now().Add(h.writeTimeout))
+			err := writeMCPMessage(c, conn.Encoding, msg)
+			if err != nil {
+				log.Printf("Failed to write message to MCP client, closing connection: %v", err)
+				c.Close()
 				return
 			}
-
-			err := c.WriteJSON(msg)
-			if err != nil {
-				log.Printf("Failed to write message to MCP client: %v", err)
+			if msg.Type == "disconnect" {
+				// Server-initiated disconnect (revoked): close the socket too
+				c.Close()
 				return
 			}
 
 		case <-conn.StopChan:
-			// Stop signal received
+			// Stop signal received. The write channel is never closed, so drain what
+			// is queued without blocking to deliver a disconnect notice.
+			for drained := false; !drained; {
+				select {
+				case msg := <-conn.WriteChan:
+					if msg.Type == "disconnect" {
+						c.SetWriteDeadline(time.Now().Add(h.writeTimeout))
+						writeMCPMessage(c, conn.Encoding, msg)
+					}
+				default:
+					drained = true
+				}
+			}
+			// Close the socket so the read loop ends and the connection slot is freed,
+			// including for watchdog reaps that send no disconnect notice
+			c.Close()
 			return
 
-		case <-ticker.C:
+		case <-pingTimer.C:
 			// Send ping to keep connection alive
+			c.SetWriteDeadline(time.Now().Add(h.writeTimeout))
 			err := c.WriteMessage(websocket.PingMessage, []byte{})
 			if err != nil {
-				log.Printf("Failed to send ping to MCP client: %v", err)
+				log.Printf("Failed to send ping to MCP client, closing connection: %v", err)
+				c.Close()
 				return
 			}
+			pingTimer.Reset(services.JitterMCPInterval(services.MCPPingInterval))
 		}
 	}
 }
+
+// readMCPMessage reads one client message, decoding binary frames as msgpack and
+// text frames as JSON
+func readMCPMessage(c *websocket.Conn) (models.MCPClientMessage, error) {
+	frameType, data, err := c.ReadMessage()
+	if err != nil {
+		return models.MCPClientMessage{}, err
+	}
+	msgType, payload, err := services.DecodeMCPMessage(data, frameType == websocket.BinaryMessage)
+	if err != nil {
+		return models.MCPClientMessage{}, err
+	}
+	return models.MCPClientMessage{Type: msgType, Payload: payload}, nil
+}
+
+// writeMCPMessage writes one message in the connection's negotiated encoding
+func writeMCPMessage(c *websocket.Conn, encoding string, msg models.MCPServerMessage) error {
+	data, binary, err := services.EncodeMCPMessage(encoding, msg.Type, msg.Payload)
+	if err != nil {
+		return err
+	}
+	if binary {
+		return c.WriteMessage(websocket.BinaryMessage, data)
+	}
+	return c.WriteMessage(websocket.TextMessage, data)
+}
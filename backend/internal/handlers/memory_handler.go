@@ -115,6 +115,56 @@ func (h *MemoryHandler) ListMemories(c *fiber.Ctx) error {
 	})
 }
 
+// SearchMemories returns memories whose content or tags contain the query
+// GET /api/v1/memories/search?q=typescript&includeArchived=false&limit=20
+func (h *MemoryHandler) SearchMemories(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	query := strings.TrimSpace(c.Query("q", ""))
+	if query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Search query (q) is required",
+		})
+	}
+	if len(query) > 200 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Search query must be 200 characters or less",
+		})
+	}
+	includeArchived := c.Query("includeArchived", "false") == "true"
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	memories, err := h.memoryStorageService.SearchMemories(ctx, userID, query, includeArchived, limit)
+	if err != nil {
+		log.Printf("❌ [MEMORY-API] Failed to search memories: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search memories",
+		})
+	}
+
+	memoryResponses := make([]fiber.Map, len(memories))
+	for i, mem := range memories {
+		memoryResponses[i] = buildMemoryResponse(mem)
+	}
+
+	return c.JSON(fiber.Map{
+		"memories": memoryResponses,
+		"query":    query,
+		"count":    len(memoryResponses),
+	})
+}
+
 // GetMemory returns a single memory by ID
 // GET /api/v1/memories/:id
 func (h *MemoryHandler) GetMemory(c *fiber.Ctx) error {
@@ -416,6 +466,51 @@ func (h *MemoryHandler) DeleteMemory(c *fiber.Ctx) error {
 	})
 }
 
+// DeleteAllMemories permanently deletes all of the user's memories and their queued
+// extraction jobs. Requires ?confirm=true.
+// DELETE /api/v1/memories?confirm=true
+func (h *MemoryHandler) DeleteAllMemories(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	if c.Query("confirm") != "true" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Deleting all memories requires confirm=true",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	// Purge queued jobs first so none of them re-creates memories afterwards
+	jobsDeleted, err := h.memoryExtractionService.DeleteJobsByUser(ctx, userID)
+	if err != nil {
+		log.Printf("❌ [MEMORY-API] Failed to delete extraction jobs: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete memories",
+		})
+	}
+
+	memoriesDeleted, err := h.memoryStorageService.DeleteAllMemories(ctx, userID)
+	if err != nil {
+		log.Printf("❌ [MEMORY-API] Failed to delete memories: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete memories",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":          true,
+		"message":          "All memories deleted successfully",
+		"memories_deleted": memoriesDeleted,
+		"jobs_deleted":     jobsDeleted,
+	})
+}
+
 // ArchiveMemory archives a memory
 // POST /api/v1/memories/:id/archive
 func (h *MemoryHandler) ArchiveMemory(c *fiber.Ctx) error {
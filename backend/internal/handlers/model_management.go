@@ -688,6 +688,91 @@ func (h *ModelManagementHandler) BulkUpdateVisibility(c *fiber.Ctx) error {
 	})
 }
 
+// ================== MODEL CATALOG ==================
+
+// GetModelCatalog lists the cached models of enabled providers with their capabilities
+// GET /api/admin/models/catalog?provider_id=1&capability=vision|memory|image
+func (h *ModelManagementHandler) GetModelCatalog(c *fiber.Ctx) error {
+	catalog := services.GetModelCatalog()
+	if catalog == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Model catalog is not available",
+		})
+	}
+
+	var catalogModels []services.CatalogModel
+	var err error
+	switch capability := c.Query("capability"); capability {
+	case "":
+		catalogModels, err = catalog.All()
+	case "vision":
+		catalogModels, err = catalog.VisionModels()
+	case "memory":
+		catalogModels, err = catalog.MemoryModels()
+	case "image":
+		catalogModels, err = catalog.ImageModels()
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "capability must be one of vision, memory, image",
+		})
+	}
+	if err != nil {
+		log.Printf("❌ Failed to list model catalog: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list models: " + err.Error(),
+		})
+	}
+
+	if providerID := c.QueryInt("provider_id"); providerID != 0 {
+		filtered := []services.CatalogModel{}
+		for _, model := range catalogModels {
+			if model.ProviderID == providerID {
+				filtered = append(filtered, model)
+			}
+		}
+		catalogModels = filtered
+	}
+	if catalogModels == nil {
+		catalogModels = []services.CatalogModel{}
+	}
+
+	return c.JSON(fiber.Map{
+		"models": catalogModels,
+		"count":  len(catalogModels),
+	})
+}
+
+// RefreshModelCatalog re-reads the catalog's models now, e.g. after a model was added
+// to a provider. Without provider_id every provider is refreshed.
+// POST /api/admin/models/catalog/refresh?provider_id=1
+func (h *ModelManagementHandler) RefreshModelCatalog(c *fiber.Ctx) error {
+	adminUserID := c.Locals("user_id").(string)
+
+	catalog := services.GetModelCatalog()
+	if catalog == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Model catalog is not available",
+		})
+	}
+
+	providerID := c.QueryInt("provider_id")
+	log.Printf("🔄 Admin %s refreshing model catalog (provider %d)", adminUserID, providerID)
+
+	count, err := catalog.Refresh(providerID)
+	if err != nil {
+		log.Printf("❌ Failed to refresh model catalog: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to refresh model catalog: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"models":  count,
+		"message": "Model catalog refreshed",
+	})
+}
+
 // ================== GLOBAL TIER MANAGEMENT ==================
 
 // SetModelTier assigns a model to a global tier (tier1-tier5)
@@ -245,6 +245,15 @@ func (h *ScheduleHandler) TriggerNow(c *fiber.Ctx) error {
 		})
 	}
 
+	// The run itself happens in the background, so refuse disabled agents up front
+	if agent, err := h.agentService.GetAgent(agentID, userID); err == nil {
+		if err := services.CheckAgentEnabled(agent); err != nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Agent " + err.Error(),
+			})
+		}
+	}
+
 	log.Printf("▶️ [SCHEDULE] Triggering immediate run for schedule %s (agent: %s)", existingSchedule.ID.Hex(), agentID)
 
 	if err := h.schedulerService.TriggerNow(c.Context(), existingSchedule.ID.Hex(), userID); err != nil {
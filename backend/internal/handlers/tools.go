@@ -31,6 +31,7 @@ type ToolResponse struct {
 	Icon        string   `json:"icon"`
 	Category    string   `json:"category"`
 	Keywords    []string `json:"keywords"`
+	Tags        []string `json:"tags,omitempty"`
 	Source      string   `json:"source"`
 }
 
@@ -41,7 +42,8 @@ type CategoryResponse struct {
 	Tools []ToolResponse `json:"tools"`
 }
 
-// ListTools returns all tools available to the authenticated user, grouped by category
+// ListTools returns all tools available to the authenticated user, grouped by category.
+// ?tag=a,b limits the list to tools carrying any of the given tags.
 func (h *ToolsHandler) ListTools(c *fiber.Ctx) error {
 	// Extract user ID from auth middleware
 	userID, ok := c.Locals("user_id").(string)
@@ -51,8 +53,12 @@ func (h *ToolsHandler) ListTools(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get all tools for the user (built-in + MCP)
+	// Get all tools for the user (built-in + MCP), optionally filtered by tag
 	toolsList := h.registry.GetUserTools(userID)
+	tags := tagFilter(c)
+	if len(tags) > 0 {
+		toolsList = h.registry.GetUserToolsByTag(userID, tags...)
+	}
 
 	// Group tools by category
 	categoryMap := make(map[string][]ToolResponse)
@@ -79,6 +85,7 @@ func (h *ToolsHandler) ListTools(c *fiber.Ctx) error {
 			Icon:        tool.Icon,
 			Category:    tool.Category,
 			Keywords:    tool.Keywords,
+			Tags:        tool.Tags,
 			Source:      string(tool.Source),
 		}
 
@@ -106,12 +113,28 @@ func (h *ToolsHandler) ListTools(c *fiber.Ctx) error {
 		return categories[i].Name < categories[j].Name
 	})
 
+	total := h.registry.CountUserTools(userID)
+	if len(tags) > 0 {
+		total = len(toolsList)
+	}
+
 	return c.JSON(fiber.Map{
 		"categories": categories,
-		"total":      h.registry.CountUserTools(userID),
+		"total":      total,
 	})
 }
 
+// tagFilter parses the comma-separated ?tag= query parameter
+func tagFilter(c *fiber.Ctx) []string {
+	var tags []string
+	for _, tag := range strings.Split(c.Query("tag"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 // AvailableToolResponse represents a tool with credential metadata
 type AvailableToolResponse struct {
 	Name               string   `json:"name"`
@@ -120,13 +143,15 @@ type AvailableToolResponse struct {
 	Icon               string   `json:"icon"`
 	Category           string   `json:"category"`
 	Keywords           []string `json:"keywords"`
+	Tags               []string `json:"tags,omitempty"`
 	Source             string   `json:"source"`
 	RequiresCredential bool     `json:"requires_credential"`
 	IntegrationType    string   `json:"integration_type,omitempty"`
 }
 
 // GetAvailableTools returns tools filtered by user's credentials
-// Only tools that don't require credentials OR tools where user has configured credentials are returned
+// Only tools that don't require credentials OR tools where user has configured credentials are returned.
+// Supports the same ?tag= filter as ListTools.
 func (h *ToolsHandler) GetAvailableTools(c *fiber.Ctx) error {
 	// Extract user ID from auth middleware
 	userID, ok := c.Locals("user_id").(string)
@@ -143,6 +168,7 @@ func (h *ToolsHandler) GetAvailableTools(c *fiber.Ctx) error {
 
 	// Get filtered tools from tool service
 	filteredTools := h.toolService.GetAvailableTools(c.Context(), userID)
+	tags := tagFilter(c)
 
 	// Build response with metadata
 	toolResponses := make([]AvailableToolResponse, 0, len(filteredTools))
@@ -159,7 +185,7 @@ func (h *ToolsHandler) GetAvailableTools(c *fiber.Ctx) error {
 
 		// Get the actual tool to extract metadata
 		tool, exists := h.registry.GetUserTool(userID, name)
-		if !exists {
+		if !exists || (len(tags) > 0 && !tool.HasTag(tags...)) {
 			continue
 		}
 
@@ -174,6 +200,7 @@ func (h *ToolsHandler) GetAvailableTools(c *fiber.Ctx) error {
 			Icon:               tool.Icon,
 			Category:           tool.Category,
 			Keywords:           tool.Keywords,
+			Tags:               tool.Tags,
 			Source:             string(tool.Source),
 			RequiresCredential: requiresCredential,
 			IntegrationType:    integrationType,
@@ -219,7 +246,7 @@ func (h *ToolsHandler) GetAvailableTools(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"categories":     categories,
-		"total":          len(filteredTools),
+		"total":          len(toolResponses),
 		"filtered_count": filteredCount, // Number of tools filtered out due to missing credentials
 	})
 }
@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"claraverse/internal/execution"
+	"claraverse/internal/middleware"
 	"claraverse/internal/models"
 	"claraverse/internal/services"
+	"claraverse/internal/tools"
 	"context"
+	"errors"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,6 +21,9 @@ type TriggerHandler struct {
 	agentService     *services.AgentService
 	executionService *services.ExecutionService
 	workflowEngine   *execution.WorkflowEngine
+	activeExecutions *services.ActiveExecutionRegistry
+	webhooks         *services.CompletionWebhookService
+	executionLimiter *middleware.ExecutionLimiter
 }
 
 // NewTriggerHandler creates a new trigger handler
@@ -31,6 +39,21 @@ func NewTriggerHandler(
 	}
 }
 
+// SetActiveExecutions sets the registry used to cancel running executions by ID
+func (h *TriggerHandler) SetActiveExecutions(registry *services.ActiveExecutionRegistry) {
+	h.activeExecutions = registry
+}
+
+// SetCompletionWebhookService sets the service notifying agents' completion webhooks
+func (h *TriggerHandler) SetCompletionWebhookService(svc *services.CompletionWebhookService) {
+	h.webhooks = svc
+}
+
+// SetExecutionLimiter sets the limiter that counts replays against the daily execution limit
+func (h *TriggerHandler) SetExecutionLimiter(limiter *middleware.ExecutionLimiter) {
+	h.executionLimiter = limiter
+}
+
 // TriggerAgent executes an agent via API key
 // POST /api/trigger/:agentId
 func (h *TriggerHandler) TriggerAgent(c *fiber.Ctx) error {
@@ -62,6 +85,13 @@ func (h *TriggerHandler) TriggerAgent(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := services.CheckAgentEnabled(agent); err != nil {
+		log.Printf("⛔ [TRIGGER] Refusing to run disabled agent %s", agentID)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Agent " + err.Error(),
+		})
+	}
+
 	// Check if agent has a workflow
 	if agent.Workflow == nil || len(agent.Workflow.Blocks) == 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -69,12 +99,34 @@ func (h *TriggerHandler) TriggerAgent(c *fiber.Ctx) error {
 		})
 	}
 
+	// A workflow triggered from another execution's tool call nests under it
+	parentCtx, err := execution.JoinExecution(context.Background(), c.Get(tools.ExecutionLinkHeader), userID)
+	if err != nil {
+		log.Printf("🔁 [TRIGGER] Refusing nested execution of agent %s: %v", agentID, err)
+		return c.Status(fiber.StatusLoopDetected).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Get API key ID from context (for tracking)
 	var apiKeyID primitive.ObjectID
 	if apiKey, ok := c.Locals("api_key").(*models.APIKey); ok {
 		apiKeyID = apiKey.ID
 	}
 
+	// Reject rather than queue: API callers get a Retry-After to back off on
+	release, err := h.activeExecutions.TryAcquire(userID)
+	if err != nil {
+		var busy *services.ServerBusyError
+		if errors.As(err, &busy) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(busy.RetryAfter.Seconds())))
+		}
+		log.Printf("⚠️ [TRIGGER] Rejecting execution for user %s: %v", userID, err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Create execution record
 	execReq := &services.CreateExecutionRequest{
 		AgentID:         agentID,
@@ -87,6 +139,7 @@ func (h *TriggerHandler) TriggerAgent(c *fiber.Ctx) error {
 
 	execRecord, err := h.executionService.Create(c.Context(), execReq)
 	if err != nil {
+		release()
 		log.Printf("❌ [TRIGGER] Failed to create execution record: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create execution",
@@ -101,13 +154,21 @@ func (h *TriggerHandler) TriggerAgent(c *fiber.Ctx) error {
 	// Build execution options - block checker is DISABLED for API triggers
 	// Block checker should only run during platform testing (WebSocket), not production API calls
 	execOpts := &ExecuteWorkflowOptions{
-		AgentDescription:   agent.Description,
-		EnableBlockChecker: false, // Disabled for API triggers
-		CheckerModelID:     req.CheckerModelID,
+		AgentDescription: agent.Description,
+		AgentID:          agent.ID,
+		Agent:            agent,
+		Settings: &models.ExecutionDefaults{
+			CheckerModelID: req.CheckerModelID,
+			EnableHistory:  req.EnableHistory,
+			HistoryWindow:  req.HistoryWindow,
+		},
 	}
 
 	// Execute workflow asynchronously (pass userID for credential resolution)
-	go h.executeWorkflow(execRecord.ID, agent.Workflow, req.Input, userID, execOpts)
+	go func() {
+		defer release()
+		h.executeWorkflow(parentCtx, execRecord.ID, agent.Workflow, req.Input, userID, execOpts)
+	}()
 
 	log.Printf("🚀 [TRIGGER] Triggered agent %s via API (execution: %s)", agentID, execRecord.ID.Hex())
 
@@ -118,16 +179,151 @@ func (h *TriggerHandler) TriggerAgent(c *fiber.Ctx) error {
 	})
 }
 
+// ReplayExecution re-runs a past execution with its stored input as a new execution
+// of the agent's current workflow, recording which execution it replays
+// POST /api/executions/:id/replay
+func (h *TriggerHandler) ReplayExecution(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	originalID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid execution ID",
+		})
+	}
+
+	original, err := h.executionService.GetByIDAndUser(c.Context(), originalID, userID)
+	if err != nil {
+		if err.Error() == "execution not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Execution not found",
+			})
+		}
+		log.Printf("❌ [REPLAY] Failed to get execution: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get execution",
+		})
+	}
+
+	agent, err := h.agentService.GetAgent(original.AgentID, userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Agent not found",
+		})
+	}
+
+	if err := services.CheckAgentEnabled(agent); err != nil {
+		log.Printf("⛔ [REPLAY] Refusing to run disabled agent %s", agent.ID)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Agent " + err.Error(),
+		})
+	}
+
+	if agent.Workflow == nil || len(agent.Workflow.Blocks) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Agent has no workflow configured",
+		})
+	}
+
+	parentCtx, err := execution.JoinExecution(context.Background(), c.Get(tools.ExecutionLinkHeader), userID)
+	if err != nil {
+		log.Printf("🔁 [REPLAY] Refusing nested execution of agent %s: %v", agent.ID, err)
+		return c.Status(fiber.StatusLoopDetected).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Replays count against the daily execution limit like any other run
+	if h.executionLimiter != nil {
+		remaining, err := h.executionLimiter.GetRemainingExecutions(userID)
+		if errors.Is(err, middleware.ErrExecutionLimitUnavailable) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Execution limits are temporarily unavailable. Please try again shortly.",
+			})
+		} else if err != nil {
+			log.Printf("⚠️  [REPLAY] Failed to check execution limit: %v", err)
+		} else if remaining == 0 {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Daily execution limit exceeded",
+			})
+		}
+	}
+
+	release, err := h.activeExecutions.TryAcquire(userID)
+	if err != nil {
+		var busy *services.ServerBusyError
+		if errors.As(err, &busy) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(busy.RetryAfter.Seconds())))
+		}
+		log.Printf("⚠️ [REPLAY] Rejecting execution for user %s: %v", userID, err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	execRecord, err := h.executionService.Create(c.Context(), &services.CreateExecutionRequest{
+		AgentID:         agent.ID,
+		UserID:          userID,
+		WorkflowVersion: agent.Workflow.Version,
+		TriggerType:     "replay",
+		ReplayOf:        original.ID,
+		Input:           original.Input,
+	})
+	if err != nil {
+		release()
+		log.Printf("❌ [REPLAY] Failed to create execution record: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create execution",
+		})
+	}
+
+	if err := h.executionService.UpdateStatus(c.Context(), execRecord.ID, "running"); err != nil {
+		log.Printf("⚠️ [REPLAY] Failed to update status: %v", err)
+	}
+
+	if h.executionLimiter != nil {
+		if err := h.executionLimiter.IncrementCount(userID); err != nil {
+			log.Printf("⚠️  [REPLAY] Failed to increment execution count: %v", err)
+		}
+	}
+
+	execOpts := &ExecuteWorkflowOptions{
+		AgentDescription: agent.Description,
+		AgentID:          agent.ID,
+		Agent:            agent,
+	}
+	go func() {
+		defer release()
+		h.executeWorkflow(parentCtx, execRecord.ID, agent.Workflow, original.Input, userID, execOpts)
+	}()
+
+	log.Printf("🔁 [REPLAY] Replaying execution %s of agent %s (execution: %s)", original.ID.Hex(), agent.ID, execRecord.ID.Hex())
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"executionId": execRecord.ID.Hex(),
+		"replayOf":    original.ID.Hex(),
+		"status":      "running",
+		"message":     "Execution replay started",
+	})
+}
+
 // ExecuteWorkflowOptions contains options for executing a workflow
 type ExecuteWorkflowOptions struct {
-	AgentDescription   string
-	EnableBlockChecker bool
-	CheckerModelID     string
+	AgentDescription string
+	AgentID          string
+	Agent            *models.Agent // Notified through its completion webhook, if any
+	// Settings from the request; they override the agent's execution defaults
+	Settings *models.ExecutionDefaults
 }
 
-// executeWorkflow runs the workflow and updates the execution record
-func (h *TriggerHandler) executeWorkflow(executionID primitive.ObjectID, workflow *models.Workflow, input map[string]interface{}, userID string, opts *ExecuteWorkflowOptions) {
-	ctx := context.Background()
+// executeWorkflow runs the workflow and updates the execution record. ctx carries the
+// budget of the execution that triggered it, if any (see execution.JoinExecution).
+func (h *TriggerHandler) executeWorkflow(ctx context.Context, executionID primitive.ObjectID, workflow *models.Workflow, input map[string]interface{}, userID string, opts *ExecuteWorkflowOptions) {
+	startTime := time.Now()
+
+	// Register so the execution can be cancelled via POST /api/executions/:id/cancel
+	execCtx, active := h.activeExecutions.Register(ctx, executionID.Hex(), userID)
+	defer h.activeExecutions.Unregister(active)
 
 	// Create a channel for status updates (we'll drain it since API triggers don't need real-time)
 	statusChan := make(chan models.ExecutionUpdate, 100)
@@ -148,27 +344,46 @@ func (h *TriggerHandler) executeWorkflow(executionID primitive.ObjectID, workflo
 
 	// Build execution options - block checker DISABLED for API triggers
 	// Block checker should only run during platform testing (WebSocket), not production API calls
-	execOptions := &execution.ExecutionOptions{
-		EnableBlockChecker: false, // Disabled for API triggers
-	}
+	execOptions := &execution.ExecutionOptions{}
 	if opts != nil {
 		execOptions.WorkflowGoal = opts.AgentDescription
-		execOptions.CheckerModelID = opts.CheckerModelID
+		execOptions.AgentID = opts.AgentID
+		var agentDefaults *models.ExecutionDefaults
+		if opts.Agent != nil {
+			agentDefaults = opts.Agent.ExecutionDefaults
+		}
+		execOptions.ApplyDefaults(opts.Settings, agentDefaults)
 	}
+	execOptions.EnableBlockChecker = false // Disabled for API triggers, even when the agent enables it
 	log.Printf("🔍 [TRIGGER] Block checker disabled (API trigger - validation only runs during platform testing)")
 
 	// Execute the workflow
-	result, err := h.workflowEngine.ExecuteWithOptions(ctx, workflow, transformedInput, statusChan, execOptions)
+	result, err := h.workflowEngine.ExecuteWithOptions(execCtx, workflow, transformedInput, statusChan, execOptions)
 	close(statusChan)
+	h.activeExecutions.Unregister(active)
+
+	// The cancel endpoint already recorded the final status
+	if active.Cancelled() {
+		log.Printf("🛑 [TRIGGER] Execution %s cancelled", executionID.Hex())
+		return
+	}
 
 	// Update execution record
 	completeReq := &services.ExecutionCompleteRequest{
 		Status: "completed",
 	}
 
+	var agentID string
+	if opts != nil {
+		agentID = opts.AgentID
+	}
+	duration := time.Since(startTime).Milliseconds()
+	var apiResponse *models.ExecutionAPIResponse
+
 	if err != nil {
 		completeReq.Status = "failed"
 		completeReq.Error = err.Error()
+		apiResponse = models.NewFailedExecutionAPIResponse(executionID.Hex(), agentID, err.Error(), duration)
 		log.Printf("❌ [TRIGGER] Execution %s failed: %v", executionID.Hex(), err)
 	} else {
 		completeReq.Status = result.Status
@@ -177,12 +392,18 @@ func (h *TriggerHandler) executeWorkflow(executionID primitive.ObjectID, workflo
 		if result.Error != "" {
 			completeReq.Error = result.Error
 		}
+		apiResponse = h.workflowEngine.BuildAPIResponse(result, workflow, executionID.Hex(), duration)
+		apiResponse.Metadata.AgentID = agentID
 		log.Printf("✅ [TRIGGER] Execution %s completed with status: %s", executionID.Hex(), result.Status)
 	}
 
 	if err := h.executionService.Complete(ctx, executionID, completeReq); err != nil {
 		log.Printf("⚠️ [TRIGGER] Failed to complete execution record: %v", err)
 	}
+
+	if h.webhooks != nil && opts != nil {
+		h.webhooks.Notify(opts.Agent, apiResponse)
+	}
 }
 
 // GetExecutionStatus gets the status of an execution
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"claraverse/internal/filecache"
+	"claraverse/internal/vision"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VisionHandler handles image analysis requests
+type VisionHandler struct{}
+
+// NewVisionHandler creates a new vision handler
+func NewVisionHandler() *VisionHandler {
+	return &VisionHandler{}
+}
+
+// DescribeBatchRequest describes up to vision.MaxBatchSize uploaded images at once
+type DescribeBatchRequest struct {
+	Images []DescribeBatchImage `json:"images"`
+	// TimeoutSeconds bounds the whole batch (default and maximum vision.BatchTimeout)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// DescribeBatchImage is one image of a batch, by the file ID returned from /api/upload
+type DescribeBatchImage struct {
+	FileID      string `json:"file_id"`
+	Question    string `json:"question,omitempty"`
+	Detail      string `json:"detail,omitempty"`       // "brief" or "detailed"
+	ImageDetail string `json:"image_detail,omitempty"` // "auto", "low", "high" or "auto-smart"
+}
+
+// DescribeBatch describes several uploaded images concurrently
+// POST /api/vision/describe-batch
+func (h *VisionHandler) DescribeBatch(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" || userID == "anonymous" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	visionService := vision.GetService()
+	if visionService == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Vision service is not available",
+		})
+	}
+
+	var req DescribeBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if len(req.Images) == 0 || len(req.Images) > vision.MaxBatchSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("images must contain between 1 and %d images", vision.MaxBatchSize),
+		})
+	}
+
+	timeout := vision.BatchTimeout
+	if req.TimeoutSeconds < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "timeout_seconds must be positive",
+		})
+	}
+	if requested := time.Duration(req.TimeoutSeconds) * time.Second; requested > 0 && requested < timeout {
+		timeout = requested
+	}
+
+	// Images are read only once every file is known to be an image of this user
+	files := make([]*filecache.CachedFile, len(req.Images))
+	for i, image := range req.Images {
+		file, err := filecache.GetService().GetByUser(image.FileID, userID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("image %d: file not found or expired", i),
+			})
+		}
+		if !strings.HasPrefix(file.MimeType, "image/") || file.FilePath == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("image %d: file is not an image (type: %s)", i, file.MimeType),
+			})
+		}
+		files[i] = file
+	}
+
+	reqs := make([]vision.DescribeImageRequest, len(req.Images))
+	for i, image := range req.Images {
+		data, err := os.ReadFile(files[i].FilePath)
+		if err != nil {
+			log.Printf("❌ [VISION-API] Failed to read image %s: %v", image.FileID, err)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("image %d: file not found or expired", i),
+			})
+		}
+		reqs[i] = vision.DescribeImageRequest{
+			ImageData:   data,
+			MimeType:    files[i].MimeType,
+			Question:    image.Question,
+			Detail:      image.Detail,
+			ImageDetail: image.ImageDetail,
+			OwnerID:     userID,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+	defer cancel()
+
+	results, err := visionService.DescribeBatchContext(ctx, reqs)
+	if err != nil {
+		if visionErr, ok := vision.AsError(err); ok {
+			return c.Status(visionErr.HTTPStatus()).JSON(visionErr.Response())
+		}
+		log.Printf("❌ [VISION-API] Batch failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to describe images",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"results": results,
+	})
+}
@@ -229,6 +229,12 @@ func (h *WebSocketHandler) handleChatMessage(userConn *models.UserConnection, cl
 		log.Printf("🔒 Tools disabled for %s (agent builder mode)", userConn.ConnID)
 	}
 
+	// Update dry run flag: tool calls are validated and echoed back, never executed
+	userConn.DryRunTools = clientMsg.DryRunTools
+	if userConn.DryRunTools {
+		log.Printf("🧪 Tool dry run enabled for %s", userConn.ConnID)
+	}
+
 	// Priority-based history handling: prefer backend cache, fall back to client history
 	userConn.Mutex.Lock()
 
@@ -5,9 +5,14 @@ import (
 	"claraverse/internal/middleware"
 	"claraverse/internal/models"
 	"claraverse/internal/services"
+	"claraverse/pkg/workflowproto"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
@@ -17,10 +22,42 @@ import (
 
 // WorkflowWebSocketHandler handles WebSocket connections for workflow execution
 type WorkflowWebSocketHandler struct {
-	agentService      *services.AgentService
-	executionService  *services.ExecutionService
-	workflowEngine    *execution.WorkflowEngine
-	executionLimiter  *middleware.ExecutionLimiter
+	agentService     *services.AgentService
+	executionService *services.ExecutionService
+	workflowEngine   *execution.WorkflowEngine
+	executionLimiter *middleware.ExecutionLimiter
+	activeExecutions *services.ActiveExecutionRegistry
+	webhooks         *services.CompletionWebhookService
+
+	// Shutdown tracking: open sockets and in-flight executions
+	mu         sync.Mutex
+	conns      map[string]*workflowConn
+	executions map[string]*activeWorkflowExecution
+	inflight   sync.WaitGroup
+	draining   atomic.Bool
+}
+
+// workflowConn serializes writes to a WebSocket connection
+// (status updates, completion and shutdown notices come from different goroutines)
+type workflowConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+// WriteJSON writes a message under the connection's write lock
+func (wc *workflowConn) WriteJSON(v interface{}) error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	return wc.conn.WriteJSON(v)
+}
+
+// activeWorkflowExecution is an execution currently running on this server
+type activeWorkflowExecution struct {
+	execID       string
+	execObjectID primitive.ObjectID
+	hasRecord    bool
+	cancel       context.CancelFunc
+	interrupted  atomic.Bool
 }
 
 // NewWorkflowWebSocketHandler creates a new workflow WebSocket handler
@@ -33,6 +70,8 @@ func NewWorkflowWebSocketHandler(
 		agentService:     agentService,
 		workflowEngine:   workflowEngine,
 		executionLimiter: executionLimiter,
+		conns:            make(map[string]*workflowConn),
+		executions:       make(map[string]*activeWorkflowExecution),
 	}
 }
 
@@ -41,38 +80,22 @@ func (h *WorkflowWebSocketHandler) SetExecutionService(svc *services.ExecutionSe
 	h.executionService = svc
 }
 
-// WorkflowClientMessage represents a message from the client
-type WorkflowClientMessage struct {
-	Type    string         `json:"type"` // execute_workflow, cancel_execution
-	AgentID string         `json:"agent_id,omitempty"`
-	Input   map[string]any `json:"input,omitempty"`
-
-	// EnableBlockChecker enables block completion validation (optional)
-	// When true, each block is checked to ensure it accomplished its job
-	EnableBlockChecker bool `json:"enable_block_checker,omitempty"`
-
-	// CheckerModelID is the model to use for block checking (optional)
-	// Defaults to gpt-4o-mini for fast, cheap validation
-	CheckerModelID string `json:"checker_model_id,omitempty"`
+// SetCompletionWebhookService sets the service notifying agents' completion webhooks
+func (h *WorkflowWebSocketHandler) SetCompletionWebhookService(svc *services.CompletionWebhookService) {
+	h.webhooks = svc
 }
 
-// WorkflowServerMessage represents a message to send to the client
-type WorkflowServerMessage struct {
-	Type        string         `json:"type"` // connected, execution_started, execution_update, execution_complete, error
-	ExecutionID string         `json:"execution_id,omitempty"`
-	BlockID     string         `json:"block_id,omitempty"`
-	Status      string         `json:"status,omitempty"`
-	Inputs      map[string]any `json:"inputs,omitempty"`
-	Output      map[string]any `json:"output,omitempty"`
-	FinalOutput map[string]any `json:"final_output,omitempty"`
-	Duration    int64          `json:"duration_ms,omitempty"`
-	Error       string         `json:"error,omitempty"`
-
-	// APIResponse is the standardized, clean response for API consumers
-	// This provides a well-structured output with result, artifacts, files, etc.
-	APIResponse *models.ExecutionAPIResponse `json:"api_response,omitempty"`
+// SetActiveExecutions sets the registry used to cancel running executions by ID
+func (h *WorkflowWebSocketHandler) SetActiveExecutions(registry *services.ActiveExecutionRegistry) {
+	h.activeExecutions = registry
 }
 
+// WorkflowClientMessage represents a message from the client
+type WorkflowClientMessage = workflowproto.ClientMessage
+
+// WorkflowServerMessage represents a message to send to the client
+type WorkflowServerMessage = workflowproto.ServerMessage
+
 // Handle handles a new WebSocket connection for workflow execution
 func (h *WorkflowWebSocketHandler) Handle(c *websocket.Conn) {
 	userID := c.Locals("user_id").(string)
@@ -80,8 +103,18 @@ func (h *WorkflowWebSocketHandler) Handle(c *websocket.Conn) {
 
 	log.Printf("🔌 [WORKFLOW-WS] New connection: connID=%s, userID=%s", connID, userID)
 
+	wc := &workflowConn{conn: c}
+	h.mu.Lock()
+	h.conns[connID] = wc
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.conns, connID)
+		h.mu.Unlock()
+	}()
+
 	// Send connected message
-	if err := c.WriteJSON(WorkflowServerMessage{
+	if err := wc.WriteJSON(WorkflowServerMessage{
 		Type: "connected",
 	}); err != nil {
 		log.Printf("❌ [WORKFLOW-WS] Failed to send connected message: %v", err)
@@ -103,7 +136,7 @@ func (h *WorkflowWebSocketHandler) Handle(c *websocket.Conn) {
 		var clientMsg WorkflowClientMessage
 		if err := json.Unmarshal(msg, &clientMsg); err != nil {
 			log.Printf("⚠️ [WORKFLOW-WS] Invalid message format from %s: %v", connID, err)
-			c.WriteJSON(WorkflowServerMessage{
+			wc.WriteJSON(WorkflowServerMessage{
 				Type:  "error",
 				Error: "Invalid message format",
 			})
@@ -112,7 +145,7 @@ func (h *WorkflowWebSocketHandler) Handle(c *websocket.Conn) {
 
 		switch clientMsg.Type {
 		case "execute_workflow":
-			h.handleExecuteWorkflow(ctx, c, userID, clientMsg)
+			h.handleExecuteWorkflow(ctx, wc, userID, clientMsg)
 		case "cancel_execution":
 			cancel()
 			ctx, cancel = context.WithCancel(context.Background())
@@ -125,18 +158,40 @@ func (h *WorkflowWebSocketHandler) Handle(c *websocket.Conn) {
 // handleExecuteWorkflow handles a workflow execution request
 func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 	ctx context.Context,
-	c *websocket.Conn,
+	c *workflowConn,
 	userID string,
 	msg WorkflowClientMessage,
 ) {
 	startTime := time.Now()
 
+	// Refuse new work once shutdown has begun
+	if h.draining.Load() {
+		c.WriteJSON(WorkflowServerMessage{
+			Type:  "error",
+			Error: "Server is shutting down. Please retry in a moment.",
+		})
+		return
+	}
+	h.inflight.Add(1)
+	defer h.inflight.Done()
+
+	// Count the request as queued until it is registered as running
+	dequeue := h.activeExecutions.Queue()
+	defer dequeue()
+
 	log.Printf("🔍 [WORKFLOW-WS] Received execute request: AgentID=%s, Input=%+v", msg.AgentID, msg.Input)
 
 	// Check daily execution limit
 	if h.executionLimiter != nil {
 		remaining, err := h.executionLimiter.GetRemainingExecutions(userID)
-		if err != nil {
+		if errors.Is(err, middleware.ErrExecutionLimitUnavailable) {
+			log.Printf("⚠️  [WORKFLOW-WS] Rejecting execution for user %s: %v", userID, err)
+			c.WriteJSON(WorkflowServerMessage{
+				Type:  "error",
+				Error: "Execution limits are temporarily unavailable. Please try again shortly.",
+			})
+			return
+		} else if err != nil {
 			log.Printf("⚠️  [WORKFLOW-WS] Failed to check execution limit: %v", err)
 			// Continue on error, don't block execution
 		} else if remaining == 0 {
@@ -162,6 +217,15 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 		return
 	}
 
+	if err := services.CheckAgentEnabled(agent); err != nil {
+		log.Printf("⛔ [WORKFLOW-WS] Refusing to run disabled agent %s", msg.AgentID)
+		c.WriteJSON(WorkflowServerMessage{
+			Type:  "error",
+			Error: "Agent " + err.Error(),
+		})
+		return
+	}
+
 	if agent.Workflow == nil {
 		log.Printf("❌ [WORKFLOW-WS] No workflow for agent: %s", msg.AgentID)
 		c.WriteJSON(WorkflowServerMessage{
@@ -171,6 +235,22 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 		return
 	}
 
+	// Wait for a concurrency slot (bounded), or turn the request away as busy
+	release, err := h.activeExecutions.Acquire(ctx, userID)
+	if err != nil {
+		var busy *services.ServerBusyError
+		if errors.As(err, &busy) {
+			log.Printf("⚠️  [WORKFLOW-WS] Rejecting execution for user %s: %v", userID, err)
+			c.WriteJSON(WorkflowServerMessage{
+				Type:       "error",
+				Error:      busy.Error(),
+				RetryAfter: int(busy.RetryAfter.Seconds()),
+			})
+		}
+		return
+	}
+	defer release()
+
 	// Create execution record using ExecutionService (MongoDB) if available
 	var execID string
 	var execObjectID primitive.ObjectID
@@ -193,6 +273,10 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 		}
 		execID = execRecord.ID.Hex()
 		execObjectID = execRecord.ID
+
+		if err := h.executionService.UpdateStatus(ctx, execObjectID, "running"); err != nil {
+			log.Printf("⚠️ [WORKFLOW-WS] Failed to mark execution running: %v", err)
+		}
 	} else {
 		// Fallback: generate a local ID if ExecutionService is not available
 		execID = uuid.New().String()
@@ -201,6 +285,27 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 
 	log.Printf("🚀 [WORKFLOW-WS] Starting execution %s for agent %s", execID, msg.AgentID)
 
+	// Track the execution so shutdown can drain or interrupt it
+	execCtx, registered := h.activeExecutions.Register(ctx, execID, userID)
+	defer h.activeExecutions.Unregister(registered)
+	dequeue()
+	execCtx, execCancel := context.WithCancel(execCtx)
+	defer execCancel()
+	active := &activeWorkflowExecution{
+		execID:       execID,
+		execObjectID: execObjectID,
+		hasRecord:    h.executionService != nil,
+		cancel:       execCancel,
+	}
+	h.mu.Lock()
+	h.executions[execID] = active
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.executions, execID)
+		h.mu.Unlock()
+	}()
+
 	// Send execution started message
 	c.WriteJSON(WorkflowServerMessage{
 		Type:        "execution_started",
@@ -222,14 +327,20 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 	go func() {
 		for update := range statusChan {
 			update.ExecutionID = execID
+			msgType := "execution_update"
+			if update.Type == "block_progress" {
+				msgType = update.Type
+			}
 			c.WriteJSON(WorkflowServerMessage{
-				Type:        "execution_update",
+				Type:        msgType,
 				ExecutionID: execID,
 				BlockID:     update.BlockID,
 				Status:      update.Status,
 				Inputs:      update.Inputs,
 				Output:      update.Output,
 				Error:       update.Error,
+				Message:     update.Message,
+				Progress:    update.Progress,
 			})
 		}
 	}()
@@ -240,14 +351,14 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 	}
 	msg.Input["__user_id__"] = userID
 
-	// Build execution options - block checker is controlled by client request
-	// When enabled, it validates that each block actually accomplished its job
+	// Build execution options - settings in the request override the agent's defaults.
+	// The block checker validates that each block actually accomplished its job.
 	execOptions := &execution.ExecutionOptions{
-		WorkflowGoal:       agent.Description,      // Use agent description as workflow goal
-		EnableBlockChecker: msg.EnableBlockChecker, // Controlled by frontend toggle
-		CheckerModelID:     msg.CheckerModelID,
+		WorkflowGoal: agent.Description, // Use agent description as workflow goal
+		AgentID:      agent.ID,
 	}
-	if msg.EnableBlockChecker {
+	execOptions.ApplyDefaults(&msg.ExecutionDefaults, agent.ExecutionDefaults)
+	if execOptions.EnableBlockChecker {
 		log.Printf("🔍 [WORKFLOW-WS] Block checker ENABLED (model: %s)", execOptions.CheckerModelID)
 	} else {
 		log.Printf("🔍 [WORKFLOW-WS] Block checker DISABLED")
@@ -255,11 +366,38 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 
 	// Execute workflow
 	log.Printf("🔍 [WORKFLOW-WS] Executing with input: %+v", msg.Input)
-	result, err := h.workflowEngine.ExecuteWithOptions(ctx, agent.Workflow, msg.Input, statusChan, execOptions)
+	result, err := h.workflowEngine.ExecuteWithOptions(execCtx, agent.Workflow, msg.Input, statusChan, execOptions)
 	close(statusChan)
+	h.activeExecutions.Unregister(registered)
 
 	duration := time.Since(startTime).Milliseconds()
 
+	// Cancelled via the REST endpoint, which already recorded the final status
+	if registered.Cancelled() {
+		log.Printf("🛑 [WORKFLOW-WS] Execution %s cancelled", execID)
+		c.WriteJSON(WorkflowServerMessage{
+			Type:        "execution_complete",
+			ExecutionID: execID,
+			Status:      "cancelled",
+			Duration:    duration,
+			Error:       services.ExecutionCancelledError,
+		})
+		return
+	}
+
+	// Shutdown already recorded this execution as interrupted
+	if active.interrupted.Load() {
+		log.Printf("🛑 [WORKFLOW-WS] Execution %s interrupted by server shutdown", execID)
+		c.WriteJSON(WorkflowServerMessage{
+			Type:        "execution_complete",
+			ExecutionID: execID,
+			Status:      "interrupted",
+			Duration:    duration,
+			Error:       interruptedByShutdownError,
+		})
+		return
+	}
+
 	if err != nil {
 		log.Printf("❌ [WORKFLOW-WS] Execution failed: %v", err)
 
@@ -271,6 +409,10 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 			})
 		}
 
+		if h.webhooks != nil {
+			h.webhooks.Notify(agent, models.NewFailedExecutionAPIResponse(execID, msg.AgentID, err.Error(), duration))
+		}
+
 		c.WriteJSON(WorkflowServerMessage{
 			Type:        "execution_complete",
 			ExecutionID: execID,
@@ -302,14 +444,104 @@ func (h *WorkflowWebSocketHandler) handleExecuteWorkflow(
 	log.Printf("✅ [WORKFLOW-WS] Execution %s completed: status=%s, duration=%dms, result=%d chars",
 		execID, result.Status, duration, len(apiResponse.Result))
 
+	if h.webhooks != nil {
+		h.webhooks.Notify(agent, apiResponse)
+	}
+
 	// Send completion message with both legacy and new API response format
 	c.WriteJSON(WorkflowServerMessage{
 		Type:        "execution_complete",
 		ExecutionID: execID,
 		Status:      result.Status,
-		FinalOutput: result.Output,      // Legacy format (backward compat)
+		FinalOutput: result.Output, // Legacy format (backward compat)
 		Duration:    duration,
 		Error:       result.Error,
-		APIResponse: apiResponse,         // New standardized format
+		APIResponse: apiResponse, // New standardized format
 	})
 }
+
+// interruptedByShutdownError is stored on executions cut short by a server shutdown
+const interruptedByShutdownError = "Execution interrupted: server shut down before it finished"
+
+// Shutdown stops accepting new executions, notifies connected clients, and waits up
+// to drainTimeout for running executions to finish. Executions still running after
+// the timeout are cancelled and marked interrupted. All sockets are then closed.
+func (h *WorkflowWebSocketHandler) Shutdown(drainTimeout time.Duration) {
+	h.draining.Store(true)
+
+	h.mu.Lock()
+	running := len(h.executions)
+	conns := make([]*workflowConn, 0, len(h.conns))
+	for _, wc := range h.conns {
+		conns = append(conns, wc)
+	}
+	h.mu.Unlock()
+
+	log.Printf("🛑 [WORKFLOW-WS] Draining %d running execution(s) across %d connection(s) (timeout %s)",
+		running, len(conns), drainTimeout)
+
+	for _, wc := range conns {
+		wc.WriteJSON(WorkflowServerMessage{
+			Type:  "server_shutdown",
+			Error: fmt.Sprintf("Server is shutting down. Running executions have up to %s to finish.", drainTimeout),
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Printf("✅ [WORKFLOW-WS] All executions drained")
+	case <-time.After(drainTimeout):
+		h.interruptRunning()
+	}
+
+	// Close sockets cleanly
+	h.mu.Lock()
+	conns = conns[:0]
+	for _, wc := range h.conns {
+		conns = append(conns, wc)
+	}
+	h.mu.Unlock()
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown")
+	for _, wc := range conns {
+		wc.mu.Lock()
+		wc.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		wc.conn.Close()
+		wc.mu.Unlock()
+	}
+}
+
+// interruptRunning cancels executions that outlived the drain timeout and marks them interrupted
+func (h *WorkflowWebSocketHandler) interruptRunning() {
+	h.mu.Lock()
+	remaining := make([]*activeWorkflowExecution, 0, len(h.executions))
+	for _, active := range h.executions {
+		remaining = append(remaining, active)
+	}
+	h.mu.Unlock()
+
+	log.Printf("⚠️ [WORKFLOW-WS] Drain timeout reached, interrupting %d execution(s)", len(remaining))
+
+	for _, active := range remaining {
+		active.interrupted.Store(true)
+		active.cancel()
+
+		if active.hasRecord && h.executionService != nil {
+			// The execution's own context is cancelled, so record the outcome with a fresh one
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := h.executionService.Complete(ctx, active.execObjectID, &services.ExecutionCompleteRequest{
+				Status: "interrupted",
+				Error:  interruptedByShutdownError,
+			}); err != nil {
+				log.Printf("⚠️ [WORKFLOW-WS] Failed to mark execution %s interrupted: %v", active.execID, err)
+			}
+			cancel()
+		}
+	}
+}
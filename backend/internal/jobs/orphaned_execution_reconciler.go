@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"claraverse/internal/services"
+	"context"
+	"log"
+	"time"
+)
+
+// OrphanedExecutionReconciler marks executions left in pending/running by a crash as interrupted
+type OrphanedExecutionReconciler struct {
+	executionService *services.ExecutionService
+	threshold        time.Duration
+	interval         time.Duration
+	activeIDs        func() []string
+}
+
+// NewOrphanedExecutionReconciler creates a new reconciler.
+// threshold is how old a pending/running execution must be before it is treated as orphaned;
+// interval is how often the job repeats when registered with the scheduler.
+// activeIDs (optional) lists executions still running on this server, which are never touched.
+func NewOrphanedExecutionReconciler(
+	executionService *services.ExecutionService,
+	threshold time.Duration,
+	interval time.Duration,
+	activeIDs func() []string,
+) *OrphanedExecutionReconciler {
+	return &OrphanedExecutionReconciler{
+		executionService: executionService,
+		threshold:        threshold,
+		interval:         interval,
+		activeIDs:        activeIDs,
+	}
+}
+
+// Run marks orphaned executions as interrupted
+func (r *OrphanedExecutionReconciler) Run(ctx context.Context) error {
+	if r.executionService == nil {
+		log.Println("⚠️  [EXEC-RECONCILE] Reconciler disabled (requires ExecutionService)")
+		return nil
+	}
+
+	var active []string
+	if r.activeIDs != nil {
+		active = r.activeIDs()
+	}
+
+	count, err := r.executionService.MarkOrphanedInterrupted(ctx, r.threshold, active)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✅ [EXEC-RECONCILE] Reconciled orphaned executions: %d marked interrupted (threshold %s, %d active skipped)",
+		count, r.threshold, len(active))
+	return nil
+}
+
+// RunHeartbeat refreshes the heartbeat of the executions running on this server every
+// interval until ctx is cancelled, so reconcilers on other replicas leave them alone.
+// interval must be well below the reconcile threshold.
+func (r *OrphanedExecutionReconciler) RunHeartbeat(ctx context.Context, interval time.Duration) {
+	if r.executionService == nil || r.activeIDs == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.executionService.Heartbeat(ctx, r.activeIDs()); err != nil {
+				log.Printf("⚠️  [EXEC-RECONCILE] %v", err)
+			}
+		}
+	}
+}
+
+// GetNextRunTime returns when the job should run next
+func (r *OrphanedExecutionReconciler) GetNextRunTime() time.Time {
+	return time.Now().UTC().Add(r.interval)
+}
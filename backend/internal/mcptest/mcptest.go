@@ -0,0 +1,320 @@
+// Package mcptest provides an in-memory MCP client for tests of code that calls MCP
+// tools through services.MCPBridgeService, without a WebSocket or a MySQL server.
+//
+// A Client registers through the real RegisterClient, so tool registration, protocol
+// negotiation, dispatch, timeouts and auditing all run the production code paths:
+//
+//	service := mcptest.NewService(t)
+//	client := mcptest.Connect(t, service, "user-1", models.MCPTool{Name: "read_file"})
+//	client.Handle(func(call mcptest.ToolCall) (string, error) {
+//		return "file contents", nil
+//	})
+package mcptest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"claraverse/internal/database"
+	"claraverse/internal/models"
+	"claraverse/internal/securefile"
+	"claraverse/internal/services"
+	"claraverse/internal/tools"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultWait is how long Expect* helpers wait before failing the test
+const DefaultWait = 2 * time.Second
+
+// schema holds SQLite versions of the tables MCPBridgeService reads and writes
+var schema = []string{
+	`CREATE TABLE mcp_connections (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		client_id TEXT NOT NULL,
+		client_version TEXT,
+		platform TEXT,
+		connection_name TEXT,
+		is_active BOOLEAN DEFAULT TRUE,
+		connected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_heartbeat TIMESTAMP,
+		disconnected_at TIMESTAMP NULL,
+		revoked_at TIMESTAMP NULL
+	)`,
+	`CREATE TABLE mcp_tools (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		connection_id INTEGER NOT NULL,
+		tool_name TEXT NOT NULL,
+		tool_definition TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, tool_name)
+	)`,
+	`CREATE TABLE mcp_audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		tool_name TEXT NOT NULL,
+		conversation_id TEXT,
+		execution_time_ms INTEGER,
+		tool_time_ms INTEGER,
+		success BOOLEAN NOT NULL,
+		error_message TEXT,
+		executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+// NewDB returns an in-memory database with the MCP tables, closed when the test ends
+func NewDB(t testing.TB) *database.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+uuid.New().String()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("mcptest: failed to open database: %v", err)
+	}
+	// Every connection to an in-memory database would otherwise get its own copy
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("mcptest: failed to create schema: %v", err)
+		}
+	}
+	return &database.DB{DB: db}
+}
+
+// NewService returns an MCP bridge service backed by NewDB and the global tool registry.
+// Registered tools land in the shared registry, so tests should use distinct user IDs.
+// Binary results are stored in a temporary directory.
+func NewService(t testing.TB) *services.MCPBridgeService {
+	t.Helper()
+	service := services.NewMCPBridgeService(NewDB(t), tools.GetRegistry())
+	service.SetFileService(securefile.NewService(t.TempDir()))
+	return service
+}
+
+// ToolCall is a tool_call as the client receives it, after a JSON round trip
+type ToolCall struct {
+	CallID    string                 `json:"call_id"`
+	ToolName  string                 `json:"tool_name"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Timeout   int                    `json:"timeout"`
+	// StreamResults is set when the backend accepts the output in tool_result_partial frames
+	StreamResults bool `json:"stream_results"`
+}
+
+// HandlerFunc answers a tool call: a nil error becomes a successful result
+type HandlerFunc func(call ToolCall) (string, error)
+
+// Client is a registered in-memory MCP client. Tool calls go to the handler set with
+// Handle, or queue for ExpectCall; every other message queues for ExpectMessage.
+type Client struct {
+	ClientID string
+	UserID   string
+	Conn     *models.MCPConnection
+	Ack      models.MCPServerMessage // The registration acknowledgment
+
+	service  *services.MCPBridgeService
+	calls    chan ToolCall
+	messages chan models.MCPServerMessage
+	done     chan struct{}
+
+	mu      sync.Mutex
+	handler HandlerFunc
+}
+
+// Connect registers a client offering tools for userID at the current protocol version
+func Connect(t testing.TB, service *services.MCPBridgeService, userID string, mcpTools ...models.MCPTool) *Client {
+	t.Helper()
+	return Register(t, service, userID, &models.MCPToolRegistration{
+		ClientVersion:   "mcptest",
+		Platform:        "test",
+		ProtocolVersion: services.MCPProtocolVersion,
+		Tools:           mcpTools,
+	})
+}
+
+// Register registers a client with a custom registration (e.g. an older protocol
+// version) and waits for its ack. A missing ClientID is generated. The test fails
+// if registration fails.
+func Register(t testing.TB, service *services.MCPBridgeService, userID string, registration *models.MCPToolRegistration) *Client {
+	t.Helper()
+
+	if registration.ClientID == "" {
+		registration.ClientID = "mcptest-" + uuid.New().String()
+	}
+	conn, err := service.RegisterClient(userID, registration)
+	if err != nil {
+		t.Fatalf("mcptest: failed to register client: %v", err)
+	}
+
+	c := &Client{
+		ClientID: registration.ClientID,
+		UserID:   userID,
+		Conn:     conn,
+		service:  service,
+		calls:    make(chan ToolCall, 100),
+		messages: make(chan models.MCPServerMessage, 100),
+		done:     make(chan struct{}),
+	}
+	go c.readLoop()
+	t.Cleanup(c.Disconnect)
+
+	// The ack is sent asynchronously; wait for it so a quick disconnect can't race it
+	c.Ack = c.ExpectMessage(t, "ack")
+	return c
+}
+
+// readLoop plays the part of the WebSocket write loop until the connection closes
+func (c *Client) readLoop() {
+	defer close(c.done)
+	for {
+		var msg models.MCPServerMessage
+		select {
+		case msg = <-c.Conn.WriteChan:
+		case <-c.Conn.StopChan:
+			// Like the write loop, deliver what was queued before the stop
+			for {
+				select {
+				case msg := <-c.Conn.WriteChan:
+					if msg.Type != "tool_call" {
+						c.messages <- msg
+					}
+				default:
+					return
+				}
+			}
+		}
+
+		if msg.Type != "tool_call" {
+			c.messages <- msg
+			continue
+		}
+
+		call, err := decodeToolCall(msg)
+		if err != nil {
+			panic(fmt.Sprintf("mcptest: malformed tool_call: %v", err))
+		}
+		c.mu.Lock()
+		handler := c.handler
+		c.mu.Unlock()
+		if handler == nil {
+			c.calls <- call
+			continue
+		}
+		go func() {
+			start := time.Now()
+			result, err := handler(call)
+			c.deliver(call, result, err, time.Since(start))
+		}()
+	}
+}
+
+// decodeToolCall round-trips the payload through JSON, as a real client sees it
+func decodeToolCall(msg models.MCPServerMessage) (ToolCall, error) {
+	var call ToolCall
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return call, err
+	}
+	err = json.Unmarshal(data, &call)
+	return call, err
+}
+
+// Handle answers all later tool calls with handler; nil queues them for ExpectCall again
+func (c *Client) Handle(handler HandlerFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
+// ExpectCall returns the next queued tool call, failing the test if none arrives within DefaultWait
+func (c *Client) ExpectCall(t testing.TB) ToolCall {
+	t.Helper()
+	select {
+	case call := <-c.calls:
+		return call
+	case <-time.After(DefaultWait):
+		t.Fatalf("mcptest: no tool call received within %v", DefaultWait)
+		return ToolCall{}
+	}
+}
+
+// ExpectMessage returns the next non tool_call message, failing the test if none
+// arrives within DefaultWait or it is not of msgType
+func (c *Client) ExpectMessage(t testing.TB, msgType string) models.MCPServerMessage {
+	t.Helper()
+	select {
+	case msg := <-c.messages:
+		if msg.Type != msgType {
+			t.Fatalf("mcptest: expected %s message, got %s: %v", msgType, msg.Type, msg.Payload)
+		}
+		return msg
+	case <-time.After(DefaultWait):
+		t.Fatalf("mcptest: no %s message received within %v", msgType, DefaultWait)
+		return models.MCPServerMessage{}
+	}
+}
+
+// Respond answers call successfully. It returns false if the call is no longer waiting.
+func (c *Client) Respond(call ToolCall, result string) bool {
+	return c.deliver(call, result, nil, 0)
+}
+
+// RespondError fails call with errMsg. It returns false if the call is no longer waiting.
+func (c *Client) RespondError(call ToolCall, errMsg string) bool {
+	return c.deliver(call, "", fmt.Errorf("%s", errMsg), 0)
+}
+
+// Progress reports progress of call as the client's tool_progress would; a negative
+// percent is left out. It returns false if the caller is not listening for progress.
+func (c *Client) Progress(call ToolCall, message string, percent float64) bool {
+	progress := models.MCPToolProgress{CallID: call.CallID, Message: message}
+	if percent >= 0 {
+		progress.Percent = &percent
+	}
+	return c.service.DeliverToolProgress(c.ClientID, progress)
+}
+
+// Partial streams part of call's output as the client's tool_result_partial would;
+// final completes the call. It returns false if the call is not streaming.
+func (c *Client) Partial(call ToolCall, content string, final bool) bool {
+	return c.service.DeliverToolResultPartial(c.ClientID, models.MCPToolResultPartial{CallID: call.CallID, Content: content, Final: final})
+}
+
+// Deliver sends a hand-built result, e.g. one marked truncated by the client
+func (c *Client) Deliver(result models.MCPToolResult) bool {
+	return c.service.DeliverToolResult(c.ClientID, result)
+}
+
+func (c *Client) deliver(call ToolCall, result string, err error, took time.Duration) bool {
+	toolResult := models.MCPToolResult{
+		CallID:     call.CallID,
+		Success:    err == nil,
+		Result:     result,
+		DurationMs: took.Milliseconds(),
+	}
+	if err != nil {
+		toolResult.Error = err.Error()
+	}
+	return c.Deliver(toolResult)
+}
+
+// Disconnect disconnects the client as if it closed its socket. It is safe to call
+// more than once and does nothing if the connection was already replaced.
+func (c *Client) Disconnect() {
+	if current, exists := c.service.GetConnection(c.ClientID); exists && current == c.Conn {
+		c.service.DisconnectClientWithReason(c.ClientID, services.MCPDisconnectClientClosed)
+	}
+}
+
+// Done is closed once the service has closed the client's connection
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
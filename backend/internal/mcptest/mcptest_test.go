@@ -0,0 +1,584 @@
+package mcptest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+	"claraverse/internal/services"
+	"claraverse/internal/tools"
+
+	"github.com/google/uuid"
+)
+
+func testUserID() string {
+	return "mcptest-user-" + uuid.New().String()
+}
+
+func TestConnectRegistersClient(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+
+	client := Connect(t, service, userID, models.MCPTool{Name: "read_file", Description: "Reads a file"})
+
+	if !service.IsUserConnected(userID) {
+		t.Fatal("Expected user to be connected")
+	}
+	if got := client.Ack.Payload["tools_registered"]; got != 1 {
+		t.Errorf("Expected 1 tool registered, got %v", got)
+	}
+	if got := client.Ack.Payload["protocol_version"]; got != services.MCPProtocolVersion {
+		t.Errorf("Expected protocol version %d, got %v", services.MCPProtocolVersion, got)
+	}
+}
+
+func TestRegisterNegotiatesOlderProtocol(t *testing.T) {
+	service := NewService(t)
+
+	client := Register(t, service, testUserID(), &models.MCPToolRegistration{
+		Tools: []models.MCPTool{{Name: "read_file"}},
+	})
+
+	if client.Conn.ProtocolVersion != 1 {
+		t.Errorf("Expected a client without protocol_version to get version 1, got %d", client.Conn.ProtocolVersion)
+	}
+}
+
+func TestHandlerAnswersToolCalls(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "read_file"})
+	client.Handle(func(call ToolCall) (string, error) {
+		if call.ToolName != "read_file" {
+			return "", fmt.Errorf("unexpected tool %s", call.ToolName)
+		}
+		return "contents of " + call.Arguments["path"].(string), nil
+	})
+
+	result, err := service.ExecuteToolOnClient(context.Background(), userID, "read_file", map[string]interface{}{"path": "a.txt"}, time.Second)
+	if err != nil {
+		t.Fatalf("ExecuteToolOnClient failed: %v", err)
+	}
+	if result != "contents of a.txt" {
+		t.Errorf("Unexpected result: %q", result)
+	}
+}
+
+func TestExpectCallAndRespondError(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "write_file"})
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := service.ExecuteToolOnClient(context.Background(), userID, "write_file", map[string]interface{}{"path": "a.txt"}, time.Second)
+		errc <- err
+	}()
+
+	call := client.ExpectCall(t)
+	if call.ToolName != "write_file" || call.Arguments["path"] != "a.txt" {
+		t.Fatalf("Unexpected call: %+v", call)
+	}
+	if !client.RespondError(call, "permission denied") {
+		t.Fatal("Expected the call to be waiting for a result")
+	}
+
+	if err := <-errc; err == nil || err.Error() != "permission denied" {
+		t.Errorf("Expected the client's error, got %v", err)
+	}
+}
+
+func TestMissingToolErrors(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+
+	_, err := service.ExecuteToolOnClient(context.Background(), userID, "read_file", map[string]interface{}{}, time.Second)
+	if !errors.Is(err, tools.ErrToolUnavailable) {
+		t.Errorf("Expected ErrToolUnavailable without a client, got %v", err)
+	}
+
+	client := Connect(t, service, userID, models.MCPTool{Name: "read_file"})
+	_, err = service.ExecuteToolOnClient(context.Background(), userID, "made_up_tool", map[string]interface{}{}, time.Second)
+	if !errors.Is(err, tools.ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound for an unregistered tool, got %v", err)
+	}
+
+	// The client reports tools whose server is no longer running
+	errc := make(chan error, 1)
+	go func() {
+		_, err := service.ExecuteToolOnClient(context.Background(), userID, "read_file", map[string]interface{}{}, time.Second)
+		errc <- err
+	}()
+	call := client.ExpectCall(t)
+	client.Deliver(models.MCPToolResult{CallID: call.CallID, Error: "tool read_file not found in any running server", NotFound: true})
+	if err := <-errc; !errors.Is(err, tools.ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound from the client, got %v", err)
+	}
+}
+
+func TestUnansweredCallTimesOut(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "slow_tool"})
+
+	_, err := service.ExecuteToolOnClient(context.Background(), userID, "slow_tool", map[string]interface{}{}, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+
+	// A result arriving after the caller gave up goes nowhere
+	if client.Respond(client.ExpectCall(t), "too late") {
+		t.Error("Expected a late result not to be delivered")
+	}
+}
+
+func TestCancelledCallIsCancelledOnClient(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "scrape"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := service.ExecuteToolOnClient(ctx, userID, "scrape", map[string]interface{}{}, time.Minute)
+		errc <- err
+	}()
+	call := client.ExpectCall(t)
+	cancel()
+
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	msg := client.ExpectMessage(t, "cancel_tool_call")
+	if msg.Payload["call_id"] != call.CallID {
+		t.Errorf("Expected cancel for %s, got %v", call.CallID, msg.Payload["call_id"])
+	}
+	if client.Deliver(models.MCPToolResult{CallID: call.CallID, Cancelled: true}) {
+		t.Error("Expected the cancelled result not to be delivered")
+	}
+}
+
+func TestToolProgressReachesCaller(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "scrape"})
+
+	type update struct {
+		message string
+		percent float64
+	}
+	updates := make(chan update, 10)
+	ctx := services.WithMCPToolProgress(context.Background(), func(message string, percent float64) {
+		updates <- update{message, percent}
+	})
+	errc := make(chan error, 1)
+	go func() {
+		_, err := service.ExecuteToolOnClient(ctx, userID, "scrape", map[string]interface{}{}, time.Second)
+		errc <- err
+	}()
+	call := client.ExpectCall(t)
+
+	if !client.Progress(call, "Fetching page 1", -1) {
+		t.Fatal("Expected progress to be delivered")
+	}
+	// Throttled: too soon after the previous update
+	client.Progress(call, "Fetching page 2", 50)
+	// Completion always gets through, clamped to 100
+	client.Progress(call, "Done", 120)
+	client.Respond(call, "ok")
+	if err := <-errc; err != nil {
+		t.Fatalf("ExecuteToolOnClient failed: %v", err)
+	}
+
+	close(updates)
+	var got []update
+	for u := range updates {
+		got = append(got, u)
+	}
+	want := []update{{"Fetching page 1", -1}, {"Done", 100}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected updates %v, got %v", want, got)
+	}
+
+	// Finished calls, and calls whose caller is not listening, get no progress
+	if client.Progress(call, "late", 10) {
+		t.Error("Expected progress of a finished call not to be delivered")
+	}
+	go service.ExecuteToolOnClient(context.Background(), userID, "scrape", map[string]interface{}{}, time.Second)
+	unwatched := client.ExpectCall(t)
+	if client.Progress(unwatched, "nobody listens", 10) {
+		t.Error("Expected progress without a listener not to be delivered")
+	}
+	client.Respond(unwatched, "ok")
+}
+
+func TestStreamedToolResult(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "tail_logs", StreamsResults: true}, models.MCPTool{Name: "read_file"})
+
+	parts := make(chan string, 10)
+	ctx := services.WithMCPToolOutput(context.Background(), func(content string) { parts <- content })
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := service.ExecuteToolOnClient(ctx, userID, "tail_logs", map[string]interface{}{}, time.Second)
+		done <- outcome{result, err}
+	}()
+	call := client.ExpectCall(t)
+	if !call.StreamResults {
+		t.Fatal("Expected the call to ask for streamed results")
+	}
+
+	client.Partial(call, "line 1\n", false)
+	client.Partial(call, "line 2\n", false)
+	if !client.Partial(call, "line 3\n", true) {
+		t.Fatal("Expected the final part to complete the call")
+	}
+	got := <-done
+	if got.err != nil || got.result != "line 1\nline 2\nline 3\n" {
+		t.Errorf("Expected the whole streamed output, got %q (err %v)", got.result, got.err)
+	}
+	close(parts)
+	var received []string
+	for part := range parts {
+		received = append(received, part)
+	}
+	if len(received) != 3 || received[0] != "line 1\n" {
+		t.Errorf("Expected the caller to see each part as it arrived, got %q", received)
+	}
+
+	// Tools that did not opt in are not streamed
+	go service.ExecuteToolOnClient(context.Background(), userID, "read_file", map[string]interface{}{}, time.Second)
+	plain := client.ExpectCall(t)
+	if plain.StreamResults {
+		t.Error("Expected a tool without streams_results not to be streamed")
+	}
+	if client.Partial(plain, "nope", true) {
+		t.Error("Expected partial results of a non-streamed call to be rejected")
+	}
+	client.Respond(plain, "ok")
+}
+
+func TestStreamedToolTimeoutKeepsOutput(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "tail_logs", StreamsResults: true})
+	client.Handle(func(call ToolCall) (string, error) {
+		client.Partial(call, "started\n", false)
+		<-client.Done() // Runs until the client disconnects
+		return "", nil
+	})
+
+	result, err := service.ExecuteToolOnClient(context.Background(), userID, "tail_logs", map[string]interface{}{}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected the streamed output instead of a timeout, got %v", err)
+	}
+	if !strings.HasPrefix(result, "started\n") || !strings.Contains(result, "Incomplete result") {
+		t.Errorf("Expected the output marked incomplete, got %q", result)
+	}
+}
+
+func TestDisconnectClosesConnection(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "read_file"})
+
+	client.Disconnect()
+
+	select {
+	case <-client.Done():
+	case <-time.After(DefaultWait):
+		t.Fatal("Expected the connection to be closed")
+	}
+	if service.IsUserConnected(userID) {
+		t.Error("Expected user to be disconnected")
+	}
+	if _, err := service.ExecuteToolOnClient(context.Background(), userID, "read_file", map[string]interface{}{}, time.Second); err == nil {
+		t.Error("Expected calls after disconnect to fail")
+	}
+}
+
+func TestRevokedClientCannotRegisterAgain(t *testing.T) {
+	db := NewDB(t)
+	service := services.NewMCPBridgeService(db, tools.GetRegistry())
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "read_file"})
+
+	if err := service.RevokeConnection(client.ClientID, userID); err != nil {
+		t.Fatalf("RevokeConnection failed: %v", err)
+	}
+	if msg := client.ExpectMessage(t, "disconnect"); msg.Payload["reason"] != services.MCPDisconnectRevoked {
+		t.Errorf("Expected a revoked disconnect, got %v", msg.Payload)
+	}
+
+	// The revocation is stored, so it holds across restarts of the server
+	restarted := services.NewMCPBridgeService(db, tools.GetRegistry())
+	registration := &models.MCPToolRegistration{ClientID: client.ClientID, ProtocolVersion: services.MCPProtocolVersion}
+	if _, err := restarted.RegisterClient(userID, registration); !errors.Is(err, services.ErrMCPClientRevoked) {
+		t.Errorf("Expected ErrMCPClientRevoked, got %v", err)
+	}
+	if restarted.IsUserConnected(userID) {
+		t.Error("Expected the revoked client to stay disconnected")
+	}
+
+	// A new client of the same user is not affected
+	Connect(t, restarted, userID, models.MCPTool{Name: "read_file"})
+}
+
+// TestRevocationRevokesEarlierTokens tests that revoking a client also revokes the
+// access token it connected with, so a new client ID does not get around it
+func TestRevocationRevokesEarlierTokens(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "read_file"})
+	signedIn := time.Now().Add(-time.Minute)
+
+	if err := service.CheckTokenRevocation(userID, signedIn); err != nil {
+		t.Fatalf("Expected the token to be accepted before revocation, got %v", err)
+	}
+	if err := service.RevokeConnection(client.ClientID, userID); err != nil {
+		t.Fatalf("RevokeConnection failed: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		userID   string
+		issuedAt time.Time
+		want     error
+	}{
+		{"token of the revoked client", userID, signedIn, services.ErrMCPTokenRevoked},
+		{"token without issue time", userID, time.Time{}, services.ErrMCPTokenRevoked},
+		{"sign-in after the revocation", userID, time.Now().Add(time.Minute), nil},
+		{"other user", testUserID(), signedIn, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := service.CheckTokenRevocation(tc.userID, tc.issuedAt); !errors.Is(err, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestClientResumesWithinReconnectGrace(t *testing.T) {
+	service := NewService(t)
+	service.SetReconnectGrace(time.Minute)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "scrape"})
+
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := service.ExecuteToolOnClient(context.Background(), userID, "scrape", map[string]interface{}{}, 5*time.Second)
+		done <- outcome{result, err}
+	}()
+	call := client.ExpectCall(t)
+
+	// The heartbeats stop: the connection closes but its tool stays registered
+	if reaped := service.ReapStaleConnections(0); reaped != 1 {
+		t.Fatalf("Expected 1 connection reaped, got %d", reaped)
+	}
+	<-client.Done()
+	if service.IsUserConnected(userID) {
+		t.Error("Expected the reaped client to be disconnected")
+	}
+	if _, ok := tools.GetRegistry().GetUserTool(userID, "scrape"); !ok {
+		t.Error("Expected the tool to stay registered during the grace window")
+	}
+
+	// Back within the window, the client answers the call it had pending
+	resumed := Register(t, service, userID, &models.MCPToolRegistration{
+		ClientID:        client.ClientID,
+		ProtocolVersion: services.MCPProtocolVersion,
+		Tools:           []models.MCPTool{{Name: "scrape"}},
+	})
+	if resumed.Ack.Payload["resumed"] != true {
+		t.Errorf("Expected the ack to report a resumed connection, got %v", resumed.Ack.Payload)
+	}
+	if !resumed.Respond(call, "pages") {
+		t.Fatal("Expected the pending call's result to be delivered")
+	}
+	if got := <-done; got.err != nil || got.result != "pages" {
+		t.Errorf("Expected result %q, got %q (%v)", "pages", got.result, got.err)
+	}
+}
+
+func TestReconnectGraceExpires(t *testing.T) {
+	service := NewService(t)
+	service.SetReconnectGrace(time.Nanosecond)
+	expired := make(chan services.MCPConnectionEvent, 1)
+	service.SetEventHooks(services.MCPEventHooks{
+		OnReconnectExpired: func(event services.MCPConnectionEvent) { expired <- event },
+	})
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "scrape"})
+
+	service.ReapStaleConnections(0)
+	<-client.Done()
+	// The next sweep gives up on the client
+	if reaped := service.ReapStaleConnections(time.Hour); reaped != 0 {
+		t.Errorf("Expected no connections reaped, got %d", reaped)
+	}
+
+	select {
+	case event := <-expired:
+		if event.UserID != userID || event.Reason != services.MCPDisconnectReconnectExpired {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	default:
+		t.Fatal("Expected a reconnect_expired event")
+	}
+	if _, ok := tools.GetRegistry().GetUserTool(userID, "scrape"); ok {
+		t.Error("Expected the tool to be unregistered once the window ended")
+	}
+	// A later registration starts afresh
+	fresh := Connect(t, service, userID, models.MCPTool{Name: "scrape"})
+	if _, ok := fresh.Ack.Payload["resumed"]; ok {
+		t.Error("Expected a registration after the window not to resume")
+	}
+}
+
+// TestCallWaitsForReconnectingClient tests that a tool of a client within its grace
+// window, still offered to the model, is called once the client is back
+func TestCallWaitsForReconnectingClient(t *testing.T) {
+	service := NewService(t)
+	service.SetReconnectGrace(time.Minute)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "scrape"})
+
+	service.ReapStaleConnections(0)
+	<-client.Done()
+	if !service.IsUserReachable(userID) {
+		t.Fatal("Expected a client within its grace window to be reachable")
+	}
+
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := service.ExecuteToolOnClient(context.Background(), userID, "scrape", map[string]interface{}{}, 5*time.Second)
+		done <- outcome{result, err}
+	}()
+	select {
+	case got := <-done:
+		t.Fatalf("Expected the call to wait for the client, got %q (%v)", got.result, got.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	resumed := Register(t, service, userID, &models.MCPToolRegistration{
+		ClientID:        client.ClientID,
+		ProtocolVersion: services.MCPProtocolVersion,
+		Tools:           []models.MCPTool{{Name: "scrape"}},
+	})
+	resumed.Respond(resumed.ExpectCall(t), "pages")
+	if got := <-done; got.err != nil || got.result != "pages" {
+		t.Errorf("Expected result %q, got %q (%v)", "pages", got.result, got.err)
+	}
+}
+
+// TestReconnectGraceExpiryFailsCalls tests that calls pending on or waiting for a
+// client that does not come back fail when its window ends, not at their timeout
+func TestReconnectGraceExpiryFailsCalls(t *testing.T) {
+	service := NewService(t)
+	service.SetReconnectGrace(time.Nanosecond)
+	userID := testUserID()
+	client := Connect(t, service, userID, models.MCPTool{Name: "scrape"})
+
+	pending := make(chan error, 1)
+	go func() {
+		_, err := service.ExecuteToolOnClient(context.Background(), userID, "scrape", map[string]interface{}{}, time.Minute)
+		pending <- err
+	}()
+	client.ExpectCall(t)
+	service.ReapStaleConnections(0)
+	<-client.Done()
+
+	_, err := service.ExecuteToolOnClient(context.Background(), userID, "scrape", map[string]interface{}{}, time.Minute)
+	if !errors.Is(err, tools.ErrToolUnavailable) {
+		t.Errorf("Expected a call after the window to be unavailable, got %v", err)
+	}
+
+	service.ReapStaleConnections(time.Hour)
+	select {
+	case err := <-pending:
+		if err == nil || !strings.Contains(err.Error(), "did not reconnect") {
+			t.Errorf("Expected the pending call to fail with the expiry, got %v", err)
+		}
+	case <-time.After(DefaultWait):
+		t.Fatal("Expected the pending call to fail when the window ended")
+	}
+	if service.IsUserReachable(userID) {
+		t.Error("Expected the user to be unreachable once the window ended")
+	}
+}
+
+func TestRegistrationOverToolLimitIsRejected(t *testing.T) {
+	service := NewService(t)
+	service.SetMaxToolsPerClient(2)
+
+	_, err := service.RegisterClient(testUserID(), &models.MCPToolRegistration{
+		ClientID:        "mcptest-" + uuid.New().String(),
+		ProtocolVersion: services.MCPProtocolVersion,
+		Tools:           []models.MCPTool{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+	})
+	if !errors.Is(err, services.ErrMCPTooManyTools) {
+		t.Fatalf("Expected ErrMCPTooManyTools, got %v", err)
+	}
+}
+
+func TestAddingToolsPastLimitIsRejected(t *testing.T) {
+	service := NewService(t)
+	service.SetMaxToolsPerClient(2)
+	client := Connect(t, service, testUserID(), models.MCPTool{Name: "a"}, models.MCPTool{Name: "b"})
+
+	if _, err := service.AddTools(client.ClientID, []models.MCPTool{{Name: "c"}}); !errors.Is(err, services.ErrMCPTooManyTools) {
+		t.Fatalf("Expected ErrMCPTooManyTools, got %v", err)
+	}
+	if _, err := service.AddTools(client.ClientID, []models.MCPTool{{Name: "a", Description: "updated"}}); err != nil {
+		t.Errorf("Expected updating a registered tool to pass, got %v", err)
+	}
+}
+
+func TestReconnectAckReportsToolChanges(t *testing.T) {
+	service := NewService(t)
+	userID := testUserID()
+
+	first := Connect(t, service, userID,
+		models.MCPTool{Name: "read_file"}, models.MCPTool{Name: "list_dir"}, models.MCPTool{Name: "search"})
+	if _, ok := first.Ack.Payload["tool_changes"]; ok {
+		t.Error("Expected no tool_changes on a user's first connection")
+	}
+	first.Disconnect()
+
+	second := Connect(t, service, userID,
+		models.MCPTool{Name: "read_file"}, models.MCPTool{Name: "search", Description: "now with regex"}, models.MCPTool{Name: "write_file"})
+	changes, ok := second.Ack.Payload["tool_changes"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected tool_changes in the reconnect ack, got %v", second.Ack.Payload)
+	}
+
+	want := map[string][]string{
+		"added":     {"write_file"},
+		"removed":   {"list_dir"},
+		"updated":   {"search"},
+		"unchanged": {"read_file"},
+	}
+	for key, names := range want {
+		if got := changes[key].([]string); strings.Join(got, ",") != strings.Join(names, ",") {
+			t.Errorf("Expected %s %v, got %v", key, names, got)
+		}
+	}
+}
@@ -51,8 +51,10 @@ func AuthMiddleware(supabaseAuth *auth.SupabaseAuth) fiber.Handler {
 		}
 
 		// 2. Try query parameter (for WebSocket connections)
+		fromQuery := false
 		if token == "" {
 			token = c.Query("token")
+			fromQuery = token != ""
 		}
 
 		// No token found
@@ -62,8 +64,13 @@ func AuthMiddleware(supabaseAuth *auth.SupabaseAuth) fiber.Handler {
 			})
 		}
 
-		// Verify token with Supabase
-		user, err := supabaseAuth.VerifyToken(token)
+		// Verify token with Supabase. WebSocket upgrades are verified locally against the
+		// project's signing keys so reconnect storms don't hit Supabase on every upgrade.
+		verify := supabaseAuth.VerifyToken
+		if fromQuery {
+			verify = supabaseAuth.VerifyTokenLocally
+		}
+		user, err := verify(token)
 		if err != nil {
 			log.Printf("❌ Auth failed: %v", err)
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -98,8 +105,10 @@ func OptionalAuthMiddleware(supabaseAuth *auth.SupabaseAuth) fiber.Handler {
 		}
 
 		// 2. Try query parameter (for WebSocket connections)
+		fromQuery := false
 		if token == "" {
 			token = c.Query("token")
+			fromQuery = token != ""
 		}
 
 		// If no token found, proceed as anonymous
@@ -132,8 +141,13 @@ func OptionalAuthMiddleware(supabaseAuth *auth.SupabaseAuth) fiber.Handler {
 			return c.Next()
 		}
 
-		// Verify token with Supabase
-		user, err := supabaseAuth.VerifyToken(token)
+		// Verify token with Supabase. WebSocket upgrades are verified locally against the
+		// project's signing keys so reconnect storms don't hit Supabase on every upgrade.
+		verify := supabaseAuth.VerifyToken
+		if fromQuery {
+			verify = supabaseAuth.VerifyTokenLocally
+		}
+		user, err := verify(token)
 		if err != nil {
 			log.Printf("⚠️  Token validation failed: %v (continuing as anonymous)", err)
 			c.Locals("user_id", "anonymous")
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"claraverse/pkg/auth"
+	"context"
+	"fmt"
 	"log"
 	"os"
 
@@ -72,6 +74,7 @@ func LocalAuthMiddleware(jwtAuth *auth.LocalJWTAuth) fiber.Handler {
 		c.Locals("user_id", user.ID)
 		c.Locals("user_email", user.Email)
 		c.Locals("user_role", user.Role)
+		c.Locals("token_issued_at", user.IssuedAt)
 
 		log.Printf("✅ Authenticated user: %s (%s)", user.Email, user.ID)
 		return c.Next()
@@ -141,12 +144,96 @@ func OptionalLocalAuthMiddleware(jwtAuth *auth.LocalJWTAuth) fiber.Handler {
 		c.Locals("user_id", user.ID)
 		c.Locals("user_email", user.Email)
 		c.Locals("user_role", user.Role)
+		c.Locals("token_issued_at", user.IssuedAt)
 
 		log.Printf("✅ Authenticated user: %s (%s)", user.Email, user.ID)
 		return c.Next()
 	}
 }
 
+// SupabaseWebSocketAuth accepts Supabase access tokens on WebSocket upgrades for users
+// that already have an account here. Tokens are verified locally against the
+// project's signing keys, so reconnect storms don't call Supabase on every upgrade.
+type SupabaseWebSocketAuth struct {
+	Auth *auth.SupabaseAuth
+	// LookupUser returns the existing user linked to a Supabase user ID
+	LookupUser func(ctx context.Context, supabaseUserID string) (*auth.User, error)
+}
+
+// authenticate verifies token and maps its subject to an existing user
+func (s *SupabaseWebSocketAuth) authenticate(ctx context.Context, token string) (*auth.User, error) {
+	supabaseUser, err := s.Auth.VerifyTokenLocally(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.LookupUser(ctx, supabaseUser.ID)
+	if err != nil {
+		return nil, fmt.Errorf("no account for Supabase user %s: %w", supabaseUser.ID, err)
+	}
+	user.IssuedAt = supabaseUser.IssuedAt
+	return user, nil
+}
+
+// OptionalWebSocketAuthMiddleware is OptionalLocalAuthMiddleware for WebSocket upgrades
+// that also accepts Supabase access tokens of existing users when supabaseAuth is set
+// (opt-in with SUPABASE_WEBSOCKET_AUTH). supabaseAuth may be nil (local tokens only).
+func OptionalWebSocketAuthMiddleware(jwtAuth *auth.LocalJWTAuth, supabaseAuth *SupabaseWebSocketAuth) fiber.Handler {
+	localAuth := OptionalLocalAuthMiddleware(jwtAuth)
+	if supabaseAuth == nil {
+		return localAuth
+	}
+
+	return supabaseAuth.middleware(jwtAuth, localAuth)
+}
+
+// WebSocketClientAuthMiddleware is LocalAuthMiddleware that accepts the same tokens as
+// OptionalWebSocketAuthMiddleware, for REST calls a WebSocket client makes with the
+// token it connects with (e.g. requesting an MCP connection token)
+func WebSocketClientAuthMiddleware(jwtAuth *auth.LocalJWTAuth, supabaseAuth *SupabaseWebSocketAuth) fiber.Handler {
+	localAuth := LocalAuthMiddleware(jwtAuth)
+	if supabaseAuth == nil {
+		return localAuth
+	}
+	return supabaseAuth.middleware(jwtAuth, localAuth)
+}
+
+// middleware authenticates Supabase tokens of linked users and passes every other
+// request (no token, local tokens, unknown tokens) to localAuth
+func (s *SupabaseWebSocketAuth) middleware(jwtAuth *auth.LocalJWTAuth, localAuth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var token string
+		if extracted, err := auth.ExtractToken(c.Get("Authorization")); err == nil {
+			token = extracted
+		} else {
+			token = c.Query("token")
+		}
+		if token == "" {
+			return localAuth(c)
+		}
+		if jwtAuth != nil {
+			if _, err := jwtAuth.VerifyAccessToken(token); err == nil {
+				return localAuth(c)
+			}
+		}
+
+		user, err := s.authenticate(c.UserContext(), token)
+		if err != nil {
+			// Not a Supabase token of a known user either; let the local middleware reject it
+			log.Printf("⚠️  Supabase token not accepted: %v", err)
+			return localAuth(c)
+		}
+
+		// Store authenticated user info
+		c.Locals("user_id", user.ID)
+		c.Locals("user_email", user.Email)
+		c.Locals("user_role", user.Role)
+		c.Locals("token_issued_at", user.IssuedAt)
+
+		log.Printf("✅ Authenticated Supabase user: %s (%s)", user.Email, user.ID)
+		return c.Next()
+	}
+}
+
 // RateLimitedAuthMiddleware combines rate limiting with authentication
 // Rate limit: 5 attempts per 15 minutes per IP
 // Note: This function is currently unused. Apply rate limiting separately in routes if needed.
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"claraverse/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSupabaseURL = "https://project.supabase.co"
+
+// signTestSupabaseToken signs an HS256 Supabase access token for supabaseUserID
+func signTestSupabaseToken(t *testing.T, secret, supabaseUserID string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.JWTClaims{
+		UserID: supabaseUserID,
+		Email:  "a@b.c",
+		Role:   "authenticated",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testSupabaseURL + "/auth/v1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// newTestSupabaseWebSocketAuth links Supabase user "supabase-1" to local user "local-1"
+func newTestSupabaseWebSocketAuth(secret string) *SupabaseWebSocketAuth {
+	supabaseAuth := auth.NewSupabaseAuth(testSupabaseURL, "service-key", 0, 0)
+	supabaseAuth.JWTSecret = secret
+	return &SupabaseWebSocketAuth{
+		Auth: supabaseAuth,
+		LookupUser: func(ctx context.Context, supabaseUserID string) (*auth.User, error) {
+			if supabaseUserID != "supabase-1" {
+				return nil, errors.New("user not found")
+			}
+			return &auth.User{ID: "local-1", Email: "a@b.c", Role: "user"}, nil
+		},
+	}
+}
+
+// authenticatedUserID runs handler and returns the user_id it stored
+func authenticatedUserID(t *testing.T, handler fiber.Handler, target string) string {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/ws", handler, func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("user_id").(string)
+		return c.SendString(userID)
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestOptionalWebSocketAuthMapsSupabaseUsers(t *testing.T) {
+	const secret = "supabase-secret"
+	jwtAuth, err := auth.NewLocalJWTAuth("local-secret-that-is-long-enough-1234", time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	supabaseAuth := newTestSupabaseWebSocketAuth(secret)
+
+	cases := []struct {
+		name         string
+		supabaseAuth *SupabaseWebSocketAuth
+		token        string
+		want         string
+	}{
+		{"linked user", supabaseAuth, signTestSupabaseToken(t, secret, "supabase-1"), "local-1"},
+		{"no linked account", supabaseAuth, signTestSupabaseToken(t, secret, "supabase-2"), "anonymous"},
+		{"not opted in", nil, signTestSupabaseToken(t, secret, "supabase-1"), "anonymous"},
+		{"wrong signature", supabaseAuth, signTestSupabaseToken(t, "other-secret", "supabase-1"), "anonymous"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := OptionalWebSocketAuthMiddleware(jwtAuth, tc.supabaseAuth)
+			if got := authenticatedUserID(t, handler, "/ws?token="+tc.token); got != tc.want {
+				t.Errorf("expected user %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestSupabaseClientCanRequestConnectToken tests that an MCP client logged in with
+// Supabase can call the connection token endpoint with its token in the header
+func TestSupabaseClientCanRequestConnectToken(t *testing.T) {
+	const secret = "supabase-secret"
+	jwtAuth, err := auth.NewLocalJWTAuth("local-secret-that-is-long-enough-1234", time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Post("/api/mcp/connect-token", WebSocketClientAuthMiddleware(jwtAuth, newTestSupabaseWebSocketAuth(secret)), func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("user_id").(string)
+		return c.SendString(userID)
+	})
+
+	cases := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantUser   string
+	}{
+		{"linked Supabase user", signTestSupabaseToken(t, secret, "supabase-1"), fiber.StatusOK, "local-1"},
+		{"unlinked Supabase user", signTestSupabaseToken(t, secret, "supabase-2"), fiber.StatusUnauthorized, ""},
+		{"garbage", "not-a-token", fiber.StatusUnauthorized, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/mcp/connect-token", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantStatus, resp.StatusCode, body)
+			}
+			if tc.wantUser != "" && string(body) != tc.wantUser {
+				t.Errorf("expected user %q, got %q", tc.wantUser, body)
+			}
+		})
+	}
+}
@@ -3,18 +3,32 @@ package middleware
 import (
 	"claraverse/internal/services"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 )
 
-// ExecutionLimiter middleware checks daily execution limits based on user tier
+// ErrExecutionLimitUnavailable is returned when Redis is down and the limiter fails closed
+var ErrExecutionLimitUnavailable = errors.New("execution limits are temporarily unavailable")
+
+// ExecutionLimiter middleware checks daily execution limits based on user tier.
+// Counts live in Redis; while Redis is unavailable the limiter either keeps a
+// per-instance in-memory count (the default) or, when failing closed, rejects executions.
 type ExecutionLimiter struct {
 	tierService *services.TierService
 	redis       *redis.Client
+	failClosed  bool
+
+	// Degraded-mode fallback, used only while Redis is unavailable
+	mu       sync.Mutex
+	degraded bool
+	fallback map[string]int64 // Redis key -> executions counted in memory
 }
 
 // NewExecutionLimiter creates a new execution limiter middleware
@@ -22,9 +36,16 @@ func NewExecutionLimiter(tierService *services.TierService, redisClient *redis.C
 	return &ExecutionLimiter{
 		tierService: tierService,
 		redis:       redisClient,
+		fallback:    make(map[string]int64),
 	}
 }
 
+// SetFailClosed makes the limiter reject executions while Redis is unavailable
+// instead of falling back to in-memory counting
+func (el *ExecutionLimiter) SetFailClosed(failClosed bool) {
+	el.failClosed = failClosed
+}
+
 // CheckLimit verifies if user can execute another workflow today
 func (el *ExecutionLimiter) CheckLimit(c *fiber.Ctx) error {
 	userID := c.Locals("user_id")
@@ -51,25 +72,21 @@ func (el *ExecutionLimiter) CheckLimit(c *fiber.Ctx) error {
 		return c.Next()
 	}
 
-	// Get today's execution count from Redis
-	today := time.Now().UTC().Format("2006-01-02")
-	key := fmt.Sprintf("executions:%s:%s", userIDStr, today)
-
-	// Get current count
-	count, err := el.redis.Get(ctx, key).Int64()
-	if err != nil && err != redis.Nil {
-		log.Printf("⚠️  Failed to get execution count from Redis: %v", err)
-		// On Redis error, allow execution but log warning
-		return c.Next()
+	key := executionCountKey(userIDStr)
+	count, err := el.count(ctx, key)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Execution limits are temporarily unavailable. Please try again shortly.",
+		})
 	}
 
 	// Check if limit exceeded
 	if count >= limits.MaxExecutionsPerDay {
 		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-			"error":     "Daily execution limit exceeded",
-			"limit":     limits.MaxExecutionsPerDay,
-			"used":      count,
-			"reset_at":  getNextMidnightUTC(),
+			"error":    "Daily execution limit exceeded",
+			"limit":    limits.MaxExecutionsPerDay,
+			"used":     count,
+			"reset_at": getNextMidnightUTC(),
 		})
 	}
 
@@ -81,39 +98,35 @@ func (el *ExecutionLimiter) CheckLimit(c *fiber.Ctx) error {
 
 // IncrementCount increments the execution counter after successful execution start
 func (el *ExecutionLimiter) IncrementCount(userID string) error {
-	if el.redis == nil {
-		return nil // Redis not available, skip increment
-	}
-
 	ctx := context.Background()
-	today := time.Now().UTC().Format("2006-01-02")
-	key := fmt.Sprintf("executions:%s:%s", userID, today)
-
-	// Increment counter
-	pipe := el.redis.Pipeline()
-	pipe.Incr(ctx, key)
-
-	// Set expiry to end of day + 1 day (to allow historical querying)
-	midnight := getNextMidnightUTC()
-	expiryDuration := time.Until(midnight) + 24*time.Hour
-	pipe.Expire(ctx, key, expiryDuration)
-
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		log.Printf("⚠️  Failed to increment execution count: %v", err)
-		return err
+	key := executionCountKey(userID)
+
+	if err := el.incrementRedis(ctx, key, 1); err != nil {
+		el.enterDegraded(err)
+		if el.failClosed {
+			return err
+		}
+		el.mu.Lock()
+		// Drop counters from previous days so a long outage does not grow the map
+		todaySuffix := key[strings.LastIndex(key, ":"):]
+		for k := range el.fallback {
+			if !strings.HasSuffix(k, todaySuffix) {
+				delete(el.fallback, k)
+			}
+		}
+		el.fallback[key]++
+		el.mu.Unlock()
+		log.Printf("⚠️  [EXEC-LIMIT] Counted execution for user %s in memory (degraded mode)", userID)
+		return nil
 	}
 
 	log.Printf("✅ Incremented execution count for user %s (key: %s)", userID, key)
 	return nil
 }
 
-// GetRemainingExecutions returns how many executions user has left today
+// GetRemainingExecutions returns how many executions user has left today.
+// Returns ErrExecutionLimitUnavailable if Redis is down and the limiter fails closed.
 func (el *ExecutionLimiter) GetRemainingExecutions(userID string) (int64, error) {
-	if el.redis == nil {
-		return -1, nil // Redis not available, return unlimited
-	}
-
 	ctx := context.Background()
 
 	// Get user's tier limits
@@ -122,14 +135,7 @@ func (el *ExecutionLimiter) GetRemainingExecutions(userID string) (int64, error)
 		return -1, nil // Unlimited
 	}
 
-	// Get today's count
-	today := time.Now().UTC().Format("2006-01-02")
-	key := fmt.Sprintf("executions:%s:%s", userID, today)
-
-	count, err := el.redis.Get(ctx, key).Int64()
-	if err == redis.Nil {
-		return limits.MaxExecutionsPerDay, nil // No executions today
-	}
+	count, err := el.count(ctx, executionCountKey(userID))
 	if err != nil {
 		return -1, err
 	}
@@ -142,6 +148,97 @@ func (el *ExecutionLimiter) GetRemainingExecutions(userID string) (int64, error)
 	return remaining, nil
 }
 
+// count returns today's execution count for key. Redis is preferred; while it is
+// unavailable the in-memory count is used, or ErrExecutionLimitUnavailable when failing closed.
+func (el *ExecutionLimiter) count(ctx context.Context, key string) (int64, error) {
+	count, err := el.getRedis(ctx, key)
+	if err != nil {
+		el.enterDegraded(err)
+		if el.failClosed {
+			return 0, ErrExecutionLimitUnavailable
+		}
+		el.mu.Lock()
+		defer el.mu.Unlock()
+		return el.fallback[key], nil
+	}
+
+	el.recover(ctx)
+	return count, nil
+}
+
+func (el *ExecutionLimiter) getRedis(ctx context.Context, key string) (int64, error) {
+	if el.redis == nil {
+		return 0, errors.New("redis not configured")
+	}
+	count, err := el.redis.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (el *ExecutionLimiter) incrementRedis(ctx context.Context, key string, n int64) error {
+	if el.redis == nil {
+		return errors.New("redis not configured")
+	}
+
+	pipe := el.redis.Pipeline()
+	pipe.IncrBy(ctx, key, n)
+
+	// Set expiry to end of day + 1 day (to allow historical querying)
+	midnight := getNextMidnightUTC()
+	expiryDuration := time.Until(midnight) + 24*time.Hour
+	pipe.Expire(ctx, key, expiryDuration)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// enterDegraded switches to the in-memory fallback, logging only on the transition
+func (el *ExecutionLimiter) enterDegraded(err error) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	if el.degraded {
+		return
+	}
+	el.degraded = true
+	if el.failClosed {
+		log.Printf("🚨 [EXEC-LIMIT] Redis unavailable, rejecting executions until it recovers (fail-closed): %v", err)
+	} else {
+		log.Printf("🚨 [EXEC-LIMIT] Redis unavailable, enforcing limits per instance in memory (degraded mode): %v", err)
+	}
+}
+
+// recover leaves degraded mode once Redis answers again. Executions counted in
+// memory today are added to Redis, which stays the source of truth from then on.
+func (el *ExecutionLimiter) recover(ctx context.Context) {
+	el.mu.Lock()
+	if !el.degraded {
+		el.mu.Unlock()
+		return
+	}
+	el.degraded = false
+	pending := el.fallback
+	el.fallback = make(map[string]int64)
+	el.mu.Unlock()
+
+	log.Printf("✅ [EXEC-LIMIT] Redis recovered, reconciling %d in-memory counter(s)", len(pending))
+	for key, n := range pending {
+		if err := el.incrementRedis(ctx, key, n); err != nil {
+			// Redis failed again: keep the count in memory until the next recovery
+			el.enterDegraded(err)
+			el.mu.Lock()
+			el.fallback[key] += n
+			el.mu.Unlock()
+		}
+	}
+}
+
+// executionCountKey returns the Redis key counting userID's executions today
+func executionCountKey(userID string) string {
+	return fmt.Sprintf("executions:%s:%s", userID, time.Now().UTC().Format("2006-01-02"))
+}
+
 // getNextMidnightUTC returns the next midnight UTC
 func getNextMidnightUTC() time.Time {
 	now := time.Now().UTC()
@@ -12,6 +12,48 @@ type Agent struct {
 	Workflow    *Workflow `json:"workflow,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// CompletionWebhookURL receives the ExecutionAPIResponse when an execution finishes
+	CompletionWebhookURL string `json:"completion_webhook_url,omitempty"`
+	// CompletionWebhookSecret signs completion webhook payloads (generated when the URL is set).
+	// Never serialized: it is returned only when the webhook is set or the secret rotated.
+	CompletionWebhookSecret string `json:"-"`
+
+	// ExecutionDefaults are applied to every run of the agent unless the request overrides them
+	ExecutionDefaults *ExecutionDefaults `json:"execution_defaults,omitempty"`
+
+	// Disabled is the agent's kill switch: a disabled agent is kept, but manual runs,
+	// API triggers and schedules are all refused until it is enabled again
+	Disabled       bool       `json:"disabled,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DisabledBy     string     `json:"disabled_by,omitempty"` // user ID of the owner or admin
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+}
+
+// ExecutionDefaults are per-run execution settings. A request's settings take precedence
+// over the agent's, which take precedence over the system defaults; nil or empty fields
+// are unset and fall through to the next layer.
+type ExecutionDefaults struct {
+	EnableBlockChecker *bool  `json:"enable_block_checker,omitempty" bson:"enableBlockChecker,omitempty"`
+	CheckerModelID     string `json:"checker_model_id,omitempty" bson:"checkerModelId,omitempty"`
+	CheckerMaxRetries  *int   `json:"checker_max_retries,omitempty" bson:"checkerMaxRetries,omitempty"`
+	EnableHistory      *bool  `json:"enable_history,omitempty" bson:"enableHistory,omitempty"`
+	HistoryWindow      *int   `json:"history_window,omitempty" bson:"historyWindow,omitempty"`
+	// UnavailableTools is what llm_inference blocks do when tools they are configured
+	// with cannot run: UnavailableToolsFail (the default) or UnavailableToolsMark
+	UnavailableTools string `json:"unavailable_tools,omitempty" bson:"unavailableTools,omitempty"`
+}
+
+// Handling of a block's unavailable tools (MCP client not connected, credential missing)
+const (
+	UnavailableToolsFail = "fail" // fail the block before the model is called
+	UnavailableToolsMark = "mark" // run without the tools, telling the model they are unavailable
+)
+
+// IsEmpty reports whether no setting is set
+func (d *ExecutionDefaults) IsEmpty() bool {
+	return d == nil || (d.EnableBlockChecker == nil && d.CheckerModelID == "" && d.CheckerMaxRetries == nil &&
+		d.EnableHistory == nil && d.HistoryWindow == nil && d.UnavailableTools == "")
 }
 
 // Workflow represents a DAG of blocks for an agent
@@ -85,6 +127,31 @@ type BlockState struct {
 	// Retry tracking
 	RetryCount   int            `json:"retry_count,omitempty"`   // Number of retries attempted
 	RetryHistory []RetryAttempt `json:"retry_history,omitempty"` // Detailed retry history
+
+	// Block checker outcome (only set when the block checker ran)
+	Checker *BlockCheckerOutcome `json:"checker,omitempty"`
+
+	// CacheHit is set when a cacheable block's output came from an earlier run
+	CacheHit bool `json:"cache_hit,omitempty"`
+
+	// ContinuedOnError is set when a continue_on_error block failed and the
+	// workflow carried on without its output
+	ContinuedOnError bool `json:"continued_on_error,omitempty"`
+}
+
+// Block checker statuses
+const (
+	CheckerStatusPassed = "passed"
+	CheckerStatusFailed = "failed"
+	CheckerStatusError  = "error" // Checker could not run; the block was not judged
+)
+
+// BlockCheckerOutcome records what the block checker decided for a block
+type BlockCheckerOutcome struct {
+	Status  string `json:"status"`           // Final decision: passed, failed or error
+	Reason  string `json:"reason,omitempty"` // Checker explanation for the final decision
+	Checks  int    `json:"checks"`           // Number of checks performed
+	Retries int    `json:"retries"`          // Block re-runs triggered by failed checks
 }
 
 // RetryAttempt records a single retry attempt for debugging and monitoring
@@ -98,13 +165,15 @@ type RetryAttempt struct {
 
 // ExecutionUpdate is sent via WebSocket to stream execution progress
 type ExecutionUpdate struct {
-	Type        string         `json:"type"` // execution_update
+	Type        string         `json:"type"` // execution_update, block_progress
 	ExecutionID string         `json:"execution_id"`
 	BlockID     string         `json:"block_id"`
 	Status      string         `json:"status"`
 	Inputs      map[string]any `json:"inputs,omitempty"`  // Available inputs for debugging
 	Output      map[string]any `json:"output,omitempty"`
 	Error       string         `json:"error,omitempty"`
+	Message     string         `json:"message,omitempty"`  // block_progress: what the block is doing
+	Progress    *float64       `json:"progress,omitempty"` // block_progress: 0-100, omitted when unknown
 }
 
 // ExecutionComplete is sent when workflow execution finishes
@@ -124,7 +193,7 @@ type ExecutionComplete struct {
 // ExecutionAPIResponse is the standardized response for workflow execution
 // This provides a clean, predictable structure for API consumers
 type ExecutionAPIResponse struct {
-	// Status of the execution: completed, failed, partial
+	// Status of the execution: completed, completed_with_errors, failed, partial
 	Status string `json:"status"`
 
 	// Result contains the primary output from the workflow
@@ -171,6 +240,19 @@ type APIFile struct {
 	SourceBlock string `json:"source_block,omitempty"`
 }
 
+// NewFailedExecutionAPIResponse builds the response for an execution that could not run
+func NewFailedExecutionAPIResponse(executionID, agentID, errMsg string, durationMs int64) *ExecutionAPIResponse {
+	return &ExecutionAPIResponse{
+		Status: "failed",
+		Metadata: ExecutionMetadata{
+			ExecutionID: executionID,
+			AgentID:     agentID,
+			DurationMs:  durationMs,
+		},
+		Error: errMsg,
+	}
+}
+
 // APIBlockOutput is a clean representation of a block's output
 type APIBlockOutput struct {
 	Name       string         `json:"name"`
@@ -191,6 +273,25 @@ type ExecutionMetadata struct {
 	TotalTokens     int    `json:"total_tokens,omitempty"`
 	BlocksExecuted  int    `json:"blocks_executed"`
 	BlocksFailed    int    `json:"blocks_failed"`
+
+	// CheckerSummary aggregates block checker outcomes (only when the checker ran)
+	CheckerSummary *CheckerSummary `json:"checker_summary,omitempty"`
+}
+
+// CheckerSummary aggregates block checker outcomes across an execution
+type CheckerSummary struct {
+	BlocksChecked int                   `json:"blocks_checked"`
+	BlocksPassed  int                   `json:"blocks_passed"`
+	BlocksFailed  int                   `json:"blocks_failed"`
+	TotalRetries  int                   `json:"total_retries"`
+	Blocks        []CheckerBlockSummary `json:"blocks"`
+}
+
+// CheckerBlockSummary is the checker outcome of one block
+type CheckerBlockSummary struct {
+	BlockID string `json:"block_id"`
+	Name    string `json:"name"`
+	BlockCheckerOutcome
 }
 
 // ExecuteWorkflowRequest is received from the client to start execution
@@ -211,6 +312,15 @@ type UpdateAgentRequest struct {
 	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
 	Status      string `json:"status,omitempty"`
+	// CompletionWebhookURL sets the completion webhook; an empty string removes it
+	CompletionWebhookURL *string `json:"completion_webhook_url,omitempty"`
+	// ExecutionDefaults replaces the agent's execution defaults; an empty object removes them
+	ExecutionDefaults *ExecutionDefaults `json:"execution_defaults,omitempty"`
+}
+
+// DisableAgentRequest is the request body for disabling an agent
+type DisableAgentRequest struct {
+	Reason string `json:"reason,omitempty"`
 }
 
 // SaveWorkflowRequest is the request body for saving a workflow
@@ -232,6 +342,11 @@ type AgentBlockConfig struct {
 	// Model Configuration
 	Model       string  `json:"model,omitempty"`       // Default: "sonnet-4.5" (resolves to glm-4.6)
 	Temperature float64 `json:"temperature,omitempty"` // Default: 0.7
+	MaxTokens   int     `json:"maxTokens,omitempty"`   // Default: 0 (provider limit)
+
+	// ModelOverride pins this block to a model (config key "model_id"), taking
+	// precedence over the workflow model. Fails the block if unavailable.
+	ModelOverride string `json:"model_id,omitempty"`
 
 	// Prompts
 	SystemPrompt string `json:"systemPrompt,omitempty"`
@@ -241,12 +356,20 @@ type AgentBlockConfig struct {
 	EnabledTools []string `json:"enabledTools,omitempty"` // e.g., ["search_web", "calculate_math"]
 	MaxToolCalls int      `json:"maxToolCalls,omitempty"` // Default: 15
 	Credentials  []string `json:"credentials,omitempty"`  // Credential IDs for tool authentication
+	// MaxTools caps how many of EnabledTools are offered to the model (0 = all); the
+	// ones most relevant to the prompts are kept, preferring tools tagged with ToolTags
+	MaxTools int      `json:"maxTools,omitempty"`
+	ToolTags []string `json:"toolTags,omitempty"`
 
 	// Execution Mode Configuration (for deterministic block execution)
 	RequireToolUsage bool     `json:"requireToolUsage,omitempty"` // Default: true when tools exist - forces tool calls
 	MaxRetries       int      `json:"maxRetries,omitempty"`       // Default: 2 - retry attempts if tool not called
 	RequiredTools    []string `json:"requiredTools,omitempty"`    // Specific tools that MUST be called
 
+	// UnavailableTools holds the configured tools that cannot run, with the reason for
+	// each, when the agent runs blocks without them (set at execution time)
+	UnavailableTools map[string]string `json:"-"`
+
 	// Retry Policy for LLM API calls (transient error handling)
 	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"` // Optional retry configuration for API failures
 
@@ -378,3 +501,54 @@ type SyncAgentResponse struct {
 	Workflow       *Workflow `json:"workflow"`
 	ConversationID string    `json:"conversation_id"`
 }
+
+// AgentExportFormatVersion is the version of the agent export format produced by this server
+const AgentExportFormatVersion = 1
+
+// AgentExport is a self-contained, portable agent definition. It carries no secrets,
+// credential references or user-specific IDs.
+type AgentExport struct {
+	FormatVersion int                 `json:"format_version"`
+	ExportedAt    time.Time           `json:"exported_at"`
+	Name          string              `json:"name"`
+	Description   string              `json:"description,omitempty"`
+	Workflow      AgentExportWorkflow `json:"workflow"`
+	// Inputs are the workflow's input variable blocks, for callers of the imported agent
+	Inputs []AgentExportInput `json:"inputs,omitempty"`
+	// RequiredIntegrations are the credential types the workflow's tools need
+	RequiredIntegrations []AgentExportIntegration `json:"required_integrations,omitempty"`
+	// RemovedFields lists the block config fields stripped on export (block name + path)
+	RemovedFields []string `json:"removed_fields,omitempty"`
+}
+
+// AgentExportWorkflow is the workflow of an exported agent
+type AgentExportWorkflow struct {
+	Blocks      []Block      `json:"blocks"`
+	Connections []Connection `json:"connections"`
+	Variables   []Variable   `json:"variables,omitempty"`
+}
+
+// AgentExportInput describes an input the exported workflow reads
+type AgentExportInput struct {
+	BlockName    string `json:"block_name"`
+	VariableName string `json:"variable_name"`
+	InputType    string `json:"input_type,omitempty"` // text, file, json
+}
+
+// AgentExportIntegration is a credential type needed by the tools of an exported workflow
+type AgentExportIntegration struct {
+	IntegrationType string   `json:"integration_type"`
+	Tools           []string `json:"tools"`
+	// Configured is set on import: whether the importing user already has such a credential
+	Configured bool `json:"configured"`
+}
+
+// ImportAgentResponse is the response after importing an agent
+type ImportAgentResponse struct {
+	Agent    *Agent    `json:"agent"`
+	Workflow *Workflow `json:"workflow"`
+	// RequiredIntegrations lists every credential type the agent needs;
+	// MissingCredentials the ones the importer still has to configure
+	RequiredIntegrations []AgentExportIntegration `json:"required_integrations"`
+	MissingCredentials   []string                 `json:"missing_credentials"`
+}
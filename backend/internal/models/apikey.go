@@ -161,6 +161,14 @@ type TriggerAgentRequest struct {
 	// CheckerModelID is the model to use for block checking (optional)
 	// Defaults to gpt-4o-mini for fast, cheap validation
 	CheckerModelID string `json:"checker_model_id,omitempty"`
+
+	// EnableHistory gives the agent a summary of its recent runs for this user (optional,
+	// defaults to the agent's execution defaults)
+	EnableHistory *bool `json:"enable_history,omitempty"`
+
+	// HistoryWindow is how many prior runs to summarize (optional, defaults to the
+	// agent's execution defaults, then 5)
+	HistoryWindow *int `json:"history_window,omitempty"`
 }
 
 // TriggerAgentResponse is returned after triggering an agent
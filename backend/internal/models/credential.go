@@ -31,6 +31,7 @@ type CredentialMetadata struct {
 	UsageCount    int64      `bson:"usageCount" json:"usageCount"`
 	LastTestAt    *time.Time `bson:"lastTestAt,omitempty" json:"lastTestAt,omitempty"`
 	TestStatus    string     `bson:"testStatus,omitempty" json:"testStatus,omitempty"` // "success", "failed", "pending"
+	TestError     string     `bson:"testError,omitempty" json:"testError,omitempty"`   // Provider error from the last failed test
 }
 
 // CredentialListItem is a safe representation for listing credentials
@@ -85,6 +86,8 @@ type TestCredentialResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"` // Additional info (sanitized)
+	// Unsupported is set when the integration type has no test; the credential is left untested
+	Unsupported bool `json:"unsupported,omitempty"`
 }
 
 // CredentialReference is used in block configs to reference credentials
@@ -151,3 +154,35 @@ type CredentialsByIntegration struct {
 type GetCredentialsByIntegrationResponse struct {
 	Integrations []CredentialsByIntegration `json:"integrations"`
 }
+
+// MinSecretValueLength is the shortest credential value treated as a secret.
+// Shorter values (flags, ports, single digits) would redact unrelated text.
+const MinSecretValueLength = 6
+
+// SecretValues returns every string value in the credential data, including
+// nested ones, that must never appear in stored or streamed output
+func (c *DecryptedCredential) SecretValues() []string {
+	if c == nil {
+		return nil
+	}
+	var values []string
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch val := v.(type) {
+		case string:
+			if len(val) >= MinSecretValueLength {
+				values = append(values, val)
+			}
+		case map[string]interface{}:
+			for _, nested := range val {
+				collect(nested)
+			}
+		case []interface{}:
+			for _, nested := range val {
+				collect(nested)
+			}
+		}
+	}
+	collect(c.Data)
+	return values
+}
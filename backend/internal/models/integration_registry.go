@@ -1138,6 +1138,94 @@ var IntegrationRegistry = map[string]Integration{
 		Tools:   []string{"redis_read", "redis_write"},
 		DocsURL: "https://redis.io/docs/",
 	},
+
+	"sql_database": {
+		ID:          "sql_database",
+		Name:        "SQL Database",
+		Description: "Run parameterized queries against a MySQL or MariaDB database. Read-only unless writes are enabled.",
+		Icon:        "database",
+		Category:    "database",
+		Fields: []IntegrationField{
+			{
+				Key:       "driver",
+				Label:     "Database Type",
+				Type:      "select",
+				Required:  true,
+				Options:   []string{"mysql"},
+				Default:   "mysql",
+				HelpText:  "MySQL-compatible databases (MySQL, MariaDB, TiDB, PlanetScale)",
+				Sensitive: false,
+			},
+			{
+				Key:         "host",
+				Label:       "Host",
+				Type:        "text",
+				Required:    true,
+				Placeholder: "db.example.com",
+				HelpText:    "Database server hostname or IP",
+				Sensitive:   false,
+			},
+			{
+				Key:         "port",
+				Label:       "Port",
+				Type:        "text",
+				Required:    false,
+				Placeholder: "3306",
+				HelpText:    "Database server port (default: 3306)",
+				Default:     "3306",
+				Sensitive:   false,
+			},
+			{
+				Key:         "database",
+				Label:       "Database Name",
+				Type:        "text",
+				Required:    true,
+				Placeholder: "analytics",
+				HelpText:    "The database to connect to",
+				Sensitive:   false,
+			},
+			{
+				Key:         "username",
+				Label:       "Username",
+				Type:        "text",
+				Required:    true,
+				Placeholder: "readonly_user",
+				HelpText:    "Preferably a user with read-only grants",
+				Sensitive:   false,
+			},
+			{
+				Key:         "password",
+				Label:       "Password",
+				Type:        "api_key",
+				Required:    false,
+				Placeholder: "Your database password",
+				HelpText:    "Database user password",
+				Sensitive:   true,
+			},
+			{
+				Key:       "tls",
+				Label:     "Use TLS",
+				Type:      "select",
+				Required:  false,
+				Options:   []string{"false", "true"},
+				Default:   "false",
+				HelpText:  "Encrypt the connection with TLS",
+				Sensitive: false,
+			},
+			{
+				Key:       "allow_writes",
+				Label:     "Allow Writes",
+				Type:      "select",
+				Required:  false,
+				Options:   []string{"false", "true"},
+				Default:   "false",
+				HelpText:  "Allow INSERT, UPDATE, DELETE and REPLACE statements. Schema changes are never allowed.",
+				Sensitive: false,
+			},
+		},
+		Tools:   []string{"sql_query"},
+		DocsURL: "",
+	},
 }
 
 // IntegrationCategories defines the categories and their order
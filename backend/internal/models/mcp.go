@@ -1,21 +1,32 @@
 package models
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // MCPConnection represents an active MCP client connection
 type MCPConnection struct {
-	ID             string                       `json:"id"`
-	UserID         string                       `json:"user_id"`
-	ClientID       string                       `json:"client_id"`
-	ClientVersion  string                       `json:"client_version"`
-	Platform       string                       `json:"platform"`
-	ConnectedAt    time.Time                    `json:"connected_at"`
-	LastHeartbeat  time.Time                    `json:"last_heartbeat"`
-	IsActive       bool                         `json:"is_active"`
-	Tools          []MCPTool                    `json:"tools"`
-	WriteChan      chan MCPServerMessage        `json:"-"`
-	StopChan       chan bool                    `json:"-"`
-	PendingResults map[string]chan MCPToolResult `json:"-"` // call_id -> result channel
+	ID              string                         `json:"id"`
+	UserID          string                         `json:"user_id"`
+	ClientID        string                         `json:"client_id"`
+	ClientVersion   string                         `json:"client_version"`
+	Platform        string                         `json:"platform"`
+	ProtocolVersion int                            `json:"protocol_version"` // Negotiated bridge protocol version
+	Encoding        string                         `json:"encoding"`         // Negotiated message encoding ("json" or "msgpack")
+	ConnectedAt     time.Time                      `json:"connected_at"`
+	LastHeartbeat   time.Time                      `json:"last_heartbeat"`
+	IsActive        bool                           `json:"is_active"`
+	Tools           []MCPTool                      `json:"tools"`
+	WriteChan       chan MCPServerMessage          `json:"-"`
+	StopChan        chan bool                      `json:"-"`
+	PendingResults  map[string]chan MCPToolResult  `json:"-"` // call_id -> result channel
+	PendingProgress map[string]MCPToolProgressFunc `json:"-"` // call_id -> progress listener
+	PendingStreams  map[string]MCPToolOutputStream `json:"-"` // call_id -> streamed output, for tools that stream results
+	PendingMu       sync.Mutex                     `json:"-"` // Guards PendingResults, PendingProgress and PendingStreams
+	// Successor is the connection that resumed this one within the reconnect grace
+	// window and took over its pending calls (guarded by PendingMu)
+	Successor *MCPConnection `json:"-"`
 }
 
 // MCPTool represents a tool registered by an MCP client
@@ -25,11 +36,35 @@ type MCPTool struct {
 	Parameters  map[string]interface{} `json:"parameters"` // JSON Schema
 	Source      string                 `json:"source"`     // "mcp_local"
 	UserID      string                 `json:"user_id"`
+	// ReadOnly is the server's read-only hint; RetryOnTimeout is the client-side opt-in.
+	// A timed-out call is re-sent only when both are set.
+	ReadOnly       bool `json:"read_only,omitempty"`
+	RetryOnTimeout bool `json:"retry_on_timeout,omitempty"`
+	// MaxConcurrency limits concurrent calls to the tool per connection (0 = unlimited)
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	// Timeout is the tool's call timeout in seconds, used when the caller sets none (0 = server default)
+	Timeout int `json:"timeout,omitempty"`
+	// Category groups the tool in listings ("uncategorized" when absent); Tags allow filtering
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// Examples show the model how to call the tool
+	Examples []MCPToolExample `json:"examples,omitempty"`
+	// OutputSchema is the JSON Schema of the tool's result, if the server declares one
+	OutputSchema map[string]interface{} `json:"output_schema,omitempty"`
+	// StreamsResults is the client-side opt-in to send the tool's output in
+	// tool_result_partial frames while it runs (protocol version 6+)
+	StreamsResults bool `json:"streams_results,omitempty"`
+}
+
+// MCPToolExample is a sample call of a tool and, optionally, what it returned
+type MCPToolExample struct {
+	Arguments map[string]interface{} `json:"arguments"`
+	Result    string                 `json:"result,omitempty"`
 }
 
 // MCPClientMessage represents messages from MCP client to backend
 type MCPClientMessage struct {
-	Type    string                 `json:"type"` // "register_tools", "tool_result", "heartbeat", "disconnect"
+	Type    string                 `json:"type"` // "register_tools", "add_tools", "remove_tools", "update_tool", "tool_result", "tool_result_partial", "tool_progress", "heartbeat", "disconnect"
 	Payload map[string]interface{} `json:"payload"`
 }
 
@@ -45,6 +80,20 @@ type MCPToolRegistration struct {
 	ClientVersion string    `json:"client_version"`
 	Platform      string    `json:"platform"`
 	Tools         []MCPTool `json:"tools"`
+	// ProtocolVersion is the highest bridge protocol the client speaks (0 for clients
+	// predating negotiation)
+	ProtocolVersion int `json:"protocol_version,omitempty"`
+	// Encodings lists the message encodings the client accepts, most preferred first
+	// (JSON when absent)
+	Encodings []string `json:"encodings,omitempty"`
+}
+
+// MCPToolChanges is the payload of incremental tool updates sent after register_tools:
+// add_tools carries Tools, remove_tools carries Names and update_tool carries Tool
+type MCPToolChanges struct {
+	Tools []MCPTool `json:"tools,omitempty"`
+	Names []string  `json:"names,omitempty"`
+	Tool  *MCPTool  `json:"tool,omitempty"`
 }
 
 // MCPToolCall represents a tool execution request to client
@@ -61,6 +110,48 @@ type MCPToolResult struct {
 	Success bool   `json:"success"`
 	Result  string `json:"result"`
 	Error   string `json:"error,omitempty"`
+	// Truncated is set when Result was cut to fit a size limit; OriginalSize is the
+	// full result size in bytes
+	Truncated    bool `json:"truncated,omitempty"`
+	OriginalSize int  `json:"original_size,omitempty"`
+	// DurationMs is how long the tool ran on the client (0 if the client did not report it)
+	DurationMs int64 `json:"duration_ms,omitempty"`
+	// Cancelled is set when the client aborted the call after a cancel_tool_call
+	Cancelled bool `json:"cancelled,omitempty"`
+	// Incomplete is set when a streamed call timed out and Result is the output
+	// received before then
+	Incomplete bool `json:"incomplete,omitempty"`
+	// NotFound is set when the client has no running server providing the tool
+	NotFound bool `json:"not_found,omitempty"`
+}
+
+// MCPToolProgress is a progress update the client sends while a tool call runs
+type MCPToolProgress struct {
+	CallID  string   `json:"call_id"`
+	Message string   `json:"message,omitempty"`
+	Percent *float64 `json:"percent,omitempty"` // 0-100, absent when the total is unknown
+}
+
+// MCPToolProgressFunc receives a running tool call's progress; percent is 0-100, or
+// negative when the total is unknown
+type MCPToolProgressFunc func(message string, percent float64)
+
+// MCPToolResultPartial is part of a streamed tool call's output. Parts are appended
+// in the order they arrive; the part with Final set completes the call.
+type MCPToolResultPartial struct {
+	CallID  string `json:"call_id"`
+	Content string `json:"content"`
+	Final   bool   `json:"final,omitempty"`
+	// DurationMs is how long the tool ran on the client, reported with the final part
+	DurationMs int64 `json:"duration_ms,omitempty"`
+}
+
+// MCPToolOutputStream collects the output of a streamed tool call as it arrives
+type MCPToolOutputStream interface {
+	// Append adds the next part of the output
+	Append(content string)
+	// Result is the output received so far, as the call's result
+	Result(callID string) MCPToolResult
 }
 
 // MCPHeartbeat represents a heartbeat message
@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// Provider auth styles: how the API key is attached to outgoing requests
+const (
+	AuthStyleBearer = "bearer"  // Authorization: Bearer <key>
+	AuthStyleAPIKey = "api-key" // api-key: <key> (Azure OpenAI)
+)
+
 // Provider represents an AI API provider (OpenAI, Anthropic, etc.)
 type Provider struct {
 	ID            int       `json:"id"`
@@ -16,6 +22,12 @@ type Provider struct {
 	DefaultModel  string    `json:"default_model,omitempty"`   // Default model for image generation
 	SystemPrompt  string    `json:"system_prompt,omitempty"`
 	Favicon       string    `json:"favicon,omitempty"` // Optional favicon URL for the provider
+	AuthStyle     string    `json:"auth_style,omitempty"` // How the API key is sent: "bearer" (default) or "api-key"
+	Headers       map[string]string `json:"headers,omitempty"` // Extra headers sent with every request (gateways, OpenAI-Organization, ...)
+	// UsesMaxCompletionTokens selects max_completion_tokens over max_tokens; nil falls back to the base URL heuristic
+	UsesMaxCompletionTokens *bool     `json:"uses_max_completion_tokens,omitempty"`
+	// Flavor is the API dialect ("openai", "anthropic", "azure-openai", "ollama", ...); empty guesses it from the base URL
+	Flavor        string    `json:"flavor,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
@@ -36,6 +48,7 @@ type ModelAlias struct {
 	StructuredOutputBadge       string `json:"structured_output_badge,omitempty"`        // Badge label (e.g., "FASTEST")
 	MemoryExtractor             *bool  `json:"memory_extractor,omitempty"`               // If true, model can extract memories from conversations
 	MemorySelector              *bool  `json:"memory_selector,omitempty"`                // If true, model can select relevant memories for context
+	MemoryTags                  []string `json:"memory_tags,omitempty"`                  // Content the model handles best for memory operations (e.g. "code", "prose")
 }
 
 // ModelAliasView represents a model alias from the database (includes DB metadata)
@@ -87,6 +100,10 @@ type ProviderConfig struct {
 	DefaultModel      string                `json:"default_model,omitempty"`      // Default model for image generation
 	SystemPrompt      string                `json:"system_prompt,omitempty"`
 	Favicon           string                `json:"favicon,omitempty"`            // Optional favicon URL
+	AuthStyle         string                `json:"auth_style,omitempty"`         // How the API key is sent: "bearer" (default) or "api-key"
+	Headers           map[string]string     `json:"headers,omitempty"`            // Extra headers sent with every request
+	UsesMaxCompletionTokens *bool           `json:"uses_max_completion_tokens,omitempty"` // Token limit parameter override; nil = detect from base URL
+	Flavor            string                `json:"flavor,omitempty"`             // API dialect: openai, azure-openai, anthropic, ollama, chutes, openai-compatible; empty = detect from base URL
 	Filters           []FilterConfig        `json:"filters"`
 	ModelAliases      map[string]ModelAlias `json:"model_aliases,omitempty"`      // Maps frontend model names to actual model names with descriptions
 	RecommendedModels *RecommendedModels    `json:"recommended_models,omitempty"` // Recommended model tiers
@@ -96,6 +96,7 @@ type TierLimits struct {
 	MaxFileUploadsPerDay      int64 `json:"maxFileUploadsPerDay"`      // Daily file upload limit
 	MaxImageGensPerDay        int64 `json:"maxImageGensPerDay"`        // Daily image generation limit
 	MaxMemoryExtractionsPerDay int64 `json:"maxMemoryExtractionsPerDay"` // Daily memory extraction limit
+	MaxMCPTools                int   `json:"maxMcpTools"`                // Tools one MCP client may register
 }
 
 // DefaultTierLimits provides tier configurations
@@ -111,6 +112,7 @@ var DefaultTierLimits = map[string]TierLimits{
 		MaxFileUploadsPerDay:       10,
 		MaxImageGensPerDay:         10,
 		MaxMemoryExtractionsPerDay: 15, // ~15 extractions/day for free tier
+		MaxMCPTools:                50,
 	},
 	"pro": {
 		MaxSchedules:               50,
@@ -123,6 +125,7 @@ var DefaultTierLimits = map[string]TierLimits{
 		MaxFileUploadsPerDay:       50,
 		MaxImageGensPerDay:         50,
 		MaxMemoryExtractionsPerDay: 100, // ~100 extractions/day for pro
+		MaxMCPTools:                200,
 	},
 	"max": {
 		MaxSchedules:               100,
@@ -135,6 +138,7 @@ var DefaultTierLimits = map[string]TierLimits{
 		MaxFileUploadsPerDay:       -1,  // unlimited
 		MaxImageGensPerDay:         -1,  // unlimited
 		MaxMemoryExtractionsPerDay: -1,  // unlimited
+		MaxMCPTools:                500,
 	},
 	"enterprise": {
 		MaxSchedules:               -1,  // unlimited
@@ -147,6 +151,7 @@ var DefaultTierLimits = map[string]TierLimits{
 		MaxFileUploadsPerDay:       -1,  // unlimited
 		MaxImageGensPerDay:         -1,  // unlimited
 		MaxMemoryExtractionsPerDay: -1,  // unlimited
+		MaxMCPTools:                -1,  // unlimited
 	},
 	"legacy_unlimited": {
 		MaxSchedules:               -1,  // unlimited
@@ -159,6 +164,7 @@ var DefaultTierLimits = map[string]TierLimits{
 		MaxFileUploadsPerDay:       -1,  // unlimited
 		MaxImageGensPerDay:         -1,  // unlimited
 		MaxMemoryExtractionsPerDay: -1,  // unlimited
+		MaxMCPTools:                -1,  // unlimited
 	},
 }
 
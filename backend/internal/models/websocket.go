@@ -18,6 +18,7 @@ type ClientMessage struct {
 	SystemInstructions string                   `json:"system_instructions,omitempty"` // Optional: Custom system prompt override
 	Attachments        []MessageAttachment      `json:"attachments,omitempty"`         // File attachments (images, documents)
 	DisableTools       bool                     `json:"disable_tools,omitempty"`       // Disable tools for this message (e.g., agent builder)
+	DryRunTools        bool                     `json:"dry_run_tools,omitempty"`       // Validate tool calls and return their arguments instead of running them
 
 	// Interactive prompt response fields
 	PromptID string                      `json:"prompt_id,omitempty"` // ID of the prompt being responded to
@@ -73,14 +74,14 @@ type InteractiveAnswer struct {
 
 // ServerMessage represents a message sent to the client
 type ServerMessage struct {
-	Type            string                 `json:"type"` // "stream_chunk", "reasoning_chunk", "tool_call", "tool_result", "stream_end", "stream_resume", "stream_missed", "conversation_reset", "conversation_title", "context_optimizing", "interactive_prompt", "prompt_timeout", "prompt_validation_error", "error"
+	Type            string                 `json:"type"` // "stream_chunk", "reasoning_chunk", "tool_call", "tool_progress", "tool_result_partial", "tool_result", "stream_end", "stream_resume", "stream_missed", "conversation_reset", "conversation_title", "context_optimizing", "interactive_prompt", "prompt_timeout", "prompt_validation_error", "error"
 	Content         string                 `json:"content,omitempty"`
 	Title           string                 `json:"title,omitempty"` // Auto-generated conversation title OR interactive prompt title
 	ToolName        string                 `json:"tool_name,omitempty"`
 	ToolDisplayName string                 `json:"tool_display_name,omitempty"` // User-friendly tool name (e.g., "Search Web")
 	ToolIcon        string                 `json:"tool_icon,omitempty"`         // Lucide React icon name (e.g., "Calculator", "Search", "Clock")
 	ToolDescription string                 `json:"tool_description,omitempty"`  // Human-readable tool description
-	Status          string                 `json:"status,omitempty"`            // "executing", "completed", "started"
+	Status          string                 `json:"status,omitempty"`            // "executing", "completed", "started", "dry_run"
 	Arguments       map[string]interface{} `json:"arguments,omitempty"`
 	Result          string                 `json:"result,omitempty"`
 	Plots           []PlotData             `json:"plots,omitempty"`           // Visualization plots from E2B tools
@@ -90,7 +91,7 @@ type ServerMessage struct {
 	ErrorMessage    string                 `json:"message,omitempty"`
 	IsComplete      bool                   `json:"is_complete,omitempty"`      // For stream_resume: whether generation completed
 	Reason          string                 `json:"reason,omitempty"`           // For stream_missed: "expired" or "not_found"
-	Progress        int                    `json:"progress,omitempty"`         // For context_optimizing: progress percentage (0-100)
+	Progress        int                    `json:"progress,omitempty"`         // For context_optimizing and tool_progress: progress percentage (0-100)
 
 	// Interactive prompt fields
 	PromptID    string                 `json:"prompt_id,omitempty"`    // Unique prompt ID
@@ -128,6 +129,8 @@ type UserConnection struct {
 	CustomConfig       *CustomAPIConfig // OR user's custom API configuration (BYOK)
 	SystemInstructions string           // Optional: User-provided system prompt override
 	DisableTools       bool             // Disable tools for this connection (e.g., agent builder)
+	DryRunTools        bool             // Validate tool calls without executing them (debugging agents)
+	OfferedTools       []string         // Tools sent with the current request, suggested when the model calls a missing one
 	CreatedAt          time.Time
 	WriteChan          chan ServerMessage
 	StopChan           chan bool
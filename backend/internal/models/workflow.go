@@ -1,5 +1,7 @@
 package models
 
+import "context"
+
 // ConversationMessage represents a message in the conversation history
 type ConversationMessage struct {
 	Role    string `json:"role"`    // "user" or "assistant"
@@ -132,8 +134,11 @@ type WorkflowExecuteResult struct {
 	Output      map[string]interface{}
 	BlockStates map[string]*BlockState
 	Error       string
+	// APIResponse is the standardized response (nil if the workflow could not run)
+	APIResponse *ExecutionAPIResponse
 }
 
 // WorkflowExecuteFunc is a function type for executing workflows
 // This allows scheduler to call workflow engine without import cycle
-type WorkflowExecuteFunc func(workflow *Workflow, inputs map[string]interface{}) (*WorkflowExecuteResult, error)
+// Cancelling ctx cancels the execution
+type WorkflowExecuteFunc func(ctx context.Context, workflow *Workflow, inputs map[string]interface{}) (*WorkflowExecuteResult, error)
@@ -0,0 +1,40 @@
+package providerhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StatusError is a non-2xx response from a provider API
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay the provider asked for (zero if none was given)
+	RetryAfter time.Duration
+}
+
+// NewStatusError builds a StatusError from a provider response and its body
+func NewStatusError(resp *http.Response, body []byte) *StatusError {
+	retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"))
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: retryAfter,
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// IsRateLimited reports whether err is (or wraps) a 429 response from a provider,
+// returning the delay the provider asked for, if any
+func IsRateLimited(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+		return statusErr.RetryAfter, true
+	}
+	return 0, false
+}
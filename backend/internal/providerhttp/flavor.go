@@ -0,0 +1,92 @@
+package providerhttp
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Provider flavors: the API dialect a provider speaks, which decides request shaping,
+// auth headers and parameter names. Providers set it explicitly (flavor in
+// providers.json or the admin API); when it is unset it is guessed from the base URL.
+const (
+	FlavorOpenAI           = "openai"
+	FlavorAzureOpenAI      = "azure-openai"
+	FlavorAnthropic        = "anthropic"
+	FlavorOllama           = "ollama"
+	FlavorChutes           = "chutes"
+	FlavorOpenAICompatible = "openai-compatible" // any other /chat/completions API
+)
+
+// Flavors lists the valid flavor values
+var Flavors = []string{FlavorOpenAI, FlavorAzureOpenAI, FlavorAnthropic, FlavorOllama, FlavorChutes, FlavorOpenAICompatible}
+
+// IsValidFlavor reports whether flavor is empty (guess from the URL) or a known flavor
+func IsValidFlavor(flavor string) bool {
+	if flavor == "" {
+		return true
+	}
+	for _, f := range Flavors {
+		if f == flavor {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveFlavor returns the provider's flavor, guessing it from the base URL only
+// when none is configured. Gateways and self-hosted endpoints should set it.
+func ResolveFlavor(flavor, baseURL string) string {
+	if flavor = strings.ToLower(strings.TrimSpace(flavor)); flavor != "" {
+		return flavor
+	}
+
+	url := strings.ToLower(baseURL)
+	switch {
+	case strings.Contains(url, "openai.azure.com"):
+		return FlavorAzureOpenAI
+	case strings.Contains(url, "openai.com"):
+		return FlavorOpenAI
+	case strings.Contains(url, "anthropic.com"):
+		return FlavorAnthropic
+	case strings.Contains(url, "chutes.ai"):
+		return FlavorChutes
+	case strings.Contains(url, "ollama") || strings.Contains(url, ":11434"):
+		return FlavorOllama
+	}
+	return FlavorOpenAICompatible
+}
+
+// Auth styles, for providers that override how their flavor sends the API key
+const (
+	AuthStyleBearer = "bearer"  // Authorization: Bearer <key>
+	AuthStyleAPIKey = "api-key" // api-key: <key> (Azure OpenAI)
+)
+
+// AnthropicVersion is sent with requests to Anthropic's API
+const AnthropicVersion = "2023-06-01"
+
+// SetAuthHeaders sets the API key header for the provider's flavor, unless authStyle
+// overrides it, then the provider's extra headers. No key header is sent without a
+// key (e.g. a local Ollama).
+func SetAuthHeaders(req *http.Request, flavor, baseURL, authStyle, apiKey string, headers map[string]string) {
+	switch {
+	case apiKey == "":
+	case authStyle == AuthStyleAPIKey:
+		req.Header.Set("api-key", apiKey)
+	case authStyle == AuthStyleBearer:
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	default:
+		switch ResolveFlavor(flavor, baseURL) {
+		case FlavorAzureOpenAI:
+			req.Header.Set("api-key", apiKey)
+		case FlavorAnthropic:
+			req.Header.Set("x-api-key", apiKey)
+			req.Header.Set("anthropic-version", AnthropicVersion)
+		default:
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
@@ -0,0 +1,60 @@
+package providerhttp
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResolveFlavor(t *testing.T) {
+	tests := []struct {
+		flavor  string
+		baseURL string
+		want    string
+	}{
+		{"", "https://api.openai.com/v1", FlavorOpenAI},
+		{"", "https://res.openai.azure.com/openai/v1", FlavorAzureOpenAI},
+		{"", "https://api.anthropic.com/v1", FlavorAnthropic},
+		{"", "http://localhost:11434/v1", FlavorOllama},
+		{"", "https://image.chutes.ai", FlavorChutes},
+		{"", "https://openrouter.ai/api/v1", FlavorOpenAICompatible},
+		{"Anthropic", "https://gateway.example.com/v1", FlavorAnthropic},
+		{FlavorOllama, "https://api.openai.com/v1", FlavorOllama},
+	}
+
+	for _, tt := range tests {
+		if got := ResolveFlavor(tt.flavor, tt.baseURL); got != tt.want {
+			t.Errorf("ResolveFlavor(%q, %q) = %q, want %q", tt.flavor, tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestSetAuthHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		flavor    string
+		authStyle string
+		apiKey    string
+		want      map[string]string
+	}{
+		{"bearer by default", FlavorOpenAI, "", "k", map[string]string{"Authorization": "Bearer k"}},
+		{"azure api-key", FlavorAzureOpenAI, "", "k", map[string]string{"Api-Key": "k", "Authorization": ""}},
+		{"anthropic", FlavorAnthropic, "", "k", map[string]string{"X-Api-Key": "k", "Anthropic-Version": AnthropicVersion}},
+		{"auth style overrides flavor", FlavorAzureOpenAI, AuthStyleBearer, "k", map[string]string{"Authorization": "Bearer k", "Api-Key": ""}},
+		{"no key", FlavorOllama, "", "", map[string]string{"Authorization": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "https://example.com", nil)
+			SetAuthHeaders(req, tt.flavor, "", tt.authStyle, tt.apiKey, map[string]string{"X-Gateway": "g"})
+			for name, want := range tt.want {
+				if got := req.Header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if req.Header.Get("X-Gateway") != "g" {
+				t.Error("Expected extra headers to be applied")
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package providerhttp
+
+// UsesMaxCompletionTokens reports whether a provider expects the output token limit in
+// max_completion_tokens instead of max_tokens. An explicit per-provider setting wins;
+// without one, OpenAI and Azure OpenAI are assumed to require it.
+func UsesMaxCompletionTokens(flavor, baseURL string, override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	switch ResolveFlavor(flavor, baseURL) {
+	case FlavorOpenAI, FlavorAzureOpenAI:
+		return true
+	}
+	return false
+}
+
+// MaxTokensParam returns the request field carrying the output token limit
+func MaxTokensParam(flavor, baseURL string, override *bool) string {
+	if UsesMaxCompletionTokens(flavor, baseURL, override) {
+		return "max_completion_tokens"
+	}
+	return "max_tokens"
+}
@@ -0,0 +1,30 @@
+package providerhttp
+
+import "testing"
+
+func TestMaxTokensParam(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name     string
+		flavor   string
+		baseURL  string
+		override *bool
+		want     string
+	}{
+		{"openai detected", "", "https://api.openai.com/v1", nil, "max_completion_tokens"},
+		{"other provider detected", "", "https://openrouter.ai/api/v1", nil, "max_tokens"},
+		{"azure detected", "", "https://my-resource.openai.azure.com/openai/v1", nil, "max_completion_tokens"},
+		{"openai gateway by flavor", FlavorOpenAI, "https://gateway.example.com/v1", nil, "max_completion_tokens"},
+		{"compatible flavor beats URL", FlavorOpenAICompatible, "https://api.openai.com/v1", nil, "max_tokens"},
+		{"compatible endpoint opted in", "", "https://llm.example.com/v1", &yes, "max_completion_tokens"},
+		{"openai proxy opted out", "", "https://gateway.example.com/openai.com/v1", &no, "max_tokens"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaxTokensParam(tt.flavor, tt.baseURL, tt.override); got != tt.want {
+				t.Errorf("MaxTokensParam(%q, %q) = %q, want %q", tt.flavor, tt.baseURL, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package providerhttp provides the HTTP client used for requests to AI provider APIs.
+//
+// Responses with 429 (rate limited) or 503 (temporarily unavailable) are retried with
+// exponential backoff and jitter, honouring the provider's Retry-After header, until
+// the request's retry budget is spent. Services create clients with NewClient instead
+// of building their own retry loops.
+package providerhttp
+
+import (
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how provider requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt (0 disables retries)
+	MaxRetries int
+	// BaseDelay is the first backoff delay, doubled on each retry up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Budget bounds the total time one request may spend waiting between attempts.
+	// A Retry-After longer than what is left of the budget is not waited for.
+	Budget time.Duration
+}
+
+// DefaultPolicy is used by clients created with NewClient unless replaced with SetPolicy
+var DefaultPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   10 * time.Second,
+	Budget:     30 * time.Second,
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   = DefaultPolicy
+)
+
+// SetPolicy replaces the retry policy of all clients created with NewClient, including
+// ones already in use. Tests pass RetryPolicy{} to disable retries.
+func SetPolicy(p RetryPolicy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	policy = p
+}
+
+// CurrentPolicy returns the retry policy clients from NewClient are using
+func CurrentPolicy() RetryPolicy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return policy
+}
+
+// NewClient returns an HTTP client for provider calls. The timeout covers the whole
+// request including retries.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &Transport{},
+	}
+}
+
+// Transport is an http.RoundTripper that retries rate-limited and unavailable responses
+type Transport struct {
+	// Base performs the requests (http.DefaultTransport if nil)
+	Base http.RoundTripper
+	// Policy overrides the package policy set with SetPolicy
+	Policy *RetryPolicy
+}
+
+// RoundTrip sends the request, retrying 429 and 503 responses. Requests whose body
+// cannot be replayed (no GetBody) are sent once.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	p := CurrentPolicy()
+	if t.Policy != nil {
+		p = *t.Policy
+	}
+
+	resp, err := base.RoundTrip(req)
+	if p.MaxRetries <= 0 || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return resp, err
+	}
+
+	var waited time.Duration
+	for attempt := 0; attempt < p.MaxRetries; attempt++ {
+		if err != nil || !retryableStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		delay := backoff(p, attempt)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok && retryAfter > delay {
+			delay = retryAfter
+		}
+		if waited+delay > p.Budget {
+			return resp, nil
+		}
+
+		log.Printf("⏳ [PROVIDER-HTTP] %s %s returned %d, retrying in %v (attempt %d/%d)",
+			req.Method, req.URL.Host, resp.StatusCode, delay.Round(time.Millisecond), attempt+1, p.MaxRetries)
+
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		waited += delay
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			retry.Body = body
+		}
+		resp, err = base.RoundTrip(retry)
+	}
+	return resp, err
+}
+
+// retryableStatus reports whether a provider response is worth retrying
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// backoff returns the delay before retry attempt (0-indexed), with +/-20% jitter
+func backoff(p RetryPolicy, attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	jitter := (rand.Float64()*0.4 - 0.2) * float64(delay)
+	return delay + time.Duration(jitter)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
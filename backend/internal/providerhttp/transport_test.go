@@ -0,0 +1,113 @@
+package providerhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Millisecond,
+	MaxDelay:   5 * time.Millisecond,
+	Budget:     time.Second,
+}
+
+// newFlakyServer fails the first failures requests with status, then echoes the body
+func newFlakyServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func post(t *testing.T, client *http.Client, url string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"model":"m"}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestTransportRetriesRateLimitedRequests(t *testing.T) {
+	srv, calls := newFlakyServer(t, 2, http.StatusTooManyRequests, "")
+	client := &http.Client{Transport: &Transport{Policy: &testPolicy}}
+
+	resp := post(t, client, srv.URL)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"model":"m"}` {
+		t.Errorf("expected replayed body with 200, got %d %q", resp.StatusCode, body)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestTransportGivesUpAfterMaxRetries(t *testing.T) {
+	srv, calls := newFlakyServer(t, 10, http.StatusServiceUnavailable, "")
+	client := &http.Client{Transport: &Transport{Policy: &testPolicy}}
+
+	if resp := post(t, client, srv.URL); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected final 503, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("expected 1 attempt + 3 retries, got %d", calls.Load())
+	}
+}
+
+func TestTransportRetryAfterBeyondBudget(t *testing.T) {
+	srv, calls := newFlakyServer(t, 1, http.StatusTooManyRequests, "120")
+	client := &http.Client{Transport: &Transport{Policy: &testPolicy}}
+
+	start := time.Now()
+	if resp := post(t, client, srv.URL); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected 429 to be returned, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected no wait for a Retry-After beyond the budget (%d calls)", calls.Load())
+	}
+}
+
+func TestSetPolicyDisablesRetries(t *testing.T) {
+	SetPolicy(RetryPolicy{})
+	t.Cleanup(func() { SetPolicy(DefaultPolicy) })
+
+	srv, calls := newFlakyServer(t, 1, http.StatusTooManyRequests, "")
+	if resp := post(t, NewClient(time.Second), srv.URL); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected 429 without retries, got %d", resp.StatusCode)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("expected 3s, got %v %v", d, ok)
+	}
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(date); !ok || d <= 8*time.Second || d > 10*time.Second {
+		t.Errorf("expected ~10s from HTTP date, got %v %v", d, ok)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Error("expected invalid value to be rejected")
+	}
+}
@@ -239,6 +239,29 @@ func (s *Service) GetFileInfo(fileID, accessCode string) (*File, error) {
 	return file, nil
 }
 
+// GetFileForUser returns file metadata if userID owns the file, for callers that
+// authenticate the user instead of presenting the access code
+func (s *Service) GetFileForUser(fileID, userID string) (*File, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, found := s.cache.Get(fileID)
+	if !found {
+		return nil, fmt.Errorf("file not found or expired")
+	}
+
+	file, ok := value.(*File)
+	if !ok {
+		return nil, fmt.Errorf("invalid file data")
+	}
+
+	if file.UserID != userID {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	return file, nil
+}
+
 // DeleteFile removes a file (requires ownership)
 func (s *Service) DeleteFile(fileID, userID string) error {
 	s.mu.Lock()
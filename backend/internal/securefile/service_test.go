@@ -207,6 +207,29 @@ func TestDeleteFile(t *testing.T) {
 	}
 }
 
+// TestGetFileForUser tests owner-checked access without an access code
+func TestGetFileForUser(t *testing.T) {
+	tempDir := t.TempDir()
+	svc := NewService(tempDir)
+
+	result, _ := svc.CreateFile("owner-a", []byte("report"), "report.pdf", "application/pdf")
+
+	file, err := svc.GetFileForUser(result.ID, "owner-a")
+	if err != nil {
+		t.Fatalf("GetFileForUser failed: %v", err)
+	}
+	if file.Filename != "report.pdf" || file.MimeType != "application/pdf" {
+		t.Errorf("Unexpected file: %+v", file)
+	}
+
+	if _, err := svc.GetFileForUser(result.ID, "owner-b"); err == nil || err.Error() != "access denied" {
+		t.Errorf("Expected 'access denied' for non-owner, got: %v", err)
+	}
+	if _, err := svc.GetFileForUser("non-existent", "owner-a"); err == nil {
+		t.Error("Expected error for non-existent file")
+	}
+}
+
 // TestDeleteFileWrongOwner tests deletion by non-owner
 func TestDeleteFileWrongOwner(t *testing.T) {
 	tempDir := t.TempDir()
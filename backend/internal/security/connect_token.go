@@ -0,0 +1,112 @@
+package security
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultConnectTokenTTL is how long a connection token can be exchanged for a
+// WebSocket connection
+const DefaultConnectTokenTTL = 60 * time.Second
+
+// ConnectToken is the user a connection token was issued to
+type ConnectToken struct {
+	UserID    string
+	Email     string
+	ExpiresAt time.Time
+	// TokenIssuedAt is when the access token it was requested with was issued
+	TokenIssuedAt time.Time
+}
+
+// ConnectTokenStore issues short-lived, single-use tokens that authenticate a
+// WebSocket upgrade, so long-lived access tokens never appear in a URL
+type ConnectTokenStore struct {
+	ttl    time.Duration
+	tokens map[string]*ConnectToken
+	mutex  sync.Mutex
+}
+
+// NewConnectTokenStore creates a connection token store whose tokens expire after
+// ttl (0 uses DefaultConnectTokenTTL)
+func NewConnectTokenStore(ttl time.Duration) *ConnectTokenStore {
+	if ttl <= 0 {
+		ttl = DefaultConnectTokenTTL
+	}
+	store := &ConnectTokenStore{
+		ttl:    ttl,
+		tokens: make(map[string]*ConnectToken),
+	}
+
+	// Start cleanup goroutine to remove tokens that were never exchanged
+	go store.cleanupExpired()
+
+	return store
+}
+
+// Issue generates a connection token for the user, who authenticated with an access
+// token issued at tokenIssuedAt
+func (s *ConnectTokenStore) Issue(userID, email string, tokenIssuedAt time.Time) (string, *ConnectToken, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", nil, err
+	}
+	token := hex.EncodeToString(randomBytes)
+
+	issued := &ConnectToken{
+		UserID:        userID,
+		Email:         email,
+		ExpiresAt:     time.Now().Add(s.ttl),
+		TokenIssuedAt: tokenIssuedAt,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[token] = issued
+
+	return token, issued, nil
+}
+
+// Consume validates a connection token and returns who it was issued to. The token
+// is deleted whether or not it is still valid, so it can be used only once.
+func (s *ConnectTokenStore) Consume(token string) (*ConnectToken, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	issued, exists := s.tokens[token]
+	if !exists {
+		return nil, errors.New("invalid or already used connection token")
+	}
+	delete(s.tokens, token)
+
+	if time.Now().After(issued.ExpiresAt) {
+		return nil, errors.New("connection token expired")
+	}
+	return issued, nil
+}
+
+// cleanupExpired removes expired tokens every minute
+func (s *ConnectTokenStore) cleanupExpired() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mutex.Lock()
+		now := time.Now()
+		for token, issued := range s.tokens {
+			if now.After(issued.ExpiresAt) {
+				delete(s.tokens, token)
+			}
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// Count returns the number of outstanding tokens (for monitoring)
+func (s *ConnectTokenStore) Count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.tokens)
+}
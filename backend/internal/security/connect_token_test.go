@@ -0,0 +1,46 @@
+package security
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnectTokenSingleUse(t *testing.T) {
+	store := NewConnectTokenStore(time.Minute)
+
+	signedIn := time.Now().Add(-time.Hour).Truncate(time.Second)
+	token, issued, err := store.Issue("user-1", "user@example.com", signedIn)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if len(token) != 64 || time.Until(issued.ExpiresAt) > time.Minute {
+		t.Errorf("Unexpected token %q expiring at %v", token, issued.ExpiresAt)
+	}
+
+	got, err := store.Consume(token)
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if got.UserID != "user-1" || got.Email != "user@example.com" || !got.TokenIssuedAt.Equal(signedIn) {
+		t.Errorf("Consume returned %+v", got)
+	}
+	if _, err := store.Consume(token); err == nil {
+		t.Error("Expected a used token to be rejected")
+	}
+	if _, err := store.Consume("unknown"); err == nil {
+		t.Error("Expected an unknown token to be rejected")
+	}
+}
+
+func TestConnectTokenExpires(t *testing.T) {
+	store := NewConnectTokenStore(time.Millisecond)
+
+	token, _, _ := store.Issue("user-1", "", time.Now())
+	time.Sleep(5 * time.Millisecond)
+	if _, err := store.Consume(token); err == nil {
+		t.Error("Expected an expired token to be rejected")
+	}
+	if store.Count() != 0 {
+		t.Errorf("Expected the expired token to be removed, %d left", store.Count())
+	}
+}
@@ -93,6 +93,12 @@ func ValidateURLForSSRF(rawURL string) error {
 		return fmt.Errorf("URL must have a hostname")
 	}
 
+	return ValidateHostForSSRF(hostname)
+}
+
+// ValidateHostForSSRF validates a hostname or IP address before connecting to it,
+// for connections not made from a URL (e.g. database drivers)
+func ValidateHostForSSRF(hostname string) error {
 	// Check against blocked hostnames
 	if IsBlockedHostname(hostname) {
 		return fmt.Errorf("access to internal hostname '%s' is not allowed", hostname)
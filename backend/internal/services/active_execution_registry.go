@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ExecutionCancelledError is stored on executions cancelled by their owner
+const ExecutionCancelledError = "Execution cancelled by user"
+
+// ActiveExecution is a workflow execution currently running on this server
+type ActiveExecution struct {
+	ID        string
+	UserID    string
+	StartedAt time.Time
+	cancel    context.CancelFunc
+	cancelled atomic.Bool
+}
+
+// Cancelled reports whether the execution was cancelled through the registry.
+// The canceller has already recorded the final status, so runners should not overwrite it.
+func (a *ActiveExecution) Cancelled() bool {
+	return a != nil && a.cancelled.Load()
+}
+
+// ActiveExecutionRegistry tracks running executions by ID so they can be cancelled
+// from outside the goroutine (or WebSocket) that started them
+type ActiveExecutionRegistry struct {
+	mu         sync.Mutex
+	executions map[string]*ActiveExecution
+	queued     atomic.Int64
+
+	// Concurrency slots handed out by Acquire
+	limits    ExecutionLimits
+	slots     int
+	userSlots map[string]int
+	slotFreed chan struct{} // closed and replaced whenever a slot is released
+}
+
+// ExecutionLimits caps concurrently running executions (0 means unlimited)
+type ExecutionLimits struct {
+	MaxConcurrent        int
+	MaxConcurrentPerUser int
+	// QueueTimeout is how long Acquire waits for a free slot before reporting busy
+	QueueTimeout time.Duration
+}
+
+// ServerBusyRetryAfter is the retry delay suggested to callers turned away by the limits
+const ServerBusyRetryAfter = 15 * time.Second
+
+// ServerBusyError is returned when no execution slot is available
+type ServerBusyError struct {
+	PerUser    bool // The caller's own limit was reached, not the global one
+	RetryAfter time.Duration
+}
+
+func (e *ServerBusyError) Error() string {
+	if e.PerUser {
+		return fmt.Sprintf("Too many of your workflows are already running. Please retry in %d seconds.", int(e.RetryAfter.Seconds()))
+	}
+	return fmt.Sprintf("Server busy: too many workflows are running. Please retry in %d seconds.", int(e.RetryAfter.Seconds()))
+}
+
+// NewActiveExecutionRegistry creates an empty registry
+func NewActiveExecutionRegistry() *ActiveExecutionRegistry {
+	return &ActiveExecutionRegistry{
+		executions: make(map[string]*ActiveExecution),
+		userSlots:  make(map[string]int),
+		slotFreed:  make(chan struct{}),
+	}
+}
+
+// SetLimits configures the concurrency caps enforced by Acquire
+func (r *ActiveExecutionRegistry) SetLimits(limits ExecutionLimits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = limits
+}
+
+// Acquire reserves a concurrency slot for userID, waiting up to the configured
+// queue timeout for one to free up. Returns *ServerBusyError if none does. The
+// returned release func must be called when the execution ends; it is safe to
+// call more than once, so it can be deferred.
+func (r *ActiveExecutionRegistry) Acquire(ctx context.Context, userID string) (release func(), err error) {
+	if r == nil {
+		return func() {}, nil
+	}
+	r.mu.Lock()
+	timeout := r.limits.QueueTimeout
+	r.mu.Unlock()
+	return r.acquire(ctx, userID, timeout)
+}
+
+// TryAcquire reserves a concurrency slot without waiting
+func (r *ActiveExecutionRegistry) TryAcquire(userID string) (release func(), err error) {
+	if r == nil {
+		return func() {}, nil
+	}
+	return r.acquire(context.Background(), userID, 0)
+}
+
+func (r *ActiveExecutionRegistry) acquire(ctx context.Context, userID string, timeout time.Duration) (func(), error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		r.mu.Lock()
+		globalFull := r.limits.MaxConcurrent > 0 && r.slots >= r.limits.MaxConcurrent
+		userFull := r.limits.MaxConcurrentPerUser > 0 && r.userSlots[userID] >= r.limits.MaxConcurrentPerUser
+		if !globalFull && !userFull {
+			r.slots++
+			r.userSlots[userID]++
+			r.mu.Unlock()
+			return r.releaseFunc(userID), nil
+		}
+		freed := r.slotFreed
+		r.mu.Unlock()
+
+		busy := &ServerBusyError{PerUser: userFull && !globalFull, RetryAfter: ServerBusyRetryAfter}
+		if deadline == nil {
+			return nil, busy
+		}
+		select {
+		case <-freed:
+		case <-deadline:
+			return nil, busy
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// releaseFunc returns a func that frees userID's slot exactly once
+func (r *ActiveExecutionRegistry) releaseFunc(userID string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.slots--
+			if r.userSlots[userID]--; r.userSlots[userID] <= 0 {
+				delete(r.userSlots, userID)
+			}
+			close(r.slotFreed)
+			r.slotFreed = make(chan struct{})
+		})
+	}
+}
+
+// Register tracks an execution and returns a context that is cancelled when the
+// execution is cancelled by ID. Call Unregister when the execution finishes.
+// A nil registry still returns a cancellable context.
+func (r *ActiveExecutionRegistry) Register(ctx context.Context, executionID, userID string) (context.Context, *ActiveExecution) {
+	execCtx, cancel := context.WithCancel(ctx)
+	active := &ActiveExecution{ID: executionID, UserID: userID, StartedAt: time.Now(), cancel: cancel}
+	if r == nil {
+		return execCtx, active
+	}
+
+	r.mu.Lock()
+	r.executions[executionID] = active
+	r.mu.Unlock()
+	return execCtx, active
+}
+
+// Unregister stops tracking an execution and releases its context
+func (r *ActiveExecutionRegistry) Unregister(active *ActiveExecution) {
+	if active == nil {
+		return
+	}
+	active.cancel()
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	if r.executions[active.ID] == active {
+		delete(r.executions, active.ID)
+	}
+	r.mu.Unlock()
+}
+
+// Cancel signals cancellation to a running execution owned by userID.
+// Returns false if no such execution is running on this server.
+func (r *ActiveExecutionRegistry) Cancel(executionID, userID string) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	active, exists := r.executions[executionID]
+	if !exists || active.UserID != userID || !active.cancelled.CompareAndSwap(false, true) {
+		r.mu.Unlock()
+		return false
+	}
+	r.mu.Unlock()
+
+	active.cancel()
+	return true
+}
+
+// CancelUser signals cancellation to every execution userID is running on this server
+// and returns the IDs of the executions it cancelled
+func (r *ActiveExecutionRegistry) CancelUser(userID string) []string {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	var cancelled []*ActiveExecution
+	for _, active := range r.executions {
+		if active.UserID == userID && active.cancelled.CompareAndSwap(false, true) {
+			cancelled = append(cancelled, active)
+		}
+	}
+	r.mu.Unlock()
+
+	ids := make([]string, 0, len(cancelled))
+	for _, active := range cancelled {
+		active.cancel()
+		ids = append(ids, active.ID)
+	}
+	return ids
+}
+
+// ActiveIDs returns the IDs of all executions currently running on this server
+func (r *ActiveExecutionRegistry) ActiveIDs() []string {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.executions))
+	for id := range r.executions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Queue counts an execution request that has been accepted but is not running yet.
+// The returned func removes it from the queue and is safe to call more than once,
+// so callers can both call it when the execution starts and defer it for early
+// returns and panics.
+func (r *ActiveExecutionRegistry) Queue() (dequeue func()) {
+	if r == nil {
+		return func() {}
+	}
+
+	r.queued.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { r.queued.Add(-1) })
+	}
+}
+
+// ActiveExecutionInfo describes a running execution for monitoring
+type ActiveExecutionInfo struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	StartedAt      time.Time `json:"started_at"`
+	RunningSeconds int64     `json:"running_seconds"`
+}
+
+// ExecutionLoad is a snapshot of execution load on this server
+type ExecutionLoad struct {
+	Active               int                   `json:"active"`
+	Queued               int                   `json:"queued"`
+	MaxConcurrent        int                   `json:"max_concurrent"`          // 0 = unlimited
+	MaxConcurrentPerUser int                   `json:"max_concurrent_per_user"` // 0 = unlimited
+	Executions           []ActiveExecutionInfo `json:"executions"`              // Oldest first, to spot stuck executions
+}
+
+// Load returns the running executions and the number of queued requests
+func (r *ActiveExecutionRegistry) Load() ExecutionLoad {
+	load := ExecutionLoad{Executions: []ActiveExecutionInfo{}}
+	if r == nil {
+		return load
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	for _, active := range r.executions {
+		load.Executions = append(load.Executions, ActiveExecutionInfo{
+			ID:             active.ID,
+			UserID:         active.UserID,
+			StartedAt:      active.StartedAt,
+			RunningSeconds: int64(now.Sub(active.StartedAt).Seconds()),
+		})
+	}
+	load.MaxConcurrent = r.limits.MaxConcurrent
+	load.MaxConcurrentPerUser = r.limits.MaxConcurrentPerUser
+	r.mu.Unlock()
+
+	sort.Slice(load.Executions, func(i, j int) bool {
+		return load.Executions[i].StartedAt.Before(load.Executions[j].StartedAt)
+	})
+	load.Active = len(load.Executions)
+	load.Queued = int(r.queued.Load())
+	return load
+}
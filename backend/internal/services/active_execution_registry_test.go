@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestActiveExecutionRegistryCancel(t *testing.T) {
+	registry := NewActiveExecutionRegistry()
+	ctx, active := registry.Register(context.Background(), "exec-1", "user-1")
+
+	if ids := registry.ActiveIDs(); len(ids) != 1 || ids[0] != "exec-1" {
+		t.Fatalf("Expected exec-1 to be active, got %v", ids)
+	}
+
+	// Other users cannot cancel the execution
+	if registry.Cancel("exec-1", "user-2") {
+		t.Fatal("Expected cancel by non-owner to fail")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Context should not be cancelled by non-owner")
+	}
+
+	if !registry.Cancel("exec-1", "user-1") {
+		t.Fatal("Expected cancel by owner to succeed")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected execution context to be cancelled")
+	}
+	if !active.Cancelled() {
+		t.Error("Expected execution to be marked cancelled")
+	}
+	if registry.Cancel("exec-1", "user-1") {
+		t.Error("Expected second cancel to report nothing to cancel")
+	}
+
+	registry.Unregister(active)
+	if registry.Cancel("exec-1", "user-1") || len(registry.ActiveIDs()) != 0 {
+		t.Error("Expected execution to be gone after unregister")
+	}
+}
+
+func TestActiveExecutionRegistryCancelUser(t *testing.T) {
+	registry := NewActiveExecutionRegistry()
+	ctx1, first := registry.Register(context.Background(), "exec-1", "user-1")
+	ctx2, _ := registry.Register(context.Background(), "exec-2", "user-1")
+	otherCtx, _ := registry.Register(context.Background(), "exec-3", "user-2")
+
+	// An execution the user already cancelled is not reported again
+	registry.Cancel("exec-1", "user-1")
+
+	ids := registry.CancelUser("user-1")
+	if len(ids) != 1 || ids[0] != "exec-2" {
+		t.Fatalf("Expected only exec-2 to be cancelled, got %v", ids)
+	}
+	if ctx1.Err() == nil || ctx2.Err() == nil {
+		t.Error("Expected all of user-1's executions to be cancelled")
+	}
+	if !first.Cancelled() {
+		t.Error("Expected exec-1 to stay marked cancelled")
+	}
+	if otherCtx.Err() != nil {
+		t.Error("Other users' executions must not be cancelled")
+	}
+	if ids := registry.CancelUser("user-1"); len(ids) != 0 {
+		t.Errorf("Expected nothing left to cancel, got %v", ids)
+	}
+
+	var nilRegistry *ActiveExecutionRegistry
+	if ids := nilRegistry.CancelUser("user-1"); len(ids) != 0 {
+		t.Errorf("Expected nil registry to cancel nothing, got %v", ids)
+	}
+}
+
+func TestActiveExecutionRegistryUnregisterNotCancelled(t *testing.T) {
+	var registry *ActiveExecutionRegistry // nil registry still works
+	ctx, active := registry.Register(context.Background(), "exec-1", "user-1")
+	registry.Unregister(active)
+
+	if active.Cancelled() {
+		t.Error("Finished execution should not report cancelled")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected context to be released on unregister")
+	}
+}
+
+func TestActiveExecutionRegistryLoad(t *testing.T) {
+	registry := NewActiveExecutionRegistry()
+
+	dequeue := registry.Queue()
+	registry.Queue()() // abandoned before starting
+	if stats := registry.Load(); stats.Queued != 1 || stats.Active != 0 {
+		t.Fatalf("Expected 1 queued, 0 active, got %+v", stats)
+	}
+
+	_, first := registry.Register(context.Background(), "exec-1", "user-1")
+	dequeue()
+	dequeue() // extra calls (e.g. deferred) must not drive the count negative
+	_, second := registry.Register(context.Background(), "exec-2", "user-2")
+
+	stats := registry.Load()
+	if stats.Queued != 0 || stats.Active != 2 {
+		t.Fatalf("Expected 0 queued, 2 active, got %+v", stats)
+	}
+	if stats.Executions[0].ID != "exec-1" {
+		t.Errorf("Expected oldest execution first, got %v", stats.Executions)
+	}
+
+	registry.Unregister(first)
+	registry.Unregister(second)
+	if stats := registry.Load(); stats.Active != 0 || len(stats.Executions) != 0 {
+		t.Errorf("Expected no active executions, got %+v", stats)
+	}
+}
+
+func TestActiveExecutionRegistryLimits(t *testing.T) {
+	registry := NewActiveExecutionRegistry()
+	registry.SetLimits(ExecutionLimits{MaxConcurrent: 2, MaxConcurrentPerUser: 1, QueueTimeout: time.Second})
+
+	releaseA, err := registry.Acquire(context.Background(), "user-a")
+	if err != nil {
+		t.Fatalf("Expected first slot, got %v", err)
+	}
+
+	// Per-user cap is reached for user-a
+	var busy *ServerBusyError
+	if _, err := registry.TryAcquire("user-a"); !errors.As(err, &busy) || !busy.PerUser {
+		t.Fatalf("Expected per-user busy error, got %v", err)
+	}
+
+	releaseB, err := registry.TryAcquire("user-b")
+	if err != nil {
+		t.Fatalf("Expected second slot, got %v", err)
+	}
+
+	// Global cap is reached: a waiting request gets the slot once one is released
+	acquired := make(chan error, 1)
+	go func() {
+		release, err := registry.Acquire(context.Background(), "user-c")
+		if err == nil {
+			defer release()
+		}
+		acquired <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	releaseB()
+	releaseB() // releasing twice must not free a second slot
+	if err := <-acquired; err != nil {
+		t.Fatalf("Expected queued request to get a slot, got %v", err)
+	}
+
+	releaseA()
+	registry.SetLimits(ExecutionLimits{MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond})
+	hold, _ := registry.TryAcquire("user-a")
+	defer hold()
+	if _, err := registry.Acquire(context.Background(), "user-b"); !errors.As(err, &busy) || busy.PerUser {
+		t.Errorf("Expected global busy error after queue timeout, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"claraverse/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrAgentDisabled is returned for executions of an agent whose kill switch is on
+var ErrAgentDisabled = errors.New("agent is disabled")
+
+// ErrAgentDisabledByAdmin is returned when an owner switches an agent an admin disabled
+var ErrAgentDisabledByAdmin = errors.New("agent was disabled by an administrator")
+
+// CheckAgentEnabled returns an error wrapping ErrAgentDisabled, with the reason it
+// was disabled, if the agent must not run. Every execution path checks it.
+func CheckAgentEnabled(agent *models.Agent) error {
+	if agent == nil || !agent.Disabled {
+		return nil
+	}
+	if agent.DisabledReason != "" {
+		return fmt.Errorf("%w (%s); enable it to run it again", ErrAgentDisabled, agent.DisabledReason)
+	}
+	return fmt.Errorf("%w; enable it to run it again", ErrAgentDisabled)
+}
+
+// CheckOwnerCanSwitch returns ErrAgentDisabledByAdmin if the agent was disabled by
+// someone other than its owner: only an admin can lift (or reword) that.
+func CheckOwnerCanSwitch(agent *models.Agent) error {
+	if agent.Disabled && agent.DisabledBy != "" && agent.DisabledBy != agent.UserID {
+		return ErrAgentDisabledByAdmin
+	}
+	return nil
+}
+
+// SetAgentDisabled turns an agent's kill switch on or off. by is the user ID of
+// the admin who changed it. The workflow and schedule are left untouched, so
+// enabling the agent restores it.
+func (s *AgentService) SetAgentDisabled(agentID string, disabled bool, reason, by string) (*models.Agent, error) {
+	return s.setAgentDisabled(bson.M{"agentId": agentID}, agentID, disabled, reason, by)
+}
+
+// SetAgentDisabledByOwner is SetAgentDisabled for the agent's owner. It returns
+// ErrAgentDisabledByAdmin while an admin's disable is in place (see CheckOwnerCanSwitch).
+func (s *AgentService) SetAgentDisabledByOwner(agentID string, disabled bool, reason, ownerID string) (*models.Agent, error) {
+	agent, err := s.GetAgent(agentID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckOwnerCanSwitch(agent); err != nil {
+		return nil, err
+	}
+
+	// Guard the update too, in case an admin disables the agent in the meantime
+	filter := bson.M{
+		"agentId":    agentID,
+		"userId":     ownerID,
+		"disabledBy": bson.M{"$in": []interface{}{nil, "", ownerID}},
+	}
+	agent, err = s.setAgentDisabled(filter, agentID, disabled, reason, ownerID)
+	if err != nil && err.Error() == "agent not found" {
+		return nil, ErrAgentDisabledByAdmin
+	}
+	return agent, err
+}
+
+func (s *AgentService) setAgentDisabled(filter bson.M, agentID string, disabled bool, reason, by string) (*models.Agent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var update bson.M
+	if disabled {
+		update = bson.M{"$set": bson.M{
+			"disabled":       true,
+			"disabledReason": reason,
+			"disabledBy":     by,
+			"disabledAt":     now,
+			"updatedAt":      now,
+		}}
+	} else {
+		update = bson.M{
+			"$set":   bson.M{"updatedAt": now},
+			"$unset": bson.M{"disabled": "", "disabledReason": "", "disabledBy": "", "disabledAt": ""},
+		}
+	}
+
+	result, err := s.agentsCollection().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update agent: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("agent not found")
+	}
+
+	if disabled {
+		log.Printf("⛔ [AGENT] Agent %s disabled by %s (reason: %q)", agentID, by, reason)
+	} else {
+		log.Printf("✅ [AGENT] Agent %s enabled by %s", agentID, by)
+	}
+	return s.GetAgentByID(agentID)
+}
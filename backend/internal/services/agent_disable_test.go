@@ -0,0 +1,45 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"claraverse/internal/models"
+)
+
+func TestCheckAgentEnabled(t *testing.T) {
+	if err := CheckAgentEnabled(&models.Agent{ID: "agent-1"}); err != nil {
+		t.Errorf("Expected an enabled agent to run, got %v", err)
+	}
+
+	err := CheckAgentEnabled(&models.Agent{ID: "agent-1", Disabled: true, DisabledReason: "runaway trigger"})
+	if !errors.Is(err, ErrAgentDisabled) {
+		t.Fatalf("Expected ErrAgentDisabled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "runaway trigger") {
+		t.Errorf("Expected the reason in the error, got %q", err.Error())
+	}
+
+	if err := CheckAgentEnabled(&models.Agent{ID: "agent-1", Disabled: true}); !errors.Is(err, ErrAgentDisabled) {
+		t.Errorf("Expected ErrAgentDisabled without a reason, got %v", err)
+	}
+}
+
+func TestCheckOwnerCanSwitch(t *testing.T) {
+	cases := []struct {
+		name  string
+		agent *models.Agent
+		want  error
+	}{
+		{"enabled", &models.Agent{UserID: "owner"}, nil},
+		{"disabled by owner", &models.Agent{UserID: "owner", Disabled: true, DisabledBy: "owner"}, nil},
+		{"disabled before disabledBy was tracked", &models.Agent{UserID: "owner", Disabled: true}, nil},
+		{"disabled by admin", &models.Agent{UserID: "owner", Disabled: true, DisabledBy: "admin"}, ErrAgentDisabledByAdmin},
+	}
+	for _, tc := range cases {
+		if err := CheckOwnerCanSwitch(tc.agent); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
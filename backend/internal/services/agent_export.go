@@ -0,0 +1,324 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// workflowBlockTypes are the block types the execution engine has executors for
+var workflowBlockTypes = map[string]bool{
+	"variable":      true,
+	"llm_inference": true,
+	"code_block":    true,
+	"loop":          true,
+}
+
+// exportRemovedConfigKeys are block config keys never exported: credential IDs and
+// uploaded file references only mean something to the exporting user
+var exportRemovedConfigKeys = map[string]bool{
+	"credentials": true,
+	"fileValue":   true,
+}
+
+// secretKeyNames are key names (lowercased, without "_" and "-") that hold secrets,
+// at any nesting depth (e.g. static tool arguments)
+var secretKeyNames = map[string]bool{
+	"apikey":        true,
+	"secret":        true,
+	"secretkey":     true,
+	"clientsecret":  true,
+	"password":      true,
+	"token":         true,
+	"accesstoken":   true,
+	"authtoken":     true,
+	"bearertoken":   true,
+	"refreshtoken":  true,
+	"authorization": true,
+	"webhooksecret": true,
+	"privatekey":    true,
+}
+
+// WorkflowValidationError reports why a workflow's structure was rejected
+type WorkflowValidationError struct {
+	Errors []models.ValidationError
+}
+
+func (e *WorkflowValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Message
+	}
+	return "invalid workflow: " + strings.Join(messages, "; ")
+}
+
+// ExportAgent returns a portable definition of the user's agent, without secrets
+func (s *AgentService) ExportAgent(agentID, userID string) (*models.AgentExport, error) {
+	agent, err := s.GetAgent(agentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := agent.Workflow
+	if workflow == nil {
+		if workflow, err = s.GetWorkflow(agentID); err != nil && err.Error() != "workflow not found" {
+			return nil, err
+		}
+	}
+
+	export := &models.AgentExport{
+		FormatVersion: models.AgentExportFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Name:          agent.Name,
+		Description:   agent.Description,
+	}
+	if workflow == nil {
+		return export, nil
+	}
+
+	export.Workflow = models.AgentExportWorkflow{
+		Blocks:      sanitizeExportBlocks(workflow.Blocks, &export.RemovedFields),
+		Connections: workflow.Connections,
+		Variables:   workflow.Variables,
+	}
+	export.Inputs = workflowInputs(workflow.Blocks)
+	export.RequiredIntegrations = WorkflowRequiredIntegrations(workflow)
+
+	return export, nil
+}
+
+// ImportAgent creates a new agent for the user from an export. Block and connection
+// IDs are regenerated, and the workflow structure is validated before anything is saved.
+func (s *AgentService) ImportAgent(userID string, export *models.AgentExport) (*models.Agent, *models.Workflow, error) {
+	if export.FormatVersion < 1 || export.FormatVersion > models.AgentExportFormatVersion {
+		return nil, nil, &WorkflowValidationError{Errors: []models.ValidationError{{
+			Type:    "schema",
+			Message: fmt.Sprintf("Unsupported export format version %d (supported: 1-%d)", export.FormatVersion, models.AgentExportFormatVersion),
+		}}}
+	}
+	if strings.TrimSpace(export.Name) == "" {
+		return nil, nil, &WorkflowValidationError{Errors: []models.ValidationError{{
+			Type:    "schema",
+			Message: "Agent name is required",
+		}}}
+	}
+
+	if validationErrors := ValidateWorkflowStructure(export.Workflow.Blocks, export.Workflow.Connections); len(validationErrors) > 0 {
+		return nil, nil, &WorkflowValidationError{Errors: validationErrors}
+	}
+
+	// Exports from elsewhere may still carry credential references or secrets
+	var removed []string
+	blocks, connections := remapWorkflowIDs(sanitizeExportBlocks(export.Workflow.Blocks, &removed), export.Workflow.Connections)
+	if len(removed) > 0 {
+		log.Printf("🧹 [AGENT] Import for user %s dropped config fields: %v", userID, removed)
+	}
+
+	agent, err := s.CreateAgent(userID, export.Name, export.Description)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workflow, err := s.SaveWorkflowWithDescription(agent.ID, userID, &models.SaveWorkflowRequest{
+		Blocks:        blocks,
+		Connections:   connections,
+		Variables:     export.Workflow.Variables,
+		CreateVersion: true,
+	}, "Imported")
+	if err != nil {
+		// Don't leave an empty agent behind
+		if delErr := s.DeleteAgent(agent.ID, userID); delErr != nil {
+			log.Printf("⚠️ [AGENT] Failed to clean up agent %s after failed import: %v", agent.ID, delErr)
+		}
+		return nil, nil, err
+	}
+
+	agent.Workflow = workflow
+	return agent, workflow, nil
+}
+
+// ValidateWorkflowStructure checks that a workflow is a well-formed DAG: known block
+// types, unique IDs, connections between existing blocks and no cycles
+func ValidateWorkflowStructure(blocks []models.Block, connections []models.Connection) []models.ValidationError {
+	var errs []models.ValidationError
+	if len(blocks) == 0 {
+		return append(errs, models.ValidationError{Type: "schema", Message: "Workflow must have at least one block"})
+	}
+
+	blockIDs := make(map[string]bool, len(blocks))
+	for _, block := range blocks {
+		switch {
+		case block.ID == "":
+			errs = append(errs, models.ValidationError{Type: "schema", Message: fmt.Sprintf("Block %q has no ID", block.Name)})
+		case blockIDs[block.ID]:
+			errs = append(errs, models.ValidationError{Type: "schema", Message: fmt.Sprintf("Duplicate block ID: %s", block.ID), BlockID: block.ID})
+		}
+		blockIDs[block.ID] = true
+
+		if !workflowBlockTypes[block.Type] {
+			errs = append(errs, models.ValidationError{Type: "schema", Message: fmt.Sprintf("Invalid block type: %s", block.Type), BlockID: block.ID})
+		}
+	}
+
+	dependents := make(map[string][]string)
+	inDegree := make(map[string]int, len(blocks))
+	for _, conn := range connections {
+		valid := true
+		if !blockIDs[conn.SourceBlockID] {
+			errs = append(errs, models.ValidationError{Type: "missing_input", Message: fmt.Sprintf("Connection references non-existent source block: %s", conn.SourceBlockID), ConnectionID: conn.ID})
+			valid = false
+		}
+		if !blockIDs[conn.TargetBlockID] {
+			errs = append(errs, models.ValidationError{Type: "missing_input", Message: fmt.Sprintf("Connection references non-existent target block: %s", conn.TargetBlockID), ConnectionID: conn.ID})
+			valid = false
+		}
+		if valid {
+			dependents[conn.SourceBlockID] = append(dependents[conn.SourceBlockID], conn.TargetBlockID)
+			inDegree[conn.TargetBlockID]++
+		}
+	}
+
+	// Kahn's algorithm: blocks never reaching in-degree 0 are on a cycle
+	var ready []string
+	for id := range blockIDs {
+		if inDegree[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	visited := 0
+	for len(ready) > 0 {
+		id := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		visited++
+		for _, next := range dependents[id] {
+			if inDegree[next]--; inDegree[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+	if visited < len(blockIDs) {
+		errs = append(errs, models.ValidationError{Type: "cycle", Message: "Workflow connections contain a cycle"})
+	}
+
+	return errs
+}
+
+// remapWorkflowIDs gives every block and connection a new ID, keeping the
+// connections between them. Blocks reference each other by normalized name, not ID.
+func remapWorkflowIDs(blocks []models.Block, connections []models.Connection) ([]models.Block, []models.Connection) {
+	newIDs := make(map[string]string, len(blocks))
+	remappedBlocks := make([]models.Block, len(blocks))
+	for i, block := range blocks {
+		newIDs[block.ID] = uuid.New().String()
+		block.ID = newIDs[block.ID]
+		remappedBlocks[i] = block
+	}
+
+	remappedConns := make([]models.Connection, len(connections))
+	for i, conn := range connections {
+		conn.ID = uuid.New().String()
+		conn.SourceBlockID = newIDs[conn.SourceBlockID]
+		conn.TargetBlockID = newIDs[conn.TargetBlockID]
+		remappedConns[i] = conn
+	}
+	return remappedBlocks, remappedConns
+}
+
+// sanitizeExportBlocks returns copies of blocks without credential references or
+// secret values in their configs, appending the removed "<block>.<path>" to removed
+func sanitizeExportBlocks(blocks []models.Block, removed *[]string) []models.Block {
+	sanitized := make([]models.Block, len(blocks))
+	for i, block := range blocks {
+		if config, ok := sanitizeExportValue(block.Config, block.Name, removed, true).(map[string]any); ok {
+			block.Config = config
+		}
+		sanitized[i] = block
+	}
+	return sanitized
+}
+
+func sanitizeExportValue(value any, path string, removed *[]string, topLevel bool) any {
+	switch v := value.(type) {
+	case primitive.M:
+		return sanitizeExportValue(map[string]any(v), path, removed, topLevel)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, nested := range v {
+			if (topLevel && exportRemovedConfigKeys[key]) || isSecretKeyName(key) {
+				*removed = append(*removed, path+"."+key)
+				continue
+			}
+			out[key] = sanitizeExportValue(nested, path+"."+key, removed, false)
+		}
+		return out
+	case primitive.A:
+		return sanitizeExportValue([]any(v), path, removed, false)
+	case []any:
+		out := make([]any, len(v))
+		for i, nested := range v {
+			out[i] = sanitizeExportValue(nested, fmt.Sprintf("%s[%d]", path, i), removed, false)
+		}
+		return out
+	}
+	return value
+}
+
+func isSecretKeyName(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return secretKeyNames[normalized]
+}
+
+// workflowInputs lists the variable blocks reading workflow inputs
+func workflowInputs(blocks []models.Block) []models.AgentExportInput {
+	var inputs []models.AgentExportInput
+	for _, block := range blocks {
+		if block.Type != "variable" {
+			continue
+		}
+		if operation, _ := block.Config["operation"].(string); operation != "" && operation != "read" {
+			continue
+		}
+		name, _ := block.Config["variableName"].(string)
+		if name == "" {
+			continue
+		}
+		inputType, _ := block.Config["inputType"].(string)
+		inputs = append(inputs, models.AgentExportInput{
+			BlockName:    block.Name,
+			VariableName: name,
+			InputType:    inputType,
+		})
+	}
+	return inputs
+}
+
+// WorkflowRequiredIntegrations groups the workflow's tools by the credential type they need
+func WorkflowRequiredIntegrations(workflow *models.Workflow) []models.AgentExportIntegration {
+	byType := make(map[string][]string)
+	for name := range workflowToolBlocks(workflow) {
+		if integrationType := tools.GetIntegrationTypeForTool(name); integrationType != "" {
+			byType[integrationType] = append(byType[integrationType], name)
+		}
+	}
+
+	integrations := make([]models.AgentExportIntegration, 0, len(byType))
+	for integrationType, toolNames := range byType {
+		sort.Strings(toolNames)
+		integrations = append(integrations, models.AgentExportIntegration{
+			IntegrationType: integrationType,
+			Tools:           toolNames,
+		})
+	}
+	sort.Slice(integrations, func(i, j int) bool {
+		return integrations[i].IntegrationType < integrations[j].IntegrationType
+	})
+	return integrations
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"claraverse/internal/models"
+)
+
+func exportTestWorkflow() *models.Workflow {
+	return &models.Workflow{
+		Blocks: []models.Block{
+			{ID: "b1", Name: "Start", Type: "variable", Config: map[string]any{
+				"operation": "read", "variableName": "input", "inputType": "file",
+				"fileValue": map[string]any{"file_id": "f-123"},
+			}},
+			{ID: "b2", Name: "Notify", Type: "llm_inference", Config: map[string]any{
+				"enabledTools": []any{"send_slack_message", "search_web"},
+				"credentials":  []any{"cred-1"},
+				"maxTokens":    1000,
+			}},
+			{ID: "b3", Name: "Call API", Type: "code_block", Config: map[string]any{
+				"toolName": "api_request",
+				"argumentMapping": map[string]any{
+					"url":     "https://example.com",
+					"headers": map[string]any{"Authorization": "Bearer secret"},
+					"api_key": "sk-123",
+				},
+			}},
+		},
+		Connections: []models.Connection{
+			{ID: "c1", SourceBlockID: "b1", TargetBlockID: "b2"},
+			{ID: "c2", SourceBlockID: "b2", TargetBlockID: "b3"},
+		},
+	}
+}
+
+func TestSanitizeExportBlocks(t *testing.T) {
+	workflow := exportTestWorkflow()
+
+	var removed []string
+	blocks := sanitizeExportBlocks(workflow.Blocks, &removed)
+
+	if _, ok := blocks[0].Config["fileValue"]; ok {
+		t.Error("Expected file reference to be removed")
+	}
+	if _, ok := blocks[1].Config["credentials"]; ok {
+		t.Error("Expected credential references to be removed")
+	}
+	if blocks[1].Config["maxTokens"] != 1000 {
+		t.Error("Expected maxTokens to be kept")
+	}
+	args := blocks[2].Config["argumentMapping"].(map[string]any)
+	if _, ok := args["api_key"]; ok {
+		t.Error("Expected nested api_key to be removed")
+	}
+	if _, ok := args["headers"].(map[string]any)["Authorization"]; ok {
+		t.Error("Expected nested Authorization header to be removed")
+	}
+	if args["url"] != "https://example.com" {
+		t.Error("Expected non-secret arguments to be kept")
+	}
+	if len(removed) != 4 {
+		t.Errorf("Expected 4 removed fields, got %v", removed)
+	}
+
+	// The stored workflow must not be modified
+	if _, ok := workflow.Blocks[1].Config["credentials"]; !ok {
+		t.Error("Sanitizing modified the original block config")
+	}
+}
+
+func TestValidateWorkflowStructure(t *testing.T) {
+	workflow := exportTestWorkflow()
+	if errs := ValidateWorkflowStructure(workflow.Blocks, workflow.Connections); len(errs) != 0 {
+		t.Fatalf("Expected valid workflow, got %v", errs)
+	}
+
+	tests := map[string]struct {
+		blocks      []models.Block
+		connections []models.Connection
+		wantType    string
+	}{
+		"empty":        {nil, nil, "schema"},
+		"unknown type": {[]models.Block{{ID: "a", Type: "shell"}}, nil, "schema"},
+		"duplicate id": {[]models.Block{{ID: "a", Type: "variable"}, {ID: "a", Type: "variable"}}, nil, "schema"},
+		"dangling connection": {
+			[]models.Block{{ID: "a", Type: "variable"}},
+			[]models.Connection{{ID: "c", SourceBlockID: "a", TargetBlockID: "missing"}},
+			"missing_input",
+		},
+		"cycle": {
+			[]models.Block{{ID: "a", Type: "variable"}, {ID: "b", Type: "llm_inference"}},
+			[]models.Connection{{ID: "c1", SourceBlockID: "a", TargetBlockID: "b"}, {ID: "c2", SourceBlockID: "b", TargetBlockID: "a"}},
+			"cycle",
+		},
+	}
+	for name, tt := range tests {
+		errs := ValidateWorkflowStructure(tt.blocks, tt.connections)
+		if len(errs) == 0 || errs[0].Type != tt.wantType {
+			t.Errorf("%s: expected a %s error, got %v", name, tt.wantType, errs)
+		}
+	}
+}
+
+func TestRemapWorkflowIDs(t *testing.T) {
+	workflow := exportTestWorkflow()
+	blocks, connections := remapWorkflowIDs(workflow.Blocks, workflow.Connections)
+
+	for i, block := range blocks {
+		if block.ID == workflow.Blocks[i].ID {
+			t.Errorf("Block %s kept its ID", block.Name)
+		}
+	}
+	if connections[0].SourceBlockID != blocks[0].ID || connections[0].TargetBlockID != blocks[1].ID ||
+		connections[1].SourceBlockID != blocks[1].ID || connections[1].TargetBlockID != blocks[2].ID {
+		t.Errorf("Connections were not remapped to the new block IDs: %+v", connections)
+	}
+	if errs := ValidateWorkflowStructure(blocks, connections); len(errs) != 0 {
+		t.Errorf("Remapped workflow is invalid: %v", errs)
+	}
+}
+
+func TestWorkflowExportMetadata(t *testing.T) {
+	workflow := exportTestWorkflow()
+
+	inputs := workflowInputs(workflow.Blocks)
+	if len(inputs) != 1 || inputs[0].VariableName != "input" || inputs[0].InputType != "file" {
+		t.Errorf("Unexpected inputs %+v", inputs)
+	}
+
+	want := []models.AgentExportIntegration{
+		{IntegrationType: "rest_api", Tools: []string{"api_request"}},
+		{IntegrationType: "slack", Tools: []string{"send_slack_message"}},
+	}
+	if got := WorkflowRequiredIntegrations(workflow); !reflect.DeepEqual(got, want) {
+		t.Errorf("WorkflowRequiredIntegrations = %+v, want %+v", got, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,6 +30,16 @@ type AgentRecord struct {
 	Status      string             `bson:"status" json:"status"`
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+
+	CompletionWebhookURL    string `bson:"completionWebhookUrl,omitempty" json:"completionWebhookUrl,omitempty"`
+	CompletionWebhookSecret string `bson:"completionWebhookSecret,omitempty" json:"-"`
+
+	ExecutionDefaults *models.ExecutionDefaults `bson:"executionDefaults,omitempty" json:"executionDefaults,omitempty"`
+
+	Disabled       bool       `bson:"disabled,omitempty" json:"disabled,omitempty"`
+	DisabledReason string     `bson:"disabledReason,omitempty" json:"disabledReason,omitempty"`
+	DisabledBy     string     `bson:"disabledBy,omitempty" json:"disabledBy,omitempty"`
+	DisabledAt     *time.Time `bson:"disabledAt,omitempty" json:"disabledAt,omitempty"`
 }
 
 // ToModel converts AgentRecord to models.Agent
@@ -41,6 +52,16 @@ func (r *AgentRecord) ToModel() *models.Agent {
 		Status:      r.Status,
 		CreatedAt:   r.CreatedAt,
 		UpdatedAt:   r.UpdatedAt,
+
+		CompletionWebhookURL:    r.CompletionWebhookURL,
+		CompletionWebhookSecret: r.CompletionWebhookSecret,
+
+		ExecutionDefaults: r.ExecutionDefaults,
+
+		Disabled:       r.Disabled,
+		DisabledReason: r.DisabledReason,
+		DisabledBy:     r.DisabledBy,
+		DisabledAt:     r.DisabledAt,
 	}
 }
 
@@ -263,9 +284,44 @@ func (s *AgentService) UpdateAgent(agentID, userID string, req *models.UpdateAge
 		agent.Status = req.Status
 	}
 
+	update := bson.M{"$set": updateFields}
+	if req.CompletionWebhookURL != nil {
+		if url := strings.TrimSpace(*req.CompletionWebhookURL); url != "" {
+			updateFields["completionWebhookUrl"] = url
+			agent.CompletionWebhookURL = url
+			// Keep the existing secret so receivers don't have to be reconfigured
+			if agent.CompletionWebhookSecret == "" {
+				secret, err := GenerateCompletionWebhookSecret()
+				if err != nil {
+					return nil, err
+				}
+				updateFields["completionWebhookSecret"] = secret
+				agent.CompletionWebhookSecret = secret
+			}
+		} else {
+			update["$unset"] = bson.M{"completionWebhookUrl": "", "completionWebhookSecret": ""}
+			agent.CompletionWebhookURL = ""
+			agent.CompletionWebhookSecret = ""
+		}
+	}
+	if req.ExecutionDefaults != nil {
+		if req.ExecutionDefaults.IsEmpty() {
+			unset, _ := update["$unset"].(bson.M)
+			if unset == nil {
+				unset = bson.M{}
+				update["$unset"] = unset
+			}
+			unset["executionDefaults"] = ""
+			agent.ExecutionDefaults = nil
+		} else {
+			updateFields["executionDefaults"] = req.ExecutionDefaults
+			agent.ExecutionDefaults = req.ExecutionDefaults
+		}
+	}
+
 	_, err = s.agentsCollection().UpdateOne(ctx,
 		bson.M{"agentId": agentID, "userId": userID},
-		bson.M{"$set": updateFields})
+		update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update agent: %w", err)
 	}
@@ -274,6 +330,34 @@ func (s *AgentService) UpdateAgent(agentID, userID string, req *models.UpdateAge
 	return agent, nil
 }
 
+// RotateCompletionWebhookSecret replaces the signing secret of an agent's completion
+// webhook and returns the new one. Fails with ErrNoCompletionWebhook if none is set.
+func (s *AgentService) RotateCompletionWebhookSecret(agentID, userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	secret, err := GenerateCompletionWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := s.agentsCollection().UpdateOne(ctx,
+		bson.M{"agentId": agentID, "userId": userID, "completionWebhookUrl": bson.M{"$nin": []any{nil, ""}}},
+		bson.M{"$set": bson.M{"completionWebhookSecret": secret, "updatedAt": time.Now()}})
+	if err != nil {
+		return "", fmt.Errorf("failed to rotate completion webhook secret: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.GetAgent(agentID, userID); err != nil {
+			return "", err
+		}
+		return "", ErrNoCompletionWebhook
+	}
+
+	log.Printf("🔑 [AGENT] Rotated completion webhook secret of agent %s", agentID)
+	return secret, nil
+}
+
 // DeleteAgent deletes an agent and its workflow/versions
 func (s *AgentService) DeleteAgent(agentID, userID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -5,15 +5,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"claraverse/internal/tools"
 
 	cache "github.com/patrickmn/go-cache"
@@ -601,7 +604,7 @@ Be THOROUGH - it's better to include too much detail than to lose critical conte
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+config.APIKey)
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := providerhttp.NewClient(60 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("❌ [SUMMARY] Request failed: %v", err)
@@ -1229,38 +1232,24 @@ func (s *ChatService) modelSupportsVision(modelID string) bool {
 // findVisionCapableModel finds a vision-capable model to use as fallback
 // Returns (providerID, modelName, found)
 func (s *ChatService) findVisionCapableModel() (int, string, bool) {
-	// First, check aliases for vision-capable models (preferred)
-	configService := GetConfigService()
-	allAliases := configService.GetAllModelAliases()
-
-	for providerID, aliases := range allAliases {
-		for aliasKey, aliasInfo := range aliases {
-			if aliasInfo.SupportsVision != nil && *aliasInfo.SupportsVision {
-				log.Printf("🔍 [VISION FALLBACK] Found vision-capable alias: %s (provider %d)", aliasKey, providerID)
-				return providerID, aliasKey, true
-			}
-		}
+	// Aliases are preferred; the catalog lists them before plain models
+	var visionModels []CatalogModel
+	var err error
+	if catalog := GetModelCatalog(); catalog != nil {
+		visionModels, err = catalog.VisionModels()
 	}
-
-	// Query database for any vision-capable model
-	var providerID int
-	var modelName string
-	err := s.db.QueryRow(`
-		SELECT m.provider_id, m.name
-		FROM models m
-		JOIN providers p ON m.provider_id = p.id
-		WHERE m.supports_vision = 1 AND m.is_visible = 1 AND p.enabled = 1
-		ORDER BY m.provider_id ASC
-		LIMIT 1
-	`).Scan(&providerID, &modelName)
-
-	if err != nil {
-		log.Printf("⚠️  [VISION FALLBACK] No vision-capable model found in database")
+	if err != nil || len(visionModels) == 0 {
+		log.Printf("⚠️  [VISION FALLBACK] No vision-capable model found")
 		return 0, "", false
 	}
 
-	log.Printf("🔍 [VISION FALLBACK] Found vision-capable model: %s (provider %d)", modelName, providerID)
-	return providerID, modelName, true
+	model := visionModels[0]
+	modelName := model.Name
+	if model.Alias != "" {
+		modelName = model.Alias
+	}
+	log.Printf("🔍 [VISION FALLBACK] Found vision-capable model: %s (provider %d)", modelName, model.ProviderID)
+	return model.ProviderID, modelName, true
 }
 
 // modelSupportsTools checks if a model supports tools (returns true if unknown - optimistic approach)
@@ -1607,6 +1596,9 @@ func (s *ChatService) StreamChatCompletion(userConn *models.UserConnection) erro
 		log.Printf("🚫 [REQUEST] EXCLUDING TOOLS for model: %s (marked as incompatible)", config.Model)
 	}
 
+	// A call to a missing tool is answered with the tools of this request
+	userConn.OfferedTools = offeredToolNames(tools)
+
 	// Get system prompt - include ask_user instructions only if tools are available
 	// This prevents models like Gemini from failing with MALFORMED_FUNCTION_CALL
 	includeAskUser := len(tools) > 0
@@ -1741,7 +1733,7 @@ func (s *ChatService) StreamChatCompletion(userConn *models.UserConnection) erro
 	req.Header.Set("Authorization", "Bearer "+config.APIKey)
 
 	// Send request
-	client := &http.Client{Timeout: 120 * time.Second}
+	client := providerhttp.NewClient(120 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
@@ -2128,6 +2120,116 @@ func (s *ChatService) processStream(reader io.Reader, userConn *models.UserConne
 	return nil
 }
 
+// dryRunTool validates a tool call's arguments and reports them, with schema defaults
+// applied, to both the client and the LLM instead of executing the tool
+func (s *ChatService) dryRunTool(toolName, toolDisplayName, toolIcon, toolDescription string, args map[string]interface{}, userConn *models.UserConnection) string {
+	tool, exists := s.toolRegistry.GetUserTool(userConn.UserID, toolName)
+	if !exists {
+		errorMsg := fmt.Sprintf("Dry run: unknown tool %s", toolName)
+		userConn.SafeSend(models.ServerMessage{
+			Type:     "tool_result",
+			ToolName: toolName,
+			Status:   "failed",
+			Result:   errorMsg,
+		})
+		return errorMsg
+	}
+
+	validation := tool.ValidateArgs(args)
+	log.Printf("🧪 [TOOL] Dry run of %s (valid: %v, defaults: %v, errors: %v)", toolName, validation.Valid, validation.DefaultsApplied, validation.Errors)
+
+	report, _ := json.Marshal(validation)
+	for _, msg := range []models.ServerMessage{
+		{Type: "tool_call", Arguments: validation.Arguments},
+		{Type: "tool_result", Result: string(report)},
+	} {
+		msg.ToolName = toolName
+		msg.ToolDisplayName = toolDisplayName
+		msg.ToolIcon = toolIcon
+		msg.ToolDescription = toolDescription
+		msg.Status = "dry_run"
+		if !userConn.SafeSend(msg) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("Dry run: %s was not executed. Validation result: %s", toolName, report)
+}
+
+// mcpToolContext returns a context that is cancelled when the user stops generation
+// or disconnects while an MCP tool runs. A stop signal is put back on StopChan so the
+// generation loop still sees it once the tool returns.
+func mcpToolContext(userConn *models.UserConnection) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case stop, ok := <-userConn.StopChan:
+			cancel()
+			if !ok {
+				return
+			}
+			// Use defer/recover in case StopChan was closed in the meantime
+			defer func() { recover() }()
+			select {
+			case userConn.StopChan <- stop:
+			default:
+			}
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// maxToolSuggestions caps the tool names listed when the model calls a missing tool
+const maxToolSuggestions = 50
+
+// isMissingToolError reports whether a tool call failed because the tool does not
+// exist or cannot run right now, rather than because the tool itself failed
+func isMissingToolError(err error) bool {
+	return errors.Is(err, tools.ErrToolNotFound) || errors.Is(err, tools.ErrToolUnavailable)
+}
+
+// offeredToolNames returns the sorted names of the tool definitions sent to the model
+func offeredToolNames(toolDefs []map[string]interface{}) []string {
+	names := make([]string, 0, len(toolDefs))
+	for _, def := range toolDefs {
+		if name := extractToolName(def); name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// missingToolFeedback is the result the model gets for a call to a missing or
+// unavailable tool: what went wrong and which of the tools offered with the request
+// it can use instead, so it recovers instead of calling the same tool again
+func missingToolFeedback(offered []string, toolName string, err error) string {
+	var feedback strings.Builder
+	if errors.Is(err, tools.ErrToolUnavailable) {
+		fmt.Fprintf(&feedback, "Error: the tool %q is currently unavailable (%v). Do not call it again in this conversation turn.", toolName, err)
+	} else {
+		fmt.Fprintf(&feedback, "Error: the tool %q does not exist or is no longer available. Do not call it again.", toolName)
+	}
+
+	var available []string
+	for _, name := range offered {
+		if name != toolName {
+			available = append(available, name)
+		}
+	}
+	switch {
+	case len(available) == 0:
+		feedback.WriteString(" No other tools are available; answer without tools.")
+	case len(available) > maxToolSuggestions:
+		fmt.Fprintf(&feedback, " Tools you can use instead: %s (and %d more).",
+			strings.Join(available[:maxToolSuggestions], ", "), len(available)-maxToolSuggestions)
+	default:
+		fmt.Fprintf(&feedback, " Tools you can use instead: %s.", strings.Join(available, ", "))
+	}
+	return feedback.String()
+}
+
 // executeToolSyncWithResult executes a tool call synchronously and returns the result
 func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON string, userConn *models.UserConnection) string {
 	// Get tool metadata from registry
@@ -2162,6 +2264,12 @@ func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON s
 
 	log.Printf("✅ [TOOL] Successfully parsed arguments for %s: %+v", toolName, args)
 
+	// Dry run: validate against the tool's schema and hand the arguments back, before
+	// any user context or credentials are injected and without dispatching the call
+	if userConn.DryRunTools {
+		return s.dryRunTool(toolName, toolDisplayName, toolIcon, toolDescription, args, userConn)
+	}
+
 	// Inject user context into args (internal use only, not exposed to AI)
 	// This allows tools to access authenticated user info without breaking the tool interface
 	args["__user_id__"] = userConn.UserID
@@ -2193,13 +2301,13 @@ func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON s
 	// Inject image provider config and registry for generate_image tool
 	if toolName == "generate_image" {
 		imageProviderService := GetImageProviderService()
-		provider := imageProviderService.GetProvider()
-		if provider != nil {
+		if provider, ok := imageProviderService.GetProvider(); ok {
 			args[tools.ImageProviderConfigKey] = &tools.ImageProviderConfig{
 				Name:         provider.Name,
 				BaseURL:      provider.BaseURL,
 				APIKey:       provider.APIKey,
 				DefaultModel: provider.DefaultModel,
+				Flavor:       provider.Flavor,
 			}
 			log.Printf("🎨 [CHAT] Injected image provider config for generate_image tool (provider: %s)", provider.Name)
 		}
@@ -2221,8 +2329,7 @@ func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON s
 
 		// Inject image edit provider config from dedicated edit provider
 		imageEditProviderService := GetImageEditProviderService()
-		editProvider := imageEditProviderService.GetProvider()
-		if editProvider != nil {
+		if editProvider, ok := imageEditProviderService.GetProvider(); ok {
 			args[tools.ImageEditConfigKey] = &tools.ImageEditConfig{
 				BaseURL: editProvider.BaseURL,
 				APIKey:  editProvider.APIKey,
@@ -2273,7 +2380,7 @@ func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON s
 		// MCP tool - route to local client
 		log.Printf("🔌 [MCP] Routing tool %s to local MCP client for user %s", toolName, userConn.UserID)
 
-		if s.mcpBridge == nil || !s.mcpBridge.IsUserConnected(userConn.UserID) {
+		if s.mcpBridge == nil || !s.mcpBridge.IsUserReachable(userConn.UserID) {
 			errorMsg := "MCP client not connected. Please start your local MCP client."
 			log.Printf("❌ [MCP] No client connected for user %s", userConn.UserID)
 			userConn.SafeSend(models.ServerMessage{
@@ -2285,16 +2392,48 @@ func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON s
 				Status:          "failed",
 				Result:          errorMsg,
 			})
-			return errorMsg
+			return missingToolFeedback(userConn.OfferedTools, toolName, fmt.Errorf("%w: MCP client not connected", tools.ErrToolUnavailable))
 		}
 
-		// Execute on MCP client with 30 second timeout
+		// Execute on MCP client; the tool's declared timeout or the service default applies
 		startTime := time.Now()
-		result, err = s.mcpBridge.ExecuteToolOnClient(userConn.UserID, toolName, args, 30*time.Second)
+		var toolTime time.Duration
+		// Stopping generation or disconnecting aborts the call on the client
+		toolCtx, cancelTool := mcpToolContext(userConn)
+		// Long-running tools report progress, shown on the tool call until it finishes
+		toolCtx = WithMCPToolProgress(toolCtx, func(message string, percent float64) {
+			update := models.ServerMessage{
+				Type:            "tool_progress",
+				ToolName:        toolName,
+				ToolDisplayName: toolDisplayName,
+				Status:          "executing",
+				Content:         message,
+			}
+			if percent >= 0 {
+				update.Progress = int(percent)
+			}
+			userConn.SafeSend(update)
+		})
+		// Tools that stream their output show it as it arrives; the model gets it whole
+		toolCtx = WithMCPToolOutput(toolCtx, func(content string) {
+			userConn.SafeSend(models.ServerMessage{
+				Type:            "tool_result_partial",
+				ToolName:        toolName,
+				ToolDisplayName: toolDisplayName,
+				Status:          "executing",
+				Content:         content,
+			})
+		})
+		result, toolTime, err = s.mcpBridge.ExecuteToolOnClientTimed(toolCtx, userConn.UserID, toolName, args, 0)
+		cancelTool()
 		executionTime := int(time.Since(startTime).Milliseconds())
 
-		// Log execution for audit
-		s.mcpBridge.LogToolExecution(userConn.UserID, toolName, userConn.ConversationID, executionTime, err == nil, "")
+		// Log execution for audit (also feeds tool reliability stats)
+		auditErr := ""
+		if err != nil {
+			auditErr = err.Error()
+		}
+		s.mcpBridge.LogToolExecution(userConn.UserID, toolName, userConn.ConversationID, executionTime, int(toolTime.Milliseconds()), err == nil, auditErr)
 
 		if err != nil {
 			log.Printf("❌ [MCP] Tool execution failed for %s: %v", toolName, err)
@@ -2308,6 +2447,9 @@ func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON s
 				Status:          "failed",
 				Result:          errorMsg,
 			})
+			if isMissingToolError(err) {
+				return missingToolFeedback(userConn.OfferedTools, toolName, err)
+			}
 			return errorMsg
 		}
 	} else {
@@ -2325,6 +2467,9 @@ func (s *ChatService) executeToolSyncWithResult(toolCallID, toolName, argsJSON s
 				Status:          "failed",
 				Result:          errorMsg,
 			})
+			if isMissingToolError(err) {
+				return missingToolFeedback(userConn.OfferedTools, toolName, err)
+			}
 
 			return errorMsg
 		}
@@ -2957,7 +3102,7 @@ func (s *ChatService) generateConversationTitle(userConn *models.UserConnection,
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+config.APIKey)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("❌ [TITLE] Request failed: %v", err)
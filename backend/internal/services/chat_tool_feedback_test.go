@@ -0,0 +1,29 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"claraverse/internal/tools"
+)
+
+func TestMissingToolFeedbackSuggestsOfferedTools(t *testing.T) {
+	offered := offeredToolNames([]map[string]interface{}{
+		{"type": "function", "function": map[string]interface{}{"name": "web_search"}},
+		{"type": "function", "function": map[string]interface{}{"name": "mcp_files"}},
+	})
+
+	feedback := missingToolFeedback(offered, "mcp_files", fmt.Errorf("%w: MCP client not connected", tools.ErrToolUnavailable))
+	if !strings.Contains(feedback, "currently unavailable") {
+		t.Errorf("expected unavailable wording, got %q", feedback)
+	}
+	if !strings.HasSuffix(feedback, "Tools you can use instead: web_search.") {
+		t.Errorf("expected only the other offered tool to be suggested, got %q", feedback)
+	}
+
+	feedback = missingToolFeedback(nil, "made_up", tools.ErrToolNotFound)
+	if !strings.Contains(feedback, "does not exist") || !strings.Contains(feedback, "answer without tools") {
+		t.Errorf("expected no suggestions without offered tools, got %q", feedback)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bytes"
+	"claraverse/internal/models"
+	"claraverse/internal/security"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Completion webhook request headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the agent's completion webhook secret.
+const (
+	CompletionWebhookSignatureHeader = "X-ClaraVerse-Signature"
+	CompletionWebhookTimestampHeader = "X-ClaraVerse-Timestamp"
+	CompletionWebhookEventHeader     = "X-ClaraVerse-Event"
+	CompletionWebhookEvent           = "execution.completed"
+)
+
+const (
+	// completionWebhookAttempts is the number of delivery attempts before giving up
+	completionWebhookAttempts = 5
+	// completionWebhookBaseDelay is the first retry delay, doubled on each retry
+	completionWebhookBaseDelay = 2 * time.Second
+	completionWebhookTimeout   = 15 * time.Second
+)
+
+// CompletionWebhookService delivers execution results to agents' completion webhooks
+type CompletionWebhookService struct {
+	httpClient  *http.Client
+	attempts    int
+	baseDelay   time.Duration
+	validateURL func(string) error
+	wg          sync.WaitGroup
+}
+
+// NewCompletionWebhookService creates a completion webhook service
+func NewCompletionWebhookService() *CompletionWebhookService {
+	s := &CompletionWebhookService{
+		attempts:    completionWebhookAttempts,
+		baseDelay:   completionWebhookBaseDelay,
+		validateURL: security.ValidateURLForSSRF,
+	}
+	s.httpClient = &http.Client{
+		Timeout: completionWebhookTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return fmt.Errorf("too many redirects")
+			}
+			// A public webhook URL must not redirect the signed payload to an internal one
+			if err := s.validateURL(req.URL.String()); err != nil {
+				return fmt.Errorf("redirect blocked: %w", err)
+			}
+			return nil
+		},
+	}
+	return s
+}
+
+// ErrNoCompletionWebhook is returned when rotating the secret of an agent without a completion webhook
+var ErrNoCompletionWebhook = errors.New("agent has no completion webhook")
+
+// GenerateCompletionWebhookSecret returns a new random signing secret
+func GenerateCompletionWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// SignCompletionWebhook computes the signature header value for a payload
+func SignCompletionWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify posts the execution response to the agent's completion webhook in the background.
+// Agents without a webhook are ignored. Non-2xx responses are retried with backoff.
+func (s *CompletionWebhookService) Notify(agent *models.Agent, response *models.ExecutionAPIResponse) {
+	if agent == nil || agent.CompletionWebhookURL == "" || response == nil {
+		return
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("⚠️ [COMPLETION-WEBHOOK] Failed to encode execution %s: %v", response.Metadata.ExecutionID, err)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.deliver(agent.CompletionWebhookURL, agent.CompletionWebhookSecret, response.Metadata.ExecutionID, body); err != nil {
+			log.Printf("❌ [COMPLETION-WEBHOOK] Giving up on execution %s for agent %s: %v",
+				response.Metadata.ExecutionID, agent.ID, err)
+		}
+	}()
+}
+
+// Wait blocks until in-flight deliveries have finished (used on shutdown and in tests)
+func (s *CompletionWebhookService) Wait() {
+	s.wg.Wait()
+}
+
+// deliver sends the payload, retrying failed attempts with exponential backoff
+func (s *CompletionWebhookService) deliver(url, secret, executionID string, body []byte) error {
+	var lastErr error
+	delay := s.baseDelay
+	for attempt := 1; attempt <= s.attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		// Re-validated on every attempt since DNS may change between retries
+		if err := s.validateURL(url); err != nil {
+			return fmt.Errorf("webhook URL rejected: %w", err)
+		}
+
+		lastErr = s.post(url, secret, executionID, body)
+		if lastErr == nil {
+			log.Printf("✅ [COMPLETION-WEBHOOK] Delivered execution %s (attempt %d)", executionID, attempt)
+			return nil
+		}
+		log.Printf("⚠️ [COMPLETION-WEBHOOK] Attempt %d/%d for execution %s failed: %v", attempt, s.attempts, executionID, lastErr)
+	}
+	return lastErr
+}
+
+func (s *CompletionWebhookService) post(url, secret, executionID string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ClaraVerse-Webhook/1.0")
+	req.Header.Set(CompletionWebhookEventHeader, CompletionWebhookEvent)
+	req.Header.Set(CompletionWebhookTimestampHeader, timestamp)
+	req.Header.Set(CompletionWebhookSignatureHeader, SignCompletionWebhook(secret, timestamp, body))
+	req.Header.Set("X-ClaraVerse-Execution-ID", executionID)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"claraverse/internal/models"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCompletionWebhookService() *CompletionWebhookService {
+	svc := NewCompletionWebhookService()
+	svc.baseDelay = time.Millisecond
+	// httptest servers listen on loopback, which the SSRF check rejects
+	svc.validateURL = func(string) error { return nil }
+	return svc
+}
+
+func TestCompletionWebhookSignedDeliveryWithRetry(t *testing.T) {
+	var calls atomic.Int32
+	var verified atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		expected := SignCompletionWebhook("whsec_test", r.Header.Get(CompletionWebhookTimestampHeader), body)
+		verified.Store(r.Header.Get(CompletionWebhookSignatureHeader) == expected &&
+			r.Header.Get(CompletionWebhookEventHeader) == CompletionWebhookEvent)
+	}))
+	defer srv.Close()
+
+	svc := newTestCompletionWebhookService()
+	agent := &models.Agent{ID: "agent-1", CompletionWebhookURL: srv.URL, CompletionWebhookSecret: "whsec_test"}
+	svc.Notify(agent, models.NewFailedExecutionAPIResponse("exec-1", "agent-1", "boom", 10))
+	svc.Wait()
+
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+	if !verified.Load() {
+		t.Error("expected a valid signature on the delivered payload")
+	}
+}
+
+func TestCompletionWebhookGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	svc := newTestCompletionWebhookService()
+	svc.attempts = 3
+	svc.Notify(&models.Agent{ID: "agent-1", CompletionWebhookURL: srv.URL}, &models.ExecutionAPIResponse{Status: "completed"})
+	svc.Wait()
+
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestCompletionWebhookValidatesRedirects(t *testing.T) {
+	var internalCalls atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalCalls.Add(1)
+	}))
+	defer internal.Close()
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/admin", http.StatusTemporaryRedirect)
+	}))
+	defer redirector.Close()
+
+	svc := newTestCompletionWebhookService()
+	svc.attempts = 1
+	// Only the redirecting server passes the SSRF check
+	svc.validateURL = func(url string) error {
+		if strings.HasPrefix(url, internal.URL) {
+			return errors.New("internal address")
+		}
+		return nil
+	}
+	svc.Notify(&models.Agent{ID: "agent-1", CompletionWebhookURL: redirector.URL}, &models.ExecutionAPIResponse{Status: "completed"})
+	svc.Wait()
+
+	if internalCalls.Load() != 0 {
+		t.Errorf("expected the redirect to an internal address to be blocked, got %d calls", internalCalls.Load())
+	}
+}
+
+func TestCompletionWebhookSkipsAgentsWithoutURL(t *testing.T) {
+	svc := newTestCompletionWebhookService()
+	svc.validateURL = func(string) error {
+		t.Error("no delivery expected for an agent without a webhook")
+		return nil
+	}
+	svc.Notify(&models.Agent{ID: "agent-1"}, &models.ExecutionAPIResponse{Status: "completed"})
+	svc.Wait()
+}
+
+func TestCompletionWebhookSecretIsNotSerialized(t *testing.T) {
+	record := &AgentRecord{AgentID: "agent-1", CompletionWebhookURL: "https://example.com/hook", CompletionWebhookSecret: "whsec_test"}
+	for _, v := range []any{record, record.ToModel()} {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+		if strings.Contains(string(encoded), "whsec_test") {
+			t.Errorf("webhook secret serialized: %s", encoded)
+		}
+	}
+}
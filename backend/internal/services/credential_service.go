@@ -298,7 +298,12 @@ func (s *CredentialService) Update(ctx context.Context, credentialID primitive.O
 		updateFields["metadata.testStatus"] = "pending" // Reset test status
 	}
 
-	_, err = s.collection().UpdateByID(ctx, credentialID, bson.M{"$set": updateFields})
+	update := bson.M{"$set": updateFields}
+	if _, reset := updateFields["metadata.testStatus"]; reset {
+		update["$unset"] = bson.M{"metadata.testError": ""}
+	}
+
+	_, err = s.collection().UpdateByID(ctx, credentialID, update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update credential: %w", err)
 	}
@@ -365,10 +370,17 @@ func (s *CredentialService) UpdateTestStatus(ctx context.Context, credentialID p
 		"updatedAt":           time.Now(),
 	}
 
+	update := bson.M{"$set": updateFields}
+	if err != nil {
+		updateFields["metadata.testError"] = err.Error()
+	} else {
+		update["$unset"] = bson.M{"metadata.testError": ""}
+	}
+
 	_, updateErr := s.collection().UpdateOne(ctx, bson.M{
 		"_id":    credentialID,
 		"userId": userID,
-	}, bson.M{"$set": updateFields})
+	}, update)
 	if updateErr != nil {
 		return fmt.Errorf("failed to update test status: %w", updateErr)
 	}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"claraverse/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// redactedBlockValue replaces values under secret-named keys (matches execution.RedactedValue)
+const redactedBlockValue = "***"
+
+// ExecutionBlockDetail is one block's recorded inputs, output and outcome in an execution
+type ExecutionBlockDetail struct {
+	ExecutionID  string                      `json:"executionId"`
+	BlockID      string                      `json:"blockId"`
+	Status       string                      `json:"status"`
+	Inputs       map[string]any              `json:"inputs,omitempty"`
+	Output       map[string]any              `json:"output,omitempty"`
+	Error        string                      `json:"error,omitempty"`
+	StartedAt    *time.Time                  `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time                  `json:"completedAt,omitempty"`
+	DurationMs   *int64                      `json:"durationMs,omitempty"` // Unset until the block finished
+	RetryCount   int                         `json:"retryCount,omitempty"`
+	RetryHistory []models.RetryAttempt       `json:"retryHistory,omitempty"`
+	Checker      *models.BlockCheckerOutcome `json:"checker,omitempty"`
+}
+
+// GetBlockDetail returns one block's state from the user's execution. Credential values
+// were already redacted when the execution ran; values under secret-named keys (api_key,
+// token, ...) are redacted here as well.
+func (s *ExecutionService) GetBlockDetail(ctx context.Context, executionID primitive.ObjectID, userID, blockID string) (*ExecutionBlockDetail, error) {
+	execution, err := s.GetByIDAndUser(ctx, executionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, ok := execution.BlockStates[blockID]
+	if !ok || state == nil {
+		return nil, fmt.Errorf("block not found")
+	}
+	return newExecutionBlockDetail(executionID.Hex(), blockID, state), nil
+}
+
+func newExecutionBlockDetail(executionID, blockID string, state *models.BlockState) *ExecutionBlockDetail {
+	detail := &ExecutionBlockDetail{
+		ExecutionID:  executionID,
+		BlockID:      blockID,
+		Status:       state.Status,
+		Inputs:       redactSecretKeys(state.Inputs),
+		Output:       redactSecretKeys(state.Outputs),
+		Error:        state.Error,
+		StartedAt:    state.StartedAt,
+		CompletedAt:  state.CompletedAt,
+		RetryCount:   state.RetryCount,
+		RetryHistory: state.RetryHistory,
+		Checker:      state.Checker,
+	}
+	if state.StartedAt != nil && state.CompletedAt != nil {
+		durationMs := state.CompletedAt.Sub(*state.StartedAt).Milliseconds()
+		detail.DurationMs = &durationMs
+	}
+	return detail
+}
+
+// redactSecretKeys returns a copy of m with the values of secret-named keys replaced,
+// at any nesting depth
+func redactSecretKeys(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	redacted, _ := redactSecretKeysValue(m).(map[string]any)
+	return redacted
+}
+
+func redactSecretKeysValue(value any) any {
+	switch v := value.(type) {
+	case primitive.M:
+		return redactSecretKeysValue(map[string]any(v))
+	case primitive.D:
+		m := make(map[string]any, len(v))
+		for _, elem := range v {
+			m[elem.Key] = elem.Value
+		}
+		return redactSecretKeysValue(m)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, nested := range v {
+			if isSecretKeyName(key) {
+				out[key] = redactedBlockValue
+				continue
+			}
+			out[key] = redactSecretKeysValue(nested)
+		}
+		return out
+	case primitive.A:
+		return redactSecretKeysValue([]any(v))
+	case []any:
+		out := make([]any, len(v))
+		for i, nested := range v {
+			out[i] = redactSecretKeysValue(nested)
+		}
+		return out
+	}
+	return value
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewExecutionBlockDetail(t *testing.T) {
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	completed := started.Add(1500 * time.Millisecond)
+	state := &models.BlockState{
+		Status:      "failed",
+		Inputs:      map[string]any{"query": "weather"},
+		Outputs:     map[string]any{"response": "partial"},
+		Error:       "upstream returned 500",
+		StartedAt:   &started,
+		CompletedAt: &completed,
+		RetryCount:  1,
+	}
+
+	detail := newExecutionBlockDetail("exec-1", "block-1", state)
+
+	if detail.ExecutionID != "exec-1" || detail.BlockID != "block-1" {
+		t.Errorf("Unexpected IDs: %s/%s", detail.ExecutionID, detail.BlockID)
+	}
+	if detail.Status != "failed" || detail.Error != "upstream returned 500" || detail.RetryCount != 1 {
+		t.Errorf("Unexpected outcome: %+v", detail)
+	}
+	if detail.Inputs["query"] != "weather" || detail.Output["response"] != "partial" {
+		t.Errorf("Unexpected inputs/output: %v / %v", detail.Inputs, detail.Output)
+	}
+	if detail.DurationMs == nil || *detail.DurationMs != 1500 {
+		t.Errorf("Expected duration 1500ms, got %v", detail.DurationMs)
+	}
+}
+
+func TestNewExecutionBlockDetailRunningBlockHasNoDuration(t *testing.T) {
+	started := time.Now()
+	detail := newExecutionBlockDetail("exec-1", "block-1", &models.BlockState{Status: "running", StartedAt: &started})
+
+	if detail.DurationMs != nil {
+		t.Errorf("Expected no duration for an unfinished block, got %d", *detail.DurationMs)
+	}
+}
+
+func TestRedactSecretKeys(t *testing.T) {
+	inputs := map[string]any{
+		"url":     "https://api.example.com",
+		"api_key": "sk-live-123",
+		"headers": primitive.D{
+			{Key: "Authorization", Value: "Bearer abc"},
+			{Key: "Accept", Value: "application/json"},
+		},
+		"calls": primitive.A{
+			map[string]any{"name": "fetch", "accessToken": "tok"},
+		},
+	}
+
+	redacted := redactSecretKeys(inputs)
+
+	if redacted["url"] != "https://api.example.com" {
+		t.Errorf("Expected url to be kept, got %v", redacted["url"])
+	}
+	if redacted["api_key"] != redactedBlockValue {
+		t.Errorf("Expected api_key to be redacted, got %v", redacted["api_key"])
+	}
+	headers := redacted["headers"].(map[string]any)
+	if headers["Authorization"] != redactedBlockValue || headers["Accept"] != "application/json" {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+	call := redacted["calls"].([]any)[0].(map[string]any)
+	if call["accessToken"] != redactedBlockValue || call["name"] != "fetch" {
+		t.Errorf("Unexpected nested call: %v", call)
+	}
+	if inputs["api_key"] != "sk-live-123" {
+		t.Error("Expected the stored inputs to be left untouched")
+	}
+}
@@ -24,12 +24,13 @@ type ExecutionRecord struct {
 	WorkflowVersion int                     `bson:"workflowVersion" json:"workflowVersion"`
 
 	// Trigger info
-	TriggerType string             `bson:"triggerType" json:"triggerType"` // manual, scheduled, webhook, api
+	TriggerType string             `bson:"triggerType" json:"triggerType"` // manual, scheduled, webhook, api, replay
 	ScheduleID  primitive.ObjectID `bson:"scheduleId,omitempty" json:"scheduleId,omitempty"`
 	APIKeyID    primitive.ObjectID `bson:"apiKeyId,omitempty" json:"apiKeyId,omitempty"`
+	ReplayOf    primitive.ObjectID `bson:"replayOf,omitempty" json:"replayOf,omitempty"` // Execution a replay re-runs
 
 	// Execution state
-	Status      string                          `bson:"status" json:"status"` // pending, running, completed, failed, partial
+	Status      string                          `bson:"status" json:"status"` // pending, running, completed, completed_with_errors, failed, partial, interrupted, cancelled
 	Input       map[string]interface{}          `bson:"input,omitempty" json:"input,omitempty"`
 	Output      map[string]interface{}          `bson:"output,omitempty" json:"output,omitempty"`
 	BlockStates map[string]*models.BlockState   `bson:"blockStates,omitempty" json:"blockStates,omitempty"`
@@ -44,6 +45,9 @@ type ExecutionRecord struct {
 	StartedAt   time.Time  `bson:"startedAt" json:"startedAt"`
 	CompletedAt *time.Time `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
 	DurationMs  int64      `bson:"durationMs,omitempty" json:"durationMs,omitempty"`
+	// HeartbeatAt is refreshed while the server running the execution is alive (see
+	// Heartbeat); executions whose heartbeat stopped are orphaned
+	HeartbeatAt *time.Time `bson:"heartbeatAt,omitempty" json:"heartbeatAt,omitempty"`
 
 	// TTL (tier-based retention)
 	ExpiresAt time.Time `bson:"expiresAt" json:"expiresAt"`
@@ -53,8 +57,9 @@ type ExecutionRecord struct {
 
 // ExecutionService manages execution history in MongoDB
 type ExecutionService struct {
-	mongoDB     *database.MongoDB
-	tierService *TierService
+	mongoDB          *database.MongoDB
+	tierService      *TierService
+	activeExecutions *ActiveExecutionRegistry
 }
 
 // NewExecutionService creates a new execution service
@@ -65,6 +70,11 @@ func NewExecutionService(mongoDB *database.MongoDB, tierService *TierService) *E
 	}
 }
 
+// SetActiveExecutions sets the registry of running executions (required for CancelUserExecutions)
+func (s *ExecutionService) SetActiveExecutions(registry *ActiveExecutionRegistry) {
+	s.activeExecutions = registry
+}
+
 // collection returns the executions collection
 func (s *ExecutionService) collection() *mongo.Collection {
 	return s.mongoDB.Database().Collection("executions")
@@ -86,9 +96,11 @@ func (s *ExecutionService) Create(ctx context.Context, req *CreateExecutionReque
 		TriggerType:     req.TriggerType,
 		ScheduleID:      req.ScheduleID,
 		APIKeyID:        req.APIKeyID,
+		ReplayOf:        req.ReplayOf,
 		Status:          "pending",
 		Input:           req.Input,
 		StartedAt:       now,
+		HeartbeatAt:     &now,
 		ExpiresAt:       now.Add(time.Duration(retentionDays) * 24 * time.Hour),
 		CreatedAt:       now,
 	}
@@ -110,9 +122,10 @@ type CreateExecutionRequest struct {
 	AgentID         string
 	UserID          string
 	WorkflowVersion int
-	TriggerType     string // manual, scheduled, webhook, api
+	TriggerType     string // manual, scheduled, webhook, api, replay
 	ScheduleID      primitive.ObjectID
 	APIKeyID        primitive.ObjectID
+	ReplayOf        primitive.ObjectID
 	Input           map[string]interface{}
 }
 
@@ -179,6 +192,35 @@ func (s *ExecutionService) Complete(ctx context.Context, executionID primitive.O
 	return nil
 }
 
+// CancelUserExecutions signals cancellation to every execution userID is running on
+// this server and marks their records cancelled. Returns the IDs of the cancelled
+// executions; an error means some records could not be updated.
+func (s *ExecutionService) CancelUserExecutions(ctx context.Context, userID string) ([]string, error) {
+	ids := s.activeExecutions.CancelUser(userID)
+
+	var firstErr error
+	for _, id := range ids {
+		executionID, err := primitive.ObjectIDFromHex(id)
+		if err == nil {
+			err = s.Complete(ctx, executionID, &ExecutionCompleteRequest{
+				Status: "cancelled",
+				Error:  ExecutionCancelledError,
+			})
+		}
+		if err != nil {
+			log.Printf("⚠️ [EXECUTION] Failed to mark execution %s cancelled: %v", id, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to mark execution %s cancelled: %w", id, err)
+			}
+		}
+	}
+
+	if len(ids) > 0 {
+		log.Printf("🛑 [EXECUTION] Cancelled %d running execution(s) for user %s", len(ids), userID)
+	}
+	return ids, firstErr
+}
+
 // ExecutionCompleteRequest contains the completion data
 type ExecutionCompleteRequest struct {
 	Status      string
@@ -370,8 +412,8 @@ func (s *ExecutionService) GetStats(ctx context.Context, agentID, userID string)
 			Count:       r.Count,
 			AvgDuration: int64(r.AvgDuration),
 		}
-		if r.ID == "completed" {
-			stats.SuccessCount = r.Count
+		if r.ID == "completed" || r.ID == "completed_with_errors" {
+			stats.SuccessCount += r.Count
 		} else if r.ID == "failed" {
 			stats.FailedCount = r.Count
 		}
@@ -415,6 +457,72 @@ func (s *ExecutionService) DeleteExpired(ctx context.Context) (int64, error) {
 	return result.DeletedCount, nil
 }
 
+// Heartbeat records that the executions in ids are still running on this server, so
+// no replica's MarkOrphanedInterrupted takes them for orphans
+func (s *ExecutionService) Heartbeat(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+
+	_, err := s.collection().UpdateMany(ctx, bson.M{
+		"_id":    bson.M{"$in": objIDs},
+		"status": bson.M{"$in": []string{"pending", "running"}},
+	}, bson.M{"$set": bson.M{"heartbeatAt": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to record execution heartbeat: %w", err)
+	}
+	return nil
+}
+
+// MarkOrphanedInterrupted marks pending/running executions whose heartbeat stopped
+// more than olderThan ago as interrupted (executions from before heartbeats, by their
+// start time). activeIDs are executions known to still be running on this server
+// and are skipped. Returns the number of executions updated.
+func (s *ExecutionService) MarkOrphanedInterrupted(ctx context.Context, olderThan time.Duration, activeIDs []string) (int64, error) {
+	cutoff := time.Now().Add(-olderThan)
+	filter := bson.M{
+		"status": bson.M{"$in": []string{"pending", "running"}},
+		"$or": []bson.M{
+			{"heartbeatAt": bson.M{"$lt": cutoff}},
+			{"heartbeatAt": bson.M{"$exists": false}, "startedAt": bson.M{"$lt": cutoff}},
+		},
+	}
+
+	if len(activeIDs) > 0 {
+		excluded := make([]primitive.ObjectID, 0, len(activeIDs))
+		for _, id := range activeIDs {
+			if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+				excluded = append(excluded, objID)
+			}
+		}
+		filter["_id"] = bson.M{"$nin": excluded}
+	}
+
+	now := time.Now()
+	result, err := s.collection().UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{
+			"status":      "interrupted",
+			"error":       fmt.Sprintf("Execution interrupted: its server sent no heartbeat for over %s (restarted or crashed before it finished)", olderThan),
+			"completedAt": now,
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile orphaned executions: %w", err)
+	}
+
+	if result.ModifiedCount > 0 {
+		log.Printf("🧹 [EXECUTION] Marked %d orphaned execution(s) as interrupted", result.ModifiedCount)
+	}
+
+	return result.ModifiedCount, nil
+}
+
 // DeleteAllByUser deletes all executions for a user (GDPR compliance)
 func (s *ExecutionService) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
 	if userID == "" {
@@ -61,18 +61,18 @@ func (s *ImageEditProviderService) LoadFromProviders(providers []models.Provider
 	log.Printf("🖌️ [IMAGE-EDIT-PROVIDER] Total image edit providers loaded: %d", len(s.providers))
 }
 
-// GetProvider returns the first enabled image edit provider
-// Returns nil if no image edit providers are configured
-func (s *ImageEditProviderService) GetProvider() *ImageEditProviderConfig {
+// GetProvider returns a copy of the first enabled image edit provider
+// Returns false if no image edit providers are configured
+func (s *ImageEditProviderService) GetProvider() (ImageEditProviderConfig, bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	if len(s.providers) == 0 {
-		return nil
+		return ImageEditProviderConfig{}, false
 	}
 
 	// Return the first provider
-	return &s.providers[0]
+	return s.providers[0], true
 }
 
 // GetAllProviders returns all configured image edit providers
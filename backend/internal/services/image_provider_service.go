@@ -13,6 +13,7 @@ type ImageProviderConfig struct {
 	APIKey       string
 	DefaultModel string
 	Favicon      string
+	Flavor       string // API dialect; "" = detect from BaseURL
 }
 
 // ImageProviderService manages image generation providers
@@ -54,6 +55,7 @@ func (s *ImageProviderService) LoadFromProviders(providers []models.ProviderConf
 				APIKey:       p.APIKey,
 				DefaultModel: p.DefaultModel,
 				Favicon:      p.Favicon,
+				Flavor:       p.Flavor,
 			}
 			s.providers = append(s.providers, config)
 			log.Printf("🎨 [IMAGE-PROVIDER] Loaded image provider: %s (model: %s)", p.Name, p.DefaultModel)
@@ -63,18 +65,19 @@ func (s *ImageProviderService) LoadFromProviders(providers []models.ProviderConf
 	log.Printf("🎨 [IMAGE-PROVIDER] Total image providers loaded: %d", len(s.providers))
 }
 
-// GetProvider returns the first enabled image provider
-// Returns nil if no image providers are configured
-func (s *ImageProviderService) GetProvider() *ImageProviderConfig {
+// GetProvider returns a copy of the first enabled image provider
+// Returns false if no image providers are configured. The copy is returned by value
+// so callers never hold a pointer into a slice that a reload may replace.
+func (s *ImageProviderService) GetProvider() (ImageProviderConfig, bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	if len(s.providers) == 0 {
-		return nil
+		return ImageProviderConfig{}, false
 	}
 
 	// Return the first provider (could be enhanced to support multiple providers)
-	return &s.providers[0]
+	return s.providers[0], true
 }
 
 // GetAllProviders returns all configured image providers
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"claraverse/internal/models"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestImageProviderReloadWhileReading is meant to be run with -race: GetProvider
+// must not hand out memory that a concurrent LoadFromProviders replaces.
+func TestImageProviderReloadWhileReading(t *testing.T) {
+	s := &ImageProviderService{}
+	load := func(i int) {
+		s.LoadFromProviders([]models.ProviderConfig{
+			{Name: fmt.Sprintf("images-%d", i), Enabled: true, ImageOnly: true, DefaultModel: "flux"},
+			{Name: "chat", Enabled: true},
+		})
+	}
+	load(0)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			provider, ok := s.GetProvider()
+			if !ok {
+				t.Error("Expected a provider while reloading")
+				return
+			}
+			// Mutating the copy must not affect the service
+			provider.APIKey = "changed"
+			_ = provider.Name + provider.DefaultModel
+		}
+	}()
+
+	for i := 1; i <= 200; i++ {
+		load(i)
+	}
+	close(stop)
+	wg.Wait()
+
+	provider, ok := s.GetProvider()
+	if !ok || provider.Name != "images-200" || provider.APIKey != "" {
+		t.Errorf("Unexpected provider after reloads: %+v (ok=%v)", provider, ok)
+	}
+
+	s.LoadFromProviders(nil)
+	if _, ok := s.GetProvider(); ok {
+		t.Error("Expected no provider after loading an empty config")
+	}
+}
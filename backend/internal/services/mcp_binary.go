@@ -0,0 +1,186 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"claraverse/internal/models"
+	"claraverse/internal/securefile"
+)
+
+// MCP binary convention shared with the mcp-client bridge.
+//
+// Binary values in tool arguments and results are wrapped as:
+//
+//	{"__b64__": "<standard base64>", "mime_type": "image/png"}
+//
+// ExecuteToolOnClient wraps any []byte argument automatically. A binary tool result
+// is stored as a file for the user when it is delivered, before the size cap could
+// cut its envelope, and replaced with a reference to it (see storeMCPBinaryResult).
+//
+// Size limits: a single decoded value may not exceed MCPMaxBinarySize. Wrapped values
+// are never chunked - each travels whole inside one WebSocket message, so the base64
+// size (~4/3 of the raw bytes) counts against any message size limit.
+const (
+	MCPBinaryKey     = "__b64__"
+	MCPBinaryMimeKey = "mime_type"
+	MCPMaxBinarySize = 8 * 1024 * 1024 // 8 MiB decoded
+)
+
+// EncodeMCPBinary wraps raw bytes using the MCP binary convention
+func EncodeMCPBinary(data []byte, mimeType string) (map[string]interface{}, error) {
+	if len(data) > MCPMaxBinarySize {
+		return nil, fmt.Errorf("binary value is %d bytes, exceeds limit of %d", len(data), MCPMaxBinarySize)
+	}
+	wrapped := map[string]interface{}{
+		MCPBinaryKey: base64.StdEncoding.EncodeToString(data),
+	}
+	if mimeType != "" {
+		wrapped[MCPBinaryMimeKey] = mimeType
+	}
+	return wrapped, nil
+}
+
+// DecodeMCPBinary unwraps a binary value. ok is false if v is not a wrapper.
+func DecodeMCPBinary(v interface{}) (data []byte, mimeType string, ok bool, err error) {
+	m, isMap := v.(map[string]interface{})
+	if !isMap {
+		return nil, "", false, nil
+	}
+	encoded, isStr := m[MCPBinaryKey].(string)
+	if !isStr {
+		return nil, "", false, nil
+	}
+	if base64.StdEncoding.DecodedLen(len(encoded)) > MCPMaxBinarySize+2 {
+		return nil, "", true, fmt.Errorf("binary value exceeds limit of %d bytes", MCPMaxBinarySize)
+	}
+	data, err = base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("invalid %s value: %w", MCPBinaryKey, err)
+	}
+	if len(data) > MCPMaxBinarySize {
+		return nil, "", true, fmt.Errorf("binary value is %d bytes, exceeds limit of %d", len(data), MCPMaxBinarySize)
+	}
+	mimeType, _ = m[MCPBinaryMimeKey].(string)
+	return data, mimeType, true, nil
+}
+
+// DecodeMCPBinaryResult unwraps a tool result string that holds a single binary value
+func DecodeMCPBinaryResult(result string) (data []byte, mimeType string, ok bool, err error) {
+	trimmed := strings.TrimSpace(result)
+	if !strings.HasPrefix(trimmed, "{") || !strings.Contains(trimmed, MCPBinaryKey) {
+		return nil, "", false, nil
+	}
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(trimmed), &parsed); err != nil {
+		return nil, "", false, nil
+	}
+	return DecodeMCPBinary(parsed)
+}
+
+// storeMCPBinaryResult stores a binary tool result in the secure file service for
+// userID and replaces it with a file reference in the tool result file contract
+// (file_id / download_url plus a "files" array), so callers get a download link
+// instead of base64 that means nothing to the model. A binary result that can't be
+// decoded or stored, including one the client already truncated, becomes an error
+// placeholder. It reports whether result was a binary result.
+func storeMCPBinaryResult(files *securefile.Service, userID string, result *models.MCPToolResult) bool {
+	if !result.Success {
+		return false
+	}
+	data, mimeType, ok, err := DecodeMCPBinaryResult(result.Result)
+	if !ok {
+		head := strings.TrimSpace(result.Result)
+		if len(head) > 256 {
+			head = head[:256]
+		}
+		if !result.Truncated || !strings.HasPrefix(head, "{") || !strings.Contains(head, `"`+MCPBinaryKey+`"`) {
+			return false
+		}
+		err = fmt.Errorf("truncated by the client")
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	result.Truncated = false
+	result.OriginalSize = 0
+
+	var stored *securefile.Result
+	if err == nil {
+		stored, err = files.CreateFile(userID, data, mcpBinaryFilename(mimeType), mimeType)
+	}
+	if err != nil {
+		result.Result = fmt.Sprintf("[Binary result (%s) could not be decoded: %v]", mimeType, err)
+		return true
+	}
+
+	ref := map[string]interface{}{
+		"file_id":      stored.ID,
+		"filename":     stored.Filename,
+		"download_url": stored.DownloadURL,
+		"access_code":  stored.AccessCode,
+		"mime_type":    stored.MimeType,
+		"size":         stored.Size,
+	}
+	encoded, _ := json.Marshal(map[string]interface{}{
+		"success":      true,
+		"files":        []interface{}{ref},
+		"file_id":      stored.ID,
+		"filename":     stored.Filename,
+		"download_url": stored.DownloadURL,
+		"mime_type":    stored.MimeType,
+		"size":         stored.Size,
+		"message":      fmt.Sprintf("Binary result (%d bytes of %s) saved as '%s'. Download link: %s", len(data), mimeType, stored.Filename, stored.DownloadURL),
+	})
+	result.Result = string(encoded)
+	return true
+}
+
+// mcpBinaryFilename names a stored binary result after its MIME type
+func mcpBinaryFilename(mimeType string) string {
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return "mcp_result" + exts[0]
+	}
+	return "mcp_result"
+}
+
+// wrapMCPBinaryArgs returns a copy of args with []byte values wrapped for transport.
+// Without this, json.Marshal would turn []byte into an untagged base64 string.
+func wrapMCPBinaryArgs(args map[string]interface{}) (map[string]interface{}, error) {
+	wrapped := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		value, err := wrapMCPBinaryValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", k, err)
+		}
+		wrapped[k] = value
+	}
+	return wrapped, nil
+}
+
+func wrapMCPBinaryValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case []byte:
+		return EncodeMCPBinary(val, "")
+	case map[string]interface{}:
+		if _, isBinary := val[MCPBinaryKey]; isBinary {
+			return val, nil
+		}
+		return wrapMCPBinaryArgs(val)
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, nested := range val {
+			value, err := wrapMCPBinaryValue(nested)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
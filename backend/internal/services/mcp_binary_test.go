@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"claraverse/internal/models"
+	"claraverse/internal/securefile"
+)
+
+func TestMCPBinaryRoundTrip(t *testing.T) {
+	raw := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+
+	args, err := wrapMCPBinaryArgs(map[string]interface{}{
+		"image":  raw,
+		"nested": map[string]interface{}{"files": []interface{}{raw}},
+		"name":   "chart.png",
+	})
+	if err != nil {
+		t.Fatalf("wrapMCPBinaryArgs failed: %v", err)
+	}
+
+	data, _, ok, err := DecodeMCPBinary(args["image"])
+	if !ok || err != nil || !bytes.Equal(data, raw) {
+		t.Errorf("Expected top-level []byte to round-trip, got ok=%v err=%v data=%v", ok, err, data)
+	}
+
+	files := args["nested"].(map[string]interface{})["files"].([]interface{})
+	if _, _, ok, _ := DecodeMCPBinary(files[0]); !ok {
+		t.Errorf("Expected nested []byte to be wrapped, got %T", files[0])
+	}
+	if args["name"] != "chart.png" {
+		t.Errorf("Expected non-binary args unchanged, got %v", args["name"])
+	}
+
+	wrapped, _ := EncodeMCPBinary(raw, "image/png")
+	encoded, _ := json.Marshal(wrapped)
+	data, mimeType, ok, err := DecodeMCPBinaryResult(string(encoded))
+	if !ok || err != nil || !bytes.Equal(data, raw) || mimeType != "image/png" {
+		t.Errorf("Expected binary result to round-trip, got ok=%v err=%v mime=%q", ok, err, mimeType)
+	}
+
+	if _, _, ok, _ := DecodeMCPBinaryResult(`{"text": "plain"}`); ok {
+		t.Error("Expected plain JSON result not to be treated as binary")
+	}
+}
+
+func TestMCPBinarySizeLimit(t *testing.T) {
+	if _, err := EncodeMCPBinary(make([]byte, MCPMaxBinarySize+1), ""); err == nil {
+		t.Error("Expected oversized binary value to be rejected")
+	}
+}
+
+func TestStoreMCPBinaryResult(t *testing.T) {
+	files := securefile.NewService(t.TempDir())
+	raw := bytes.Repeat([]byte{0xff}, 1000)
+	wrapped, _ := EncodeMCPBinary(raw, "image/png")
+	encoded, _ := json.Marshal(wrapped)
+
+	result := models.MCPToolResult{Success: true, Result: string(encoded)}
+	if !storeMCPBinaryResult(files, "user-1", &result) {
+		t.Fatal("Expected the result to be treated as binary")
+	}
+	var ref struct {
+		FileID   string                   `json:"file_id"`
+		Filename string                   `json:"filename"`
+		MimeType string                   `json:"mime_type"`
+		Size     int64                    `json:"size"`
+		Files    []map[string]interface{} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(result.Result), &ref); err != nil {
+		t.Fatalf("Expected a JSON file reference, got %q", result.Result)
+	}
+	if ref.MimeType != "image/png" || ref.Size != 1000 || !strings.HasSuffix(ref.Filename, ".png") || len(ref.Files) != 1 {
+		t.Errorf("Unexpected file reference: %+v", ref)
+	}
+	stored := files.ListUserFiles("user-1")
+	if len(stored) != 1 || stored[0].ID != ref.FileID {
+		t.Fatalf("Expected the blob to be stored for the user, got %v", stored)
+	}
+
+	// An envelope the client cut can't be decoded, but must not reach the model either
+	cut := models.MCPToolResult{Success: true, Result: string(encoded[:100]), Truncated: true, OriginalSize: len(encoded)}
+	storeMCPBinaryResult(files, "user-1", &cut)
+	if !strings.HasPrefix(cut.Result, "[Binary result (application/octet-stream) could not be decoded") || cut.Truncated {
+		t.Errorf("Expected a placeholder for the cut envelope, got %q (truncated %v)", cut.Result, cut.Truncated)
+	}
+
+	text := models.MCPToolResult{Success: true, Result: `{"text": "plain"}`}
+	if storeMCPBinaryResult(files, "user-1", &text) || text.Result != `{"text": "plain"}` {
+		t.Errorf("Expected a text result unchanged, got %q", text.Result)
+	}
+}
+
+// TestDeliverBinaryResultBeforeCap tests that a binary result larger than the size
+// cap is stored whole instead of being cut
+func TestDeliverBinaryResultBeforeCap(t *testing.T) {
+	files := securefile.NewService(t.TempDir())
+	service := NewMCPBridgeService(nil, nil)
+	service.SetMaxResultBytes(64)
+	service.SetFileService(files)
+	conn := newRetryTestConnection()
+	service.connections[conn.ClientID] = conn
+	resultChan := make(chan models.MCPToolResult, 1)
+	conn.PendingResults["call-1"] = resultChan
+
+	raw := bytes.Repeat([]byte("%PDF"), 1024)
+	wrapped, _ := EncodeMCPBinary(raw, "application/pdf")
+	encoded, _ := json.Marshal(wrapped)
+	if !service.DeliverToolResult(conn.ClientID, models.MCPToolResult{CallID: "call-1", Success: true, Result: string(encoded)}) {
+		t.Fatal("Expected the result to be delivered")
+	}
+	got := <-resultChan
+	var body struct {
+		Files []struct {
+			FileID     string `json:"file_id"`
+			AccessCode string `json:"access_code"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(got.Result), &body); err != nil || len(body.Files) != 1 || got.Truncated {
+		t.Fatalf("Expected an untruncated file reference, got %q (truncated %v)", got.Result, got.Truncated)
+	}
+	stored, data, err := files.GetFile(body.Files[0].FileID, body.Files[0].AccessCode)
+	if err != nil || stored.UserID != conn.UserID || !bytes.Equal(data, raw) {
+		t.Errorf("Expected the whole blob stored for %s, got %v (%v)", conn.UserID, stored, err)
+	}
+}
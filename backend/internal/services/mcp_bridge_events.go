@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// MCP connection lifecycle event types
+const (
+	MCPEventConnect        = "connect"
+	MCPEventDisconnect     = "disconnect"
+	MCPEventToolRegistered = "tools_registered"
+	// MCPEventReconnectExpired follows the disconnect of a reaped client that did not
+	// reconnect within the grace window, once its tools are unregistered
+	MCPEventReconnectExpired = "reconnect_expired"
+)
+
+// Reasons attached to disconnect events
+const (
+	MCPDisconnectClientClosed        = "client_closed"        // client sent a disconnect message
+	MCPDisconnectConnectionLost      = "connection_lost"      // WebSocket read failed
+	MCPDisconnectReplaced            = "replaced"             // same user registered a newer client
+	MCPDisconnectHeartbeatTimeout    = "heartbeat_timeout"    // reaped by the heartbeat watchdog
+	MCPDisconnectRevoked             = "revoked"              // revoked by the user or an admin via the API
+	MCPDisconnectUnsupportedProtocol = "unsupported_protocol" // client is older than MCPMinProtocolVersion
+	MCPDisconnectReconnectExpired    = "reconnect_expired"    // reaped client did not come back within the grace window
+)
+
+// MCPHeartbeatTimeout is how long a client may go without a heartbeat before the
+// watchdog disconnects it (clients send one every 30 seconds, ±10%)
+const MCPHeartbeatTimeout = 90 * time.Second
+
+// MCPConnectionEvent describes a change in an MCP client connection
+type MCPConnectionEvent struct {
+	Type          string    `json:"type"`
+	UserID        string    `json:"user_id"`
+	ClientID      string    `json:"client_id"`
+	ClientVersion string    `json:"client_version,omitempty"`
+	Platform      string    `json:"platform,omitempty"`
+	ToolCount     int       `json:"tool_count"`
+	Reason        string    `json:"reason,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// MCPEventHooks receive connection lifecycle events. Any hook may be nil.
+// Hooks run synchronously after the bridge releases its lock, so they may call
+// back into the service but should hand slow work off to another goroutine.
+type MCPEventHooks struct {
+	OnConnect        func(MCPConnectionEvent)
+	OnDisconnect     func(MCPConnectionEvent)
+	OnToolRegistered func(MCPConnectionEvent)
+	// OnReconnectExpired is told when the bridge gives up on a reaped client
+	OnReconnectExpired func(MCPConnectionEvent)
+}
+
+// SetEventHooks sets the lifecycle event hooks
+func (s *MCPBridgeService) SetEventHooks(hooks MCPEventHooks) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.hooks = hooks
+}
+
+// emitEvents delivers queued events to the configured hooks (call without the lock held)
+func (s *MCPBridgeService) emitEvents(events []MCPConnectionEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.mutex.RLock()
+	hooks := s.hooks
+	s.mutex.RUnlock()
+
+	for _, event := range events {
+		var hook func(MCPConnectionEvent)
+		switch event.Type {
+		case MCPEventConnect:
+			hook = hooks.OnConnect
+		case MCPEventDisconnect:
+			hook = hooks.OnDisconnect
+		case MCPEventToolRegistered:
+			hook = hooks.OnToolRegistered
+		case MCPEventReconnectExpired:
+			hook = hooks.OnReconnectExpired
+		}
+		if hook != nil {
+			hook(event)
+		}
+	}
+}
+
+// ReapStaleConnections disconnects clients that have not sent a heartbeat within
+// maxSilence and returns how many it disconnected. With a reconnect grace window
+// set, their tools and pending calls are held for them to reconnect, and held
+// clients whose window has ended are given up on here.
+func (s *MCPBridgeService) ReapStaleConnections(maxSilence time.Duration) int {
+	var events []MCPConnectionEvent
+	defer func() { s.emitEvents(events) }() // runs after unlock
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	events = append(events, s.expireHeldLocked(now)...)
+	expired := len(events)
+
+	cutoff := now.Add(-maxSilence)
+	for clientID, conn := range s.connections {
+		if conn.LastHeartbeat.Before(cutoff) {
+			log.Printf("💀 MCP client missed heartbeats for %s, disconnecting: user=%s, client=%s",
+				time.Since(conn.LastHeartbeat).Round(time.Second), conn.UserID, clientID)
+			if s.reconnectGrace > 0 {
+				events = append(events, s.holdClientLocked(clientID, conn, MCPDisconnectHeartbeatTimeout))
+			} else {
+				events = append(events, s.disconnectClientLocked(clientID, conn, MCPDisconnectHeartbeatTimeout))
+			}
+		}
+	}
+	return len(events) - expired
+}
+
+// StartHeartbeatWatchdog periodically reaps clients whose heartbeats stopped
+// until ctx is cancelled
+func (s *MCPBridgeService) StartHeartbeatWatchdog(ctx context.Context, interval, maxSilence time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.ReapStaleConnections(maxSilence)
+			}
+		}
+	}()
+}
+
+func newMCPConnectionEvent(eventType string, clientID string, userID, clientVersion, platform string, toolCount int, reason string) MCPConnectionEvent {
+	return MCPConnectionEvent{
+		Type:          eventType,
+		UserID:        userID,
+		ClientID:      clientID,
+		ClientVersion: clientVersion,
+		Platform:      platform,
+		ToolCount:     toolCount,
+		Reason:        reason,
+		Timestamp:     time.Now(),
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+func newRetryTestConnection(tools ...models.MCPTool) *models.MCPConnection {
+	return &models.MCPConnection{
+		UserID:         "user-1",
+		ClientID:       "client-1",
+		Tools:          tools,
+		WriteChan:      make(chan models.MCPServerMessage, 4),
+		PendingResults: make(map[string]chan models.MCPToolResult),
+	}
+}
+
+func TestMCPToolRetriesOnTimeout(t *testing.T) {
+	conn := newRetryTestConnection(
+		models.MCPTool{Name: "read_file", ReadOnly: true, RetryOnTimeout: true},
+		models.MCPTool{Name: "list_dir", ReadOnly: true},
+		models.MCPTool{Name: "write_file", RetryOnTimeout: true},
+	)
+
+	tests := map[string]bool{
+		"read_file":  true,
+		"list_dir":   false, // not opted in
+		"write_file": false, // not read-only
+		"unknown":    false,
+	}
+	for name, want := range tests {
+		if got := mcpToolRetriesOnTimeout(conn, name); got != want {
+			t.Errorf("mcpToolRetriesOnTimeout(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestExecuteToolOnClientRetriesReadOnlyTool(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	conn := newRetryTestConnection(models.MCPTool{Name: "read_file", ReadOnly: true, RetryOnTimeout: true})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	start := time.Now()
+	_, err := service.ExecuteToolOnClient(context.Background(), conn.UserID, "read_file", map[string]interface{}{}, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "retried once") {
+		t.Fatalf("Expected retried timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("Retry exceeded the overall budget: %v", elapsed)
+	}
+
+	if len(conn.WriteChan) != 2 {
+		t.Fatalf("Expected 2 dispatched calls, got %d", len(conn.WriteChan))
+	}
+	first := (<-conn.WriteChan).Payload["call_id"]
+	second := (<-conn.WriteChan).Payload["call_id"]
+	if first == second {
+		t.Errorf("Expected retry to use a new call_id, both were %v", first)
+	}
+	if len(conn.PendingResults) != 0 {
+		t.Errorf("Expected pending results to be cleaned up, got %d", len(conn.PendingResults))
+	}
+}
+
+func TestMCPRetryJitterFitsBudget(t *testing.T) {
+	for _, remaining := range []time.Duration{10 * time.Second, 400 * time.Millisecond, 100 * time.Millisecond, 0} {
+		for i := 0; i < 50; i++ {
+			jitter := mcpRetryJitter(remaining)
+			if jitter < 0 || jitter > MCPRetryMaxJitter {
+				t.Fatalf("mcpRetryJitter(%v) = %v, outside [0, %v]", remaining, jitter, MCPRetryMaxJitter)
+			}
+			if remaining < 2*time.Second && jitter > time.Duration(float64(remaining)*MCPRetryJitterShare) {
+				t.Fatalf("mcpRetryJitter(%v) = %v, more than its share of the budget", remaining, jitter)
+			}
+		}
+	}
+}
+
+func TestSubSecondTimeoutIsSentRoundedUp(t *testing.T) {
+	conn := newRetryTestConnection(models.MCPTool{Name: "read_file"})
+	callID, _, err := sendMCPToolCall(conn, "read_file", map[string]interface{}{}, 300*time.Millisecond, time.Second, nil, nil)
+	if err != nil {
+		t.Fatalf("sendMCPToolCall failed: %v", err)
+	}
+	defer removePendingResult(conn, callID)
+	if timeout := (<-conn.WriteChan).Payload["timeout"]; timeout != 1 {
+		t.Errorf("Expected a 300ms budget to be sent as 1s, got %v", timeout)
+	}
+}
+
+func TestExecuteToolOnClientDoesNotRetryWriteTool(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	conn := newRetryTestConnection(models.MCPTool{Name: "write_file", RetryOnTimeout: true})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	if _, err := service.ExecuteToolOnClient(context.Background(), conn.UserID, "write_file", map[string]interface{}{}, 200*time.Millisecond); err == nil {
+		t.Fatal("Expected timeout error")
+	}
+	if len(conn.WriteChan) != 1 {
+		t.Errorf("Expected exactly 1 dispatched call, got %d", len(conn.WriteChan))
+	}
+}
+
+func TestExecuteToolOnClientSerializesLimitedTool(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	conn := newRetryTestConnection(models.MCPTool{Name: "browser", MaxConcurrency: 1})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	// The first call holds the only slot until it times out
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.ExecuteToolOnClient(context.Background(), conn.UserID, "browser", map[string]interface{}{}, 300*time.Millisecond)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	_, err := service.ExecuteToolOnClient(context.Background(), conn.UserID, "browser", map[string]interface{}{}, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "concurrency slot") {
+		t.Fatalf("Expected the second call to time out waiting for a slot, got %v", err)
+	}
+	if len(conn.WriteChan) != 1 {
+		t.Errorf("Expected only the first call to be dispatched, got %d", len(conn.WriteChan))
+	}
+	<-done
+}
+
+func TestMCPToolLimiterRejectsFullQueue(t *testing.T) {
+	limiter := newMCPToolLimiter()
+	conn := newRetryTestConnection()
+
+	release, err := limiter.acquire(conn, "browser", 1, time.Second)
+	if err != nil {
+		t.Fatalf("Expected a free slot, got %v", err)
+	}
+	limiter.sems[conn]["browser"].waiting = MCPToolQueueLimit
+
+	if _, err := limiter.acquire(conn, "browser", 1, time.Second); err == nil || !strings.Contains(err.Error(), "busy") {
+		t.Errorf("Expected a busy error with a full queue, got %v", err)
+	}
+
+	limiter.sems[conn]["browser"].waiting = 0
+	release()
+	if release, err = limiter.acquire(conn, "browser", 1, time.Second); err != nil {
+		t.Fatalf("Expected the released slot to be reusable, got %v", err)
+	}
+	release()
+}
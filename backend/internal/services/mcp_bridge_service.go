@@ -1,25 +1,92 @@
 package services
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/securefile"
 	"claraverse/internal/tools"
 	"github.com/google/uuid"
 )
 
+const (
+	// MCPRetryFirstAttemptShare is the fraction of the timeout the first attempt of a
+	// retryable tool call gets before it is re-dispatched
+	MCPRetryFirstAttemptShare = 0.6
+	// MCPRetryMinJitter and MCPRetryMaxJitter bound the pause before the re-dispatch
+	MCPRetryMinJitter = 100 * time.Millisecond
+	MCPRetryMaxJitter = 500 * time.Millisecond
+	// MCPRetryJitterShare caps the pause at this fraction of the budget left after
+	// the first attempt, so short budgets still leave time for the retry
+	MCPRetryJitterShare = 0.25
+
+	// DefaultMCPMaxResultBytes is the backend's ceiling on tool result size. Clients
+	// truncate at their own (usually lower) limit; this guards against ones that don't.
+	DefaultMCPMaxResultBytes = 8 << 20
+	// DefaultMCPMaxArgBytes is the ceiling on a tool call's JSON-encoded arguments;
+	// larger calls are rejected before they reach the client's write queue
+	DefaultMCPMaxArgBytes = 4 << 20
+)
+
+// ErrMCPConnectionNotFound is returned when revoking a connection that does not
+// exist or belongs to another user
+var ErrMCPConnectionNotFound = errors.New("MCP connection not found")
+
+// ErrMCPClientRevoked is returned when a revoked client tries to register again
+var ErrMCPClientRevoked = errors.New("MCP client has been revoked")
+
+// ErrMCPTokenRevoked is returned when a client authenticates with an access token
+// issued before one of the user's MCP clients was revoked
+var ErrMCPTokenRevoked = errors.New("access token was revoked with an MCP client, sign in again")
+
+// ErrMCPToolArgsTooLarge is returned for tool calls whose arguments exceed the limit
+var ErrMCPToolArgsTooLarge = errors.New("tool arguments too large")
+
+// MCPConnectionInfo describes an active MCP client connection for listings
+type MCPConnectionInfo struct {
+	ClientID      string    `json:"client_id"`
+	UserID        string    `json:"user_id"`
+	ClientVersion string    `json:"client_version"`
+	Platform      string    `json:"platform"`
+	ConnectedAt   time.Time `json:"connected_at"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	ToolCount     int       `json:"tool_count"`
+}
+
 // MCPBridgeService manages MCP client connections and tool routing
 type MCPBridgeService struct {
 	db          *database.DB
 	connections map[string]*models.MCPConnection // clientID -> connection
 	userConns   map[string]string                // userID -> clientID
+	held        map[string]*heldMCPConnection    // userID -> reaped connection awaiting reconnect
 	registry    *tools.Registry
+	hooks       MCPEventHooks
 	mutex       sync.RWMutex
+
+	maxResultBytes     int
+	maxArgBytes        int
+	toolLimiter        *mcpToolLimiter
+	defaultToolTimeout time.Duration
+	maxToolTimeout     time.Duration
+	sendTimeout        time.Duration
+	hideToolExamples   bool
+	maxToolsPerClient  int
+	outputValidation   string
+	tierService        *TierService
+	reconnectGrace     time.Duration
+	files              *securefile.Service
 }
 
 // NewMCPBridgeService creates a new MCP bridge service
@@ -28,12 +95,121 @@ func NewMCPBridgeService(db *database.DB, registry *tools.Registry) *MCPBridgeSe
 		db:          db,
 		connections: make(map[string]*models.MCPConnection),
 		userConns:   make(map[string]string),
+		held:        make(map[string]*heldMCPConnection),
 		registry:    registry,
+
+		maxResultBytes:     DefaultMCPMaxResultBytes,
+		maxArgBytes:        DefaultMCPMaxArgBytes,
+		toolLimiter:        newMCPToolLimiter(),
+		defaultToolTimeout: DefaultMCPToolTimeout,
+		maxToolTimeout:     DefaultMCPMaxToolTimeout,
+		sendTimeout:        DefaultMCPToolSendTimeout,
+		maxToolsPerClient:  DefaultMCPMaxToolsPerClient,
+		outputValidation:   MCPOutputValidationWarn,
+		reconnectGrace:     DefaultMCPReconnectGrace,
 	}
 }
 
+// SetMaxResultBytes sets the ceiling on tool result size (0 disables it)
+func (s *MCPBridgeService) SetMaxResultBytes(maxBytes int) {
+	s.maxResultBytes = maxBytes
+}
+
+// SetMaxArgBytes sets the ceiling on tool call argument size (0 disables it)
+func (s *MCPBridgeService) SetMaxArgBytes(maxBytes int) {
+	s.maxArgBytes = maxBytes
+}
+
+// SetFileService sets where binary tool results are stored (defaults to the shared
+// secure file service)
+func (s *MCPBridgeService) SetFileService(files *securefile.Service) {
+	s.files = files
+}
+
+func (s *MCPBridgeService) fileService() *securefile.Service {
+	if s.files != nil {
+		return s.files
+	}
+	return securefile.GetService()
+}
+
+// checkToolArgSize records the JSON-encoded size of a call's arguments and rejects
+// calls over the ceiling
+func (s *MCPBridgeService) checkToolArgSize(toolName string, args map[string]interface{}) error {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("invalid tool arguments: %w", err)
+	}
+	size := len(encoded)
+	tooLarge := s.maxArgBytes > 0 && size > s.maxArgBytes
+	GetMetrics().RecordMCPToolArguments(size, tooLarge)
+	if tooLarge {
+		log.Printf("🚫 MCP tool %s: rejected %d bytes of arguments (limit %d)", toolName, size, s.maxArgBytes)
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrMCPToolArgsTooLarge, size, s.maxArgBytes)
+	}
+	return nil
+}
+
+// CapToolResult truncates a result over the ceiling, marking it truncated. Results
+// the client already truncated keep their original size.
+func (s *MCPBridgeService) CapToolResult(result *models.MCPToolResult) {
+	if s.maxResultBytes <= 0 || len(result.Result) <= s.maxResultBytes {
+		return
+	}
+	if !result.Truncated {
+		result.OriginalSize = len(result.Result)
+	}
+	cut := s.maxResultBytes
+	for cut > 0 && !utf8.RuneStart(result.Result[cut]) {
+		cut--
+	}
+	result.Result = result.Result[:cut]
+	result.Truncated = true
+}
+
 // RegisterClient registers a new MCP client connection
 func (s *MCPBridgeService) RegisterClient(userID string, registration *models.MCPToolRegistration) (*models.MCPConnection, error) {
+	protocolVersion, err := NegotiateMCPProtocol(registration.ProtocolVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revocations outlive the connection, so a revoked client can't simply reconnect
+	if revoked, err := s.isClientRevoked(registration.ClientID); err != nil {
+		return nil, fmt.Errorf("failed to check client revocation: %w", err)
+	} else if revoked {
+		log.Printf("⛔ Revoked MCP client tried to register: user=%s, client=%s", userID, registration.ClientID)
+		return nil, ErrMCPClientRevoked
+	}
+
+	// Past success rates are shown to the model in tool descriptions
+	reliability, err := s.GetUserToolReliability(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to load tool reliability for user %s: %v", userID, err)
+	}
+
+	// A tool name must route to exactly one tool, so later duplicates are skipped
+	toolSet, duplicates := dedupeMCPTools(registration.Tools)
+	if len(duplicates) > 0 {
+		log.Printf("⚠️  MCP client %s registered duplicate tool names, keeping the first of each: %v",
+			registration.ClientID, duplicates)
+	}
+	if err := checkMCPToolLimit(len(toolSet), s.maxToolsFor(userID)); err != nil {
+		return nil, err
+	}
+
+	// Diff against the previous connection's tools so the client can report drift
+	var toolChanges *mcpToolChanges
+	if previous, ok, err := s.previousMCPToolDefinitions(userID); err != nil {
+		log.Printf("Warning: Failed to load previous MCP tools for user %s: %v", userID, err)
+	} else if ok {
+		changes := diffMCPTools(previous, toolSet)
+		toolChanges = &changes
+	}
+
+	var events []MCPConnectionEvent
+	defer func() { s.emitEvents(events) }() // runs after unlock
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -42,24 +218,26 @@ func (s *MCPBridgeService) RegisterClient(userID string, registration *models.MC
 		// Disconnect existing connection
 		if existingConn, ok := s.connections[existingClientID]; ok {
 			log.Printf("Disconnecting existing MCP client for user %s", userID)
-			s.disconnectClientLocked(existingClientID, existingConn)
+			events = append(events, s.disconnectClientLocked(existingClientID, existingConn, MCPDisconnectReplaced))
 		}
 	}
 
 	// Create new connection
 	conn := &models.MCPConnection{
-		ID:             uuid.New().String(),
-		UserID:         userID,
-		ClientID:       registration.ClientID,
-		ClientVersion:  registration.ClientVersion,
-		Platform:       registration.Platform,
-		ConnectedAt:    time.Now(),
-		LastHeartbeat:  time.Now(),
-		IsActive:       true,
-		Tools:          registration.Tools,
-		WriteChan:      make(chan models.MCPServerMessage, 100),
-		StopChan:       make(chan bool, 1),
-		PendingResults: make(map[string]chan models.MCPToolResult),
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		ClientID:        registration.ClientID,
+		ClientVersion:   registration.ClientVersion,
+		Platform:        registration.Platform,
+		ProtocolVersion: protocolVersion,
+		Encoding:        NegotiateMCPEncoding(protocolVersion, registration.Encodings),
+		ConnectedAt:     time.Now(),
+		LastHeartbeat:   time.Now(),
+		IsActive:        true,
+		Tools:           toolSet,
+		WriteChan:       make(chan models.MCPServerMessage, 100),
+		StopChan:        make(chan bool, 1),
+		PendingResults:  make(map[string]chan models.MCPToolResult),
 	}
 
 	// Store in memory
@@ -67,7 +245,7 @@ func (s *MCPBridgeService) RegisterClient(userID string, registration *models.MC
 	s.userConns[userID] = registration.ClientID
 
 	// Store in database
-	_, err := s.db.Exec(`
+	_, err = s.db.Exec(`
 		INSERT INTO mcp_connections (user_id, client_id, client_version, platform, connected_at, last_heartbeat, is_active)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`, userID, registration.ClientID, registration.ClientVersion, registration.Platform, conn.ConnectedAt, conn.LastHeartbeat, true)
@@ -78,60 +256,292 @@ func (s *MCPBridgeService) RegisterClient(userID string, registration *models.MC
 		return nil, fmt.Errorf("failed to store connection in database: %w", err)
 	}
 
-	// Get connection ID from database
-	var dbConnID int64
-	err = s.db.QueryRow("SELECT id FROM mcp_connections WHERE client_id = ?", registration.ClientID).Scan(&dbConnID)
-	if err != nil {
-		log.Printf("Warning: Failed to get connection ID from database: %v", err)
-	}
+	dbConnID := s.connectionDBID(registration.ClientID)
 
-	// Register tools in registry and database
-	for _, tool := range registration.Tools {
-		// Register in registry
-		err := s.registry.RegisterUserTool(userID, &tools.Tool{
-			Name:        tool.Name,
-			Description: tool.Description,
-			Parameters:  tool.Parameters,
-			Source:      tools.ToolSourceMCPLocal,
-			UserID:      userID,
-			Execute:     nil, // MCP tools don't have direct execute functions
-		})
+	// A client back within the grace window answers the calls it left pending
+	resumed := s.resumeHeldLocked(userID, conn)
 
-		if err != nil {
+	events = append(events, newMCPConnectionEvent(MCPEventConnect, registration.ClientID, userID,
+		registration.ClientVersion, registration.Platform, len(toolSet), ""))
+
+	// Register tools in registry and database
+	registered := 0
+	for _, tool := range toolSet {
+		if err := s.registerToolLocked(userID, dbConnID, tool, reliability[tool.Name]); err != nil {
 			log.Printf("Warning: Failed to register tool %s: %v", tool.Name, err)
 			continue
 		}
-
-		// Store tool in database
-		toolDefJSON, _ := json.Marshal(tool)
-		_, err = s.db.Exec(`
-			INSERT OR REPLACE INTO mcp_tools (user_id, connection_id, tool_name, tool_definition)
-			VALUES (?, ?, ?, ?)
-		`, userID, dbConnID, tool.Name, string(toolDefJSON))
-
-		if err != nil {
-			log.Printf("Warning: Failed to store tool %s in database: %v", tool.Name, err)
-		}
+		registered++
 	}
 
-	log.Printf("✅ MCP client registered: user=%s, client=%s, tools=%d", userID, registration.ClientID, len(registration.Tools))
+	log.Printf("✅ MCP client registered: user=%s, client=%s, tools=%d, protocol=%d, encoding=%s",
+		userID, registration.ClientID, len(toolSet), protocolVersion, conn.Encoding)
+	events = append(events, newMCPConnectionEvent(MCPEventToolRegistered, registration.ClientID, userID,
+		registration.ClientVersion, registration.Platform, registered, ""))
 
 	// Send acknowledgment
+	payload := map[string]interface{}{
+		"status":           "connected",
+		"tools_registered": len(toolSet),
+		"protocol_version": protocolVersion,
+		"encoding":         conn.Encoding,
+	}
+	if len(duplicates) > 0 {
+		payload["duplicate_tools"] = duplicates
+	}
+	if resumed {
+		payload["resumed"] = true
+	}
+	if toolChanges != nil {
+		payload["tool_changes"] = toolChanges.payload()
+		log.Printf("🔁 MCP client %s tool changes since last connection: %d added, %d removed, %d updated, %d unchanged",
+			registration.ClientID, len(toolChanges.Added), len(toolChanges.Removed), len(toolChanges.Updated), len(toolChanges.Unchanged))
+	}
 	go func() {
-		conn.WriteChan <- models.MCPServerMessage{
-			Type: "ack",
-			Payload: map[string]interface{}{
-				"status":          "connected",
-				"tools_registered": len(registration.Tools),
-			},
+		select {
+		case conn.WriteChan <- models.MCPServerMessage{
+			Type:    "ack",
+			Payload: payload,
+		}:
+		case <-conn.StopChan:
 		}
 	}()
 
 	return conn, nil
 }
 
+// AddTools registers additional tools on a connected client, leaving its other
+// tools (and calls in flight to them) untouched. Tools already registered are replaced.
+func (s *MCPBridgeService) AddTools(clientID string, added []models.MCPTool) (int, error) {
+	return s.changeTools(clientID, added, nil, false)
+}
+
+// UpdateTool replaces the definition of a tool the client has already registered
+func (s *MCPBridgeService) UpdateTool(clientID string, tool models.MCPTool) error {
+	_, err := s.changeTools(clientID, []models.MCPTool{tool}, nil, true)
+	return err
+}
+
+// RemoveTools unregisters the named tools from a connected client; unknown names are ignored
+func (s *MCPBridgeService) RemoveTools(clientID string, names []string) (int, error) {
+	return s.changeTools(clientID, nil, names, false)
+}
+
+// changeTools applies an incremental change to a client's tool set and returns how
+// many tools were added, updated or removed. register_tools remains the full sync.
+func (s *MCPBridgeService) changeTools(clientID string, upsert []models.MCPTool, remove []string, mustExist bool) (int, error) {
+	s.mutex.RLock()
+	conn, exists := s.connections[clientID]
+	s.mutex.RUnlock()
+	if !exists {
+		return 0, fmt.Errorf("client %s not found", clientID)
+	}
+
+	upsert, duplicates := dedupeMCPTools(upsert)
+	if len(duplicates) > 0 {
+		log.Printf("⚠️  MCP client %s sent duplicate tool names, keeping the first of each: %v", clientID, duplicates)
+	}
+
+	maxTools := -1
+	if len(upsert) > 0 {
+		maxTools = s.maxToolsFor(conn.UserID)
+	}
+
+	var reliability map[string]*ToolReliability
+	if len(upsert) > 0 {
+		var err error
+		if reliability, err = s.GetUserToolReliability(conn.UserID); err != nil {
+			log.Printf("Warning: Failed to load tool reliability for user %s: %v", conn.UserID, err)
+		}
+	}
+
+	var events []MCPConnectionEvent
+	defer func() { s.emitEvents(events) }() // runs after unlock
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The client may have disconnected or been replaced while reliability loaded
+	if s.connections[clientID] != conn {
+		return 0, fmt.Errorf("client %s not found", clientID)
+	}
+
+	if mustExist {
+		for _, tool := range upsert {
+			if findMCPTool(conn.Tools, tool.Name) < 0 {
+				return 0, fmt.Errorf("tool %s is not registered", tool.Name)
+			}
+		}
+	}
+
+	// Only growth is refused, so a client already over a lowered limit can still shrink
+	if total := len(applyMCPToolChanges(conn.Tools, upsert, remove)); total > len(conn.Tools) {
+		if err := checkMCPToolLimit(total, maxTools); err != nil {
+			return 0, err
+		}
+	}
+
+	dbConnID := s.connectionDBID(clientID)
+	var applied []models.MCPTool
+	for _, tool := range upsert {
+		if err := s.registerToolLocked(conn.UserID, dbConnID, tool, reliability[tool.Name]); err != nil {
+			log.Printf("Warning: Failed to register tool %s: %v", tool.Name, err)
+			continue
+		}
+		applied = append(applied, tool)
+	}
+
+	var removed []string
+	for _, name := range remove {
+		if findMCPTool(conn.Tools, name) < 0 {
+			continue
+		}
+		s.registry.UnregisterUserTool(conn.UserID, name)
+		if _, err := s.db.Exec("DELETE FROM mcp_tools WHERE user_id = ? AND tool_name = ?", conn.UserID, name); err != nil {
+			log.Printf("Warning: Failed to remove tool %s from database: %v", name, err)
+		}
+		removed = append(removed, name)
+	}
+
+	conn.Tools = applyMCPToolChanges(conn.Tools, applied, removed)
+	changed := len(applied) + len(removed)
+
+	log.Printf("🔧 MCP tools changed: user=%s, client=%s, upserted=%d, removed=%d, total=%d",
+		conn.UserID, clientID, len(applied), len(removed), len(conn.Tools))
+	events = append(events, newMCPConnectionEvent(MCPEventToolRegistered, clientID, conn.UserID,
+		conn.ClientVersion, conn.Platform, len(conn.Tools), ""))
+
+	payload := map[string]interface{}{
+		"status":        "tools_updated",
+		"tools_changed": changed,
+		"tools_total":   len(conn.Tools),
+	}
+	if len(duplicates) > 0 {
+		payload["duplicate_tools"] = duplicates
+	}
+
+	select {
+	case conn.WriteChan <- models.MCPServerMessage{
+		Type:    "ack",
+		Payload: payload,
+	}:
+	default:
+		log.Printf("⚠️  Write channel full, dropping tools ack for client %s", clientID)
+	}
+
+	return changed, nil
+}
+
+// registerToolLocked adds or replaces one MCP tool in the registry and database
+// (must be called with lock held)
+func (s *MCPBridgeService) registerToolLocked(userID string, dbConnID int64, tool models.MCPTool, reliability *ToolReliability) error {
+	category := tool.Category
+	if category == "" {
+		category = tools.CategoryUncategorized
+	}
+
+	description := annotateToolDescription(tool.Description, reliability)
+	if !s.hideToolExamples {
+		description = appendToolExamples(description, tool.Examples)
+	}
+
+	err := s.registry.RegisterUserTool(userID, &tools.Tool{
+		Name:        tool.Name,
+		Description: description,
+		Parameters:  tool.Parameters,
+		Source:      tools.ToolSourceMCPLocal,
+		UserID:      userID,
+		Category:    category,
+		Tags:        tool.Tags,
+		Execute:     nil, // MCP tools don't have direct execute functions
+	})
+	if err != nil {
+		return err
+	}
+
+	// Store tool in database
+	toolDefJSON, _ := json.Marshal(tool)
+	_, err = s.db.Exec(`
+		INSERT OR REPLACE INTO mcp_tools (user_id, connection_id, tool_name, tool_definition)
+		VALUES (?, ?, ?, ?)
+	`, userID, dbConnID, tool.Name, string(toolDefJSON))
+	if err != nil {
+		log.Printf("Warning: Failed to store tool %s in database: %v", tool.Name, err)
+	}
+	return nil
+}
+
+// connectionDBID looks up the database ID of a client's connection row
+func (s *MCPBridgeService) connectionDBID(clientID string) int64 {
+	var dbConnID int64
+	err := s.db.QueryRow("SELECT id FROM mcp_connections WHERE client_id = ?", clientID).Scan(&dbConnID)
+	if err != nil {
+		log.Printf("Warning: Failed to get connection ID from database: %v", err)
+	}
+	return dbConnID
+}
+
+// applyMCPToolChanges returns a new tool set with upserted tools replaced or appended
+// and removed names dropped. The original slice is not modified, so readers holding
+// it keep a consistent view.
+func applyMCPToolChanges(current []models.MCPTool, upsert []models.MCPTool, remove []string) []models.MCPTool {
+	result := append([]models.MCPTool(nil), current...)
+	for _, tool := range upsert {
+		if i := findMCPTool(result, tool.Name); i >= 0 {
+			result[i] = tool
+		} else {
+			result = append(result, tool)
+		}
+	}
+	for _, name := range remove {
+		if i := findMCPTool(result, name); i >= 0 {
+			result = append(result[:i], result[i+1:]...)
+		}
+	}
+	return result
+}
+
+// dedupeMCPTools keeps the first tool of each name and returns the names that
+// appeared more than once, in order of first duplicate
+func dedupeMCPTools(toolSet []models.MCPTool) ([]models.MCPTool, []string) {
+	seen := make(map[string]bool, len(toolSet))
+	reported := make(map[string]bool)
+	var duplicates []string
+	unique := make([]models.MCPTool, 0, len(toolSet))
+	for _, tool := range toolSet {
+		if seen[tool.Name] {
+			if !reported[tool.Name] {
+				reported[tool.Name] = true
+				duplicates = append(duplicates, tool.Name)
+			}
+			continue
+		}
+		seen[tool.Name] = true
+		unique = append(unique, tool)
+	}
+	return unique, duplicates
+}
+
+// findMCPTool returns the index of the named tool, or -1
+func findMCPTool(toolSet []models.MCPTool, name string) int {
+	for i, tool := range toolSet {
+		if tool.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // DisconnectClient handles client disconnection
 func (s *MCPBridgeService) DisconnectClient(clientID string) error {
+	return s.DisconnectClientWithReason(clientID, MCPDisconnectClientClosed)
+}
+
+// DisconnectClientWithReason disconnects a client, recording why in the disconnect event
+func (s *MCPBridgeService) DisconnectClientWithReason(clientID string, reason string) error {
+	var events []MCPConnectionEvent
+	defer func() { s.emitEvents(events) }() // runs after unlock
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -140,12 +550,13 @@ func (s *MCPBridgeService) DisconnectClient(clientID string) error {
 		return fmt.Errorf("client %s not found", clientID)
 	}
 
-	s.disconnectClientLocked(clientID, conn)
+	events = append(events, s.disconnectClientLocked(clientID, conn, reason))
 	return nil
 }
 
 // disconnectClientLocked handles disconnection (must be called with lock held)
-func (s *MCPBridgeService) disconnectClientLocked(clientID string, conn *models.MCPConnection) {
+// and returns the disconnect event to emit once the lock is released
+func (s *MCPBridgeService) disconnectClientLocked(clientID string, conn *models.MCPConnection, reason string) MCPConnectionEvent {
 	// Mark as inactive in database
 	_, err := s.db.Exec("UPDATE mcp_connections SET is_active = 0 WHERE client_id = ?", clientID)
 	if err != nil {
@@ -158,12 +569,108 @@ func (s *MCPBridgeService) disconnectClientLocked(clientID string, conn *models.
 	// Clean up memory
 	delete(s.connections, clientID)
 	delete(s.userConns, conn.UserID)
+	s.toolLimiter.forget(conn)
 
-	// Close channels
+	// Stop the write loop. The write channel is never closed: a tool call or ack racing
+	// the disconnect (e.g. a watchdog reap) must not panic sending on it.
 	close(conn.StopChan)
-	close(conn.WriteChan)
 
-	log.Printf("🔌 MCP client disconnected: user=%s, client=%s", conn.UserID, clientID)
+	log.Printf("🔌 MCP client disconnected: user=%s, client=%s, reason=%s", conn.UserID, clientID, reason)
+
+	return newMCPConnectionEvent(MCPEventDisconnect, clientID, conn.UserID,
+		conn.ClientVersion, conn.Platform, len(conn.Tools), reason)
+}
+
+// ListConnections returns the active connections of userID, or of all users when
+// userID is empty, oldest first
+func (s *MCPBridgeService) ListConnections(userID string) []MCPConnectionInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	infos := make([]MCPConnectionInfo, 0)
+	for clientID, conn := range s.connections {
+		if userID != "" && conn.UserID != userID {
+			continue
+		}
+		infos = append(infos, MCPConnectionInfo{
+			ClientID:      clientID,
+			UserID:        conn.UserID,
+			ClientVersion: conn.ClientVersion,
+			Platform:      conn.Platform,
+			ConnectedAt:   conn.ConnectedAt,
+			LastHeartbeat: conn.LastHeartbeat,
+			ToolCount:     len(conn.Tools),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ConnectedAt.Before(infos[j].ConnectedAt)
+	})
+	return infos
+}
+
+// RevokeConnection force-disconnects a client, sending it a disconnect message with
+// reason "revoked" first, and records the revocation so the client ID can never
+// register again. The client ID is chosen by the client, so the revocation also
+// revokes the user's access tokens issued until now for MCP connections (see
+// CheckTokenRevocation): whoever holds the client's token has to sign in again. A
+// non-empty userID restricts revocation to that user's own clients; other users'
+// clients are reported as not found.
+func (s *MCPBridgeService) RevokeConnection(clientID string, userID string) error {
+	var events []MCPConnectionEvent
+	defer func() { s.emitEvents(events) }() // runs after unlock
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	conn, exists := s.connections[clientID]
+	if !exists || (userID != "" && conn.UserID != userID) {
+		return ErrMCPConnectionNotFound
+	}
+
+	// Without the record the client could just reconnect, so fail the revocation
+	if _, err := s.db.Exec("UPDATE mcp_connections SET revoked_at = ? WHERE client_id = ?", time.Now(), clientID); err != nil {
+		return fmt.Errorf("failed to record revocation: %w", err)
+	}
+
+	// Queued before the stop channel closes; the write loop delivers it and closes the socket
+	select {
+	case conn.WriteChan <- models.MCPServerMessage{
+		Type: "disconnect",
+		Payload: map[string]interface{}{
+			"reason": MCPDisconnectRevoked,
+		},
+	}:
+	default:
+		log.Printf("⚠️  Write channel full, revoking client %s without notice", clientID)
+	}
+
+	events = append(events, s.disconnectClientLocked(clientID, conn, MCPDisconnectRevoked))
+	return nil
+}
+
+// isClientRevoked reports whether clientID was revoked with RevokeConnection
+func (s *MCPBridgeService) isClientRevoked(clientID string) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM mcp_connections WHERE client_id = ? AND revoked_at IS NOT NULL", clientID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CheckTokenRevocation returns ErrMCPTokenRevoked if an access token of userID issued
+// at issuedAt (zero if unknown) predates the user's latest client revocation. It is
+// checked before issuing connection tokens and before accepting a connection.
+func (s *MCPBridgeService) CheckTokenRevocation(userID string, issuedAt time.Time) error {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM mcp_connections WHERE user_id = ? AND revoked_at > ?", userID, issuedAt).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if count > 0 {
+		return ErrMCPTokenRevoked
+	}
+	return nil
 }
 
 // UpdateHeartbeat updates the last heartbeat time for a client
@@ -183,69 +690,360 @@ func (s *MCPBridgeService) UpdateHeartbeat(clientID string) error {
 	return err
 }
 
-// ExecuteToolOnClient sends a tool execution request to the MCP client
-func (s *MCPBridgeService) ExecuteToolOnClient(userID string, toolName string, args map[string]interface{}, timeout time.Duration) (string, error) {
+// ExecuteToolOnClient sends a tool execution request to the MCP client. A timeout of 0
+// uses the tool's declared timeout or the service default (see mcp_tool_timeout.go).
+// Cancelling ctx stops waiting and asks the client to abort the call (see mcp_tool_cancel.go).
+func (s *MCPBridgeService) ExecuteToolOnClient(ctx context.Context, userID string, toolName string, args map[string]interface{}, timeout time.Duration) (string, error) {
+	result, _, err := s.ExecuteToolOnClientTimed(ctx, userID, toolName, args, timeout)
+	return result, err
+}
+
+// ExecuteToolOnClientTimed is ExecuteToolOnClient that also returns how long the tool
+// ran on the client, as reported by it (0 if unknown)
+func (s *MCPBridgeService) ExecuteToolOnClientTimed(ctx context.Context, userID string, toolName string, args map[string]interface{}, timeout time.Duration) (string, time.Duration, error) {
+	result, err := s.executeToolOnClient(ctx, userID, toolName, args, timeout)
+	if err != nil {
+		return "", 0, err
+	}
+	result = s.checkToolOutput(ctx, userID, toolName, args, timeout, result)
+	value, err := mcpToolResultValue(result)
+	return value, time.Duration(result.DurationMs) * time.Millisecond, err
+}
+
+func (s *MCPBridgeService) executeToolOnClient(ctx context.Context, userID string, toolName string, args map[string]interface{}, callerTimeout time.Duration) (models.MCPToolResult, error) {
 	s.mutex.RLock()
 	clientID, exists := s.userConns[userID]
 	if !exists {
 		s.mutex.RUnlock()
-		return "", fmt.Errorf("no MCP client connected for user %s", userID)
+		// A client within its reconnect grace window gets the call once it is back
+		if held, err := s.waitForHeldClient(ctx, userID, callerTimeout); held {
+			if err != nil {
+				return models.MCPToolResult{}, err
+			}
+			return s.executeToolOnClient(ctx, userID, toolName, args, callerTimeout)
+		}
+		return models.MCPToolResult{}, fmt.Errorf("%w: no MCP client connected for user %s", tools.ErrToolUnavailable, userID)
 	}
 
 	conn, connExists := s.connections[clientID]
+	// Tool sets change under the lock, so decide on retries and limits while holding it
+	var retryOnTimeout, streamsResults bool
+	var maxConcurrency, toolTimeout int
+	var registered bool
+	if connExists {
+		registered = findMCPTool(conn.Tools, toolName) >= 0
+		retryOnTimeout = mcpToolRetriesOnTimeout(conn, toolName)
+		streamsResults = mcpToolStreamsResults(conn, toolName)
+		maxConcurrency = mcpToolMaxConcurrency(conn, toolName)
+		toolTimeout = mcpToolDeclaredTimeout(conn, toolName)
+	}
 	s.mutex.RUnlock()
 
 	if !connExists {
-		return "", fmt.Errorf("MCP client connection not found")
+		return models.MCPToolResult{}, fmt.Errorf("%w: MCP client connection not found", tools.ErrToolUnavailable)
+	}
+	if !registered {
+		return models.MCPToolResult{}, fmt.Errorf("%w: %s is not registered by the MCP client", tools.ErrToolNotFound, toolName)
+	}
+
+	// Oversized arguments would clog the client's write queue; refuse them up front
+	if err := s.checkToolArgSize(toolName, args); err != nil {
+		return models.MCPToolResult{}, err
+	}
+
+	budget := resolveMCPToolTimeout(callerTimeout, toolTimeout, s.defaultToolTimeout, s.maxToolTimeout)
+	log.Printf("⏱️  MCP tool %s: timeout %s", toolName, budget)
+	timeout := budget.timeout
+
+	// Stateful tools declare a concurrency limit; queued calls wait within the timeout
+	if maxConcurrency > 0 {
+		start := time.Now()
+		release, err := s.toolLimiter.acquire(conn, toolName, maxConcurrency, timeout)
+		if err != nil {
+			log.Printf("⏱️  MCP tool %s: %v", toolName, err)
+			return models.MCPToolResult{}, err
+		}
+		defer release()
+		timeout -= time.Since(start)
+	}
+
+	// Wrap raw binary arguments using the {"__b64__": ...} convention. Older clients
+	// don't unwrap, so they get the plain base64 string json.Marshal produces.
+	if conn.ProtocolVersion >= MCPProtocolBinaryArgs {
+		var err error
+		if args, err = wrapMCPBinaryArgs(args); err != nil {
+			return models.MCPToolResult{}, fmt.Errorf("invalid tool arguments: %w", err)
+		}
+	}
+
+	// Read-only tools that opted in get one re-dispatch within the same budget
+	if retryOnTimeout {
+		return s.executeWithRetry(ctx, conn, toolName, args, timeout, budget, streamsResults)
+	}
+
+	var stream *mcpToolOutputStream
+	if streamsResults {
+		stream = s.newMCPToolOutputStream(ctx)
+	}
+	callID, resultChan, err := sendMCPToolCall(conn, toolName, args, timeout, s.sendTimeout, mcpToolProgressListener(ctx), stream)
+	if err != nil {
+		return models.MCPToolResult{}, err
+	}
+	defer removePendingResult(conn, callID)
+
+	// Wait for result with timeout
+	select {
+	case result := <-resultChan:
+		return result, nil
+	case <-time.After(timeout):
+		log.Printf("⏱️  MCP tool %s timed out after %s", toolName, budget)
+		// A streamed call still hands over what it sent before the timeout
+		if result, ok := stream.incompleteResult(callID); ok {
+			s.CapToolResult(&result)
+			return result, nil
+		}
+		return models.MCPToolResult{}, fmt.Errorf("tool execution timeout after %s", budget)
+	case <-ctx.Done():
+		return models.MCPToolResult{}, s.cancelMCPToolCall(ctx, conn, toolName, callID)
+	}
+}
+
+// executeWithRetry waits part of the budget for the first attempt, then re-dispatches
+// under a new call_id after a jittered pause. The first call stays pending, so
+// whichever attempt answers first wins. Each attempt of a streamed call has its own
+// stream, and a timeout returns no incomplete output since the attempts race.
+func (s *MCPBridgeService) executeWithRetry(ctx context.Context, conn *models.MCPConnection, toolName string, args map[string]interface{}, timeout time.Duration, budget mcpToolTimeout, streamsResults bool) (models.MCPToolResult, error) {
+	deadline := time.Now().Add(timeout)
+	progress := mcpToolProgressListener(ctx)
+	newStream := func() *mcpToolOutputStream {
+		if !streamsResults {
+			return nil
+		}
+		return s.newMCPToolOutputStream(ctx)
+	}
+
+	firstID, firstChan, err := sendMCPToolCall(conn, toolName, args, timeout, s.sendTimeout, progress, newStream())
+	if err != nil {
+		return models.MCPToolResult{}, err
 	}
+	defer removePendingResult(conn, firstID)
 
+	select {
+	case result := <-firstChan:
+		return result, nil
+	case <-time.After(time.Duration(float64(timeout) * MCPRetryFirstAttemptShare)):
+	case <-ctx.Done():
+		return models.MCPToolResult{}, s.cancelMCPToolCall(ctx, conn, toolName, firstID)
+	}
+
+	jitter := mcpRetryJitter(time.Until(deadline))
+	select {
+	case result := <-firstChan:
+		return result, nil
+	case <-time.After(jitter):
+	case <-ctx.Done():
+		return models.MCPToolResult{}, s.cancelMCPToolCall(ctx, conn, toolName, firstID)
+	}
+
+	// The pause is clamped to the budget, but the first attempt may have run late
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		log.Printf("⏱️  MCP tool %s timed out after %s, no budget left to retry", toolName, budget)
+		return models.MCPToolResult{}, fmt.Errorf("tool execution timeout after %s", budget)
+	}
+
+	log.Printf("MCP tool %s timed out on first attempt, retrying (%v left)", toolName, remaining.Round(time.Millisecond))
+	secondID, secondChan, err := sendMCPToolCall(conn, toolName, args, remaining, s.sendTimeout, progress, newStream())
+	if err != nil {
+		// Could not re-dispatch; the first attempt may still answer
+		log.Printf("Warning: Retry dispatch for MCP tool %s failed: %v", toolName, err)
+		remaining = time.Until(deadline)
+	} else {
+		defer removePendingResult(conn, secondID)
+	}
+
+	select {
+	case result := <-firstChan:
+		return result, nil
+	case result := <-secondChan:
+		return result, nil
+	case <-time.After(remaining):
+		log.Printf("⏱️  MCP tool %s timed out after %s, retried once", toolName, budget)
+		return models.MCPToolResult{}, fmt.Errorf("tool execution timeout after %s (retried once)", budget)
+	case <-ctx.Done():
+		if secondID != "" {
+			s.cancelMCPToolCall(ctx, conn, toolName, secondID)
+		}
+		return models.MCPToolResult{}, s.cancelMCPToolCall(ctx, conn, toolName, firstID)
+	}
+}
+
+// mcpRetryJitter picks the pause before a re-dispatch: MCPRetryMinJitter to
+// MCPRetryMaxJitter, clamped to MCPRetryJitterShare of the remaining budget
+func mcpRetryJitter(remaining time.Duration) time.Duration {
+	low, high := MCPRetryMinJitter, MCPRetryMaxJitter
+	if limit := time.Duration(float64(remaining) * MCPRetryJitterShare); high > limit {
+		high = limit
+		if low > high/2 {
+			low = high / 2
+		}
+	}
+	if high <= low {
+		return 0
+	}
+	return low + time.Duration(rand.Int63n(int64(high-low)))
+}
+
+// mcpToolRetriesOnTimeout reports whether a timed-out call to toolName may be re-sent.
+// Both conditions are required: the server declared the tool read-only, and the
+// user opted it in on the client.
+func mcpToolRetriesOnTimeout(conn *models.MCPConnection, toolName string) bool {
+	if i := findMCPTool(conn.Tools, toolName); i >= 0 {
+		return conn.Tools[i].ReadOnly && conn.Tools[i].RetryOnTimeout
+	}
+	return false
+}
+
+// sendMCPToolCall registers a pending result (and progress listener and output stream,
+// if any) under a new call ID and sends the call, waiting at most sendTimeout for room
+// to queue it. With a stream the call asks the client to stream its output.
+func sendMCPToolCall(conn *models.MCPConnection, toolName string, args map[string]interface{}, timeout, sendTimeout time.Duration, progress models.MCPToolProgressFunc, stream *mcpToolOutputStream) (string, chan models.MCPToolResult, error) {
 	// Generate unique call ID
 	callID := uuid.New().String()
 
 	// Create result channel for this call
 	resultChan := make(chan models.MCPToolResult, 1)
+	conn.PendingMu.Lock()
 	conn.PendingResults[callID] = resultChan
+	if progress != nil {
+		if conn.PendingProgress == nil {
+			conn.PendingProgress = make(map[string]models.MCPToolProgressFunc)
+		}
+		conn.PendingProgress[callID] = progress
+	}
+	if stream != nil {
+		if conn.PendingStreams == nil {
+			conn.PendingStreams = make(map[string]models.MCPToolOutputStream)
+		}
+		conn.PendingStreams[callID] = stream
+	}
+	conn.PendingMu.Unlock()
 
 	// Create tool call message
 	toolCall := models.MCPToolCall{
 		CallID:    callID,
 		ToolName:  toolName,
 		Arguments: args,
-		Timeout:   int(timeout.Seconds()),
+		Timeout:   int(math.Ceil(timeout.Seconds())), // Sub-second budgets must not read as "no timeout"
 	}
 
-	// Send to client
+	payload := map[string]interface{}{
+		"call_id":   toolCall.CallID,
+		"tool_name": toolCall.ToolName,
+		"arguments": toolCall.Arguments,
+		"timeout":   toolCall.Timeout,
+	}
+	if stream != nil {
+		payload["stream_results"] = true
+	}
+
+	// Send to client, unless it disconnected in the meantime
 	select {
-	case conn.WriteChan <- models.MCPServerMessage{
-		Type: "tool_call",
-		Payload: map[string]interface{}{
-			"call_id":   toolCall.CallID,
-			"tool_name": toolCall.ToolName,
-			"arguments": toolCall.Arguments,
-			"timeout":   toolCall.Timeout,
-		},
-	}:
+	case <-conn.StopChan:
+		removePendingResult(conn, callID)
+		return "", nil, fmt.Errorf("%w: client %s disconnected, the tool did not run", ErrMCPConnectionNotFound, conn.ClientID)
+	default:
+	}
+	select {
+	case conn.WriteChan <- models.MCPServerMessage{Type: "tool_call", Payload: payload}:
 		// Message sent successfully
-	case <-time.After(5 * time.Second):
-		delete(conn.PendingResults, callID)
-		return "", fmt.Errorf("timeout sending tool call to client")
+		return callID, resultChan, nil
+	case <-conn.StopChan:
+		removePendingResult(conn, callID)
+		return "", nil, fmt.Errorf("%w: client %s disconnected, the tool did not run", ErrMCPConnectionNotFound, conn.ClientID)
+	case <-time.After(sendTimeout):
+		removePendingResult(conn, callID)
+		log.Printf("⏱️  MCP tool %s: send timeout, client %s is not reading", toolName, conn.ClientID)
+		return "", nil, fmt.Errorf("%w: tool call could not be sent within %v, the tool did not run", ErrMCPClientBusy, sendTimeout)
 	}
+}
 
-	// Wait for result with timeout
-	select {
-	case result := <-resultChan:
-		delete(conn.PendingResults, callID)
-		if result.Success {
-			return result.Result, nil
+// removePendingResult removes callID from conn, following the connections that
+// resumed conn, where the call may have moved
+func removePendingResult(conn *models.MCPConnection, callID string) {
+	for c := conn; c != nil; {
+		c.PendingMu.Lock()
+		delete(c.PendingResults, callID)
+		delete(c.PendingProgress, callID)
+		delete(c.PendingStreams, callID)
+		next := c.Successor
+		c.PendingMu.Unlock()
+		c = next
+	}
+}
+
+// DeliverToolResult caps a result received from a client and forwards it to the call
+// waiting for it. It returns false if no call is waiting (timed out or unknown call ID).
+func (s *MCPBridgeService) DeliverToolResult(clientID string, result models.MCPToolResult) bool {
+	// Enforce the backend's size ceiling before the result goes anywhere
+	if result.Truncated {
+		GetMetrics().RecordMCPToolResult(result.OriginalSize)
+	} else {
+		GetMetrics().RecordMCPToolResult(len(result.Result))
+	}
+
+	conn, exists := s.GetConnection(clientID)
+	if !exists {
+		return false
+	}
+	resultChan, pending := pendingResult(conn, result.CallID)
+	if !pending {
+		if result.Cancelled {
+			log.Printf("🛑 Tool call %s was cancelled on the client", result.CallID)
 		} else {
-			return "", fmt.Errorf("%s", result.Error)
+			log.Printf("⚠️  No pending result channel for call_id: %s", result.CallID)
 		}
-	case <-time.After(timeout):
-		delete(conn.PendingResults, callID)
-		return "", fmt.Errorf("tool execution timeout after %v", timeout)
+		return false
+	}
+
+	// Binary results are stored as files first, so the cap never cuts an envelope
+	if !storeMCPBinaryResult(s.fileService(), conn.UserID, &result) {
+		s.CapToolResult(&result)
+	}
+	if result.Truncated {
+		log.Printf("✂️  Tool result %s truncated (original %d bytes)", result.CallID, result.OriginalSize)
+	}
+
+	// Non-blocking send to result channel
+	select {
+	case resultChan <- result:
+		log.Printf("✅ Tool result forwarded to waiting channel: %s", result.CallID)
+		return true
+	default:
+		log.Printf("⚠️  Result channel full or closed for call_id: %s", result.CallID)
+		return false
 	}
 }
 
+func mcpToolResultValue(result models.MCPToolResult) (string, error) {
+	if result.Success {
+		value := result.Result
+		if result.Truncated {
+			// Tell the model it is looking at partial data
+			value = fmt.Sprintf("%s\n\n[Result truncated: showing the first %d of %d bytes]",
+				value, len(result.Result), result.OriginalSize)
+		}
+		if result.Incomplete {
+			value += "\n\n[Incomplete result: the tool did not finish in time; this is the output it streamed before then]"
+		}
+		return value, nil
+	}
+	if result.NotFound {
+		return "", fmt.Errorf("%w: %s", tools.ErrToolNotFound, result.Error)
+	}
+	return "", fmt.Errorf("%s", result.Error)
+}
+
 // GetConnection retrieves a connection by client ID
 func (s *MCPBridgeService) GetConnection(clientID string) (*models.MCPConnection, bool) {
 	s.mutex.RLock()
@@ -283,12 +1081,18 @@ func (s *MCPBridgeService) GetConnectionCount() int {
 	return len(s.connections)
 }
 
-// LogToolExecution logs a tool execution for audit purposes
-func (s *MCPBridgeService) LogToolExecution(userID, toolName, conversationID string, executionTimeMs int, success bool, errorMsg string) {
+// LogToolExecution logs a tool execution for audit purposes. executionTimeMs is the
+// round trip seen by the server; toolTimeMs is the client-reported tool run time (0 if unknown).
+func (s *MCPBridgeService) LogToolExecution(userID, toolName, conversationID string, executionTimeMs, toolTimeMs int, success bool, errorMsg string) {
+	var toolTime sql.NullInt64
+	if toolTimeMs > 0 {
+		toolTime = sql.NullInt64{Int64: int64(toolTimeMs), Valid: true}
+	}
+
 	_, err := s.db.Exec(`
-		INSERT INTO mcp_audit_log (user_id, tool_name, conversation_id, execution_time_ms, success, error_message)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, userID, toolName, conversationID, executionTimeMs, success, errorMsg)
+		INSERT INTO mcp_audit_log (user_id, tool_name, conversation_id, execution_time_ms, tool_time_ms, success, error_message)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, userID, toolName, conversationID, executionTimeMs, toolTime, success, errorMsg)
 
 	if err != nil {
 		log.Printf("Warning: Failed to log tool execution: %v", err)
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+func TestApplyMCPToolChanges(t *testing.T) {
+	current := []models.MCPTool{
+		{Name: "read_file", Description: "v1"},
+		{Name: "list_dir"},
+		{Name: "search"},
+	}
+
+	updated := applyMCPToolChanges(current,
+		[]models.MCPTool{{Name: "read_file", Description: "v2"}, {Name: "write_file"}},
+		[]string{"list_dir", "unknown"},
+	)
+
+	var names []string
+	for _, tool := range updated {
+		names = append(names, tool.Name)
+	}
+	if len(names) != 3 || names[0] != "read_file" || names[1] != "search" || names[2] != "write_file" {
+		t.Fatalf("unexpected tool set %v", names)
+	}
+	if updated[0].Description != "v2" {
+		t.Errorf("expected read_file to be replaced, got description %q", updated[0].Description)
+	}
+
+	// The previous tool set is left intact for readers still holding it
+	if len(current) != 3 || current[0].Description != "v1" || current[1].Name != "list_dir" {
+		t.Errorf("original tool set was modified: %+v", current)
+	}
+}
+
+func TestDedupeMCPTools(t *testing.T) {
+	unique, duplicates := dedupeMCPTools([]models.MCPTool{
+		{Name: "search", Description: "first"},
+		{Name: "fetch"},
+		{Name: "search", Description: "second"},
+		{Name: "fetch"},
+		{Name: "search"},
+	})
+
+	if len(unique) != 2 || unique[0].Name != "search" || unique[1].Name != "fetch" {
+		t.Fatalf("unexpected unique tools %+v", unique)
+	}
+	if unique[0].Description != "first" {
+		t.Errorf("expected the first definition to win, got %q", unique[0].Description)
+	}
+	if len(duplicates) != 2 || duplicates[0] != "search" || duplicates[1] != "fetch" {
+		t.Errorf("expected each collision reported once, got %v", duplicates)
+	}
+}
+
+func TestChangeToolsRequiresConnectedClient(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+
+	if _, err := service.AddTools("missing", []models.MCPTool{{Name: "read_file"}}); err == nil {
+		t.Error("expected error adding tools to an unknown client")
+	}
+	if err := service.UpdateTool("missing", models.MCPTool{Name: "read_file"}); err == nil {
+		t.Error("expected error updating a tool on an unknown client")
+	}
+}
+
+func TestListAndRevokeConnectionsScopedToUser(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	now := time.Now()
+	for _, conn := range []*models.MCPConnection{
+		{ClientID: "laptop", UserID: "user-1", Platform: "darwin", ConnectedAt: now, Tools: []models.MCPTool{{Name: "read_file"}}},
+		{ClientID: "desktop", UserID: "user-2", Platform: "linux", ConnectedAt: now.Add(-time.Hour)},
+	} {
+		service.connections[conn.ClientID] = conn
+		service.userConns[conn.UserID] = conn.ClientID
+	}
+
+	own := service.ListConnections("user-1")
+	if len(own) != 1 || own[0].ClientID != "laptop" || own[0].Platform != "darwin" || own[0].ToolCount != 1 {
+		t.Errorf("unexpected connections for user-1: %+v", own)
+	}
+	if all := service.ListConnections(""); len(all) != 2 || all[0].ClientID != "desktop" {
+		t.Errorf("expected all connections oldest first, got %+v", all)
+	}
+
+	// Another user's client is indistinguishable from a missing one
+	if err := service.RevokeConnection("desktop", "user-1"); !errors.Is(err, ErrMCPConnectionNotFound) {
+		t.Errorf("expected ErrMCPConnectionNotFound revoking another user's client, got %v", err)
+	}
+	if err := service.RevokeConnection("missing", ""); !errors.Is(err, ErrMCPConnectionNotFound) {
+		t.Errorf("expected ErrMCPConnectionNotFound for unknown client, got %v", err)
+	}
+	if _, exists := service.GetConnection("desktop"); !exists {
+		t.Error("failed revocation must leave the connection in place")
+	}
+}
+
+func TestExecuteToolOnClientRejectsOversizedArguments(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	service.SetMaxArgBytes(64)
+	conn := newRetryTestConnection(models.MCPTool{Name: "write_file"})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	args := map[string]interface{}{"path": "notes.txt", "content": strings.Repeat("x", 100)}
+	_, err := service.ExecuteToolOnClient(context.Background(), conn.UserID, "write_file", args, time.Second)
+	if !errors.Is(err, ErrMCPToolArgsTooLarge) {
+		t.Fatalf("expected ErrMCPToolArgsTooLarge, got %v", err)
+	}
+	if len(conn.WriteChan) != 0 {
+		t.Errorf("oversized call must not be dispatched, got %d queued messages", len(conn.WriteChan))
+	}
+
+	if err := service.checkToolArgSize("write_file", map[string]interface{}{"path": "notes.txt"}); err != nil {
+		t.Errorf("expected small arguments to pass, got %v", err)
+	}
+	service.SetMaxArgBytes(0)
+	if err := service.checkToolArgSize("write_file", args); err != nil {
+		t.Errorf("expected no limit when disabled, got %v", err)
+	}
+}
+
+func TestCapToolResultTruncatesOversizedResults(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	service.SetMaxResultBytes(10)
+
+	result := models.MCPToolResult{Success: true, Result: "héllo wörld, this is long"}
+	service.CapToolResult(&result)
+	if !result.Truncated || result.OriginalSize != 27 || len(result.Result) > 10 {
+		t.Fatalf("expected truncation to at most 10 bytes, got %+v", result)
+	}
+	if result.Result != "héllo wö" {
+		t.Errorf("expected cut on a character boundary, got %q", result.Result)
+	}
+
+	value, err := mcpToolResultValue(result)
+	if err != nil || !strings.Contains(value, "[Result truncated: showing the first 10 of 27 bytes]") {
+		t.Errorf("expected truncation note for the model, got %q (err %v)", value, err)
+	}
+
+	// A result the client already truncated keeps its reported original size
+	clientCut := models.MCPToolResult{Success: true, Result: strings.Repeat("a", 20), Truncated: true, OriginalSize: 5000}
+	service.CapToolResult(&clientCut)
+	if clientCut.OriginalSize != 5000 || len(clientCut.Result) != 10 {
+		t.Errorf("expected client original size to be kept, got %+v", clientCut)
+	}
+}
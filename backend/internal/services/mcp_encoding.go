@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// MCP message encodings. Messages are JSON in text frames unless the client offers
+// msgpack in register_tools (protocol version 3+) and the ack confirms it; messages
+// are then msgpack in binary frames. Readers pick the decoder by frame type, so a
+// message sent before the switch is still understood.
+//
+// Both encodings carry the same {"type", "payload"} document and decode to the same
+// values: msgpack numbers come back as float64 and structs are sent through their
+// JSON form, so payload handling does not depend on the encoding.
+const (
+	MCPEncodingJSON    = "json"
+	MCPEncodingMsgpack = "msgpack"
+)
+
+// NegotiateMCPEncoding returns the encoding to use with a client speaking
+// protocolVersion that offered encodings, in its order of preference
+func NegotiateMCPEncoding(protocolVersion int, offered []string) string {
+	if protocolVersion < MCPProtocolMsgpack {
+		return MCPEncodingJSON
+	}
+	for _, encoding := range offered {
+		if encoding == MCPEncodingMsgpack || encoding == MCPEncodingJSON {
+			return encoding
+		}
+	}
+	return MCPEncodingJSON
+}
+
+// EncodeMCPMessage encodes a message; binary reports whether it must be sent as a
+// binary frame
+func EncodeMCPMessage(encoding, msgType string, payload map[string]interface{}) (data []byte, binary bool, err error) {
+	if encoding != MCPEncodingMsgpack {
+		data, err = json.Marshal(map[string]interface{}{"type": msgType, "payload": payload})
+		return data, false, err
+	}
+
+	data = msgp.AppendMapHeader(nil, 2)
+	data = msgp.AppendString(data, "type")
+	data = msgp.AppendString(data, msgType)
+	data = msgp.AppendString(data, "payload")
+	if data, err = appendMsgpackValue(data, payload); err != nil {
+		return nil, true, fmt.Errorf("failed to encode %s payload: %w", msgType, err)
+	}
+	return data, true, nil
+}
+
+// DecodeMCPMessage decodes a message read from a binary (msgpack) or text (JSON) frame
+func DecodeMCPMessage(data []byte, binary bool) (msgType string, payload map[string]interface{}, err error) {
+	var doc struct {
+		Type    string                 `json:"type"`
+		Payload map[string]interface{} `json:"payload"`
+	}
+	if !binary {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return "", nil, err
+		}
+		return doc.Type, doc.Payload, nil
+	}
+
+	value, _, err := msgp.ReadIntfBytes(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid msgpack message: %w", err)
+	}
+	fields, ok := normalizeMsgpackValue(value).(map[string]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("invalid msgpack message: not a map")
+	}
+	msgType, _ = fields["type"].(string)
+	payload, _ = fields["payload"].(map[string]interface{})
+	return msgType, payload, nil
+}
+
+// appendMsgpackValue appends v as the value JSON would produce for it: the common
+// payload types are written directly, anything else goes through encoding/json
+func appendMsgpackValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil, bool, string, float64, float32, int, int64, int32, uint, uint64, uint32:
+		return msgp.AppendIntf(b, v)
+	case map[string]interface{}:
+		b = msgp.AppendMapHeader(b, uint32(len(v)))
+		for key, nested := range v {
+			b = msgp.AppendString(b, key)
+			var err error
+			if b, err = appendMsgpackValue(b, nested); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case []interface{}:
+		b = msgp.AppendArrayHeader(b, uint32(len(v)))
+		for _, nested := range v {
+			var err error
+			if b, err = appendMsgpackValue(b, nested); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case []string:
+		b = msgp.AppendArrayHeader(b, uint32(len(v)))
+		for _, s := range v {
+			b = msgp.AppendString(b, s)
+		}
+		return b, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return b, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return b, err
+	}
+	return appendMsgpackValue(b, generic)
+}
+
+// normalizeMsgpackValue converts decoded msgpack values to the types encoding/json
+// decodes to (float64 numbers, string-keyed maps, []interface{} arrays)
+func normalizeMsgpackValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	case map[string]interface{}:
+		for key, nested := range v {
+			v[key] = normalizeMsgpackValue(nested)
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = normalizeMsgpackValue(nested)
+		}
+		return v
+	}
+	return v
+}
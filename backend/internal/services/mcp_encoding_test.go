@@ -0,0 +1,79 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"claraverse/internal/models"
+)
+
+func TestNegotiateMCPEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  int
+		offered  []string
+		expected string
+	}{
+		{name: "nothing offered", version: 3, expected: MCPEncodingJSON},
+		{name: "msgpack offered", version: 3, offered: []string{"msgpack", "json"}, expected: MCPEncodingMsgpack},
+		{name: "client prefers json", version: 3, offered: []string{"json", "msgpack"}, expected: MCPEncodingJSON},
+		{name: "unknown encodings skipped", version: 3, offered: []string{"cbor", "msgpack"}, expected: MCPEncodingMsgpack},
+		{name: "older protocol stays json", version: 2, offered: []string{"msgpack"}, expected: MCPEncodingJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NegotiateMCPEncoding(tt.version, tt.offered); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMCPEncodingsDecodeIdentically(t *testing.T) {
+	payload := map[string]interface{}{
+		"call_id":   "call-1",
+		"tool_name": "read_file",
+		"timeout":   30,
+		"ratio":     0.5,
+		"success":   true,
+		"error":     nil,
+		"names":     []string{"a", "b"},
+		"arguments": map[string]interface{}{"path": "a.txt", "lines": []interface{}{1, 2}},
+		"tool":      models.MCPTool{Name: "read_file", ReadOnly: true},
+	}
+
+	jsonData, jsonBinary, err := EncodeMCPMessage(MCPEncodingJSON, "tool_call", payload)
+	if err != nil || jsonBinary {
+		t.Fatalf("JSON encode failed: binary=%v err=%v", jsonBinary, err)
+	}
+	msgpackData, msgpackBinary, err := EncodeMCPMessage(MCPEncodingMsgpack, "tool_call", payload)
+	if err != nil || !msgpackBinary {
+		t.Fatalf("msgpack encode failed: binary=%v err=%v", msgpackBinary, err)
+	}
+
+	jsonType, jsonPayload, err := DecodeMCPMessage(jsonData, false)
+	if err != nil {
+		t.Fatalf("JSON decode failed: %v", err)
+	}
+	msgpackType, msgpackPayload, err := DecodeMCPMessage(msgpackData, true)
+	if err != nil {
+		t.Fatalf("msgpack decode failed: %v", err)
+	}
+
+	if jsonType != "tool_call" || msgpackType != "tool_call" {
+		t.Errorf("Expected type tool_call, got %q and %q", jsonType, msgpackType)
+	}
+	if !reflect.DeepEqual(jsonPayload, msgpackPayload) {
+		t.Errorf("Expected identical payloads:\njson:    %#v\nmsgpack: %#v", jsonPayload, msgpackPayload)
+	}
+}
+
+func TestDecodeMCPMessageRejectsInvalidMsgpack(t *testing.T) {
+	if _, _, err := DecodeMCPMessage([]byte{0xc1}, true); err == nil {
+		t.Error("Expected an error for invalid msgpack")
+	}
+	if _, _, err := DecodeMCPMessage([]byte{0x01}, true); err == nil {
+		t.Error("Expected an error for a message that is not a map")
+	}
+}
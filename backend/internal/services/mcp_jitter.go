@@ -0,0 +1,19 @@
+package services
+
+import (
+	"math/rand"
+	"time"
+)
+
+// MCPPingInterval is the average time between WebSocket pings to an MCP client
+const MCPPingInterval = 30 * time.Second
+
+// MCPIntervalJitter is the fraction by which ping and heartbeat intervals vary (±10%),
+// so clients that reconnected together after a backend restart drift apart instead
+// of hitting the backend in lockstep
+const MCPIntervalJitter = 0.1
+
+// JitterMCPInterval returns d varied randomly by up to ±MCPIntervalJitter
+func JitterMCPInterval(d time.Duration) time.Duration {
+	return d + time.Duration((rand.Float64()*2-1)*MCPIntervalJitter*float64(d))
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterMCPIntervalStaysWithinBounds(t *testing.T) {
+	spread := time.Duration(MCPIntervalJitter * float64(MCPPingInterval))
+	min, max := MCPPingInterval-spread, MCPPingInterval+spread
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		d := JitterMCPInterval(MCPPingInterval)
+		if d < min || d > max {
+			t.Fatalf("Expected an interval within [%v, %v], got %v", min, max, d)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Error("Expected intervals to vary")
+	}
+}
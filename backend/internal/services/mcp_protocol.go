@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MCP bridge protocol versions. The client sends its highest version in register_tools
+// and the ack carries the negotiated one (the lower of the two); each side then only
+// uses features the negotiated version has.
+//
+//	1: register_tools, tool_call/tool_result and heartbeats. Clients that send no
+//	   protocol_version predate negotiation and are treated as version 1.
+//	2: wrapped binary arguments ({"__b64__": ...}) and incremental tool updates
+//	   (add_tools, remove_tools, update_tool)
+//	3: msgpack message encoding, when offered in register_tools "encodings" (see
+//	   NegotiateMCPEncoding)
+//	4: cancel_tool_call, sent when the caller of a tool call gives up on it
+//	5: tool_progress, sent by the client while a tool call runs
+//	6: tool_result_partial, streamed output of tools registered with streams_results
+const (
+	MCPProtocolVersion    = 6
+	MCPMinProtocolVersion = 1
+
+	// MCPProtocolBinaryArgs is the first version whose clients unwrap binary arguments
+	MCPProtocolBinaryArgs = 2
+	// MCPProtocolIncrementalTools is the first version that may send incremental tool updates
+	MCPProtocolIncrementalTools = 2
+	// MCPProtocolMsgpack is the first version that may negotiate msgpack encoding
+	MCPProtocolMsgpack = 3
+	// MCPProtocolCancel is the first version whose clients abort cancelled tool calls
+	MCPProtocolCancel = 4
+	// MCPProtocolProgress is the first version whose clients report tool progress
+	MCPProtocolProgress = 5
+	// MCPProtocolPartialResults is the first version whose clients stream tool output
+	MCPProtocolPartialResults = 6
+)
+
+// ErrMCPProtocolUnsupported is returned for clients older than MCPMinProtocolVersion
+var ErrMCPProtocolUnsupported = errors.New("unsupported MCP protocol version")
+
+// NegotiateMCPProtocol returns the protocol version to use with a client that
+// supports up to clientVersion (0 if it did not say)
+func NegotiateMCPProtocol(clientVersion int) (int, error) {
+	if clientVersion <= 0 {
+		clientVersion = 1
+	}
+	if clientVersion < MCPMinProtocolVersion {
+		return 0, fmt.Errorf("%w: client speaks version %d, the minimum supported version is %d; please update your MCP client",
+			ErrMCPProtocolUnsupported, clientVersion, MCPMinProtocolVersion)
+	}
+	return min(clientVersion, MCPProtocolVersion), nil
+}
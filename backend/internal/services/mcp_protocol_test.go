@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+func TestNegotiateMCPProtocol(t *testing.T) {
+	tests := map[int]int{
+		0:                      1, // client predates negotiation
+		1:                      1,
+		MCPProtocolVersion:     MCPProtocolVersion,
+		MCPProtocolVersion + 3: MCPProtocolVersion, // newer client falls back to ours
+	}
+	for client, want := range tests {
+		got, err := NegotiateMCPProtocol(client)
+		if err != nil {
+			t.Fatalf("NegotiateMCPProtocol(%d) failed: %v", client, err)
+		}
+		if got != want {
+			t.Errorf("NegotiateMCPProtocol(%d) = %d, want %d", client, got, want)
+		}
+	}
+}
+
+func TestExecuteToolOnClientWrapsBinaryForNegotiatedProtocol(t *testing.T) {
+	for _, version := range []int{1, MCPProtocolBinaryArgs} {
+		service := NewMCPBridgeService(nil, nil)
+		conn := newRetryTestConnection(models.MCPTool{Name: "upload"})
+		conn.ProtocolVersion = version
+		service.connections[conn.ClientID] = conn
+		service.userConns[conn.UserID] = conn.ClientID
+
+		service.ExecuteToolOnClient(context.Background(), conn.UserID, "upload", map[string]interface{}{"data": []byte("hi")}, 10*time.Millisecond)
+
+		args := (<-conn.WriteChan).Payload["arguments"].(map[string]interface{})
+		_, wrapped := args["data"].(map[string]interface{})
+		if want := version >= MCPProtocolBinaryArgs; wrapped != want {
+			t.Errorf("Protocol %d: expected wrapped=%v, got argument %#v", version, want, args["data"])
+		}
+	}
+}
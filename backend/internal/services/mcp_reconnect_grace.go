@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+)
+
+// DefaultMCPReconnectGrace is how long a client reaped by the heartbeat watchdog may
+// take to reconnect before its tools are unregistered
+const DefaultMCPReconnectGrace = 60 * time.Second
+
+// heldMCPConnection is a reaped connection waiting for its client to reconnect
+type heldMCPConnection struct {
+	conn  *models.MCPConnection
+	until time.Time
+	done  chan struct{} // closed when the client reconnects or the window ends
+}
+
+// SetReconnectGrace sets how long a reaped client's tools and pending calls are held
+// for it to reconnect (0 unregisters them as soon as it is reaped)
+func (s *MCPBridgeService) SetReconnectGrace(grace time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reconnectGrace = grace
+}
+
+// holdClientLocked disconnects a reaped client like disconnectClientLocked, but keeps
+// its tools registered and its pending calls waiting until the grace window ends, so
+// a client back from a brief network drop resumes where it left off. New calls to its
+// tools wait for it too (see waitForHeldClient). Must be called with the lock held;
+// returns the disconnect event to emit once it is released.
+func (s *MCPBridgeService) holdClientLocked(clientID string, conn *models.MCPConnection, reason string) MCPConnectionEvent {
+	_, err := s.db.Exec("UPDATE mcp_connections SET is_active = 0 WHERE client_id = ?", clientID)
+	if err != nil {
+		log.Printf("Warning: Failed to mark connection as inactive: %v", err)
+	}
+
+	delete(s.connections, clientID)
+	delete(s.userConns, conn.UserID)
+	s.toolLimiter.forget(conn)
+	close(conn.StopChan)
+
+	s.held[conn.UserID] = &heldMCPConnection{
+		conn:  conn,
+		until: time.Now().Add(s.reconnectGrace),
+		done:  make(chan struct{}),
+	}
+	log.Printf("⏸️  MCP client disconnected, holding its tools for %s: user=%s, client=%s, reason=%s",
+		s.reconnectGrace, conn.UserID, clientID, reason)
+
+	return newMCPConnectionEvent(MCPEventDisconnect, clientID, conn.UserID,
+		conn.ClientVersion, conn.Platform, len(conn.Tools), reason)
+}
+
+// resumeHeldLocked hands a held connection of userID over to its reconnected
+// successor: the calls still pending on it move to the new connection, which then
+// answers them. The held tools are unregistered for the new registration to replace.
+// Reports whether one was held.
+func (s *MCPBridgeService) resumeHeldLocked(userID string, conn *models.MCPConnection) bool {
+	held, ok := s.held[userID]
+	if !ok {
+		return false
+	}
+	delete(s.held, userID)
+	s.registry.UnregisterAllUserTools(userID)
+
+	movePendingCalls(held.conn, conn)
+	close(held.done)
+	log.Printf("▶️  MCP client reconnected within the grace window: user=%s, client=%s (was %s)",
+		userID, conn.ClientID, held.conn.ClientID)
+	return true
+}
+
+// expireHeldLocked gives up on held connections whose grace window has ended,
+// unregistering their tools and failing the calls still pending on them. Returns the
+// reconnect_expired events to emit.
+func (s *MCPBridgeService) expireHeldLocked(now time.Time) []MCPConnectionEvent {
+	var events []MCPConnectionEvent
+	for userID, held := range s.held {
+		if now.Before(held.until) {
+			continue
+		}
+		delete(s.held, userID)
+		s.registry.UnregisterAllUserTools(userID)
+
+		conn := held.conn
+		failPendingCalls(conn, fmt.Sprintf("MCP client did not reconnect within %s", s.reconnectGrace))
+		close(held.done)
+		log.Printf("🔌 MCP client did not reconnect within %s, unregistering its tools: user=%s, client=%s",
+			s.reconnectGrace, userID, conn.ClientID)
+		events = append(events, newMCPConnectionEvent(MCPEventReconnectExpired, conn.ClientID, userID,
+			conn.ClientVersion, conn.Platform, len(conn.Tools), MCPDisconnectReconnectExpired))
+	}
+	return events
+}
+
+// waitForHeldClient queues a call for the held client of userID until it reconnects,
+// the grace window ends, maxWait passes (0 waits for the whole window) or ctx is done.
+// It reports whether the user had a held client; err is nil once the client is back
+// or the window was closed, and the call should be dispatched again.
+func (s *MCPBridgeService) waitForHeldClient(ctx context.Context, userID string, maxWait time.Duration) (bool, error) {
+	s.mutex.RLock()
+	held, ok := s.held[userID]
+	s.mutex.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	wait := time.Until(held.until)
+	if maxWait > 0 && maxWait < wait {
+		wait = maxWait
+	}
+	log.Printf("⏸️  MCP client of user %s is reconnecting, holding the call for up to %s", userID, wait.Round(time.Millisecond))
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-held.done:
+		return true, nil
+	case <-ctx.Done():
+		return true, ctx.Err()
+	case <-timer.C:
+		return true, fmt.Errorf("%w: MCP client did not reconnect in time", tools.ErrToolUnavailable)
+	}
+}
+
+// IsUserReachable reports whether calls for userID can be dispatched: a client is
+// connected, or a reaped one is within its reconnect grace window and calls wait
+// for it
+func (s *MCPBridgeService) IsUserReachable(userID string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if _, connected := s.userConns[userID]; connected {
+		return true
+	}
+	_, held := s.held[userID]
+	return held
+}
+
+// failPendingCalls answers every call still pending on conn with a failed result
+func failPendingCalls(conn *models.MCPConnection, errMsg string) {
+	conn.PendingMu.Lock()
+	defer conn.PendingMu.Unlock()
+
+	for callID, resultChan := range conn.PendingResults {
+		select {
+		case resultChan <- models.MCPToolResult{CallID: callID, Success: false, Error: errMsg}:
+		default:
+		}
+	}
+	conn.PendingResults = make(map[string]chan models.MCPToolResult)
+	conn.PendingProgress = nil
+	conn.PendingStreams = nil
+}
+
+// movePendingCalls moves the pending results, progress listeners and output streams
+// of from onto to, and links from to to so callers still waiting on from remove their
+// call where it moved. to keeps no reference to from, so repeated reconnects do not
+// keep old connections alive.
+func movePendingCalls(from, to *models.MCPConnection) {
+	from.PendingMu.Lock()
+	defer from.PendingMu.Unlock()
+	to.PendingMu.Lock()
+	defer to.PendingMu.Unlock()
+
+	if to.PendingResults == nil {
+		to.PendingResults = make(map[string]chan models.MCPToolResult)
+	}
+	for callID, resultChan := range from.PendingResults {
+		to.PendingResults[callID] = resultChan
+	}
+	if len(from.PendingProgress) > 0 && to.PendingProgress == nil {
+		to.PendingProgress = make(map[string]models.MCPToolProgressFunc)
+	}
+	for callID, listener := range from.PendingProgress {
+		to.PendingProgress[callID] = listener
+	}
+	if len(from.PendingStreams) > 0 && to.PendingStreams == nil {
+		to.PendingStreams = make(map[string]models.MCPToolOutputStream)
+	}
+	for callID, stream := range from.PendingStreams {
+		to.PendingStreams[callID] = stream
+	}
+
+	from.PendingResults = make(map[string]chan models.MCPToolResult)
+	from.PendingProgress = nil
+	from.PendingStreams = nil
+	from.Successor = to
+}
+
+// pendingResult finds the result channel of callID on conn
+func pendingResult(conn *models.MCPConnection, callID string) (chan models.MCPToolResult, bool) {
+	conn.PendingMu.Lock()
+	defer conn.PendingMu.Unlock()
+	resultChan, pending := conn.PendingResults[callID]
+	return resultChan, pending
+}
+
+// pendingProgress finds the progress listener of callID on conn
+func pendingProgress(conn *models.MCPConnection, callID string) (models.MCPToolProgressFunc, bool) {
+	conn.PendingMu.Lock()
+	defer conn.PendingMu.Unlock()
+	listener, listening := conn.PendingProgress[callID]
+	return listener, listening
+}
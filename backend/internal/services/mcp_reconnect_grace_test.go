@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"claraverse/internal/models"
+)
+
+func TestResumedPendingCallsMoveToNewestConnection(t *testing.T) {
+	newConn := func(id string) *models.MCPConnection {
+		return &models.MCPConnection{ClientID: id, PendingResults: make(map[string]chan models.MCPToolResult)}
+	}
+	first, second, third := newConn("first"), newConn("second"), newConn("third")
+
+	resultChan := make(chan models.MCPToolResult, 1)
+	first.PendingResults["call-1"] = resultChan
+	first.PendingProgress = map[string]models.MCPToolProgressFunc{"call-1": func(string, float64) {}}
+
+	movePendingCalls(first, second)
+	movePendingCalls(second, third)
+
+	if got, pending := pendingResult(third, "call-1"); !pending || got != resultChan {
+		t.Fatal("expected the pending call to move to the newest connection")
+	}
+	if _, listening := pendingProgress(third, "call-1"); !listening {
+		t.Error("expected the progress listener to move with the call")
+	}
+	if _, pending := pendingResult(first, "call-1"); pending {
+		t.Error("expected the call to be gone from the resumed connection")
+	}
+	if third.Successor != nil || second.Successor != third || first.Successor != second {
+		t.Error("expected only forward links from resumed connections")
+	}
+
+	// The caller still holds the connection it sent on and cleans up from there
+	removePendingResult(first, "call-1")
+	if _, pending := pendingResult(third, "call-1"); pending {
+		t.Error("expected cleanup to follow the call to the connection it moved to")
+	}
+}
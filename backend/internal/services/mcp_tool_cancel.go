@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"claraverse/internal/models"
+)
+
+// cancelMCPToolCall stops waiting for callID and, for clients that support it (protocol
+// version 4+), sends cancel_tool_call so the client aborts the tool instead of running
+// it to completion. The client answers with a cancelled tool_result, which is dropped
+// because the call is no longer pending. Returns the error for the caller.
+func (s *MCPBridgeService) cancelMCPToolCall(ctx context.Context, conn *models.MCPConnection, toolName, callID string) error {
+	removePendingResult(conn, callID)
+	log.Printf("🛑 MCP tool %s cancelled (call_id: %s)", toolName, callID)
+
+	// Only a connection still registered is worth telling
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.connections[conn.ClientID] == conn && conn.ProtocolVersion >= MCPProtocolCancel {
+		// Best effort: a client too busy to take the message will finish the call and
+		// its result is dropped
+		select {
+		case conn.WriteChan <- models.MCPServerMessage{
+			Type:    "cancel_tool_call",
+			Payload: map[string]interface{}{"call_id": callID},
+		}:
+		default:
+			log.Printf("⚠️  Could not send cancel_tool_call for %s, client %s is not reading", callID, conn.ClientID)
+		}
+	}
+	return fmt.Errorf("tool execution cancelled: %w", ctx.Err())
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+// MCPToolQueueLimit bounds how many calls may wait for a slot of one concurrency
+// limited tool on a connection; further calls fail immediately
+const MCPToolQueueLimit = 16
+
+// mcpToolSemaphore limits concurrent calls to one tool on one connection
+type mcpToolSemaphore struct {
+	slots   chan struct{}
+	waiting int
+}
+
+// mcpToolLimiter holds the semaphores of concurrency limited tools per connection
+type mcpToolLimiter struct {
+	mu   sync.Mutex
+	sems map[*models.MCPConnection]map[string]*mcpToolSemaphore
+}
+
+func newMCPToolLimiter() *mcpToolLimiter {
+	return &mcpToolLimiter{sems: make(map[*models.MCPConnection]map[string]*mcpToolSemaphore)}
+}
+
+// mcpToolMaxConcurrency returns the tool's declared concurrency limit (0 = unlimited)
+func mcpToolMaxConcurrency(conn *models.MCPConnection, toolName string) int {
+	if i := findMCPTool(conn.Tools, toolName); i >= 0 && conn.Tools[i].MaxConcurrency > 0 {
+		return conn.Tools[i].MaxConcurrency
+	}
+	return 0
+}
+
+// acquire waits up to timeout for a slot and returns the function releasing it.
+// Calls beyond MCPToolQueueLimit waiters are rejected without waiting.
+func (l *mcpToolLimiter) acquire(conn *models.MCPConnection, toolName string, limit int, timeout time.Duration) (func(), error) {
+	l.mu.Lock()
+	tools, ok := l.sems[conn]
+	if !ok {
+		tools = make(map[string]*mcpToolSemaphore)
+		l.sems[conn] = tools
+	}
+	sem, ok := tools[toolName]
+	// A changed limit takes effect for new calls; holders release into the old semaphore
+	if !ok || cap(sem.slots) != limit {
+		sem = &mcpToolSemaphore{slots: make(chan struct{}, limit)}
+		tools[toolName] = sem
+	}
+
+	select {
+	case sem.slots <- struct{}{}:
+		l.mu.Unlock()
+		return func() { <-sem.slots }, nil
+	default:
+	}
+
+	if sem.waiting >= MCPToolQueueLimit {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("tool %s is busy: %d calls already queued", toolName, sem.waiting)
+	}
+	sem.waiting++
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		sem.waiting--
+		l.mu.Unlock()
+	}()
+
+	select {
+	case sem.slots <- struct{}{}:
+		return func() { <-sem.slots }, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("tool execution timeout after %v waiting for a concurrency slot", timeout)
+	}
+}
+
+// forget drops the semaphores of a disconnected connection
+func (l *mcpToolLimiter) forget(conn *models.MCPConnection) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.sems, conn)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"claraverse/internal/models"
+)
+
+// mcpToolChanges is how a client's tool set differs from the one it registered on
+// its previous connection, reported in the registration ack so tool-set drift is
+// visible on reconnect
+type mcpToolChanges struct {
+	Added     []string // new tool names
+	Removed   []string // names the previous connection had and this one doesn't
+	Updated   []string // same name, different definition
+	Unchanged []string
+}
+
+// payload is the ack form of the changes; every list is present, possibly empty
+func (c mcpToolChanges) payload() map[string]interface{} {
+	list := func(names []string) []string {
+		if names == nil {
+			return []string{}
+		}
+		return names
+	}
+	return map[string]interface{}{
+		"added":     list(c.Added),
+		"removed":   list(c.Removed),
+		"updated":   list(c.Updated),
+		"unchanged": list(c.Unchanged),
+	}
+}
+
+// previousMCPToolDefinitions returns the tool definitions stored for the user's most
+// recent connection, keyed by name. ok is false when the user has never connected.
+func (s *MCPBridgeService) previousMCPToolDefinitions(userID string) (definitions map[string]string, ok bool, err error) {
+	var connID int64
+	err = s.db.QueryRow("SELECT id FROM mcp_connections WHERE user_id = ? ORDER BY id DESC LIMIT 1", userID).Scan(&connID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find previous connection: %w", err)
+	}
+
+	rows, err := s.db.Query("SELECT tool_name, tool_definition FROM mcp_tools WHERE user_id = ? AND connection_id = ?", userID, connID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query previous tools: %w", err)
+	}
+	defer rows.Close()
+
+	definitions = make(map[string]string)
+	for rows.Next() {
+		var name, definition string
+		if err := rows.Scan(&name, &definition); err != nil {
+			return nil, false, fmt.Errorf("failed to scan previous tool: %w", err)
+		}
+		definitions[name] = definition
+	}
+	return definitions, true, rows.Err()
+}
+
+// diffMCPTools compares a new tool set with the previous connection's stored
+// definitions. Lists are sorted by name.
+func diffMCPTools(previous map[string]string, current []models.MCPTool) mcpToolChanges {
+	var changes mcpToolChanges
+	seen := make(map[string]bool, len(current))
+	for _, tool := range current {
+		seen[tool.Name] = true
+		definition, existed := previous[tool.Name]
+		switch {
+		case !existed:
+			changes.Added = append(changes.Added, tool.Name)
+		case sameMCPToolDefinition(definition, tool):
+			changes.Unchanged = append(changes.Unchanged, tool.Name)
+		default:
+			changes.Updated = append(changes.Updated, tool.Name)
+		}
+	}
+	for name := range previous {
+		if !seen[name] {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Updated)
+	sort.Strings(changes.Unchanged)
+	return changes
+}
+
+// sameMCPToolDefinition compares a stored definition with a tool by value, since
+// the database may normalize the stored JSON
+func sameMCPToolDefinition(stored string, tool models.MCPTool) bool {
+	encoded, err := json.Marshal(tool)
+	if err != nil {
+		return false
+	}
+	var a, b interface{}
+	if json.Unmarshal([]byte(stored), &a) != nil || json.Unmarshal(encoded, &b) != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
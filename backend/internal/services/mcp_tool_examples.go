@@ -0,0 +1,65 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"claraverse/internal/models"
+)
+
+// Tool examples are appended to the description the model sees, bounded so a tool
+// with many or large examples cannot crowd out the rest of the prompt
+const (
+	MCPMaxToolExamples        = 3
+	MCPToolExampleArgsChars   = 500
+	MCPToolExampleResultChars = 200
+)
+
+// SetShowToolExamples controls whether tool examples are shown to the model. They are
+// stored with the tool either way.
+func (s *MCPBridgeService) SetShowToolExamples(show bool) {
+	s.hideToolExamples = !show
+}
+
+// appendToolExamples adds up to MCPMaxToolExamples examples to a tool description:
+//
+//	Examples:
+//	- {"path":"notes.txt"} -> "Meeting at 10am"
+func appendToolExamples(description string, examples []models.MCPToolExample) string {
+	if len(examples) == 0 {
+		return description
+	}
+
+	var b strings.Builder
+	b.WriteString(description)
+	if description != "" {
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Examples:")
+	for i, example := range examples {
+		if i == MCPMaxToolExamples {
+			break
+		}
+		args, err := json.Marshal(example.Arguments)
+		if err != nil || example.Arguments == nil {
+			args = []byte("{}")
+		}
+		b.WriteString("\n- ")
+		b.WriteString(truncateExample(string(args), MCPToolExampleArgsChars))
+		if example.Result != "" {
+			result, _ := json.Marshal(truncateExample(example.Result, MCPToolExampleResultChars))
+			b.WriteString(" -> ")
+			b.Write(result)
+		}
+	}
+	return b.String()
+}
+
+// truncateExample cuts s to at most max characters, marking the cut with "..."
+func truncateExample(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "..."
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"claraverse/internal/models"
+)
+
+func TestAppendToolExamples(t *testing.T) {
+	examples := []models.MCPToolExample{
+		{Arguments: map[string]interface{}{"path": "notes.txt"}, Result: "Meeting at 10am"},
+		{Arguments: map[string]interface{}{"path": "empty.txt"}},
+	}
+
+	got := appendToolExamples("Read a file", examples)
+	want := "Read a file\n\nExamples:\n- {\"path\":\"notes.txt\"} -> \"Meeting at 10am\"\n- {\"path\":\"empty.txt\"}"
+	if got != want {
+		t.Errorf("Unexpected description:\n%s\nwant:\n%s", got, want)
+	}
+	if appendToolExamples("Read a file", nil) != "Read a file" {
+		t.Error("Expected a description without examples to be unchanged")
+	}
+}
+
+func TestAppendToolExamplesBoundsOutput(t *testing.T) {
+	var examples []models.MCPToolExample
+	for i := 0; i < MCPMaxToolExamples+2; i++ {
+		examples = append(examples, models.MCPToolExample{Result: strings.Repeat("x", 1000)})
+	}
+
+	got := appendToolExamples("", examples)
+	if n := strings.Count(got, "\n- "); n != MCPMaxToolExamples {
+		t.Errorf("Expected %d examples, got %d", MCPMaxToolExamples, n)
+	}
+	if !strings.Contains(got, "- {} -> ") {
+		t.Errorf("Expected missing arguments to render as {}, got %q", got)
+	}
+	if strings.Contains(got, strings.Repeat("x", MCPToolExampleResultChars+1)) {
+		t.Error("Expected long results to be truncated")
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultToolLatencyWindow is the window used when none is requested
+	DefaultToolLatencyWindow = 7 * 24 * time.Hour
+	// MaxToolLatencyWindow bounds how far back latency stats may look
+	MaxToolLatencyWindow = 90 * 24 * time.Hour
+	// toolLatencyMaxSamples caps the audit rows read for one aggregation
+	toolLatencyMaxSamples = 50000
+)
+
+// LatencyPercentiles summarizes a set of durations in milliseconds
+type LatencyPercentiles struct {
+	P50Ms int64 `json:"p50_ms"`
+	P95Ms int64 `json:"p95_ms"`
+	P99Ms int64 `json:"p99_ms"`
+	MaxMs int64 `json:"max_ms"`
+}
+
+// ToolLatencyStats summarizes the latency of one MCP tool from the audit log.
+// Latency is the round trip seen by the server; ToolTime is what the client reported
+// the tool itself took, and Overhead the difference (network, queueing and transfer).
+type ToolLatencyStats struct {
+	ToolName string             `json:"tool_name"`
+	Calls    int                `json:"calls"`
+	Latency  LatencyPercentiles `json:"latency"`
+	// Only set when the client reported tool run times (bridge versions that send duration_ms)
+	ToolTime *LatencyPercentiles `json:"tool_time,omitempty"`
+	Overhead *LatencyPercentiles `json:"overhead,omitempty"`
+}
+
+// toolLatencySample is one audited call's timings
+type toolLatencySample struct {
+	latencyMs  int64
+	toolTimeMs int64 // 0 when not reported
+}
+
+// GetToolLatencyStats returns latency percentiles per tool for the user's calls within window
+func (s *MCPBridgeService) GetToolLatencyStats(userID string, window time.Duration) ([]*ToolLatencyStats, error) {
+	rows, err := s.db.Query(`
+		SELECT tool_name, execution_time_ms, tool_time_ms
+		FROM mcp_audit_log
+		WHERE user_id = ? AND tool_name <> '' AND execution_time_ms IS NOT NULL AND executed_at >= ?
+		ORDER BY executed_at DESC
+		LIMIT ?
+	`, userID, time.Now().Add(-window), toolLatencyMaxSamples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool latency: %w", err)
+	}
+	defer rows.Close()
+
+	samples := make(map[string][]toolLatencySample)
+	for rows.Next() {
+		var toolName string
+		var latencyMs int64
+		var toolTimeMs sql.NullInt64
+		if err := rows.Scan(&toolName, &latencyMs, &toolTimeMs); err != nil {
+			return nil, fmt.Errorf("failed to scan tool latency: %w", err)
+		}
+		samples[toolName] = append(samples[toolName], toolLatencySample{latencyMs: latencyMs, toolTimeMs: toolTimeMs.Int64})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tool latency: %w", err)
+	}
+
+	stats := make([]*ToolLatencyStats, 0, len(samples))
+	for toolName, toolSamples := range samples {
+		stats = append(stats, newToolLatencyStats(toolName, toolSamples))
+	}
+	// Slowest tools first
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Latency.P95Ms != stats[j].Latency.P95Ms {
+			return stats[i].Latency.P95Ms > stats[j].Latency.P95Ms
+		}
+		return stats[i].ToolName < stats[j].ToolName
+	})
+	return stats, nil
+}
+
+func newToolLatencyStats(toolName string, samples []toolLatencySample) *ToolLatencyStats {
+	latencies := make([]int64, 0, len(samples))
+	var toolTimes, overheads []int64
+	for _, sample := range samples {
+		latencies = append(latencies, sample.latencyMs)
+		if sample.toolTimeMs > 0 {
+			toolTimes = append(toolTimes, sample.toolTimeMs)
+			overheads = append(overheads, max(sample.latencyMs-sample.toolTimeMs, 0))
+		}
+	}
+
+	stats := &ToolLatencyStats{
+		ToolName: toolName,
+		Calls:    len(samples),
+		Latency:  latencyPercentiles(latencies),
+	}
+	if len(toolTimes) > 0 {
+		toolTime := latencyPercentiles(toolTimes)
+		overhead := latencyPercentiles(overheads)
+		stats.ToolTime = &toolTime
+		stats.Overhead = &overhead
+	}
+	return stats
+}
+
+// latencyPercentiles computes nearest-rank percentiles; values is sorted in place
+func latencyPercentiles(values []int64) LatencyPercentiles {
+	if len(values) == 0 {
+		return LatencyPercentiles{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	rank := func(p float64) int64 {
+		i := int(math.Ceil(p*float64(len(values)))) - 1
+		return values[max(i, 0)]
+	}
+	return LatencyPercentiles{
+		P50Ms: rank(0.50),
+		P95Ms: rank(0.95),
+		P99Ms: rank(0.99),
+		MaxMs: values[len(values)-1],
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import "testing"
+
+func TestNewToolLatencyStats(t *testing.T) {
+	var samples []toolLatencySample
+	for i := int64(1); i <= 100; i++ {
+		sample := toolLatencySample{latencyMs: i * 10}
+		if i%2 == 0 {
+			sample.toolTimeMs = i*10 - 5 // 5ms of network overhead
+		}
+		samples = append(samples, sample)
+	}
+
+	stats := newToolLatencyStats("read_file", samples)
+	if stats.Calls != 100 {
+		t.Errorf("Expected 100 calls, got %d", stats.Calls)
+	}
+	want := LatencyPercentiles{P50Ms: 500, P95Ms: 950, P99Ms: 990, MaxMs: 1000}
+	if stats.Latency != want {
+		t.Errorf("Latency = %+v, want %+v", stats.Latency, want)
+	}
+	if stats.ToolTime == nil || stats.ToolTime.MaxMs != 995 {
+		t.Errorf("Unexpected tool time %+v", stats.ToolTime)
+	}
+	if stats.Overhead == nil || *stats.Overhead != (LatencyPercentiles{P50Ms: 5, P95Ms: 5, P99Ms: 5, MaxMs: 5}) {
+		t.Errorf("Unexpected overhead %+v", stats.Overhead)
+	}
+
+	// Old clients don't report tool time
+	stats = newToolLatencyStats("legacy", []toolLatencySample{{latencyMs: 42}})
+	if stats.Latency.P99Ms != 42 || stats.ToolTime != nil || stats.Overhead != nil {
+		t.Errorf("Unexpected stats for a single unreported sample: %+v", stats)
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultMCPMaxToolsPerClient caps the tools one client may register, whatever the
+// user's tier allows
+const DefaultMCPMaxToolsPerClient = 1000
+
+// ErrMCPTooManyTools is returned when a registration or tool change would take a
+// client past its tool limit
+var ErrMCPTooManyTools = errors.New("too many MCP tools")
+
+// SetTierService enables the per-tier tool limit (TierLimits.MaxMCPTools)
+func (s *MCPBridgeService) SetTierService(tierService *TierService) {
+	s.tierService = tierService
+}
+
+// SetMaxToolsPerClient sets the hard cap on tools per client (0 disables it)
+func (s *MCPBridgeService) SetMaxToolsPerClient(max int) {
+	s.maxToolsPerClient = max
+}
+
+// maxToolsFor returns the number of tools the user's client may register: the lower
+// of the hard cap and the tier limit, or -1 when neither applies
+func (s *MCPBridgeService) maxToolsFor(userID string) int {
+	limit := -1
+	if s.maxToolsPerClient > 0 {
+		limit = s.maxToolsPerClient
+	}
+	if s.tierService != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if tierMax := s.tierService.GetLimits(ctx, userID).MaxMCPTools; tierMax > 0 && (limit < 0 || tierMax < limit) {
+			limit = tierMax
+		}
+	}
+	return limit
+}
+
+// checkMCPToolLimit rejects a tool set of count tools over limit (-1 = unlimited)
+func checkMCPToolLimit(count, limit int) error {
+	if limit >= 0 && count > limit {
+		return fmt.Errorf("%w: %d tools exceeds the limit of %d for this account; disable some MCP servers or tools", ErrMCPTooManyTools, count, limit)
+	}
+	return nil
+}
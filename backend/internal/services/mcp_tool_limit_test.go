@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckMCPToolLimit(t *testing.T) {
+	if err := checkMCPToolLimit(50, 50); err != nil {
+		t.Errorf("Expected a set at the limit to pass, got %v", err)
+	}
+	if err := checkMCPToolLimit(51, 50); !errors.Is(err, ErrMCPTooManyTools) {
+		t.Errorf("Expected ErrMCPTooManyTools, got %v", err)
+	}
+	if err := checkMCPToolLimit(10000, -1); err != nil {
+		t.Errorf("Expected no limit at -1, got %v", err)
+	}
+}
+
+func TestMaxToolsForWithoutTierService(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	if got := service.maxToolsFor("user-1"); got != DefaultMCPMaxToolsPerClient {
+		t.Errorf("Expected the hard cap %d, got %d", DefaultMCPMaxToolsPerClient, got)
+	}
+
+	service.SetMaxToolsPerClient(0)
+	if got := service.maxToolsFor("user-1"); got != -1 {
+		t.Errorf("Expected no limit, got %d", got)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+// MCP tool output validation. Tools may declare an output schema at registration
+// (MCPTool.OutputSchema); successful, untruncated results of those tools are
+// checked against it. What happens on a mismatch depends on the mode:
+//
+//   - off: results are not checked
+//   - warn: the result is passed on with a validation warning the model can see
+//   - retry: read-only tools are re-run once first; other tools are warned about
+const (
+	MCPOutputValidationOff   = "off"
+	MCPOutputValidationWarn  = "warn"
+	MCPOutputValidationRetry = "retry"
+)
+
+// SetOutputValidation sets how results are checked against declared output schemas
+func (s *MCPBridgeService) SetOutputValidation(mode string) error {
+	switch mode {
+	case "":
+		s.outputValidation = MCPOutputValidationWarn
+	case MCPOutputValidationOff, MCPOutputValidationWarn, MCPOutputValidationRetry:
+		s.outputValidation = mode
+	default:
+		return fmt.Errorf("unknown output validation mode %q (want off, warn or retry)", mode)
+	}
+	return nil
+}
+
+// checkToolOutput validates a result against the tool's declared output schema,
+// re-running or annotating it on a mismatch
+func (s *MCPBridgeService) checkToolOutput(ctx context.Context, userID, toolName string, args map[string]interface{}, timeout time.Duration, result models.MCPToolResult) models.MCPToolResult {
+	if s.outputValidation == MCPOutputValidationOff || !result.Success || result.Truncated || result.Incomplete {
+		return result
+	}
+	schema, readOnly := s.toolOutputSchema(userID, toolName)
+	if schema == nil {
+		return result
+	}
+
+	err := validateMCPToolOutput(result.Result, schema)
+	if err == nil {
+		return result
+	}
+	log.Printf("⚠️  MCP tool %s returned output not matching its schema: %v", toolName, err)
+
+	if s.outputValidation == MCPOutputValidationRetry && readOnly {
+		retried, retryErr := s.executeToolOnClient(ctx, userID, toolName, args, timeout)
+		if retryErr == nil && retried.Success && !retried.Truncated {
+			if err = validateMCPToolOutput(retried.Result, schema); err == nil {
+				log.Printf("✅ MCP tool %s output matched its schema on retry", toolName)
+				return retried
+			}
+			result = retried
+		}
+	}
+
+	result.Result = fmt.Sprintf("%s\n\n[Output validation warning: the result does not match the tool's declared output schema: %v]",
+		result.Result, err)
+	return result
+}
+
+// toolOutputSchema returns the user's tool's declared output schema (nil if none)
+// and whether the tool is read-only
+func (s *MCPBridgeService) toolOutputSchema(userID, toolName string) (map[string]interface{}, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conn, exists := s.connections[s.userConns[userID]]
+	if !exists {
+		return nil, false
+	}
+	i := findMCPTool(conn.Tools, toolName)
+	if i < 0 || len(conn.Tools[i].OutputSchema) == 0 {
+		return nil, false
+	}
+	return conn.Tools[i].OutputSchema, conn.Tools[i].ReadOnly
+}
+
+// validateMCPToolOutput checks a JSON result against a schema. It covers the parts
+// of JSON Schema tools use to describe results: type, enum, properties, required
+// and items.
+func validateMCPToolOutput(output string, schema map[string]interface{}) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(output), &value); err != nil {
+		return fmt.Errorf("result is not valid JSON")
+	}
+	return validateJSONValue(value, schema, "result")
+}
+
+// validateJSONValue checks value against schema; path names the value in errors
+func validateJSONValue(value interface{}, schema map[string]interface{}, path string) error {
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesJSONType(value, types) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeName(value))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of the allowed values", path, value)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if key, ok := name.(string); ok {
+					if _, exists := v[key]; !exists {
+						return fmt.Errorf("%s: missing required field %q", path, key)
+					}
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(properties))
+		for key := range properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := properties[key].(map[string]interface{})
+			field, exists := v[key]
+			if !ok || !exists {
+				continue
+			}
+			if err := validateJSONValue(field, property, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateJSONValue(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns a schema's "type", which may be a string or a list of strings
+func schemaTypes(raw interface{}) []string {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, entry := range t {
+			if name, ok := entry.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchesJSONType reports whether a decoded JSON value is of one of the types
+func matchesJSONType(value interface{}, types []string) bool {
+	actual := jsonTypeName(value)
+	for _, want := range types {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeName is the JSON Schema type of a decoded JSON value
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
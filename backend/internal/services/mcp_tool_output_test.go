@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+var weatherOutputSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"city":  map[string]interface{}{"type": "string"},
+		"temp":  map[string]interface{}{"type": "number"},
+		"units": map[string]interface{}{"type": "string", "enum": []interface{}{"C", "F"}},
+		"days":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+	},
+	"required": []interface{}{"city", "temp"},
+}
+
+func TestValidateMCPToolOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		wantErr string
+	}{
+		{"valid", `{"city": "Oslo", "temp": 4.5, "units": "C", "days": [1, 2]}`, ""},
+		{"integer is a number", `{"city": "Oslo", "temp": 4}`, ""},
+		{"not JSON", `sunny`, "not valid JSON"},
+		{"missing required", `{"city": "Oslo"}`, `missing required field "temp"`},
+		{"wrong type", `{"city": "Oslo", "temp": "warm"}`, "result.temp: expected number, got string"},
+		{"not in enum", `{"city": "Oslo", "temp": 4, "units": "K"}`, "result.units: K is not one of"},
+		{"array item", `{"city": "Oslo", "temp": 4, "days": [1, 2.5]}`, "result.days[1]: expected integer"},
+		{"wrong root", `[1, 2]`, "result: expected object, got array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMCPToolOutput(tt.output, weatherOutputSchema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckToolOutputWarnsOnMismatch(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	conn := newRetryTestConnection(models.MCPTool{Name: "weather", ReadOnly: true, OutputSchema: weatherOutputSchema})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	result := service.checkToolOutput(context.Background(), conn.UserID, "weather", nil, time.Second,
+		models.MCPToolResult{Success: true, Result: `{"city": "Oslo"}`})
+	if !strings.Contains(result.Result, "[Output validation warning:") {
+		t.Errorf("Expected a validation warning, got %q", result.Result)
+	}
+	if len(conn.WriteChan) != 0 {
+		t.Errorf("Expected no retry in warn mode, got %d dispatched calls", len(conn.WriteChan))
+	}
+
+	valid := `{"city": "Oslo", "temp": 4}`
+	if result := service.checkToolOutput(context.Background(), conn.UserID, "weather", nil, time.Second,
+		models.MCPToolResult{Success: true, Result: valid}); result.Result != valid {
+		t.Errorf("Expected a valid result to pass unchanged, got %q", result.Result)
+	}
+
+	if err := service.SetOutputValidation(MCPOutputValidationOff); err != nil {
+		t.Fatalf("SetOutputValidation failed: %v", err)
+	}
+	if result := service.checkToolOutput(context.Background(), conn.UserID, "weather", nil, time.Second,
+		models.MCPToolResult{Success: true, Result: "sunny"}); result.Result != "sunny" {
+		t.Errorf("Expected no validation when off, got %q", result.Result)
+	}
+
+	if err := service.SetOutputValidation("strict"); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
+
+func TestCheckToolOutputRetriesReadOnlyTool(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	if err := service.SetOutputValidation(MCPOutputValidationRetry); err != nil {
+		t.Fatalf("SetOutputValidation failed: %v", err)
+	}
+	conn := newRetryTestConnection(models.MCPTool{Name: "weather", ReadOnly: true, OutputSchema: weatherOutputSchema})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	// Answer the re-run with a valid result
+	go func() {
+		msg := <-conn.WriteChan
+		callID, _ := msg.Payload["call_id"].(string)
+		conn.PendingMu.Lock()
+		resultChan := conn.PendingResults[callID]
+		conn.PendingMu.Unlock()
+		resultChan <- models.MCPToolResult{CallID: callID, Success: true, Result: `{"city": "Oslo", "temp": 4}`}
+	}()
+
+	result := service.checkToolOutput(context.Background(), conn.UserID, "weather", nil, time.Second,
+		models.MCPToolResult{Success: true, Result: `{"city": "Oslo"}`})
+	if result.Result != `{"city": "Oslo", "temp": 4}` {
+		t.Errorf("Expected the re-run's valid result, got %q", result.Result)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"claraverse/internal/models"
+)
+
+// mcpToolProgressMinInterval throttles progress updates per tool call so a tool
+// reporting every item does not flood the caller. Completion (100%) is always passed on.
+const mcpToolProgressMinInterval = 250 * time.Millisecond
+
+// mcpToolProgressMaxMessage caps progress messages, in bytes, before they reach callers
+const mcpToolProgressMaxMessage = 500
+
+// mcpToolProgressKey is the context key for a tool call's progress listener
+type mcpToolProgressKey struct{}
+
+// WithMCPToolProgress returns a context whose MCP tool calls report the client's
+// tool_progress updates (protocol version 5+) to fn while they run
+func WithMCPToolProgress(ctx context.Context, fn models.MCPToolProgressFunc) context.Context {
+	return context.WithValue(ctx, mcpToolProgressKey{}, fn)
+}
+
+// mcpToolProgressListener returns the throttled progress listener for a call made
+// with ctx, or nil if the caller is not listening
+func mcpToolProgressListener(ctx context.Context) models.MCPToolProgressFunc {
+	fn, ok := ctx.Value(mcpToolProgressKey{}).(models.MCPToolProgressFunc)
+	if !ok || fn == nil {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var lastSent time.Time
+	return func(message string, percent float64) {
+		mu.Lock()
+		now := time.Now()
+		if percent < 100 && now.Sub(lastSent) < mcpToolProgressMinInterval {
+			mu.Unlock()
+			return
+		}
+		lastSent = now
+		mu.Unlock()
+		fn(message, percent)
+	}
+}
+
+// DeliverToolProgress passes a progress update received from a client to the caller
+// of the tool call. It returns false if no one is listening (unknown or finished
+// call, or a caller that did not ask for progress).
+func (s *MCPBridgeService) DeliverToolProgress(clientID string, progress models.MCPToolProgress) bool {
+	conn, exists := s.GetConnection(clientID)
+	if !exists {
+		return false
+	}
+	listener, listening := pendingProgress(conn, progress.CallID)
+	if !listening {
+		return false
+	}
+
+	percent := -1.0
+	if progress.Percent != nil && !math.IsNaN(*progress.Percent) {
+		percent = math.Max(0, math.Min(*progress.Percent, 100))
+	}
+	message := progress.Message
+	if len(message) > mcpToolProgressMaxMessage {
+		cut := mcpToolProgressMaxMessage
+		for cut > 0 && !utf8.RuneStart(message[cut]) {
+			cut--
+		}
+		message = message[:cut]
+	}
+	listener(message, percent)
+	return true
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// ToolReliabilityWindow is how far back the audit log is aggregated
+	ToolReliabilityWindow = 30 * 24 * time.Hour
+	// ToolReliabilityMinSamples is the minimum number of calls before a tool's
+	// reliability is shown to the model
+	ToolReliabilityMinSamples = 5
+)
+
+// ToolReliability summarizes a tool's recent success rate from the MCP audit log
+type ToolReliability struct {
+	ToolName    string  `json:"tool_name"`
+	Total       int     `json:"total"`
+	Successes   int     `json:"successes"`
+	Failures    int     `json:"failures"`
+	SuccessRate float64 `json:"success_rate"` // 0.0 - 1.0
+}
+
+// Annotation returns a short description suffix like "reliability: 72%",
+// or "" if there are too few samples to be meaningful
+func (r *ToolReliability) Annotation() string {
+	if r == nil || r.Total < ToolReliabilityMinSamples {
+		return ""
+	}
+	return fmt.Sprintf("reliability: %.0f%%", r.SuccessRate*100)
+}
+
+func newToolReliability(toolName string, total, successes int) *ToolReliability {
+	r := &ToolReliability{
+		ToolName:  toolName,
+		Total:     total,
+		Successes: successes,
+		Failures:  total - successes,
+	}
+	if total > 0 {
+		r.SuccessRate = float64(successes) / float64(total)
+	}
+	return r
+}
+
+// GetToolReliability returns the success rate of one tool for a user over ToolReliabilityWindow
+func (s *MCPBridgeService) GetToolReliability(userID, toolName string) (*ToolReliability, error) {
+	var total, successes int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0)
+		FROM mcp_audit_log
+		WHERE user_id = ? AND tool_name = ? AND executed_at >= ?
+	`, userID, toolName, time.Now().Add(-ToolReliabilityWindow)).Scan(&total, &successes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool reliability: %w", err)
+	}
+
+	return newToolReliability(toolName, total, successes), nil
+}
+
+// GetUserToolReliability returns success rates for every tool a user has called, keyed by tool name
+func (s *MCPBridgeService) GetUserToolReliability(userID string) (map[string]*ToolReliability, error) {
+	rows, err := s.db.Query(`
+		SELECT tool_name, COUNT(*), COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0)
+		FROM mcp_audit_log
+		WHERE user_id = ? AND tool_name <> '' AND executed_at >= ?
+		GROUP BY tool_name
+	`, userID, time.Now().Add(-ToolReliabilityWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool reliability: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*ToolReliability)
+	for rows.Next() {
+		var toolName string
+		var total, successes int
+		if err := rows.Scan(&toolName, &total, &successes); err != nil {
+			return nil, fmt.Errorf("failed to scan tool reliability: %w", err)
+		}
+		stats[toolName] = newToolReliability(toolName, total, successes)
+	}
+	return stats, rows.Err()
+}
+
+// annotateToolDescription appends the tool's reliability to its description when known
+func annotateToolDescription(description string, reliability *ToolReliability) string {
+	annotation := reliability.Annotation()
+	if annotation == "" {
+		return description
+	}
+	if description == "" {
+		return "(" + annotation + ")"
+	}
+	return description + " (" + annotation + ")"
+}
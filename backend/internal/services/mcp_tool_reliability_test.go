@@ -0,0 +1,29 @@
+package services
+
+import "testing"
+
+func TestToolReliabilityAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		reliability *ToolReliability
+		want        string
+	}{
+		{"no data", nil, "List files"},
+		{"too few samples", newToolReliability("ls", ToolReliabilityMinSamples-1, 0), "List files"},
+		{"enough samples", newToolReliability("ls", 25, 18), "List files (reliability: 72%)"},
+		{"always fails", newToolReliability("ls", 10, 0), "List files (reliability: 0%)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := annotateToolDescription("List files", tt.reliability); got != tt.want {
+				t.Errorf("annotateToolDescription() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	r := newToolReliability("ls", 4, 3)
+	if r.Failures != 1 || r.SuccessRate != 0.75 {
+		t.Errorf("Unexpected stats: failures=%d rate=%v", r.Failures, r.SuccessRate)
+	}
+}
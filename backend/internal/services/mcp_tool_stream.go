@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"claraverse/internal/models"
+)
+
+// mcpToolOutputKey is the context key for a tool call's streamed output listener
+type mcpToolOutputKey struct{}
+
+// MCPToolOutputFunc receives each part of a streamed tool call's output as it arrives
+type MCPToolOutputFunc func(content string)
+
+// WithMCPToolOutput returns a context whose MCP tool calls pass each part of their
+// streamed output (tools registered with streams_results, protocol version 6+) to fn
+// while they run. The call's result is still the whole output.
+func WithMCPToolOutput(ctx context.Context, fn MCPToolOutputFunc) context.Context {
+	return context.WithValue(ctx, mcpToolOutputKey{}, fn)
+}
+
+// mcpToolOutputStream collects a streamed call's output, up to maxBytes (0 = no limit)
+type mcpToolOutputStream struct {
+	mu       sync.Mutex
+	output   strings.Builder
+	size     int // bytes received, including any past maxBytes
+	maxBytes int
+	listener MCPToolOutputFunc
+}
+
+// Append adds the next part of the output and passes it on to the caller's listener
+func (st *mcpToolOutputStream) Append(content string) {
+	st.mu.Lock()
+	st.size += len(content)
+	if room := st.maxBytes - st.output.Len(); st.maxBytes <= 0 || len(content) <= room {
+		st.output.WriteString(content)
+	} else if room > 0 {
+		cut := room
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		st.output.WriteString(content[:cut])
+	}
+	st.mu.Unlock()
+
+	if st.listener != nil && content != "" {
+		st.listener(content)
+	}
+}
+
+// Result is the output received so far as a successful result, marked truncated if
+// parts were dropped for size
+func (st *mcpToolOutputStream) Result(callID string) models.MCPToolResult {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	result := models.MCPToolResult{CallID: callID, Success: true, Result: st.output.String()}
+	if st.size > st.output.Len() {
+		result.Truncated = true
+		result.OriginalSize = st.size
+	}
+	return result
+}
+
+// received reports whether any output has arrived
+func (st *mcpToolOutputStream) received() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.size > 0
+}
+
+// mcpToolStreamsResults reports whether calls to toolName stream their output: the
+// client opted the tool in and speaks a protocol version with partial results
+func mcpToolStreamsResults(conn *models.MCPConnection, toolName string) bool {
+	if conn.ProtocolVersion < MCPProtocolPartialResults {
+		return false
+	}
+	i := findMCPTool(conn.Tools, toolName)
+	return i >= 0 && conn.Tools[i].StreamsResults
+}
+
+// newMCPToolOutputStream returns the output stream for a call made with ctx
+func (s *MCPBridgeService) newMCPToolOutputStream(ctx context.Context) *mcpToolOutputStream {
+	listener, _ := ctx.Value(mcpToolOutputKey{}).(MCPToolOutputFunc)
+	return &mcpToolOutputStream{maxBytes: s.maxResultBytes, listener: listener}
+}
+
+// incompleteResult is the output a timed-out streamed call produced, so the caller
+// gets what the tool sent instead of only the timeout. ok is false without output.
+func (st *mcpToolOutputStream) incompleteResult(callID string) (result models.MCPToolResult, ok bool) {
+	if st == nil || !st.received() {
+		return models.MCPToolResult{}, false
+	}
+	result = st.Result(callID)
+	result.Incomplete = true
+	return result, true
+}
+
+// pendingStream finds the output stream of callID on conn
+func pendingStream(conn *models.MCPConnection, callID string) (models.MCPToolOutputStream, bool) {
+	conn.PendingMu.Lock()
+	defer conn.PendingMu.Unlock()
+	stream, streaming := conn.PendingStreams[callID]
+	return stream, streaming
+}
+
+// DeliverToolResultPartial appends a part of a streamed call's output received from
+// a client. The final part completes the call with all output received. It returns
+// false if no call is streaming under that call ID.
+func (s *MCPBridgeService) DeliverToolResultPartial(clientID string, partial models.MCPToolResultPartial) bool {
+	conn, exists := s.GetConnection(clientID)
+	if !exists {
+		return false
+	}
+	stream, streaming := pendingStream(conn, partial.CallID)
+	if !streaming {
+		return false
+	}
+
+	stream.Append(partial.Content)
+	if !partial.Final {
+		return true
+	}
+	result := stream.Result(partial.CallID)
+	result.DurationMs = partial.DurationMs
+	return s.DeliverToolResult(clientID, result)
+}
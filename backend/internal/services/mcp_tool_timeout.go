@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+// MCP tool call timeouts. A call's budget comes from the first layer that sets one:
+//
+//  1. the caller (ExecuteToolOnClient with a timeout > 0)
+//  2. the tool, as declared by the client at registration (MCPTool.Timeout)
+//  3. the service default (SetDefaultToolTimeout)
+//
+// and is then clamped to the service maximum (SetMaxToolTimeout). The budget covers
+// waiting for a concurrency slot, the client's run and any timeout retry. Queueing the
+// call for the client is bounded separately by the send timeout (SetSendTimeout),
+// which only fires when the client's connection is backed up; the call then fails
+// with ErrMCPClientBusy without having run.
+const (
+	DefaultMCPToolTimeout     = 30 * time.Second
+	DefaultMCPMaxToolTimeout  = 5 * time.Minute
+	DefaultMCPToolSendTimeout = 5 * time.Second
+)
+
+// ErrMCPClientBusy is returned when a tool call could not be queued for the client
+// within the send timeout. The tool did not run, unlike a tool that ran and failed.
+var ErrMCPClientBusy = errors.New("MCP client is busy")
+
+// Where a call's timeout came from
+const (
+	MCPTimeoutSourceCaller  = "caller"
+	MCPTimeoutSourceTool    = "tool"
+	MCPTimeoutSourceDefault = "default"
+)
+
+// mcpToolTimeout is a resolved call budget and the layer that set it
+type mcpToolTimeout struct {
+	timeout time.Duration
+	source  string
+	clamped bool // Reduced to the service maximum
+}
+
+// String describes the budget for logs and errors, e.g. "30s (default timeout)"
+func (t mcpToolTimeout) String() string {
+	if t.clamped {
+		return fmt.Sprintf("%v (%s timeout, clamped to max)", t.timeout, t.source)
+	}
+	return fmt.Sprintf("%v (%s timeout)", t.timeout, t.source)
+}
+
+// resolveMCPToolTimeout applies the timeout hierarchy. toolTimeoutSeconds is the
+// tool's declared timeout (0 if none); a maxTimeout <= 0 disables clamping.
+func resolveMCPToolTimeout(callerTimeout time.Duration, toolTimeoutSeconds int, defaultTimeout, maxTimeout time.Duration) mcpToolTimeout {
+	var resolved mcpToolTimeout
+	switch {
+	case callerTimeout > 0:
+		resolved = mcpToolTimeout{timeout: callerTimeout, source: MCPTimeoutSourceCaller}
+	case toolTimeoutSeconds > 0:
+		resolved = mcpToolTimeout{timeout: time.Duration(toolTimeoutSeconds) * time.Second, source: MCPTimeoutSourceTool}
+	default:
+		resolved = mcpToolTimeout{timeout: defaultTimeout, source: MCPTimeoutSourceDefault}
+	}
+
+	if maxTimeout > 0 && resolved.timeout > maxTimeout {
+		resolved.timeout = maxTimeout
+		resolved.clamped = true
+	}
+	return resolved
+}
+
+// mcpToolDeclaredTimeout returns the timeout in seconds the client declared for toolName (0 if none)
+func mcpToolDeclaredTimeout(conn *models.MCPConnection, toolName string) int {
+	if i := findMCPTool(conn.Tools, toolName); i >= 0 && conn.Tools[i].Timeout > 0 {
+		return conn.Tools[i].Timeout
+	}
+	return 0
+}
+
+// SetDefaultToolTimeout sets the timeout for calls whose caller and tool set none
+func (s *MCPBridgeService) SetDefaultToolTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.defaultToolTimeout = timeout
+	}
+}
+
+// SetSendTimeout sets how long a call may wait to be queued for a busy client
+func (s *MCPBridgeService) SetSendTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.sendTimeout = timeout
+	}
+}
+
+// SetMaxToolTimeout sets the ceiling every call's timeout is clamped to
+func (s *MCPBridgeService) SetMaxToolTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.maxToolTimeout = timeout
+	}
+}
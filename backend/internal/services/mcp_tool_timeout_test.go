@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"claraverse/internal/models"
+)
+
+func TestResolveMCPToolTimeoutPrecedence(t *testing.T) {
+	tests := []struct {
+		name        string
+		caller      time.Duration
+		toolSeconds int
+		max         time.Duration
+		want        time.Duration
+		wantSource  string
+		wantClamped bool
+	}{
+		{name: "caller wins over tool", caller: 10 * time.Second, toolSeconds: 60, max: 5 * time.Minute, want: 10 * time.Second, wantSource: MCPTimeoutSourceCaller},
+		{name: "tool wins over default", toolSeconds: 60, max: 5 * time.Minute, want: 60 * time.Second, wantSource: MCPTimeoutSourceTool},
+		{name: "default when neither is set", max: 5 * time.Minute, want: 30 * time.Second, wantSource: MCPTimeoutSourceDefault},
+		{name: "caller clamped to max", caller: time.Hour, max: 5 * time.Minute, want: 5 * time.Minute, wantSource: MCPTimeoutSourceCaller, wantClamped: true},
+		{name: "tool clamped to max", toolSeconds: 3600, max: 5 * time.Minute, want: 5 * time.Minute, wantSource: MCPTimeoutSourceTool, wantClamped: true},
+		{name: "no max disables clamping", toolSeconds: 3600, want: time.Hour, wantSource: MCPTimeoutSourceTool},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveMCPToolTimeout(tt.caller, tt.toolSeconds, 30*time.Second, tt.max)
+			if got.timeout != tt.want || got.source != tt.wantSource || got.clamped != tt.wantClamped {
+				t.Errorf("Expected %v from %s (clamped=%v), got %v from %s (clamped=%v)",
+					tt.want, tt.wantSource, tt.wantClamped, got.timeout, got.source, got.clamped)
+			}
+		})
+	}
+}
+
+func TestMCPToolTimeoutString(t *testing.T) {
+	if got := (mcpToolTimeout{timeout: 30 * time.Second, source: MCPTimeoutSourceDefault}).String(); got != "30s (default timeout)" {
+		t.Errorf("Unexpected description: %q", got)
+	}
+	if got := (mcpToolTimeout{timeout: time.Minute, source: MCPTimeoutSourceTool, clamped: true}).String(); got != "1m0s (tool timeout, clamped to max)" {
+		t.Errorf("Unexpected description: %q", got)
+	}
+}
+
+func TestExecuteToolOnClientUsesDeclaredToolTimeout(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	service.SetMaxToolTimeout(200 * time.Millisecond)
+	conn := newRetryTestConnection(models.MCPTool{Name: "slow_query", Timeout: 60})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	start := time.Now()
+	_, err := service.ExecuteToolOnClient(context.Background(), conn.UserID, "slow_query", map[string]interface{}{}, 0)
+	if err == nil || !strings.Contains(err.Error(), "(tool timeout, clamped to max)") {
+		t.Fatalf("Expected the clamped tool timeout to fire, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the call to stop at the max timeout, took %v", elapsed)
+	}
+}
+
+func TestExecuteToolOnClientCallerTimeoutOverridesTool(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	conn := newRetryTestConnection(models.MCPTool{Name: "slow_query", Timeout: 60})
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	_, err := service.ExecuteToolOnClient(context.Background(), conn.UserID, "slow_query", map[string]interface{}{}, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "100ms (caller timeout)") {
+		t.Fatalf("Expected the caller timeout to fire, got %v", err)
+	}
+}
+
+func TestExecuteToolOnClientBusyClient(t *testing.T) {
+	service := NewMCPBridgeService(nil, nil)
+	service.SetSendTimeout(50 * time.Millisecond)
+	conn := newRetryTestConnection(models.MCPTool{Name: "read_file"})
+	conn.WriteChan = make(chan models.MCPServerMessage) // Nobody reading
+	service.connections[conn.ClientID] = conn
+	service.userConns[conn.UserID] = conn.ClientID
+
+	start := time.Now()
+	_, err := service.ExecuteToolOnClient(context.Background(), conn.UserID, "read_file", map[string]interface{}{}, time.Second)
+	if !errors.Is(err, ErrMCPClientBusy) {
+		t.Fatalf("Expected ErrMCPClientBusy, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the send timeout rather than the call timeout to fire, took %v", elapsed)
+	}
+	if len(conn.PendingResults) != 0 {
+		t.Errorf("Expected no pending result left behind, got %d", len(conn.PendingResults))
+	}
+}
+
+func TestSendMCPToolCallAfterDisconnect(t *testing.T) {
+	conn := newRetryTestConnection(models.MCPTool{Name: "read_file"})
+	conn.StopChan = make(chan bool, 1)
+	// The watchdog reaps the client after the call looked the connection up
+	close(conn.StopChan)
+
+	_, _, err := sendMCPToolCall(conn, "read_file", map[string]interface{}{}, time.Second, time.Second, nil, nil)
+	if !errors.Is(err, ErrMCPConnectionNotFound) {
+		t.Fatalf("Expected ErrMCPConnectionNotFound, got %v", err)
+	}
+	if len(conn.WriteChan) != 0 || len(conn.PendingResults) != 0 {
+		t.Errorf("Expected nothing queued or pending, got %d queued and %d pending", len(conn.WriteChan), len(conn.PendingResults))
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"claraverse/internal/crypto"
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -202,6 +203,18 @@ func (s *MemoryExtractionService) EnqueueExtraction(
 	return nil
 }
 
+// DeleteJobsByUser deletes a user's extraction jobs, so queued conversations are
+// not turned into memories after the user has deleted theirs
+func (s *MemoryExtractionService) DeleteJobsByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := s.jobCollection.DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete extraction jobs: %w", err)
+	}
+
+	log.Printf("🗑️ [MEMORY-EXTRACTION] Deleted %d extraction jobs for user %s", result.DeletedCount, userID)
+	return result.DeletedCount, nil
+}
+
 // ProcessPendingJobs processes all pending extraction jobs (background worker)
 func (s *MemoryExtractionService) ProcessPendingJobs(ctx context.Context) error {
 	// Find pending jobs
@@ -325,8 +338,8 @@ func (s *MemoryExtractionService) extractMemories(
 		extractorModelID = userPreferredModel
 		log.Printf("👤 [MEMORY-EXTRACTION] Using user-preferred model: %s", extractorModelID)
 	} else {
-		// No user preference, get from model pool
-		extractorModelID, err = s.modelPool.GetNextExtractor()
+		// No user preference, get from model pool, preferring models tagged for this content
+		extractorModelID, err = s.modelPool.GetNextExtractorFor(memoryContentHint(messages))
 		if err != nil {
 			return nil, fmt.Errorf("no extractor models available: %w", err)
 		}
@@ -347,7 +360,7 @@ func (s *MemoryExtractionService) extractMemories(
 
 		// Extraction failed
 		lastError = err
-		s.modelPool.MarkFailure(extractorModelID)
+		s.modelPool.MarkFailure(extractorModelID, err)
 		log.Printf("⚠️ [MEMORY-EXTRACTION] Attempt %d/%d failed with model %s: %v",
 			attempt, maxAttempts, extractorModelID, err)
 
@@ -436,10 +449,10 @@ Analyze this conversation and extract ONLY NEW memories that are NOT already cap
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
+	providerhttp.SetAuthHeaders(httpReq, provider.Flavor, provider.BaseURL, provider.AuthStyle, provider.APIKey, provider.Headers)
 
 	// Send request with 60s timeout
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := providerhttp.NewClient(60 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -453,7 +466,7 @@ Analyze this conversation and extract ONLY NEW memories that are NOT already cap
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("⚠️ [MEMORY-EXTRACTION] API error: %s", string(body))
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, providerhttp.NewStatusError(resp, body)
 	}
 
 	// Parse response
@@ -1,14 +1,16 @@
 package services
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"claraverse/internal/config"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 )
 
 // MemoryModelPool manages multiple models for memory operations with health tracking and failover
@@ -20,7 +22,20 @@ type MemoryModelPool struct {
 	healthTracker    map[string]*ModelHealth
 	mu               sync.Mutex
 	chatService      *ChatService
-	db               *sql.DB // Database connection for querying model_aliases
+	onHealthChange   HealthChangeFunc
+	// providerCooldowns holds rate-limited providers and when they may be used again
+	providerCooldowns map[string]time.Time
+}
+
+// HealthChangeFunc is called when a model flips between healthy and unhealthy
+type HealthChangeFunc func(modelID string, healthy bool, reason string)
+
+// healthChange is a pending HealthChangeFunc invocation, fired after the pool mutex is released
+type healthChange struct {
+	handler HealthChangeFunc
+	modelID string
+	healthy bool
+	reason  string
 }
 
 // ModelCandidate represents a model eligible for memory operations
@@ -29,6 +44,54 @@ type ModelCandidate struct {
 	ProviderName string
 	SpeedMs     int
 	DisplayName string
+	// Tags name the content the model handles best (model_aliases.memory_tags), e.g. "code"
+	Tags []string
+}
+
+// Memory content hints matched against candidate tags
+const (
+	MemoryContentCode  = "code"
+	MemoryContentProse = "prose"
+)
+
+// HasTag reports whether the candidate is tagged with tag (case-insensitive)
+func (c ModelCandidate) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryContentHint classifies conversation messages for model preference: code when
+// any message contains a fenced code block, prose otherwise
+func memoryContentHint(messages []map[string]interface{}) string {
+	for _, msg := range messages {
+		if content, ok := msg["content"].(string); ok && strings.Contains(content, "```") {
+			return MemoryContentCode
+		}
+	}
+	return MemoryContentProse
+}
+
+// parseMemoryTags splits a comma-separated memory_tags value into lowercase tags
+func parseMemoryTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// joinMemoryTags is the memory_tags column value for tags (NULL when there are none)
+func joinMemoryTags(tags []string) interface{} {
+	if tags := parseMemoryTags(strings.Join(tags, ",")); len(tags) > 0 {
+		return strings.Join(tags, ",")
+	}
+	return nil
 }
 
 // ModelHealth tracks model health and failures
@@ -39,6 +102,9 @@ type ModelHealth struct {
 	LastSuccess     time.Time
 	IsHealthy       bool
 	ConsecutiveFails int
+	// Disabled is an administrative kill switch, independent of IsHealthy:
+	// disabled models are never selected, not even as a last resort
+	Disabled bool
 }
 
 const (
@@ -46,13 +112,16 @@ const (
 	MaxConsecutiveFailures = 3
 	HealthCheckCooldown    = 5 * time.Minute
 	MinSuccessesToRecover  = 2
+
+	// RateLimitCooldown is how long a rate-limited (429) provider's models are skipped,
+	// unless the provider asked for a longer Retry-After
+	RateLimitCooldown = 30 * time.Second
 )
 
 // NewMemoryModelPool creates a new model pool by discovering eligible models from providers
-func NewMemoryModelPool(chatService *ChatService, db *sql.DB) (*MemoryModelPool, error) {
+func NewMemoryModelPool(chatService *ChatService) (*MemoryModelPool, error) {
 	pool := &MemoryModelPool{
 		chatService:   chatService,
-		db:            db,
 		healthTracker: make(map[string]*ModelHealth),
 	}
 
@@ -79,6 +148,61 @@ func NewMemoryModelPool(chatService *ChatService, db *sql.DB) (*MemoryModelPool,
 	return pool, nil
 }
 
+// Refresh rediscovers the pool's models after providers change. Health is reset,
+// since failures may have come from credentials that have since been rotated;
+// disabled models stay disabled and rate-limit cooldowns are kept.
+func (p *MemoryModelPool) Refresh() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	disabled := make(map[string]bool)
+	for modelID, health := range p.healthTracker {
+		if health.Disabled {
+			disabled[modelID] = true
+		}
+	}
+
+	p.extractorModels = nil
+	p.selectorModels = nil
+	p.extractorIndex = 0
+	p.selectorIndex = 0
+	p.healthTracker = make(map[string]*ModelHealth)
+	err := p.discoverModels()
+
+	for modelID := range disabled {
+		if health, exists := p.healthTracker[modelID]; exists {
+			health.Disabled = true
+		}
+	}
+
+	log.Printf("🔄 [MODEL-POOL] Refreshed: %d extractors, %d selectors", len(p.extractorModels), len(p.selectorModels))
+	return err
+}
+
+// SetOnHealthChange registers a callback for model health transitions (nil disables it).
+// The callback runs outside the pool mutex, so it may safely call back into the pool.
+func (p *MemoryModelPool) SetOnHealthChange(handler HealthChangeFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onHealthChange = handler
+}
+
+// newHealthChange captures a transition to report once the mutex is released (caller holds p.mu)
+func (p *MemoryModelPool) newHealthChange(modelID string, healthy bool, reason string) *healthChange {
+	if p.onHealthChange == nil {
+		return nil
+	}
+	return &healthChange{handler: p.onHealthChange, modelID: modelID, healthy: healthy, reason: reason}
+}
+
+// emit invokes the captured callback; safe to call on nil
+func (c *healthChange) emit() {
+	if c == nil {
+		return
+	}
+	c.handler(c.modelID, c.healthy, c.reason)
+}
+
 // discoverModels scans database for models with memory flags
 func (p *MemoryModelPool) discoverModels() error {
 	// First try loading from database (MySQL-first approach)
@@ -113,6 +237,7 @@ func (p *MemoryModelPool) discoverModels() error {
 					ProviderName: providerConfig.Name,
 					DisplayName:  getDisplayName(modelConfig),
 					SpeedMs:      getSpeedMs(modelConfig),
+					Tags:         parseMemoryTags(strings.Join(modelAlias.MemoryTags, ",")),
 				}
 				p.extractorModels = append(p.extractorModels, candidate)
 				p.healthTracker[alias] = &ModelHealth{IsHealthy: true}
@@ -133,6 +258,7 @@ func (p *MemoryModelPool) discoverModels() error {
 					ProviderName: providerConfig.Name,
 					DisplayName:  getDisplayName(modelConfig),
 					SpeedMs:      getSpeedMs(modelConfig),
+					Tags:         parseMemoryTags(strings.Join(modelAlias.MemoryTags, ",")),
 				}
 				p.selectorModels = append(p.selectorModels, candidate)
 
@@ -149,62 +275,51 @@ func (p *MemoryModelPool) discoverModels() error {
 	return nil
 }
 
-// discoverFromDatabase loads memory models from database (model_aliases table)
+// discoverFromDatabase loads memory models from the model catalog (model_aliases of
+// enabled providers)
 func (p *MemoryModelPool) discoverFromDatabase() ([]ModelCandidate, error) {
-	if p.db == nil {
-		return nil, fmt.Errorf("database connection not available")
-	}
-
-	// Query for models with memory_extractor or memory_selector flags
-	rows, err := p.db.Query(`
-		SELECT
-			a.alias_name,
-			pr.name as provider_name,
-			a.display_name,
-			COALESCE(a.structured_output_speed_ms, 999999) as speed_ms,
-			COALESCE(a.memory_extractor, 0) as memory_extractor,
-			COALESCE(a.memory_selector, 0) as memory_selector
-		FROM model_aliases a
-		JOIN providers pr ON a.provider_id = pr.id
-		WHERE a.memory_extractor = 1 OR a.memory_selector = 1
-	`)
+	catalog := GetModelCatalog()
+	if catalog == nil {
+		return nil, fmt.Errorf("model catalog not available")
+	}
 
+	memoryModels, err := catalog.MemoryModels()
 	if err != nil {
-		return nil, fmt.Errorf("failed to query model_aliases: %w", err)
+		return nil, fmt.Errorf("failed to list memory models: %w", err)
 	}
-	defer rows.Close()
 
 	var candidates []ModelCandidate
-	for rows.Next() {
-		var aliasName, providerName, displayName string
-		var speedMs int
-		var isExtractor, isSelector int
-
-		if err := rows.Scan(&aliasName, &providerName, &displayName, &speedMs, &isExtractor, &isSelector); err != nil {
-			log.Printf("⚠️ [MODEL-POOL] Failed to scan row: %v", err)
-			continue
+	for _, model := range memoryModels {
+		aliasName := model.Alias
+		if aliasName == "" {
+			aliasName = model.Name
+		}
+		speedMs := model.SpeedMs
+		if speedMs == 0 {
+			speedMs = 999999 // Unmeasured models sort last
 		}
 
 		candidate := ModelCandidate{
 			ModelID:      aliasName,
-			ProviderName: providerName,
-			DisplayName:  displayName,
+			ProviderName: model.ProviderName,
+			DisplayName:  model.DisplayName,
 			SpeedMs:      speedMs,
+			Tags:         model.MemoryTags,
 		}
 
-		if isExtractor == 1 {
+		if model.MemoryExtractor {
 			p.extractorModels = append(p.extractorModels, candidate)
 			p.healthTracker[aliasName] = &ModelHealth{IsHealthy: true}
-			log.Printf("✅ [MODEL-POOL] Found extractor from DB: %s (%s) - %dms", aliasName, providerName, speedMs)
+			log.Printf("✅ [MODEL-POOL] Found extractor from DB: %s (%s) - %dms", aliasName, model.ProviderName, speedMs)
 		}
 
-		if isSelector == 1 {
+		if model.MemorySelector {
 			// Avoid duplicates if model is both extractor and selector
 			if _, exists := p.healthTracker[aliasName]; !exists {
 				p.healthTracker[aliasName] = &ModelHealth{IsHealthy: true}
 			}
 			p.selectorModels = append(p.selectorModels, candidate)
-			log.Printf("✅ [MODEL-POOL] Found selector from DB: %s (%s) - %dms", aliasName, providerName, speedMs)
+			log.Printf("✅ [MODEL-POOL] Found selector from DB: %s (%s) - %dms", aliasName, model.ProviderName, speedMs)
 		}
 
 		candidates = append(candidates, candidate)
@@ -242,6 +357,15 @@ func modelAliasToMap(alias models.ModelAlias) map[string]interface{} {
 
 // GetNextExtractor returns the next healthy extractor model using round-robin
 func (p *MemoryModelPool) GetNextExtractor() (string, error) {
+	return p.GetNextExtractorFor("")
+}
+
+// GetNextExtractorFor is GetNextExtractor preferring extractors tagged with hint
+// (e.g. MemoryContentCode). Without a usable tagged extractor it falls back to the
+// general round-robin, so failover is unaffected.
+func (p *MemoryModelPool) GetNextExtractorFor(hint string) (string, error) {
+	var change *healthChange
+	defer func() { change.emit() }() // runs after unlock
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -249,41 +373,22 @@ func (p *MemoryModelPool) GetNextExtractor() (string, error) {
 		return "", fmt.Errorf("no extractor models available")
 	}
 
-	// Try all models in round-robin fashion
-	attempts := 0
-	maxAttempts := len(p.extractorModels)
-
-	for attempts < maxAttempts {
-		candidate := p.extractorModels[p.extractorIndex]
-		p.extractorIndex = (p.extractorIndex + 1) % len(p.extractorModels)
-		attempts++
-
-		// Check if model is healthy
-		health := p.healthTracker[candidate.ModelID]
-		if health.IsHealthy {
-			log.Printf("🔄 [MODEL-POOL] Selected extractor: %s (healthy)", candidate.ModelID)
-			return candidate.ModelID, nil
-		}
-
-		// Check if enough time has passed since last failure (cooldown)
-		if time.Since(health.LastFailure) > HealthCheckCooldown {
-			log.Printf("⚡ [MODEL-POOL] Retrying extractor after cooldown: %s", candidate.ModelID)
-			health.IsHealthy = true
-			health.ConsecutiveFails = 0
-			return candidate.ModelID, nil
-		}
-
-		log.Printf("⏭️ [MODEL-POOL] Skipping unhealthy extractor: %s (fails: %d, last: %s ago)",
-			candidate.ModelID, health.ConsecutiveFails, time.Since(health.LastFailure).Round(time.Second))
-	}
-
-	// All models unhealthy - return fastest anyway as last resort
-	log.Printf("⚠️ [MODEL-POOL] All extractors unhealthy, using fastest: %s", p.extractorModels[0].ModelID)
-	return p.extractorModels[0].ModelID, nil
+	var modelID string
+	var err error
+	modelID, change, err = p.nextCandidate("extractor", p.extractorModels, &p.extractorIndex, hint)
+	return modelID, err
 }
 
 // GetNextSelector returns the next healthy selector model using round-robin
 func (p *MemoryModelPool) GetNextSelector() (string, error) {
+	return p.GetNextSelectorFor("")
+}
+
+// GetNextSelectorFor is GetNextSelector preferring selectors tagged with hint, falling
+// back to the general round-robin
+func (p *MemoryModelPool) GetNextSelectorFor(hint string) (string, error) {
+	var change *healthChange
+	defer func() { change.emit() }() // runs after unlock
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -291,41 +396,87 @@ func (p *MemoryModelPool) GetNextSelector() (string, error) {
 		return "", fmt.Errorf("no selector models available")
 	}
 
-	// Try all models in round-robin fashion
-	attempts := 0
-	maxAttempts := len(p.selectorModels)
+	var modelID string
+	var err error
+	modelID, change, err = p.nextCandidate("selector", p.selectorModels, &p.selectorIndex, hint)
+	return modelID, err
+}
 
-	for attempts < maxAttempts {
-		candidate := p.selectorModels[p.selectorIndex]
-		p.selectorIndex = (p.selectorIndex + 1) % len(p.selectorModels)
-		attempts++
+// nextCandidate picks the next usable candidate in round-robin order starting at
+// *index, trying candidates tagged with hint first (caller holds p.mu)
+func (p *MemoryModelPool) nextCandidate(role string, candidates []ModelCandidate, index *int, hint string) (string, *healthChange, error) {
+	if hint != "" {
+		for i := 0; i < len(candidates); i++ {
+			pos := (*index + i) % len(candidates)
+			candidate := candidates[pos]
+			if !candidate.HasTag(hint) {
+				continue
+			}
+			if ok, change := p.tryCandidate(role, candidate); ok {
+				*index = (pos + 1) % len(candidates)
+				log.Printf("🏷️ [MODEL-POOL] Preferred %s %s for %q", role, candidate.ModelID, hint)
+				return candidate.ModelID, change, nil
+			}
+		}
+		log.Printf("ℹ️ [MODEL-POOL] No usable %s tagged %q, using round-robin", role, hint)
+	}
 
-		// Check if model is healthy
-		health := p.healthTracker[candidate.ModelID]
-		if health.IsHealthy {
-			log.Printf("🔄 [MODEL-POOL] Selected selector: %s (healthy)", candidate.ModelID)
-			return candidate.ModelID, nil
+	// Try all models in round-robin fashion
+	for attempts := 0; attempts < len(candidates); attempts++ {
+		candidate := candidates[*index]
+		*index = (*index + 1) % len(candidates)
+
+		if ok, change := p.tryCandidate(role, candidate); ok {
+			return candidate.ModelID, change, nil
 		}
+	}
 
-		// Check if enough time has passed since last failure (cooldown)
-		if time.Since(health.LastFailure) > HealthCheckCooldown {
-			log.Printf("⚡ [MODEL-POOL] Retrying selector after cooldown: %s", candidate.ModelID)
-			health.IsHealthy = true
-			health.ConsecutiveFails = 0
-			return candidate.ModelID, nil
+	// All models unhealthy - return fastest enabled one anyway as last resort
+	for _, candidate := range candidates {
+		if !p.healthTracker[candidate.ModelID].Disabled {
+			log.Printf("⚠️ [MODEL-POOL] All %ss unhealthy, using fastest: %s", role, candidate.ModelID)
+			return candidate.ModelID, nil, nil
 		}
+	}
+	return "", nil, fmt.Errorf("all %s models are disabled", role)
+}
+
+// tryCandidate reports whether candidate may be used now, reviving it if its failure
+// cooldown has elapsed (caller holds p.mu)
+func (p *MemoryModelPool) tryCandidate(role string, candidate ModelCandidate) (bool, *healthChange) {
+	health := p.healthTracker[candidate.ModelID]
+	if health.Disabled {
+		return false, nil
+	}
+	if until, limited := p.providerCooldown(candidate.ProviderName); limited {
+		log.Printf("⏭️ [MODEL-POOL] Skipping %s %s: provider %s rate limited for %s",
+			role, candidate.ModelID, candidate.ProviderName, time.Until(until).Round(time.Second))
+		return false, nil
+	}
+
+	// Check if model is healthy
+	if health.IsHealthy {
+		log.Printf("🔄 [MODEL-POOL] Selected %s: %s (healthy)", role, candidate.ModelID)
+		return true, nil
+	}
 
-		log.Printf("⏭️ [MODEL-POOL] Skipping unhealthy selector: %s (fails: %d, last: %s ago)",
-			candidate.ModelID, health.ConsecutiveFails, time.Since(health.LastFailure).Round(time.Second))
+	// Check if enough time has passed since last failure (cooldown)
+	if time.Since(health.LastFailure) > HealthCheckCooldown {
+		log.Printf("⚡ [MODEL-POOL] Retrying %s after cooldown: %s", role, candidate.ModelID)
+		health.IsHealthy = true
+		health.ConsecutiveFails = 0
+		return true, p.newHealthChange(candidate.ModelID, true, fmt.Sprintf("cooldown of %s elapsed, retrying", HealthCheckCooldown))
 	}
 
-	// All models unhealthy - return fastest anyway as last resort
-	log.Printf("⚠️ [MODEL-POOL] All selectors unhealthy, using fastest: %s", p.selectorModels[0].ModelID)
-	return p.selectorModels[0].ModelID, nil
+	log.Printf("⏭️ [MODEL-POOL] Skipping unhealthy %s: %s (fails: %d, last: %s ago)",
+		role, candidate.ModelID, health.ConsecutiveFails, time.Since(health.LastFailure).Round(time.Second))
+	return false, nil
 }
 
 // MarkSuccess records a successful model call
 func (p *MemoryModelPool) MarkSuccess(modelID string) {
+	var change *healthChange
+	defer func() { change.emit() }() // runs after unlock
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -342,11 +493,16 @@ func (p *MemoryModelPool) MarkSuccess(modelID string) {
 	if !health.IsHealthy && health.SuccessCount >= MinSuccessesToRecover {
 		health.IsHealthy = true
 		log.Printf("💚 [MODEL-POOL] Model recovered: %s (successes: %d)", modelID, health.SuccessCount)
+		change = p.newHealthChange(modelID, true, fmt.Sprintf("recovered after %d successes", health.SuccessCount))
 	}
 }
 
-// MarkFailure records a failed model call
-func (p *MemoryModelPool) MarkFailure(modelID string) {
+// MarkFailure records a failed model call. A rate-limited (429) error puts the
+// model's provider on a short cooldown shared by all its models instead of counting
+// toward the model's consecutive-failure threshold.
+func (p *MemoryModelPool) MarkFailure(modelID string, err error) {
+	var change *healthChange
+	defer func() { change.emit() }() // runs after unlock
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -355,12 +511,30 @@ func (p *MemoryModelPool) MarkFailure(modelID string) {
 		return
 	}
 
+	if retryAfter, limited := providerhttp.IsRateLimited(err); limited {
+		provider := p.providerOf(modelID)
+		cooldown := RateLimitCooldown
+		if retryAfter > cooldown {
+			cooldown = retryAfter
+		}
+		if p.providerCooldowns == nil {
+			p.providerCooldowns = make(map[string]time.Time)
+		}
+		p.providerCooldowns[provider] = time.Now().Add(cooldown)
+		log.Printf("⏳ [MODEL-POOL] Provider %s rate limited (model %s), cooling down for %s",
+			provider, modelID, cooldown)
+		return
+	}
+
 	health.FailureCount++
 	health.ConsecutiveFails++
 	health.LastFailure = time.Now()
 
 	// Mark unhealthy after consecutive failures
 	if health.ConsecutiveFails >= MaxConsecutiveFailures {
+		if health.IsHealthy {
+			change = p.newHealthChange(modelID, false, fmt.Sprintf("%d consecutive failures", health.ConsecutiveFails))
+		}
 		health.IsHealthy = false
 		log.Printf("💔 [MODEL-POOL] Model marked unhealthy: %s (consecutive fails: %d, total fails: %d)",
 			modelID, health.ConsecutiveFails, health.FailureCount)
@@ -370,32 +544,141 @@ func (p *MemoryModelPool) MarkFailure(modelID string) {
 	}
 }
 
-// GetStats returns current pool statistics
-func (p *MemoryModelPool) GetStats() map[string]interface{} {
+// providerOf returns the provider name of a pooled model (caller holds p.mu)
+func (p *MemoryModelPool) providerOf(modelID string) string {
+	for _, candidates := range [][]ModelCandidate{p.extractorModels, p.selectorModels} {
+		for _, candidate := range candidates {
+			if candidate.ModelID == modelID {
+				return candidate.ProviderName
+			}
+		}
+	}
+	return ""
+}
+
+// providerCooldown reports whether a provider is rate limited and until when (caller holds p.mu)
+func (p *MemoryModelPool) providerCooldown(provider string) (time.Time, bool) {
+	until, exists := p.providerCooldowns[provider]
+	if !exists {
+		return time.Time{}, false
+	}
+	if time.Now().After(until) {
+		delete(p.providerCooldowns, provider)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// ResetHealth marks every model healthy and clears consecutive failures and provider
+// rate-limit cooldowns, so models that failed during an outage are tried again
+// immediately instead of after HealthCheckCooldown. Disabled models stay disabled.
+// It returns how many models were unhealthy.
+func (p *MemoryModelPool) ResetHealth() int {
+	var changes []*healthChange
+	defer func() { // runs after unlock
+		for _, change := range changes {
+			change.emit()
+		}
+	}()
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	healthyExtractors := 0
-	healthySelectors := 0
-
-	for _, model := range p.extractorModels {
-		if p.healthTracker[model.ModelID].IsHealthy {
-			healthyExtractors++
+	recovered := 0
+	for modelID, health := range p.healthTracker {
+		if !health.IsHealthy {
+			recovered++
+			health.IsHealthy = true
+			changes = append(changes, p.newHealthChange(modelID, true, "health reset by admin"))
 		}
+		health.ConsecutiveFails = 0
 	}
+	p.providerCooldowns = nil
+
+	log.Printf("💚 [MODEL-POOL] Health reset by admin: %d unhealthy models restored", recovered)
+	return recovered
+}
+
+// DisableModel administratively disables a model so it is skipped in selection
+// until EnableModel is called. Unlike unhealthy models, disabled models are not
+// retried after the cooldown and are never used as a last resort.
+func (p *MemoryModelPool) DisableModel(modelID string) error {
+	return p.setDisabled(modelID, true)
+}
 
-	for _, model := range p.selectorModels {
-		if p.healthTracker[model.ModelID].IsHealthy {
-			healthySelectors++
+// EnableModel re-enables a model disabled with DisableModel. Its health state is
+// left as it was before it was disabled.
+func (p *MemoryModelPool) EnableModel(modelID string) error {
+	return p.setDisabled(modelID, false)
+}
+
+func (p *MemoryModelPool) setDisabled(modelID string, disabled bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health, exists := p.healthTracker[modelID]
+	if !exists {
+		return fmt.Errorf("model %s is not in the memory model pool", modelID)
+	}
+
+	if health.Disabled != disabled {
+		health.Disabled = disabled
+		if disabled {
+			log.Printf("⛔ [MODEL-POOL] Model disabled by admin: %s", modelID)
+		} else {
+			log.Printf("✅ [MODEL-POOL] Model re-enabled by admin: %s", modelID)
 		}
 	}
+	return nil
+}
+
+// GetStats returns current pool statistics. Disabled models are counted separately
+// and are excluded from the healthy and unhealthy counts.
+func (p *MemoryModelPool) GetStats() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	healthyExtractors, unhealthyExtractors, disabledExtractors := p.countHealth(p.extractorModels)
+	healthySelectors, unhealthySelectors, disabledSelectors := p.countHealth(p.selectorModels)
 
 	return map[string]interface{}{
-		"total_extractors":   len(p.extractorModels),
-		"healthy_extractors": healthyExtractors,
-		"total_selectors":    len(p.selectorModels),
-		"healthy_selectors":  healthySelectors,
+		"total_extractors":       len(p.extractorModels),
+		"healthy_extractors":     healthyExtractors,
+		"unhealthy_extractors":   unhealthyExtractors,
+		"disabled_extractors":    disabledExtractors,
+		"total_selectors":        len(p.selectorModels),
+		"healthy_selectors":      healthySelectors,
+		"unhealthy_selectors":    unhealthySelectors,
+		"disabled_selectors":     disabledSelectors,
+		"rate_limited_providers": p.rateLimitedProviders(),
+	}
+}
+
+// rateLimitedProviders lists providers currently cooling down (caller holds p.mu)
+func (p *MemoryModelPool) rateLimitedProviders() []string {
+	providers := make([]string, 0, len(p.providerCooldowns))
+	for provider := range p.providerCooldowns {
+		if _, limited := p.providerCooldown(provider); limited {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// countHealth tallies candidates by state (caller holds p.mu)
+func (p *MemoryModelPool) countHealth(candidates []ModelCandidate) (healthy, unhealthy, disabled int) {
+	for _, model := range candidates {
+		health := p.healthTracker[model.ModelID]
+		switch {
+		case health.Disabled:
+			disabled++
+		case health.IsHealthy:
+			healthy++
+		default:
+			unhealthy++
+		}
 	}
+	return healthy, unhealthy, disabled
 }
 
 // Helper functions
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"claraverse/internal/providerhttp"
+)
+
+func TestMemoryModelPoolDisableModel(t *testing.T) {
+	pool := &MemoryModelPool{
+		extractorModels: []ModelCandidate{{ModelID: "fast"}, {ModelID: "slow"}},
+		healthTracker: map[string]*ModelHealth{
+			"fast": {IsHealthy: true},
+			"slow": {IsHealthy: true},
+		},
+	}
+
+	if err := pool.DisableModel("fast"); err != nil {
+		t.Fatalf("DisableModel failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if model, err := pool.GetNextExtractor(); err != nil || model != "slow" {
+			t.Fatalf("Expected slow while fast is disabled, got %q (err %v)", model, err)
+		}
+	}
+
+	// An unhealthy model is still a last resort, a disabled one never is
+	pool.healthTracker["slow"].IsHealthy = false
+	pool.healthTracker["slow"].LastFailure = time.Now()
+	if model, err := pool.GetNextExtractor(); err != nil || model != "slow" {
+		t.Errorf("Expected unhealthy slow as last resort, got %q (err %v)", model, err)
+	}
+
+	stats := pool.GetStats()
+	if stats["disabled_extractors"] != 1 || stats["unhealthy_extractors"] != 1 || stats["healthy_extractors"] != 0 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+
+	pool.DisableModel("slow")
+	if _, err := pool.GetNextExtractor(); err == nil {
+		t.Error("Expected an error when every extractor is disabled")
+	}
+
+	pool.EnableModel("fast")
+	if model, err := pool.GetNextExtractor(); err != nil || model != "fast" {
+		t.Errorf("Expected fast after re-enabling, got %q (err %v)", model, err)
+	}
+
+	if err := pool.DisableModel("unknown"); err == nil {
+		t.Error("Expected an error for a model outside the pool")
+	}
+}
+
+func TestMemoryModelPoolRateLimitCoolsDownProvider(t *testing.T) {
+	pool := &MemoryModelPool{
+		extractorModels: []ModelCandidate{
+			{ModelID: "groq-fast", ProviderName: "groq"},
+			{ModelID: "groq-small", ProviderName: "groq"},
+			{ModelID: "openai-mini", ProviderName: "openai"},
+		},
+		healthTracker: map[string]*ModelHealth{
+			"groq-fast":   {IsHealthy: true},
+			"groq-small":  {IsHealthy: true},
+			"openai-mini": {IsHealthy: true},
+		},
+	}
+
+	rateLimited := &providerhttp.StatusError{StatusCode: 429, Body: "slow down"}
+	for i := 0; i < MaxConsecutiveFailures; i++ {
+		pool.MarkFailure("groq-fast", rateLimited)
+	}
+	if health := pool.healthTracker["groq-fast"]; !health.IsHealthy || health.ConsecutiveFails != 0 {
+		t.Errorf("rate limiting must not count toward model health, got %+v", health)
+	}
+
+	// Every model of the rate-limited provider is skipped while it cools down
+	for i := 0; i < 3; i++ {
+		if model, err := pool.GetNextExtractor(); err != nil || model != "openai-mini" {
+			t.Fatalf("Expected openai-mini while groq cools down, got %q (err %v)", model, err)
+		}
+	}
+	if providers := pool.GetStats()["rate_limited_providers"].([]string); len(providers) != 1 || providers[0] != "groq" {
+		t.Errorf("Expected groq to be reported as rate limited, got %v", providers)
+	}
+
+	// Once the cooldown has passed the provider is used again
+	pool.providerCooldowns["groq"] = time.Now().Add(-time.Second)
+	if model, _ := pool.GetNextExtractor(); model != "groq-fast" {
+		t.Errorf("Expected groq-fast after the cooldown, got %q", model)
+	}
+
+	// Other errors still count toward the failure threshold
+	pool.MarkFailure("openai-mini", errors.New("connection reset"))
+	if pool.healthTracker["openai-mini"].ConsecutiveFails != 1 {
+		t.Errorf("Expected a regular failure to be counted")
+	}
+}
+
+func TestMemoryModelPoolResetHealth(t *testing.T) {
+	pool := &MemoryModelPool{
+		extractorModels: []ModelCandidate{
+			{ModelID: "broken", ProviderName: "openai"},
+			{ModelID: "limited", ProviderName: "groq"},
+			{ModelID: "off", ProviderName: "openai"},
+		},
+		healthTracker: map[string]*ModelHealth{
+			"broken":  {IsHealthy: false, ConsecutiveFails: MaxConsecutiveFailures, LastFailure: time.Now()},
+			"limited": {IsHealthy: true},
+			"off":     {IsHealthy: false, ConsecutiveFails: 1, Disabled: true},
+		},
+		providerCooldowns: map[string]time.Time{"groq": time.Now().Add(time.Hour)},
+	}
+	var recovered []string
+	pool.SetOnHealthChange(func(modelID string, healthy bool, reason string) {
+		if healthy {
+			recovered = append(recovered, modelID)
+		}
+	})
+
+	if reset := pool.ResetHealth(); reset != 2 {
+		t.Errorf("Expected 2 models reset, got %d", reset)
+	}
+	if health := pool.healthTracker["broken"]; !health.IsHealthy || health.ConsecutiveFails != 0 {
+		t.Errorf("Expected broken to be healthy after the reset, got %+v", health)
+	}
+	if len(recovered) != 2 {
+		t.Errorf("Expected a recovery for each unhealthy model, got %v", recovered)
+	}
+
+	stats := pool.GetStats()
+	if stats["healthy_extractors"] != 2 || stats["disabled_extractors"] != 1 || len(stats["rate_limited_providers"].([]string)) != 0 {
+		t.Errorf("Unexpected stats after the reset: %v", stats)
+	}
+	// The cooldown is gone and the disabled model is still skipped
+	if model, err := pool.GetNextExtractor(); err != nil || model == "off" {
+		t.Errorf("Expected an enabled model after the reset, got %q (err %v)", model, err)
+	}
+}
+
+func TestMemoryModelPoolPrefersTaggedModels(t *testing.T) {
+	pool := &MemoryModelPool{
+		extractorModels: []ModelCandidate{
+			{ModelID: "fast"},
+			{ModelID: "coder-a", Tags: []string{"code"}},
+			{ModelID: "coder-b", Tags: []string{"code", "prose"}},
+		},
+		healthTracker: map[string]*ModelHealth{
+			"fast":    {IsHealthy: true},
+			"coder-a": {IsHealthy: true},
+			"coder-b": {IsHealthy: true},
+		},
+	}
+
+	// Tagged models are rotated among themselves
+	for _, want := range []string{"coder-a", "coder-b", "coder-a"} {
+		if model, err := pool.GetNextExtractorFor(MemoryContentCode); err != nil || model != want {
+			t.Fatalf("Expected %s for code, got %q (err %v)", want, model, err)
+		}
+	}
+
+	// Without usable tagged models the general round-robin takes over
+	pool.DisableModel("coder-a")
+	pool.healthTracker["coder-b"].IsHealthy = false
+	pool.healthTracker["coder-b"].LastFailure = time.Now()
+	if model, err := pool.GetNextExtractorFor(MemoryContentCode); err != nil || model != "fast" {
+		t.Errorf("Expected fallback to fast, got %q (err %v)", model, err)
+	}
+
+	// An unknown hint behaves like no hint
+	if model, err := pool.GetNextExtractorFor("poetry"); err != nil || model != "fast" {
+		t.Errorf("Expected fast for an unmatched hint, got %q (err %v)", model, err)
+	}
+}
+
+func TestMemoryTags(t *testing.T) {
+	if tags := parseMemoryTags(" Code, prose ,,"); len(tags) != 2 || tags[0] != "code" || tags[1] != "prose" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+	if value := joinMemoryTags([]string{"Code", " "}); value != "code" {
+		t.Errorf("Expected code, got %v", value)
+	}
+	if value := joinMemoryTags(nil); value != nil {
+		t.Errorf("Expected NULL for no tags, got %v", value)
+	}
+
+	code := []map[string]interface{}{{"role": "user", "content": "why does this fail?\n```go\nx := 1\n```"}}
+	prose := []map[string]interface{}{{"role": "user", "content": "I moved to Berlin last year"}}
+	if hint := memoryContentHint(code); hint != MemoryContentCode {
+		t.Errorf("Expected code hint, got %s", hint)
+	}
+	if hint := memoryContentHint(prose); hint != MemoryContentProse {
+		t.Errorf("Expected prose hint, got %s", hint)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"claraverse/internal/crypto"
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -170,8 +171,8 @@ func (s *MemorySelectionService) selectMemoriesWithLLM(
 		selectorModelID = userPreferredModel
 		log.Printf("👤 [MEMORY-SELECTION] Using user-preferred model: %s", selectorModelID)
 	} else {
-		// No user preference, get from model pool
-		selectorModelID, err = s.modelPool.GetNextSelector()
+		// No user preference, get from model pool, preferring models tagged for this content
+		selectorModelID, err = s.modelPool.GetNextSelectorFor(memoryContentHint(recentMessages))
 		if err != nil {
 			return nil, "", fmt.Errorf("no selector models available: %w", err)
 		}
@@ -192,7 +193,7 @@ func (s *MemorySelectionService) selectMemoriesWithLLM(
 
 		// Selection failed
 		lastError = err
-		s.modelPool.MarkFailure(selectorModelID)
+		s.modelPool.MarkFailure(selectorModelID, err)
 		log.Printf("⚠️ [MEMORY-SELECTION] Attempt %d/%d failed with model %s: %v",
 			attempt, maxAttempts, selectorModelID, err)
 
@@ -282,10 +283,10 @@ Select up to %d memories that are DIRECTLY relevant to the current conversation.
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
+	providerhttp.SetAuthHeaders(httpReq, provider.Flavor, provider.BaseURL, provider.AuthStyle, provider.APIKey, provider.Headers)
 
 	// Send request with 30s timeout
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, "", fmt.Errorf("request failed: %w", err)
@@ -299,7 +300,7 @@ Select up to %d memories that are DIRECTLY relevant to the current conversation.
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("⚠️ [MEMORY-SELECTION] API error: %s", string(body))
-		return nil, "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, "", providerhttp.NewStatusError(resp, body)
 	}
 
 	// Parse response
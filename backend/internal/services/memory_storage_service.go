@@ -433,6 +433,79 @@ func (s *MemoryStorageService) DeleteMemory(ctx context.Context, userID string,
 	return nil
 }
 
+// DeleteAllMemories permanently deletes every memory of a user, including archived ones
+func (s *MemoryStorageService) DeleteAllMemories(ctx context.Context, userID string) (int64, error) {
+	result, err := s.collection.DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete memories: %w", err)
+	}
+
+	log.Printf("🗑️ [MEMORY-STORAGE] Deleted all %d memories for user %s", result.DeletedCount, userID)
+	return result.DeletedCount, nil
+}
+
+// SearchMemories returns the user's memories whose content or tags contain query
+// (case-insensitive), best scored first. Content is encrypted at rest, so memories
+// are decrypted and matched here rather than in the database.
+func (s *MemoryStorageService) SearchMemories(ctx context.Context, userID, query string, includeArchived bool, limit int) ([]models.DecryptedMemory, error) {
+	filter := bson.M{"userId": userID}
+	if !includeArchived {
+		filter["isArchived"] = false
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "score", Value: -1}, {Key: "updatedAt", Value: -1}})
+
+	cursor, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find memories: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var memories []models.Memory
+	if err := cursor.All(ctx, &memories); err != nil {
+		return nil, fmt.Errorf("failed to decode memories: %w", err)
+	}
+
+	needle := strings.ToLower(strings.TrimSpace(query))
+	matches := make([]models.DecryptedMemory, 0)
+	for _, memory := range memories {
+		decryptedBytes, err := s.encryptionService.Decrypt(userID, memory.EncryptedContent)
+		if err != nil {
+			log.Printf("⚠️ [MEMORY-STORAGE] Failed to decrypt memory %s: %v", memory.ID.Hex(), err)
+			continue
+		}
+
+		decrypted := models.DecryptedMemory{
+			Memory:           memory,
+			DecryptedContent: string(decryptedBytes),
+		}
+		if !memoryMatches(decrypted, needle) {
+			continue
+		}
+
+		matches = append(matches, decrypted)
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+	}
+
+	return matches, nil
+}
+
+// memoryMatches reports whether a memory's content or one of its tags contains
+// needle, which must already be lowercased
+func memoryMatches(memory models.DecryptedMemory, needle string) bool {
+	if strings.Contains(strings.ToLower(memory.DecryptedContent), needle) {
+		return true
+	}
+	for _, tag := range memory.Tags {
+		if strings.Contains(strings.ToLower(tag), needle) {
+			return true
+		}
+	}
+	return false
+}
+
 // CheckDuplicate checks if a memory with the same content hash exists
 func (s *MemoryStorageService) CheckDuplicate(ctx context.Context, userID, contentHash string) (*models.Memory, error) {
 	var memory models.Memory
@@ -2,6 +2,8 @@ package services
 
 import (
 	"testing"
+
+	"claraverse/internal/models"
 )
 
 // TestNormalizeContent tests content normalization for deduplication
@@ -311,6 +313,31 @@ func TestMemoryCategories(t *testing.T) {
 	}
 }
 
+// TestMemoryMatches tests search matching against decrypted content and tags
+func TestMemoryMatches(t *testing.T) {
+	memory := models.DecryptedMemory{
+		Memory:           models.Memory{Tags: []string{"coding", "TypeScript"}},
+		DecryptedContent: "User prefers dark mode in their editor",
+	}
+
+	tests := []struct {
+		needle string
+		want   bool
+	}{
+		{"dark mode", true},
+		{"editor", true},
+		{"typescript", true},
+		{"cod", true},
+		{"light mode", false},
+	}
+
+	for _, tt := range tests {
+		if got := memoryMatches(memory, tt.needle); got != tt.want {
+			t.Errorf("memoryMatches(%q) = %v, want %v", tt.needle, got, tt.want)
+		}
+	}
+}
+
 // BenchmarkNormalizeContent benchmarks content normalization
 func BenchmarkNormalizeContent(b *testing.B) {
 	service := &MemoryStorageService{}
@@ -16,6 +16,11 @@ type Metrics struct {
 	ChatRequestLatency prometheus.Histogram
 	ChatErrors         *prometheus.CounterVec
 
+	// MCP tool call size metrics
+	MCPToolArgumentBytes     prometheus.Histogram
+	MCPToolResultBytes       prometheus.Histogram
+	MCPToolArgumentsRejected prometheus.Counter
+
 	// Connection manager reference for dynamic metrics
 	connManager *ConnectionManager
 }
@@ -57,6 +62,22 @@ func InitMetrics(connManager *ConnectionManager) *Metrics {
 			Name: "claraverse_chat_errors_total",
 			Help: "Total number of chat errors by type",
 		}, []string{"error_type"}),
+
+		// MCP tool argument and result sizes, to spot oversized or abusive calls
+		MCPToolArgumentBytes: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "claraverse_mcp_tool_argument_bytes",
+			Help:    "Size of MCP tool call arguments in bytes",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8), // 256B to 4MB
+		}),
+		MCPToolResultBytes: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "claraverse_mcp_tool_result_bytes",
+			Help:    "Size of MCP tool results in bytes, before truncation",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}),
+		MCPToolArgumentsRejected: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "claraverse_mcp_tool_arguments_rejected_total",
+			Help: "Total number of MCP tool calls rejected for oversized arguments",
+		}),
 	}
 
 	// Register a collector that updates WebSocket connections from ConnectionManager
@@ -112,3 +133,23 @@ func (m *Metrics) RecordChatError(errorType string) {
 	m.ChatErrors.WithLabelValues(errorType).Inc()
 }
 
+// RecordMCPToolArguments records the size of a tool call's arguments and whether the
+// call was rejected for it. Safe to call before InitMetrics.
+func (m *Metrics) RecordMCPToolArguments(bytes int, rejected bool) {
+	if m == nil {
+		return
+	}
+	m.MCPToolArgumentBytes.Observe(float64(bytes))
+	if rejected {
+		m.MCPToolArgumentsRejected.Inc()
+	}
+}
+
+// RecordMCPToolResult records the size of a tool result as the client sent it. Safe
+// to call before InitMetrics.
+func (m *Metrics) RecordMCPToolResult(bytes int) {
+	if m == nil {
+		return
+	}
+	m.MCPToolResultBytes.Observe(float64(bytes))
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"claraverse/internal/database"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultModelCatalogTTL is how long the model catalog serves a provider's models
+// before reading them again
+const DefaultModelCatalogTTL = 5 * time.Minute
+
+// CatalogModel is a model of an enabled provider with the capabilities it is
+// configured with. Aliased models carry their alias settings; other visible models
+// carry their own flags.
+type CatalogModel struct {
+	ProviderID      int      `json:"provider_id"`
+	ProviderName    string   `json:"provider_name"`
+	Name            string   `json:"name"`            // Model name sent to the provider API
+	Alias           string   `json:"alias,omitempty"` // Alias the model is configured under, if any
+	DisplayName     string   `json:"display_name,omitempty"`
+	Vision          bool     `json:"vision"`
+	MemoryExtractor bool     `json:"memory_extractor"`
+	MemorySelector  bool     `json:"memory_selector"`
+	MemoryTags      []string `json:"memory_tags,omitempty"`
+	SpeedMs         int      `json:"speed_ms,omitempty"` // Structured output latency, 0 when unmeasured
+	ImageGeneration bool     `json:"image_generation"`   // Served by an image-only provider
+}
+
+// catalogProvider is one provider's cached models
+type catalogProvider struct {
+	name     string
+	models   []CatalogModel
+	loadedAt time.Time
+}
+
+// ModelCatalog is a read-through cache of the models of every enabled provider and
+// their capabilities, shared by the vision model finder, memory model discovery
+// and image model listing so each lookup does not query the database again.
+// Entries expire after the TTL and are dropped when providers change.
+type ModelCatalog struct {
+	db  *database.DB
+	ttl time.Duration
+
+	mu          sync.Mutex
+	providers   map[int]*catalogProvider // provider ID -> cached models
+	providerIDs []int                    // enabled providers, ascending
+	listedAt    time.Time                // when providerIDs was read
+}
+
+var (
+	modelCatalog   *ModelCatalog
+	modelCatalogMu sync.RWMutex
+)
+
+// NewModelCatalog creates a model catalog whose entries live for ttl (0 uses
+// DefaultModelCatalogTTL)
+func NewModelCatalog(db *database.DB, ttl time.Duration) *ModelCatalog {
+	if ttl <= 0 {
+		ttl = DefaultModelCatalogTTL
+	}
+	return &ModelCatalog{
+		db:        db,
+		ttl:       ttl,
+		providers: make(map[int]*catalogProvider),
+	}
+}
+
+// SetModelCatalog sets the catalog the model finders consult
+func SetModelCatalog(catalog *ModelCatalog) {
+	modelCatalogMu.Lock()
+	defer modelCatalogMu.Unlock()
+	modelCatalog = catalog
+}
+
+// GetModelCatalog returns the shared model catalog, or nil before SetModelCatalog
+func GetModelCatalog() *ModelCatalog {
+	modelCatalogMu.RLock()
+	defer modelCatalogMu.RUnlock()
+	return modelCatalog
+}
+
+// All returns the models of every enabled provider, ordered by provider ID with
+// aliases first, reading expired or missing providers from the database
+func (c *ModelCatalog) All() ([]CatalogModel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.listedAt.IsZero() || now.Sub(c.listedAt) >= c.ttl {
+		if err := c.loadLocked(0); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []CatalogModel
+	for _, providerID := range c.providerIDs {
+		entry, ok := c.providers[providerID]
+		if !ok || now.Sub(entry.loadedAt) >= c.ttl {
+			if err := c.loadLocked(providerID); err != nil {
+				return nil, err
+			}
+			if entry, ok = c.providers[providerID]; !ok {
+				continue // disabled since the list was read
+			}
+		}
+		result = append(result, entry.models...)
+	}
+	return result, nil
+}
+
+// Models returns the models of one enabled provider (none if it is disabled or unknown)
+func (c *ModelCatalog) Models(providerID int) ([]CatalogModel, error) {
+	all, err := c.All()
+	if err != nil {
+		return nil, err
+	}
+	var result []CatalogModel
+	for _, model := range all {
+		if model.ProviderID == providerID {
+			result = append(result, model)
+		}
+	}
+	return result, nil
+}
+
+// VisionModels returns the vision-capable models, configured aliases before plain models
+func (c *ModelCatalog) VisionModels() ([]CatalogModel, error) {
+	result, err := c.filter(func(model CatalogModel) bool { return model.Vision })
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(result, func(a, b CatalogModel) int {
+		switch {
+		case a.Alias != "" && b.Alias == "":
+			return -1
+		case a.Alias == "" && b.Alias != "":
+			return 1
+		}
+		return 0
+	})
+	return result, nil
+}
+
+// MemoryModels returns the models flagged as memory extractors or selectors
+func (c *ModelCatalog) MemoryModels() ([]CatalogModel, error) {
+	return c.filter(func(model CatalogModel) bool { return model.MemoryExtractor || model.MemorySelector })
+}
+
+// ImageModels returns the models of image-only providers
+func (c *ModelCatalog) ImageModels() ([]CatalogModel, error) {
+	return c.filter(func(model CatalogModel) bool { return model.ImageGeneration })
+}
+
+func (c *ModelCatalog) filter(keep func(CatalogModel) bool) ([]CatalogModel, error) {
+	all, err := c.All()
+	if err != nil {
+		return nil, err
+	}
+	var result []CatalogModel
+	for _, model := range all {
+		if keep(model) {
+			result = append(result, model)
+		}
+	}
+	return result, nil
+}
+
+// Invalidate drops the cached models of providerID, or of every provider when it
+// is 0, so the next lookup reads them again. Call it when providers or their
+// models change.
+func (c *ModelCatalog) Invalidate(providerID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if providerID == 0 {
+		c.providers = make(map[int]*catalogProvider)
+		c.providerIDs = nil
+		c.listedAt = time.Time{}
+		return
+	}
+	delete(c.providers, providerID)
+}
+
+// invalidateModelCatalog drops cached models after providerID's models or aliases
+// change (0 for every provider), if a catalog is set
+func invalidateModelCatalog(providerID int) {
+	if catalog := GetModelCatalog(); catalog != nil {
+		catalog.Invalidate(providerID)
+	}
+}
+
+// Refresh reads the models of providerID (every provider when 0) again now and
+// returns how many models the catalog holds for it
+func (c *ModelCatalog) Refresh(providerID int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadLocked(providerID); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for id, entry := range c.providers {
+		if providerID == 0 || id == providerID {
+			count += len(entry.models)
+		}
+	}
+	log.Printf("🗂️ [MODEL-CATALOG] Refreshed %d models (provider %d)", count, providerID)
+	return count, nil
+}
+
+// loadLocked reads the models of providerID, or of every enabled provider when it is
+// 0 (which also re-reads which providers are enabled). The caller holds c.mu.
+func (c *ModelCatalog) loadLocked(providerID int) error {
+	if c.db == nil {
+		return fmt.Errorf("model catalog has no database")
+	}
+
+	providerFilter, args := "", []interface{}{}
+	if providerID != 0 {
+		providerFilter, args = " AND p.id = ?", []interface{}{providerID}
+	}
+
+	loaded := make(map[int]*catalogProvider)
+	var order []int
+	imageOnly := make(map[int]bool)
+	defaultModels := make(map[int]string)
+
+	rows, err := c.db.Query(`
+		SELECT p.id, p.name, COALESCE(p.image_only, 0), COALESCE(p.default_model, '')
+		FROM providers p
+		WHERE p.enabled = 1`+providerFilter+`
+		ORDER BY p.id`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to list providers: %w", err)
+	}
+	for rows.Next() {
+		var id int
+		var name, defaultModel string
+		var image bool
+		if err := rows.Scan(&id, &name, &image, &defaultModel); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan provider: %w", err)
+		}
+		loaded[id] = &catalogProvider{name: name}
+		order = append(order, id)
+		imageOnly[id] = image
+		defaultModels[id] = defaultModel
+	}
+	rows.Close()
+
+	// Aliases come first: their settings override the plain model's flags
+	aliased := make(map[int]map[string]bool)
+	rows, err = c.db.Query(`
+		SELECT a.provider_id, a.alias_name, a.model_id, a.display_name,
+		       COALESCE(a.supports_vision, 0), COALESCE(a.memory_extractor, 0),
+		       COALESCE(a.memory_selector, 0), COALESCE(a.memory_tags, ''),
+		       COALESCE(a.structured_output_speed_ms, 0)
+		FROM model_aliases a
+		JOIN providers p ON a.provider_id = p.id
+		WHERE p.enabled = 1`+providerFilter+`
+		ORDER BY a.provider_id, a.alias_name`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to list model aliases: %w", err)
+	}
+	for rows.Next() {
+		var model CatalogModel
+		var memoryTags string
+		if err := rows.Scan(&model.ProviderID, &model.Alias, &model.Name, &model.DisplayName,
+			&model.Vision, &model.MemoryExtractor, &model.MemorySelector, &memoryTags, &model.SpeedMs); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan model alias: %w", err)
+		}
+		entry, ok := loaded[model.ProviderID]
+		if !ok {
+			continue
+		}
+		model.ProviderName = entry.name
+		model.MemoryTags = parseMemoryTags(memoryTags)
+		model.ImageGeneration = imageOnly[model.ProviderID]
+		entry.models = append(entry.models, model)
+		if aliased[model.ProviderID] == nil {
+			aliased[model.ProviderID] = make(map[string]bool)
+		}
+		aliased[model.ProviderID][model.Name] = true
+	}
+	rows.Close()
+
+	rows, err = c.db.Query(`
+		SELECT m.provider_id, m.name, COALESCE(m.display_name, ''), COALESCE(m.supports_vision, 0)
+		FROM models m
+		JOIN providers p ON m.provider_id = p.id
+		WHERE m.is_visible = 1 AND p.enabled = 1`+providerFilter+`
+		ORDER BY m.provider_id, m.name`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to list models: %w", err)
+	}
+	for rows.Next() {
+		var model CatalogModel
+		if err := rows.Scan(&model.ProviderID, &model.Name, &model.DisplayName, &model.Vision); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan model: %w", err)
+		}
+		entry, ok := loaded[model.ProviderID]
+		if !ok || aliased[model.ProviderID][model.Name] {
+			continue
+		}
+		model.ProviderName = entry.name
+		model.ImageGeneration = imageOnly[model.ProviderID]
+		entry.models = append(entry.models, model)
+	}
+	rows.Close()
+
+	// Image providers are often configured with only a default model
+	for id, defaultModel := range defaultModels {
+		if !imageOnly[id] || defaultModel == "" || hasCatalogModel(loaded[id].models, defaultModel) {
+			continue
+		}
+		loaded[id].models = append(loaded[id].models, CatalogModel{
+			ProviderID:      id,
+			ProviderName:    loaded[id].name,
+			Name:            defaultModel,
+			ImageGeneration: true,
+		})
+	}
+
+	now := time.Now()
+	for _, entry := range loaded {
+		entry.loadedAt = now
+	}
+	if providerID == 0 {
+		c.providers = loaded
+		c.providerIDs = order
+		c.listedAt = now
+		return nil
+	}
+
+	if entry, ok := loaded[providerID]; ok {
+		c.providers[providerID] = entry
+		if !slices.Contains(c.providerIDs, providerID) && !c.listedAt.IsZero() {
+			c.providerIDs = append(c.providerIDs, providerID)
+			slices.Sort(c.providerIDs)
+		}
+	} else {
+		delete(c.providers, providerID)
+	}
+	return nil
+}
+
+func hasCatalogModel(models []CatalogModel, name string) bool {
+	for _, model := range models {
+		if model.Name == name {
+			return true
+		}
+	}
+	return false
+}
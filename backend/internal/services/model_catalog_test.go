@@ -0,0 +1,138 @@
+package services
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"claraverse/internal/database"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+)
+
+// newCatalogTestDB returns an in-memory database with the tables the catalog reads
+func newCatalogTestDB(t *testing.T) *database.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+uuid.New().String()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range []string{
+		`CREATE TABLE providers (id INTEGER PRIMARY KEY, name TEXT, enabled INTEGER, image_only INTEGER, default_model TEXT)`,
+		`CREATE TABLE models (id TEXT PRIMARY KEY, provider_id INTEGER, name TEXT, display_name TEXT, supports_vision INTEGER, is_visible INTEGER)`,
+		`CREATE TABLE model_aliases (alias_name TEXT, model_id TEXT, provider_id INTEGER, display_name TEXT,
+			supports_vision INTEGER, memory_extractor INTEGER, memory_selector INTEGER, memory_tags TEXT,
+			structured_output_speed_ms INTEGER)`,
+		`INSERT INTO providers VALUES (1, 'openai', 1, 0, NULL), (2, 'painter', 1, 1, 'paint-1'), (3, 'off', 0, 0, NULL)`,
+		`INSERT INTO models VALUES
+			('gpt-4o', 1, 'gpt-4o', 'GPT-4o', 0, 1),
+			('gpt-4o-mini', 1, 'gpt-4o-mini', 'GPT-4o mini', 1, 1),
+			('hidden', 1, 'hidden', 'Hidden', 1, 0),
+			('off-vision', 3, 'off-vision', 'Off', 1, 1)`,
+		`INSERT INTO model_aliases VALUES
+			('smart', 'gpt-4o', 1, 'Smart', 1, 1, 0, 'code,chat', 800),
+			('fast', 'gpt-4o-nano', 1, 'Fast', 0, 0, 1, NULL, NULL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to create schema: %v", err)
+		}
+	}
+	return &database.DB{DB: db}
+}
+
+func catalogNames(models []CatalogModel) []string {
+	names := []string{}
+	for _, model := range models {
+		names = append(names, model.Name)
+	}
+	return names
+}
+
+func TestModelCatalogCapabilities(t *testing.T) {
+	catalog := NewModelCatalog(newCatalogTestDB(t), time.Minute)
+
+	all, err := catalog.All()
+	if err != nil {
+		t.Fatalf("All failed: %v", err)
+	}
+	// Aliases replace the plain model they point at; hidden models and disabled providers are left out
+	if got, want := catalogNames(all), []string{"gpt-4o-nano", "gpt-4o", "gpt-4o-mini", "paint-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("All = %v, want %v", got, want)
+	}
+
+	vision, _ := catalog.VisionModels()
+	if got, want := catalogNames(vision), []string{"gpt-4o", "gpt-4o-mini"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("VisionModels = %v, want %v", got, want)
+	}
+	if vision[0].Alias != "smart" {
+		t.Errorf("First vision model alias = %q, want smart", vision[0].Alias)
+	}
+
+	memory, _ := catalog.MemoryModels()
+	if len(memory) != 2 {
+		t.Fatalf("MemoryModels = %v, want 2 models", catalogNames(memory))
+	}
+	smart := memory[1]
+	if !smart.MemoryExtractor || smart.MemorySelector || smart.SpeedMs != 800 || !reflect.DeepEqual(smart.MemoryTags, []string{"code", "chat"}) {
+		t.Errorf("Memory model smart = %+v", smart)
+	}
+
+	image, _ := catalog.ImageModels()
+	if len(image) != 1 || image[0].Name != "paint-1" || image[0].ProviderName != "painter" {
+		t.Errorf("ImageModels = %+v, want painter's default model", image)
+	}
+}
+
+func TestModelCatalogReadThrough(t *testing.T) {
+	db := newCatalogTestDB(t)
+	catalog := NewModelCatalog(db, time.Minute)
+
+	if models, _ := catalog.Models(1); len(models) != 3 {
+		t.Fatalf("Models(1) = %v, want 3 models", catalogNames(models))
+	}
+	if _, err := db.Exec(`INSERT INTO models VALUES ('o1', 1, 'o1', 'o1', 0, 1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Served from the cache until invalidated or refreshed
+	if models, _ := catalog.Models(1); len(models) != 3 {
+		t.Errorf("Models(1) before invalidation = %v, want the cached 3", catalogNames(models))
+	}
+	catalog.Invalidate(1)
+	if models, _ := catalog.Models(1); len(models) != 4 {
+		t.Errorf("Models(1) after invalidation = %v, want 4", catalogNames(models))
+	}
+
+	if _, err := db.Exec(`UPDATE providers SET enabled = 1 WHERE id = 3`); err != nil {
+		t.Fatal(err)
+	}
+	count, err := catalog.Refresh(0)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if count != 6 {
+		t.Errorf("Refresh counted %d models, want 6", count)
+	}
+}
+
+func TestModelCatalogExpires(t *testing.T) {
+	db := newCatalogTestDB(t)
+	catalog := NewModelCatalog(db, time.Millisecond)
+
+	if models, _ := catalog.Models(2); len(models) != 1 {
+		t.Fatalf("Models(2) = %v, want 1 model", catalogNames(models))
+	}
+	if _, err := db.Exec(`UPDATE providers SET enabled = 0 WHERE id = 2`); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if models, _ := catalog.Models(2); len(models) != 0 {
+		t.Errorf("Models(2) after expiry = %v, want none", catalogNames(models))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -154,6 +155,7 @@ func (s *ModelManagementService) UpdateModel(ctx context.Context, modelID string
 	}
 
 	log.Printf("✅ [MODEL-MGMT] Updated model: %s", modelID)
+	invalidateModelCatalog(0)
 
 	// Get fresh model state from database
 	updatedModel, err := s.GetModelByID(modelID)
@@ -200,6 +202,7 @@ func (s *ModelManagementService) reloadConfigServiceCache() error {
 	log.Printf("🔄 [MODEL-MGMT] Reloading config service cache from database...")
 
 	configService := GetConfigService()
+	invalidateModelCatalog(0)
 
 	// Get all providers and their aliases from database
 	rows, err := s.db.Query(`
@@ -344,7 +347,7 @@ func (s *ModelManagementService) FetchModelsFromProvider(ctx context.Context, pr
 	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch models: %w", err)
@@ -389,6 +392,7 @@ func (s *ModelManagementService) FetchModelsFromProvider(ctx context.Context, pr
 	}
 
 	log.Printf("✅ [MODEL-MGMT] Stored %d models for provider %d", count, providerID)
+	invalidateModelCatalog(providerID)
 	return count, nil
 }
 
@@ -428,7 +432,7 @@ func (s *ModelManagementService) TestModelConnection(ctx context.Context, modelI
 	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return &ConnectionTestResult{
@@ -562,7 +566,7 @@ func (s *ModelManagementService) testStructuredOutput(ctx context.Context, model
 	totalTests := len(testPrompts)
 	failureReasons := []string{}
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := providerhttp.NewClient(60 * time.Second)
 
 	for i, prompt := range testPrompts {
 		start := time.Now()
@@ -651,7 +655,7 @@ func (s *ModelManagementService) testPerformance(ctx context.Context, modelID st
 	totalLatency := 0
 	totalTokens := 0
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := providerhttp.NewClient(60 * time.Second)
 
 	for i := 0; i < numTests; i++ {
 		start := time.Now()
@@ -754,12 +758,13 @@ func (s *ModelManagementService) CreateAlias(ctx context.Context, req *CreateAli
 		INSERT INTO model_aliases (alias_name, model_id, provider_id, display_name, description,
 			supports_vision, agents_enabled, smart_tool_router, free_tier,
 			structured_output_support, structured_output_compliance, structured_output_warning,
-			structured_output_speed_ms, structured_output_badge, memory_extractor, memory_selector)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			structured_output_speed_ms, structured_output_badge, memory_extractor, memory_selector, memory_tags)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, req.AliasName, req.ModelID, req.ProviderID, req.DisplayName, description,
 		req.SupportsVision, req.AgentsEnabled, req.SmartToolRouter, req.FreeTier,
 		structuredOutputSupport, req.StructuredOutputCompliance, structuredOutputWarning,
-		req.StructuredOutputSpeedMs, structuredOutputBadge, req.MemoryExtractor, req.MemorySelector)
+		req.StructuredOutputSpeedMs, structuredOutputBadge, req.MemoryExtractor, req.MemorySelector,
+		joinMemoryTags(req.MemoryTags))
 
 	if err != nil {
 		return fmt.Errorf("failed to insert alias: %w", err)
@@ -957,18 +962,19 @@ func (s *ModelManagementService) ImportAliasesFromJSON(ctx context.Context) erro
 			structuredOutputBadge := aliasConfig.StructuredOutputBadge
 			memoryExtractor := aliasConfig.MemoryExtractor
 			memorySelector := aliasConfig.MemorySelector
+			memoryTags := joinMemoryTags(aliasConfig.MemoryTags)
 
 			// Insert alias into database
 			_, err = s.db.Exec(`
 				INSERT INTO model_aliases (alias_name, model_id, provider_id, display_name, description,
 					supports_vision, agents_enabled, smart_tool_router, free_tier,
 					structured_output_support, structured_output_compliance, structured_output_warning,
-					structured_output_speed_ms, structured_output_badge, memory_extractor, memory_selector)
-				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+					structured_output_speed_ms, structured_output_badge, memory_extractor, memory_selector, memory_tags)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			`, aliasName, modelID, providerID, displayName, description,
 				supportsVision, agentsEnabled, smartToolRouter, freeTier,
 				structuredOutputSupport, structuredOutputCompliance, structuredOutputWarning,
-				structuredOutputSpeedMs, structuredOutputBadge, memoryExtractor, memorySelector)
+				structuredOutputSpeedMs, structuredOutputBadge, memoryExtractor, memorySelector, memoryTags)
 
 			if err != nil {
 				log.Printf("⚠️  [MODEL-MGMT] Failed to import alias %s: %v", aliasName, err)
@@ -1093,22 +1099,23 @@ type UpdateModelRequest struct {
 
 // CreateAliasRequest represents a request to create a model alias
 type CreateAliasRequest struct {
-	AliasName                  string `json:"alias_name"`
-	ModelID                    string `json:"model_id"`
-	ProviderID                 int    `json:"provider_id"`
-	DisplayName                string `json:"display_name"`
-	Description                string `json:"description"`
-	SupportsVision             *bool  `json:"supports_vision"`
-	AgentsEnabled              *bool  `json:"agents_enabled"`
-	SmartToolRouter            *bool  `json:"smart_tool_router"`
-	FreeTier                   *bool  `json:"free_tier"`
-	StructuredOutputSupport    string `json:"structured_output_support"`
-	StructuredOutputCompliance *int   `json:"structured_output_compliance"`
-	StructuredOutputWarning    string `json:"structured_output_warning"`
-	StructuredOutputSpeedMs    *int   `json:"structured_output_speed_ms"`
-	StructuredOutputBadge      string `json:"structured_output_badge"`
-	MemoryExtractor            *bool  `json:"memory_extractor"`
-	MemorySelector             *bool  `json:"memory_selector"`
+	AliasName                  string   `json:"alias_name"`
+	ModelID                    string   `json:"model_id"`
+	ProviderID                 int      `json:"provider_id"`
+	DisplayName                string   `json:"display_name"`
+	Description                string   `json:"description"`
+	SupportsVision             *bool    `json:"supports_vision"`
+	AgentsEnabled              *bool    `json:"agents_enabled"`
+	SmartToolRouter            *bool    `json:"smart_tool_router"`
+	FreeTier                   *bool    `json:"free_tier"`
+	StructuredOutputSupport    string   `json:"structured_output_support"`
+	StructuredOutputCompliance *int     `json:"structured_output_compliance"`
+	StructuredOutputWarning    string   `json:"structured_output_warning"`
+	StructuredOutputSpeedMs    *int     `json:"structured_output_speed_ms"`
+	StructuredOutputBadge      string   `json:"structured_output_badge"`
+	MemoryExtractor            *bool    `json:"memory_extractor"`
+	MemorySelector             *bool    `json:"memory_selector"`
+	MemoryTags                 []string `json:"memory_tags"`
 }
 
 // ConnectionTestResult represents the result of a connection test
@@ -1336,6 +1343,7 @@ func (s *ModelManagementService) BulkUpdateVisibility(modelIDs []string, visible
 
 	rowsAffected, _ := result.RowsAffected()
 	log.Printf("✅ [BULK] Updated is_visible=%v for %d models", visible, rowsAffected)
+	invalidateModelCatalog(0)
 	return nil
 }
 
@@ -3,6 +3,7 @@ package services
 import (
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -225,7 +226,7 @@ func (s *ModelService) FetchFromProvider(provider *models.Provider) error {
 	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to fetch models: %w", err)
@@ -277,6 +278,7 @@ func (s *ModelService) FetchFromProvider(provider *models.Provider) error {
 	}
 
 	log.Printf("✅ Refreshed %d models for provider %s", len(modelsResp.Data), provider.Name)
+	invalidateModelCatalog(provider.ID)
 	return nil
 }
 
@@ -530,8 +532,8 @@ func (s *ModelService) SaveAliasesToDB(providerID int, aliases map[string]models
 				supports_vision, agents_enabled, smart_tool_router, free_tier,
 				structured_output_support, structured_output_compliance,
 				structured_output_warning, structured_output_speed_ms,
-				structured_output_badge, memory_extractor, memory_selector
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				structured_output_badge, memory_extractor, memory_selector, memory_tags
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			ON DUPLICATE KEY UPDATE
 				model_id = VALUES(model_id),
 				display_name = VALUES(display_name),
@@ -546,7 +548,8 @@ func (s *ModelService) SaveAliasesToDB(providerID int, aliases map[string]models
 				structured_output_speed_ms = VALUES(structured_output_speed_ms),
 				structured_output_badge = VALUES(structured_output_badge),
 				memory_extractor = VALUES(memory_extractor),
-				memory_selector = VALUES(memory_selector)
+				memory_selector = VALUES(memory_selector),
+				memory_tags = VALUES(memory_tags)
 		`,
 			aliasName,
 			alias.ActualModel,
@@ -564,6 +567,7 @@ func (s *ModelService) SaveAliasesToDB(providerID int, aliases map[string]models
 			nullString(alias.StructuredOutputBadge),
 			nullBool(alias.MemoryExtractor),
 			nullBool(alias.MemorySelector),
+			joinMemoryTags(alias.MemoryTags),
 		)
 
 		if err != nil {
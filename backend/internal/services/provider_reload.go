@@ -0,0 +1,43 @@
+package services
+
+import (
+	"claraverse/internal/models"
+	"fmt"
+	"log"
+)
+
+// ProvidersChangedFunc refreshes state cached from provider config, such as API
+// keys. It is passed every provider, including disabled ones.
+type ProvidersChangedFunc func(providers []models.Provider)
+
+// OnProvidersChanged subscribes fn to every Reload. Services that keep a copy of
+// provider config subscribe so key rotations reach them without a restart.
+func (s *ProviderService) OnProvidersChanged(fn ProvidersChangedFunc) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Reload reads all providers from the database and passes them to the
+// OnProvidersChanged subscribers. Call it after changing providers. It returns
+// the number of providers loaded.
+func (s *ProviderService) Reload() (int, error) {
+	providers, err := s.GetAllIncludingDisabled()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reload providers: %w", err)
+	}
+	s.notifyProvidersChanged(providers)
+	log.Printf("🔄 [PROVIDER] Reloaded %d providers", len(providers))
+	return len(providers), nil
+}
+
+// notifyProvidersChanged runs the subscribers in the order they subscribed
+func (s *ProviderService) notifyProvidersChanged(providers []models.Provider) {
+	s.listenersMu.Lock()
+	listeners := append([]ProvidersChangedFunc(nil), s.listeners...)
+	s.listenersMu.Unlock()
+
+	for _, fn := range listeners {
+		fn(providers)
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"claraverse/internal/models"
+	"testing"
+)
+
+func TestProviderServiceNotifiesSubscribers(t *testing.T) {
+	service := &ProviderService{}
+
+	var order []string
+	var received []models.Provider
+	service.OnProvidersChanged(func(providers []models.Provider) {
+		order = append(order, "first")
+		received = providers
+	})
+	service.OnProvidersChanged(func([]models.Provider) {
+		order = append(order, "second")
+	})
+
+	providers := []models.Provider{{ID: 1, Name: "openai", APIKey: "rotated"}}
+	service.notifyProvidersChanged(providers)
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("Expected subscribers to run in order, got %v", order)
+	}
+	if len(received) != 1 || received[0].APIKey != "rotated" {
+		t.Errorf("Expected the reloaded providers, got %+v", received)
+	}
+}
+
+func TestMemoryModelPoolRefreshDropsStaleModels(t *testing.T) {
+	pool := &MemoryModelPool{
+		extractorModels: []ModelCandidate{{ModelID: "stale"}},
+		extractorIndex:  1,
+		healthTracker: map[string]*ModelHealth{
+			"stale": {IsHealthy: false, ConsecutiveFails: 3, Disabled: true},
+		},
+	}
+
+	// Without a database or providers.json there is nothing to rediscover
+	if err := pool.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if len(pool.extractorModels) != 0 || pool.extractorIndex != 0 {
+		t.Errorf("Expected the stale models to be dropped, got %v", pool.extractorModels)
+	}
+	if _, exists := pool.healthTracker["stale"]; exists {
+		t.Error("Expected health of models no longer in the pool to be dropped")
+	}
+}
@@ -4,15 +4,20 @@ import (
 	"claraverse/internal/database"
 	"claraverse/internal/models"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // ProviderService handles provider operations
 type ProviderService struct {
 	db *database.DB
+
+	listenersMu sync.Mutex
+	listeners   []ProvidersChangedFunc
 }
 
 // NewProviderService creates a new provider service
@@ -23,7 +28,7 @@ func NewProviderService(db *database.DB) *ProviderService {
 // GetAll returns all enabled providers
 func (s *ProviderService) GetAll() ([]models.Provider, error) {
 	rows, err := s.db.Query(`
-		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, created_at, updated_at
+		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, auth_style, custom_headers, uses_max_completion_tokens, flavor, created_at, updated_at
 		FROM providers
 		WHERE enabled = 1
 		ORDER BY name
@@ -36,8 +41,9 @@ func (s *ProviderService) GetAll() ([]models.Provider, error) {
 	var providers []models.Provider
 	for rows.Next() {
 		var p models.Provider
-		var systemPrompt, favicon, defaultModel sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		var systemPrompt, favicon, defaultModel, authStyle, customHeaders, flavor sql.NullString
+		var usesMaxCompletionTokens sql.NullBool
+		if err := rows.Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &authStyle, &customHeaders, &usesMaxCompletionTokens, &flavor, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan provider: %w", err)
 		}
 		if systemPrompt.Valid {
@@ -49,6 +55,12 @@ func (s *ProviderService) GetAll() ([]models.Provider, error) {
 		if defaultModel.Valid {
 			p.DefaultModel = defaultModel.String
 		}
+		applyProviderAuth(&p, authStyle, customHeaders)
+		p.Flavor = flavor.String
+		if usesMaxCompletionTokens.Valid {
+			val := usesMaxCompletionTokens.Bool
+			p.UsesMaxCompletionTokens = &val
+		}
 		providers = append(providers, p)
 	}
 
@@ -58,7 +70,7 @@ func (s *ProviderService) GetAll() ([]models.Provider, error) {
 // GetAllForModels returns all enabled providers that are NOT audio-only (for model selection)
 func (s *ProviderService) GetAllForModels() ([]models.Provider, error) {
 	rows, err := s.db.Query(`
-		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, created_at, updated_at
+		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, auth_style, custom_headers, uses_max_completion_tokens, flavor, created_at, updated_at
 		FROM providers
 		WHERE enabled = 1 AND (audio_only = 0 OR audio_only IS NULL) AND (image_only = 0 OR image_only IS NULL) AND (image_edit_only = 0 OR image_edit_only IS NULL)
 		ORDER BY name
@@ -71,8 +83,9 @@ func (s *ProviderService) GetAllForModels() ([]models.Provider, error) {
 	var providers []models.Provider
 	for rows.Next() {
 		var p models.Provider
-		var systemPrompt, favicon, defaultModel sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		var systemPrompt, favicon, defaultModel, authStyle, customHeaders, flavor sql.NullString
+		var usesMaxCompletionTokens sql.NullBool
+		if err := rows.Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &authStyle, &customHeaders, &usesMaxCompletionTokens, &flavor, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan provider: %w", err)
 		}
 		if systemPrompt.Valid {
@@ -84,6 +97,12 @@ func (s *ProviderService) GetAllForModels() ([]models.Provider, error) {
 		if defaultModel.Valid {
 			p.DefaultModel = defaultModel.String
 		}
+		applyProviderAuth(&p, authStyle, customHeaders)
+		p.Flavor = flavor.String
+		if usesMaxCompletionTokens.Valid {
+			val := usesMaxCompletionTokens.Bool
+			p.UsesMaxCompletionTokens = &val
+		}
 		providers = append(providers, p)
 	}
 
@@ -93,12 +112,13 @@ func (s *ProviderService) GetAllForModels() ([]models.Provider, error) {
 // GetByID returns a provider by ID
 func (s *ProviderService) GetByID(id int) (*models.Provider, error) {
 	var p models.Provider
-	var systemPrompt, favicon, defaultModel sql.NullString
+	var systemPrompt, favicon, defaultModel, authStyle, customHeaders, flavor sql.NullString
+	var usesMaxCompletionTokens sql.NullBool
 	err := s.db.QueryRow(`
-		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, created_at, updated_at
+		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, auth_style, custom_headers, uses_max_completion_tokens, flavor, created_at, updated_at
 		FROM providers
 		WHERE id = ?
-	`, id).Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &p.CreatedAt, &p.UpdatedAt)
+	`, id).Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &authStyle, &customHeaders, &usesMaxCompletionTokens, &flavor, &p.CreatedAt, &p.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("provider not found")
@@ -116,6 +136,12 @@ func (s *ProviderService) GetByID(id int) (*models.Provider, error) {
 	if defaultModel.Valid {
 		p.DefaultModel = defaultModel.String
 	}
+	applyProviderAuth(&p, authStyle, customHeaders)
+	p.Flavor = flavor.String
+	if usesMaxCompletionTokens.Valid {
+		val := usesMaxCompletionTokens.Bool
+		p.UsesMaxCompletionTokens = &val
+	}
 
 	return &p, nil
 }
@@ -123,12 +149,13 @@ func (s *ProviderService) GetByID(id int) (*models.Provider, error) {
 // GetByName returns a provider by name
 func (s *ProviderService) GetByName(name string) (*models.Provider, error) {
 	var p models.Provider
-	var systemPrompt, favicon, defaultModel sql.NullString
+	var systemPrompt, favicon, defaultModel, authStyle, customHeaders, flavor sql.NullString
+	var usesMaxCompletionTokens sql.NullBool
 	err := s.db.QueryRow(`
-		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, created_at, updated_at
+		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, auth_style, custom_headers, uses_max_completion_tokens, flavor, created_at, updated_at
 		FROM providers
 		WHERE name = ?
-	`, name).Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &p.CreatedAt, &p.UpdatedAt)
+	`, name).Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &authStyle, &customHeaders, &usesMaxCompletionTokens, &flavor, &p.CreatedAt, &p.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found, not an error
@@ -146,16 +173,51 @@ func (s *ProviderService) GetByName(name string) (*models.Provider, error) {
 	if defaultModel.Valid {
 		p.DefaultModel = defaultModel.String
 	}
+	applyProviderAuth(&p, authStyle, customHeaders)
+	p.Flavor = flavor.String
+	if usesMaxCompletionTokens.Valid {
+		val := usesMaxCompletionTokens.Bool
+		p.UsesMaxCompletionTokens = &val
+	}
 
 	return &p, nil
 }
 
+// applyProviderAuth fills in the auth style and custom headers read from the database
+func applyProviderAuth(p *models.Provider, authStyle, customHeaders sql.NullString) {
+	if authStyle.Valid {
+		p.AuthStyle = authStyle.String
+	}
+	if customHeaders.Valid && customHeaders.String != "" {
+		if err := json.Unmarshal([]byte(customHeaders.String), &p.Headers); err != nil {
+			log.Printf("⚠️  Ignoring invalid custom headers for provider %s: %v", p.Name, err)
+		}
+	}
+}
+
+// encodeProviderHeaders serializes custom headers for storage (NULL when there are none)
+func encodeProviderHeaders(headers map[string]string) (sql.NullString, error) {
+	if len(headers) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode custom headers: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
 // Create creates a new provider
 func (s *ProviderService) Create(config models.ProviderConfig) (*models.Provider, error) {
+	customHeaders, err := encodeProviderHeaders(config.Headers)
+	if err != nil {
+		return nil, err
+	}
+
 	result, err := s.db.Exec(`
-		INSERT INTO providers (name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, default_model, system_prompt, favicon)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, config.Name, config.BaseURL, config.APIKey, config.Enabled, config.AudioOnly, config.ImageOnly, config.ImageEditOnly, config.DefaultModel, config.SystemPrompt, config.Favicon)
+		INSERT INTO providers (name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, default_model, system_prompt, favicon, auth_style, custom_headers, uses_max_completion_tokens, flavor)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, config.Name, config.BaseURL, config.APIKey, config.Enabled, config.AudioOnly, config.ImageOnly, config.ImageEditOnly, config.DefaultModel, config.SystemPrompt, config.Favicon, config.AuthStyle, customHeaders, config.UsesMaxCompletionTokens, config.Flavor)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create provider: %w", err)
@@ -172,13 +234,19 @@ func (s *ProviderService) Create(config models.ProviderConfig) (*models.Provider
 
 // Update updates an existing provider
 func (s *ProviderService) Update(id int, config models.ProviderConfig) error {
-	_, err := s.db.Exec(`
+	customHeaders, err := encodeProviderHeaders(config.Headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
 		UPDATE providers
 		SET base_url = ?, api_key = ?, enabled = ?, audio_only = ?, image_only = ?, image_edit_only = ?,
-		    default_model = ?, system_prompt = ?, favicon = ?, updated_at = CURRENT_TIMESTAMP
+		    default_model = ?, system_prompt = ?, favicon = ?, auth_style = ?, custom_headers = ?,
+		    uses_max_completion_tokens = ?, flavor = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`, config.BaseURL, config.APIKey, config.Enabled, config.AudioOnly, config.ImageOnly, config.ImageEditOnly,
-		config.DefaultModel, config.SystemPrompt, config.Favicon, id)
+		config.DefaultModel, config.SystemPrompt, config.Favicon, config.AuthStyle, customHeaders, config.UsesMaxCompletionTokens, config.Flavor, id)
 
 	if err != nil {
 		return fmt.Errorf("failed to update provider: %w", err)
@@ -312,7 +380,7 @@ func (s *ProviderService) GetByModelID(modelID string) (*models.Provider, error)
 // GetAllIncludingDisabled returns all providers including disabled ones
 func (s *ProviderService) GetAllIncludingDisabled() ([]models.Provider, error) {
 	rows, err := s.db.Query(`
-		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, created_at, updated_at
+		SELECT id, name, base_url, api_key, enabled, audio_only, image_only, image_edit_only, secure, default_model, system_prompt, favicon, auth_style, custom_headers, uses_max_completion_tokens, flavor, created_at, updated_at
 		FROM providers
 		ORDER BY name
 	`)
@@ -324,8 +392,9 @@ func (s *ProviderService) GetAllIncludingDisabled() ([]models.Provider, error) {
 	var providers []models.Provider
 	for rows.Next() {
 		var p models.Provider
-		var systemPrompt, favicon, defaultModel sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		var systemPrompt, favicon, defaultModel, authStyle, customHeaders, flavor sql.NullString
+		var usesMaxCompletionTokens sql.NullBool
+		if err := rows.Scan(&p.ID, &p.Name, &p.BaseURL, &p.APIKey, &p.Enabled, &p.AudioOnly, &p.ImageOnly, &p.ImageEditOnly, &p.Secure, &defaultModel, &systemPrompt, &favicon, &authStyle, &customHeaders, &usesMaxCompletionTokens, &flavor, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan provider: %w", err)
 		}
 		if systemPrompt.Valid {
@@ -337,6 +406,12 @@ func (s *ProviderService) GetAllIncludingDisabled() ([]models.Provider, error) {
 		if defaultModel.Valid {
 			p.DefaultModel = defaultModel.String
 		}
+		applyProviderAuth(&p, authStyle, customHeaders)
+		p.Flavor = flavor.String
+		if usesMaxCompletionTokens.Valid {
+			val := usesMaxCompletionTokens.Bool
+			p.UsesMaxCompletionTokens = &val
+		}
 		providers = append(providers, p)
 	}
 
@@ -26,6 +26,8 @@ type SchedulerService struct {
 	agentService     *AgentService
 	executionService *ExecutionService
 	workflowExecutor models.WorkflowExecuteFunc
+	activeExecutions *ActiveExecutionRegistry
+	webhooks         *CompletionWebhookService
 	instanceID       string
 	mu               sync.RWMutex
 	jobs             map[string]gocron.Job // scheduleID -> job
@@ -86,6 +88,39 @@ func (s *SchedulerService) SetWorkflowExecutor(executor models.WorkflowExecuteFu
 	s.workflowExecutor = executor
 }
 
+// SetActiveExecutions sets the registry used to cancel running executions by ID
+func (s *SchedulerService) SetActiveExecutions(registry *ActiveExecutionRegistry) {
+	s.activeExecutions = registry
+}
+
+// SetCompletionWebhookService sets the service notifying agents' completion webhooks
+func (s *SchedulerService) SetCompletionWebhookService(svc *CompletionWebhookService) {
+	s.webhooks = svc
+}
+
+// scheduledAPIResponse builds the completion webhook payload for a scheduled run
+func scheduledAPIResponse(execRecord *ExecutionRecord, agentID string, result *models.WorkflowExecuteResult, execErr error, durationMs int64) *models.ExecutionAPIResponse {
+	executionID := ""
+	if execRecord != nil {
+		executionID = execRecord.ID.Hex()
+	}
+	if execErr != nil || result == nil || result.APIResponse == nil {
+		errMsg := "execution produced no result"
+		if execErr != nil {
+			errMsg = execErr.Error()
+		} else if result != nil && result.Error != "" {
+			errMsg = result.Error
+		}
+		return models.NewFailedExecutionAPIResponse(executionID, agentID, errMsg, durationMs)
+	}
+
+	response := result.APIResponse
+	response.Metadata.ExecutionID = executionID
+	response.Metadata.AgentID = agentID
+	response.Metadata.DurationMs = durationMs
+	return response
+}
+
 // loadSchedules loads all enabled schedules from MongoDB and registers them
 func (s *SchedulerService) loadSchedules(ctx context.Context) error {
 	if s.mongoDB == nil {
@@ -213,6 +248,12 @@ func (s *SchedulerService) executeScheduledJob(schedule *models.Schedule) {
 		return
 	}
 
+	// A disabled agent's schedule stays in place but does not run (or count as failed)
+	if err := CheckAgentEnabled(agent); err != nil {
+		log.Printf("⛔ Skipping scheduled run of agent %s: %v", schedule.AgentID, err)
+		return
+	}
+
 	if agent.Workflow == nil {
 		log.Printf("❌ Agent %s has no workflow", schedule.AgentID)
 		s.updateScheduleStats(ctx, schedule.ID, false, schedule)
@@ -269,18 +310,29 @@ func (s *SchedulerService) executeScheduledJob(schedule *models.Schedule) {
 		return
 	}
 
+	// Register so the execution can be cancelled via POST /api/executions/:id/cancel
+	execCtx := ctx
+	var active *ActiveExecution
+	if execRecord != nil {
+		execCtx, active = s.activeExecutions.Register(ctx, execRecord.ID.Hex(), schedule.UserID)
+		defer s.activeExecutions.Unregister(active)
+	}
+
 	// Execute the workflow using the callback function
-	result, execErr := executor(agent.Workflow, input)
+	startTime := time.Now()
+	result, execErr := executor(execCtx, agent.Workflow, input)
+	s.activeExecutions.Unregister(active)
 
 	// Determine success status
 	status := "failed"
 	if result != nil {
 		status = result.Status
 	}
-	success := status == "completed" && execErr == nil
+	// Only best-effort (continue_on_error) blocks failed in a completed_with_errors run
+	success := (status == "completed" || status == "completed_with_errors") && execErr == nil
 
-	// Complete the execution record
-	if execRecord != nil {
+	// Complete the execution record (the cancel endpoint records cancelled executions itself)
+	if execRecord != nil && !active.Cancelled() {
 		completeReq := &ExecutionCompleteRequest{
 			Status: status,
 		}
@@ -309,6 +361,10 @@ func (s *SchedulerService) executeScheduledJob(schedule *models.Schedule) {
 		log.Printf("❌ Scheduled execution failed for agent %s: %s (status: %s)", schedule.AgentID, errMsg, status)
 	}
 
+	if s.webhooks != nil && !active.Cancelled() {
+		s.webhooks.Notify(agent, scheduledAPIResponse(execRecord, schedule.AgentID, result, execErr, time.Since(startTime).Milliseconds()))
+	}
+
 	// Update schedule statistics and next run time
 	s.updateScheduleStats(ctx, schedule.ID, success, schedule)
 }
@@ -164,6 +164,9 @@ func (s *TierService) mergeLimits(base, override models.TierLimits) models.TierL
 	if override.MaxImageGensPerDay != 0 {
 		result.MaxImageGensPerDay = override.MaxImageGensPerDay
 	}
+	if override.MaxMCPTools != 0 {
+		result.MaxMCPTools = override.MaxMCPTools
+	}
 
 	return result
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -222,7 +223,7 @@ Select the minimal set of tools needed. Return JSON with selected_tools and reas
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
 	// Send request with 30s timeout
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -949,6 +949,17 @@ var ToolRegistry = []ToolDefinition{
 		Parameters:  "action: insertOne|insertMany|updateOne|updateMany, collection: Collection name, document: Document to insert, documents: Array for insertMany, filter: Update filter, update: Update operations",
 		CodeBlockExample: `{"action": "insertOne", "collection": "users", "document": {"name": "John", "email": "john@example.com"}}`,
 	},
+	{
+		ID:          "sql_query",
+		Name:        "SQL Query",
+		Description: "Run parameterized SQL queries against a MySQL/MariaDB database - read-only unless writes are enabled",
+		Category:    "database",
+		Icon:        "Database",
+		Keywords:    []string{"sql", "database", "query", "select", "mysql", "mariadb", "table", "rows", "report"},
+		UseCases:    []string{"Pull rows from a SQL table", "Run reporting queries", "Look up records by ID"},
+		Parameters:  "query: Single SQL statement with ? placeholders, params: Values for the placeholders, limit: Max rows (default 100), timeout_seconds: Query timeout (default 30)",
+		CodeBlockExample: `{"query": "SELECT id, email FROM users WHERE status = ? ORDER BY created_at DESC", "params": ["active"], "limit": 50}`,
+	},
 	{
 		ID:          "redis_read",
 		Name:        "Redis Read",
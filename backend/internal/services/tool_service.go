@@ -1,10 +1,15 @@
 package services
 
 import (
+	"claraverse/internal/models"
 	"claraverse/internal/tools"
 	"context"
+	"fmt"
 	"log"
+	"sort"
 	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ToolService handles tool-related operations with credential awareness.
@@ -13,6 +18,7 @@ import (
 type ToolService struct {
 	toolRegistry      *tools.Registry
 	credentialService *CredentialService
+	mcpBridge         *MCPBridgeService
 }
 
 // NewToolService creates a new tool service
@@ -23,6 +29,11 @@ func NewToolService(registry *tools.Registry, credentialService *CredentialServi
 	}
 }
 
+// SetMCPBridgeService sets the MCP bridge used to check whether MCP tools are reachable
+func (s *ToolService) SetMCPBridgeService(bridge *MCPBridgeService) {
+	s.mcpBridge = bridge
+}
+
 // GetAvailableTools returns tools filtered by user's credentials.
 // - Tools not in ToolIntegrationMap are always included (no credential needed)
 // - Tools in ToolIntegrationMap are only included if user has a credential for that integration type
@@ -194,3 +205,133 @@ func (s *ToolService) CreateCredentialResolver(userID string) tools.CredentialRe
 func (s *ToolService) GetCredentialService() *CredentialService {
 	return s.credentialService
 }
+
+// Tool sources reported by PreviewWorkflowTools
+const (
+	ToolPreviewSourceBuiltin     = "builtin"
+	ToolPreviewSourceMCP         = "mcp"
+	ToolPreviewSourceIntegration = "integration"
+	ToolPreviewSourceUnknown     = "unknown" // not registered for the user
+)
+
+// ToolPreview describes a tool a workflow is configured to use and whether it can run right now
+type ToolPreview struct {
+	Name            string   `json:"name"`
+	DisplayName     string   `json:"display_name,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	Source          string   `json:"source"`
+	IntegrationType string   `json:"integration_type,omitempty"`
+	Usable          bool     `json:"usable"`
+	Reason          string   `json:"reason,omitempty"` // Why the tool is not usable
+	Blocks          []string `json:"blocks"`           // Names of the blocks using the tool
+}
+
+// PreviewWorkflowTools resolves the tools used by the workflow's blocks for a user, the
+// same way the engine does at execution time, without running anything. MCP tools are
+// usable while the user's MCP client is connected; integration tools need a credential
+// of their integration type.
+func (s *ToolService) PreviewWorkflowTools(ctx context.Context, userID string, workflow *models.Workflow) ([]ToolPreview, error) {
+	toolBlocks := workflowToolBlocks(workflow)
+	if len(toolBlocks) == 0 {
+		return []ToolPreview{}, nil
+	}
+
+	integrations, err := s.GetUserIntegrationTypes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	mcpConnected := s.mcpBridge != nil && s.mcpBridge.IsUserConnected(userID)
+
+	previews := make([]ToolPreview, 0, len(toolBlocks))
+	for name, blocks := range toolBlocks {
+		preview := ToolPreview{
+			Name:            name,
+			IntegrationType: tools.GetIntegrationTypeForTool(name),
+			Blocks:          blocks,
+		}
+
+		tool, exists := s.toolRegistry.GetUserTool(userID, name)
+		switch {
+		case !exists:
+			preview.Source = ToolPreviewSourceUnknown
+			preview.Reason = "Tool is not registered"
+			if !mcpConnected {
+				preview.Reason += " (no MCP client is connected)"
+			}
+		case tool.Source == tools.ToolSourceMCPLocal:
+			preview.Source = ToolPreviewSourceMCP
+			preview.Usable = mcpConnected
+			if !mcpConnected {
+				preview.Reason = "MCP client is not connected"
+			}
+		case preview.IntegrationType != "" || tool.Source == tools.ToolSourceComposio:
+			preview.Source = ToolPreviewSourceIntegration
+			preview.Usable = preview.IntegrationType == "" || integrations[preview.IntegrationType]
+			if !preview.Usable {
+				preview.Reason = fmt.Sprintf("No %s credential configured", preview.IntegrationType)
+			}
+		default:
+			preview.Source = ToolPreviewSourceBuiltin
+			preview.Usable = true
+		}
+		if exists {
+			preview.DisplayName = tool.DisplayName
+			preview.Description = tool.Description
+		}
+
+		previews = append(previews, preview)
+	}
+
+	sort.Slice(previews, func(i, j int) bool {
+		return previews[i].Name < previews[j].Name
+	})
+	return previews, nil
+}
+
+// workflowToolBlocks maps each tool referenced by the workflow to the names of the
+// blocks using it: enabled tools of llm_inference blocks and the tool of code blocks
+func workflowToolBlocks(workflow *models.Workflow) map[string][]string {
+	toolBlocks := make(map[string][]string)
+	if workflow == nil {
+		return toolBlocks
+	}
+
+	for _, block := range workflow.Blocks {
+		var names []string
+		switch block.Type {
+		case "llm_inference":
+			names = toolNameList(block.Config["enabledTools"])
+			if len(names) == 0 {
+				names = toolNameList(block.Config["enabled_tools"])
+			}
+		case "tool_execution", "code_block":
+			if name, ok := block.Config["toolName"].(string); ok && name != "" {
+				names = []string{name}
+			}
+		}
+
+		for _, name := range names {
+			toolBlocks[name] = append(toolBlocks[name], block.Name)
+		}
+	}
+	return toolBlocks
+}
+
+// toolNameList reads a block config list that may be []string, []any (JSON) or primitive.A (BSON)
+func toolNameList(raw any) []string {
+	switch v := raw.(type) {
+	case []string:
+		return v
+	case primitive.A:
+		return toolNameList([]any(v))
+	case []any:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			if name, ok := item.(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
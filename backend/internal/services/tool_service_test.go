@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"claraverse/internal/models"
+	"claraverse/internal/tools"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPreviewWorkflowTools(t *testing.T) {
+	registry := tools.GetRegistry()
+	userID := "preview-user"
+	if err := registry.RegisterUserTool(userID, &tools.Tool{Name: "local_notes", DisplayName: "Local Notes"}); err != nil {
+		t.Fatalf("failed to register MCP tool: %v", err)
+	}
+	t.Cleanup(func() { registry.UnregisterAllUserTools(userID) })
+
+	var builtin string
+	for name := range toolNamesOf(registry.List()) {
+		if tools.GetIntegrationTypeForTool(name) == "" {
+			builtin = name
+			break
+		}
+	}
+
+	workflow := &models.Workflow{Blocks: []models.Block{
+		{Name: "Research", Type: "llm_inference", Config: map[string]any{
+			"enabledTools": []any{builtin, "local_notes", "send_slack_message"},
+		}},
+		{Name: "Notify", Type: "tool_execution", Config: map[string]any{"toolName": "send_slack_message"}},
+		{Name: "Legacy", Type: "llm_inference", Config: map[string]any{"enabled_tools": primitive.A{"missing_tool"}}},
+	}}
+
+	previews, err := NewToolService(registry, nil).PreviewWorkflowTools(context.Background(), userID, workflow)
+	if err != nil {
+		t.Fatalf("PreviewWorkflowTools failed: %v", err)
+	}
+
+	byName := make(map[string]ToolPreview)
+	for _, preview := range previews {
+		byName[preview.Name] = preview
+	}
+	if len(byName) != 4 {
+		t.Fatalf("expected 4 distinct tools, got %+v", previews)
+	}
+
+	expect := map[string]struct {
+		source string
+		usable bool
+	}{
+		builtin:              {ToolPreviewSourceBuiltin, true},
+		"local_notes":        {ToolPreviewSourceMCP, false},
+		"send_slack_message": {ToolPreviewSourceIntegration, false},
+		"missing_tool":       {ToolPreviewSourceUnknown, false},
+	}
+	for name, want := range expect {
+		got := byName[name]
+		if got.Source != want.source || got.Usable != want.usable {
+			t.Errorf("%s: expected source %s usable %v, got %+v", name, want.source, want.usable, got)
+		}
+		if !got.Usable && got.Reason == "" {
+			t.Errorf("%s: expected a reason for an unusable tool", name)
+		}
+	}
+	if blocks := byName["send_slack_message"].Blocks; len(blocks) != 2 {
+		t.Errorf("expected send_slack_message to be used by 2 blocks, got %v", blocks)
+	}
+}
+
+// toolNamesOf collects tool names from OpenAI tool definitions
+func toolNamesOf(defs []map[string]interface{}) map[string]bool {
+	names := make(map[string]bool)
+	for _, def := range defs {
+		if name := extractToolName(def); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -1,7 +1,6 @@
 package services
 
 import (
-	"claraverse/internal/database"
 	"claraverse/internal/vision"
 	"fmt"
 	"log"
@@ -9,16 +8,21 @@ import (
 )
 
 var (
-	visionInitOnce       sync.Once
-	visionProviderSvc    *ProviderService
-	visionDB             *database.DB
+	visionInitOnce        sync.Once
+	visionProviderSvc     *ProviderService
+	visionPromptTemplates map[string]string
 )
 
 // SetVisionDependencies sets the dependencies needed for vision service
 // Must be called before InitVisionService
-func SetVisionDependencies(providerService *ProviderService, db *database.DB) {
+func SetVisionDependencies(providerService *ProviderService) {
 	visionProviderSvc = providerService
-	visionDB = db
+}
+
+// SetVisionPromptTemplates overrides the vision prompts per detail level
+// Must be called before InitVisionService
+func SetVisionPromptTemplates(templates map[string]string) {
+	visionPromptTemplates = templates
 }
 
 // InitVisionService initializes the vision package with provider access
@@ -29,8 +33,6 @@ func InitVisionService() {
 	}
 
 	visionInitOnce.Do(func() {
-		configService := GetConfigService()
-
 		// Provider getter callback
 		providerGetter := func(id int) (*vision.Provider, error) {
 			p, err := visionProviderSvc.GetByID(id)
@@ -38,56 +40,65 @@ func InitVisionService() {
 				return nil, err
 			}
 			return &vision.Provider{
-				ID:      p.ID,
-				Name:    p.Name,
-				BaseURL: p.BaseURL,
-				APIKey:  p.APIKey,
-				Enabled: p.Enabled,
+				ID:                      p.ID,
+				Name:                    p.Name,
+				BaseURL:                 p.BaseURL,
+				APIKey:                  p.APIKey,
+				Enabled:                 p.Enabled,
+				AuthStyle:               p.AuthStyle,
+				Headers:                 p.Headers,
+				UsesMaxCompletionTokens: p.UsesMaxCompletionTokens,
+				Flavor:                  p.Flavor,
 			}, nil
 		}
 
-		// Vision model finder callback
+		// Vision model finder callback: the first vision model in the catalog, aliases first
 		visionModelFinder := func() (int, string, error) {
-			// First check aliases for vision-capable models
-			allAliases := configService.GetAllModelAliases()
-
-			for providerID, aliases := range allAliases {
-				for _, aliasInfo := range aliases {
-					if aliasInfo.SupportsVision != nil && *aliasInfo.SupportsVision {
-						provider, err := visionProviderSvc.GetByID(providerID)
-						if err == nil && provider.Enabled {
-							log.Printf("🖼️ [VISION-INIT] Found vision model via alias: %s -> %s", aliasInfo.DisplayName, aliasInfo.ActualModel)
-							return providerID, aliasInfo.ActualModel, nil
-						}
-					}
-				}
+			catalog := GetModelCatalog()
+			if catalog == nil {
+				return 0, "", fmt.Errorf("model catalog not available")
 			}
-
-			// Fallback: Check database for vision models
-			if visionDB == nil {
-				return 0, "", fmt.Errorf("database not available")
+			models, err := catalog.VisionModels()
+			if err != nil {
+				return 0, "", fmt.Errorf("no vision model found: %w", err)
+			}
+			if len(models) == 0 {
+				return 0, "", fmt.Errorf("no vision model found")
 			}
 
-			var providerID int
-			var modelName string
-			err := visionDB.QueryRow(`
-				SELECT m.provider_id, m.name
-				FROM models m
-				JOIN providers p ON m.provider_id = p.id
-				WHERE m.supports_vision = 1 AND m.is_visible = 1 AND p.enabled = 1
-				ORDER BY m.provider_id ASC
-				LIMIT 1
-			`).Scan(&providerID, &modelName)
+			model := models[0]
+			log.Printf("🖼️ [VISION-INIT] Found vision model: %s (provider: %d)", model.Name, model.ProviderID)
+			return model.ProviderID, model.Name, nil
+		}
 
+		// Vision model lister callback: every vision model, aliases first (failover list for batches)
+		visionModelLister := func() ([]vision.VisionModel, error) {
+			catalog := GetModelCatalog()
+			if catalog == nil {
+				return nil, fmt.Errorf("model catalog not available")
+			}
+			catalogModels, err := catalog.VisionModels()
 			if err != nil {
-				return 0, "", fmt.Errorf("no vision model found: %w", err)
+				return nil, fmt.Errorf("failed to list vision models: %w", err)
 			}
 
-			log.Printf("🖼️ [VISION-INIT] Found vision model from database: %s (provider: %d)", modelName, providerID)
-			return providerID, modelName, nil
+			var models []vision.VisionModel
+			seen := make(map[vision.VisionModel]bool)
+			for _, catalogModel := range catalogModels {
+				model := vision.VisionModel{ProviderID: catalogModel.ProviderID, ModelName: catalogModel.Name}
+				if !seen[model] {
+					seen[model] = true
+					models = append(models, model)
+				}
+			}
+
+			if len(models) == 0 {
+				return nil, fmt.Errorf("no vision model found")
+			}
+			return models, nil
 		}
 
-		vision.InitService(providerGetter, visionModelFinder)
+		vision.InitService(providerGetter, visionModelFinder, visionPromptTemplates).SetVisionModelLister(visionModelLister)
 		log.Printf("✅ [VISION-INIT] Vision service initialized")
 	})
 }
@@ -15,6 +15,7 @@ import (
 
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 )
 
 // WorkflowGeneratorService handles workflow generation with structured output
@@ -848,7 +849,7 @@ func (s *WorkflowGeneratorService) GenerateWorkflow(req *models.WorkflowGenerate
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
 	// Send request with timeout
-	client := &http.Client{Timeout: 120 * time.Second}
+	client := providerhttp.NewClient(120 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -1191,7 +1192,7 @@ DESC: Analyzes data files and generates visual charts`,
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
 	// Send request with timeout
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -1385,7 +1386,7 @@ RESPOND with ONLY the description text, nothing else.`,
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := providerhttp.NewClient(30 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
@@ -1537,7 +1538,7 @@ EXAMPLES:
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
 	// Send request with timeout
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := providerhttp.NewClient(60 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -14,6 +14,7 @@ import (
 
 	"claraverse/internal/database"
 	"claraverse/internal/models"
+	"claraverse/internal/providerhttp"
 )
 
 // WorkflowGeneratorV2Service handles multi-step workflow generation
@@ -236,7 +237,7 @@ func (s *WorkflowGeneratorV2Service) Step1SelectTools(req *MultiStepGenerateRequ
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
 	// Send request
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := providerhttp.NewClient(60 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -374,7 +375,7 @@ func (s *WorkflowGeneratorV2Service) Step2GenerateWorkflow(
 	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
 
 	// Send request
-	client := &http.Client{Timeout: 120 * time.Second}
+	client := providerhttp.NewClient(120 * time.Second)
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
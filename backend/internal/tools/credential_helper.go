@@ -15,6 +15,11 @@ type CredentialResolver func(credentialID string) (*models.DecryptedCredential,
 const CredentialResolverKey = "__credential_resolver__"
 const UserIDKey = "__user_id__"
 
+// ExecutionContextKey carries the workflow execution context.Context into tool args
+// Tools making HTTP requests pass its link on with SetExecutionLinkHeader so
+// workflows they trigger nest under the execution (see ExecutionLinkHeader)
+const ExecutionContextKey = "__execution_context__"
+
 // Note: CreateCredentialResolver is defined in services/credential_service.go
 // to avoid import cycles (services imports tools, so tools cannot import services)
 
@@ -33,8 +33,10 @@ Parameters:
 - file_id: Alternative - use the direct file ID from an upload response
 - question: Optional specific question about the image
 - detail: "brief" for 1-2 sentences, "detailed" for comprehensive description
+- keep_session: Set to true to get a session_id for follow-up questions about the same image
+- session_id: The session_id from a previous describe_image result (made with keep_session), to ask a follow-up question about the same image without re-sending it
 
-You must provide one of: image_url, image_id, OR file_id. Use image_url for web images, image_id for generated/edited images, file_id for uploaded files.`,
+You must provide one of: image_url, image_id, file_id, OR session_id (with a question). Use image_url for web images, image_id for generated/edited images, file_id for uploaded files.`,
 		Icon: "Image",
 		Parameters: map[string]interface{}{
 			"type": "object",
@@ -60,6 +62,19 @@ You must provide one of: image_url, image_id, OR file_id. Use image_url for web
 					"enum":        []string{"brief", "detailed"},
 					"description": "Level of detail: 'brief' for 1-2 sentences, 'detailed' for comprehensive description. Default is 'detailed'",
 				},
+				"image_detail": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"auto", "low", "high", "auto-smart"},
+					"description": "Optional image resolution the model sees: 'low' is cheaper, 'high' keeps fine detail, 'auto-smart' picks low for small images and high for large ones. Default is 'auto'",
+				},
+				"keep_session": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Optional: set to true only if you expect follow-up questions about this image. The result then includes a session_id.",
+				},
+				"session_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: session_id returned by a previous describe_image call made with keep_session. Use with 'question' to ask a follow-up about the same image.",
+				},
 			},
 			"required": []string{},
 		},
@@ -82,6 +97,11 @@ func executeDescribeImage(args map[string]interface{}) (string, error) {
 	imageID, hasImageID := args["image_id"].(string)
 	fileID, hasFileID := args["file_id"].(string)
 
+	// Follow-up questions on an existing session don't need the image again
+	if sessionID, ok := args["session_id"].(string); ok && sessionID != "" {
+		return executeDescribeImageFollowUp(args, sessionID)
+	}
+
 	if (!hasImageURL || imageURL == "") && (!hasImageID || imageID == "") && (!hasFileID || fileID == "") {
 		return "", fmt.Errorf("one of image_url, image_id, or file_id is required. Use image_url for web images, image_id (e.g., 'img-1') for generated images, or file_id for uploaded files")
 	}
@@ -98,10 +118,20 @@ func executeDescribeImage(args map[string]interface{}) (string, error) {
 		detail = d
 	}
 
+	// Extract image detail level (the vision service defaults to "auto")
+	imageDetail, _ := args["image_detail"].(string)
+
 	// Extract user context (injected by tool executor)
 	userID, _ := args["__user_id__"].(string)
 	convID, _ := args["__conversation_id__"].(string)
 
+	// Sessions belong to a user, so without one no follow-ups are possible
+	keepSession, _ := args["keep_session"].(bool)
+	if keepSession && userID == "" {
+		log.Printf("⚠️ [DESCRIBE-IMAGE] keep_session ignored: no user to own the session")
+		keepSession = false
+	}
+
 	// Variables to hold image data and metadata
 	var imageData []byte
 	var mimeType string
@@ -224,17 +254,20 @@ func executeDescribeImage(args map[string]interface{}) (string, error) {
 
 	// Build the request
 	req := &vision.DescribeImageRequest{
-		ImageData: imageData,
-		MimeType:  mimeType,
-		Question:  question,
-		Detail:    detail,
+		ImageData:     imageData,
+		MimeType:      mimeType,
+		Question:      question,
+		Detail:        detail,
+		ImageDetail:   imageDetail,
+		CreateSession: keepSession,
+		OwnerID:       userID,
 	}
 
 	// Call vision service
 	result, err := visionService.DescribeImage(req)
 	if err != nil {
 		log.Printf("❌ [DESCRIBE-IMAGE] Vision analysis failed: %v", err)
-		return "", fmt.Errorf("failed to analyze image: %v", err)
+		return "", describeImageError(err)
 	}
 
 	// Build response
@@ -263,6 +296,10 @@ func executeDescribeImage(args map[string]interface{}) (string, error) {
 		response["question"] = question
 	}
 
+	if result.SessionID != "" {
+		response["session_id"] = result.SessionID
+	}
+
 	responseJSON, err := json.Marshal(response)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal response: %w", err)
@@ -273,6 +310,66 @@ func executeDescribeImage(args map[string]interface{}) (string, error) {
 	return string(responseJSON), nil
 }
 
+// describeImageError words a vision failure so the caller can tell a missing vision
+// model (configure one) from provider failures (retry later)
+func describeImageError(err error) error {
+	visionErr, ok := vision.AsError(err)
+	if !ok {
+		return fmt.Errorf("failed to analyze image: %v", err)
+	}
+	switch visionErr.Code {
+	case vision.ErrCodeNoVisionModel:
+		return fmt.Errorf("failed to analyze image [%s]: %v. Please configure a vision-capable model (e.g., GPT-4o)", visionErr.Code, err)
+	case vision.ErrCodeRateLimited, vision.ErrCodeAllProvidersFailed:
+		providers := make([]string, 0, len(visionErr.Attempts))
+		for _, attempt := range visionErr.Attempts {
+			providers = append(providers, attempt.Provider+"/"+attempt.Model)
+		}
+		return fmt.Errorf("failed to analyze image [%s]: %v (tried %s). Retry later", visionErr.Code, err, strings.Join(providers, ", "))
+	default:
+		return fmt.Errorf("failed to analyze image [%s]: %v", visionErr.Code, err)
+	}
+}
+
+// executeDescribeImageFollowUp asks another question about an image from a previous call
+func executeDescribeImageFollowUp(args map[string]interface{}, sessionID string) (string, error) {
+	question, _ := args["question"].(string)
+	if strings.TrimSpace(question) == "" {
+		return "", fmt.Errorf("question is required when using session_id")
+	}
+
+	visionService := vision.GetService()
+	if visionService == nil {
+		return "", fmt.Errorf("vision service not available. Please configure a vision-capable model (e.g., GPT-4o)")
+	}
+
+	userID, _ := args["__user_id__"].(string)
+	result, err := visionService.DescribeImage(&vision.DescribeImageRequest{
+		Question:  question,
+		SessionID: sessionID,
+		OwnerID:   userID,
+	})
+	if err != nil {
+		log.Printf("❌ [DESCRIBE-IMAGE] Follow-up failed: %v", err)
+		return "", fmt.Errorf("failed to answer follow-up: %v. Call describe_image again with the image to start a new session", err)
+	}
+
+	responseJSON, err := json.Marshal(map[string]interface{}{
+		"success":     true,
+		"description": result.Description,
+		"model":       result.Model,
+		"provider":    result.Provider,
+		"question":    question,
+		"session_id":  result.SessionID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	log.Printf("✅ [DESCRIBE-IMAGE] Answered follow-up on session %s using %s", sessionID, result.Model)
+	return string(responseJSON), nil
+}
+
 // fetchImageFromURL downloads an image from a URL and returns the data, mime type, and filename
 func fetchImageFromURL(urlStr string) ([]byte, string, string, error) {
 	// Validate URL using the existing validation function from download_file_tool
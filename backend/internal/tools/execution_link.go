@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ExecutionLinkHeader carries the link token of the workflow execution a tool runs
+// in on the HTTP requests it makes. When such a request triggers another workflow
+// through the API, the triggered execution nests under the calling one, so
+// recursion depth and tool call budgets hold across the whole tree.
+const ExecutionLinkHeader = "X-ClaraVerse-Execution"
+
+// executionLinkKey is the context key for an execution's link token
+type executionLinkKey struct{}
+
+// WithExecutionLink returns ctx carrying the link token of the execution running in it
+func WithExecutionLink(ctx context.Context, link string) context.Context {
+	return context.WithValue(ctx, executionLinkKey{}, link)
+}
+
+// ExecutionLink returns the link token of the execution running in ctx ("" outside one)
+func ExecutionLink(ctx context.Context) string {
+	link, _ := ctx.Value(executionLinkKey{}).(string)
+	return link
+}
+
+// SetExecutionLinkHeader adds the link of the execution calling a tool, taken from
+// the execution context in its args, to a request the tool makes. Only requests to
+// this backend (BACKEND_URL) can trigger a workflow, so the link is never sent to
+// any other host.
+func SetExecutionLinkHeader(req *http.Request, args map[string]interface{}) {
+	if !isBackendURL(req.URL) {
+		return
+	}
+	ctx, ok := args[ExecutionContextKey].(context.Context)
+	if !ok {
+		return
+	}
+	if link := ExecutionLink(ctx); link != "" {
+		req.Header.Set(ExecutionLinkHeader, link)
+	}
+}
+
+// isBackendURL reports whether u has the scheme and host of this backend's public
+// URL (BACKEND_URL)
+func isBackendURL(u *url.URL) bool {
+	backendURL := os.Getenv("BACKEND_URL")
+	if backendURL == "" {
+		backendURL = "http://localhost:3001" // Default fallback for development
+	}
+	backend, err := url.Parse(backendURL)
+	if err != nil || u == nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, backend.Scheme) &&
+		strings.EqualFold(u.Hostname(), backend.Hostname()) &&
+		urlPort(u) == urlPort(backend)
+}
+
+// urlPort returns the port of u, or the default port of its scheme
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
+	}
+	return ""
+}
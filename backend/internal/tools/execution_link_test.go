@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestSetExecutionLinkHeaderOnlyForBackend(t *testing.T) {
+	t.Setenv("BACKEND_URL", "https://api.claraverse.example")
+	args := map[string]interface{}{ExecutionContextKey: WithExecutionLink(context.Background(), "link-1")}
+
+	cases := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.claraverse.example/api/trigger/agent-1", "link-1"},
+		{"https://API.claraverse.example:443/api/trigger/agent-1", "link-1"},
+		{"https://hooks.example.com/api/trigger/agent-1", ""},
+		{"http://api.claraverse.example/api/trigger/agent-1", ""},
+		{"https://api.claraverse.example:8443/api/trigger/agent-1", ""},
+		{"https://api.claraverse.example.evil.com/api/trigger/agent-1", ""},
+	}
+	for _, tc := range cases {
+		req, err := http.NewRequest(http.MethodPost, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetExecutionLinkHeader(req, args)
+		if got := req.Header.Get(ExecutionLinkHeader); got != tc.want {
+			t.Errorf("%s: expected link %q, got %q", tc.url, tc.want, got)
+		}
+	}
+}
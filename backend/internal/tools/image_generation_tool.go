@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"claraverse/internal/filecache"
+	"claraverse/internal/providerhttp"
 
 	"github.com/google/uuid"
 )
@@ -38,6 +39,7 @@ type ImageProviderConfig struct {
 	BaseURL      string
 	APIKey       string
 	DefaultModel string
+	Flavor       string // API dialect; "" = detect from BaseURL
 }
 
 // ImageGenerationRequest represents the OpenAI-compatible request format
@@ -250,8 +252,8 @@ func executeImageGeneration(args map[string]interface{}) (string, error) {
 	var imageData string
 	var err error
 
-	// Check if this is a Chutes API endpoint (simple /generate endpoint)
-	if strings.Contains(providerConfig.BaseURL, "chutes.ai") {
+	// Chutes has its own simple /generate endpoint
+	if providerhttp.ResolveFlavor(providerConfig.Flavor, providerConfig.BaseURL) == providerhttp.FlavorChutes {
 		imageData, err = executeChutesImageGeneration(providerConfig, prompt, width, height)
 	} else {
 		// Use OpenAI-compatible format
@@ -381,9 +383,7 @@ func executeChutesImageGeneration(config *ImageProviderConfig, prompt string, wi
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+config.APIKey)
-	}
+	providerhttp.SetAuthHeaders(req, config.Flavor, config.BaseURL, "", config.APIKey, nil)
 
 	// Execute request with timeout (image generation can take a while)
 	client := &http.Client{Timeout: 180 * time.Second}
@@ -450,9 +450,7 @@ func executeOpenAIImageGeneration(config *ImageProviderConfig, prompt, model str
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+config.APIKey)
-	}
+	providerhttp.SetAuthHeaders(req, config.Flavor, config.BaseURL, "", config.APIKey, nil)
 
 	// Execute request with timeout (image generation can take a while)
 	client := &http.Client{Timeout: 180 * time.Second}
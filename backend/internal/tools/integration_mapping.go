@@ -119,6 +119,7 @@ var ToolIntegrationMap = map[string]string{
 	"mongodb_write": "mongodb",
 	"redis_read":    "redis",
 	"redis_write":   "redis",
+	"sql_query":     "sql_database",
 
 	// Composio Google Sheets tools
 	"googlesheets_read":          "composio_googlesheets",
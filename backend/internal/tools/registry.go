@@ -1,10 +1,21 @@
 package tools
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 )
 
+// ErrToolNotFound is returned for calls to a tool that does not exist, e.g. a name
+// the model made up or a tool its MCP server no longer provides
+var ErrToolNotFound = errors.New("tool not found")
+
+// ErrToolUnavailable is returned for calls to a tool that exists but cannot run
+// right now, e.g. because the user's MCP client is disconnected
+var ErrToolUnavailable = errors.New("tool unavailable")
+
 // ToolSource represents where a tool comes from
 type ToolSource string
 
@@ -26,6 +37,22 @@ type Tool struct {
 	UserID      string      // For user-specific MCP tools (empty for built-in)
 	Category    string      // Tool category: data_sources, computation, time, output, integration
 	Keywords    []string    // Keywords for smart recommendations
+	Tags        []string    // Free-form tags for filtering (MCP tools supply their own)
+}
+
+// CategoryUncategorized is the category given to MCP tools registered without one
+const CategoryUncategorized = "uncategorized"
+
+// HasTag reports whether the tool carries any of the given tags (case-insensitive)
+func (t *Tool) HasTag(tags ...string) bool {
+	for _, want := range tags {
+		for _, tag := range t.Tags {
+			if strings.EqualFold(tag, want) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // ExecuteFunc is the function signature for tool execution
@@ -91,7 +118,7 @@ func (r *Registry) List() []map[string]interface{} {
 	defer r.mutex.RUnlock()
 
 	tools := make([]map[string]interface{}, 0, len(r.tools))
-	for _, tool := range r.tools {
+	for _, tool := range sortedTools(r.tools) {
 		tools = append(tools, map[string]interface{}{
 			"type": "function",
 			"function": map[string]interface{}{
@@ -108,7 +135,7 @@ func (r *Registry) List() []map[string]interface{} {
 func (r *Registry) Execute(name string, args map[string]interface{}) (string, error) {
 	tool, exists := r.Get(name)
 	if !exists {
-		return "", fmt.Errorf("tool %s not found", name)
+		return "", fmt.Errorf("%w: %s", ErrToolNotFound, name)
 	}
 	return tool.Execute(args)
 }
@@ -126,7 +153,7 @@ func (r *Registry) GetToolsByCategory(category string) []*Tool {
 	defer r.mutex.RUnlock()
 
 	var categoryTools []*Tool
-	for _, tool := range r.tools {
+	for _, tool := range sortedTools(r.tools) {
 		if tool.Category == category {
 			categoryTools = append(categoryTools, tool)
 		}
@@ -148,6 +175,19 @@ func (r *Registry) GetCategories() map[string]int {
 	return categories
 }
 
+// sortedTools returns tools ordered by name. Tool lists are sent to the LLM, and a
+// stable order keeps prompts reproducible and cacheable across requests.
+func sortedTools(tools map[string]*Tool) []*Tool {
+	sorted := make([]*Tool, 0, len(tools))
+	for _, tool := range tools {
+		sorted = append(sorted, tool)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
 // registerBuiltInTools registers the default tools
 func registerBuiltInTools(r *Registry) {
 	// Register time tool
@@ -318,6 +358,9 @@ func registerBuiltInTools(r *Registry) {
 	_ = r.Register(NewRedisReadTool())
 	_ = r.Register(NewRedisWriteTool())
 
+	// Register SQL tool
+	_ = r.Register(NewSQLQueryTool())
+
 	// Register Composio Google Sheets tools
 	_ = r.Register(NewComposioGoogleSheetsReadTool())
 	_ = r.Register(NewComposioGoogleSheetsWriteTool())
@@ -403,13 +446,26 @@ func (r *Registry) UnregisterAllUserTools(userID string) {
 
 // GetUserTools returns all tools available to a specific user (built-in + user's MCP tools)
 func (r *Registry) GetUserTools(userID string) []map[string]interface{} {
+	return r.userToolDefinitions(userID, nil)
+}
+
+// GetUserToolsByTag returns the user's tools (built-in + MCP) carrying any of the given tags
+func (r *Registry) GetUserToolsByTag(userID string, tags ...string) []map[string]interface{} {
+	return r.userToolDefinitions(userID, func(tool *Tool) bool {
+		return tool.HasTag(tags...)
+	})
+}
+
+// userToolDefinitions builds OpenAI tool definitions for the user's tools accepted by keep (all if nil)
+func (r *Registry) userToolDefinitions(userID string, keep func(*Tool) bool) []map[string]interface{} {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	tools := make([]map[string]interface{}, 0)
-
-	// Add built-in tools
-	for _, tool := range r.tools {
+	add := func(tool *Tool) {
+		if keep != nil && !keep(tool) {
+			return
+		}
 		tools = append(tools, map[string]interface{}{
 			"type": "function",
 			"function": map[string]interface{}{
@@ -420,17 +476,15 @@ func (r *Registry) GetUserTools(userID string) []map[string]interface{} {
 		})
 	}
 
+	// Add built-in tools
+	for _, tool := range sortedTools(r.tools) {
+		add(tool)
+	}
+
 	// Add user's MCP tools
 	if r.userTools[userID] != nil {
-		for _, tool := range r.userTools[userID] {
-			tools = append(tools, map[string]interface{}{
-				"type": "function",
-				"function": map[string]interface{}{
-					"name":        tool.Name,
-					"description": tool.Description,
-					"parameters":  tool.Parameters,
-				},
-			})
+		for _, tool := range sortedTools(r.userTools[userID]) {
+			add(tool)
 		}
 	}
 
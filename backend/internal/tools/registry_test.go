@@ -212,6 +212,35 @@ func TestRegistry_List(t *testing.T) {
 	}
 }
 
+func TestRegistry_GetUserTools_SortedByName(t *testing.T) {
+	registry := &Registry{
+		tools:     make(map[string]*Tool),
+		userTools: make(map[string]map[string]*Tool),
+	}
+
+	noop := func(args map[string]interface{}) (string, error) { return "", nil }
+	for _, name := range []string{"zeta", "alpha", "mu", "beta"} {
+		registry.Register(&Tool{Name: name, Description: name, Execute: noop})
+	}
+	for _, name := range []string{"mcp_b", "mcp_a"} {
+		registry.RegisterUserTool("user-1", &Tool{Name: name, Description: name, Source: ToolSourceMCPLocal})
+	}
+
+	want := []string{"alpha", "beta", "mu", "zeta", "mcp_a", "mcp_b"}
+	for run := 0; run < 5; run++ {
+		toolsList := registry.GetUserTools("user-1")
+		if len(toolsList) != len(want) {
+			t.Fatalf("Expected %d tools, got %d", len(want), len(toolsList))
+		}
+		for i, toolDef := range toolsList {
+			name := toolDef["function"].(map[string]interface{})["name"]
+			if name != want[i] {
+				t.Fatalf("Run %d: expected tool %d to be %s, got %v", run, i, want[i], name)
+			}
+		}
+	}
+}
+
 func TestRegistry_Execute(t *testing.T) {
 	registry := &Registry{
 		tools: make(map[string]*Tool),
@@ -309,6 +338,9 @@ func TestRegistry_Execute_NotFound(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for nonexistent tool, got nil")
 	}
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound, got %v", err)
+	}
 }
 
 func TestRegistry_Count(t *testing.T) {
@@ -459,3 +491,38 @@ func TestTool_ExecuteWithComplexArgs(t *testing.T) {
 		t.Errorf("Expected result 'test_value', got %s", result)
 	}
 }
+
+func TestRegistry_GetUserToolsByTag(t *testing.T) {
+	registry := &Registry{
+		tools:     make(map[string]*Tool),
+		userTools: make(map[string]map[string]*Tool),
+	}
+	registry.Register(&Tool{
+		Name:    "builtin_search",
+		Tags:    []string{"web"},
+		Execute: func(args map[string]interface{}) (string, error) { return "", nil },
+	})
+	registry.RegisterUserTool("user-1", &Tool{Name: "read_file", Category: CategoryUncategorized, Tags: []string{"Filesystem"}})
+	registry.RegisterUserTool("user-1", &Tool{Name: "list_issues", Tags: []string{"github", "web"}})
+
+	names := func(defs []map[string]interface{}) []string {
+		var result []string
+		for _, def := range defs {
+			result = append(result, def["function"].(map[string]interface{})["name"].(string))
+		}
+		return result
+	}
+
+	if got := names(registry.GetUserToolsByTag("user-1", "filesystem")); len(got) != 1 || got[0] != "read_file" {
+		t.Errorf("expected [read_file] for tag filesystem, got %v", got)
+	}
+	if got := names(registry.GetUserToolsByTag("user-1", "web")); len(got) != 2 || got[0] != "builtin_search" || got[1] != "list_issues" {
+		t.Errorf("expected [builtin_search list_issues] for tag web, got %v", got)
+	}
+	if got := registry.GetUserToolsByTag("user-2", "filesystem"); len(got) != 0 {
+		t.Errorf("expected no tools for another user, got %v", names(got))
+	}
+	if got := registry.GetUserTools("user-1"); len(got) != 3 {
+		t.Errorf("expected 3 unfiltered tools, got %d", len(got))
+	}
+}
@@ -145,6 +145,9 @@ func executeRESTAPI(args map[string]interface{}) (string, error) {
 		}
 	}
 
+	// A workflow triggered by this request nests under the calling execution
+	SetExecutionLinkHeader(req, args)
+
 	// Apply authentication
 	switch authType {
 	case "bearer":
@@ -0,0 +1,380 @@
+package tools
+
+import (
+	"claraverse/internal/security"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// sqlDefaultRowLimit and sqlMaxRowLimit bound the rows returned by sql_query
+	sqlDefaultRowLimit = 100
+	sqlMaxRowLimit     = 1000
+	// sqlDefaultTimeout and sqlMaxTimeout bound how long a query may run
+	sqlDefaultTimeout = 30 * time.Second
+	sqlMaxTimeout     = 120 * time.Second
+)
+
+// sqlReadStatements are the statement types allowed on a read-only datasource
+var sqlReadStatements = map[string]bool{
+	"SELECT": true, "WITH": true, "SHOW": true, "DESCRIBE": true, "DESC": true, "EXPLAIN": true,
+}
+
+// sqlWriteStatements are additionally allowed when the credential enables writes
+var sqlWriteStatements = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true,
+}
+
+// sqlWriteKeywords must not appear anywhere in a read-only query (e.g. a WITH
+// clause feeding a DELETE, or SELECT ... INTO OUTFILE)
+var sqlWriteKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true, "MERGE": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true, "CALL": true, "LOAD": true, "INTO": true,
+	"LOCK": true, "UNLOCK": true, "SET": true, "HANDLER": true,
+}
+
+// errSQLMultipleStatements is returned for stacked statements ("SELECT 1; DROP ...")
+var errSQLMultipleStatements = errors.New("only a single SQL statement is allowed")
+
+// NewSQLQueryTool creates a tool for running parameterized queries against a SQL datasource
+func NewSQLQueryTool() *Tool {
+	return &Tool{
+		Name:        "sql_query",
+		DisplayName: "SQL Query",
+		Description: "Run a parameterized SQL query against a configured SQL database and return the rows as JSON. Use ? placeholders in the query and pass values in params - never put values into the query text. Only read queries (SELECT, WITH, SHOW, DESCRIBE, EXPLAIN) are allowed unless the datasource enables writes.",
+		Icon:        "Database",
+		Source:      ToolSourceBuiltin,
+		Category:    "database",
+		Keywords:    []string{"sql", "database", "query", "select", "mysql", "mariadb", "table", "rows", "read"},
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"credential_id": map[string]interface{}{
+					"type":        "string",
+					"description": "INTERNAL: Auto-injected by system. Do not set manually.",
+				},
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "A single SQL statement with ? placeholders (e.g., \"SELECT id, email FROM users WHERE status = ?\")",
+				},
+				"params": map[string]interface{}{
+					"type":        "array",
+					"description": "Values bound to the ? placeholders, in order",
+					"items":       map[string]interface{}{},
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of rows to return (default: 100, max: 1000)",
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "integer",
+					"description": "Query timeout in seconds (default: 30, max: 120)",
+				},
+			},
+			"required": []string{"query"},
+		},
+		Execute: executeSQLQuery,
+	}
+}
+
+func executeSQLQuery(args map[string]interface{}) (string, error) {
+	credData, err := GetCredentialData(args, "sql_database")
+	if err != nil {
+		return "", fmt.Errorf("failed to get SQL database credentials: %w", err)
+	}
+
+	query, _ := args["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query is required")
+	}
+
+	allowWrites := credentialFlag(credData, "allow_writes")
+	statement, err := checkSQLStatement(query, allowWrites)
+	if err != nil {
+		return "", err
+	}
+
+	var params []interface{}
+	if p, ok := args["params"].([]interface{}); ok {
+		params = p
+	}
+
+	limit := sqlDefaultRowLimit
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+		if limit > sqlMaxRowLimit {
+			limit = sqlMaxRowLimit
+		}
+	}
+
+	timeout := sqlDefaultTimeout
+	if t, ok := args["timeout_seconds"].(float64); ok && t > 0 {
+		timeout = time.Duration(t) * time.Second
+		if timeout > sqlMaxTimeout {
+			timeout = sqlMaxTimeout
+		}
+	}
+
+	db, err := OpenSQLDatasource(credData)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var result map[string]interface{}
+	if sqlWriteStatements[statement] {
+		res, err := db.ExecContext(ctx, query, params...)
+		if err != nil {
+			return "", fmt.Errorf("query failed: %w", err)
+		}
+		affected, _ := res.RowsAffected()
+		result = map[string]interface{}{
+			"statement":     statement,
+			"rows_affected": affected,
+		}
+	} else {
+		result, err = querySQLRows(ctx, db, query, params, limit)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	return string(jsonResult), nil
+}
+
+// querySQLRows runs a read query in a read-only transaction and collects up to limit rows
+func querySQLRows(ctx context.Context, db *sql.DB, query string, params []interface{}, limit int) (map[string]interface{}, error) {
+	// The transaction is a second line of defence behind checkSQLStatement
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start read-only transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	records := make([]map[string]interface{}, 0)
+	truncated := false
+	for rows.Next() {
+		if len(records) >= limit {
+			truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		scanArgs := make([]interface{}, len(columns))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			record[column] = sqlJSONValue(values[i])
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return map[string]interface{}{
+		"columns":   columns,
+		"rows":      records,
+		"count":     len(records),
+		"truncated": truncated,
+	}, nil
+}
+
+// OpenSQLDatasource opens a connection pool for a sql_database credential.
+// Multi-statement queries stay disabled in the driver.
+func OpenSQLDatasource(credData map[string]interface{}) (*sql.DB, error) {
+	driver, _ := credData["driver"].(string)
+	if driver == "" {
+		driver = "mysql"
+	}
+	if driver != "mysql" {
+		return nil, fmt.Errorf("unsupported SQL driver: %s (supported: mysql)", driver)
+	}
+
+	host, _ := credData["host"].(string)
+	if host == "" {
+		return nil, fmt.Errorf("host is required in credentials")
+	}
+	// SSRF protection: block connections to internal/private networks
+	if err := security.ValidateHostForSSRF(host); err != nil {
+		return nil, fmt.Errorf("SSRF protection: %w", err)
+	}
+	port, _ := credData["port"].(string)
+	if port == "" {
+		port = "3306"
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return nil, fmt.Errorf("invalid port: %s", port)
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, port)
+	cfg.User, _ = credData["username"].(string)
+	cfg.Passwd, _ = credData["password"].(string)
+	cfg.DBName, _ = credData["database"].(string)
+	cfg.ParseTime = true
+	cfg.Timeout = 10 * time.Second
+	if credentialFlag(credData, "tls") {
+		cfg.TLSConfig = "true"
+	}
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SQL datasource configuration: %w", err)
+	}
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// checkSQLStatement validates that query is a single statement of an allowed type
+// and returns its leading keyword
+func checkSQLStatement(query string, allowWrites bool) (string, error) {
+	words, err := sqlKeywords(query)
+	if err != nil {
+		return "", err
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("query is empty")
+	}
+
+	statement := words[0]
+	switch {
+	case sqlReadStatements[statement]:
+		for _, word := range words[1:] {
+			if sqlWriteKeywords[word] && !(allowWrites && sqlWriteStatements[word]) {
+				return "", fmt.Errorf("%s is not allowed in a read query", word)
+			}
+		}
+		return statement, nil
+	case sqlWriteStatements[statement]:
+		if !allowWrites {
+			return "", fmt.Errorf("%s statements are not allowed: the datasource is read-only", statement)
+		}
+		return statement, nil
+	default:
+		return "", fmt.Errorf("%s statements are not allowed", statement)
+	}
+}
+
+// sqlKeywords returns the upper-cased bare words of query, skipping string
+// literals, quoted identifiers and comments. It fails on a statement separator
+// followed by more SQL, and on MySQL executable comments (/*! ... */), whose
+// contents MySQL runs as SQL.
+func sqlKeywords(query string) ([]string, error) {
+	var words []string
+	ended := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Skip the quoted section, honouring backslash escapes and doubled quotes
+			i++
+			for i < len(query) {
+				if query[i] == '\\' && c != '`' {
+					i += 2
+					continue
+				}
+				if query[i] == c {
+					if i+1 < len(query) && query[i+1] == c {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*!"):
+			return nil, fmt.Errorf("executable comments (/*! ... */) are not allowed")
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+		case c == ';':
+			ended = true
+			i++
+		case isSQLWordChar(c):
+			start := i
+			for i < len(query) && isSQLWordChar(query[i]) {
+				i++
+			}
+			if ended {
+				return nil, errSQLMultipleStatements
+			}
+			words = append(words, strings.ToUpper(query[start:i]))
+		default:
+			if ended && c > ' ' {
+				return nil, errSQLMultipleStatements
+			}
+			i++
+		}
+	}
+	return words, nil
+}
+
+func isSQLWordChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// sqlJSONValue converts a scanned column value into a JSON-friendly value
+func sqlJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// credentialFlag reads a boolean credential field stored as a bool or string
+func credentialFlag(credData map[string]interface{}, key string) bool {
+	switch v := credData[key].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true") || v == "1" || strings.EqualFold(v, "yes")
+	}
+	return false
+}
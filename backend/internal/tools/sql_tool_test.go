@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSQLStatement(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		allowWrites bool
+		want        string
+		wantErr     bool
+	}{
+		{"select", "SELECT id FROM users WHERE status = ?", false, "SELECT", false},
+		{"lowercase with comment", "-- latest\nselect * from orders", false, "SELECT", false},
+		{"cte", "WITH recent AS (SELECT * FROM orders) SELECT * FROM recent", false, "WITH", false},
+		{"trailing semicolon", "SELECT 1;", false, "SELECT", false},
+		{"keyword inside literal", "SELECT * FROM logs WHERE message = 'DROP TABLE users; --'", false, "SELECT", false},
+		{"keyword as quoted identifier", "SELECT `update` FROM events", false, "SELECT", false},
+		{"insert read-only", "INSERT INTO users (email) VALUES (?)", false, "", true},
+		{"insert with writes", "INSERT INTO users (email) VALUES (?)", true, "INSERT", false},
+		{"ddl with writes", "DROP TABLE users", true, "", true},
+		{"stacked statements", "SELECT 1; DELETE FROM users", true, "", true},
+		{"stacked after comment", "SELECT 1; /* x */ DROP TABLE users", false, "", true},
+		{"cte feeding delete", "WITH x AS (SELECT id FROM users) DELETE FROM users", false, "", true},
+		{"select into outfile", "SELECT * FROM users INTO OUTFILE '/tmp/users'", false, "", true},
+		{"hidden in block comment", "/* SELECT */ DELETE FROM users", false, "", true},
+		{"executable comment", "SELECT 1 /*!50000 UNION SELECT password FROM users */", false, "", true},
+		{"executable comment inside literal", "SELECT * FROM logs WHERE message = '/*! x */'", false, "SELECT", false},
+		{"empty", "  -- nothing\n", false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkSQLStatement(tt.query, tt.allowWrites)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkSQLStatement(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("checkSQLStatement(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenSQLDatasourceRejectsUnsupportedDriver(t *testing.T) {
+	if _, err := OpenSQLDatasource(map[string]interface{}{"driver": "oracle", "host": "db"}); err == nil {
+		t.Error("expected an unsupported driver to be rejected")
+	}
+	if _, err := OpenSQLDatasource(map[string]interface{}{"host": "db", "port": "abc"}); err == nil {
+		t.Error("expected an invalid port to be rejected")
+	}
+}
+
+func TestOpenSQLDatasourceBlocksPrivateHosts(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "10.0.0.5", "localhost", "169.254.169.254"} {
+		if _, err := OpenSQLDatasource(map[string]interface{}{"host": host}); err == nil || !strings.Contains(err.Error(), "SSRF") {
+			t.Errorf("expected host %s to be blocked, got %v", host, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ArgsValidation is the outcome of checking a call's arguments against a tool's parameter schema
+type ArgsValidation struct {
+	Valid           bool                   `json:"valid"`
+	Arguments       map[string]interface{} `json:"arguments"`                  // Arguments with schema defaults applied
+	DefaultsApplied []string               `json:"defaults_applied,omitempty"` // Paths of the arguments filled from defaults
+	Errors          []string               `json:"errors,omitempty"`
+}
+
+// ValidateArgs checks args against the tool's JSON schema parameters and fills in
+// defaults for missing optional arguments. args is not modified. Only the schema
+// keywords tools actually use are checked: type, required, properties, items, enum,
+// default and additionalProperties.
+func (t *Tool) ValidateArgs(args map[string]interface{}) *ArgsValidation {
+	v := &ArgsValidation{}
+	validated := v.check("", args, t.Parameters)
+	v.Arguments, _ = validated.(map[string]interface{})
+	if v.Arguments == nil {
+		v.Arguments = map[string]interface{}{}
+	}
+	v.Valid = len(v.Errors) == 0
+	// Objects are walked in map order; sort so reports are stable
+	sort.Strings(v.DefaultsApplied)
+	sort.Strings(v.Errors)
+	return v
+}
+
+// check validates value against schema and returns it with defaults applied
+func (v *ArgsValidation) check(path string, value interface{}, schema map[string]interface{}) interface{} {
+	if schema == nil {
+		return value
+	}
+
+	if types := schemaStrings(schema["type"]); len(types) > 0 && !matchesAnyType(value, types) {
+		v.addError(path, "expected %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+		return value
+	}
+
+	if enum, ok := schema["enum"]; ok && !inEnum(value, enum) {
+		v.addError(path, "must be one of %v", enum)
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return v.checkObject(path, typed, schema)
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		if items == nil {
+			return typed
+		}
+		out := make([]interface{}, len(typed))
+		for i, item := range typed {
+			out[i] = v.check(fmt.Sprintf("%s[%d]", path, i), item, items)
+		}
+		return out
+	}
+	return value
+}
+
+func (v *ArgsValidation) checkObject(path string, obj map[string]interface{}, schema map[string]interface{}) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	out := make(map[string]interface{}, len(obj))
+
+	for _, name := range schemaStrings(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			v.addError(joinPath(path, name), "required argument is missing")
+		}
+	}
+
+	for name, value := range obj {
+		propSchema, known := properties[name].(map[string]interface{})
+		if !known {
+			if allowed, ok := schema["additionalProperties"].(bool); ok && !allowed {
+				v.addError(joinPath(path, name), "unknown argument")
+			}
+			out[name] = value
+			continue
+		}
+		out[name] = v.check(joinPath(path, name), value, propSchema)
+	}
+
+	for name, prop := range properties {
+		propSchema, _ := prop.(map[string]interface{})
+		if _, present := obj[name]; present || propSchema == nil {
+			continue
+		}
+		if def, ok := propSchema["default"]; ok {
+			out[name] = def
+			v.DefaultsApplied = append(v.DefaultsApplied, joinPath(path, name))
+		}
+	}
+
+	return out
+}
+
+func (v *ArgsValidation) addError(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "arguments"
+	}
+	v.Errors = append(v.Errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// schemaStrings reads a schema keyword that is a string or a list of strings
+// (built-in tools use []string, schemas decoded from JSON use []interface{})
+func schemaStrings(value interface{}) []string {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}
+	case []string:
+		return typed
+	case []interface{}:
+		out := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true // Unknown types are not ours to reject
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// jsonTypeName names value's JSON type for error messages
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(value interface{}, enum interface{}) bool {
+	list := reflect.ValueOf(enum)
+	if list.Kind() != reflect.Slice {
+		return true
+	}
+	for i := 0; i < list.Len(); i++ {
+		option := list.Index(i).Interface()
+		if a, ok := toFloat(option); ok {
+			if b, ok := toFloat(value); ok && a == b {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+	return false
+}
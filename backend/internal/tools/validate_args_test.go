@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToolValidateArgs(t *testing.T) {
+	allowExtra := false
+	tool := &Tool{
+		Name: "search",
+		Parameters: map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": allowExtra,
+			"required":             []string{"query"},
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "integer", "default": 10},
+				"order": map[string]interface{}{"type": "string", "enum": []string{"asc", "desc"}, "default": "desc"},
+				"filters": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type":     "object",
+						"required": []interface{}{"field"},
+						"properties": map[string]interface{}{
+							"field": map[string]interface{}{"type": "string"},
+							"exact": map[string]interface{}{"type": "boolean", "default": false},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	args := map[string]interface{}{
+		"query":   "clara",
+		"filters": []interface{}{map[string]interface{}{"field": "title"}},
+	}
+	result := tool.ValidateArgs(args)
+	if !result.Valid {
+		t.Fatalf("Expected valid arguments, got errors %v", result.Errors)
+	}
+	want := map[string]interface{}{
+		"query":   "clara",
+		"limit":   10,
+		"order":   "desc",
+		"filters": []interface{}{map[string]interface{}{"field": "title", "exact": false}},
+	}
+	if !reflect.DeepEqual(result.Arguments, want) {
+		t.Errorf("Arguments = %v, want %v", result.Arguments, want)
+	}
+	if wantDefaults := []string{"filters[0].exact", "limit", "order"}; !reflect.DeepEqual(result.DefaultsApplied, wantDefaults) {
+		t.Errorf("DefaultsApplied = %v, want %v", result.DefaultsApplied, wantDefaults)
+	}
+	if _, ok := args["limit"]; ok {
+		t.Error("ValidateArgs must not modify the caller's arguments")
+	}
+
+	result = tool.ValidateArgs(map[string]interface{}{
+		"limit":   2.5,
+		"order":   "random",
+		"filters": []interface{}{map[string]interface{}{}},
+		"page":    2.0,
+	})
+	if result.Valid {
+		t.Fatal("Expected invalid arguments")
+	}
+	for _, path := range []string{"query", "limit", "order", "filters[0].field", "page"} {
+		found := false
+		for _, err := range result.Errors {
+			if len(err) > len(path) && err[:len(path)+1] == path+":" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected an error for %s, got %v", path, result.Errors)
+		}
+	}
+}
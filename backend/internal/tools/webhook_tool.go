@@ -141,6 +141,8 @@ func executeWebhook(args map[string]interface{}) (string, error) {
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
+	// A workflow triggered by this request nests under the calling execution
+	SetExecutionLinkHeader(req, args)
 
 	// Execute request
 	resp, err := client.Do(req)
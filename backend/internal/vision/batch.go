@@ -0,0 +1,183 @@
+package vision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxBatchSize is the maximum number of images in one DescribeBatch call
+	MaxBatchSize = 20
+	// BatchConcurrency bounds how many images of a batch are analyzed at once
+	BatchConcurrency = 4
+	// BatchTimeout bounds the total time of a batch; images not done by then fail.
+	// DescribeBatchContext callers may set a shorter deadline on the context.
+	BatchTimeout = 2 * time.Minute
+)
+
+// BatchItemResult is the outcome for one image of a batch.
+// Exactly one of Response and Error is set; Code is set with Error when the
+// failure has a vision error code.
+type BatchItemResult struct {
+	Index    int                    `json:"index"`
+	Response *DescribeImageResponse `json:"response,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Code     ErrorCode              `json:"code,omitempty"`
+}
+
+// DescribeBatch describes independent images concurrently, spreading them across the
+// vision models from the failover list. An image whose model fails is retried on the
+// next model in the list. Results are returned in input order with per-item errors;
+// the error return is only set when the batch as a whole cannot run. Follow-ups on a
+// session are not supported in batches.
+func (s *Service) DescribeBatch(reqs []DescribeImageRequest) ([]BatchItemResult, error) {
+	return s.DescribeBatchContext(context.Background(), reqs)
+}
+
+// DescribeBatchContext is DescribeBatch bounded by ctx as well as BatchTimeout
+func (s *Service) DescribeBatchContext(ctx context.Context, reqs []DescribeImageRequest) ([]BatchItemResult, error) {
+	if len(reqs) == 0 {
+		return nil, &Error{Code: ErrCodeInvalidBatch, Message: "batch is empty"}
+	}
+	if len(reqs) > MaxBatchSize {
+		return nil, &Error{
+			Code:    ErrCodeInvalidBatch,
+			Message: fmt.Sprintf("batch has %d images, maximum is %d", len(reqs), MaxBatchSize),
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.visionModelFinder == nil || s.providerGetter == nil {
+		return nil, fmt.Errorf("vision service not properly initialized")
+	}
+
+	models, err := s.batchModels()
+	if err != nil {
+		return nil, &Error{Code: ErrCodeNoVisionModel, Message: "no vision-capable model available", Err: err}
+	}
+
+	log.Printf("🖼️ [VISION] Describing batch of %d images across %d model(s)", len(reqs), len(models))
+
+	ctx, cancel := context.WithTimeout(ctx, BatchTimeout)
+	defer cancel()
+
+	results := make([]BatchItemResult, len(reqs))
+	sem := make(chan struct{}, BatchConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range reqs {
+		results[i].Index = i
+		if reqs[i].SessionID != "" {
+			results[i].Error = "session follow-ups are not supported in batches"
+			continue
+		}
+		if err := validateImage(&reqs[i]); err != nil {
+			results[i].setError(err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].Error = "batch timed out before the image was analyzed"
+				return
+			}
+
+			// Start each image on a different model so the batch is spread out
+			resp, err := s.describeWithFailover(ctx, &reqs[i], models, i%len(models))
+			if err != nil {
+				results[i].setError(err)
+				return
+			}
+			results[i].Response = resp
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	log.Printf("✅ [VISION] Batch finished: %d described, %d failed", len(reqs)-failed, failed)
+
+	return results, nil
+}
+
+// setError records a failed image, with its code if it is a vision error
+func (r *BatchItemResult) setError(err error) {
+	r.Error = err.Error()
+	if visionErr, ok := AsError(err); ok {
+		r.Code = visionErr.Code
+	}
+}
+
+// batchModels returns the failover list, falling back to the single model from the finder
+func (s *Service) batchModels() ([]VisionModel, error) {
+	if s.visionModelLister != nil {
+		models, err := s.visionModelLister()
+		if err == nil && len(models) > 0 {
+			return models, nil
+		}
+		log.Printf("⚠️ [VISION] Vision model list unavailable (%v), using the default model", err)
+	}
+
+	providerID, modelName, err := s.visionModelFinder()
+	if err != nil {
+		return nil, err
+	}
+	return []VisionModel{{ProviderID: providerID, ModelName: modelName}}, nil
+}
+
+// describeWithFailover tries the models in order starting at start, wrapping around.
+// When every model fails the error lists the attempts (see attemptsError).
+func (s *Service) describeWithFailover(ctx context.Context, req *DescribeImageRequest, models []VisionModel, start int) (*DescribeImageResponse, error) {
+	var attempts []ProviderAttempt
+	var lastErr error
+	for attempt := 0; attempt < len(models); attempt++ {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("batch timed out: %w", ctx.Err())
+		}
+
+		model := models[(start+attempt)%len(models)]
+		provider, err := s.providerGetter(model.ProviderID)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to get provider: %w", err)
+			attempts = append(attempts, ProviderAttempt{Provider: fmt.Sprintf("provider %d", model.ProviderID), Model: model.ModelName, Error: lastErr.Error()})
+			continue
+		}
+		if !provider.Enabled {
+			lastErr = fmt.Errorf("provider %s is disabled", provider.Name)
+			attempts = append(attempts, ProviderAttempt{Provider: provider.Name, Model: model.ModelName, Error: lastErr.Error()})
+			continue
+		}
+
+		resp, err := s.describeWithModel(ctx, req, provider, model)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		failed := ProviderAttempt{Provider: provider.Name, Model: model.ModelName, Error: err.Error()}
+		var statusErr *providerStatusError
+		if errors.As(err, &statusErr) {
+			failed.Status = statusErr.status
+		}
+		attempts = append(attempts, failed)
+		if attempt+1 < len(models) {
+			log.Printf("⚠️ [VISION] Model %s failed, trying next model: %v", model.ModelName, err)
+		}
+	}
+	return nil, attemptsError(attempts, lastErr)
+}
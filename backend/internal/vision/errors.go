@@ -0,0 +1,142 @@
+package vision
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MaxImageBytes is the largest image DescribeImage accepts
+const MaxImageBytes = 20 * 1024 * 1024
+
+// ErrorCode is a stable identifier for a class of vision failure, safe to match on
+type ErrorCode string
+
+// Vision error codes
+const (
+	ErrCodeNoVisionModel      ErrorCode = "no_vision_model"       // Nothing to analyze with; configure a vision model
+	ErrCodeAllProvidersFailed ErrorCode = "all_providers_failed"  // Every vision model failed; may succeed later
+	ErrCodeImageTooLarge      ErrorCode = "image_too_large"       // Over MaxImageBytes, or rejected as too large by the provider
+	ErrCodeInvalidImage       ErrorCode = "invalid_image"         // Missing or non-image data
+	ErrCodeRateLimited        ErrorCode = "provider_rate_limited" // Every vision model was rate limited; retry later
+	ErrCodeInvalidBatch       ErrorCode = "invalid_batch"         // Empty batch, or more than MaxBatchSize images
+)
+
+// ProviderAttempt is one vision model tried while describing an image
+type ProviderAttempt struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Error    string `json:"error"`
+	Status   int    `json:"status,omitempty"` // HTTP status the provider answered with, if any
+}
+
+// Error is a vision failure with a stable code. Attempts lists the models tried,
+// when the failure came from the providers.
+type Error struct {
+	Code     ErrorCode
+	Message  string
+	Attempts []ProviderAttempt
+	Err      error // Underlying cause, if any
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus is the status an HTTP endpoint should answer the error with
+func (e *Error) HTTPStatus() int {
+	switch e.Code {
+	case ErrCodeNoVisionModel:
+		return http.StatusServiceUnavailable
+	case ErrCodeImageTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrCodeInvalidImage, ErrCodeInvalidBatch:
+		return http.StatusBadRequest
+	case ErrCodeRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// ErrorResponse is the JSON body for a vision error
+type ErrorResponse struct {
+	Error    string            `json:"error"`
+	Code     ErrorCode         `json:"code"`
+	Attempts []ProviderAttempt `json:"attempts,omitempty"`
+}
+
+// Response returns the JSON body for the error
+func (e *Error) Response() ErrorResponse {
+	return ErrorResponse{Error: e.Error(), Code: e.Code, Attempts: e.Attempts}
+}
+
+// AsError returns the vision error in err's chain, if there is one
+func AsError(err error) (*Error, bool) {
+	var visionErr *Error
+	if errors.As(err, &visionErr) {
+		return visionErr, true
+	}
+	return nil, false
+}
+
+// providerStatusError is a non-200 answer from a provider's API
+type providerStatusError struct {
+	status int
+}
+
+func (e *providerStatusError) Error() string {
+	return fmt.Sprintf("API error: %d", e.status)
+}
+
+// validateImage rejects requests without usable image data
+func validateImage(req *DescribeImageRequest) error {
+	if len(req.ImageData) == 0 {
+		return &Error{Code: ErrCodeInvalidImage, Message: "image data is empty"}
+	}
+	if len(req.ImageData) > MaxImageBytes {
+		return &Error{
+			Code:    ErrCodeImageTooLarge,
+			Message: fmt.Sprintf("image is %d bytes, maximum is %d", len(req.ImageData), MaxImageBytes),
+		}
+	}
+	if !strings.HasPrefix(req.MimeType, "image/") {
+		return &Error{Code: ErrCodeInvalidImage, Message: fmt.Sprintf("unsupported image type %q", req.MimeType)}
+	}
+	return nil
+}
+
+// attemptsError classifies a failure after every model was tried: rate limited or
+// too large when all providers said so, otherwise all providers failed
+func attemptsError(attempts []ProviderAttempt, lastErr error) *Error {
+	allStatus := func(status int) bool {
+		for _, attempt := range attempts {
+			if attempt.Status != status {
+				return false
+			}
+		}
+		return len(attempts) > 0
+	}
+
+	switch {
+	case allStatus(http.StatusTooManyRequests):
+		return &Error{Code: ErrCodeRateLimited, Message: "vision providers are rate limited, retry later", Attempts: attempts, Err: lastErr}
+	case allStatus(http.StatusRequestEntityTooLarge):
+		return &Error{Code: ErrCodeImageTooLarge, Message: "image is too large for the vision providers", Attempts: attempts, Err: lastErr}
+	default:
+		return &Error{
+			Code:     ErrCodeAllProvidersFailed,
+			Message:  fmt.Sprintf("all %d vision model(s) failed", len(attempts)),
+			Attempts: attempts,
+			Err:      lastErr,
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package vision
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"  // register GIF for image.DecodeConfig
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	_ "image/png"  // register PNG for image.DecodeConfig
+	"log"
+	"strings"
+)
+
+// Image detail levels sent with the image (the image_url "detail" field), which
+// trade token cost against fidelity. ImageDetailAutoSmart is resolved here rather
+// than by the provider: small images get low detail, larger ones high.
+const (
+	ImageDetailAuto      = "auto"
+	ImageDetailLow       = "low"
+	ImageDetailHigh      = "high"
+	ImageDetailAutoSmart = "auto-smart"
+)
+
+// SmartDetailMaxLowSide is the longest side, in pixels, of an image that
+// auto-smart sends at low detail. Low detail renders images at 512x512, so
+// anything that fits loses nothing.
+const SmartDetailMaxLowSide = 512
+
+// resolveImageDetail returns the detail level to send for a request. Explicit
+// low/high/auto are used as given; auto-smart picks from the decoded image size
+// and falls back to auto when the dimensions cannot be read (e.g. WebP).
+func resolveImageDetail(req *DescribeImageRequest) string {
+	detail := strings.ToLower(strings.TrimSpace(req.ImageDetail))
+	switch detail {
+	case ImageDetailLow, ImageDetailHigh:
+		return detail
+	case ImageDetailAutoSmart:
+	default:
+		return ImageDetailAuto
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(req.ImageData))
+	if err != nil {
+		log.Printf("🖼️ [VISION] auto-smart: could not read dimensions of %s image (%v), using detail=auto", req.MimeType, err)
+		return ImageDetailAuto
+	}
+
+	chosen := ImageDetailHigh
+	if max(config.Width, config.Height) <= SmartDetailMaxLowSide {
+		chosen = ImageDetailLow
+	}
+	log.Printf("🖼️ [VISION] auto-smart: %dx%d %s image, using detail=%s", config.Width, config.Height, format, chosen)
+	return chosen
+}
@@ -2,6 +2,8 @@ package vision
 
 import (
 	"bytes"
+	"claraverse/internal/providerhttp"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -20,6 +22,26 @@ type Provider struct {
 	BaseURL string
 	APIKey  string
 	Enabled bool
+	// AuthStyle selects how APIKey is sent: AuthStyleBearer (default) or AuthStyleAPIKey
+	AuthStyle string
+	// Headers are extra headers added to every request (e.g. OpenAI-Organization, X-Gateway-Key)
+	Headers map[string]string
+	// UsesMaxCompletionTokens overrides the token limit parameter detection (nil = detect from the flavor)
+	UsesMaxCompletionTokens *bool
+	// Flavor is the provider's API dialect (see providerhttp.ResolveFlavor; "" = detect from BaseURL)
+	Flavor string
+}
+
+// Provider auth styles
+const (
+	AuthStyleBearer = providerhttp.AuthStyleBearer // Authorization: Bearer <key>
+	AuthStyleAPIKey = providerhttp.AuthStyleAPIKey // api-key: <key> (Azure OpenAI)
+)
+
+// setAuthHeaders applies the auth header for the provider's flavor or auth style,
+// and its custom headers, to a request
+func (p *Provider) setAuthHeaders(req *http.Request) {
+	providerhttp.SetAuthHeaders(req, p.Flavor, p.BaseURL, p.AuthStyle, p.APIKey, p.Headers)
 }
 
 // ModelAlias represents a model alias with vision support info
@@ -35,12 +57,35 @@ type ProviderGetter func(id int) (*Provider, error)
 // VisionModelFinder is a function type to find vision-capable models
 type VisionModelFinder func() (providerID int, modelName string, err error)
 
+// VisionModel identifies a vision-capable model on a provider
+type VisionModel struct {
+	ProviderID int
+	ModelName  string
+}
+
+// VisionModelLister returns every vision-capable model in preference order.
+// It is the failover list used by DescribeBatch.
+type VisionModelLister func() ([]VisionModel, error)
+
+// QuestionPlaceholder is replaced with DescribeImageRequest.Question in prompt templates
+const QuestionPlaceholder = "{question}"
+
+// DefaultPromptTemplates are the built-in prompts keyed by detail level
+var DefaultPromptTemplates = map[string]string{
+	"detailed": "Describe this image in detail.",
+	"brief":    "Briefly describe this image in 1-2 sentences.",
+}
+
 // Service handles image analysis using vision-capable models
 type Service struct {
 	httpClient        *http.Client
 	providerGetter    ProviderGetter
 	visionModelFinder VisionModelFinder
+	visionModelLister VisionModelLister
+	promptTemplates   map[string]string
 	mu                sync.RWMutex
+	sessions          map[string]*imageSession
+	sessionMu         sync.Mutex
 }
 
 var (
@@ -55,25 +100,83 @@ func GetService() *Service {
 }
 
 // InitService initializes the vision service with dependencies
-func InitService(providerGetter ProviderGetter, visionModelFinder VisionModelFinder) *Service {
+// promptTemplates maps detail level ("detailed", "brief") to a prompt; missing
+// levels fall back to DefaultPromptTemplates. Pass nil to use the defaults.
+func InitService(providerGetter ProviderGetter, visionModelFinder VisionModelFinder, promptTemplates map[string]string) *Service {
 	once.Do(func() {
 		instance = &Service{
-			httpClient: &http.Client{
-				Timeout: 60 * time.Second,
-			},
+			httpClient:        providerhttp.NewClient(60 * time.Second),
 			providerGetter:    providerGetter,
 			visionModelFinder: visionModelFinder,
+			promptTemplates:   mergePromptTemplates(promptTemplates),
 		}
 	})
 	return instance
 }
 
+// SetVisionModelLister sets the failover list of vision models used by DescribeBatch.
+// Without it, batches use the single model returned by the VisionModelFinder.
+func (s *Service) SetVisionModelLister(lister VisionModelLister) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.visionModelLister = lister
+}
+
+// mergePromptTemplates overlays custom templates on the defaults
+func mergePromptTemplates(custom map[string]string) map[string]string {
+	templates := make(map[string]string, len(DefaultPromptTemplates)+len(custom))
+	for detail, tmpl := range DefaultPromptTemplates {
+		templates[detail] = tmpl
+	}
+	for detail, tmpl := range custom {
+		if strings.TrimSpace(tmpl) != "" {
+			templates[strings.ToLower(detail)] = tmpl
+		}
+	}
+	return templates
+}
+
+// buildPrompt picks the prompt for a request.
+// A question replaces the template entirely, unless the template for the detail
+// level contains {question}, in which case the question is substituted into it.
+func (s *Service) buildPrompt(question, detail string) string {
+	templates := s.promptTemplates
+	if templates == nil {
+		templates = DefaultPromptTemplates
+	}
+
+	tmpl, ok := templates[strings.ToLower(detail)]
+	if !ok {
+		tmpl = templates["detailed"]
+	}
+
+	if question == "" {
+		return strings.TrimSpace(strings.ReplaceAll(tmpl, QuestionPlaceholder, ""))
+	}
+	if strings.Contains(tmpl, QuestionPlaceholder) {
+		return strings.ReplaceAll(tmpl, QuestionPlaceholder, question)
+	}
+	return question
+}
+
 // DescribeImageRequest contains parameters for image description
 type DescribeImageRequest struct {
 	ImageData []byte
 	MimeType  string
 	Question  string // Optional question about the image
 	Detail    string // "brief" or "detailed"
+	// ImageDetail is the image detail level: "auto" (default), "low", "high", or
+	// "auto-smart" to choose low or high from the image size (see image_detail.go)
+	ImageDetail string
+	// SessionID continues an earlier conversation about the same image.
+	// ImageData may be omitted; Question is required for follow-ups.
+	SessionID string
+	// CreateSession keeps the conversation so follow-ups can reuse it via SessionID.
+	// Sessions hold the full image, so only set it when follow-ups are expected.
+	CreateSession bool
+	// OwnerID scopes sessions to a user; follow-ups must come from the same owner.
+	// It is required with CreateSession or SessionID.
+	OwnerID string
 }
 
 // DescribeImageResponse contains the result of image description
@@ -81,9 +184,11 @@ type DescribeImageResponse struct {
 	Description string `json:"description"`
 	Model       string `json:"model"`
 	Provider    string `json:"provider"`
+	SessionID   string `json:"session_id,omitempty"`
 }
 
-// DescribeImage analyzes an image and returns a text description
+// DescribeImage analyzes an image and returns a text description, trying each
+// vision model in turn. Failures of new images are *Error values with a stable code.
 func (s *Service) DescribeImage(req *DescribeImageRequest) (*DescribeImageResponse, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -92,30 +197,38 @@ func (s *Service) DescribeImage(req *DescribeImageRequest) (*DescribeImageRespon
 		return nil, fmt.Errorf("vision service not properly initialized")
 	}
 
-	log.Printf("🖼️ [VISION] Analyzing image (%d bytes, %s)", len(req.ImageData), req.MimeType)
-
-	// Convert to base64
-	base64Image := base64.StdEncoding.EncodeToString(req.ImageData)
-	dataURL := fmt.Sprintf("data:%s;base64,%s", req.MimeType, base64Image)
+	if (req.CreateSession || req.SessionID != "") && req.OwnerID == "" {
+		return nil, ErrSessionOwnerRequired
+	}
+	if req.SessionID != "" {
+		return s.describeFollowUp(req)
+	}
 
-	// Find a vision-capable model
-	providerID, modelName, err := s.visionModelFinder()
-	if err != nil {
-		return nil, fmt.Errorf("no vision-capable model available: %w", err)
+	if err := validateImage(req); err != nil {
+		return nil, err
 	}
 
-	provider, err := s.providerGetter(providerID)
+	log.Printf("🖼️ [VISION] Analyzing image (%d bytes, %s)", len(req.ImageData), req.MimeType)
+
+	// Find the vision-capable models, failing over to the next one on errors
+	models, err := s.batchModels()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get provider: %w", err)
+		return nil, &Error{Code: ErrCodeNoVisionModel, Message: "no vision-capable model available", Err: err}
 	}
 
+	return s.describeWithFailover(context.Background(), req, models, 0)
+}
+
+// describeWithModel describes a new image (not a follow-up) with the given model
+func (s *Service) describeWithModel(ctx context.Context, req *DescribeImageRequest, provider *Provider, model VisionModel) (*DescribeImageResponse, error) {
+	modelName := model.ModelName
+
+	// Convert to base64
+	base64Image := base64.StdEncoding.EncodeToString(req.ImageData)
+	dataURL := fmt.Sprintf("data:%s;base64,%s", req.MimeType, base64Image)
+
 	// Build the prompt
-	prompt := "Describe this image in detail."
-	if req.Question != "" {
-		prompt = req.Question
-	} else if req.Detail == "brief" {
-		prompt = "Briefly describe this image in 1-2 sentences."
-	}
+	prompt := s.buildPrompt(req.Question, req.Detail)
 
 	// Build the API request
 	messages := []map[string]interface{}{
@@ -130,59 +243,119 @@ func (s *Service) DescribeImage(req *DescribeImageRequest) (*DescribeImageRespon
 					"type": "image_url",
 					"image_url": map[string]interface{}{
 						"url":    dataURL,
-						"detail": "auto",
+						"detail": resolveImageDetail(req),
 					},
 				},
 			},
 		},
 	}
 
-	// Detect if using OpenAI - they require max_completion_tokens instead of max_tokens
-	isOpenAI := strings.Contains(strings.ToLower(provider.BaseURL), "openai.com")
+	description, err := s.callVisionAPI(ctx, provider, modelName, messages)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("✅ [VISION] Image described successfully (%d chars)", len(description))
+
+	response := &DescribeImageResponse{
+		Description: description,
+		Model:       modelName,
+		Provider:    provider.Name,
+	}
+
+	if req.CreateSession {
+		messages = append(messages, map[string]interface{}{
+			"role":    "assistant",
+			"content": description,
+		})
+		response.SessionID = s.createSession(req.OwnerID, model.ProviderID, modelName, messages)
+	}
+
+	return response, nil
+}
+
+// describeFollowUp answers a new question using the conversation stored in a session
+func (s *Service) describeFollowUp(req *DescribeImageRequest) (*DescribeImageResponse, error) {
+	if strings.TrimSpace(req.Question) == "" {
+		return nil, fmt.Errorf("question is required for follow-ups on a vision session")
+	}
+
+	session, ok := s.getSession(req.SessionID, req.OwnerID)
+	if !ok {
+		return nil, fmt.Errorf("vision session %s not found or expired", req.SessionID)
+	}
 
+	provider, err := s.providerGetter(session.providerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+
+	// Chat completion providers are stateless, so the full history (including the
+	// original image) is resent; the user just doesn't have to upload it again
+	messages := append(session.history(), map[string]interface{}{
+		"role":    "user",
+		"content": req.Question,
+	})
+
+	log.Printf("🖼️ [VISION] Follow-up question on session %s (turn %d)", req.SessionID, len(messages)/2+1)
+
+	description, err := s.callVisionAPI(context.Background(), provider, session.modelName, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	s.appendToSession(req.SessionID,
+		map[string]interface{}{"role": "user", "content": req.Question},
+		map[string]interface{}{"role": "assistant", "content": description},
+	)
+
+	return &DescribeImageResponse{
+		Description: description,
+		Model:       session.modelName,
+		Provider:    provider.Name,
+		SessionID:   req.SessionID,
+	}, nil
+}
+
+// callVisionAPI sends messages to the provider's chat completions endpoint and returns the reply
+func (s *Service) callVisionAPI(ctx context.Context, provider *Provider, modelName string, messages []map[string]interface{}) (string, error) {
+	// OpenAI-style APIs require max_completion_tokens instead of max_tokens, others reject it
 	requestBody := map[string]interface{}{
 		"model":    modelName,
 		"messages": messages,
-	}
-
-	// Use correct token limit parameter based on provider
-	if isOpenAI {
-		requestBody["max_completion_tokens"] = 1000
-	} else {
-		requestBody["max_tokens"] = 1000
+		providerhttp.MaxTokensParam(provider.Flavor, provider.BaseURL, provider.UsesMaxCompletionTokens): 1000,
 	}
 
 	requestJSON, err := json.Marshal(requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Make the API call
 	apiURL := fmt.Sprintf("%s/chat/completions", strings.TrimSuffix(provider.BaseURL, "/"))
-	httpReq, err := http.NewRequest("POST", apiURL, bytes.NewReader(requestJSON))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(requestJSON))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", provider.APIKey))
+	provider.setAuthHeaders(httpReq)
 
 	log.Printf("🔄 [VISION] Calling %s with model %s", provider.Name, modelName)
 
 	resp, err := s.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("API request failed: %w", err)
+		return "", fmt.Errorf("API request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("❌ [VISION] API error: %d - %s", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("API error: %d", resp.StatusCode)
+		return "", &providerStatusError{status: resp.StatusCode}
 	}
 
 	// Parse response
@@ -195,19 +368,12 @@ func (s *Service) DescribeImage(req *DescribeImageRequest) (*DescribeImageRespon
 	}
 
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(apiResp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from vision model")
+		return "", fmt.Errorf("no response from vision model")
 	}
 
-	description := apiResp.Choices[0].Message.Content
-	log.Printf("✅ [VISION] Image described successfully (%d chars)", len(description))
-
-	return &DescribeImageResponse{
-		Description: description,
-		Model:       modelName,
-		Provider:    provider.Name,
-	}, nil
+	return apiResp.Choices[0].Message.Content, nil
 }
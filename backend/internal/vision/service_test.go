@@ -1,7 +1,16 @@
 package vision
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 // TestProviderStructure tests provider structure
@@ -181,3 +190,314 @@ func BenchmarkRequestCreation(b *testing.B) {
 		}
 	}
 }
+
+// TestBuildPromptTemplates tests prompt template selection and {question} substitution
+func TestBuildPromptTemplates(t *testing.T) {
+	defaults := &Service{promptTemplates: mergePromptTemplates(nil)}
+	custom := &Service{promptTemplates: mergePromptTemplates(map[string]string{
+		"Brief":    "Décris brièvement cette image.",
+		"detailed": "Réponds en français : {question}",
+	})}
+
+	tests := []struct {
+		name     string
+		svc      *Service
+		question string
+		detail   string
+		expected string
+	}{
+		{"default detailed", defaults, "", "detailed", "Describe this image in detail."},
+		{"default brief", defaults, "", "brief", "Briefly describe this image in 1-2 sentences."},
+		{"unknown detail falls back to detailed", defaults, "", "auto", "Describe this image in detail."},
+		{"question overrides default template", defaults, "What color is the car?", "brief", "What color is the car?"},
+		{"custom brief template", custom, "", "brief", "Décris brièvement cette image."},
+		{"question substituted into placeholder", custom, "What color is the car?", "detailed", "Réponds en français : What color is the car?"},
+		{"placeholder removed without question", custom, "", "detailed", "Réponds en français :"},
+		{"question overrides template without placeholder", custom, "What color is the car?", "brief", "What color is the car?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.svc.buildPrompt(tt.question, tt.detail); got != tt.expected {
+				t.Errorf("Expected prompt %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestImageSessions tests session ownership, history snapshots and expiry
+func TestImageSessions(t *testing.T) {
+	svc := &Service{}
+	first := []map[string]interface{}{
+		{"role": "user", "content": "describe"},
+		{"role": "assistant", "content": "a cat"},
+	}
+	id := svc.createSession("user-1", 1, "gpt-4o", first)
+
+	if _, ok := svc.getSession(id, "user-2"); ok {
+		t.Error("Expected session to be hidden from other users")
+	}
+
+	sess, ok := svc.getSession(id, "user-1")
+	if !ok {
+		t.Fatal("Expected session to be found for its owner")
+	}
+	svc.appendToSession(id, map[string]interface{}{"role": "user", "content": "what color?"})
+	if len(sess.messages) != 2 {
+		t.Errorf("Expected snapshot to be unaffected by later turns, got %d messages", len(sess.messages))
+	}
+	if sess, _ := svc.getSession(id, "user-1"); len(sess.messages) != 3 {
+		t.Errorf("Expected 3 messages after append, got %d", len(sess.messages))
+	}
+
+	svc.sessions[id].expiresAt = time.Now().Add(-time.Second)
+	if _, ok := svc.getSession(id, "user-1"); ok {
+		t.Error("Expected expired session to be pruned")
+	}
+}
+
+// TestImageSessionLimits tests the per-owner session cap and that sessions need an owner
+func TestImageSessionLimits(t *testing.T) {
+	svc := &Service{}
+	other := svc.createSession("user-2", 1, "gpt-4o", nil)
+	first := svc.createSession("user-1", 1, "gpt-4o", nil)
+	for i := 1; i < MaxSessionsPerOwner; i++ {
+		svc.createSession("user-1", 1, "gpt-4o", nil)
+	}
+	svc.sessions[first].expiresAt = time.Now().Add(time.Second) // closest to expiry
+
+	// One more evicts the owner's own oldest session, not another user's
+	svc.createSession("user-1", 1, "gpt-4o", nil)
+	if _, ok := svc.getSession(first, "user-1"); ok {
+		t.Error("Expected the owner's oldest session to be evicted")
+	}
+	if _, ok := svc.getSession(other, "user-2"); !ok {
+		t.Error("Expected another user's session to survive")
+	}
+	if len(svc.sessions) != MaxSessionsPerOwner+1 {
+		t.Errorf("Expected %d sessions, got %d", MaxSessionsPerOwner+1, len(svc.sessions))
+	}
+
+	svc.visionModelFinder = func() (int, string, error) { return 1, "gpt-4o", nil }
+	svc.providerGetter = func(id int) (*Provider, error) { return &Provider{ID: id}, nil }
+	for name, req := range map[string]*DescribeImageRequest{
+		"create":    {ImageData: []byte("img"), MimeType: "image/png", CreateSession: true},
+		"follow-up": {SessionID: other, Question: "what color?"},
+	} {
+		if _, err := svc.DescribeImage(req); !errors.Is(err, ErrSessionOwnerRequired) {
+			t.Errorf("%s: expected ErrSessionOwnerRequired, got %v", name, err)
+		}
+	}
+}
+
+// TestProviderAuthHeaders verifies auth style and custom headers reach the outgoing request
+func TestProviderAuthHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		want     map[string]string
+	}{
+		{
+			name:     "bearer by default",
+			provider: Provider{APIKey: "key"},
+			want:     map[string]string{"Authorization": "Bearer key", "Api-Key": ""},
+		},
+		{
+			name:     "azure api-key",
+			provider: Provider{APIKey: "key", AuthStyle: AuthStyleAPIKey},
+			want:     map[string]string{"Authorization": "", "Api-Key": "key"},
+		},
+		{
+			name: "custom headers",
+			provider: Provider{APIKey: "key", Headers: map[string]string{
+				"OpenAI-Organization": "org-1",
+				"X-Gateway-Key":       "gw",
+			}},
+			want: map[string]string{"Authorization": "Bearer key", "Openai-Organization": "org-1", "X-Gateway-Key": "gw"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "https://example.com", nil)
+			tt.provider.setAuthHeaders(req)
+			for name, want := range tt.want {
+				if got := req.Header.Get(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestDescribeBatch verifies input ordering, failover to the next model and per-item errors
+func TestDescribeBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%q}}]}`, body.Messages[0].Content[0].Text)
+	}))
+	defer server.Close()
+
+	svc := &Service{
+		httpClient: server.Client(),
+		providerGetter: func(id int) (*Provider, error) {
+			return &Provider{ID: id, Name: "test", BaseURL: server.URL, Enabled: true}, nil
+		},
+		visionModelFinder: func() (int, string, error) { return 1, "working", nil },
+		visionModelLister: func() ([]VisionModel, error) {
+			return []VisionModel{{ProviderID: 1, ModelName: "broken"}, {ProviderID: 1, ModelName: "working"}}, nil
+		},
+	}
+
+	reqs := make([]DescribeImageRequest, 6)
+	for i := range reqs {
+		reqs[i] = DescribeImageRequest{ImageData: []byte("img"), MimeType: "image/png", Question: fmt.Sprintf("image %d", i)}
+	}
+	reqs[3].SessionID = "session"
+
+	results, err := svc.DescribeBatch(reqs)
+	if err != nil {
+		t.Fatalf("DescribeBatch failed: %v", err)
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("result %d has index %d", i, result.Index)
+		}
+		if i == 3 {
+			if result.Error == "" {
+				t.Error("expected session follow-up to be rejected")
+			}
+			continue
+		}
+		if result.Error != "" || result.Response == nil {
+			t.Errorf("image %d failed: %s", i, result.Error)
+			continue
+		}
+		if result.Response.Description != fmt.Sprintf("image %d", i) || result.Response.Model != "working" {
+			t.Errorf("image %d: unexpected response %+v", i, result.Response)
+		}
+	}
+
+	_, err = svc.DescribeBatch(make([]DescribeImageRequest, MaxBatchSize+1))
+	var visionErr *Error
+	if !errors.As(err, &visionErr) || visionErr.HTTPStatus() != http.StatusBadRequest {
+		t.Errorf("expected oversized batch to be rejected with 400, got %v", err)
+	}
+}
+
+// pngOfSize encodes a blank PNG of the given dimensions
+func pngOfSize(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestResolveImageDetail tests auto-smart selection and explicit overrides
+func TestResolveImageDetail(t *testing.T) {
+	small := pngOfSize(t, 320, 240)
+	large := pngOfSize(t, 1600, 400)
+
+	tests := []struct {
+		name     string
+		detail   string
+		data     []byte
+		expected string
+	}{
+		{"default is auto", "", large, ImageDetailAuto},
+		{"auto-smart small image", "auto-smart", small, ImageDetailLow},
+		{"auto-smart at the threshold", "auto-smart", pngOfSize(t, 512, 512), ImageDetailLow},
+		{"auto-smart large image", "auto-smart", large, ImageDetailHigh},
+		{"auto-smart undecodable", "auto-smart", []byte("RIFF....WEBP"), ImageDetailAuto},
+		{"explicit low wins", "low", large, ImageDetailLow},
+		{"explicit high wins", "HIGH", small, ImageDetailHigh},
+		{"unknown falls back to auto", "ultra", small, ImageDetailAuto},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &DescribeImageRequest{ImageData: tt.data, MimeType: "image/png", ImageDetail: tt.detail}
+			if got := resolveImageDetail(req); got != tt.expected {
+				t.Errorf("resolveImageDetail(%q) = %q, want %q", tt.detail, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDescribeImageErrors verifies each failure class maps to its code, HTTP status and attempts
+func TestDescribeImageErrors(t *testing.T) {
+	statuses := map[string]int{"limited": http.StatusTooManyRequests, "broken": http.StatusInternalServerError}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(statuses[body.Model])
+	}))
+	defer server.Close()
+
+	newService := func(modelNames ...string) *Service {
+		return &Service{
+			httpClient: server.Client(),
+			providerGetter: func(id int) (*Provider, error) {
+				return &Provider{ID: id, Name: "test", BaseURL: server.URL, Enabled: true}, nil
+			},
+			visionModelFinder: func() (int, string, error) { return 0, "", fmt.Errorf("none configured") },
+			visionModelLister: func() ([]VisionModel, error) {
+				var models []VisionModel
+				for _, name := range modelNames {
+					models = append(models, VisionModel{ProviderID: 1, ModelName: name})
+				}
+				return models, nil
+			},
+		}
+	}
+	valid := DescribeImageRequest{ImageData: []byte("img"), MimeType: "image/png"}
+
+	tests := []struct {
+		name         string
+		svc          *Service
+		req          DescribeImageRequest
+		wantCode     ErrorCode
+		wantStatus   int
+		wantAttempts int
+	}{
+		{"no vision model", newService(), valid, ErrCodeNoVisionModel, http.StatusServiceUnavailable, 0},
+		{"empty image", newService("limited"), DescribeImageRequest{MimeType: "image/png"}, ErrCodeInvalidImage, http.StatusBadRequest, 0},
+		{"not an image", newService("limited"), DescribeImageRequest{ImageData: []byte("%PDF"), MimeType: "application/pdf"}, ErrCodeInvalidImage, http.StatusBadRequest, 0},
+		{"too large", newService("limited"), DescribeImageRequest{ImageData: make([]byte, MaxImageBytes+1), MimeType: "image/png"}, ErrCodeImageTooLarge, http.StatusRequestEntityTooLarge, 0},
+		{"all rate limited", newService("limited", "limited"), valid, ErrCodeRateLimited, http.StatusTooManyRequests, 2},
+		{"all failed", newService("limited", "broken"), valid, ErrCodeAllProvidersFailed, http.StatusBadGateway, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.svc.DescribeImage(&tt.req)
+			visionErr, ok := AsError(err)
+			if !ok {
+				t.Fatalf("DescribeImage error = %v, want a vision error", err)
+			}
+			if visionErr.Code != tt.wantCode || visionErr.HTTPStatus() != tt.wantStatus || len(visionErr.Attempts) != tt.wantAttempts {
+				t.Errorf("Got code=%s status=%d attempts=%+v, want code=%s status=%d with %d attempts",
+					visionErr.Code, visionErr.HTTPStatus(), visionErr.Attempts, tt.wantCode, tt.wantStatus, tt.wantAttempts)
+			}
+			if body := visionErr.Response(); body.Code != tt.wantCode || body.Error == "" {
+				t.Errorf("Response = %+v", body)
+			}
+		})
+	}
+}
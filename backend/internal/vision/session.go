@@ -0,0 +1,134 @@
+package vision
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SessionTTL is how long a vision session survives after its last use
+	SessionTTL = 15 * time.Minute
+	// MaxSessions caps stored sessions since each one holds a full image
+	MaxSessions = 50
+	// MaxSessionsPerOwner keeps one user from evicting everyone else's sessions
+	MaxSessionsPerOwner = 5
+)
+
+// ErrSessionOwnerRequired is returned when a session is created or continued without
+// an owner, which would make it reachable by anyone holding its ID
+var ErrSessionOwnerRequired = errors.New("vision sessions require an owner")
+
+// imageSession holds the conversation about one image for follow-up questions
+type imageSession struct {
+	ownerID    string
+	providerID int
+	modelName  string
+	messages   []map[string]interface{}
+	expiresAt  time.Time
+}
+
+// history returns a copy of the session's messages
+func (sess *imageSession) history() []map[string]interface{} {
+	messages := make([]map[string]interface{}, len(sess.messages))
+	copy(messages, sess.messages)
+	return messages
+}
+
+// createSession stores a conversation and returns its ID
+func (s *Service) createSession(ownerID string, providerID int, modelName string, messages []map[string]interface{}) string {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if s.sessions == nil {
+		s.sessions = make(map[string]*imageSession)
+	}
+	s.pruneSessionsLocked()
+
+	// Evict the session closest to expiry when the owner, or the store, is full
+	owned := 0
+	for _, sess := range s.sessions {
+		if sess.ownerID == ownerID {
+			owned++
+		}
+	}
+	if owned >= MaxSessionsPerOwner {
+		evicted := s.evictOldestSessionLocked(ownerID)
+		log.Printf("🗑️ [VISION] Session limit reached for %s, evicted %s", ownerID, evicted)
+	} else if len(s.sessions) >= MaxSessions {
+		evicted := s.evictOldestSessionLocked("")
+		log.Printf("🗑️ [VISION] Session limit reached, evicted %s", evicted)
+	}
+
+	id := uuid.New().String()
+	s.sessions[id] = &imageSession{
+		ownerID:    ownerID,
+		providerID: providerID,
+		modelName:  modelName,
+		messages:   messages,
+		expiresAt:  time.Now().Add(SessionTTL),
+	}
+	return id
+}
+
+// evictOldestSessionLocked deletes the session closest to expiry, of ownerID or of
+// anyone when ownerID is empty, and returns its ID (caller holds sessionMu)
+func (s *Service) evictOldestSessionLocked(ownerID string) string {
+	var oldestID string
+	var oldest time.Time
+	for id, sess := range s.sessions {
+		if ownerID != "" && sess.ownerID != ownerID {
+			continue
+		}
+		if oldestID == "" || sess.expiresAt.Before(oldest) {
+			oldestID, oldest = id, sess.expiresAt
+		}
+	}
+	delete(s.sessions, oldestID)
+	return oldestID
+}
+
+// getSession returns a live session owned by ownerID and refreshes its TTL
+func (s *Service) getSession(id, ownerID string) (*imageSession, bool) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	s.pruneSessionsLocked()
+	sess, ok := s.sessions[id]
+	if !ok || ownerID == "" || sess.ownerID != ownerID {
+		return nil, false
+	}
+	sess.expiresAt = time.Now().Add(SessionTTL)
+
+	// Return a snapshot so concurrent follow-ups don't share the slice
+	return &imageSession{
+		ownerID:    sess.ownerID,
+		providerID: sess.providerID,
+		modelName:  sess.modelName,
+		messages:   sess.history(),
+		expiresAt:  sess.expiresAt,
+	}, true
+}
+
+// appendToSession records a completed follow-up turn
+func (s *Service) appendToSession(id string, messages ...map[string]interface{}) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if sess, ok := s.sessions[id]; ok {
+		sess.messages = append(sess.messages, messages...)
+		sess.expiresAt = time.Now().Add(SessionTTL)
+	}
+}
+
+// pruneSessionsLocked drops expired sessions (caller holds sessionMu)
+func (s *Service) pruneSessionsLocked() {
+	now := time.Now()
+	for id, sess := range s.sessions {
+		if now.After(sess.expiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"os"
 
 	"github.com/claraverse/mcp-client/internal/commands"
+	"github.com/claraverse/mcp-client/internal/config"
 	"github.com/spf13/cobra"
 )
 
 var (
 	version = "1.0.0"
 	verbose bool
+	profile string
 )
 
 var rootCmd = &cobra.Command{
@@ -20,19 +22,28 @@ var rootCmd = &cobra.Command{
 servers to your ClaraVerse cloud chat, giving the AI access to your local tools,
 filesystems, databases, and custom integrations.`,
 	Version: version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		config.SelectProfile(profile)
+	},
 }
 
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Backend profile to use instead of the active one")
 
 	// Add all commands
 	rootCmd.AddCommand(commands.LoginCmd)
 	rootCmd.AddCommand(commands.StartCmd)
+	rootCmd.AddCommand(commands.StopCmd)
 	rootCmd.AddCommand(commands.AddCmd)
 	rootCmd.AddCommand(commands.ListCmd)
 	rootCmd.AddCommand(commands.RemoveCmd)
 	rootCmd.AddCommand(commands.StatusCmd)
+	rootCmd.AddCommand(commands.ExportCmd)
+	rootCmd.AddCommand(commands.ImportCmd)
+	rootCmd.AddCommand(commands.ProfileCmd)
+	rootCmd.AddCommand(commands.DoctorCmd)
 }
 
 func main() {
@@ -0,0 +1,120 @@
+package bridge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Binary values are exchanged with the backend as a wrapped JSON object:
+//
+//	{"__b64__": "<standard base64>", "mime_type": "image/png"}
+//
+// The wrapper can appear anywhere in tool arguments, and a tool result string may be
+// the JSON encoding of a wrapper (or of an object containing wrappers).
+//
+// Size limits: a single decoded value may not exceed MaxBinarySize. Wrapped values
+// are not chunked - the whole base64 string travels inside one WebSocket message,
+// so the encoded size (~4/3 of the raw bytes) counts against the message size.
+const (
+	BinaryKey     = "__b64__"
+	BinaryMimeKey = "mime_type"
+	MaxBinarySize = 8 * 1024 * 1024 // 8 MiB decoded
+)
+
+// EncodeBinary wraps raw bytes in the binary convention
+func EncodeBinary(data []byte, mimeType string) (map[string]interface{}, error) {
+	if len(data) > MaxBinarySize {
+		return nil, fmt.Errorf("binary value is %d bytes, exceeds limit of %d", len(data), MaxBinarySize)
+	}
+	wrapped := map[string]interface{}{
+		BinaryKey: base64.StdEncoding.EncodeToString(data),
+	}
+	if mimeType != "" {
+		wrapped[BinaryMimeKey] = mimeType
+	}
+	return wrapped, nil
+}
+
+// DecodeBinary unwraps a binary value. ok is false if v is not a wrapper.
+func DecodeBinary(v interface{}) (data []byte, mimeType string, ok bool, err error) {
+	m, isMap := v.(map[string]interface{})
+	if !isMap {
+		return nil, "", false, nil
+	}
+	encoded, isStr := m[BinaryKey].(string)
+	if !isStr {
+		return nil, "", false, nil
+	}
+	if base64.StdEncoding.DecodedLen(len(encoded)) > MaxBinarySize+2 {
+		return nil, "", true, fmt.Errorf("binary value exceeds limit of %d bytes", MaxBinarySize)
+	}
+	data, err = base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("invalid %s value: %w", BinaryKey, err)
+	}
+	if len(data) > MaxBinarySize {
+		return nil, "", true, fmt.Errorf("binary value is %d bytes, exceeds limit of %d", len(data), MaxBinarySize)
+	}
+	mimeType, _ = m[BinaryMimeKey].(string)
+	return data, mimeType, true, nil
+}
+
+// UnwrapBinaryArgs replaces wrapped binary values in tool arguments with plain base64
+// strings, which is how MCP servers expect binary data in JSON-RPC arguments.
+// Wrappers are validated (encoding and size) before being passed on.
+func UnwrapBinaryArgs(args map[string]interface{}) error {
+	for k, v := range args {
+		unwrapped, err := unwrapBinaryValue(v)
+		if err != nil {
+			return fmt.Errorf("argument %q: %w", k, err)
+		}
+		args[k] = unwrapped
+	}
+	return nil
+}
+
+func unwrapBinaryValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if _, isBinary := val[BinaryKey]; isBinary {
+			data, _, _, err := DecodeBinary(val)
+			if err != nil {
+				return nil, err
+			}
+			return base64.StdEncoding.EncodeToString(data), nil
+		}
+		for k, nested := range val {
+			unwrapped, err := unwrapBinaryValue(nested)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = unwrapped
+		}
+		return val, nil
+	case []interface{}:
+		for i, nested := range val {
+			unwrapped, err := unwrapBinaryValue(nested)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = unwrapped
+		}
+		return val, nil
+	default:
+		return v, nil
+	}
+}
+
+// EncodeBinaryResult serializes a binary tool result as a wrapped JSON string
+func EncodeBinaryResult(data []byte, mimeType string) (string, error) {
+	wrapped, err := EncodeBinary(data, mimeType)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode binary result: %w", err)
+	}
+	return string(encoded), nil
+}
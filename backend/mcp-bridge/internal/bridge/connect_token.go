@@ -0,0 +1,101 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// connectTokenPath is where the backend issues short-lived connection tokens
+const connectTokenPath = "/api/mcp/connect-token"
+
+// connectTokenClient requests connection tokens before each connection attempt
+var connectTokenClient = &http.Client{Timeout: 15 * time.Second}
+
+// errConnectTokenUnsupported means the backend predates connection tokens
+var errConnectTokenUnsupported = errors.New("backend does not issue connection tokens")
+
+// connectTokenURL returns the token endpoint on the backend serving backendURL
+// (same host, ws:// → http://, wss:// → https://)
+func connectTokenURL(backendURL string) (string, error) {
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid backend URL: %w", err)
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	u.Path = connectTokenPath
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
+// fetchConnectToken exchanges the long-lived auth token for a single-use connection
+// token, so the auth token never appears in the WebSocket URL
+func (b *Bridge) fetchConnectToken() (string, error) {
+	tokenURL, err := connectTokenURL(b.backendURL)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.authToken)
+
+	resp, err := connectTokenClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request connection token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusServiceUnavailable:
+		return "", errConnectTokenUnsupported
+	case http.StatusUnauthorized:
+		return "", fmt.Errorf("backend rejected the auth token, run 'mcp-client login' again")
+	default:
+		return "", fmt.Errorf("failed to request connection token: %s", resp.Status)
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Token == "" {
+		return "", fmt.Errorf("invalid connection token response")
+	}
+	return body.Token, nil
+}
+
+// dialTarget returns the URL and headers to connect with: a fresh connection token
+// in the URL, or on backends without connection tokens the auth token in the
+// Authorization header
+func (b *Bridge) dialTarget() (string, http.Header, error) {
+	token, err := b.fetchConnectToken()
+	if errors.Is(err, errConnectTokenUnsupported) {
+		if b.verbose {
+			log.Printf("[Bridge] %v, authenticating with the Authorization header", err)
+		}
+		return b.backendURL, http.Header{"Authorization": {"Bearer " + b.authToken}}, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	u, err := url.Parse(b.backendURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid backend URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("connect_token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil, nil
+}
@@ -0,0 +1,168 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Message encodings. JSON (text frames) is the default; msgpack (binary frames) is
+// offered in register_tools when enabled and used once the backend's ack confirms
+// it (protocol version 3+). Incoming messages are decoded by frame type, so either
+// encoding is understood at any time.
+//
+// Both encodings decode to the same values (numbers as float64, structs in their
+// JSON form), so message handling does not depend on the encoding.
+const (
+	EncodingJSON    = "json"
+	EncodingMsgpack = "msgpack"
+)
+
+// protocolMsgpack is the first version that may negotiate msgpack encoding
+const protocolMsgpack = 3
+
+// SetEncoding sets the preferred message encoding ("json" or "msgpack"). The
+// backend may still choose JSON.
+func (b *Bridge) SetEncoding(encoding string) error {
+	switch encoding {
+	case "", EncodingJSON:
+		b.preferredEncoding = EncodingJSON
+	case EncodingMsgpack:
+		b.preferredEncoding = EncodingMsgpack
+	default:
+		return fmt.Errorf("unknown encoding %q (want json or msgpack)", encoding)
+	}
+	return nil
+}
+
+// offeredEncodings lists the encodings sent with register_tools, most preferred first
+func (b *Bridge) offeredEncodings() []string {
+	if b.preferredEncoding == EncodingMsgpack {
+		return []string{EncodingMsgpack, EncodingJSON}
+	}
+	return []string{EncodingJSON}
+}
+
+// Encoding returns the encoding messages are currently sent in
+func (b *Bridge) Encoding() string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if b.encoding == "" {
+		return EncodingJSON
+	}
+	return b.encoding
+}
+
+// readMessage reads one message, decoding binary frames as msgpack and text frames as JSON
+func readMessage(conn *websocket.Conn) (Message, error) {
+	frameType, data, err := conn.ReadMessage()
+	if err != nil {
+		return Message{}, err
+	}
+	if frameType != websocket.BinaryMessage {
+		var msg Message
+		err := json.Unmarshal(data, &msg)
+		return msg, err
+	}
+
+	value, _, err := msgp.ReadIntfBytes(data)
+	if err != nil {
+		return Message{}, fmt.Errorf("invalid msgpack message: %w", err)
+	}
+	fields, ok := normalizeMsgpackValue(value).(map[string]interface{})
+	if !ok {
+		return Message{}, fmt.Errorf("invalid msgpack message: not a map")
+	}
+	msgType, _ := fields["type"].(string)
+	payload, _ := fields["payload"].(map[string]interface{})
+	return Message{Type: msgType, Payload: payload}, nil
+}
+
+// writeMessage writes one message in the given encoding
+func writeMessage(conn *websocket.Conn, encoding string, msg Message) error {
+	if encoding != EncodingMsgpack {
+		return conn.WriteJSON(msg)
+	}
+
+	data := msgp.AppendMapHeader(nil, 2)
+	data = msgp.AppendString(data, "type")
+	data = msgp.AppendString(data, msg.Type)
+	data = msgp.AppendString(data, "payload")
+	data, err := appendMsgpackValue(data, msg.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", msg.Type, err)
+	}
+	return conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// appendMsgpackValue appends v as the value JSON would produce for it: the common
+// payload types are written directly, anything else goes through encoding/json
+func appendMsgpackValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil, bool, string, float64, float32, int, int64, int32, uint, uint64, uint32:
+		return msgp.AppendIntf(b, v)
+	case map[string]interface{}:
+		b = msgp.AppendMapHeader(b, uint32(len(v)))
+		for key, nested := range v {
+			b = msgp.AppendString(b, key)
+			var err error
+			if b, err = appendMsgpackValue(b, nested); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case []interface{}:
+		b = msgp.AppendArrayHeader(b, uint32(len(v)))
+		for _, nested := range v {
+			var err error
+			if b, err = appendMsgpackValue(b, nested); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case []string:
+		b = msgp.AppendArrayHeader(b, uint32(len(v)))
+		for _, s := range v {
+			b = msgp.AppendString(b, s)
+		}
+		return b, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return b, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return b, err
+	}
+	return appendMsgpackValue(b, generic)
+}
+
+// normalizeMsgpackValue converts decoded msgpack values to the types encoding/json
+// decodes to (float64 numbers, string-keyed maps, []interface{} arrays)
+func normalizeMsgpackValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return string(v)
+	case map[string]interface{}:
+		for key, nested := range v {
+			v[key] = normalizeMsgpackValue(nested)
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = normalizeMsgpackValue(nested)
+		}
+		return v
+	}
+	return v
+}
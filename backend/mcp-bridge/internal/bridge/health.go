@@ -0,0 +1,38 @@
+package bridge
+
+import "time"
+
+// Health is a snapshot of the backend connection for monitoring
+type Health struct {
+	Connected         bool       `json:"connected"`
+	Reconnecting      bool       `json:"reconnecting"`
+	ReconnectAttempts int        `json:"reconnect_attempts"`
+	Revoked           bool       `json:"revoked,omitempty"`
+	ProtocolVersion   int        `json:"protocol_version,omitempty"` // 0 until registration is acknowledged
+	Encoding          string     `json:"encoding"`
+	LastHeartbeat     *time.Time `json:"last_heartbeat,omitempty"` // nil until one has been sent
+}
+
+// Health returns the current connection state
+func (b *Bridge) Health() Health {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	encoding := b.encoding
+	if encoding == "" {
+		encoding = EncodingJSON
+	}
+	health := Health{
+		Connected:         b.connected,
+		Reconnecting:      b.reconnecting,
+		ReconnectAttempts: b.reconnectAttempts,
+		Revoked:           b.revoked || b.unsupported,
+		ProtocolVersion:   b.protocolVersion,
+		Encoding:          encoding,
+	}
+	if !b.lastHeartbeat.IsZero() {
+		lastHeartbeat := b.lastHeartbeat
+		health.LastHeartbeat = &lastHeartbeat
+	}
+	return health
+}
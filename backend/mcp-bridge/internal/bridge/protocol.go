@@ -0,0 +1,47 @@
+package bridge
+
+import "fmt"
+
+// ProtocolVersion is the highest backend protocol version this client speaks. It is
+// sent with register_tools; the backend acks with the negotiated (lowest common)
+// version, and backends that predate negotiation are treated as version 1.
+//
+//	1: register_tools, tool_call/tool_result and heartbeats
+//	2: wrapped binary arguments and incremental tool updates (add_tools, remove_tools, update_tool)
+//	3: msgpack message encoding, when offered with "encodings" (see SetEncoding)
+//	4: cancel_tool_call, aborting a running tool call
+//	5: tool_progress, reporting progress of a running tool call
+//	6: tool_result_partial, streaming the output of tools registered with streams_results
+const ProtocolVersion = 6
+
+// protocolIncrementalTools is the first version accepting incremental tool updates
+const protocolIncrementalTools = 2
+
+// protocolToolProgress is the first version accepting tool_progress
+const protocolToolProgress = 5
+
+// protocolPartialResults is the first version accepting tool_result_partial
+const protocolPartialResults = 6
+
+// NegotiatedProtocol returns the protocol version agreed with the backend, or 0
+// before the registration has been acknowledged
+func (b *Bridge) NegotiatedProtocol() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.protocolVersion
+}
+
+// requireProtocol fails if the negotiated protocol does not include a feature, so
+// callers fall back (e.g. to a full RegisterTools) instead of sending messages the
+// backend cannot handle
+func (b *Bridge) requireProtocol(version int, feature string) error {
+	if negotiated := b.NegotiatedProtocol(); negotiated < version {
+		return fmt.Errorf("%s need protocol version %d, negotiated %d", feature, version, negotiated)
+	}
+	return nil
+}
+
+// SupportsToolProgress reports whether the backend accepts tool_progress messages
+func (b *Bridge) SupportsToolProgress() bool {
+	return b.NegotiatedProtocol() >= protocolToolProgress
+}
@@ -1,11 +1,15 @@
 package bridge
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/websocket"
 )
@@ -22,21 +26,43 @@ type ToolCall struct {
 	ToolName  string                 `json:"tool_name"`
 	Arguments map[string]interface{} `json:"arguments"`
 	Timeout   int                    `json:"timeout"`
+	// StreamResults is set when the backend accepts the output in tool_result_partial
+	// frames (the tool streams results and the protocol supports it)
+	StreamResults bool `json:"stream_results"`
+
+	// Context is cancelled when the backend sends cancel_tool_call for the call
+	Context context.Context `json:"-"`
 }
 
 // Bridge manages the WebSocket connection to the backend
 type Bridge struct {
-	backendURL     string
-	authToken      string
-	conn           *websocket.Conn
-	writeChan      chan Message
-	stopChan       chan struct{}
-	reconnectDelay time.Duration
-	maxReconnect   time.Duration
-	connected      bool
-	mutex          sync.RWMutex
-	onToolCall     func(ToolCall)
-	verbose        bool
+	backendURL      string
+	authToken       string
+	conn            *websocket.Conn
+	writeChan       chan Message
+	stopChan        chan struct{}
+	reconnectDelay  time.Duration
+	maxReconnect    time.Duration
+	writeTimeout    time.Duration
+	connected       bool
+	revoked         bool // set when the backend revokes this client; stops reconnecting
+	unsupported     bool // set when the backend rejects this client's protocol version; stops reconnecting
+	protocolVersion int  // negotiated with the backend; 0 until registration is acknowledged
+	mutex           sync.RWMutex
+	onToolCall      func(ToolCall)
+	verbose         bool
+
+	activeCalls   map[string]context.CancelFunc // by call ID, for cancel_tool_call
+	activeCallsMu sync.Mutex
+
+	registration *Message // last register_tools, re-sent after each reconnect
+
+	preferredEncoding string // offered with register_tools
+	encoding          string // confirmed by the backend's ack; JSON until then
+
+	lastHeartbeat     time.Time // when the last heartbeat was written to the backend
+	reconnecting      bool
+	reconnectAttempts int // failed attempts since the connection was lost
 }
 
 // NewBridge creates a new WebSocket bridge
@@ -48,10 +74,20 @@ func NewBridge(backendURL, authToken string, verbose bool) *Bridge {
 		stopChan:       make(chan struct{}),
 		reconnectDelay: 1 * time.Second,
 		maxReconnect:   60 * time.Second,
+		writeTimeout:   10 * time.Second,
 		verbose:        verbose,
+		activeCalls:    make(map[string]context.CancelFunc),
+
+		preferredEncoding: EncodingJSON,
 	}
 }
 
+// SetWriteTimeout sets how long a single write may block before the connection is
+// considered dead and re-established
+func (b *Bridge) SetWriteTimeout(timeout time.Duration) {
+	b.writeTimeout = timeout
+}
+
 // SetToolCallHandler sets the callback for tool call events
 func (b *Bridge) SetToolCallHandler(handler func(ToolCall)) {
 	b.onToolCall = handler
@@ -59,13 +95,15 @@ func (b *Bridge) SetToolCallHandler(handler func(ToolCall)) {
 
 // Connect establishes the WebSocket connection
 func (b *Bridge) Connect() error {
-	url := fmt.Sprintf("%s?token=%s", b.backendURL, b.authToken)
-
 	if b.verbose {
 		log.Printf("[Bridge] Connecting to %s", b.backendURL)
 	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	url, header, err := b.dialTarget()
+	if err != nil {
+		return err
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
@@ -73,14 +111,25 @@ func (b *Bridge) Connect() error {
 	b.mutex.Lock()
 	b.conn = conn
 	b.connected = true
+	b.reconnecting = false
+	b.reconnectAttempts = 0
 	b.reconnectDelay = 1 * time.Second // Reset reconnect delay on successful connection
+	registration := b.registration
 	b.mutex.Unlock()
 
 	log.Println("✅ Connected to backend")
 
-	// Start read and write loops
-	go b.readLoop()
-	go b.writeLoop()
+	// Start read and write loops; done stops the write loop when the read loop ends
+	done := make(chan struct{})
+	go b.readLoop(conn, done)
+	go b.writeLoop(conn, done)
+
+	// After a reconnect, register again under the same client ID so the backend
+	// sees the same client rather than a new one
+	if registration != nil {
+		log.Println("📦 Re-registering tools...")
+		b.writeChan <- *registration
+	}
 
 	return nil
 }
@@ -101,6 +150,9 @@ func (b *Bridge) ConnectWithRetry() {
 		}
 
 		attempt++
+		b.mutex.Lock()
+		b.reconnectAttempts = attempt
+		b.mutex.Unlock()
 		log.Printf("❌ Connection failed (attempt %d): %v", attempt, err)
 		log.Printf("🔄 Retrying in %v...", b.reconnectDelay)
 
@@ -115,16 +167,18 @@ func (b *Bridge) ConnectWithRetry() {
 }
 
 // readLoop handles incoming messages
-func (b *Bridge) readLoop() {
+func (b *Bridge) readLoop(conn *websocket.Conn, done chan struct{}) {
 	defer func() {
+		close(done)
 		b.handleDisconnect()
 	}()
 
 	for {
-		var msg Message
-		err := b.conn.ReadJSON(&msg)
+		msg, err := readMessage(conn)
 		if err != nil {
-			if b.verbose {
+			if websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+				log.Printf("[Bridge] Backend closed the connection: a message exceeded its size limit. Disable some MCP servers or tools, or return smaller tool results")
+			} else if b.verbose {
 				log.Printf("[Bridge] Read error: %v", err)
 			}
 			return
@@ -134,27 +188,51 @@ func (b *Bridge) readLoop() {
 	}
 }
 
-// writeLoop handles outgoing messages
-func (b *Bridge) writeLoop() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+// heartbeatInterval is the average time between heartbeats. Each interval is varied
+// by up to ±heartbeatJitter so clients that reconnected together (e.g. after a backend
+// restart) don't heartbeat in lockstep.
+const (
+	heartbeatInterval = 30 * time.Second
+	heartbeatJitter   = 0.1
+)
+
+// jitteredHeartbeatInterval returns heartbeatInterval varied randomly by ±heartbeatJitter
+func jitteredHeartbeatInterval() time.Duration {
+	return heartbeatInterval + time.Duration((rand.Float64()*2-1)*heartbeatJitter*float64(heartbeatInterval))
+}
+
+// writeLoop handles outgoing messages for one connection
+func (b *Bridge) writeLoop(conn *websocket.Conn, done chan struct{}) {
+	heartbeatTimer := time.NewTimer(jitteredHeartbeatInterval())
+	defer heartbeatTimer.Stop()
 
 	for {
 		select {
 		case msg := <-b.writeChan:
-			err := b.conn.WriteJSON(msg)
+			// A stalled connection fails the write instead of blocking heartbeats and results
+			conn.SetWriteDeadline(time.Now().Add(b.writeTimeout))
+			err := writeMessage(conn, b.Encoding(), msg)
 			if err != nil {
-				if b.verbose {
-					log.Printf("[Bridge] Write error: %v", err)
-				}
+				log.Printf("❌ Write to backend failed, reconnecting: %v", err)
+				// Closing the socket ends the read loop, which reconnects
+				conn.Close()
 				return
 			}
+			if msg.Type == "heartbeat" {
+				b.mutex.Lock()
+				b.lastHeartbeat = time.Now()
+				b.mutex.Unlock()
+			}
+
+		case <-done:
+			return
 
-		case <-ticker.C:
+		case <-heartbeatTimer.C:
 			// Send heartbeat
 			if err := b.SendHeartbeat(); err != nil {
 				return
 			}
+			heartbeatTimer.Reset(jitteredHeartbeatInterval())
 
 		case <-b.stopChan:
 			return
@@ -170,13 +248,38 @@ func (b *Bridge) handleMessage(msg Message) {
 
 	switch msg.Type {
 	case "ack":
-		log.Printf("✅ Registration acknowledged")
-		if status, ok := msg.Payload["status"].(string); ok {
+		status, _ := msg.Payload["status"].(string)
+		if status == "connected" {
+			log.Printf("✅ Registration acknowledged")
+			// Backends that predate negotiation send no version and speak version 1
+			version := 1
+			if v, ok := msg.Payload["protocol_version"].(float64); ok && v > 0 {
+				version = int(math.Min(v, ProtocolVersion))
+			}
+			// Later messages use msgpack only when the backend chose it
+			encoding := EncodingJSON
+			if e, _ := msg.Payload["encoding"].(string); e == EncodingMsgpack && version >= protocolMsgpack {
+				encoding = EncodingMsgpack
+			}
+			b.mutex.Lock()
+			b.protocolVersion = version
+			b.encoding = encoding
+			b.mutex.Unlock()
+			log.Printf("   Protocol version: %d, encoding: %s", version, encoding)
+		}
+		if status != "" {
 			log.Printf("   Status: %s", status)
 		}
 		if toolsReg, ok := msg.Payload["tools_registered"].(float64); ok {
 			log.Printf("   Tools registered: %.0f", toolsReg)
 		}
+		if duplicates, ok := msg.Payload["duplicate_tools"].([]interface{}); ok && len(duplicates) > 0 {
+			log.Printf("⚠️  Duplicate tool names were skipped (only the first of each is used): %v", duplicates)
+			log.Printf("   Rename the tools or disable one of the servers providing them")
+		}
+		if changes, ok := msg.Payload["tool_changes"].(map[string]interface{}); ok {
+			logToolChanges(changes)
+		}
 
 	case "tool_call":
 		// Parse tool call
@@ -184,21 +287,63 @@ func (b *Bridge) handleMessage(msg Message) {
 		toolName := msg.Payload["tool_name"].(string)
 		args, _ := msg.Payload["arguments"].(map[string]interface{})
 		timeout, _ := msg.Payload["timeout"].(float64)
+		streamResults, _ := msg.Payload["stream_results"].(bool)
 
+		ctx, cancel := context.WithCancel(context.Background())
 		toolCall := ToolCall{
-			CallID:    callID,
-			ToolName:  toolName,
-			Arguments: args,
-			Timeout:   int(timeout),
+			CallID:        callID,
+			ToolName:      toolName,
+			Arguments:     args,
+			Timeout:       int(timeout),
+			StreamResults: streamResults && b.NegotiatedProtocol() >= protocolPartialResults,
+			Context:       ctx,
 		}
 
 		log.Printf("🔧 Tool call: %s (call_id: %s)", toolName, callID)
 
-		// Call handler if set
+		// Wrapped binary arguments are passed to MCP servers as plain base64 strings
+		if err := UnwrapBinaryArgs(args); err != nil {
+			log.Printf("❌ Invalid binary argument in %s: %v", toolName, err)
+			b.SendToolResult(callID, false, "", err.Error(), 0)
+			cancel()
+			return
+		}
+
+		// Run the handler outside the read loop so a cancel_tool_call can arrive meanwhile
 		if b.onToolCall != nil {
-			b.onToolCall(toolCall)
+			b.activeCallsMu.Lock()
+			b.activeCalls[callID] = cancel
+			b.activeCallsMu.Unlock()
+			go func() {
+				defer b.finishToolCall(callID)
+				b.onToolCall(toolCall)
+			}()
+		} else {
+			cancel()
+		}
+
+	case "cancel_tool_call":
+		callID, _ := msg.Payload["call_id"].(string)
+		if b.cancelToolCall(callID) {
+			log.Printf("🛑 Cancelling tool call %s", callID)
+		} else if b.verbose {
+			log.Printf("[Bridge] Cancel for finished or unknown tool call %s", callID)
 		}
 
+	case "disconnect":
+		reason, _ := msg.Payload["reason"].(string)
+		log.Printf("🔌 Disconnected by backend (reason: %s)", reason)
+		b.mutex.Lock()
+		switch reason {
+		case "revoked":
+			b.revoked = true
+		case "unsupported_protocol":
+			b.unsupported = true
+			minVersion, _ := msg.Payload["min_protocol_version"].(float64)
+			log.Printf("⛔ The backend requires protocol version %.0f or newer; this client speaks %d", minVersion, ProtocolVersion)
+		}
+		b.mutex.Unlock()
+
 	case "error":
 		errMsg := msg.Payload["message"].(string)
 		log.Printf("❌ Error from backend: %s", errMsg)
@@ -210,6 +355,29 @@ func (b *Bridge) handleMessage(msg Message) {
 	}
 }
 
+// cancelToolCall cancels a running tool call's context. It returns false if the call
+// is not running.
+func (b *Bridge) cancelToolCall(callID string) bool {
+	b.activeCallsMu.Lock()
+	defer b.activeCallsMu.Unlock()
+	cancel, ok := b.activeCalls[callID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// finishToolCall releases a tool call's context once its handler returns
+func (b *Bridge) finishToolCall(callID string) {
+	b.activeCallsMu.Lock()
+	cancel := b.activeCalls[callID]
+	delete(b.activeCalls, callID)
+	b.activeCallsMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
 // handleDisconnect handles disconnection and reconnection
 func (b *Bridge) handleDisconnect() {
 	b.mutex.Lock()
@@ -217,40 +385,184 @@ func (b *Bridge) handleDisconnect() {
 	if b.conn != nil {
 		b.conn.Close()
 	}
+	b.protocolVersion = 0
+	b.encoding = EncodingJSON
+	revoked, unsupported := b.revoked, b.unsupported
+	b.reconnecting = !revoked && !unsupported
 	b.mutex.Unlock()
 
 	log.Println("🔌 Disconnected from backend")
+	if revoked {
+		log.Println("⛔ This client was revoked and its sign-in is no longer accepted; not reconnecting. Run 'mcp-client login' to sign in again.")
+		return
+	}
+	if unsupported {
+		log.Println("⛔ This client is too old for the backend; not reconnecting. Please update the MCP client.")
+		return
+	}
 	log.Println("🔄 Attempting to reconnect...")
 
 	// Reconnect with exponential backoff
 	b.ConnectWithRetry()
 }
 
-// RegisterTools sends tool registration message
+// RegisterTools sends tool registration message. The registration is kept and
+// re-sent whenever the bridge reconnects, so clientID should be stable.
 func (b *Bridge) RegisterTools(clientID, clientVersion, platform string, tools []interface{}) error {
 	msg := Message{
 		Type: "register_tools",
 		Payload: map[string]interface{}{
-			"client_id":      clientID,
-			"client_version": clientVersion,
-			"platform":       platform,
-			"tools":          tools,
+			"client_id":        clientID,
+			"client_version":   clientVersion,
+			"platform":         platform,
+			"tools":            tools,
+			"protocol_version": ProtocolVersion,
+			"encodings":        b.offeredEncodings(),
 		},
 	}
 
+	b.mutex.Lock()
+	b.registration = &msg
+	b.mutex.Unlock()
+
 	b.writeChan <- msg
 	return nil
 }
 
-// SendToolResult sends tool execution result back to backend
-func (b *Bridge) SendToolResult(callID string, success bool, result, errorMsg string) error {
+// SyncTools re-sends the registration with a new full tool set, for backends
+// without incremental tool updates. RegisterTools must have been called first.
+func (b *Bridge) SyncTools(tools []interface{}) error {
+	b.updateRegisteredTools(func([]interface{}) []interface{} { return tools })
+
+	b.mutex.RLock()
+	registration := b.registration
+	b.mutex.RUnlock()
+	if registration == nil {
+		return fmt.Errorf("tools have not been registered yet")
+	}
+	return b.queueUpdate(*registration)
+}
+
+// AddTools registers additional tools (e.g. from a server that just started)
+// without re-sending the full tool set. It fails if the backend's protocol
+// version has no incremental updates; re-send everything with SyncTools then.
+func (b *Bridge) AddTools(tools []interface{}) error {
+	if err := b.requireProtocol(protocolIncrementalTools, "incremental tool updates"); err != nil {
+		return err
+	}
+	b.updateRegisteredTools(func(registered []interface{}) []interface{} {
+		return append(withoutTools(registered, toolNames(tools)), tools...)
+	})
+	return b.queueUpdate(Message{
+		Type:    "add_tools",
+		Payload: map[string]interface{}{"tools": tools},
+	})
+}
+
+// RemoveTools unregisters tools by name (e.g. from a server that stopped)
+func (b *Bridge) RemoveTools(names []string) error {
+	if err := b.requireProtocol(protocolIncrementalTools, "incremental tool updates"); err != nil {
+		return err
+	}
+	b.updateRegisteredTools(func(registered []interface{}) []interface{} {
+		return withoutTools(registered, names)
+	})
+	return b.queueUpdate(Message{
+		Type:    "remove_tools",
+		Payload: map[string]interface{}{"names": names},
+	})
+}
+
+// UpdateTool replaces the definition of a single registered tool
+func (b *Bridge) UpdateTool(tool interface{}) error {
+	if err := b.requireProtocol(protocolIncrementalTools, "incremental tool updates"); err != nil {
+		return err
+	}
+	b.updateRegisteredTools(func(registered []interface{}) []interface{} {
+		return append(withoutTools(registered, toolNames([]interface{}{tool})), tool)
+	})
+	return b.queueUpdate(Message{
+		Type:    "update_tool",
+		Payload: map[string]interface{}{"tool": tool},
+	})
+}
+
+// queueUpdate hands a tool update to the write loop. Unlike results it fails rather
+// than block forever when the bridge is closed or the queue stays full for the
+// write timeout; the kept registration already has the change for the next reconnect.
+func (b *Bridge) queueUpdate(msg Message) error {
+	timer := time.NewTimer(b.writeTimeout)
+	defer timer.Stop()
+
+	select {
+	case b.writeChan <- msg:
+		return nil
+	case <-b.stopChan:
+		return fmt.Errorf("bridge is closed, %s not sent", msg.Type)
+	case <-timer.C:
+		return fmt.Errorf("timed out queueing %s", msg.Type)
+	}
+}
+
+// updateRegisteredTools applies a tool set change to the kept registration, so
+// reconnects register the current tools. The message is copied, not modified, as
+// the previous one may still be queued.
+func (b *Bridge) updateRegisteredTools(update func(registered []interface{}) []interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.registration == nil {
+		return
+	}
+
+	payload := make(map[string]interface{}, len(b.registration.Payload))
+	for key, value := range b.registration.Payload {
+		payload[key] = value
+	}
+	registered, _ := payload["tools"].([]interface{})
+	payload["tools"] = update(append([]interface{}(nil), registered...))
+	b.registration = &Message{Type: b.registration.Type, Payload: payload}
+}
+
+// toolNames returns the names of tool definitions
+func toolNames(tools []interface{}) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if def, ok := tool.(map[string]interface{}); ok {
+			if name, ok := def["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// withoutTools returns tools without the ones named
+func withoutTools(tools []interface{}, names []string) []interface{} {
+	drop := make(map[string]bool, len(names))
+	for _, name := range names {
+		drop[name] = true
+	}
+	kept := tools[:0]
+	for _, tool := range tools {
+		if names := toolNames([]interface{}{tool}); len(names) == 1 && drop[names[0]] {
+			continue
+		}
+		kept = append(kept, tool)
+	}
+	return kept
+}
+
+// SendToolResult sends tool execution result back to backend. duration is how long
+// the tool itself ran, so the backend can tell tool time from network time.
+func (b *Bridge) SendToolResult(callID string, success bool, result, errorMsg string, duration time.Duration) error {
 	msg := Message{
 		Type: "tool_result",
 		Payload: map[string]interface{}{
-			"call_id": callID,
-			"success": success,
-			"result":  result,
-			"error":   errorMsg,
+			"call_id":     callID,
+			"success":     success,
+			"result":      result,
+			"error":       errorMsg,
+			"duration_ms": duration.Milliseconds(),
 		},
 	}
 
@@ -258,6 +570,132 @@ func (b *Bridge) SendToolResult(callID string, success bool, result, errorMsg st
 	return nil
 }
 
+// SendToolProgress reports progress of a running tool call. percent is 0-100, or
+// negative when the total is unknown and only the message is sent.
+func (b *Bridge) SendToolProgress(callID, message string, percent float64) error {
+	if err := b.requireProtocol(protocolToolProgress, "tool progress updates"); err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"call_id": callID,
+		"message": message,
+	}
+	if percent >= 0 {
+		payload["percent"] = percent
+	}
+	b.writeChan <- Message{Type: "tool_progress", Payload: payload}
+	return nil
+}
+
+// SendToolResultPartial streams part of a call's output. The part with final set
+// completes the call, with duration how long the tool ran; the backend's result is
+// all parts in order. Only calls with StreamResults set may be streamed.
+func (b *Bridge) SendToolResultPartial(callID, content string, final bool, duration time.Duration) error {
+	if err := b.requireProtocol(protocolPartialResults, "partial tool results"); err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"call_id": callID,
+		"content": content,
+	}
+	if final {
+		payload["final"] = true
+		payload["duration_ms"] = duration.Milliseconds()
+	}
+	b.writeChan <- Message{Type: "tool_result_partial", Payload: payload}
+	return nil
+}
+
+// SendCancelledToolResult reports that a call was aborted after cancel_tool_call
+func (b *Bridge) SendCancelledToolResult(callID string, duration time.Duration) error {
+	b.writeChan <- Message{
+		Type: "tool_result",
+		Payload: map[string]interface{}{
+			"call_id":     callID,
+			"success":     false,
+			"error":       "tool call cancelled",
+			"cancelled":   true,
+			"duration_ms": duration.Milliseconds(),
+		},
+	}
+	return nil
+}
+
+// SendToolNotFoundResult reports that no running server provides the called tool, so
+// the backend can tell the model to use another tool
+func (b *Bridge) SendToolNotFoundResult(callID, errorMsg string, duration time.Duration) error {
+	b.writeChan <- Message{
+		Type: "tool_result",
+		Payload: map[string]interface{}{
+			"call_id":     callID,
+			"success":     false,
+			"error":       errorMsg,
+			"not_found":   true,
+			"duration_ms": duration.Milliseconds(),
+		},
+	}
+	return nil
+}
+
+// SendTruncatedToolResult sends a successful result that was cut to fit the size
+// limit, recording the original size so the backend can tell the LLM
+func (b *Bridge) SendTruncatedToolResult(callID, result string, originalSize int, duration time.Duration) error {
+	b.writeChan <- Message{
+		Type: "tool_result",
+		Payload: map[string]interface{}{
+			"call_id":       callID,
+			"success":       true,
+			"result":        result,
+			"truncated":     true,
+			"original_size": originalSize,
+			"duration_ms":   duration.Milliseconds(),
+		},
+	}
+	return nil
+}
+
+// TruncateResult cuts result to at most maxBytes without splitting a UTF-8
+// character. It reports whether anything was cut.
+func TruncateResult(result string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(result) <= maxBytes {
+		return result, false
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(result[cut]) {
+		cut--
+	}
+	return result[:cut], true
+}
+
+// logToolChanges reports how the registered tools differ from the last connection
+func logToolChanges(changes map[string]interface{}) {
+	names := func(key string) []string {
+		list, _ := changes[key].([]interface{})
+		out := make([]string, 0, len(list))
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	added, removed, updated := names("added"), names("removed"), names("updated")
+	if len(added)+len(removed)+len(updated) == 0 {
+		log.Printf("   Tools unchanged since last connection")
+		return
+	}
+	log.Printf("   Since last connection: %d tools added, %d removed, %d updated", len(added), len(removed), len(updated))
+	if len(added) > 0 {
+		log.Printf("     + %s", strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		log.Printf("     - %s", strings.Join(removed, ", "))
+	}
+	if len(updated) > 0 {
+		log.Printf("     ~ %s", strings.Join(updated, ", "))
+	}
+}
+
 // SendHeartbeat sends a heartbeat message
 func (b *Bridge) SendHeartbeat() error {
 	msg := Message{
@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/claraverse/mcp-client/internal/config"
 	"github.com/spf13/cobra"
@@ -11,6 +14,8 @@ var (
 	serverPath string
 	serverType string
 	serverDesc string
+	retryTools []string
+	assumeYes  bool
 )
 
 var AddCmd = &cobra.Command{
@@ -19,9 +24,17 @@ var AddCmd = &cobra.Command{
 	Long: `Add a new MCP server to your configuration. The server will be
 enabled by default and started when you run 'mcp-client start'.
 
+If a server with the same name exists, the changes are shown and you are
+asked to confirm before it is overwritten (skip with --yes).
+
+The path (like command, args, url and config values edited in the config
+file) may reference environment variables as ${VAR} or $VAR; they are
+expanded each time the config is loaded. Use $$ for a literal $.
+
 Examples:
   mcp-client add filesystem --path /usr/local/bin/mcp-server-filesystem
-  mcp-client add database --path ./mcp-server-sqlite --type stdio`,
+  mcp-client add database --path ./mcp-server-sqlite --type stdio
+  mcp-client add tools --path '${HOME}/bin/mcp-server-tools'`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAdd,
 }
@@ -30,6 +43,8 @@ func init() {
 	AddCmd.Flags().StringVar(&serverPath, "path", "", "Path to MCP server executable (required)")
 	AddCmd.Flags().StringVar(&serverType, "type", "stdio", "Server type: stdio or sse")
 	AddCmd.Flags().StringVar(&serverDesc, "description", "", "Server description")
+	AddCmd.Flags().StringSliceVar(&retryTools, "retry-tools", nil, "Read-only tools to retry once on timeout (\"*\" for all)")
+	AddCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Overwrite an existing server without asking")
 	AddCmd.MarkFlagRequired("path")
 }
 
@@ -49,6 +64,21 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		Type:        serverType,
 		Description: serverDesc,
 		Enabled:     true,
+		RetryTools:  retryTools,
+	}
+
+	// Show what an overwrite would change and confirm it
+	if existing, err := cfg.GetServer(name); err == nil {
+		changes := config.DiffServers(*existing, server)
+		if len(changes) == 0 {
+			fmt.Printf("Server %s is already configured with these settings\n", name)
+			return nil
+		}
+		printServerChanges(name, changes)
+		if !assumeYes && !confirmOverwrite(name) {
+			fmt.Println("Aborted, server left unchanged")
+			return nil
+		}
 	}
 
 	// Add server
@@ -72,3 +102,46 @@ func runAdd(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// printServerChanges prints an old vs. new diff, flagging changes to what gets executed
+func printServerChanges(name string, changes []config.ServerChange) {
+	fmt.Printf("⚠️  Server %s already exists. Changes:\n", name)
+	for _, change := range changes {
+		marker := " "
+		if change.Behavioral {
+			marker = "!"
+		}
+		oldVal, newVal := change.Old, change.New
+		if strings.HasPrefix(change.Field, "env.") {
+			oldVal, newVal = maskEnvValue(oldVal), maskEnvValue(newVal)
+		}
+		fmt.Printf("  %s %-12s %q -> %q\n", marker, change.Field, oldVal, newVal)
+	}
+	for _, change := range changes {
+		if change.Behavioral {
+			fmt.Println("  (! = changes the command, arguments or environment the server runs with)")
+			break
+		}
+	}
+	fmt.Println()
+}
+
+// maskEnvValue hides an environment value, which may be an API key, showing only
+// whether it is set
+func maskEnvValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "********"
+}
+
+// confirmOverwrite asks the user to confirm replacing an existing server
+func confirmOverwrite(name string) bool {
+	fmt.Printf("Overwrite server %s? [y/N]: ", name)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/claraverse/mcp-client/internal/config"
+	"github.com/claraverse/mcp-client/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose common setup problems",
+	Long: `Run local checks on the client setup and print what passes, what fails and
+how to fix it:
+  - the config file parses and its result hooks compile
+  - an auth token is present, well-formed and not expired
+  - the backend URL is valid and the backend host can be reached
+  - servers are configured, and each enabled server's command resolves
+  - each enabled server starts and lists its tools (skip with --skip-servers)
+
+Exits with an error when any critical check fails.`,
+	RunE: runDoctor,
+}
+
+var doctorSkipServers bool
+
+func init() {
+	DoctorCmd.Flags().BoolVar(&doctorSkipServers, "skip-servers", false, "Don't start servers to list their tools")
+}
+
+// doctorDialTimeout bounds the backend reachability check
+const doctorDialTimeout = 5 * time.Second
+
+// doctorExpiryWarning is how close to expiry a token gets a warning
+const doctorExpiryWarning = 24 * time.Hour
+
+// doctorReport prints check results and counts warnings and failures
+type doctorReport struct {
+	passed, warnings, failures int
+}
+
+func (r *doctorReport) pass(check, detail string) {
+	r.passed++
+	fmt.Printf("✅ %s: %s\n", check, detail)
+}
+
+func (r *doctorReport) warn(check, detail, hint string) {
+	r.warnings++
+	fmt.Printf("⚠️  %s: %s\n", check, detail)
+	if hint != "" {
+		fmt.Printf("   → %s\n", hint)
+	}
+}
+
+func (r *doctorReport) fail(check, detail, hint string) {
+	r.failures++
+	fmt.Printf("❌ %s: %s\n", check, detail)
+	if hint != "" {
+		fmt.Printf("   → %s\n", hint)
+	}
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	// Failed checks are reported as they run; the usage text would only bury them,
+	// and main prints the final error
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	// Server start-up logs would drown out the report
+	if verbose, _ := cmd.Flags().GetBool("verbose"); !verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	fmt.Println("🩺 ClaraVerse MCP Client Doctor")
+	fmt.Println()
+
+	report := &doctorReport{}
+
+	cfg, err := config.Load()
+	if err != nil {
+		report.fail("Config", err.Error(), fmt.Sprintf("Fix or remove %s", config.GetConfigPath()))
+		return doctorResult(report)
+	}
+	cfg.ApplyEnvOverrides()
+	report.pass("Config", fmt.Sprintf("%s (profile %s)", config.GetConfigPath(), cfg.ProfileName()))
+	for i, hook := range cfg.ResultHooks {
+		if _, err := hook.Compile(); err != nil {
+			report.fail("Result hooks", fmt.Sprintf("hook %d: %v", i+1, err), "Fix the result_hooks entry in the config file")
+		}
+	}
+
+	checkDoctorToken(report, cfg)
+	checkDoctorBackend(report, cfg)
+	checkDoctorServers(report, cfg)
+
+	return doctorResult(report)
+}
+
+// checkDoctorToken checks that a token is present, shaped like a JWT and not expired
+func checkDoctorToken(report *doctorReport, cfg *config.Config) {
+	loginHint := fmt.Sprintf("Run 'mcp-client login' (or set %s)", config.EnvAuthToken)
+	if cfg.AuthToken == "" {
+		report.fail("Auth token", "not logged in", loginHint)
+		return
+	}
+	if err := config.ValidateJWT(cfg.AuthToken); err != nil {
+		report.fail("Auth token", err.Error(), loginHint)
+		return
+	}
+
+	expiry, ok := config.TokenExpiry(cfg.AuthToken)
+	switch {
+	case !ok:
+		report.pass("Auth token", fmt.Sprintf("present in %s (no expiry)", cfg.TokenLocation()))
+	case time.Now().After(expiry):
+		report.fail("Auth token", fmt.Sprintf("expired %s", expiry.Local().Format(time.RFC1123)), loginHint)
+	case time.Until(expiry) < doctorExpiryWarning:
+		report.warn("Auth token", fmt.Sprintf("expires %s", expiry.Local().Format(time.RFC1123)), "Run 'mcp-client login' soon to refresh it")
+	default:
+		report.pass("Auth token", fmt.Sprintf("valid until %s (%s)", expiry.Local().Format(time.RFC1123), cfg.TokenLocation()))
+	}
+}
+
+// checkDoctorBackend checks the backend URL and that its host accepts connections.
+// It only dials (and for wss:// completes the TLS handshake); it does not register.
+func checkDoctorBackend(report *doctorReport, cfg *config.Config) {
+	insecure, err := config.CheckBackendURL(cfg.BackendURL)
+	if err != nil {
+		report.fail("Backend URL", err.Error(), "Set it with 'mcp-client login --backend wss://<host>/mcp/connect'")
+		return
+	}
+	if insecure {
+		report.warn("Backend URL", fmt.Sprintf("%s is unencrypted", cfg.BackendURL), "Use wss:// ('start' refuses ws:// to remote hosts without --insecure)")
+	} else {
+		report.pass("Backend URL", cfg.BackendURL)
+	}
+
+	u, _ := url.Parse(cfg.BackendURL)
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	dialer := &net.Dialer{Timeout: doctorDialTimeout}
+	var conn net.Conn
+	if u.Scheme == "wss" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: u.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		report.fail("Backend reachable", fmt.Sprintf("cannot connect to %s: %v", addr, err),
+			"Check the backend URL and your network, and that the backend is running")
+		return
+	}
+	conn.Close()
+	report.pass("Backend reachable", addr)
+}
+
+// checkDoctorServers checks the configured servers: each enabled one must resolve
+// to an executable and, unless skipped, start and list its tools
+func checkDoctorServers(report *doctorReport, cfg *config.Config) {
+	if len(cfg.MCPServers) == 0 {
+		report.warn("Servers", "no MCP servers configured", "Add one with 'mcp-client add <name> --path <server-path>'")
+		return
+	}
+	enabled := cfg.GetEnabledServers()
+	if len(enabled) == 0 {
+		report.warn("Servers", fmt.Sprintf("%d configured, none enabled", len(cfg.MCPServers)), "Enable a server in the config file")
+		return
+	}
+	report.pass("Servers", fmt.Sprintf("%d configured, %d enabled", len(cfg.MCPServers), len(enabled)))
+
+	reg := registry.NewRegistry(false)
+	defer reg.StopAll()
+
+	for _, server := range enabled {
+		check := fmt.Sprintf("Server %s", server.Name)
+		if server.Type != "stdio" {
+			report.fail(check, fmt.Sprintf("type %q is not supported", server.Type), "Only stdio servers can be run; set type: stdio")
+			continue
+		}
+
+		command, hint := server.Command, "Install it or add its directory to PATH"
+		if command == "" {
+			command, hint = server.Path, "Check the server's path in the config file"
+		}
+		if command == "" {
+			report.fail(check, "no command or path configured", fmt.Sprintf("Re-add it with 'mcp-client add %s --path <server-path>'", server.Name))
+			continue
+		}
+		resolved, err := exec.LookPath(command)
+		if err != nil {
+			report.fail(check, fmt.Sprintf("%s not found", command), hint)
+			continue
+		}
+		if doctorSkipServers {
+			report.pass(check, fmt.Sprintf("command resolves to %s", resolved))
+			continue
+		}
+
+		if err := reg.StartServer(server); err != nil {
+			report.fail(check, err.Error(), "Run 'mcp-client start --dry-run -v' to see the server's output")
+			continue
+		}
+		instance, err := reg.GetServer(server.Name)
+		if err != nil || len(instance.Tools) == 0 {
+			report.warn(check, "started but lists no tools", "Check the server's own configuration")
+			continue
+		}
+		report.pass(check, fmt.Sprintf("started, %d tools", len(instance.Tools)))
+	}
+}
+
+// doctorResult prints the summary and fails the command if a critical check failed
+func doctorResult(report *doctorReport) error {
+	fmt.Println()
+	fmt.Printf("📋 Summary: %d passed, %d warnings, %d failed\n", report.passed, report.warnings, report.failures)
+	if report.failures > 0 {
+		return fmt.Errorf("%d check(s) failed", report.failures)
+	}
+	fmt.Println("✅ No problems found")
+	return nil
+}
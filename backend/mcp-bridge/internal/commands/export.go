@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/claraverse/mcp-client/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOut     string
+	exportKeepEnv []string
+)
+
+var ExportCmd = &cobra.Command{
+	Use:   "export [name...]",
+	Short: "Export MCP servers as a shareable bundle",
+	Long: `Write configured MCP servers to a YAML bundle that teammates can load with
+'mcp-client import'. All servers are exported unless names are given.
+
+The bundle never contains your auth token, user ID or backend URL. Env
+variable names are kept, but their values are left blank for each person to
+fill in, except those named with --keep-env (names that look like secrets,
+such as TOKEN, API_KEY or PASSWORD, are always blanked). Credentials in args
+(e.g. --api-key values) and URLs are removed. The file is readable only by you.
+
+Examples:
+  mcp-client export --out team-servers.yaml
+  mcp-client export filesystem github --out team-servers.yaml
+  mcp-client export --keep-env LOG_LEVEL,REGION --out team-servers.yaml`,
+	RunE: runExport,
+}
+
+func init() {
+	ExportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "File to write the bundle to (required)")
+	ExportCmd.Flags().StringSliceVar(&exportKeepEnv, "keep-env", nil, "Env variables whose values are exported (comma-separated)")
+	ExportCmd.MarkFlagRequired("out")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	// Load config
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Export servers as written, with their ${VAR} references rather than this machine's values
+	servers := cfg.SourceServers()
+	if len(args) > 0 {
+		byName := make(map[string]config.MCPServer, len(servers))
+		for _, server := range servers {
+			byName[server.Name] = server
+		}
+		servers = nil
+		for _, name := range args {
+			if _, err := cfg.GetServer(name); err != nil {
+				return err
+			}
+			servers = append(servers, byName[name])
+		}
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("no MCP servers configured to export")
+	}
+
+	bundle, warnings := config.NewBundle(servers, exportKeepEnv)
+	if err := config.WriteBundle(exportOut, bundle); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Exported %d MCP server(s) to %s\n", len(bundle.Servers), exportOut)
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	for _, server := range bundle.Servers {
+		if blank := server.BlankEnv(); len(blank) > 0 {
+			fmt.Printf("🔒 %s: left blank %v\n", server.Name, blank)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/claraverse/mcp-client/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var importAssumeYes bool
+
+var ImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import MCP servers from a bundle",
+	Long: `Merge the MCP servers in a bundle written by 'mcp-client export' into your
+configuration. New servers are added; for a server you already have, the
+changes are shown and you are asked to confirm before it is overwritten
+(skip with --yes). Blank env values in the bundle keep your local values.
+
+Examples:
+  mcp-client import team-servers.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	ImportCmd.Flags().BoolVarP(&importAssumeYes, "yes", "y", false, "Overwrite existing servers without asking")
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	bundle, err := config.ReadBundle(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Load config
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Compare with the servers as written, so ${VAR} references are kept and never
+	// replaced by this machine's values
+	sources := make(map[string]config.MCPServer)
+	for _, server := range cfg.SourceServers() {
+		sources[server.Name] = server
+	}
+
+	var added, updated, unchanged, skipped int
+	var needsEnv []config.MCPServer
+	for _, server := range bundle.Servers {
+		if existing, ok := sources[server.Name]; ok {
+			server = config.KeepLocalSecrets(existing, server)
+			changes := config.DiffServers(existing, server)
+			if len(changes) == 0 {
+				unchanged++
+				continue
+			}
+			printServerChanges(server.Name, changes)
+			if !importAssumeYes && !confirmOverwrite(server.Name) {
+				skipped++
+				continue
+			}
+			updated++
+		} else {
+			added++
+		}
+
+		if err := cfg.AddServer(server); err != nil {
+			return fmt.Errorf("failed to add server %s: %w", server.Name, err)
+		}
+		if len(server.BlankEnv()) > 0 {
+			needsEnv = append(needsEnv, server)
+		}
+	}
+
+	// Save config
+	if added+updated > 0 {
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+
+	fmt.Printf("✅ Imported %s: %d added, %d updated, %d unchanged, %d skipped\n",
+		args[0], added, updated, unchanged, skipped)
+	for _, server := range needsEnv {
+		fmt.Printf("🔒 %s needs values for %v in %s\n", server.Name, server.BlankEnv(), config.GetConfigPath())
+	}
+	return nil
+}
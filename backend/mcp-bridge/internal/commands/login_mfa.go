@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SupabaseFactor is an MFA factor enrolled on a Supabase user
+type SupabaseFactor struct {
+	ID           string `json:"id"`
+	FactorType   string `json:"factor_type"`
+	Status       string `json:"status"`
+	FriendlyName string `json:"friendly_name"`
+}
+
+// verifiedTOTPFactor returns the user's verified TOTP factor, or nil if MFA is not enabled
+func verifiedTOTPFactor(factors []SupabaseFactor) *SupabaseFactor {
+	for i := range factors {
+		if factors[i].FactorType == "totp" && factors[i].Status == "verified" {
+			return &factors[i]
+		}
+	}
+	return nil
+}
+
+// completeMFAChallenge prompts for a TOTP code and runs the Supabase
+// challenge/verify flow, returning the upgraded (aal2) access token
+func completeMFAChallenge(reader *bufio.Reader, supabaseURL, supabaseKey, accessToken string, factor *SupabaseFactor) (string, error) {
+	name := factor.FriendlyName
+	if name == "" {
+		name = "authenticator app"
+	}
+	fmt.Printf("🔑 Multi-factor authentication required (%s)\n", name)
+
+	// Create challenge
+	var challenge struct {
+		ID string `json:"id"`
+	}
+	if err := supabaseAuthRequest(supabaseURL+"/auth/v1/factors/"+factor.ID+"/challenge", supabaseKey, accessToken, map[string]string{}, &challenge); err != nil {
+		return "", fmt.Errorf("failed to create MFA challenge: %w", err)
+	}
+	if challenge.ID == "" {
+		return "", fmt.Errorf("failed to create MFA challenge: no challenge ID received")
+	}
+
+	fmt.Print("Authentication code: ")
+	code, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read authentication code: %w", err)
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", fmt.Errorf("authentication code cannot be empty")
+	}
+
+	// Verify challenge
+	var verified SupabaseAuthResponse
+	err = supabaseAuthRequest(supabaseURL+"/auth/v1/factors/"+factor.ID+"/verify", supabaseKey, accessToken, map[string]string{
+		"challenge_id": challenge.ID,
+		"code":         code,
+	}, &verified)
+	if err != nil {
+		return "", fmt.Errorf("MFA verification failed: %w", err)
+	}
+	if verified.AccessToken == "" {
+		return "", fmt.Errorf("MFA verification failed: no access token received")
+	}
+
+	return verified.AccessToken, nil
+}
+
+// supabaseAuthRequest POSTs a JSON body to a Supabase auth endpoint as the logged-in user
+func supabaseAuthRequest(url, supabaseKey, accessToken string, payload interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", supabaseKey)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := supabaseHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s (status: %d)", string(body), resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
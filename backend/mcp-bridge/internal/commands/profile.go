@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/claraverse/mcp-client/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var ProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage backend profiles",
+	Long: `Profiles keep the settings of several backends (e.g. local, staging and prod)
+in one config: each has its own backend URL, auth token, user ID and servers.
+
+Commands use the active profile unless --profile selects another. A profile is
+created by logging in to it:
+  mcp-client login --profile staging --backend wss://staging.example.com/mcp/connect
+  mcp-client profile use staging`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	RunE:  runProfileList,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the active one",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileUse,
+}
+
+func init() {
+	ProfileCmd.AddCommand(profileListCmd)
+	ProfileCmd.AddCommand(profileUseCmd)
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fmt.Println("📋 Profiles:")
+	fmt.Println()
+	for _, name := range cfg.ProfileNames() {
+		profile, _ := cfg.GetProfile(name)
+		marker := "  "
+		if name == cfg.ProfileName() {
+			marker = "* "
+		}
+		login := "🔐 Logged in"
+		if profile.AuthToken == "" {
+			login = "❌ Not logged in"
+		}
+		fmt.Printf("%s%s\n", marker, name)
+		fmt.Printf("   Backend: %s\n", profile.BackendURL)
+		fmt.Printf("   %s, %d server(s)\n", login, len(profile.MCPServers))
+	}
+	return nil
+}
+
+func runProfileUse(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.UseProfile(args[0]); err != nil {
+		return err
+	}
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("✅ Active profile: %s\n", args[0])
+	return nil
+}
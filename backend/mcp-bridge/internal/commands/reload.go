@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"log"
+	"reflect"
+
+	"github.com/claraverse/mcp-client/internal/bridge"
+	"github.com/claraverse/mcp-client/internal/config"
+	"github.com/claraverse/mcp-client/internal/registry"
+)
+
+// toolChanges is how the tool set changed when servers were reloaded
+type toolChanges struct {
+	added   []interface{}
+	removed []string
+	updated []interface{}
+}
+
+func (c *toolChanges) empty() bool {
+	return len(c.added) == 0 && len(c.removed) == 0 && len(c.updated) == 0
+}
+
+// reloadServers applies config changes to the running servers (on SIGHUP): servers
+// that were removed or disabled are stopped, new ones started and changed ones
+// restarted, leaving the others and their running calls alone. The backend is sent
+// only the tools that changed, or the full tool set if it has no incremental updates.
+func reloadServers(reg *registry.Registry, b *bridge.Bridge) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("❌ Reload failed, keeping the running servers: %v", err)
+		return
+	}
+	log.Println("🔄 Reloading MCP servers...")
+
+	enabled := make(map[string]config.MCPServer)
+	for _, server := range cfg.GetEnabledServers() {
+		enabled[server.Name] = server
+	}
+
+	var changes toolChanges
+	for _, name := range reg.GetServerNames() {
+		instance, err := reg.GetServer(name)
+		if err != nil {
+			continue
+		}
+		server, keep := enabled[name]
+		delete(enabled, name)
+		if keep && len(config.DiffServers(instance.Config, server)) == 0 {
+			continue
+		}
+
+		oldTools := reg.ServerTools(name)
+		reg.StopServer(name)
+		var newTools []map[string]interface{}
+		if keep {
+			if err := reg.StartServer(server); err != nil {
+				log.Printf("❌ Failed to restart %s: %v", name, err)
+			}
+			newTools = reg.ServerTools(name)
+		}
+		changes.diff(oldTools, newTools)
+	}
+
+	// What is left was not running before
+	for _, server := range enabled {
+		if err := reg.StartServer(server); err != nil {
+			log.Printf("❌ Failed to start %s: %v", server.Name, err)
+			continue
+		}
+		changes.diff(nil, reg.ServerTools(server.Name))
+	}
+
+	if changes.empty() {
+		log.Println("✅ Reload complete, no tool changes")
+		return
+	}
+	log.Printf("📦 Tools changed: %d added, %d removed, %d updated",
+		len(changes.added), len(changes.removed), len(changes.updated))
+
+	if err := sendToolChanges(b, &changes); err != nil {
+		log.Printf("⚠️  Incremental tool update failed (%v), re-sending all tools", err)
+		if err := b.SyncTools(convertTools(reg.GetAllTools())); err != nil {
+			log.Printf("❌ Failed to re-send tools, they are registered again on the next reconnect: %v", err)
+		}
+	}
+}
+
+// diff records the changes from a server's old tools to its new ones
+func (c *toolChanges) diff(oldTools, newTools []map[string]interface{}) {
+	old := make(map[string]map[string]interface{}, len(oldTools))
+	for _, tool := range oldTools {
+		old[tool["name"].(string)] = tool
+	}
+
+	for _, tool := range newTools {
+		name := tool["name"].(string)
+		previous, existed := old[name]
+		delete(old, name)
+		switch {
+		case !existed:
+			c.added = append(c.added, tool)
+		case !reflect.DeepEqual(previous, tool):
+			c.updated = append(c.updated, tool)
+		}
+	}
+	for name := range old {
+		c.removed = append(c.removed, name)
+	}
+}
+
+// sendToolChanges sends the changes as add_tools, remove_tools and update_tool
+func sendToolChanges(b *bridge.Bridge, changes *toolChanges) error {
+	if len(changes.removed) > 0 {
+		if err := b.RemoveTools(changes.removed); err != nil {
+			return err
+		}
+	}
+	if len(changes.added) > 0 {
+		if err := b.AddTools(changes.added); err != nil {
+			return err
+		}
+	}
+	for _, tool := range changes.updated {
+		if err := b.UpdateTool(tool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
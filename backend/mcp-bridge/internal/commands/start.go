@@ -1,17 +1,23 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/claraverse/mcp-client/internal/bridge"
 	"github.com/claraverse/mcp-client/internal/config"
+	"github.com/claraverse/mcp-client/internal/daemon"
+	"github.com/claraverse/mcp-client/internal/health"
+	"github.com/claraverse/mcp-client/internal/mcp"
 	"github.com/claraverse/mcp-client/internal/registry"
-	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
@@ -20,30 +26,111 @@ var StartCmd = &cobra.Command{
 	Short: "Start the MCP client and connect to backend",
 	Long: `Starts the MCP client daemon, connects to the ClaraVerse backend,
 and registers all enabled MCP servers. The client will run in the foreground
-and handle tool execution requests from the backend.`,
+and handle tool execution requests from the backend.
+
+For headless use (containers, CI) the auth token and backend URL can be
+supplied with CLARAVERSE_AUTH_TOKEN and CLARAVERSE_BACKEND_URL, which take
+precedence over the config file. No login step is needed in that case.
+
+Use --daemon to run in the background instead. Logs are written to
+~/.claraverse/mcp-client.log and the client can be stopped with 'mcp-client stop'.
+
+Use --dry-run to check the configuration (e.g. in CI): enabled servers are
+started, their tools listed and the servers stopped again, without connecting
+to the backend or requiring login. The exit status is non-zero if any server
+fails to start.
+
+Use --health-port (or health_port in the config) to serve GET /health on
+127.0.0.1 with the backend connection state, running servers and tool count.
+It responds 200 while connected to the backend and 503 otherwise.
+
+Send SIGHUP to reload the config while running: removed or disabled servers are
+stopped, new ones started and changed ones restarted, and only the tools that
+changed are sent to the backend. Other servers keep running their calls.`,
 	RunE: runStart,
 }
 
+var (
+	runAsDaemon   bool
+	allowInsecure bool
+	dryRun        bool
+	healthPort    int
+)
+
+func init() {
+	StartCmd.Flags().BoolVarP(&runAsDaemon, "daemon", "d", false, "Run in the background (logs to ~/.claraverse/mcp-client.log)")
+	StartCmd.Flags().BoolVar(&allowInsecure, "insecure", false, "Allow connecting to a remote backend over unencrypted ws://")
+	StartCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Start enabled servers, list their tools and exit without connecting")
+	StartCmd.Flags().IntVar(&healthPort, "health-port", 0, "Serve GET /health on this local port (overrides health_port)")
+}
+
 func runStart(cmd *cobra.Command, args []string) error {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	cfg.ApplyEnvOverrides()
+	if healthPort > 0 {
+		cfg.HealthPort = healthPort
+	}
+
+	if dryRun {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		return runDryRun(cfg, verbose)
+	}
 
 	// Check if authenticated
 	if cfg.AuthToken == "" {
-		return fmt.Errorf("not authenticated. Please run 'mcp-client login' first")
+		return fmt.Errorf("not authenticated. Please run 'mcp-client login' first or set %s", config.EnvAuthToken)
+	}
+	if err := config.ValidateJWT(cfg.AuthToken); err != nil {
+		return err
+	}
+
+	// Refuse to send the auth token in the clear to anything but localhost
+	insecure, err := config.CheckBackendURL(cfg.BackendURL)
+	if err != nil {
+		return err
+	}
+	if insecure && !allowInsecure {
+		return fmt.Errorf("refusing to connect to %s over unencrypted ws:// (your auth token would be sent in the clear). Use wss:// or pass --insecure", cfg.BackendURL)
 	}
 
+	// Fork into the background: the child re-runs 'start' without --daemon
+	if runAsDaemon && !daemon.IsDaemonChild() {
+		pid, err := daemon.Start(daemonArgs(os.Args[1:]))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ MCP client started in background (PID %d)\n", pid)
+		fmt.Printf("📄 Logs: %s\n", daemon.GetLogFilePath())
+		fmt.Println("   Stop with: mcp-client stop")
+		return nil
+	}
+
+	if !daemon.IsDaemonChild() {
+		if pid, running := daemon.Status(); running {
+			return fmt.Errorf("mcp-client is already running in the background (PID %d). Run 'mcp-client stop' first", pid)
+		}
+	}
+	defer daemon.ReleasePID()
+
 	verbose, _ := cmd.Flags().GetBool("verbose")
 
 	log.Println("🚀 Starting ClaraVerse MCP Client")
-	log.Printf("📍 Config: %s", config.GetConfigPath())
+	log.Printf("📍 Config: %s (profile: %s)", config.GetConfigPath(), cfg.ProfileName())
 	log.Printf("🌐 Backend: %s", cfg.BackendURL)
+	if insecure {
+		log.Println("⚠️  WARNING: connecting to a remote backend over unencrypted ws:// (--insecure).")
+		log.Println("⚠️  Your auth token and tool traffic can be read by anyone on the network. Use wss:// in production.")
+	}
 
 	// Create server registry
 	reg := registry.NewRegistry(verbose)
+	if err := addResultHooks(reg, cfg.ResultHooks); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 
 	// Start all enabled MCP servers
 	enabledServers := cfg.GetEnabledServers()
@@ -66,10 +153,14 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	// Create WebSocket bridge
 	b := bridge.NewBridge(cfg.BackendURL, cfg.AuthToken, verbose)
+	b.SetWriteTimeout(cfg.WriteTimeout())
+	if err := b.SetEncoding(cfg.Encoding); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 
 	// Set tool call handler
 	b.SetToolCallHandler(func(tc bridge.ToolCall) {
-		handleToolCall(reg, b, tc)
+		handleToolCall(reg, b, tc, cfg.ResultLimit())
 	})
 
 	// Connect to backend
@@ -78,23 +169,62 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to connect to backend: %w", err)
 	}
 
-	// Register tools
-	clientID := uuid.New().String()
+	// Register tools under the persisted client ID so the backend recognizes this
+	// machine across restarts; the bridge re-registers with it after reconnects
+	if cfg.EnsureClientID() {
+		if err := config.Save(cfg); err != nil {
+			log.Printf("⚠️  Failed to save client ID, a new one will be used next start: %v", err)
+		}
+	}
 	tools := reg.GetAllTools()
 
-	log.Printf("📦 Registering %d tools...", len(tools))
-	if err := b.RegisterTools(clientID, "1.0.0", runtime.GOOS, convertTools(tools)); err != nil {
+	log.Printf("📦 Registering %d tools (client %s)...", len(tools), cfg.ClientID)
+	if err := b.RegisterTools(cfg.ClientID, "1.0.0", runtime.GOOS, convertTools(tools)); err != nil {
 		return fmt.Errorf("failed to register tools: %w", err)
 	}
 
+	// Serve liveness for supervisors and 'mcp-client status'
+	if addr := cfg.HealthAddress(); addr != "" {
+		startedAt := time.Now()
+		healthServer, err := health.Start(addr, func() health.Status {
+			status := health.Status{
+				Status:         health.StatusOK,
+				PID:            os.Getpid(),
+				StartedAt:      startedAt,
+				UptimeSeconds:  int64(time.Since(startedAt).Seconds()),
+				Backend:        b.Health(),
+				ServersRunning: reg.GetServerCount(),
+				ToolCount:      reg.GetToolCount(),
+			}
+			if !status.Backend.Connected {
+				status.Status = health.StatusDegraded
+			}
+			return status
+		})
+		if err != nil {
+			log.Printf("⚠️  Health endpoint disabled: %v", err)
+		} else {
+			defer healthServer.Close()
+			log.Printf("🩺 Health endpoint: http://%s%s", addr, health.Path)
+			if cfg.HealthHost != "" && cfg.HealthHost != config.DefaultHealthHost {
+				log.Printf("⚠️  The health endpoint is bound to %s rather than %s and may be reachable from other machines", cfg.HealthHost, config.DefaultHealthHost)
+			}
+		}
+	}
+
 	log.Println("✅ MCP client running. Press Ctrl+C to exit.")
 	log.Println("💡 Tools are now available in your web chat!")
 
-	// Handle graceful shutdown
+	// Handle config reloads and graceful shutdown
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
-	<-sigChan
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadServers(reg, b)
+	}
 
 	log.Println("\n🛑 Shutting down...")
 	b.Close()
@@ -104,20 +234,157 @@ func runStart(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func handleToolCall(reg *registry.Registry, b *bridge.Bridge, tc bridge.ToolCall) {
+// runDryRun starts every enabled server, prints the tools each one provides, then
+// stops them. Nothing is sent to the backend.
+func runDryRun(cfg *config.Config, verbose bool) error {
+	enabledServers := cfg.GetEnabledServers()
+	if len(enabledServers) == 0 {
+		return fmt.Errorf("no enabled MCP servers to check. Add servers with 'mcp-client add'")
+	}
+
+	fmt.Printf("🧪 Dry run: checking %d enabled MCP servers (no backend connection)\n\n", len(enabledServers))
+
+	reg := registry.NewRegistry(verbose)
+	defer reg.StopAll()
+
+	failed := 0
+	for _, server := range enabledServers {
+		if err := reg.StartServer(server); err != nil {
+			fmt.Printf("❌ %s: %v\n", server.Name, err)
+			failed++
+			continue
+		}
+
+		instance, err := reg.GetServer(server.Name)
+		if err != nil {
+			continue
+		}
+		fmt.Printf("✅ %s: %d tools\n", server.Name, len(instance.Tools))
+		for _, tool := range instance.Tools {
+			fmt.Printf("   - %s\n", tool.Name)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("📋 Summary: %d of %d servers started, %d tools total\n",
+		len(enabledServers)-failed, len(enabledServers), reg.GetToolCount())
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d MCP servers failed to start", failed, len(enabledServers))
+	}
+	return nil
+}
+
+// toolProgressBuffer is how many progress updates of a tool call may wait to be sent;
+// more are dropped
+const toolProgressBuffer = 16
+
+// toolStreamBuffer is toolProgressBuffer for tools that stream their output, where a
+// dropped update is lost output
+const toolStreamBuffer = 256
+
+func handleToolCall(reg *registry.Registry, b *bridge.Bridge, tc bridge.ToolCall, maxResultBytes int) {
 	log.Printf("🔧 Executing tool: %s (call_id: %s)", tc.ToolName, tc.CallID)
 
-	// Execute the tool
-	result, err := reg.ExecuteTool(tc.ToolName, tc.Arguments)
+	// Execute the tool, passing on the progress it reports if the backend accepts it.
+	// For streamed calls the progress messages are the output, sent as partial results.
+	// Everything sent goes through the result hooks, like the result itself.
+	start := time.Now()
+	var result string
+	var err error
+	if tc.StreamResults || b.SupportsToolProgress() {
+		buffer := toolProgressBuffer
+		if tc.StreamResults {
+			buffer = toolStreamBuffer
+		}
+		progress := make(chan mcp.Progress, buffer)
+		forwarded := make(chan struct{})
+		go func() {
+			defer close(forwarded)
+			for update := range progress {
+				switch {
+				case !tc.StreamResults:
+					b.SendToolProgress(tc.CallID, reg.ApplyResultHooks(tc.ToolName, update.Message), update.Percent)
+				case update.Message != "":
+					b.SendToolResultPartial(tc.CallID, reg.ApplyResultHooks(tc.ToolName, update.Message), false, 0)
+				}
+			}
+		}()
+		result, err = reg.ExecuteToolProgress(tc.Context, tc.ToolName, tc.Arguments, progress)
+		close(progress)
+		// Progress must not arrive after the result
+		<-forwarded
+	} else {
+		result, err = reg.ExecuteToolContext(tc.Context, tc.ToolName, tc.Arguments)
+	}
+	duration := time.Since(start)
 
+	if errors.Is(err, context.Canceled) {
+		log.Printf("🛑 Tool call cancelled: %s (call_id: %s)", tc.ToolName, tc.CallID)
+		b.SendCancelledToolResult(tc.CallID, duration)
+		return
+	}
+	if errors.Is(err, registry.ErrToolNotFound) {
+		log.Printf("❓ Tool not found: %s (call_id: %s)", tc.ToolName, tc.CallID)
+		b.SendToolNotFoundResult(tc.CallID, reg.ApplyResultHooks(tc.ToolName, err.Error()), duration)
+		return
+	}
 	if err != nil {
 		log.Printf("❌ Tool execution failed: %v", err)
-		b.SendToolResult(tc.CallID, false, "", err.Error())
+		b.SendToolResult(tc.CallID, false, "", reg.ApplyResultHooks(tc.ToolName, err.Error()), duration)
 		return
 	}
 
 	log.Printf("✅ Tool executed successfully: %s", tc.ToolName)
-	b.SendToolResult(tc.CallID, true, result, "")
+
+	// The streamed output is capped by the backend as a whole
+	if tc.StreamResults {
+		b.SendToolResultPartial(tc.CallID, result, true, duration)
+		return
+	}
+
+	if limit, ok := reg.ResultLimit(tc.ToolName); ok {
+		maxResultBytes = limit
+	}
+	if truncated, cut := bridge.TruncateResult(result, maxResultBytes); cut {
+		log.Printf("✂️  Result of %s truncated from %d to %d bytes", tc.ToolName, len(result), len(truncated))
+		b.SendTruncatedToolResult(tc.CallID, truncated, len(result), duration)
+		return
+	}
+	b.SendToolResult(tc.CallID, true, result, "", duration)
+}
+
+// addResultHooks registers the configured result hooks with the registry
+func addResultHooks(reg *registry.Registry, hooks []config.ResultHook) error {
+	for i, hook := range hooks {
+		apply, err := hook.Compile()
+		if err != nil {
+			return fmt.Errorf("result hook %d: %w", i+1, err)
+		}
+		if hook.AppliesToAll() {
+			reg.AddResultHook("*", apply)
+			continue
+		}
+		for _, toolName := range hook.Tools {
+			reg.AddResultHook(toolName, apply)
+		}
+	}
+	if len(hooks) > 0 {
+		log.Printf("🧹 %d result hook(s) will process tool output before it is sent", len(hooks))
+	}
+	return nil
+}
+
+// daemonArgs strips the --daemon flag so the background child runs in the foreground
+func daemonArgs(args []string) []string {
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--daemon" || arg == "-d" || strings.HasPrefix(arg, "--daemon=") {
+			continue
+		}
+		result = append(result, arg)
+	}
+	return result
 }
 
 func convertTools(tools []map[string]interface{}) []interface{} {
@@ -2,8 +2,11 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/claraverse/mcp-client/internal/config"
+	"github.com/claraverse/mcp-client/internal/daemon"
+	"github.com/claraverse/mcp-client/internal/health"
 	"github.com/spf13/cobra"
 )
 
@@ -20,8 +23,22 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	cfg.ApplyEnvOverrides()
 
 	fmt.Println("📊 ClaraVerse MCP Client Status")
+	fmt.Printf("🏷️  Profile: %s\n", cfg.ProfileName())
+	fmt.Println()
+
+	// Background process status
+	if pid, running := daemon.Status(); running {
+		fmt.Printf("⚙️  Daemon: ✅ Running (PID %d)\n", pid)
+		fmt.Printf("   Logs: %s\n", daemon.GetLogFilePath())
+		if addr := cfg.HealthAddress(); addr != "" {
+			printHealth(addr)
+		}
+	} else {
+		fmt.Println("⚙️  Daemon: ⏹️  Stopped")
+	}
 	fmt.Println()
 
 	// Authentication status
@@ -30,6 +47,10 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		if cfg.UserID != "" {
 			fmt.Printf("   User ID: %s\n", cfg.UserID)
 		}
+		if cfg.ClientID != "" {
+			fmt.Printf("   Client ID: %s\n", cfg.ClientID)
+		}
+		fmt.Printf("   Token stored in: %s\n", cfg.TokenLocation())
 	} else {
 		fmt.Println("🔐 Authentication: ❌ Not logged in")
 		fmt.Println("   Run 'mcp-client login' to authenticate")
@@ -38,6 +59,13 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	// Backend configuration
 	fmt.Printf("🌐 Backend: %s\n", cfg.BackendURL)
+	if insecure, err := config.CheckBackendURL(cfg.BackendURL); err != nil {
+		fmt.Printf("   ❌ %v\n", err)
+	} else if insecure {
+		fmt.Println("   ⚠️  Unencrypted ws:// to a remote host - use wss:// ('start' requires --insecure)")
+	}
+	supabaseURL, _ := cfg.ResolveSupabase()
+	fmt.Printf("🔑 Supabase: %s\n", supabaseURL)
 	fmt.Println()
 
 	// Server configuration
@@ -73,3 +101,25 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// printHealth reports the running client's state from its health endpoint
+func printHealth(addr string) {
+	status, err := health.Fetch(addr, 2*time.Second)
+	if err != nil {
+		fmt.Printf("   Health: ❓ Unavailable (%v)\n", err)
+		return
+	}
+
+	switch {
+	case status.Backend.Connected:
+		fmt.Printf("   Backend: ✅ Connected (protocol %d, %s)\n", status.Backend.ProtocolVersion, status.Backend.Encoding)
+	case status.Backend.Reconnecting:
+		fmt.Printf("   Backend: 🔄 Reconnecting (%d failed attempts)\n", status.Backend.ReconnectAttempts)
+	default:
+		fmt.Println("   Backend: ❌ Disconnected")
+	}
+	fmt.Printf("   Servers: %d running, %d tools\n", status.ServersRunning, status.ToolCount)
+	if status.Backend.LastHeartbeat != nil {
+		fmt.Printf("   Last heartbeat: %s ago\n", time.Since(*status.Backend.LastHeartbeat).Round(time.Second))
+	}
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/claraverse/mcp-client/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var stopTimeout time.Duration
+
+var StopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the background MCP client",
+	Long:  `Stops an MCP client started with 'mcp-client start --daemon' by signalling the PID recorded in the pidfile.`,
+	RunE:  runStop,
+}
+
+func init() {
+	StopCmd.Flags().DurationVar(&stopTimeout, "timeout", 10*time.Second, "How long to wait for the client to exit")
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	pid, err := daemon.Stop(stopTimeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Stopped MCP client (PID %d)\n", pid)
+	return nil
+}
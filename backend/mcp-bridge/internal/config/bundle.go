@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BundleVersion is the format version written to exported bundles
+const BundleVersion = 1
+
+// Bundle is a shareable set of server configs. It never contains the auth token,
+// user ID or backend URL. Env values are blanked unless allow-listed, and
+// credentials found in args and URLs are stripped.
+type Bundle struct {
+	Version int         `yaml:"version"`
+	Servers []MCPServer `yaml:"mcp_servers"`
+}
+
+// secretEnvMarkers flag env variable names whose values are not exported
+var secretEnvMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "API_KEY", "APIKEY", "ACCESS_KEY", "PRIVATE_KEY", "CREDENTIAL", "AUTH"}
+
+// IsSecretEnvName reports whether an env variable name looks like it holds a secret
+func IsSecretEnvName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewBundle copies servers into a bundle. Env values are blanked except for the
+// variables named in keepEnv, and never for names that look like secrets; the env
+// keys are kept so whoever imports the bundle knows which values to fill in.
+// Credentials in args and URLs are stripped; each removal is described in the
+// returned warnings.
+func NewBundle(servers []MCPServer, keepEnv []string) (*Bundle, []string) {
+	keep := make(map[string]bool, len(keepEnv))
+	for _, name := range keepEnv {
+		keep[name] = true
+	}
+
+	var warnings []string
+	bundle := &Bundle{Version: BundleVersion, Servers: make([]MCPServer, 0, len(servers))}
+	for _, server := range servers {
+		if env, ok := server.Config["env"].(map[string]interface{}); ok {
+			config := make(map[string]interface{}, len(server.Config))
+			for k, v := range server.Config {
+				config[k] = v
+			}
+			exported := make(map[string]interface{}, len(env))
+			for k, v := range env {
+				if !keep[k] || IsSecretEnvName(k) {
+					v = ""
+				}
+				exported[k] = v
+			}
+			config["env"] = exported
+			server.Config = config
+		}
+
+		if len(server.Args) > 0 {
+			args, removed := stripArgCredentials(server.Args)
+			server.Args = args
+			for _, what := range removed {
+				warnings = append(warnings, fmt.Sprintf("%s: removed %s from args", server.Name, what))
+			}
+		}
+		if stripped, removed := stripURLCredentials(server.URL); len(removed) > 0 {
+			server.URL = stripped
+			warnings = append(warnings, fmt.Sprintf("%s: removed %s from url", server.Name, strings.Join(removed, ", ")))
+		}
+		bundle.Servers = append(bundle.Servers, server)
+	}
+	return bundle, warnings
+}
+
+// stripArgCredentials blanks the values of secret-looking flags ("--token x",
+// "--api-key=x") and strips credentials from URL arguments. It returns the cleaned
+// args and what was removed.
+func stripArgCredentials(args []string) ([]string, []string) {
+	cleaned := make([]string, len(args))
+	copy(cleaned, args)
+
+	var removed []string
+	for i := 0; i < len(cleaned); i++ {
+		arg := cleaned[i]
+		if strings.HasPrefix(arg, "-") {
+			flag, value, hasValue := strings.Cut(arg, "=")
+			if !IsSecretEnvName(strings.ReplaceAll(strings.TrimLeft(flag, "-"), "-", "_")) {
+				continue
+			}
+			if hasValue && value != "" {
+				cleaned[i] = flag + "="
+				removed = append(removed, "the value of "+flag)
+			} else if !hasValue && i+1 < len(cleaned) && !strings.HasPrefix(cleaned[i+1], "-") {
+				cleaned[i+1] = ""
+				removed = append(removed, "the value of "+arg)
+				i++
+			}
+			continue
+		}
+		if stripped, what := stripURLCredentials(arg); len(what) > 0 {
+			cleaned[i] = stripped
+			removed = append(removed, what...)
+		}
+	}
+	return cleaned, removed
+}
+
+// stripURLCredentials removes the user info and the values of secret-looking query
+// parameters from raw if it is a URL, returning what was removed
+func stripURLCredentials(raw string) (string, []string) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return raw, nil
+	}
+
+	var removed []string
+	if u.User != nil {
+		u.User = nil
+		removed = append(removed, "the user credentials of "+u.Host)
+	}
+	query := u.Query()
+	for name := range query {
+		if IsSecretEnvName(name) || strings.EqualFold(name, "key") {
+			query.Set(name, "")
+			removed = append(removed, "the "+name+" parameter of "+u.Host)
+		}
+	}
+	if len(removed) == 0 {
+		return raw, nil
+	}
+	sort.Strings(removed)
+	u.RawQuery = query.Encode()
+	return u.String(), removed
+}
+
+// BlankEnv returns the server's env variables with empty values, sorted by name
+func (s MCPServer) BlankEnv() []string {
+	var names []string
+	for name, value := range serverEnv(s) {
+		if value == "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KeepLocalSecrets fills blank env values in imported from existing, so importing a
+// bundle over a configured server does not wipe the secrets it was exported without
+func KeepLocalSecrets(existing, imported MCPServer) MCPServer {
+	env, ok := imported.Config["env"].(map[string]interface{})
+	if !ok {
+		return imported
+	}
+	local := serverEnv(existing)
+
+	merged := make(map[string]interface{}, len(env))
+	for k, v := range env {
+		if fmt.Sprint(v) == "" && local[k] != "" {
+			v = local[k]
+		}
+		merged[k] = v
+	}
+	config := make(map[string]interface{}, len(imported.Config))
+	for k, v := range imported.Config {
+		config[k] = v
+	}
+	config["env"] = merged
+	imported.Config = config
+	return imported
+}
+
+// WriteBundle writes a bundle as YAML to path, readable only by the current user
+// since it may still hold allow-listed env values
+func WriteBundle(path string, bundle *Bundle) error {
+	data, err := yaml.Marshal(bundle)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// ReadBundle reads and validates a bundle written by WriteBundle
+func ReadBundle(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	var bundle Bundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if bundle.Version > BundleVersion {
+		return nil, fmt.Errorf("bundle version %d is newer than this client supports (%d)", bundle.Version, BundleVersion)
+	}
+
+	seen := make(map[string]bool, len(bundle.Servers))
+	for i, server := range bundle.Servers {
+		if server.Name == "" {
+			return nil, fmt.Errorf("server %d in bundle has no name", i+1)
+		}
+		if seen[server.Name] {
+			return nil, fmt.Errorf("server %s appears more than once in bundle", server.Name)
+		}
+		seen[server.Name] = true
+	}
+	return &bundle, nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ServerChange describes one field that differs between two server configs
+type ServerChange struct {
+	Field string
+	Old   string
+	New   string
+	// Behavioral is set for fields that change what process runs or how
+	// (path, command, args, env), so callers can highlight them
+	Behavioral bool
+}
+
+// DiffServers lists the fields that would change if old were replaced by updated.
+// Env variables are read from the server's config map ("env") and reported per key.
+func DiffServers(old, updated MCPServer) []ServerChange {
+	var changes []ServerChange
+	add := func(field, oldVal, newVal string, behavioral bool) {
+		if oldVal != newVal {
+			changes = append(changes, ServerChange{Field: field, Old: oldVal, New: newVal, Behavioral: behavioral})
+		}
+	}
+
+	add("path", old.Path, updated.Path, true)
+	add("command", old.Command, updated.Command, true)
+	add("args", strings.Join(old.Args, " "), strings.Join(updated.Args, " "), true)
+	add("type", old.Type, updated.Type, false)
+	add("url", old.URL, updated.URL, false)
+	add("enabled", fmt.Sprint(old.Enabled), fmt.Sprint(updated.Enabled), false)
+	add("description", old.Description, updated.Description, false)
+	add("retry_tools", strings.Join(old.RetryTools, ","), strings.Join(updated.RetryTools, ","), false)
+	add("stream_tools", strings.Join(old.StreamTools, ","), strings.Join(updated.StreamTools, ","), false)
+	add("category", old.Category, updated.Category, false)
+	add("tags", strings.Join(old.Tags, ","), strings.Join(updated.Tags, ","), false)
+	add("max_result_bytes", formatLimits(old.MaxResultBytes), formatLimits(updated.MaxResultBytes), false)
+
+	oldEnv, newEnv := serverEnv(old), serverEnv(updated)
+	for _, key := range sortedKeys(oldEnv, newEnv) {
+		add("env."+key, oldEnv[key], newEnv[key], true)
+	}
+
+	oldConfig, newConfig := serverConfigValues(old), serverConfigValues(updated)
+	for _, key := range sortedKeys(oldConfig, newConfig) {
+		add("config."+key, oldConfig[key], newConfig[key], false)
+	}
+
+	return changes
+}
+
+// serverEnv returns the env map stored under the server's "env" config key
+func serverEnv(s MCPServer) map[string]string {
+	env := make(map[string]string)
+	if raw, ok := s.Config["env"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			env[k] = fmt.Sprint(v)
+		}
+	}
+	return env
+}
+
+// serverConfigValues returns the remaining config entries as strings
+func serverConfigValues(s MCPServer) map[string]string {
+	values := make(map[string]string)
+	for k, v := range s.Config {
+		if k != "env" {
+			values[k] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
+
+// formatLimits renders per-tool limits as "tool=bytes" pairs sorted by tool name
+func formatLimits(limits map[string]int) string {
+	pairs := make([]string, 0, len(limits))
+	for tool, limit := range limits {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", tool, limit))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func sortedKeys(a, b map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]string{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
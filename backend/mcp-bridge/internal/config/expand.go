@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"reflect"
+)
+
+// Environment references in server configs are expanded when the config is loaded,
+// so mcp-config.yaml can stay free of environment-specific values and secrets.
+//
+// Expanded fields: path, command, args, url and every string value in config
+// (including nested maps and lists, such as config.env). Name, type, description,
+// category and tags are used as written.
+//
+// ${VAR} and $VAR are replaced with the variable's value, or "" when it is unset,
+// and $$ stands for a literal $.
+
+// ExpandEnv expands ${VAR} and $VAR references in s from the process environment
+func ExpandEnv(s string) string {
+	return os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		return os.Getenv(name)
+	})
+}
+
+// ExpandServer returns a copy of s with environment references expanded in its
+// path, command, args, url and config values
+func ExpandServer(s MCPServer) MCPServer {
+	s.Path = ExpandEnv(s.Path)
+	s.Command = ExpandEnv(s.Command)
+	s.URL = ExpandEnv(s.URL)
+	if s.Args != nil {
+		args := make([]string, len(s.Args))
+		for i, arg := range s.Args {
+			args[i] = ExpandEnv(arg)
+		}
+		s.Args = args
+	}
+	if s.Config != nil {
+		s.Config = expandValue(s.Config).(map[string]interface{})
+	}
+	return s
+}
+
+// expandValue copies v, expanding the strings in it
+func expandValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return ExpandEnv(v)
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for k, nested := range v {
+			expanded[k] = expandValue(nested)
+		}
+		return expanded
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, nested := range v {
+			expanded[i] = expandValue(nested)
+		}
+		return expanded
+	}
+	return v
+}
+
+// SourceServers returns the servers as written in the config file: servers left
+// unchanged since Load keep their ${VAR} references, so saving or exporting the
+// config never writes the expanded values (which may be secrets) back out
+func (c *Config) SourceServers() []MCPServer {
+	sources := make(map[string]MCPServer, len(c.source))
+	for _, server := range c.source {
+		sources[server.Name] = server
+	}
+
+	servers := make([]MCPServer, len(c.MCPServers))
+	for i, server := range c.MCPServers {
+		if source, ok := sources[server.Name]; ok && reflect.DeepEqual(ExpandServer(source), server) {
+			server = source
+		}
+		servers[i] = server
+	}
+	return servers
+}
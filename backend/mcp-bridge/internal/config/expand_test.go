@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSourceServers(t *testing.T) {
+	t.Setenv("TEST_MCP_TOKEN", "s3cret")
+	t.Setenv("TEST_MCP_HOST", "db.internal")
+
+	source := []MCPServer{
+		{Name: "db", Command: "npx", Args: []string{"server-postgres", "postgres://${TEST_MCP_HOST}/app"}},
+		{Name: "gh", Command: "npx", Config: map[string]interface{}{
+			"env": map[string]interface{}{"GITHUB_TOKEN": "${TEST_MCP_TOKEN}"},
+		}},
+	}
+	expanded := func() []MCPServer {
+		servers := make([]MCPServer, len(source))
+		for i, server := range source {
+			servers[i] = ExpandServer(server)
+		}
+		return servers
+	}
+
+	cases := []struct {
+		name   string
+		change func(servers []MCPServer) []MCPServer
+		want   func(servers []MCPServer) []MCPServer
+	}{
+		{
+			name:   "unchanged servers keep their references",
+			change: func(servers []MCPServer) []MCPServer { return servers },
+			want:   func([]MCPServer) []MCPServer { return source },
+		},
+		{
+			name: "edited server is saved as edited",
+			change: func(servers []MCPServer) []MCPServer {
+				servers[0].Args = []string{"server-postgres", "postgres://db.example.com/app"}
+				return servers
+			},
+			want: func(servers []MCPServer) []MCPServer { return []MCPServer{servers[0], source[1]} },
+		},
+		{
+			name: "added server is saved as added",
+			change: func(servers []MCPServer) []MCPServer {
+				return append(servers, MCPServer{Name: "fs", Command: "npx"})
+			},
+			want: func(servers []MCPServer) []MCPServer { return append(append([]MCPServer(nil), source...), servers[2]) },
+		},
+		{
+			name: "removed server is not saved",
+			change: func(servers []MCPServer) []MCPServer {
+				return servers[1:]
+			},
+			want: func([]MCPServer) []MCPServer { return source[1:] },
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{source: source}
+			cfg.MCPServers = tc.change(expanded())
+			if got, want := cfg.SourceServers(), tc.want(cfg.MCPServers); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %+v, got %+v", want, got)
+			}
+		})
+	}
+}
+
+// TestSaveKeepsEnvReferences tests that loading and saving a config does not write
+// the expanded values back to the file
+func TestSaveKeepsEnvReferences(t *testing.T) {
+	path := useTempConfig(t)
+	t.Setenv("TEST_MCP_TOKEN", "s3cret")
+	if err := Save(&Config{
+		BackendURL: DefaultBackendURL,
+		MCPServers: []MCPServer{{Name: "remote", Type: "sse", URL: "https://example.com/sse?token=${TEST_MCP_TOKEN}"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.MCPServers[0].URL; got != "https://example.com/sse?token=s3cret" {
+		t.Fatalf("expected the loaded url to be expanded, got %q", got)
+	}
+	cfg.AuthToken = "token"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") || !strings.Contains(string(data), "${TEST_MCP_TOKEN}") {
+		t.Errorf("expected the saved config to keep the reference, got:\n%s", data)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultRedaction replaces redacted matches when a hook sets no replacement
+const DefaultRedaction = "[REDACTED]"
+
+// ResultHook transforms tool output on this machine before it is sent to the
+// backend (results, streamed partial results, progress messages and error text),
+// e.g. to redact personal data from a tool's output:
+//
+//	result_hooks:
+//	  - tools: [read_file]
+//	    redact: ['[\w.+-]+@[\w-]+\.[\w.]+']
+//	    replacement: '[EMAIL]'
+type ResultHook struct {
+	// Tools the hook applies to; all tools when empty or "*"
+	Tools []string `yaml:"tools,omitempty" mapstructure:"tools"`
+	// Redact lists regular expressions whose matches are replaced with Replacement
+	// (default "[REDACTED]"). Replacement may use $1-style group references.
+	Redact      []string `yaml:"redact,omitempty" mapstructure:"redact"`
+	Replacement string   `yaml:"replacement,omitempty" mapstructure:"replacement"`
+	// TrimSpace drops leading and trailing whitespace from the result
+	TrimSpace bool `yaml:"trim_space,omitempty" mapstructure:"trim_space"`
+}
+
+// AppliesToAll reports whether the hook runs on every tool's results
+func (h ResultHook) AppliesToAll() bool {
+	if len(h.Tools) == 0 {
+		return true
+	}
+	for _, name := range h.Tools {
+		if name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile validates the hook and returns the function applying it to a result
+func (h ResultHook) Compile() (func(string) string, error) {
+	patterns := make([]*regexp.Regexp, 0, len(h.Redact))
+	for _, expr := range h.Redact {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, re)
+	}
+	if len(patterns) == 0 && !h.TrimSpace {
+		return nil, fmt.Errorf("hook has nothing to do (set redact or trim_space)")
+	}
+
+	replacement := h.Replacement
+	if replacement == "" {
+		replacement = DefaultRedaction
+	}
+	trim := h.TrimSpace
+	return func(result string) string {
+		for _, re := range patterns {
+			result = re.ReplaceAllString(result, replacement)
+		}
+		if trim {
+			result = strings.TrimSpace(result)
+		}
+		return result
+	}, nil
+}
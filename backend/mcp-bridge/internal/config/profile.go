@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile names the backend settings at the top level of the config file,
+// used when no other profile is active
+const DefaultProfile = "default"
+
+// EnvProfile selects the profile when --profile is not given
+const EnvProfile = "CLARAVERSE_PROFILE"
+
+// Profile holds the settings for one backend environment (e.g. local, staging, prod),
+// so switching between them does not overwrite another environment's token
+type Profile struct {
+	BackendURL      string      `yaml:"backend_url" mapstructure:"backend_url"`
+	AuthToken       string      `yaml:"auth_token" mapstructure:"auth_token"`
+	UserID          string      `yaml:"user_id" mapstructure:"user_id"`
+	SupabaseURL     string      `yaml:"supabase_url,omitempty" mapstructure:"supabase_url"`
+	SupabaseAnonKey string      `yaml:"supabase_anon_key,omitempty" mapstructure:"supabase_anon_key"`
+	MCPServers      []MCPServer `yaml:"mcp_servers" mapstructure:"mcp_servers"`
+}
+
+// selectedProfile is set by the --profile flag and overrides active_profile
+var selectedProfile string
+
+// SelectProfile makes Load use the named profile instead of the active one
+func SelectProfile(name string) {
+	selectedProfile = strings.TrimSpace(name)
+}
+
+// ProfileName returns the profile this config was loaded for
+func (c *Config) ProfileName() string {
+	if c.profile == "" {
+		return DefaultProfile
+	}
+	return c.profile
+}
+
+// ProfileNames lists the configured profiles, the default one first
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+// HasProfile reports whether a profile is configured
+func (c *Config) HasProfile(name string) bool {
+	_, ok := c.Profiles[name]
+	return name == DefaultProfile || ok
+}
+
+// GetProfile returns a profile's settings, including the loaded one's unsaved changes
+func (c *Config) GetProfile(name string) (Profile, bool) {
+	switch {
+	case name == c.ProfileName():
+		return c.currentProfile(), true
+	case name == DefaultProfile:
+		profile := c.defaults
+		profile.AuthToken = c.storedToken(name, profile.AuthToken)
+		return profile, true
+	}
+	p, ok := c.Profiles[name]
+	if !ok || p == nil {
+		return Profile{}, ok
+	}
+	profile := *p
+	profile.AuthToken = c.storedToken(name, profile.AuthToken)
+	return profile, true
+}
+
+// profileToLoad returns the profile Load should use: --profile, then
+// CLARAVERSE_PROFILE, then active_profile from the file. "" means the default.
+func profileToLoad(activeProfile string) string {
+	name := firstNonEmpty(selectedProfile, strings.TrimSpace(os.Getenv(EnvProfile)), activeProfile)
+	if name == DefaultProfile {
+		return ""
+	}
+	return name
+}
+
+// useProfile moves the named profile's settings to the top level, where commands
+// read them, keeping the default profile's settings aside for Save. A profile that
+// does not exist yet starts out empty and is created when the config is saved.
+func (c *Config) useProfile(name string) {
+	c.profile = name
+	if name == "" {
+		return
+	}
+	c.defaults = c.currentProfile()
+
+	profile := Profile{BackendURL: DefaultBackendURL}
+	if p, ok := c.Profiles[name]; ok && p != nil {
+		profile = *p
+	}
+	c.setCurrentProfile(profile)
+}
+
+// forSave returns the config as it is written to the file: the loaded profile's
+// settings back in its entry and the default profile's at the top level
+func (c *Config) forSave() *Config {
+	out := *c
+	out.MCPServers = c.SourceServers()
+	if c.profile == "" {
+		return &out
+	}
+
+	profiles := make(map[string]*Profile, len(c.Profiles)+1)
+	for name, p := range c.Profiles {
+		profiles[name] = p
+	}
+	current := out.currentProfile()
+	profiles[c.profile] = &current
+	out.Profiles = profiles
+	out.setCurrentProfile(c.defaults)
+	return &out
+}
+
+func (c *Config) currentProfile() Profile {
+	return Profile{
+		BackendURL:      c.BackendURL,
+		AuthToken:       c.AuthToken,
+		UserID:          c.UserID,
+		SupabaseURL:     c.SupabaseURL,
+		SupabaseAnonKey: c.SupabaseAnonKey,
+		MCPServers:      c.MCPServers,
+	}
+}
+
+func (c *Config) setCurrentProfile(p Profile) {
+	c.BackendURL = p.BackendURL
+	c.AuthToken = p.AuthToken
+	c.UserID = p.UserID
+	c.SupabaseURL = p.SupabaseURL
+	c.SupabaseAnonKey = p.SupabaseAnonKey
+	c.MCPServers = p.MCPServers
+}
+
+// UseProfile makes name the active profile for later commands. It fails for
+// profiles that are not configured.
+func (c *Config) UseProfile(name string) error {
+	if !c.HasProfile(name) {
+		return fmt.Errorf("profile %q does not exist (log in with 'mcp-client login --profile %s' to create it)", name, name)
+	}
+	if name == DefaultProfile {
+		name = ""
+	}
+	c.ActiveProfile = name
+	return nil
+}
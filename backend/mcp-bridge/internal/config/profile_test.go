@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// useTempConfig points Load and Save at a config file in a temporary directory and
+// clears the profile selection
+func useTempConfig(t *testing.T) string {
+	t.Helper()
+	oldDir, oldPath := configDir, configPath
+	configDir = t.TempDir()
+	configPath = filepath.Join(configDir, "mcp-config.yaml")
+	t.Setenv(EnvProfile, "")
+	SelectProfile("")
+	t.Cleanup(func() {
+		configDir, configPath = oldDir, oldPath
+		SelectProfile("")
+	})
+	return configPath
+}
+
+// readSavedConfig parses the config file as Save wrote it
+func readSavedConfig(t *testing.T, path string) Config {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	return saved
+}
+
+func TestProfileToLoad(t *testing.T) {
+	cases := []struct {
+		name     string
+		selected string
+		env      string
+		active   string
+		want     string
+	}{
+		{"nothing selected", "", "", "", ""},
+		{"active profile", "", "", "staging", "staging"},
+		{"environment overrides active profile", "", "prod", "staging", "prod"},
+		{"flag overrides environment", "local", "prod", "staging", "local"},
+		{"flag selects the default profile", "default", "prod", "staging", ""},
+		{"active default profile", "", "", "default", ""},
+		{"whitespace ignored", " local ", " ", "staging", "local"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			useTempConfig(t)
+			t.Setenv(EnvProfile, tc.env)
+			SelectProfile(tc.selected)
+			if got := profileToLoad(tc.active); got != tc.want {
+				t.Errorf("expected profile %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	cfg := &Config{Profiles: map[string]*Profile{"staging": {BackendURL: "wss://staging.example.com/mcp/connect"}}}
+
+	cases := []struct {
+		name       string
+		profile    string
+		wantErr    bool
+		wantActive string
+	}{
+		{"configured profile", "staging", false, "staging"},
+		{"default profile", DefaultProfile, false, ""},
+		{"unknown profile", "prod", true, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg.ActiveProfile = ""
+			err := cfg.UseProfile(tc.profile)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if cfg.ActiveProfile != tc.wantActive {
+				t.Errorf("expected active profile %q, got %q", tc.wantActive, cfg.ActiveProfile)
+			}
+		})
+	}
+}
+
+// TestProfileSwitching tests that changes made while a profile is loaded are saved
+// to that profile and leave the default profile's token alone
+func TestProfileSwitching(t *testing.T) {
+	path := useTempConfig(t)
+	if err := Save(&Config{
+		BackendURL: "ws://localhost:3001/mcp/connect",
+		AuthToken:  "default-token",
+		UserID:     "default-user",
+		Profiles: map[string]*Profile{
+			"staging": {BackendURL: "wss://staging.example.com/mcp/connect", AuthToken: "staging-token", UserID: "staging-user"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	SelectProfile("staging")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ProfileName() != "staging" || cfg.AuthToken != "staging-token" || cfg.BackendURL != "wss://staging.example.com/mcp/connect" {
+		t.Fatalf("expected the staging profile to be loaded, got %s with %q at %s", cfg.ProfileName(), cfg.AuthToken, cfg.BackendURL)
+	}
+	if defaults, _ := cfg.GetProfile(DefaultProfile); defaults.AuthToken != "default-token" {
+		t.Errorf("expected the default profile's token to be kept aside, got %q", defaults.AuthToken)
+	}
+
+	cfg.AuthToken = "new-staging-token"
+	cfg.MCPServers = append(cfg.MCPServers, MCPServer{Name: "fs", Command: "npx", Type: "stdio", Enabled: true})
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := readSavedConfig(t, path)
+	if saved.AuthToken != "default-token" || saved.UserID != "default-user" || len(saved.MCPServers) != 0 {
+		t.Errorf("expected the default profile to be unchanged, got token %q, user %q, %d servers", saved.AuthToken, saved.UserID, len(saved.MCPServers))
+	}
+	staging := saved.Profiles["staging"]
+	if staging == nil || staging.AuthToken != "new-staging-token" || len(staging.MCPServers) != 1 {
+		t.Fatalf("expected the changes to be saved to the staging profile, got %+v", staging)
+	}
+
+	SelectProfile("")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ProfileName() != DefaultProfile || cfg.AuthToken != "default-token" {
+		t.Errorf("expected the default profile to load, got %s with %q", cfg.ProfileName(), cfg.AuthToken)
+	}
+}
+
+// TestNewProfileCreatedOnSave tests that selecting a profile that does not exist
+// starts it out empty and adds it to the file when the config is saved
+func TestNewProfileCreatedOnSave(t *testing.T) {
+	path := useTempConfig(t)
+	if err := Save(&Config{BackendURL: DefaultBackendURL, AuthToken: "default-token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvProfile, "local")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ProfileName() != "local" || cfg.AuthToken != "" || cfg.BackendURL != DefaultBackendURL {
+		t.Fatalf("expected an empty local profile, got %s with %q at %s", cfg.ProfileName(), cfg.AuthToken, cfg.BackendURL)
+	}
+
+	cfg.AuthToken = "local-token"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	saved := readSavedConfig(t, path)
+	if saved.AuthToken != "default-token" {
+		t.Errorf("expected the default token to be kept, got %q", saved.AuthToken)
+	}
+	if local := saved.Profiles["local"]; local == nil || local.AuthToken != "local-token" {
+		t.Errorf("expected the local profile to be saved, got %+v", local)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/claraverse/mcp-client/internal/keyring"
+)
+
+// Where auth tokens are kept (token_storage). With keychain storage the token is
+// stored in the OS keychain, one entry per profile, and left out of the config file;
+// if no keychain is available it falls back to the file with a warning.
+const (
+	TokenStorageFile     = "file"
+	TokenStorageKeychain = "keychain"
+)
+
+// keychainService names the client's entries in the OS keychain
+const keychainService = "claraverse-mcp-client"
+
+// Keychain accounts are "<profile>/<token kind>"
+const authTokenKind = "auth_token"
+
+// keychainGetSecret and keychainSetSecret reach the OS keychain; tests replace them
+var (
+	keychainGetSecret = keyring.Get
+	keychainSetSecret = keyring.Set
+)
+
+// ParseTokenStorage validates a token_storage value; "" means the file
+func ParseTokenStorage(value string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(value)); v {
+	case "", TokenStorageFile:
+		return TokenStorageFile, nil
+	case TokenStorageKeychain:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid token storage %q: must be %q or %q", value, TokenStorageFile, TokenStorageKeychain)
+	}
+}
+
+// UsesKeychain reports whether tokens should be kept in the OS keychain
+func (c *Config) UsesKeychain() bool {
+	return strings.EqualFold(strings.TrimSpace(c.TokenStorage), TokenStorageKeychain)
+}
+
+// TokenLocation describes where the loaded profile's token is kept, for status output
+func (c *Config) TokenLocation() string {
+	if token := strings.TrimSpace(os.Getenv(EnvAuthToken)); token != "" && token == c.AuthToken {
+		return EnvAuthToken
+	}
+	if c.UsesKeychain() && c.keychainToken != "" && c.keychainToken == c.AuthToken {
+		return "OS keychain"
+	}
+	return "config file"
+}
+
+func keychainAccount(profile, kind string) string {
+	return profile + "/" + kind
+}
+
+// loadKeychainToken fills in the loaded profile's token from the keychain. A token
+// still in the file (written by a fallback) takes precedence.
+func (c *Config) loadKeychainToken() {
+	if !c.UsesKeychain() || c.AuthToken != "" {
+		return
+	}
+	token, err := keychainGet(c.ProfileName())
+	if err != nil {
+		return
+	}
+	c.AuthToken = token
+	c.keychainToken = token
+}
+
+// storedToken returns a profile's token from the file or, when it is not there,
+// the keychain
+func (c *Config) storedToken(profile, fileToken string) string {
+	if fileToken != "" || !c.UsesKeychain() {
+		return fileToken
+	}
+	token, _ := keychainGet(profile)
+	return token
+}
+
+// keychainGet reads a profile's auth token, warning about failures other than the
+// token not being there
+func keychainGet(profile string) (string, error) {
+	token, err := keychainGetSecret(keychainService, keychainAccount(profile, authTokenKind))
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not read the auth token from the OS keychain: %v\n", err)
+	}
+	return token, err
+}
+
+// storeKeychainToken moves the loaded profile's token to the keychain before the
+// config is written, reporting whether the file can leave it out. When the keychain
+// fails the token stays in the file and a warning is printed.
+func (c *Config) storeKeychainToken() bool {
+	if !c.UsesKeychain() {
+		return false
+	}
+	if c.AuthToken == "" || c.AuthToken == c.keychainToken {
+		return true
+	}
+	if err := keychainSetSecret(keychainService, keychainAccount(c.ProfileName(), authTokenKind), c.AuthToken); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not store the auth token in the OS keychain (%v); saving it to %s instead\n", err, configPath)
+		return false
+	}
+	c.keychainToken = c.AuthToken
+	return true
+}
+
+// ForgetKeychainToken removes the loaded profile's token from the keychain, e.g.
+// after switching back to file storage
+func (c *Config) ForgetKeychainToken() error {
+	err := keyring.Delete(keychainService, keychainAccount(c.ProfileName(), authTokenKind))
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) && !errors.Is(err, keyring.ErrUnavailable) {
+		return err
+	}
+	c.keychainToken = ""
+	return nil
+}
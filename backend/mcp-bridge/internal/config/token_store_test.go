@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/claraverse/mcp-client/internal/keyring"
+)
+
+// useFakeKeychain replaces the OS keychain with an in-memory one. When err is set,
+// every keychain call fails with it.
+func useFakeKeychain(t *testing.T, err error) map[string]string {
+	t.Helper()
+	secrets := make(map[string]string)
+	oldGet, oldSet := keychainGetSecret, keychainSetSecret
+	keychainGetSecret = func(service, account string) (string, error) {
+		if err != nil {
+			return "", err
+		}
+		secret, ok := secrets[service+":"+account]
+		if !ok {
+			return "", keyring.ErrNotFound
+		}
+		return secret, nil
+	}
+	keychainSetSecret = func(service, account, secret string) error {
+		if err != nil {
+			return err
+		}
+		secrets[service+":"+account] = secret
+		return nil
+	}
+	t.Cleanup(func() {
+		keychainGetSecret, keychainSetSecret = oldGet, oldSet
+	})
+	return secrets
+}
+
+func TestParseTokenStorage(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", TokenStorageFile, false},
+		{"file", TokenStorageFile, false},
+		{" Keychain ", TokenStorageKeychain, false},
+		{"vault", "", true},
+	}
+	for _, tc := range cases {
+		got, err := ParseTokenStorage(tc.value)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("ParseTokenStorage(%q) = %q, %v; expected %q, error %v", tc.value, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// TestKeychainTokenStorage tests where Save puts the token and where Load finds it,
+// including the fallback to the file when the keychain is not available
+func TestKeychainTokenStorage(t *testing.T) {
+	cases := []struct {
+		name         string
+		storage      string
+		keychainErr  error
+		wantFile     string
+		wantKeychain string
+		wantLocation string
+	}{
+		{"file storage", TokenStorageFile, nil, "token", "", "config file"},
+		{"keychain storage", TokenStorageKeychain, nil, "", "token", "OS keychain"},
+		{"no keychain available", TokenStorageKeychain, keyring.ErrUnavailable, "token", "", "config file"},
+		{"keychain error", TokenStorageKeychain, errors.New("locked"), "token", "", "config file"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := useTempConfig(t)
+			t.Setenv(EnvAuthToken, "")
+			secrets := useFakeKeychain(t, tc.keychainErr)
+
+			if err := Save(&Config{BackendURL: DefaultBackendURL, AuthToken: "token", TokenStorage: tc.storage}); err != nil {
+				t.Fatal(err)
+			}
+			if saved := readSavedConfig(t, path); saved.AuthToken != tc.wantFile {
+				t.Errorf("expected %q in the config file, got %q", tc.wantFile, saved.AuthToken)
+			}
+			if got := secrets[keychainService+":"+keychainAccount(DefaultProfile, authTokenKind)]; got != tc.wantKeychain {
+				t.Errorf("expected %q in the keychain, got %q", tc.wantKeychain, got)
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.AuthToken != "token" {
+				t.Errorf("expected the token to load, got %q", cfg.AuthToken)
+			}
+			if got := cfg.TokenLocation(); got != tc.wantLocation {
+				t.Errorf("expected the token location %q, got %q", tc.wantLocation, got)
+			}
+		})
+	}
+}
+
+// TestKeychainTokenPerProfile tests that the tokens of profiles other than the loaded
+// one are read from their own keychain entries, and that a token left in the file by
+// a fallback takes precedence
+func TestKeychainTokenPerProfile(t *testing.T) {
+	useTempConfig(t)
+	secrets := useFakeKeychain(t, nil)
+	secrets[keychainService+":"+keychainAccount(DefaultProfile, authTokenKind)] = "default-keychain-token"
+	secrets[keychainService+":"+keychainAccount("staging", authTokenKind)] = "staging-keychain-token"
+
+	cfg := &Config{
+		TokenStorage: TokenStorageKeychain,
+		Profiles: map[string]*Profile{
+			"staging": {},
+			"prod":    {AuthToken: "prod-file-token"},
+			"local":   {},
+		},
+	}
+	cfg.useProfile("local")
+
+	cases := []struct {
+		profile string
+		want    string
+	}{
+		{DefaultProfile, "default-keychain-token"},
+		{"staging", "staging-keychain-token"},
+		{"prod", "prod-file-token"},
+		{"local", ""},
+	}
+	for _, tc := range cases {
+		if profile, _ := cfg.GetProfile(tc.profile); profile.AuthToken != tc.want {
+			t.Errorf("expected the %s token %q, got %q", tc.profile, tc.want, profile.AuthToken)
+		}
+	}
+}
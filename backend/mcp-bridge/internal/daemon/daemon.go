@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/claraverse/mcp-client/internal/config"
+)
+
+// EnvDaemonChild marks a process as the forked background instance
+const EnvDaemonChild = "CLARAVERSE_MCP_DAEMON"
+
+// GetPIDFilePath returns the path of the daemon pidfile
+func GetPIDFilePath() string {
+	return filepath.Join(config.GetConfigDir(), "mcp-client.pid")
+}
+
+// GetLogFilePath returns the path of the daemon log file
+func GetLogFilePath() string {
+	return filepath.Join(config.GetConfigDir(), "mcp-client.log")
+}
+
+// IsDaemonChild reports whether the current process was started by Start
+func IsDaemonChild() bool {
+	return os.Getenv(EnvDaemonChild) == "1"
+}
+
+// Start re-executes the current binary in the background with the given args,
+// detached from the terminal and with stdout/stderr redirected to the log file.
+// It returns the PID of the background process.
+func Start(args []string) (int, error) {
+	if pid, running := Status(); running {
+		return 0, fmt.Errorf("mcp-client is already running (PID %d)", pid)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	if err := os.MkdirAll(config.GetConfigDir(), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	logFile, err := os.OpenFile(GetLogFilePath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stdin = nil
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = append(os.Environ(), EnvDaemonChild+"=1")
+	cmd.SysProcAttr = detachedProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start background process: %w", err)
+	}
+
+	pid := cmd.Process.Pid
+	if err := WritePID(pid); err != nil {
+		cmd.Process.Kill()
+		return 0, err
+	}
+
+	// Don't wait on the child - it outlives this process
+	cmd.Process.Release()
+	return pid, nil
+}
+
+// Stop signals the running daemon to shut down and waits up to timeout for it to exit
+func Stop(timeout time.Duration) (int, error) {
+	pid, err := ReadPID()
+	if err != nil {
+		return 0, err
+	}
+
+	if !processAlive(pid) {
+		RemovePID()
+		return pid, fmt.Errorf("mcp-client is not running (removed stale pidfile for PID %d)", pid)
+	}
+
+	if err := terminateProcess(pid); err != nil {
+		return pid, fmt.Errorf("failed to signal PID %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if !processAlive(pid) {
+			RemovePID()
+			return pid, nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	return pid, fmt.Errorf("PID %d did not exit within %s", pid, timeout)
+}
+
+// Status returns the daemon PID and whether it is currently running
+func Status() (int, bool) {
+	pid, err := ReadPID()
+	if err != nil {
+		return 0, false
+	}
+	return pid, processAlive(pid)
+}
+
+// WritePID writes pid to the pidfile
+func WritePID(pid int) error {
+	if err := os.WriteFile(GetPIDFilePath(), []byte(strconv.Itoa(pid)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write pidfile: %w", err)
+	}
+	return nil
+}
+
+// ReadPID reads the PID from the pidfile
+func ReadPID() (int, error) {
+	data, err := os.ReadFile(GetPIDFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("mcp-client is not running (no pidfile at %s)", GetPIDFilePath())
+		}
+		return 0, fmt.Errorf("failed to read pidfile: %w", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pidfile %s", GetPIDFilePath())
+	}
+	return pid, nil
+}
+
+// RemovePID deletes the pidfile
+func RemovePID() {
+	os.Remove(GetPIDFilePath())
+}
+
+// ReleasePID deletes the pidfile only if it records the current process
+func ReleasePID() {
+	if pid, err := ReadPID(); err == nil && pid == os.Getpid() {
+		RemovePID()
+	}
+}
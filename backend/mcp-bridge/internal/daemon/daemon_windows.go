@@ -0,0 +1,37 @@
+//go:build windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const createNewProcessGroup = 0x00000200
+const detachedProcess = 0x00000008
+
+// detachedProcAttr starts the child without a console so it survives the terminal closing
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
+
+// processAlive checks whether pid refers to a running process
+func processAlive(pid int) bool {
+	out, err := exec.Command("tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/NH").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), strconv.Itoa(pid))
+}
+
+// terminateProcess stops the process (Windows has no SIGTERM for detached processes)
+func terminateProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
@@ -0,0 +1,100 @@
+// Package health serves the client's liveness over a small local HTTP endpoint, so
+// supervisors and 'mcp-client status' can check a background client without
+// reading its logs.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/claraverse/mcp-client/internal/bridge"
+)
+
+// Path is the endpoint's URL path
+const Path = "/health"
+
+// Overall states reported in Status.Status
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // Running but not connected to the backend
+)
+
+// Status is the body of a health response. The response code is 200 when the
+// client is connected to the backend and 503 otherwise.
+type Status struct {
+	Status         string        `json:"status"`
+	PID            int           `json:"pid"`
+	StartedAt      time.Time     `json:"started_at"`
+	UptimeSeconds  int64         `json:"uptime_seconds"`
+	Backend        bridge.Health `json:"backend"`
+	ServersRunning int           `json:"servers_running"`
+	ToolCount      int           `json:"tool_count"`
+}
+
+// Server serves the health endpoint
+type Server struct {
+	server *http.Server
+}
+
+// Start listens on addr and serves the status returned by report
+func Start(addr string, report func() Status) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(Path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		status := report()
+		w.Header().Set("Content-Type", "application/json")
+		if status.Status != StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+
+	s := &Server{server: &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("❌ Health endpoint stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Close stops the endpoint
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// Fetch queries a running client's health endpoint at addr. A degraded client
+// returns its status without an error.
+func Fetch(addr string, timeout time.Duration) (*Status, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get("http://" + addr + Path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("invalid health response: %w", err)
+	}
+	return &status, nil
+}
@@ -0,0 +1,29 @@
+// Package keyring stores secrets in the operating system's credential store: the
+// macOS Keychain, Windows Credential Manager, or the Secret Service (libsecret) on
+// Linux. Secrets are addressed by service and account, like the stores themselves.
+package keyring
+
+import "errors"
+
+var (
+	// ErrNotFound is returned by Get and Delete when no secret is stored
+	ErrNotFound = errors.New("secret not found in keychain")
+	// ErrUnavailable is returned when this machine has no usable credential store
+	// (e.g. a headless Linux box without secret-tool or a D-Bus session)
+	ErrUnavailable = errors.New("no OS keychain available")
+)
+
+// Set stores secret under service and account, replacing any existing one
+func Set(service, account, secret string) error {
+	return set(service, account, secret)
+}
+
+// Get returns the secret stored under service and account
+func Get(service, account string) (string, error) {
+	return get(service, account)
+}
+
+// Delete removes the secret stored under service and account
+func Delete(service, account string) error {
+	return del(service, account)
+}
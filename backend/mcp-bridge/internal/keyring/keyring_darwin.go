@@ -0,0 +1,58 @@
+//go:build darwin
+
+package keyring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const securityCLI = "/usr/bin/security"
+
+// errItemNotFound is the exit status of 'security' when the item does not exist
+const errItemNotFound = 44
+
+// set goes through 'security -i' so the secret is written to stdin (hex encoded)
+// rather than appearing in the process list
+func set(service, account, secret string) error {
+	cmd := exec.Command(securityCLI, "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		strconv.Quote(service), strconv.Quote(account), hex.EncodeToString([]byte(secret))))
+	return run(cmd)
+}
+
+func get(service, account string) (string, error) {
+	cmd := exec.Command(securityCLI, "find-generic-password", "-s", service, "-a", account, "-w")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := run(cmd); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(stdout.String(), "\n"), nil
+}
+
+func del(service, account string) error {
+	return run(exec.Command(securityCLI, "delete-generic-password", "-s", service, "-a", account))
+}
+
+// run runs a 'security' command, mapping its failures to the package errors
+func run(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, exec.ErrNotFound):
+		return ErrUnavailable
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound:
+		return ErrNotFound
+	}
+	return fmt.Errorf("keychain: %v: %s", err, strings.TrimSpace(stderr.String()))
+}
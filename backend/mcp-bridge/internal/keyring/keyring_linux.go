@@ -0,0 +1,53 @@
+//go:build linux
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// set goes through secret-tool, which reads the secret from stdin so it never
+// appears in the process list
+func set(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label="+service+" ("+account+")",
+		"service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	_, err := run(cmd)
+	return err
+}
+
+func get(service, account string) (string, error) {
+	out, err := run(exec.Command("secret-tool", "lookup", "service", service, "account", account))
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func del(service, account string) error {
+	_, err := run(exec.Command("secret-tool", "clear", "service", service, "account", account))
+	return err
+}
+
+// run runs a secret-tool command, mapping its failures to the package errors.
+// secret-tool exits 1 without output when there is no matching secret.
+func run(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return stdout.String(), nil
+	case errors.Is(err, exec.ErrNotFound):
+		return "", ErrUnavailable
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stdout.Len() == 0 && stderr.Len() == 0:
+		return "", ErrNotFound
+	}
+	return "", fmt.Errorf("secret service: %v: %s", err, strings.TrimSpace(stderr.String()))
+}
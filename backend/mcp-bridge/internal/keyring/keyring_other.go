@@ -0,0 +1,9 @@
+//go:build !darwin && !linux && !windows
+
+package keyring
+
+func set(service, account, secret string) error { return ErrUnavailable }
+
+func get(service, account string) (string, error) { return "", ErrUnavailable }
+
+func del(service, account string) error { return ErrUnavailable }
@@ -0,0 +1,107 @@
+//go:build windows
+
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32      = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead  = advapi32.NewProc("CredReadW")
+	procCredWrite = advapi32.NewProc("CredWriteW")
+	procCredDel   = advapi32.NewProc("CredDeleteW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+// credential mirrors CREDENTIALW
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// targetName is the Credential Manager entry for service and account
+func targetName(service, account string) string {
+	return service + ":" + account
+}
+
+func set(service, account, secret string) error {
+	target, err := syscall.UTF16PtrFromString(targetName(service, account))
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		UserName:           user,
+		CredentialBlobSize: uint32(len(secret)),
+		Persist:            credPersistLocalMachine,
+	}
+	if len(secret) > 0 {
+		blob := []byte(secret)
+		cred.CredentialBlob = &blob[0]
+	}
+	return call(procCredWrite, uintptr(unsafe.Pointer(&cred)), 0)
+}
+
+func get(service, account string) (string, error) {
+	target, err := syscall.UTF16PtrFromString(targetName(service, account))
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	if err := call(procCredRead, uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); err != nil {
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func del(service, account string) error {
+	target, err := syscall.UTF16PtrFromString(targetName(service, account))
+	if err != nil {
+		return err
+	}
+	return call(procCredDel, uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+}
+
+// call invokes a Cred* function, mapping its failures to the package errors
+func call(proc *syscall.LazyProc, args ...uintptr) error {
+	if err := proc.Find(); err != nil {
+		return ErrUnavailable
+	}
+	ret, _, err := proc.Call(args...)
+	if ret != 0 {
+		return nil
+	}
+	if errors.Is(err, errorNotFound) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("credential manager: %w", err)
+}
@@ -2,6 +2,8 @@ package mcp
 
 import (
 	"bufio"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +11,8 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+
+	"github.com/claraverse/mcp-client/internal/bridge"
 )
 
 // JSONRPCRequest represents a JSON-RPC 2.0 request
@@ -19,6 +23,13 @@ type JSONRPCRequest struct {
 	Params  map[string]interface{} `json:"params,omitempty"`
 }
 
+// JSONRPCNotification represents a JSON-RPC 2.0 notification (a request without an ID)
+type JSONRPCNotification struct {
+	JSONRPC string                 `json:"jsonrpc"`
+	Method  string                 `json:"method"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
 // JSONRPCResponse represents a JSON-RPC 2.0 response
 type JSONRPCResponse struct {
 	JSONRPC string                 `json:"jsonrpc"`
@@ -39,6 +50,38 @@ type Tool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	// OutputSchema is the JSON Schema of the tool's structured result, if declared
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+	Meta         map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// ToolAnnotations are the optional behaviour hints an MCP server reports for a tool
+type ToolAnnotations struct {
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty"`
+}
+
+// IsReadOnly reports whether the server declared the tool as read-only
+func (t Tool) IsReadOnly() bool {
+	return t.Annotations != nil && t.Annotations.ReadOnlyHint != nil && *t.Annotations.ReadOnlyHint
+}
+
+// Examples returns the usage examples a server lists under the tool's "_meta.examples",
+// keeping only entries that are objects with an "arguments" object
+func (t Tool) Examples() []interface{} {
+	raw, _ := t.Meta["examples"].([]interface{})
+	var examples []interface{}
+	for _, entry := range raw {
+		example, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := example["arguments"].(map[string]interface{}); !ok {
+			continue
+		}
+		examples = append(examples, example)
+	}
+	return examples
 }
 
 // Executor manages communication with an MCP server
@@ -51,8 +94,17 @@ type Executor struct {
 	reader     *bufio.Reader
 	writer     *bufio.Writer
 	requestID  int
-	mutex      sync.Mutex
 	verbose    bool
+
+	// slot admits one request at a time; unlike a mutex, waiting for it can be cancelled
+	slot chan struct{}
+
+	// Responses are read by readResponses and handed to the request waiting for their ID
+	pending   map[int]chan *JSONRPCResponse
+	progress  map[int]chan<- Progress // tool calls that asked for progress, by request ID
+	pendingMu sync.Mutex
+	done      chan struct{} // closed when stdout can no longer be read
+	readErr   error
 }
 
 // NewExecutor creates a new MCP executor for a stdio server (path-based)
@@ -108,10 +160,15 @@ func NewExecutorWithCommand(name, command string, args []string, verbose bool) (
 		writer:     bufio.NewWriter(stdin),
 		requestID:  0,
 		verbose:    verbose,
+		slot:       make(chan struct{}, 1),
+		pending:    make(map[int]chan *JSONRPCResponse),
+		progress:   make(map[int]chan<- Progress),
+		done:       make(chan struct{}),
 	}
 
-	// Start stderr reader
+	// Start stderr and response readers
 	go executor.readStderr()
+	go executor.readResponses()
 
 	// Initialize the server
 	if err := executor.initialize(); err != nil {
@@ -206,6 +263,17 @@ func (e *Executor) ListTools() ([]Tool, error) {
 		if schema, ok := toolMap["inputSchema"].(map[string]interface{}); ok {
 			tool.InputSchema = schema
 		}
+		if schema, ok := toolMap["outputSchema"].(map[string]interface{}); ok {
+			tool.OutputSchema = schema
+		}
+		if meta, ok := toolMap["_meta"].(map[string]interface{}); ok {
+			tool.Meta = meta
+		}
+		if annotations, ok := toolMap["annotations"].(map[string]interface{}); ok {
+			if readOnly, ok := annotations["readOnlyHint"].(bool); ok {
+				tool.Annotations = &ToolAnnotations{ReadOnlyHint: &readOnly}
+			}
+		}
 
 		tools = append(tools, tool)
 	}
@@ -215,6 +283,14 @@ func (e *Executor) ListTools() ([]Tool, error) {
 
 // CallTool executes a tool on the MCP server
 func (e *Executor) CallTool(toolName string, arguments map[string]interface{}) (string, error) {
+	return e.CallToolContext(context.Background(), toolName, arguments)
+}
+
+// CallToolContext executes a tool on the MCP server. If ctx is cancelled first, the
+// server is sent notifications/cancelled for the request and ctx's error is returned.
+// If ctx carries a progress channel (see WithProgress), the call asks the server for
+// progress notifications and forwards them to it.
+func (e *Executor) CallToolContext(ctx context.Context, toolName string, arguments map[string]interface{}) (string, error) {
 	req := JSONRPCRequest{
 		JSONRPC: "2.0",
 		ID:      e.nextID(),
@@ -225,7 +301,20 @@ func (e *Executor) CallTool(toolName string, arguments map[string]interface{}) (
 		},
 	}
 
-	resp, err := e.sendRequest(req)
+	if ch := progressFromContext(ctx); ch != nil {
+		// The request ID doubles as the progress token
+		req.Params["_meta"] = map[string]interface{}{"progressToken": req.ID}
+		e.pendingMu.Lock()
+		e.progress[req.ID] = ch
+		e.pendingMu.Unlock()
+		defer func() {
+			e.pendingMu.Lock()
+			delete(e.progress, req.ID)
+			e.pendingMu.Unlock()
+		}()
+	}
+
+	resp, err := e.sendRequestContext(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("tools/call failed: %w", err)
 	}
@@ -246,6 +335,12 @@ func (e *Executor) CallTool(toolName string, arguments map[string]interface{}) (
 		return "", fmt.Errorf("invalid content format")
 	}
 
+	// Binary content (images, audio, embedded blobs) is returned using the
+	// bridge's {"__b64__": ...} convention so it survives the string result
+	if encoded, mimeType, isBinary := binaryContent(firstContent); isBinary {
+		return encodeBinaryContent(encoded, mimeType)
+	}
+
 	text, ok := firstContent["text"].(string)
 	if !ok {
 		return "", fmt.Errorf("no text in content")
@@ -254,39 +349,131 @@ func (e *Executor) CallTool(toolName string, arguments map[string]interface{}) (
 	return text, nil
 }
 
+// binaryContent extracts base64 data from image/audio content or blob resources
+func binaryContent(content map[string]interface{}) (string, string, bool) {
+	switch content["type"] {
+	case "image", "audio":
+		data, ok := content["data"].(string)
+		mimeType, _ := content["mimeType"].(string)
+		return data, mimeType, ok
+	case "resource":
+		resource, ok := content["resource"].(map[string]interface{})
+		if !ok {
+			return "", "", false
+		}
+		blob, ok := resource["blob"].(string)
+		mimeType, _ := resource["mimeType"].(string)
+		return blob, mimeType, ok
+	}
+	return "", "", false
+}
+
+// encodeBinaryContent wraps already base64-encoded content, enforcing the size limit
+func encodeBinaryContent(encoded, mimeType string) (string, error) {
+	if base64.StdEncoding.DecodedLen(len(encoded)) > bridge.MaxBinarySize+2 {
+		return "", fmt.Errorf("binary tool result exceeds limit of %d bytes", bridge.MaxBinarySize)
+	}
+	wrapped := map[string]interface{}{bridge.BinaryKey: encoded}
+	if mimeType != "" {
+		wrapped[bridge.BinaryMimeKey] = mimeType
+	}
+	data, err := json.Marshal(wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode binary result: %w", err)
+	}
+	return string(data), nil
+}
+
 // sendRequest sends a JSON-RPC request and waits for response
 func (e *Executor) sendRequest(req JSONRPCRequest) (*JSONRPCResponse, error) {
-	e.mutex.Lock()
-	defer e.mutex.Unlock()
+	return e.sendRequestContext(context.Background(), req)
+}
 
-	// Marshal request
-	data, err := json.Marshal(req)
+// sendRequestContext sends a JSON-RPC request and waits for its response or for ctx
+// to be cancelled. A cancelled request is reported to the server with
+// notifications/cancelled and its response, if one still comes, is dropped.
+func (e *Executor) sendRequestContext(ctx context.Context, req JSONRPCRequest) (*JSONRPCResponse, error) {
+	select {
+	case e.slot <- struct{}{}:
+		defer func() { <-e.slot }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	respChan := make(chan *JSONRPCResponse, 1)
+	e.pendingMu.Lock()
+	e.pending[req.ID] = respChan
+	e.pendingMu.Unlock()
+
+	if err := e.write(req); err != nil {
+		e.forget(req.ID)
+		return nil, err
+	}
+
+	select {
+	case resp := <-respChan:
+		return resp, nil
+	case <-e.done:
+		e.forget(req.ID)
+		return nil, fmt.Errorf("failed to read response: %w", e.readErr)
+	case <-ctx.Done():
+		e.forget(req.ID)
+		notification := JSONRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/cancelled",
+			Params: map[string]interface{}{
+				"requestId": req.ID,
+				"reason":    "cancelled by the backend",
+			},
+		}
+		if err := e.write(notification); err != nil && e.verbose {
+			log.Printf("[MCP] Failed to send cancellation for request %d: %v", req.ID, err)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// write sends one JSON-RPC message; callers must hold the request slot
+func (e *Executor) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	if e.verbose {
 		log.Printf("[MCP →] %s", string(data))
 	}
 
-	// Write request
 	if _, err := e.writer.Write(data); err != nil {
-		return nil, fmt.Errorf("failed to write request: %w", err)
+		return fmt.Errorf("failed to write request: %w", err)
 	}
 	if _, err := e.writer.WriteString("\n"); err != nil {
-		return nil, fmt.Errorf("failed to write newline: %w", err)
+		return fmt.Errorf("failed to write newline: %w", err)
 	}
 	if err := e.writer.Flush(); err != nil {
-		return nil, fmt.Errorf("failed to flush: %w", err)
+		return fmt.Errorf("failed to flush: %w", err)
 	}
+	return nil
+}
+
+// forget stops waiting for the response to request id
+func (e *Executor) forget(id int) {
+	e.pendingMu.Lock()
+	delete(e.pending, id)
+	e.pendingMu.Unlock()
+}
 
-	// Read response - skip non-JSON lines (logs, etc.)
-	var resp JSONRPCResponse
-	maxAttempts := 100 // Prevent infinite loop
-	for attempt := 0; attempt < maxAttempts; attempt++ {
+// readResponses reads stdout until it closes, handing each JSON-RPC response to the
+// request waiting for it. Progress notifications go to the call that asked for them;
+// non-JSON lines (logs, etc.), other notifications and responses to cancelled requests
+// are skipped.
+func (e *Executor) readResponses() {
+	defer close(e.done)
+	for {
 		line, err := e.reader.ReadString('\n')
 		if err != nil {
-			return nil, fmt.Errorf("failed to read response: %w", err)
+			e.readErr = err
+			return
 		}
 
 		line = strings.TrimSpace(line)
@@ -298,7 +485,7 @@ func (e *Executor) sendRequest(req JSONRPCRequest) (*JSONRPCResponse, error) {
 			log.Printf("[MCP ←] %s", line)
 		}
 
-		// Try to parse as JSON-RPC response
+		var resp JSONRPCResponse
 		if err := json.Unmarshal([]byte(line), &resp); err != nil {
 			// Not valid JSON-RPC, might be a log line - skip it
 			if e.verbose {
@@ -307,15 +494,33 @@ func (e *Executor) sendRequest(req JSONRPCRequest) (*JSONRPCResponse, error) {
 			continue
 		}
 
-		// Successfully parsed JSON-RPC response
-		return &resp, nil
-	}
+		// Notifications have no ID; request IDs start at 1
+		if resp.ID == 0 {
+			var notification JSONRPCNotification
+			if json.Unmarshal([]byte(line), &notification) == nil && notification.Method == "notifications/progress" {
+				e.handleProgress(notification.Params)
+			}
+			continue
+		}
 
-	return nil, fmt.Errorf("no valid JSON-RPC response found after %d lines", maxAttempts)
+		e.pendingMu.Lock()
+		respChan, ok := e.pending[resp.ID]
+		delete(e.pending, resp.ID)
+		e.pendingMu.Unlock()
+		if !ok {
+			if e.verbose {
+				log.Printf("[MCP] Skipping message for no waiting request (id %d)", resp.ID)
+			}
+			continue
+		}
+		respChan <- &resp
+	}
 }
 
 // nextID returns the next request ID
 func (e *Executor) nextID() int {
+	e.pendingMu.Lock()
+	defer e.pendingMu.Unlock()
 	e.requestID++
 	return e.requestID
 }
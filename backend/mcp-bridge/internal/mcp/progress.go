@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"log"
+)
+
+// Progress is a progress update a server sent for a running tool call
+type Progress struct {
+	Message string
+	Percent float64 // 0-100, negative when the server gave no total
+}
+
+// progressKey is the context key for a tool call's progress channel
+type progressKey struct{}
+
+// WithProgress returns a context whose tool calls ask the server for progress and
+// send the server's notifications/progress updates to ch. Updates are dropped rather
+// than blocking when ch is full.
+func WithProgress(ctx context.Context, ch chan<- Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, ch)
+}
+
+func progressFromContext(ctx context.Context) chan<- Progress {
+	ch, _ := ctx.Value(progressKey{}).(chan<- Progress)
+	return ch
+}
+
+// handleProgress passes a notifications/progress message to the call whose
+// progress token (its request ID) it carries
+func (e *Executor) handleProgress(params map[string]interface{}) {
+	token, ok := params["progressToken"].(float64)
+	if !ok {
+		return
+	}
+	e.pendingMu.Lock()
+	ch, ok := e.progress[int(token)]
+	e.pendingMu.Unlock()
+	if !ok {
+		return
+	}
+
+	update := Progress{Percent: -1}
+	update.Message, _ = params["message"].(string)
+	if done, ok := params["progress"].(float64); ok {
+		if total, ok := params["total"].(float64); ok && total > 0 {
+			update.Percent = min(done/total*100, 100)
+		}
+	}
+
+	select {
+	case ch <- update:
+	default:
+		if e.verbose {
+			log.Printf("[MCP] Dropped progress update for request %d", int(token))
+		}
+	}
+}
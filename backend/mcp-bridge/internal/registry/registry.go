@@ -1,14 +1,20 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 
 	"github.com/claraverse/mcp-client/internal/config"
 	"github.com/claraverse/mcp-client/internal/mcp"
 )
 
+// ErrToolNotFound is returned for calls to a tool no running server provides
+var ErrToolNotFound = errors.New("tool not found in any running server")
+
 // ServerInstance represents a running MCP server
 type ServerInstance struct {
 	Config   config.MCPServer
@@ -16,9 +22,14 @@ type ServerInstance struct {
 	Tools    []mcp.Tool
 }
 
+// ResultHook post-processes tool output (results, streamed partial results, progress
+// messages and error text) before it leaves this machine
+type ResultHook func(result string) string
+
 // Registry manages all MCP server instances
 type Registry struct {
 	servers map[string]*ServerInstance
+	hooks   map[string][]ResultHook // by tool name; "*" for every tool
 	mutex   sync.RWMutex
 	verbose bool
 }
@@ -27,10 +38,42 @@ type Registry struct {
 func NewRegistry(verbose bool) *Registry {
 	return &Registry{
 		servers: make(map[string]*ServerInstance),
+		hooks:   make(map[string][]ResultHook),
 		verbose: verbose,
 	}
 }
 
+// AddResultHook registers a hook for toolName's results ("*" for every tool).
+// Hooks run in the order they were added, a tool's own hooks before the global ones.
+func (r *Registry) AddResultHook(toolName string, hook ResultHook) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.hooks[toolName] = append(r.hooks[toolName], hook)
+}
+
+// ApplyResultHooks runs the hooks registered for toolName on output the tool produced
+// other than its result, e.g. a streamed partial result, a progress message or an
+// error, so nothing it sends skips them
+func (r *Registry) ApplyResultHooks(toolName, output string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.applyResultHooks(toolName, output)
+}
+
+// applyResultHooks runs the hooks registered for toolName on result. Callers hold
+// r.mutex.
+func (r *Registry) applyResultHooks(toolName, result string) string {
+	for _, hook := range r.hooks[toolName] {
+		result = hook(result)
+	}
+	if toolName != "*" {
+		for _, hook := range r.hooks["*"] {
+			result = hook(result)
+		}
+	}
+	return result
+}
+
 // StartServer starts an MCP server
 func (r *Registry) StartServer(cfg config.MCPServer) error {
 	r.mutex.Lock()
@@ -131,23 +174,91 @@ func (r *Registry) GetAllTools() []map[string]interface{} {
 
 	var allTools []map[string]interface{}
 
-	for _, instance := range r.servers {
-		for _, tool := range instance.Tools {
-			// Convert MCP tool to OpenAI format
-			toolDef := map[string]interface{}{
-				"name":        tool.Name,
-				"description": tool.Description,
-				"parameters":  tool.InputSchema,
+	// Sorted by server name, then tool name, so the tool list (and the prompt built
+	// from it) is identical across runs
+	serverNames := make([]string, 0, len(r.servers))
+	for name := range r.servers {
+		serverNames = append(serverNames, name)
+	}
+	sort.Strings(serverNames)
+
+	for _, serverName := range serverNames {
+		allTools = append(allTools, toolDefinitions(r.servers[serverName])...)
+	}
+
+	return allTools
+}
+
+// ServerTools returns the tools of one running server, in the format of GetAllTools
+// (nil if it is not running)
+func (r *Registry) ServerTools(name string) []map[string]interface{} {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	instance, exists := r.servers[name]
+	if !exists {
+		return nil
+	}
+	return toolDefinitions(instance)
+}
+
+// toolDefinitions converts a server's tools to the definitions sent to the backend,
+// sorted by tool name
+func toolDefinitions(instance *ServerInstance) []map[string]interface{} {
+	var tools []map[string]interface{}
+
+	serverTools := append([]mcp.Tool(nil), instance.Tools...)
+	sort.SliceStable(serverTools, func(i, j int) bool {
+		return serverTools[i].Name < serverTools[j].Name
+	})
+	for _, tool := range serverTools {
+		// Convert MCP tool to OpenAI format
+		toolDef := map[string]interface{}{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"parameters":  tool.InputSchema,
+		}
+		if tool.IsReadOnly() {
+			toolDef["read_only"] = true
+			if instance.Config.RetriesTool(tool.Name) {
+				toolDef["retry_on_timeout"] = true
 			}
-			allTools = append(allTools, toolDef)
 		}
+		if instance.Config.StreamsTool(tool.Name) {
+			toolDef["streams_results"] = true
+		}
+		if limit, ok := instance.Config.ConcurrencyLimit(tool.Name); ok {
+			toolDef["max_concurrency"] = limit
+		}
+		if seconds, ok := instance.Config.ToolTimeout(tool.Name); ok {
+			toolDef["timeout"] = seconds
+		}
+		if instance.Config.Category != "" {
+			toolDef["category"] = instance.Config.Category
+		}
+		if len(instance.Config.Tags) > 0 {
+			toolDef["tags"] = instance.Config.Tags
+		}
+		if examples := tool.Examples(); len(examples) > 0 {
+			toolDef["examples"] = examples
+		}
+		if len(tool.OutputSchema) > 0 {
+			toolDef["output_schema"] = tool.OutputSchema
+		}
+		tools = append(tools, toolDef)
 	}
 
-	return allTools
+	return tools
 }
 
 // ExecuteTool executes a tool by finding which server provides it
 func (r *Registry) ExecuteTool(toolName string, arguments map[string]interface{}) (string, error) {
+	return r.ExecuteToolContext(context.Background(), toolName, arguments)
+}
+
+// ExecuteToolContext is ExecuteTool that asks the server to abort the tool when ctx
+// is cancelled. Successful results are passed through the tool's result hooks.
+func (r *Registry) ExecuteToolContext(ctx context.Context, toolName string, arguments map[string]interface{}) (string, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -156,12 +267,38 @@ func (r *Registry) ExecuteTool(toolName string, arguments map[string]interface{}
 		for _, tool := range instance.Tools {
 			if tool.Name == toolName {
 				log.Printf("🔧 Executing %s on server %s", toolName, serverName)
-				return instance.Executor.CallTool(toolName, arguments)
+				result, err := instance.Executor.CallToolContext(ctx, toolName, arguments)
+				if err != nil {
+					return "", err
+				}
+				return r.applyResultHooks(toolName, result), nil
 			}
 		}
 	}
 
-	return "", fmt.Errorf("tool %s not found in any running server", toolName)
+	return "", fmt.Errorf("%w: %s", ErrToolNotFound, toolName)
+}
+
+// ExecuteToolProgress is ExecuteToolContext that sends the progress the server
+// reports to progress while the tool runs. The channel is not closed.
+func (r *Registry) ExecuteToolProgress(ctx context.Context, toolName string, arguments map[string]interface{}, progress chan<- mcp.Progress) (string, error) {
+	return r.ExecuteToolContext(mcp.WithProgress(ctx, progress), toolName, arguments)
+}
+
+// ResultLimit returns the result size override configured for toolName on the
+// server that provides it, if any
+func (r *Registry) ResultLimit(toolName string) (int, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, instance := range r.servers {
+		for _, tool := range instance.Tools {
+			if tool.Name == toolName {
+				return instance.Config.ResultLimit(toolName)
+			}
+		}
+	}
+	return 0, false
 }
 
 // GetServerCount returns the number of running servers
@@ -31,6 +31,10 @@ CREATE TABLE providers (
     default_model VARCHAR(255) COMMENT 'Default model for this provider',
     system_prompt TEXT COMMENT 'Provider-level system prompt',
     favicon VARCHAR(512) COMMENT 'Provider icon URL',
+    auth_style VARCHAR(32) COMMENT 'How the API key is sent: bearer or api-key',
+    custom_headers TEXT COMMENT 'Extra request headers (JSON object)',
+    uses_max_completion_tokens BOOLEAN NULL COMMENT 'Send max_completion_tokens instead of max_tokens (NULL = detect from base URL)',
+    flavor VARCHAR(32) COMMENT 'API dialect: openai, azure-openai, anthropic, ollama, chutes, openai-compatible (NULL = detect from base URL)',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 
@@ -92,6 +96,7 @@ CREATE TABLE model_aliases (
     structured_output_badge VARCHAR(50) COMMENT 'UI badge (e.g., "FASTEST")',
     memory_extractor BOOLEAN DEFAULT FALSE COMMENT 'Can extract memories from conversations',
     memory_selector BOOLEAN DEFAULT FALSE COMMENT 'Can select relevant memories',
+    memory_tags VARCHAR(255) COMMENT 'Comma-separated content tags preferred for memory operations (e.g. code,prose)',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 
@@ -202,6 +207,7 @@ CREATE TABLE mcp_connections (
     is_active BOOLEAN DEFAULT TRUE COMMENT 'Is connection currently active',
     connected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     disconnected_at TIMESTAMP NULL COMMENT 'When connection was closed',
+    revoked_at TIMESTAMP NULL COMMENT 'When the client was revoked; it may not register again',
 
     INDEX idx_user (user_id),
     INDEX idx_active (is_active)
@@ -227,6 +233,8 @@ CREATE TABLE mcp_audit_log (
     user_id VARCHAR(255) NOT NULL COMMENT 'Supabase user ID',
     tool_name VARCHAR(255) NOT NULL COMMENT 'Tool that was executed',
     conversation_id VARCHAR(255) COMMENT 'Associated conversation ID',
+    execution_time_ms INT COMMENT 'Round trip measured by the server',
+    tool_time_ms INT COMMENT 'Tool run time reported by the client',
     success BOOLEAN NOT NULL COMMENT 'Was execution successful',
     error_message TEXT COMMENT 'Error message if failed',
     executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
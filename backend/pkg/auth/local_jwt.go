@@ -114,15 +114,24 @@ func (a *LocalJWTAuth) VerifyAccessToken(tokenString string) (*User, error) {
 
 	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
 		return &User{
-			ID:    claims.UserID,
-			Email: claims.Email,
-			Role:  claims.Role,
+			ID:       claims.UserID,
+			Email:    claims.Email,
+			Role:     claims.Role,
+			IssuedAt: tokenIssuedAt(claims),
 		}, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
 
+// tokenIssuedAt returns the iat claim of claims (zero if absent)
+func tokenIssuedAt(claims *JWTClaims) time.Time {
+	if claims.IssuedAt == nil {
+		return time.Time{}
+	}
+	return claims.IssuedAt.Time
+}
+
 // VerifyRefreshToken verifies a refresh token and returns claims
 func (a *LocalJWTAuth) VerifyRefreshToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -2,23 +2,65 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
+const (
+	// DefaultSupabaseTimeout bounds each request to Supabase
+	DefaultSupabaseTimeout = 10 * time.Second
+	// DefaultSupabaseRetries is the number of retries after a transient network error
+	DefaultSupabaseRetries = 2
+
+	supabaseRetryDelay = 200 * time.Millisecond
+)
+
+// defaultSupabaseClient is shared by SupabaseAuth values not built with NewSupabaseAuth
+var defaultSupabaseClient = &http.Client{Timeout: DefaultSupabaseTimeout}
+
 // SupabaseAuth handles Supabase authentication
 type SupabaseAuth struct {
-	URL string
-	Key string
+	URL        string
+	Key        string
+	MaxRetries int // Retries after transient network errors (0 = none)
+	// JWTSecret is the project's legacy HS256 signing secret. Optional; without it
+	// HS256 tokens are verified by Supabase even in VerifyTokenLocally.
+	JWTSecret string
+
+	client *http.Client
+
+	// Signing keys cached by VerifyTokenLocally
+	jwksMu        sync.Mutex
+	jwksKeys      map[string]interface{}
+	jwksFetchedAt time.Time
 }
 
-// NewSupabaseAuth creates a new Supabase auth instance
-func NewSupabaseAuth(url, key string) *SupabaseAuth {
+// NewSupabaseAuth creates a new Supabase auth instance. A zero timeout uses
+// DefaultSupabaseTimeout and a negative retry count disables retries; zero uses
+// DefaultSupabaseRetries.
+func NewSupabaseAuth(url, key string, timeout time.Duration, retries int) *SupabaseAuth {
+	if timeout == 0 {
+		timeout = DefaultSupabaseTimeout
+	}
+
+	switch {
+	case retries == 0:
+		retries = DefaultSupabaseRetries
+	case retries < 0:
+		retries = 0
+	}
+
 	return &SupabaseAuth{
-		URL: url,
-		Key: key,
+		URL:        url,
+		Key:        key,
+		MaxRetries: retries,
+		client:     &http.Client{Timeout: timeout},
 	}
 }
 
@@ -27,6 +69,8 @@ type User struct {
 	ID    string `json:"id"`
 	Email string `json:"email"`
 	Role  string `json:"role"`
+	// IssuedAt is when the verified token was issued (zero if unknown)
+	IssuedAt time.Time `json:"-"`
 }
 
 // VerifyToken verifies a Supabase JWT token and returns the user
@@ -44,8 +88,7 @@ func (s *SupabaseAuth) VerifyToken(token string) (*User, error) {
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("apikey", s.Key)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify token: %w", err)
 	}
@@ -64,6 +107,43 @@ func (s *SupabaseAuth) VerifyToken(token string) (*User, error) {
 	return &user, nil
 }
 
+// do sends a bodiless request, retrying transient network errors. Timeouts are not
+// retried so a hung Supabase holds the caller for at most one timeout.
+func (s *SupabaseAuth) do(req *http.Request) (*http.Response, error) {
+	client := s.client
+	if client == nil {
+		client = defaultSupabaseClient
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= s.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * supabaseRetryDelay)
+		}
+
+		resp, err := client.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if !isTransientNetworkError(err) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// isTransientNetworkError reports whether err is a network failure worth retrying
+// (e.g. connection refused or reset), excluding timeouts
+func isTransientNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
 // ExtractToken extracts the bearer token from Authorization header
 func ExtractToken(authHeader string) (string, error) {
 	if authHeader == "" {
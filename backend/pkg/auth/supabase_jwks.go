@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// supabaseJWKSPath is where Supabase publishes the keys signing its access tokens
+	supabaseJWKSPath = "/auth/v1/.well-known/jwks.json"
+	// jwksCacheTTL is how long fetched signing keys are trusted before refetching
+	jwksCacheTTL = 10 * time.Minute
+	// jwksMinRefreshInterval limits refetches triggered by unknown key IDs, so tokens
+	// with made-up kids cannot turn into a request per connection
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// errNoLocalKey means the token cannot be checked locally and must be verified by Supabase
+var errNoLocalKey = errors.New("no local key for token")
+
+// VerifyTokenLocally verifies a Supabase JWT against the project's signing keys
+// without calling Supabase for every token. Asymmetric tokens are checked against
+// the cached JWKS and legacy HS256 tokens against JWTSecret. Tokens that cannot be
+// checked locally (no secret, unknown key after a refresh) fall back to VerifyToken;
+// expired or badly signed tokens are rejected outright.
+func (s *SupabaseAuth) VerifyTokenLocally(token string) (*User, error) {
+	if s.URL == "" || s.Key == "" {
+		return nil, fmt.Errorf("supabase not configured")
+	}
+
+	parsed, err := jwt.ParseWithClaims(token, &JWTClaims{}, s.supabaseKeyFunc,
+		jwt.WithValidMethods([]string{"RS256", "ES256", "HS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(strings.TrimSuffix(s.URL, "/")+"/auth/v1"),
+	)
+	if errors.Is(err, errNoLocalKey) {
+		user, err := s.VerifyToken(token)
+		if err != nil {
+			return nil, err
+		}
+		// Supabase vouched for the token, so its unverified claims can be read
+		var claims JWTClaims
+		if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err == nil {
+			user.IssuedAt = tokenIssuedAt(&claims)
+		}
+		return user, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token verification failed: %w", err)
+	}
+
+	claims, ok := parsed.Claims.(*JWTClaims)
+	// Anon and service keys are valid JWTs too, but carry no user
+	if !ok || !parsed.Valid || claims.UserID == "" {
+		return nil, errors.New("token verification failed: token has no user")
+	}
+
+	return &User{
+		ID:       claims.UserID,
+		Email:    claims.Email,
+		Role:     claims.Role,
+		IssuedAt: tokenIssuedAt(claims),
+	}, nil
+}
+
+// supabaseKeyFunc returns the key verifying token
+func (s *SupabaseAuth) supabaseKeyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if s.JWTSecret == "" {
+			return nil, errNoLocalKey
+		}
+		return []byte(s.JWTSecret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errNoLocalKey
+	}
+	return s.signingKey(kid)
+}
+
+// signingKey returns the cached JWKS key with the given ID, refetching the key set
+// when it is stale or does not contain the key (e.g. after a key rotation)
+func (s *SupabaseAuth) signingKey(kid string) (interface{}, error) {
+	s.jwksMu.Lock()
+	defer s.jwksMu.Unlock()
+
+	stale := time.Since(s.jwksFetchedAt) > jwksCacheTTL
+	key, known := s.jwksKeys[kid]
+	if known && !stale {
+		return key, nil
+	}
+	if stale || time.Since(s.jwksFetchedAt) > jwksMinRefreshInterval {
+		keys, err := s.fetchJWKS()
+		if err != nil {
+			// Supabase stays authoritative: verify remotely rather than fail
+			return nil, fmt.Errorf("%w: %v", errNoLocalKey, err)
+		}
+		s.jwksKeys = keys
+		s.jwksFetchedAt = time.Now()
+		key, known = keys[kid]
+	}
+	if !known {
+		return nil, errNoLocalKey
+	}
+	return key, nil
+}
+
+// jsonWebKey is one entry of a JWKS document
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchJWKS downloads the project's public signing keys, indexed by key ID
+func (s *SupabaseAuth) fetchJWKS() (map[string]interface{}, error) {
+	req, err := http.NewRequest("GET", strings.TrimSuffix(s.URL, "/")+supabaseJWKSPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("apikey", s.Key)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		key, err := jwk.publicKey()
+		if err != nil || jwk.Kid == "" {
+			continue // Skip key types we cannot verify with; such tokens go to Supabase
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// publicKey converts an RSA or P-256 EC JWK into a crypto public key
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newSupabaseTestServer serves a JWKS with one ES256 key and a /user endpoint
+// that accepts any token, counting calls to each
+func newSupabaseTestServer(t *testing.T, key *ecdsa.PrivateKey) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var jwksCalls, userCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case supabaseJWKSPath:
+			jwksCalls.Add(1)
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kid": "key-1", "kty": "EC", "crv": "P-256",
+				"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+				"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			}}})
+		case "/auth/v1/user":
+			userCalls.Add(1)
+			w.Write([]byte(`{"id":"remote-user","email":"remote@b.c","role":"authenticated"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &jwksCalls, &userCalls
+}
+
+func signSupabaseToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid, issuer string, expiresIn time.Duration) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, JWTClaims{
+		UserID: "user-1",
+		Email:  "a@b.c",
+		Role:   "authenticated",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyTokenLocally(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, jwksCalls, userCalls := newSupabaseTestServer(t, key)
+	s := NewSupabaseAuth(srv.URL, "anon-key", time.Second, -1)
+	issuer := srv.URL + "/auth/v1"
+
+	valid := signSupabaseToken(t, jwt.SigningMethodES256, key, "key-1", issuer, time.Hour)
+	for i := 0; i < 3; i++ {
+		user, err := s.VerifyTokenLocally(valid)
+		if err != nil {
+			t.Fatalf("expected valid token to verify, got %v", err)
+		}
+		if user.IssuedAt.IsZero() {
+			t.Error("expected the token's issue time")
+		}
+		user.IssuedAt = time.Time{}
+		if *user != (User{ID: "user-1", Email: "a@b.c", Role: "authenticated"}) {
+			t.Errorf("unexpected user %+v", user)
+		}
+	}
+	if jwksCalls.Load() != 1 || userCalls.Load() != 0 {
+		t.Errorf("expected one JWKS fetch and no user lookups, got %d and %d", jwksCalls.Load(), userCalls.Load())
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rejected := map[string]string{
+		"expired":      signSupabaseToken(t, jwt.SigningMethodES256, key, "key-1", issuer, -time.Minute),
+		"wrong key":    signSupabaseToken(t, jwt.SigningMethodES256, otherKey, "key-1", issuer, time.Hour),
+		"wrong issuer": signSupabaseToken(t, jwt.SigningMethodES256, key, "key-1", "https://other.supabase.co/auth/v1", time.Hour),
+	}
+	for name, token := range rejected {
+		if _, err := s.VerifyTokenLocally(token); err == nil {
+			t.Errorf("%s: expected token to be rejected", name)
+		}
+	}
+	if userCalls.Load() != 0 {
+		t.Errorf("rejected tokens must not fall back to Supabase, got %d user lookups", userCalls.Load())
+	}
+
+	// HS256 tokens without a configured secret are verified by Supabase
+	hs := signSupabaseToken(t, jwt.SigningMethodHS256, []byte("secret"), "", issuer, time.Hour)
+	if user, err := s.VerifyTokenLocally(hs); err != nil || user.ID != "remote-user" || user.IssuedAt.IsZero() {
+		t.Fatalf("expected remote fallback with the token's issue time, got %+v, %v", user, err)
+	}
+
+	s.JWTSecret = "secret"
+	if user, err := s.VerifyTokenLocally(hs); err != nil || user.ID != "user-1" {
+		t.Fatalf("expected HS256 token to verify locally, got %+v, %v", user, err)
+	}
+	if userCalls.Load() != 1 {
+		t.Errorf("expected exactly one remote lookup, got %d", userCalls.Load())
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerifyTokenTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := NewSupabaseAuth(srv.URL, "key", 50*time.Millisecond, 0)
+	start := time.Now()
+	if _, err := s.VerifyToken("token"); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	// Timeouts are not retried
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected verification to give up after one timeout, took %v", elapsed)
+	}
+}
+
+func TestVerifyTokenRetriesConnectionErrors(t *testing.T) {
+	// Reserve a port with nothing listening so connections are refused
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := NewSupabaseAuth("http://"+addr, "key", time.Second, 1)
+	go func() {
+		// Come up before the retry
+		time.Sleep(50 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"id":"user-1","email":"a@b.c"}`))
+		}))
+	}()
+
+	user, err := s.VerifyToken("token")
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if user.ID != "user-1" {
+		t.Errorf("unexpected user %+v", user)
+	}
+}
+
+func TestNewSupabaseAuthDefaults(t *testing.T) {
+	s := NewSupabaseAuth("http://example.com", "key", 0, 0)
+	if s.client.Timeout != DefaultSupabaseTimeout || s.MaxRetries != DefaultSupabaseRetries {
+		t.Errorf("unexpected defaults: timeout=%v retries=%d", s.client.Timeout, s.MaxRetries)
+	}
+	if NewSupabaseAuth("http://example.com", "key", 0, -1).MaxRetries != 0 {
+		t.Error("expected negative retries to disable retries")
+	}
+}
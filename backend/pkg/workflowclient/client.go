@@ -0,0 +1,363 @@
+// Package workflowclient is a Go client for the /ws/workflow WebSocket protocol.
+//
+// It wraps the execute_workflow / execution_update / execution_complete message
+// exchange so integrations can run an agent's workflow with a single call:
+//
+//	client := workflowclient.NewClient("wss://api.example.com/ws/workflow", token)
+//	exec, err := client.Execute(ctx, agentID, input, nil)
+//	if err != nil {
+//		return err
+//	}
+//	for update := range exec.Updates() {
+//		log.Printf("block %s: %s", update.BlockID, update.Status)
+//	}
+//	result, err := exec.Wait()
+package workflowclient
+
+import (
+	"claraverse/internal/models"
+	"claraverse/pkg/workflowproto"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+// ClientMessage is a message sent to the server (shared with the server handler)
+type ClientMessage = workflowproto.ClientMessage
+
+// ServerMessage is a message received from the server (shared with the server handler)
+type ServerMessage = workflowproto.ServerMessage
+
+// Message types of the workflow WebSocket protocol
+const (
+	MessageExecuteWorkflow   = workflowproto.MessageExecuteWorkflow
+	MessageCancelExecution   = workflowproto.MessageCancelExecution
+	MessageConnected         = workflowproto.MessageConnected
+	MessageExecutionStarted  = workflowproto.MessageExecutionStarted
+	MessageExecutionUpdate   = workflowproto.MessageExecutionUpdate
+	MessageExecutionComplete = workflowproto.MessageExecutionComplete
+	MessageServerShutdown    = workflowproto.MessageServerShutdown
+	MessageError             = workflowproto.MessageError
+)
+
+var (
+	// ErrUnauthorized is returned when the server rejects the auth token
+	ErrUnauthorized = errors.New("workflow websocket: unauthorized")
+
+	// ErrConnectionLost is returned by Wait when the socket closes after the
+	// execution started. The server stops executions whose connection drops, so
+	// the outcome can be looked up via GET /api/executions/:id using Execution.ID.
+	ErrConnectionLost = errors.New("workflow websocket: connection lost during execution")
+)
+
+// ServerError is an "error" message sent in reply to an execute request
+// (agent not found, daily limit exceeded, server busy, server shutting down, ...)
+type ServerError struct {
+	Message string
+	// RetryAfter is the delay the server suggested before retrying (server busy)
+	RetryAfter time.Duration
+}
+
+func (e *ServerError) Error() string {
+	return "workflow websocket: " + e.Message
+}
+
+// Temporary reports whether retrying on a new connection may succeed
+func (e *ServerError) Temporary() bool {
+	return e.RetryAfter > 0 || strings.Contains(e.Message, "shutting down")
+}
+
+// Client runs agent workflows over the workflow WebSocket endpoint.
+// A Client is safe for concurrent use; each Execute call uses its own connection.
+type Client struct {
+	// URL of the endpoint, e.g. ws://localhost:3001/ws/workflow
+	URL string
+	// Token is sent as a Bearer token in the Authorization header
+	Token string
+	// Dialer used to open connections (defaults to websocket.DefaultDialer)
+	Dialer *websocket.Dialer
+	// MaxReconnects is how many times a connection is re-established before the
+	// execution has started (dial failures, dropped sockets, server busy or shutting down)
+	MaxReconnects int
+	// ReconnectDelay is the initial backoff between reconnects, doubled each attempt
+	ReconnectDelay time.Duration
+}
+
+// NewClient creates a client for the given endpoint URL and auth token
+func NewClient(url, token string) *Client {
+	return &Client{
+		URL:            url,
+		Token:          token,
+		Dialer:         websocket.DefaultDialer,
+		MaxReconnects:  3,
+		ReconnectDelay: time.Second,
+	}
+}
+
+// ExecuteOptions are optional per-execution settings. Unset (nil or empty) settings
+// use the agent's execution defaults, then the server's.
+type ExecuteOptions struct {
+	// EnableBlockChecker validates that each block accomplished its job
+	EnableBlockChecker *bool
+	// CheckerModelID is the model used for block checking
+	CheckerModelID string
+	// CheckerMaxRetries re-runs blocks that fail the check up to this many times
+	CheckerMaxRetries *int
+	// EnableHistory gives the agent a summary of its recent runs for this user
+	EnableHistory *bool
+	// HistoryWindow is how many prior runs to summarize
+	HistoryWindow *int
+}
+
+// Update is a block status change reported while the workflow runs
+type Update struct {
+	BlockID string
+	Status  string
+	Inputs  map[string]any
+	Output  map[string]any
+	Error   string
+}
+
+// Result is the final outcome of an execution
+type Result struct {
+	ExecutionID string
+	// Status is the workflow status: completed, failed, partial, cancelled, interrupted
+	Status   string
+	Output   map[string]any
+	Duration time.Duration
+	Error    string
+	// APIResponse is the standardized response (nil for cancelled/interrupted/failed runs)
+	APIResponse *models.ExecutionAPIResponse
+}
+
+// Execution is a workflow execution running on the server
+type Execution struct {
+	// ID is the server-assigned execution ID
+	ID string
+
+	updates chan Update
+	done    chan struct{}
+	result  *Result
+	err     error
+}
+
+// updateBuffer is how many unread updates are buffered before the read loop waits
+// for the consumer
+const updateBuffer = 100
+
+// Execute starts the agent's workflow and returns once the server has accepted it.
+// Connection failures before that point are retried with backoff (honouring the
+// server's suggested delay when it is busy); other errors the server reports (e.g.
+// agent not found) are returned as *ServerError. Cancelling ctx while
+// the execution runs asks the server to cancel it.
+func (c *Client) Execute(ctx context.Context, agentID string, input map[string]any, opts *ExecuteOptions) (*Execution, error) {
+	msg := ClientMessage{
+		Type:    MessageExecuteWorkflow,
+		AgentID: agentID,
+		Input:   input,
+	}
+	if opts != nil {
+		msg.EnableBlockChecker = opts.EnableBlockChecker
+		msg.CheckerModelID = opts.CheckerModelID
+		msg.CheckerMaxRetries = opts.CheckerMaxRetries
+		msg.EnableHistory = opts.EnableHistory
+		msg.HistoryWindow = opts.HistoryWindow
+	}
+
+	var lastErr error
+	delay := c.ReconnectDelay
+	for attempt := 0; attempt <= c.MaxReconnects; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		conn, err := c.dial(ctx)
+		if err != nil {
+			if errors.Is(err, ErrUnauthorized) || ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+
+		execID, err := start(ctx, conn, msg)
+		if err == nil {
+			exec := &Execution{
+				ID:      execID,
+				updates: make(chan Update, updateBuffer),
+				done:    make(chan struct{}),
+			}
+			go exec.run(ctx, conn)
+			return exec, nil
+		}
+		conn.Close()
+
+		var serverErr *ServerError
+		if errors.As(err, &serverErr) {
+			if !serverErr.Temporary() {
+				return nil, err
+			}
+			if serverErr.RetryAfter > delay {
+				delay = serverErr.RetryAfter
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("failed to start execution after %d attempts: %w", c.MaxReconnects+1, lastErr)
+}
+
+// dial opens an authenticated connection
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, c.URL, header)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return nil, ErrUnauthorized
+		}
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return conn, nil
+}
+
+// start performs the handshake and execute request, returning the execution ID
+func start(ctx context.Context, conn *websocket.Conn, msg ClientMessage) (string, error) {
+	// Unblock reads if ctx is cancelled mid-handshake
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var serverMsg ServerMessage
+	if err := conn.ReadJSON(&serverMsg); err != nil {
+		return "", fmt.Errorf("failed to read connected message: %w", err)
+	}
+	if serverMsg.Type != MessageConnected {
+		return "", fmt.Errorf("unexpected first message %q", serverMsg.Type)
+	}
+
+	if err := conn.WriteJSON(msg); err != nil {
+		return "", fmt.Errorf("failed to send execute request: %w", err)
+	}
+
+	for {
+		serverMsg = ServerMessage{}
+		if err := conn.ReadJSON(&serverMsg); err != nil {
+			return "", fmt.Errorf("connection closed before execution started: %w", err)
+		}
+
+		switch serverMsg.Type {
+		case MessageExecutionStarted:
+			return serverMsg.ExecutionID, nil
+		case MessageError:
+			return "", &ServerError{
+				Message:    serverMsg.Error,
+				RetryAfter: time.Duration(serverMsg.RetryAfter) * time.Second,
+			}
+		case MessageServerShutdown:
+			return "", &ServerError{Message: "Server is shutting down"}
+		}
+	}
+}
+
+// run reads messages until the execution completes or the connection ends
+func (e *Execution) run(ctx context.Context, conn *websocket.Conn) {
+	var closeOnce sync.Once
+	closeConn := func() { closeOnce.Do(func() { conn.Close() }) }
+	defer closeConn()
+	defer close(e.done)
+	defer close(e.updates)
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.WriteJSON(ClientMessage{Type: MessageCancelExecution})
+		closeConn()
+	})
+	defer stop()
+
+	for {
+		var msg ServerMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() != nil {
+				e.err = ctx.Err()
+			} else {
+				e.err = fmt.Errorf("%w (execution %s): %v", ErrConnectionLost, e.ID, err)
+			}
+			return
+		}
+
+		if msg.ExecutionID != "" && msg.ExecutionID != e.ID {
+			continue
+		}
+
+		switch msg.Type {
+		case MessageExecutionUpdate:
+			update := Update{
+				BlockID: msg.BlockID,
+				Status:  msg.Status,
+				Inputs:  msg.Inputs,
+				Output:  msg.Output,
+				Error:   msg.Error,
+			}
+			// Wait for a slow consumer rather than drop updates; cancelling ctx
+			// unblocks the send
+			select {
+			case e.updates <- update:
+			case <-ctx.Done():
+				e.err = ctx.Err()
+				return
+			}
+		case MessageExecutionComplete:
+			e.result = &Result{
+				ExecutionID: e.ID,
+				Status:      msg.Status,
+				Output:      msg.FinalOutput,
+				Duration:    time.Duration(msg.Duration) * time.Millisecond,
+				Error:       msg.Error,
+				APIResponse: msg.APIResponse,
+			}
+			return
+		}
+	}
+}
+
+// Updates returns block updates as they arrive. The channel is closed when the
+// execution ends. No update is dropped: once 100 are left unread the execution stops
+// reading from the server until they are, so callers must drain the channel (or
+// cancel ctx) while waiting for the result.
+func (e *Execution) Updates() <-chan Update {
+	return e.updates
+}
+
+// Done is closed when the execution has ended
+func (e *Execution) Done() <-chan struct{} {
+	return e.done
+}
+
+// Wait blocks until the execution ends and returns its result (read Updates meanwhile). A non-nil error means
+// the outcome is unknown (connection lost or ctx cancelled); a workflow that ran and
+// failed is reported through Result.Status and Result.Error instead.
+func (e *Execution) Wait() (*Result, error) {
+	<-e.done
+	return e.result, e.err
+}
@@ -0,0 +1,175 @@
+package workflowclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+// newTestServer serves the workflow protocol, calling handle once per connection
+func newTestServer(t *testing.T, handle func(conn *websocket.Conn, attempt int)) *httptest.Server {
+	t.Helper()
+	var attempts atomic.Int32
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(ServerMessage{Type: MessageConnected})
+		handle(conn, int(attempts.Add(1)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(srv *httptest.Server, token string) *Client {
+	client := NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), token)
+	client.ReconnectDelay = time.Millisecond
+	return client
+}
+
+func TestExecuteStreamsUpdatesAndResult(t *testing.T) {
+	srv := newTestServer(t, func(conn *websocket.Conn, _ int) {
+		var msg ClientMessage
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != MessageExecuteWorkflow || msg.AgentID != "agent-1" {
+			t.Errorf("unexpected request %+v (err %v)", msg, err)
+			return
+		}
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionStarted, ExecutionID: "exec-1"})
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionUpdate, ExecutionID: "exec-1", BlockID: "b1", Status: "running"})
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionUpdate, ExecutionID: "exec-1", BlockID: "b1", Status: "completed"})
+		conn.WriteJSON(ServerMessage{
+			Type:        MessageExecutionComplete,
+			ExecutionID: "exec-1",
+			Status:      "completed",
+			FinalOutput: map[string]any{"answer": "42"},
+			Duration:    1500,
+		})
+	})
+
+	exec, err := newTestClient(srv, "secret").Execute(context.Background(), "agent-1", map[string]any{"q": "?"}, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if exec.ID != "exec-1" {
+		t.Errorf("expected execution ID exec-1, got %q", exec.ID)
+	}
+
+	var statuses []string
+	for update := range exec.Updates() {
+		statuses = append(statuses, update.BlockID+":"+update.Status)
+	}
+	if strings.Join(statuses, ",") != "b1:running,b1:completed" {
+		t.Errorf("unexpected updates %v", statuses)
+	}
+
+	result, err := exec.Wait()
+	if err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if result.Status != "completed" || result.Output["answer"] != "42" || result.Duration != 1500*time.Millisecond {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestExecuteReconnectsWhenServerShutsDown(t *testing.T) {
+	srv := newTestServer(t, func(conn *websocket.Conn, attempt int) {
+		var msg ClientMessage
+		conn.ReadJSON(&msg)
+		if attempt == 1 {
+			conn.WriteJSON(ServerMessage{Type: MessageError, Error: "Server is shutting down. Please retry in a moment."})
+			return
+		}
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionStarted, ExecutionID: "exec-2"})
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionComplete, ExecutionID: "exec-2", Status: "completed"})
+	})
+
+	exec, err := newTestClient(srv, "secret").Execute(context.Background(), "agent-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result, err := exec.Wait(); err != nil || result.Status != "completed" {
+		t.Errorf("expected completed result, got %+v (err %v)", result, err)
+	}
+}
+
+func TestExecuteReturnsServerErrors(t *testing.T) {
+	srv := newTestServer(t, func(conn *websocket.Conn, attempt int) {
+		var msg ClientMessage
+		conn.ReadJSON(&msg)
+		conn.WriteJSON(ServerMessage{Type: MessageError, Error: "Agent not found: missing"})
+	})
+	client := newTestClient(srv, "secret")
+
+	_, err := client.Execute(context.Background(), "missing", nil, nil)
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.Message != "Agent not found: missing" {
+		t.Errorf("expected ServerError, got %v", err)
+	}
+
+	client.Token = "wrong"
+	if _, err := client.Execute(context.Background(), "agent-1", nil, nil); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestWaitReportsLostConnection(t *testing.T) {
+	srv := newTestServer(t, func(conn *websocket.Conn, _ int) {
+		var msg ClientMessage
+		conn.ReadJSON(&msg)
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionStarted, ExecutionID: "exec-3"})
+	})
+
+	exec, err := newTestClient(srv, "secret").Execute(context.Background(), "agent-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, err := exec.Wait(); !errors.Is(err, ErrConnectionLost) {
+		t.Errorf("expected ErrConnectionLost, got %v", err)
+	}
+}
+
+func TestUpdatesAreNotDroppedForSlowConsumers(t *testing.T) {
+	const total = updateBuffer * 3
+	srv := newTestServer(t, func(conn *websocket.Conn, _ int) {
+		var msg ClientMessage
+		conn.ReadJSON(&msg)
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionStarted, ExecutionID: "exec-4"})
+		for i := 0; i < total; i++ {
+			conn.WriteJSON(ServerMessage{Type: MessageExecutionUpdate, ExecutionID: "exec-4", BlockID: "b1", Status: "running"})
+		}
+		conn.WriteJSON(ServerMessage{Type: MessageExecutionComplete, ExecutionID: "exec-4", Status: "completed"})
+	})
+
+	exec, err := newTestClient(srv, "secret").Execute(context.Background(), "agent-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// Let the buffer fill up before reading
+	time.Sleep(100 * time.Millisecond)
+	received := 0
+	for range exec.Updates() {
+		received++
+	}
+	if received != total {
+		t.Errorf("expected %d updates, got %d", total, received)
+	}
+	if result, err := exec.Wait(); err != nil || result.Status != "completed" {
+		t.Errorf("expected completed result, got %+v (err %v)", result, err)
+	}
+}
@@ -0,0 +1,52 @@
+// Package workflowproto defines the messages of the /ws/workflow WebSocket protocol,
+// shared by the server handler and the Go client.
+package workflowproto
+
+import "claraverse/internal/models"
+
+// Message types of the workflow WebSocket protocol
+const (
+	MessageExecuteWorkflow   = "execute_workflow"
+	MessageCancelExecution   = "cancel_execution"
+	MessageConnected         = "connected"
+	MessageExecutionStarted  = "execution_started"
+	MessageExecutionUpdate   = "execution_update"
+	MessageBlockProgress     = "block_progress"
+	MessageExecutionComplete = "execution_complete"
+	MessageServerShutdown    = "server_shutdown"
+	MessageError             = "error"
+)
+
+// ClientMessage represents a message from the client
+type ClientMessage struct {
+	Type    string         `json:"type"` // execute_workflow, cancel_execution
+	AgentID string         `json:"agent_id,omitempty"`
+	Input   map[string]any `json:"input,omitempty"`
+
+	// Per-run settings (optional), each overriding the agent's execution defaults:
+	// enable_block_checker validates that each block accomplished its job,
+	// checker_model_id picks the checking model, checker_max_retries re-runs blocks
+	// that fail the check, enable_history gives the agent a summary of its recent
+	// runs for this user and history_window is how many runs (defaults to 5)
+	models.ExecutionDefaults
+}
+
+// ServerMessage represents a message to send to the client
+type ServerMessage struct {
+	Type        string         `json:"type"` // connected, execution_started, execution_update, block_progress, execution_complete, server_shutdown, error
+	ExecutionID string         `json:"execution_id,omitempty"`
+	BlockID     string         `json:"block_id,omitempty"`
+	Status      string         `json:"status,omitempty"`
+	Inputs      map[string]any `json:"inputs,omitempty"`
+	Output      map[string]any `json:"output,omitempty"`
+	FinalOutput map[string]any `json:"final_output,omitempty"`
+	Duration    int64          `json:"duration_ms,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	RetryAfter  int            `json:"retry_after_seconds,omitempty"` // Set on "server busy" errors
+	Message     string         `json:"message,omitempty"`             // block_progress text
+	Progress    *float64       `json:"progress,omitempty"`            // block_progress percentage (0-100)
+
+	// APIResponse is the standardized, clean response for API consumers
+	// This provides a well-structured output with result, artifacts, files, etc.
+	APIResponse *models.ExecutionAPIResponse `json:"api_response,omitempty"`
+}
@@ -233,7 +233,8 @@ class WorkflowExecutionService {
       type: 'execute_workflow',
       agent_id: agentId,
       input,
-      enable_block_checker: debugMode,
+      // Debug mode forces the checker on; otherwise the agent's default applies
+      enable_block_checker: debugMode || undefined,
     };
 
     console.log(